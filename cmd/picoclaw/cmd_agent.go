@@ -53,6 +53,7 @@ func agentCmd() {
 		fmt.Printf("Error loading config: %v\n", err)
 		os.Exit(1)
 	}
+	guardResources(cfg)
 
 	if modelOverride != "" {
 		cfg.Agents.Defaults.Model = modelOverride
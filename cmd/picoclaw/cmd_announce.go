@@ -0,0 +1,110 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/agent"
+	"github.com/sipeed/picoclaw/pkg/announce"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+func announceCmd() {
+	if len(os.Args) < 3 {
+		announceHelp()
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+
+	store := announce.NewStore(agent.DefaultWorkspace(cfg))
+
+	switch os.Args[2] {
+	case "send":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: picoclaw announce send <message>")
+			return
+		}
+		announceSendCmd(store, strings.Join(os.Args[3:], " "))
+	case "list":
+		announceListCmd(store)
+	case "subscribers":
+		announceSubscribersCmd(store)
+	default:
+		fmt.Printf("Unknown announce command: %s\n", os.Args[2])
+		announceHelp()
+	}
+}
+
+func announceHelp() {
+	fmt.Println("\nAnnounce commands:")
+	fmt.Println("  send <message>      Queue a message for all opted-in chats")
+	fmt.Println("  list                Show queued and delivered announcements")
+	fmt.Println("  subscribers         Show chats that opted in")
+	fmt.Println()
+	fmt.Println("Chats opt in by sending /announcements on. A running gateway")
+	fmt.Println("delivers queued announcements within a minute.")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  picoclaw announce send \"Down for maintenance 22:00-23:00 UTC\"")
+}
+
+func announceSendCmd(store *announce.Store, content string) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		fmt.Println("Error: message is empty")
+		return
+	}
+
+	a, err := store.Queue(content)
+	if err != nil {
+		fmt.Printf("Error queueing announcement: %v\n", err)
+		return
+	}
+
+	n := len(store.Subscribers())
+	fmt.Printf("✓ Announcement %s queued for %d subscriber(s)\n", a.ID, n)
+	if n == 0 {
+		fmt.Println("  No chats have opted in yet (they can send /announcements on).")
+	}
+}
+
+func announceListCmd(store *announce.Store) {
+	list := store.List()
+	if len(list) == 0 {
+		fmt.Println("No announcements.")
+		return
+	}
+
+	fmt.Println("\nAnnouncements:")
+	fmt.Println("--------------")
+	for _, a := range list {
+		status := "pending"
+		if a.DeliveredAt != nil {
+			status = fmt.Sprintf("delivered %s to %d chat(s)", a.DeliveredAt.Format("2006-01-02 15:04"), a.Recipients)
+		}
+		fmt.Printf("  %s  %s  [%s]\n", a.CreatedAt.Format("2006-01-02 15:04"), utils.Truncate(a.Content, 50), status)
+	}
+}
+
+func announceSubscribersCmd(store *announce.Store) {
+	subs := store.Subscribers()
+	if len(subs) == 0 {
+		fmt.Println("No chats have opted in.")
+		return
+	}
+
+	fmt.Println("\nSubscribers:")
+	fmt.Println("------------")
+	for _, s := range subs {
+		fmt.Printf("  %s:%s  (since %s)\n", s.Channel, s.ChatID, s.Since.Format("2006-01-02"))
+	}
+}
@@ -0,0 +1,103 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/canary"
+)
+
+func canaryCmd() {
+	if len(os.Args) < 3 {
+		canaryHelp()
+		return
+	}
+
+	switch os.Args[2] {
+	case "report":
+		canaryReportCmd(os.Args[3:])
+	default:
+		fmt.Printf("Unknown canary command: %s\n", os.Args[2])
+		canaryHelp()
+	}
+}
+
+func canaryHelp() {
+	fmt.Println("\nCanary commands:")
+	fmt.Println("  report              Compare the default model with the canary model")
+	fmt.Println()
+	fmt.Println("Report options:")
+	fmt.Println("  --days <n>          Only include turns from the last n days")
+	fmt.Println()
+	fmt.Println("Enable the experiment with canary.enabled, canary.model (a model_name")
+	fmt.Println("from model_list) and canary.percent in config.json.")
+}
+
+func canaryReportCmd(args []string) {
+	days := 0
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--days":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --days requires a value")
+				return
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n <= 0 {
+				fmt.Printf("Error: invalid --days value %q\n", args[i+1])
+				return
+			}
+			days = n
+			i++
+		default:
+			fmt.Printf("Unknown option: %s\n", args[i])
+			return
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+
+	records, err := canary.LoadRecords(canary.LogPath(cfg.WorkspacePath()))
+	if err != nil {
+		fmt.Printf("Error reading canary log: %v\n", err)
+		return
+	}
+	if len(records) == 0 {
+		fmt.Println("No canary data recorded yet.")
+		if !cfg.Canary.Enabled {
+			fmt.Println("  The experiment is disabled (canary.enabled in config.json).")
+		}
+		return
+	}
+
+	var since time.Time
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+	}
+
+	fmt.Println("\nCanary report:")
+	fmt.Println("--------------")
+	fmt.Printf("  %-8s %-24s %6s %6s %9s %9s %10s %10s %5s %5s\n",
+		"Arm", "Model", "Turns", "Errors", "Avg", "P95", "Tokens", "Cost", "👍", "👎")
+	for _, s := range canary.Summarize(records, since) {
+		model := strings.Join(s.Models, ",")
+		if model == "" {
+			model = "-"
+		}
+		fmt.Printf("  %-8s %-24s %6d %6d %9s %9s %10d %10s %5d %5d\n",
+			s.Arm, model, s.Turns, s.Errors,
+			s.AvgLatency.Round(time.Millisecond), s.P95Latency.Round(time.Millisecond),
+			s.PromptTokens+s.CompletionTokens, fmt.Sprintf("$%.4f", s.CostUSD),
+			s.ThumbsUp, s.ThumbsDown)
+	}
+}
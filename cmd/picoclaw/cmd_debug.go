@@ -0,0 +1,244 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/audit"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/replay"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+func debugCmd() {
+	if len(os.Args) < 3 {
+		debugHelp()
+		return
+	}
+
+	switch os.Args[2] {
+	case "turns":
+		debugTurnsCmd(os.Args[3:])
+	case "replay":
+		debugReplayCmd(os.Args[3:])
+	default:
+		fmt.Printf("Unknown debug command: %s\n", os.Args[2])
+		debugHelp()
+	}
+}
+
+func debugHelp() {
+	fmt.Println("\nDebug commands:")
+	fmt.Println("  turns               List recent turns with their IDs")
+	fmt.Println("  replay <turn-id>    Show the prompt and provider calls behind a turn")
+	fmt.Println()
+	fmt.Println("Turns options:")
+	fmt.Println("  --days <n>          Only include the last n days (default: 1)")
+	fmt.Println()
+	fmt.Println("Replay options:")
+	fmt.Println("  --model <name>      Send the turn's prompt to another model and compare")
+	fmt.Println()
+	fmt.Println("Replays need the wire log (wire_log.enabled in config.json) at the")
+	fmt.Println("full or redacted level; turns are read from the audit log.")
+}
+
+func debugTurnsCmd(args []string) {
+	days := 1
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--days":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --days requires a value")
+				return
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n <= 0 {
+				fmt.Printf("Error: invalid --days value %q\n", args[i+1])
+				return
+			}
+			days = n
+			i++
+		default:
+			fmt.Printf("Unknown option: %s\n", args[i])
+			return
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+
+	entries, err := audit.NewLog(cfg.WorkspacePath()).ReadSince(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		fmt.Printf("Error reading audit log: %v\n", err)
+		return
+	}
+
+	fmt.Println("\nTurns:")
+	fmt.Println("------")
+	count := 0
+	for _, e := range entries {
+		if e.Kind != audit.KindTurn {
+			continue
+		}
+		fmt.Printf("  %s  %s  %-20s %s\n", e.TurnID, e.Time.Format("2006-01-02 15:04"), e.Channel+":"+e.ChatID, utils.Truncate(e.UserMessage, 50))
+		count++
+	}
+	if count == 0 {
+		fmt.Printf("  No turns in the last %d day(s).\n", days)
+	}
+}
+
+func debugReplayCmd(args []string) {
+	turnID, model := "", ""
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--model":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --model requires a value")
+				return
+			}
+			model = args[i+1]
+			i++
+		default:
+			if strings.HasPrefix(args[i], "-") || turnID != "" {
+				fmt.Printf("Unknown option: %s\n", args[i])
+				return
+			}
+			turnID = args[i]
+		}
+	}
+	if turnID == "" {
+		fmt.Println("Usage: picoclaw debug replay <turn-id> [--model <name>]")
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+
+	workspace := cfg.WorkspacePath()
+	turn, err := replay.Load(workspace, providers.WireLogDir(cfg.WireLog, workspace), turnID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	fmt.Printf("\nTurn %s\n", turn.TurnID)
+	fmt.Println("----------------------------")
+	fmt.Printf("  Time:       %s\n", turn.Time.Format("2006-01-02 15:04:05"))
+	fmt.Printf("  Agent:      %s\n", turn.AgentID)
+	fmt.Printf("  Session:    %s\n", turn.SessionKey)
+	fmt.Printf("  Model:      %s\n", turn.Model)
+	fmt.Printf("  Iterations: %d\n", turn.Iterations)
+	if len(turn.ToolCalls) > 0 {
+		fmt.Printf("  Tools:      %s\n", strings.Join(turn.ToolCalls, ", "))
+	}
+	if turn.Stopped != "" {
+		fmt.Printf("  Stopped:    %s\n", turn.Stopped)
+	}
+	if turn.Error != "" {
+		fmt.Printf("  Error:      %s\n", turn.Error)
+	}
+	fmt.Printf("\nUser:\n%s\n\nReply:\n%s\n", turn.UserMessage, turn.Response)
+
+	if len(turn.Calls) == 0 {
+		fmt.Println("\nNo provider calls for this turn were found in the wire log.")
+		if !cfg.WireLog.Enabled {
+			fmt.Println("  The wire log is disabled (wire_log.enabled in config.json).")
+		}
+		return
+	}
+
+	fmt.Printf("\nProvider calls (%d):\n", len(turn.Calls))
+	for i, call := range turn.Calls {
+		fmt.Printf("  [%d] %s  %s  %dms  %d message(s)\n", i+1, call.Time.Format("15:04:05"), call.Model, call.DurationMS, len(call.Messages))
+		if call.Error != "" {
+			fmt.Printf("      error: %s\n", call.Error)
+		}
+		if call.Response != nil {
+			for _, tc := range call.Response.ToolCalls {
+				fmt.Printf("      → %s\n", utils.Truncate(replay.FormatToolCall(tc), 100))
+			}
+		}
+	}
+
+	if !turn.Full() {
+		fmt.Println("\nThe wire log only kept metadata for this turn; set wire_log.level to")
+		fmt.Println("full or redacted to record prompts.")
+		return
+	}
+	fmt.Println("\nPrompt:")
+	replay.RenderPrompt(os.Stdout, turn.Prompt())
+
+	if model != "" {
+		debugRerun(cfg, turn, model)
+	}
+}
+
+// debugRerun sends the turn's prompt to model, a model_name from
+// model_list or a model of the default provider, and prints both replies.
+func debugRerun(cfg *config.Config, turn *replay.Turn, model string) {
+	var provider providers.LLMProvider
+	modelID := model
+	if modelCfg, err := cfg.GetModelConfig(model); err == nil {
+		if modelCfg.Workspace == "" {
+			modelCfg.Workspace = cfg.WorkspacePath()
+		}
+		provider, modelID, err = providers.CreateProviderFromConfig(modelCfg)
+		if err != nil {
+			fmt.Printf("Error creating provider for %s: %v\n", model, err)
+			return
+		}
+	} else {
+		provider, _, err = providers.CreateProvider(cfg)
+		if err != nil {
+			fmt.Printf("Error creating provider: %v\n", err)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	resp, err := turn.Rerun(ctx, provider, modelID)
+	if err != nil {
+		fmt.Printf("Error re-running the turn on %s: %v\n", model, err)
+		return
+	}
+
+	original := turn.Calls[0].Response
+	fmt.Println("\nComparison of the first call:")
+	fmt.Println("-----------------------------")
+	fmt.Printf("Original (%s):\n", turn.Calls[0].Model)
+	printReplayResponse(original)
+	fmt.Printf("\nReplay (%s):\n", model)
+	printReplayResponse(resp)
+}
+
+func printReplayResponse(resp *providers.LLMResponse) {
+	if resp == nil {
+		fmt.Println("  (no response)")
+		return
+	}
+	if resp.Content != "" {
+		fmt.Println(resp.Content)
+	}
+	for _, tc := range resp.ToolCalls {
+		fmt.Printf("→ %s\n", replay.FormatToolCall(tc))
+	}
+	if resp.Usage != nil {
+		fmt.Printf("  (%d prompt + %d completion tokens)\n", resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	}
+}
@@ -0,0 +1,140 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/audit"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+func feedbackCmd() {
+	if len(os.Args) < 3 {
+		feedbackHelp()
+		return
+	}
+
+	subcommand := os.Args[2]
+	days := 30
+	output := ""
+	args := os.Args[3:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--days":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --days requires a value")
+				return
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n <= 0 {
+				fmt.Printf("Error: invalid --days value %q\n", args[i+1])
+				return
+			}
+			days = n
+			i++
+		case "-o", "--output":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --output requires a file path")
+				return
+			}
+			output = args[i+1]
+			i++
+		default:
+			fmt.Printf("Unknown option: %s\n", args[i])
+			return
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+
+	log := audit.NewLog(cfg.WorkspacePath())
+	entries, err := log.ReadSince(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		fmt.Printf("Error reading audit log: %v\n", err)
+		return
+	}
+	examples := audit.FeedbackExamples(entries)
+
+	switch subcommand {
+	case "list":
+		feedbackListCmd(examples, days)
+	case "export":
+		feedbackExportCmd(examples, output)
+	default:
+		fmt.Printf("Unknown feedback command: %s\n", subcommand)
+		feedbackHelp()
+	}
+}
+
+func feedbackHelp() {
+	fmt.Println("\nFeedback commands:")
+	fmt.Println("  list                Show recent 👍/👎 feedback")
+	fmt.Println("  export              Write feedback with the rated turns as JSONL")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --days <n>          Only include the last n days (default: 30)")
+	fmt.Println("  -o, --output <file> Write the export to a file instead of stdout")
+	fmt.Println()
+	fmt.Println("Users give feedback by replying 👍 or 👎 to the bot, or with")
+	fmt.Println("/feedback up|down [comment].")
+}
+
+func feedbackListCmd(examples []audit.FeedbackExample, days int) {
+	if len(examples) == 0 {
+		fmt.Printf("No feedback in the last %d days.\n", days)
+		return
+	}
+
+	up, down := 0, 0
+	fmt.Println("\nFeedback:")
+	fmt.Println("---------")
+	for _, ex := range examples {
+		icon := "👍"
+		if ex.Rating == audit.RatingDown {
+			icon = "👎"
+			down++
+		} else {
+			up++
+		}
+		fmt.Printf("  %s %s  %s\n", icon, ex.Time.Format("2006-01-02 15:04"), utils.Truncate(ex.UserMessage, 60))
+		if ex.Comment != "" {
+			fmt.Printf("       \"%s\"\n", utils.Truncate(ex.Comment, 80))
+		}
+	}
+	fmt.Printf("\n%d 👍, %d 👎\n", up, down)
+}
+
+func feedbackExportCmd(examples []audit.FeedbackExample, output string) {
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			fmt.Printf("Error creating %s: %v\n", output, err)
+			return
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	for _, ex := range examples {
+		if err := enc.Encode(ex); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing export: %v\n", err)
+			return
+		}
+	}
+	if output != "" {
+		fmt.Printf("✓ Exported %d feedback example(s) to %s\n", len(examples), output)
+	}
+}
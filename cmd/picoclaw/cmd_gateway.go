@@ -10,43 +10,71 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/sipeed/picoclaw/pkg/agent"
+	"github.com/sipeed/picoclaw/pkg/announce"
+	"github.com/sipeed/picoclaw/pkg/bookmarks"
 	"github.com/sipeed/picoclaw/pkg/bus"
 	"github.com/sipeed/picoclaw/pkg/channels"
+	"github.com/sipeed/picoclaw/pkg/commands"
 	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/constants"
 	"github.com/sipeed/picoclaw/pkg/cron"
 	"github.com/sipeed/picoclaw/pkg/devices"
+	"github.com/sipeed/picoclaw/pkg/focus"
+	"github.com/sipeed/picoclaw/pkg/followup"
+	"github.com/sipeed/picoclaw/pkg/habits"
 	"github.com/sipeed/picoclaw/pkg/health"
 	"github.com/sipeed/picoclaw/pkg/heartbeat"
+	"github.com/sipeed/picoclaw/pkg/journal"
+	"github.com/sipeed/picoclaw/pkg/kv"
 	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/maintenance"
+	"github.com/sipeed/picoclaw/pkg/proactive"
 	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/retention"
+	"github.com/sipeed/picoclaw/pkg/review"
+	"github.com/sipeed/picoclaw/pkg/skills"
+	"github.com/sipeed/picoclaw/pkg/starters"
 	"github.com/sipeed/picoclaw/pkg/state"
+	"github.com/sipeed/picoclaw/pkg/templates"
 	"github.com/sipeed/picoclaw/pkg/tools"
+	"github.com/sipeed/picoclaw/pkg/utils"
+	"github.com/sipeed/picoclaw/pkg/version"
 	"github.com/sipeed/picoclaw/pkg/voice"
+	"github.com/sipeed/picoclaw/pkg/wellness"
 )
 
 func gatewayCmd() {
 	// Check for --debug flag
-	args := os.Args[2:]
-	for _, arg := range args {
+	debug := false
+	for _, arg := range os.Args[2:] {
 		if arg == "--debug" || arg == "-d" {
-			logger.SetLevel(logger.DEBUG)
-			fmt.Println("🔍 Debug mode enabled")
+			debug = true
 			break
 		}
 	}
 
 	cfg, err := loadConfig()
 	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
+		printStatus(logger.ERROR, "Error loading config: %v", err)
 		os.Exit(1)
 	}
+	// The config says whether the output is JSON
+	if debug {
+		logger.SetLevel(logger.DEBUG)
+		printStatus(logger.INFO, "🔍 Debug mode enabled")
+	}
+	guardResources(cfg)
 
+	modelName := cfg.Agents.Defaults.Model
 	provider, modelID, err := providers.CreateProvider(cfg)
 	if err != nil {
-		fmt.Printf("Error creating provider: %v\n", err)
+		printStatus(logger.ERROR, "Error creating provider: %v", err)
 		os.Exit(1)
 	}
 	// Use the resolved model ID from provider creation
@@ -55,17 +83,22 @@ func gatewayCmd() {
 	}
 
 	msgBus := bus.NewMessageBus()
+	if err := msgBus.PersistHeld(filepath.Join(cfg.WorkspacePath(), "state", "held.json")); err != nil {
+		printStatus(logger.ERROR, "Error loading held messages: %v", err)
+	}
 	agentLoop := agent.NewAgentLoop(cfg, msgBus, provider)
 
 	// Print agent startup info
-	fmt.Println("\n📦 Agent Status:")
 	startupInfo := agentLoop.GetStartupInfo()
 	toolsInfo := startupInfo["tools"].(map[string]interface{})
 	skillsInfo := startupInfo["skills"].(map[string]interface{})
-	fmt.Printf("  • Tools: %d loaded\n", toolsInfo["count"])
-	fmt.Printf("  • Skills: %d/%d available\n",
-		skillsInfo["available"],
-		skillsInfo["total"])
+	if !logger.JSON() {
+		fmt.Println("\n📦 Agent Status:")
+		fmt.Printf("  • Tools: %d loaded\n", toolsInfo["count"])
+		fmt.Printf("  • Skills: %d/%d available\n",
+			skillsInfo["available"],
+			skillsInfo["total"])
+	}
 
 	// Log to file as well
 	logger.InfoCF("agent", "Agent initialized",
@@ -103,18 +136,72 @@ func gatewayCmd() {
 		return tools.SilentResult(response)
 	})
 
+	if cfg.Tools.Habits.Enabled && cfg.Tools.Habits.Reminders {
+		heartbeatService.OnBeat(habits.NewReminder(habits.NewStore(cfg.WorkspacePath()), msgBus).Check)
+	}
+	if journalCfg := cfg.Tools.Journal; journalCfg.Enabled {
+		var summarize journal.SummarizeFunc
+		if journalCfg.WeeklySummary {
+			summarize = func(ctx context.Context, prompt string, u journal.User) (string, error) {
+				return agentLoop.ProcessHeartbeat(ctx, prompt, u.Channel, u.ChatID)
+			}
+		}
+		heartbeatService.OnBeat(journal.NewPrompter(journal.NewStore(cfg.WorkspacePath()), msgBus, journalCfg.Questions,
+			journalCfg.PromptHour, bookmarks.ParseWeekday(journalCfg.SummaryDay), journalCfg.SummaryHour, summarize).Check)
+	}
+	if cfg.Tools.Wellness.Enabled {
+		// The last active chat is the user the briefing goes to
+		heartbeatService.AddNote(func(channel, chatID string, now time.Time) string {
+			records, err := wellness.NewStore(agentLoop.WorkspaceFor(channel, chatID)).Records(chatID)
+			if err != nil {
+				return ""
+			}
+			if note := wellness.Note(records, now); note != "" {
+				return "Wellness: " + note
+			}
+			return ""
+		})
+	}
+	if cfg.Starters.Enabled {
+		heartbeatService.OnBeat(setupStarters(cfg, agentLoop, msgBus, cronService).Check)
+	}
+
+	retentionService := retention.NewService(cfg.Retention)
+	registry := agentLoop.GetRegistry()
+	for _, agentID := range registry.ListAgentIDs() {
+		if a, ok := registry.GetAgent(agentID); ok {
+			retentionService.AddWorkspace(a.Workspace, a.Sessions)
+		}
+	}
+
 	channelManager, err := channels.NewManager(cfg, msgBus)
 	if err != nil {
-		fmt.Printf("Error creating channel manager: %v\n", err)
+		printStatus(logger.ERROR, "Error creating channel manager: %v", err)
 		os.Exit(1)
 	}
 
 	// Inject channel manager into agent loop for command handling
 	agentLoop.SetChannelManager(channelManager)
+	agentLoop.SetConfigPath(getConfigPath())
+	channelManager.SetConfigPath(getConfigPath())
+
+	store, err := kv.Open(cfg.KV, cfg.WorkspacePath())
+	if err != nil {
+		printStatus(logger.WARN, "⚠️  State store unavailable, keeping state in memory: %v", err)
+		store = kv.NewMemory()
+	}
+	channelManager.SetStore(store)
+	agentLoop.SetStore(store)
+	templates.SetUsageSource(func() templates.Usage {
+		requests, prompt, completion := agentLoop.UsageToday()
+		return templates.Usage{Requests: requests, PromptTokens: prompt, CompletionTokens: completion}
+	})
+	channelManager.SetSummarizer(agentLoop.SummarizeTranscript)
 
 	var transcriber *voice.GroqTranscriber
 	if cfg.Providers.Groq.APIKey != "" {
-		transcriber = voice.NewGroqTranscriber(cfg.Providers.Groq.APIKey)
+		transcriber = voice.NewGroqTranscriber(cfg.Providers.Groq.APIKey, cfg.Timeouts.TranscriptionTimeout())
+		transcriber.SetConverter(voice.NewAudioConverter(cfg.Voice))
 		logger.InfoC("voice", "Groq voice transcription enabled")
 	}
 
@@ -125,10 +212,13 @@ func gatewayCmd() {
 				logger.InfoC("voice", "Groq transcription attached to Telegram channel")
 			}
 		}
-		if discordChannel, ok := channelManager.GetChannel("discord"); ok {
+		for _, name := range channelManager.GetEnabledChannels() {
+			discordChannel, _ := channelManager.GetChannel(name)
 			if dc, ok := discordChannel.(*channels.DiscordChannel); ok {
 				dc.SetTranscriber(transcriber)
-				logger.InfoC("voice", "Groq transcription attached to Discord channel")
+				logger.InfoCF("voice", "Groq transcription attached to Discord channel", map[string]interface{}{
+					"channel": name,
+				})
 			}
 		}
 		if slackChannel, ok := channelManager.GetChannel("slack"); ok {
@@ -137,30 +227,112 @@ func gatewayCmd() {
 				logger.InfoC("voice", "Groq transcription attached to Slack channel")
 			}
 		}
+		if waCloudChannel, ok := channelManager.GetChannel("whatsapp_cloud"); ok {
+			if wc, ok := waCloudChannel.(*channels.WhatsAppCloudChannel); ok {
+				wc.SetTranscriber(transcriber)
+				logger.InfoC("voice", "Groq transcription attached to WhatsApp Cloud API channel")
+			}
+		}
+		if signalChannel, ok := channelManager.GetChannel("signal"); ok {
+			if sc, ok := signalChannel.(*channels.SignalChannel); ok {
+				sc.SetTranscriber(transcriber)
+				logger.InfoC("voice", "Groq transcription attached to Signal channel")
+			}
+		}
+	}
+
+	if videoProcessor := voice.NewVideoProcessor(cfg.Video, cfg.Voice.FFmpegPath, transcriber, cfg.Timeouts.VideoTimeout()); videoProcessor != nil {
+		channelManager.SetVideoProcessor(videoProcessor)
+		logger.InfoC("voice", "Video attachment processing enabled")
 	}
+	channelManager.SetVision(cfg.Vision)
 
 	enabledChannels := channelManager.GetEnabledChannels()
 	if len(enabledChannels) > 0 {
-		fmt.Printf("✓ Channels enabled: %s\n", enabledChannels)
+		printStatus(logger.INFO, "✓ Channels enabled: %s", enabledChannels)
 	} else {
-		fmt.Println("⚠ Warning: No channels enabled")
+		printStatus(logger.WARN, "⚠ Warning: No channels enabled")
 	}
 
-	fmt.Printf("✓ Gateway started on %s:%d\n", cfg.Gateway.Host, cfg.Gateway.Port)
-	fmt.Println("Press Ctrl+C to stop")
+	printStatus(logger.INFO, "✓ Gateway started on %s:%d", cfg.Gateway.Host, cfg.Gateway.Port)
+	if !logger.JSON() {
+		fmt.Println("Press Ctrl+C to stop")
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	go reportUpdate(ctx, cfg)
+
 	if err := cronService.Start(); err != nil {
-		fmt.Printf("Error starting cron service: %v\n", err)
+		printStatus(logger.ERROR, "Error starting cron service: %v", err)
 	}
-	fmt.Println("✓ Cron service started")
+	printStatus(logger.INFO, "✓ Cron service started")
 
 	if err := heartbeatService.Start(); err != nil {
-		fmt.Printf("Error starting heartbeat service: %v\n", err)
+		printStatus(logger.ERROR, "Error starting heartbeat service: %v", err)
+	}
+	printStatus(logger.INFO, "✓ Heartbeat service started")
+
+	// Services the owner manages live in the default agent's workspace;
+	// per-chat ones follow the chat's agent
+	channelManager.SetWorkspaceResolver(agentLoop.WorkspaceFor)
+	workspaces := agentLoop.Workspaces()
+	defaultWorkspace := workspaces[0]
+
+	announceService := announce.NewService(announce.NewStore(defaultWorkspace), msgBus)
+	if err := announceService.Start(); err != nil {
+		printStatus(logger.ERROR, "Error starting announcement service: %v", err)
+	}
+
+	maintenanceService := maintenance.NewService(maintenance.NewStore(defaultWorkspace), msgBus)
+	if err := maintenanceService.Start(); err != nil {
+		printStatus(logger.ERROR, "Error starting maintenance service: %v", err)
+	}
+
+	if cfg.SelfReview.Enabled {
+		reviewer := review.NewReviewer(defaultWorkspace, provider, cfg.Agents.Defaults.Model)
+		reviewService := review.NewService(reviewer, defaultWorkspace, cfg.SelfReview.Hour, msgBus)
+		reviewService.SetStore(store)
+		if err := reviewService.Schedule(cronService); err != nil {
+			printStatus(logger.ERROR, "Error scheduling self-review: %v", err)
+		} else {
+			printStatus(logger.INFO, "✓ Nightly self-review scheduled at %02d:00", cfg.SelfReview.Hour)
+		}
+	}
+
+	if cfg.Tools.Bookmarks.Enabled && cfg.Tools.Bookmarks.Digest {
+		for i, ws := range workspaces {
+			digestService := bookmarks.NewDigestService(bookmarks.NewStore(ws), ws,
+				cfg.Tools.Bookmarks.DigestDay, cfg.Tools.Bookmarks.DigestHour, msgBus)
+			namespace := ""
+			if i > 0 {
+				namespace = ws
+			}
+			digestService.SetStore(store, namespace)
+			if err := digestService.Schedule(cronService); err != nil {
+				printStatus(logger.ERROR, "Error scheduling bookmark digest: %v", err)
+			}
+		}
+	}
+
+	var focusServices []*focus.Service
+	if cfg.Tools.Focus.Enabled {
+		for _, ws := range workspaces {
+			focusService := focus.NewService(focus.NewStore(ws), msgBus)
+			if err := focusService.Start(); err != nil {
+				printStatus(logger.ERROR, "Error starting focus service: %v", err)
+				continue
+			}
+			focusServices = append(focusServices, focusService)
+		}
+	}
+
+	if err := retentionService.Start(); err != nil {
+		printStatus(logger.ERROR, "Error starting retention service: %v", err)
+	} else if cfg.Retention.Enabled {
+		printStatus(logger.INFO, "✓ Retention service started")
 	}
-	fmt.Println("✓ Heartbeat service started")
 
 	stateManager := state.NewManager(cfg.WorkspacePath())
 	deviceService := devices.NewService(devices.Config{
@@ -169,38 +341,282 @@ func gatewayCmd() {
 	}, stateManager)
 	deviceService.SetBus(msgBus)
 	if err := deviceService.Start(ctx); err != nil {
-		fmt.Printf("Error starting device service: %v\n", err)
+		printStatus(logger.ERROR, "Error starting device service: %v", err)
 	} else if cfg.Devices.Enabled {
-		fmt.Println("✓ Device event service started")
+		printStatus(logger.INFO, "✓ Device event service started")
 	}
 
 	if err := channelManager.StartAll(ctx); err != nil {
-		fmt.Printf("Error starting channels: %v\n", err)
+		printStatus(logger.ERROR, "Error starting channels: %v", err)
+	}
+	go syncCommands(ctx, agentLoop, channelManager)
+	if defaultAgent := agentLoop.GetRegistry().GetDefaultAgent(); defaultAgent != nil {
+		tracker := skills.NewChangeTracker(defaultAgent.ContextBuilder.SkillsLoader())
+		go watchSkills(ctx, tracker, cfg.Tools.Skills.NotifyUpdates, cfg.WorkspacePath(), msgBus)
 	}
 
 	healthServer := health.NewServer(cfg.Gateway.Host, cfg.Gateway.Port)
+	channelManager.RegisterRoutes(healthServer.Handle)
+	if cfg.Gateway.AdminToken != "" {
+		healthServer.Handle("/metrics", providers.MetricsHandler(cfg.Gateway.AdminToken))
+		healthServer.Handle("/admin/allowlist", channelManager.AllowListHandler(cfg.Gateway.AdminToken))
+		healthServer.Handle("/admin/budget", agentLoop.BudgetHandler(cfg.Gateway.AdminToken))
+	}
 	go func() {
 		if err := healthServer.Start(); err != nil && err != http.ErrServerClosed {
 			logger.ErrorCF("health", "Health server error", map[string]interface{}{"error": err.Error()})
 		}
 	}()
-	fmt.Printf("✓ Health endpoints available at http://%s:%d/health and /ready\n", cfg.Gateway.Host, cfg.Gateway.Port)
+	printStatus(logger.INFO, "✓ Health endpoints available at http://%s:%d/health and /ready", cfg.Gateway.Host, cfg.Gateway.Port)
+
+	selfTestTools(agentLoop, healthServer)
+
+	var providerHealth *providers.HealthChecker
+	if cfg.HealthCheck.Enabled {
+		providerHealth = checkProviders(cfg, provider, modelName, modelID, agentLoop, healthServer, msgBus)
+	}
 
 	go agentLoop.Run(ctx)
+	healthServer.SetReady(true)
 
+	// Containers are stopped with SIGTERM
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	<-sigChan
 
-	fmt.Println("\nShutting down...")
+	printStatus(logger.INFO, "\nShutting down...")
+	healthServer.SetReady(false)
 	cancel()
 	healthServer.Stop(context.Background())
 	deviceService.Stop()
 	heartbeatService.Stop()
+	if providerHealth != nil {
+		providerHealth.Stop()
+	}
+	retentionService.Stop()
+	announceService.Stop()
+	maintenanceService.Stop()
+	for _, s := range focusServices {
+		s.Stop()
+	}
 	cronService.Stop()
 	agentLoop.Stop()
 	channelManager.StopAll(ctx)
-	fmt.Println("✓ Gateway stopped")
+	store.Close()
+	printStatus(logger.INFO, "✓ Gateway stopped")
+}
+
+// commandSyncInterval is how often the gateway checks whether installed
+// skills changed the slash command list.
+const commandSyncInterval = time.Minute
+
+// syncCommands keeps the channels' slash command menus in step with the
+// agent's tools and skills.
+func syncCommands(ctx context.Context, agentLoop *agent.AgentLoop, channelManager *channels.Manager) {
+	ticker := time.NewTicker(commandSyncInterval)
+	defer ticker.Stop()
+
+	var last []commands.Command
+	for {
+		if cmds := agentLoop.Commands(); !reflect.DeepEqual(cmds, last) {
+			channelManager.SetCommands(ctx, cmds)
+			last = cmds
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// skillDiffMaxChars caps the diff shown in a skill update notification;
+// the log has all of it.
+const skillDiffMaxChars = 1500
+
+// watchSkills logs the skills that were installed, updated or removed,
+// with a diff of each update, and with notify also tells the owner on the
+// last active channel.
+func watchSkills(ctx context.Context, tracker *skills.ChangeTracker, notify bool, workspace string, msgBus *bus.MessageBus) {
+	ticker := time.NewTicker(commandSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changes := tracker.Check()
+		if len(changes) == 0 {
+			continue
+		}
+		var sb strings.Builder
+		sb.WriteString("🔄 Skills changed:")
+		for _, c := range changes {
+			logger.InfoCF("skills", "Skill changed", map[string]interface{}{
+				"skill":       c.Name,
+				"source":      c.Source,
+				"old_version": c.OldVersion,
+				"new_version": c.NewVersion,
+				"summary":     c.Summary(),
+				"diff":        c.Diff,
+			})
+			sb.WriteString("\n- " + c.Summary())
+			if c.Diff != "" {
+				sb.WriteString("\n```diff\n" + utils.Truncate(c.Diff, skillDiffMaxChars) + "\n```")
+			}
+		}
+
+		if !notify {
+			continue
+		}
+		channel, chatID, ok := strings.Cut(state.NewManager(workspace).GetLastChannel(), ":")
+		if !ok || channel == "" || chatID == "" || constants.IsInternalChannel(channel) {
+			continue
+		}
+		msgBus.PublishOutbound(bus.OutboundMessage{Channel: channel, ChatID: chatID, Content: sb.String()})
+	}
+}
+
+// selfTestTools checks the agents' tools before the agent takes messages
+// and prints the broken ones. The results, and those of later !selftest
+// runs, are a warning in /ready that doesn't make the gateway not ready.
+func selfTestTools(agentLoop *agent.AgentLoop, healthServer *health.Server) {
+	agentLoop.OnToolCheck(func(checks []agent.ToolCheck) {
+		healthServer.RegisterWarning("tools", func() (bool, string) {
+			return agent.ToolCheckSummary(checks)
+		})
+	})
+	checks := agentLoop.SelfTestTools(context.Background())
+	broken := 0
+	for _, c := range checks {
+		if !c.OK() {
+			broken++
+			printStatus(logger.WARN, "⚠️  Tool %s: %s", c.Label(), c.Problem)
+		}
+	}
+	if broken == 0 {
+		printStatus(logger.INFO, "✓ Tools self-tested: %d ok", len(checks))
+	}
+}
+
+// checkProviders asks each configured provider for a token before the
+// agent takes messages, prints the results, and keeps checking every
+// health_check.interval_minutes. The default provider is a /ready check,
+// and when it breaks or recovers the owner's last active chat is told.
+func checkProviders(cfg *config.Config, provider providers.LLMProvider, modelName, modelID string, agentLoop *agent.AgentLoop, healthServer *health.Server, msgBus *bus.MessageBus) *providers.HealthChecker {
+	checker := providers.NewHealthChecker(
+		providers.HealthTargets(cfg, provider, modelName, modelID),
+		time.Duration(cfg.HealthCheck.IntervalMinutes)*time.Minute,
+		time.Duration(cfg.HealthCheck.TimeoutSeconds)*time.Second,
+	)
+	// The owner's last chat is recorded in the default agent's workspace;
+	// tenants and agents with workspaces of their own keep theirs apart.
+	workspace := cfg.WorkspacePath()
+	if defaultAgent := agentLoop.GetRegistry().GetDefaultAgent(); defaultAgent != nil {
+		workspace = defaultAgent.Workspace
+	}
+	checker.OnResult(func(r providers.HealthResult, changed bool) {
+		// Fallbacks and other model_list entries don't stop the agents from
+		// answering, so they are only logged as warnings.
+		if !r.Primary {
+			return
+		}
+		healthServer.RegisterCheck("provider:"+r.Name, func() (bool, string) {
+			return r.OK, r.Summary()
+		})
+		if !changed {
+			return
+		}
+		channel, chatID, ok := strings.Cut(state.NewManager(workspace).GetLastChannel(), ":")
+		if !ok || channel == "" || chatID == "" || constants.IsInternalChannel(channel) {
+			return
+		}
+		msg := bus.OutboundMessage{Channel: channel, ChatID: chatID, Content: "✅ Provider recovered: " + r.Summary()}
+		if !r.OK {
+			msg.Content = "⚠️ Provider health check failed: " + r.Summary()
+			msg.Alert = bus.AlertError
+		}
+		msgBus.PublishOutbound(msg)
+	})
+
+	for _, r := range checker.CheckAll(context.Background()) {
+		if r.OK {
+			printStatus(logger.INFO, "✓ Provider %s", r.Summary())
+		} else {
+			printStatus(logger.WARN, "⚠️  Provider %s", r.Summary())
+		}
+	}
+	if err := checker.Start(); err != nil {
+		printStatus(logger.ERROR, "Error starting provider health checks: %v", err)
+	}
+	return checker
+}
+
+// setupStarters creates the morning conversation starter, drawing on the
+// calendars, today's cron jobs and parked follow-up tasks.
+func setupStarters(cfg *config.Config, agentLoop *agent.AgentLoop, msgBus *bus.MessageBus, cronService *cron.CronService) *starters.Starter {
+	workspace := agentLoop.Workspaces()[0]
+	target := func() (string, string) {
+		// Re-read the state, which the agent loop updates on every message
+		channel, chatID, _ := strings.Cut(state.NewManager(workspace).GetLastChannel(), ":")
+		if constants.IsInternalChannel(channel) {
+			return "", ""
+		}
+		return channel, chatID
+	}
+	ask := func(ctx context.Context, prompt, channel, chatID string) (string, error) {
+		return agentLoop.ProcessHeartbeat(ctx, prompt, channel, chatID)
+	}
+	starter := starters.NewStarter(workspace, cfg.Starters.Hour, target, ask, msgBus)
+
+	if len(cfg.Starters.Calendars) > 0 {
+		starter.AddSource("Calendar", starters.CalendarSource(cfg.Starters.Calendars))
+	}
+	starter.AddSource("Scheduled tasks", func(ctx context.Context, now time.Time, channel, chatID string) ([]string, error) {
+		y, m, d := now.Date()
+		endOfDay := time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+		var lines []string
+		for _, job := range cronService.ListJobs(false) {
+			if job.Payload.Channel != channel || job.Payload.To != chatID {
+				continue
+			}
+			if next := job.State.NextRunAtMS; next != nil && time.UnixMilli(*next).Before(endOfDay) {
+				lines = append(lines, fmt.Sprintf("%s %s", time.UnixMilli(*next).In(now.Location()).Format("15:04"), job.Payload.Message))
+			}
+		}
+		return lines, nil
+	})
+
+	followUps := followup.NewStore(workspace, cfg.Tools.FollowUps.Expire())
+	if cfg.Tools.FollowUps.Enabled {
+		starter.AddSource("Open tasks waiting on the user", func(ctx context.Context, now time.Time, channel, chatID string) ([]string, error) {
+			var lines []string
+			for _, t := range followUps.Pending(now) {
+				if t.Channel == channel && t.ChatID == chatID {
+					lines = append(lines, t.Task)
+				}
+			}
+			return lines, nil
+		})
+		// Park the offer so the user's reply picks it up
+		starter.OnSent = func(channel, chatID, question string) {
+			followUps.Park(followup.Task{
+				Channel:  channel,
+				ChatID:   chatID,
+				Question: question,
+				Task:     "help with what you offered in your morning question, if the user accepts",
+			})
+		}
+	}
+
+	starter.Muted = func(channel, chatID string) bool {
+		engine := proactive.NewEngine(agentLoop.WorkspaceFor(channel, chatID), cfg.Proactive)
+		return engine.DailyLimit(engine.Level(channel, chatID)) == 0
+	}
+	return starter
 }
 
 func setupCronTool(agentLoop *agent.AgentLoop, msgBus *bus.MessageBus, workspace string, restrict bool, execTimeout time.Duration, cfg *config.Config) *cron.CronService {
@@ -212,6 +628,9 @@ func setupCronTool(agentLoop *agent.AgentLoop, msgBus *bus.MessageBus, workspace
 	// Create and register CronTool
 	cronTool := tools.NewCronTool(cronService, agentLoop, msgBus, workspace, restrict, execTimeout, cfg)
 	agentLoop.RegisterTool(cronTool)
+	agentLoop.RegisterTool(tools.NewReminderListTool(cronService, msgBus))
+	agentLoop.RegisterTool(tools.NewReminderCancelTool(cronService, msgBus))
+	agentLoop.SetCronService(cronService)
 
 	// Set the onJob handler
 	cronService.SetOnJob(func(job *cron.CronJob) (string, error) {
@@ -221,3 +640,30 @@ func setupCronTool(agentLoop *agent.AgentLoop, msgBus *bus.MessageBus, workspace
 
 	return cronService
 }
+
+// reportUpdate says so when a newer release is out on the configured
+// release channel.
+func reportUpdate(ctx context.Context, cfg *config.Config) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	interval := time.Duration(cfg.Updates.CheckIntervalHours) * time.Hour
+	check, err := version.CheckForUpdate(ctx, cfg.WorkspacePath(), cfg.Updates.Channel, interval)
+	if err != nil {
+		logger.DebugCF("version", "Update check failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !check.Available() {
+		return
+	}
+	if !logger.JSON() {
+		fmt.Printf("⬆ picoclaw %s is available (you have %s): %s\n", check.Latest, version.Version, check.URL)
+	}
+	logger.InfoCF("version", "Update available", map[string]interface{}{
+		"current": version.Version,
+		"latest":  check.Latest,
+		"channel": check.Channel,
+	})
+}
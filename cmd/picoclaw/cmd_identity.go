@@ -0,0 +1,75 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/identity"
+)
+
+func identityCmd() {
+	if len(os.Args) < 3 {
+		identityHelp()
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	store := identity.NewStore(cfg.WorkspacePath())
+
+	switch os.Args[2] {
+	case "list":
+		identities := store.List()
+		if len(identities) == 0 {
+			fmt.Println("No linked accounts.")
+			return
+		}
+		for _, id := range identities {
+			fmt.Printf("  %s  %s (since %s)\n", id.Name, strings.Join(id.Accounts, ", "), id.Created.Format("2006-01-02"))
+		}
+	case "link":
+		if len(os.Args) != 5 {
+			fmt.Println("Usage: picoclaw identity link <channel:id> <channel:id>")
+			return
+		}
+		id, err := store.Link(strings.ToLower(os.Args[3]), strings.ToLower(os.Args[4]))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("✓ Linked %s as %s\n", strings.Join(id.Accounts, ", "), id.Name)
+	case "unlink":
+		if len(os.Args) != 4 {
+			fmt.Println("Usage: picoclaw identity unlink <channel:id>")
+			return
+		}
+		unlinked, err := store.Unlink(strings.ToLower(os.Args[3]))
+		switch {
+		case err != nil:
+			fmt.Printf("Error: %v\n", err)
+		case !unlinked:
+			fmt.Printf("%s isn't linked.\n", os.Args[3])
+		default:
+			fmt.Printf("✓ Unlinked %s\n", os.Args[3])
+		}
+	default:
+		fmt.Printf("Unknown identity command: %s\n", os.Args[2])
+		identityHelp()
+	}
+}
+
+func identityHelp() {
+	fmt.Println("\nIdentity commands:")
+	fmt.Println("  list                       Show linked accounts")
+	fmt.Println("  link <account> <account>   Link two accounts, e.g. discord:123 telegram:456")
+	fmt.Println("  unlink <account>           Unlink an account from the others")
+	fmt.Println()
+	fmt.Println("Linked accounts share a DM session unless session.dm_scope is \"main\".")
+}
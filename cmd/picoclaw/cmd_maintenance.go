@@ -0,0 +1,92 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/agent"
+	"github.com/sipeed/picoclaw/pkg/maintenance"
+)
+
+func maintenanceCmd() {
+	if len(os.Args) < 3 {
+		maintenanceHelp()
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+
+	store := maintenance.NewStore(agent.DefaultWorkspace(cfg))
+
+	switch os.Args[2] {
+	case "on":
+		message := strings.TrimSpace(strings.Join(os.Args[3:], " "))
+		if err := store.Enable(message); err != nil {
+			fmt.Printf("Error enabling maintenance mode: %v\n", err)
+			return
+		}
+		if message == "" {
+			message = cfg.Maintenance.Message
+		}
+		fmt.Println("✓ Maintenance mode on")
+		fmt.Printf("  Away message: %s\n", message)
+	case "off":
+		n, err := store.Disable()
+		if err != nil {
+			fmt.Printf("Error disabling maintenance mode: %v\n", err)
+			return
+		}
+		fmt.Println("✓ Maintenance mode off")
+		if n > 0 {
+			fmt.Printf("  %d queued message(s) will be processed by the running gateway\n", n)
+		}
+	case "status":
+		maintenanceStatusCmd(store)
+	default:
+		fmt.Printf("Unknown maintenance command: %s\n", os.Args[2])
+		maintenanceHelp()
+	}
+}
+
+func maintenanceHelp() {
+	fmt.Println("\nMaintenance commands:")
+	fmt.Println("  on [message]        Queue incoming messages and reply with an away message")
+	fmt.Println("  off                 Resume processing, including queued messages")
+	fmt.Println("  status              Show whether maintenance mode is on")
+	fmt.Println()
+	fmt.Println("Channels stay connected while maintenance mode is on. The default away")
+	fmt.Println("message is maintenance.message in config.json.")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  picoclaw maintenance on \"Upgrading, back in 10 minutes\"")
+	fmt.Println("  picoclaw maintenance off")
+}
+
+func maintenanceStatusCmd(store *maintenance.Store) {
+	st := store.Status()
+	if !st.Enabled {
+		fmt.Println("Maintenance mode: off")
+		if len(st.Queue) > 0 {
+			fmt.Printf("  %d queued message(s) waiting for the gateway\n", len(st.Queue))
+		}
+		return
+	}
+
+	fmt.Println("Maintenance mode: on")
+	fmt.Printf("  Since:  %s\n", st.Since.Format("2006-01-02 15:04"))
+	fmt.Printf("  Queued: %d message(s)\n", len(st.Queue))
+	if st.Dropped > 0 {
+		fmt.Printf("  Dropped: %d message(s) (queue full)\n", st.Dropped)
+	}
+	if st.Message != "" {
+		fmt.Printf("  Away message: %s\n", st.Message)
+	}
+}
@@ -0,0 +1,209 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/agent"
+)
+
+func memoryCmd() {
+	if len(os.Args) < 3 {
+		memoryHelp()
+		return
+	}
+
+	subcommand := os.Args[2]
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+
+	store := agent.NewMemoryStore(cfg.WorkspacePath())
+
+	switch subcommand {
+	case "show":
+		target := ""
+		if len(os.Args) >= 4 {
+			target = os.Args[3]
+		}
+		memoryShowCmd(store, target)
+	case "edit":
+		target := ""
+		if len(os.Args) >= 4 {
+			target = os.Args[3]
+		}
+		memoryEditCmd(store, target)
+	case "search":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: picoclaw memory search <query>")
+			return
+		}
+		memorySearchCmd(store, strings.Join(os.Args[3:], " "))
+	case "history":
+		memoryHistoryCmd(store)
+	default:
+		fmt.Printf("Unknown memory command: %s\n", subcommand)
+		memoryHelp()
+	}
+}
+
+func memoryHelp() {
+	fmt.Println("\nMemory commands:")
+	fmt.Println("  show [date]         Show long-term memory, or the daily note for a date")
+	fmt.Println("  edit [date]         Open long-term memory or a daily note in $EDITOR")
+	fmt.Println("  search <query>      Search long-term memory and all daily notes")
+	fmt.Println("  history             List daily notes, newest first")
+	fmt.Println()
+	fmt.Println("Dates can be YYYYMMDD, YYYY-MM-DD, 'today' or 'yesterday'.")
+	fmt.Println()
+	fmt.Println("History options:")
+	fmt.Println("  -n, --limit <N>     Show at most N notes (default: 30)")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  picoclaw memory show")
+	fmt.Println("  picoclaw memory show yesterday")
+	fmt.Println("  picoclaw memory edit 2026-02-14")
+	fmt.Println("  picoclaw memory search \"flight number\"")
+}
+
+// resolveMemoryFile maps a CLI target to a memory file path.
+// An empty target (or "long-term") selects MEMORY.md.
+func resolveMemoryFile(store *agent.MemoryStore, target string) (string, error) {
+	switch strings.ToLower(target) {
+	case "", "long-term", "longterm", "memory":
+		return store.MemoryFile(), nil
+	case "today":
+		return store.DailyNotePath(time.Now()), nil
+	case "yesterday":
+		return store.DailyNotePath(time.Now().AddDate(0, 0, -1)), nil
+	}
+
+	for _, layout := range []string{"20060102", "2006-01-02"} {
+		if date, err := time.ParseInLocation(layout, target, time.Local); err == nil {
+			return store.DailyNotePath(date), nil
+		}
+	}
+	return "", fmt.Errorf("invalid date %q (expected YYYYMMDD or YYYY-MM-DD)", target)
+}
+
+func memoryShowCmd(store *agent.MemoryStore, target string) {
+	path, err := resolveMemoryFile(store, target)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Printf("No memory found at %s\n", path)
+			return
+		}
+		fmt.Printf("Error reading %s: %v\n", path, err)
+		return
+	}
+
+	fmt.Printf("\n%s\n", path)
+	fmt.Println(strings.Repeat("-", len(path)))
+	fmt.Println(string(data))
+}
+
+func memoryEditCmd(store *agent.MemoryStore, target string) {
+	path, err := resolveMemoryFile(store, target)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Printf("Error creating directory: %v\n", err)
+		return
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// EDITOR may contain arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		fmt.Printf("Error running editor: %v\n", err)
+		return
+	}
+	fmt.Printf("✓ Saved %s\n", path)
+}
+
+func memorySearchCmd(store *agent.MemoryStore, query string) {
+	matches := store.Search(query)
+	if len(matches) == 0 {
+		fmt.Printf("No matches for %q.\n", query)
+		return
+	}
+
+	workspace := filepath.Dir(filepath.Dir(store.MemoryFile()))
+	currentFile := ""
+	for _, m := range matches {
+		if m.Path != currentFile {
+			currentFile = m.Path
+			rel, err := filepath.Rel(workspace, m.Path)
+			if err != nil {
+				rel = m.Path
+			}
+			fmt.Printf("\n%s\n", rel)
+		}
+		fmt.Printf("  %4d: %s\n", m.Line, m.Text)
+	}
+	fmt.Printf("\n%d match(es)\n", len(matches))
+}
+
+func memoryHistoryCmd(store *agent.MemoryStore) {
+	limit := 30
+	args := os.Args[3:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-n", "--limit":
+			if i+1 < len(args) {
+				if n, err := strconv.Atoi(args[i+1]); err == nil && n > 0 {
+					limit = n
+				}
+				i++
+			}
+		}
+	}
+
+	notes := store.ListDailyNotes()
+	if len(notes) == 0 {
+		fmt.Println("No daily notes yet.")
+		return
+	}
+
+	fmt.Println("\nDaily Notes:")
+	fmt.Println("------------")
+	for i, note := range notes {
+		if i >= limit {
+			fmt.Printf("  ... and %d more\n", len(notes)-limit)
+			break
+		}
+		fmt.Printf("  %s  %6d bytes\n", note.Date.Format("2006-01-02 (Mon)"), note.Size)
+	}
+}
@@ -0,0 +1,90 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/channels"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func pairCmd() {
+	if len(os.Args) < 3 {
+		pairHelp()
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	store := channels.NewPairingStore(cfg.WorkspacePath())
+
+	if os.Args[2] == "list" {
+		pairListCmd(store)
+		return
+	}
+
+	req, err := store.Redeem(os.Args[2])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("✓ Paired %s user %s (%s)\n", req.Channel, req.Username, req.UserID)
+
+	// Record them in allow_from too, so the pairing survives losing the
+	// workspace state
+	allow := cfg.Channels.AllowFrom(req.Channel)
+	if allow == nil || slices.Contains(*allow, req.UserID) {
+		return
+	}
+	err = config.EditFile(getConfigPath(), []string{"channels", req.Channel, "allow_from"}, func(current json.RawMessage) (any, error) {
+		var saved config.FlexibleStringSlice
+		if err := json.Unmarshal(current, &saved); err != nil {
+			return nil, err
+		}
+		return append(saved, req.UserID), nil
+	})
+	if err != nil {
+		fmt.Printf("Warning: could not add them to allow_from: %v\n", err)
+		return
+	}
+	fmt.Printf("  Added %s to channels.%s.allow_from\n", req.UserID, req.Channel)
+}
+
+func pairHelp() {
+	fmt.Println("\nPair commands:")
+	fmt.Println("  <code>              Let in the user a pairing code was sent to")
+	fmt.Println("  list                Show pending pairing requests and paired users")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  picoclaw pair 482913")
+	fmt.Println("  picoclaw pair list")
+}
+
+func pairListCmd(store *channels.PairingStore) {
+	pending, paired := store.List()
+	if len(pending) == 0 && len(paired) == 0 {
+		fmt.Println("No pairing requests.")
+		return
+	}
+	if len(pending) > 0 {
+		fmt.Println("Pending:")
+		for _, r := range pending {
+			fmt.Printf("  %s  %s %s (%s), %s ago\n", r.Code, r.Channel, r.Username, r.UserID, time.Since(r.Created).Round(time.Minute))
+		}
+	}
+	if len(paired) > 0 {
+		fmt.Println("Paired:")
+		for _, r := range paired {
+			fmt.Printf("  %s %s (%s), %s\n", r.Channel, r.Username, r.UserID, r.Created.Format("2006-01-02"))
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/agent"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/review"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+func reviewCmd() {
+	if len(os.Args) < 3 {
+		reviewHelp()
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	store := review.NewStore(agent.DefaultWorkspace(cfg))
+
+	subcommand := os.Args[2]
+	switch subcommand {
+	case "run":
+		hours := 24
+		args := os.Args[3:]
+		for i := 0; i < len(args); i++ {
+			switch args[i] {
+			case "--hours":
+				if i+1 >= len(args) {
+					fmt.Println("Error: --hours requires a value")
+					return
+				}
+				n, err := strconv.Atoi(args[i+1])
+				if err != nil || n <= 0 {
+					fmt.Printf("Error: invalid --hours value %q\n", args[i+1])
+					return
+				}
+				hours = n
+				i++
+			default:
+				fmt.Printf("Unknown option: %s\n", args[i])
+				return
+			}
+		}
+
+		provider, modelID, err := providers.CreateProvider(cfg)
+		if err != nil {
+			fmt.Printf("Error creating provider: %v\n", err)
+			return
+		}
+		if modelID == "" {
+			modelID = cfg.Agents.Defaults.Model
+		}
+		reviewRunCmd(review.NewReviewer(agent.DefaultWorkspace(cfg), provider, modelID), hours)
+	case "list":
+		all := len(os.Args) > 3 && os.Args[3] == "--all"
+		reviewListCmd(store, all)
+	case "show", "apply", "reject":
+		if len(os.Args) < 4 {
+			fmt.Printf("Usage: picoclaw review %s <id>\n", subcommand)
+			return
+		}
+		id, err := strconv.Atoi(os.Args[3])
+		if err != nil {
+			fmt.Printf("Error: invalid proposal id %q\n", os.Args[3])
+			return
+		}
+		switch subcommand {
+		case "show":
+			reviewShowCmd(store, id)
+		case "apply":
+			p, err := store.Apply(id)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Printf("✓ Applied proposal #%d to %s\n", p.ID, p.File)
+		case "reject":
+			if _, err := store.Reject(id); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			fmt.Printf("✓ Rejected proposal #%d\n", id)
+		}
+	default:
+		fmt.Printf("Unknown review command: %s\n", subcommand)
+		reviewHelp()
+	}
+}
+
+func reviewHelp() {
+	fmt.Println("\nReview commands:")
+	fmt.Println("  run                 Review recent turns now and write a memory note")
+	fmt.Println("  list                Show pending prompt/skill edit proposals")
+	fmt.Println("  show <id>           Show a proposal's reason and diff")
+	fmt.Println("  apply <id>          Write a proposal to its file")
+	fmt.Println("  reject <id>         Discard a proposal")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --hours <n>         (run) Review the last n hours (default: 24)")
+	fmt.Println("  --all               (list) Include applied and rejected proposals")
+	fmt.Println()
+	fmt.Println("Enable self_review in the config to run the review nightly from the gateway.")
+}
+
+func reviewRunCmd(reviewer *review.Reviewer, hours int) {
+	fmt.Printf("Reviewing the last %d hours...\n", hours)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	result, err := reviewer.Run(ctx, time.Now().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if result.Turns == 0 {
+		fmt.Println("No turns to review.")
+		return
+	}
+
+	fmt.Printf("\nReviewed %d turns (%d 👎, %d errors).\n", result.Turns, result.ThumbsDown, result.Errors)
+	if result.Notes != "" {
+		fmt.Printf("\n%s\n", result.Notes)
+	}
+	if len(result.Proposals) == 0 {
+		fmt.Println("\nNo edits proposed.")
+		return
+	}
+	fmt.Println("\nProposed edits:")
+	for _, p := range result.Proposals {
+		fmt.Printf("  #%d %s: %s\n", p.ID, p.File, utils.Truncate(p.Reason, 70))
+	}
+	fmt.Println("\nUse 'picoclaw review show <id>' to see the diff.")
+}
+
+func reviewListCmd(store *review.Store, all bool) {
+	shown := 0
+	for _, p := range store.List() {
+		if !all && p.Status != review.StatusPending {
+			continue
+		}
+		if shown == 0 {
+			fmt.Println("\nProposals:")
+			fmt.Println("----------")
+		}
+		shown++
+		fmt.Printf("  #%-3d %-9s %s  %-24s %s\n", p.ID, p.Status, p.Created.Format("2006-01-02"),
+			p.File, utils.Truncate(p.Reason, 50))
+	}
+	if shown == 0 {
+		fmt.Println("No pending proposals.")
+	}
+}
+
+func reviewShowCmd(store *review.Store, id int) {
+	p, ok := store.Get(id)
+	if !ok {
+		fmt.Printf("Error: proposal %d not found\n", id)
+		return
+	}
+	fmt.Printf("\nProposal #%d (%s)\n", p.ID, p.Status)
+	fmt.Printf("File:    %s\n", p.File)
+	fmt.Printf("Created: %s\n", p.Created.Format("2006-01-02 15:04"))
+	if p.Reason != "" {
+		fmt.Printf("Reason:  %s\n", p.Reason)
+	}
+	fmt.Printf("\n%s", p.Diff)
+}
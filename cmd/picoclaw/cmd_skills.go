@@ -46,10 +46,20 @@ func skillsListCmd(loader *skills.SkillsLoader) {
 	fmt.Println("\nInstalled Skills:")
 	fmt.Println("------------------")
 	for _, skill := range allSkills {
-		fmt.Printf("  ✓ %s (%s)\n", skill.Name, skill.Source)
+		if skill.Version != "" {
+			fmt.Printf("  ✓ %s %s (%s)\n", skill.Name, skill.Version, skill.Source)
+		} else {
+			fmt.Printf("  ✓ %s (%s)\n", skill.Name, skill.Source)
+		}
 		if skill.Description != "" {
 			fmt.Printf("    %s\n", skill.Description)
 		}
+		if len(skill.Shadows) > 0 {
+			fmt.Printf("    overrides the %s skill of the same name\n", strings.Join(skill.Shadows, " and "))
+		}
+		if skill.Protected {
+			fmt.Println("    protected: workspace and global copies are ignored")
+		}
 	}
 }
 
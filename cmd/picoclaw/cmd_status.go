@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"github.com/sipeed/picoclaw/pkg/auth"
+	"github.com/sipeed/picoclaw/pkg/version"
 )
 
 func statusCmd() {
@@ -20,10 +21,12 @@ func statusCmd() {
 	configPath := getConfigPath()
 
 	fmt.Printf("%s picoclaw Status\n", logo)
-	fmt.Printf("Version: %s\n", formatVersion())
-	build, _ := formatBuildInfo()
-	if build != "" {
-		fmt.Printf("Build: %s\n", build)
+	fmt.Printf("Version: %s\n", version.String())
+	if version.BuildTime != "" {
+		fmt.Printf("Build: %s\n", version.BuildTime)
+	}
+	if check := version.LastCheck(cfg.WorkspacePath()); check.Available() {
+		fmt.Printf("Update: %s available on the %s channel\n", check.Latest, check.Channel)
 	}
 	fmt.Println()
 
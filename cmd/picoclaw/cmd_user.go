@@ -0,0 +1,135 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sipeed/picoclaw/pkg/agent"
+	"github.com/sipeed/picoclaw/pkg/privacy"
+)
+
+func userCmd() {
+	if len(os.Args) < 3 {
+		userHelp()
+		return
+	}
+
+	switch os.Args[2] {
+	case "purge":
+		userPurgeCmd()
+	default:
+		fmt.Printf("Unknown user command: %s\n", os.Args[2])
+		userHelp()
+	}
+}
+
+func userHelp() {
+	fmt.Println("\nUser commands:")
+	fmt.Println("  purge <id>          Delete all stored data about a user")
+	fmt.Println()
+	fmt.Println("Purge options:")
+	fmt.Println("  --alias <name>      Also scrub memory lines mentioning this name (repeatable)")
+	fmt.Println("  --dry-run           Show what would be deleted without deleting")
+	fmt.Println("  -y, --yes           Skip the confirmation prompt")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  picoclaw user purge 123456789 --dry-run")
+	fmt.Println("  picoclaw user purge 123456789 --alias Alice")
+}
+
+func userPurgeCmd() {
+	var userID string
+	var aliases []string
+	dryRun := false
+	skipConfirm := false
+
+	args := os.Args[3:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--alias":
+			if i+1 < len(args) {
+				aliases = append(aliases, args[i+1])
+				i++
+			}
+		case "--dry-run":
+			dryRun = true
+		case "-y", "--yes":
+			skipConfirm = true
+		default:
+			if userID == "" {
+				userID = args[i]
+			}
+		}
+	}
+
+	if userID == "" {
+		fmt.Println("Usage: picoclaw user purge <id> [--alias <name>] [--dry-run] [--yes]")
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+
+	// Collect one purger per distinct agent workspace
+	registry := agent.NewAgentRegistry(cfg, nil)
+	var purgers []*privacy.Purger
+	var workspaces []string
+	seen := make(map[string]bool)
+	for _, id := range registry.ListAgentIDs() {
+		a, ok := registry.GetAgent(id)
+		if !ok || seen[a.Workspace] {
+			continue
+		}
+		seen[a.Workspace] = true
+		workspaces = append(workspaces, a.Workspace)
+		purgers = append(purgers, agent.NewUserPurger(a, nil))
+	}
+
+	empty := true
+	for i, p := range purgers {
+		report, err := p.Plan(userID, aliases)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if report.Empty() {
+			continue
+		}
+		empty = false
+		fmt.Printf("\n%s\n", workspaces[i])
+		fmt.Print(report)
+	}
+
+	if empty {
+		fmt.Printf("No stored data found for user %s.\n", userID)
+		return
+	}
+	if dryRun {
+		fmt.Println("\nDry run: nothing was deleted.")
+		return
+	}
+
+	if !skipConfirm {
+		fmt.Printf("\nThis permanently deletes the data above. Type the user ID to confirm: ")
+		var response string
+		fmt.Scanln(&response)
+		if response != userID {
+			fmt.Println("Aborted.")
+			return
+		}
+	}
+
+	for i, p := range purgers {
+		if _, err := p.Purge(userID, aliases); err != nil {
+			fmt.Printf("Error purging %s: %v\n", workspaces[i], err)
+			return
+		}
+	}
+	fmt.Printf("✓ Purged data for user %s\n", userID)
+}
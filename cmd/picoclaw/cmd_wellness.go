@@ -0,0 +1,118 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/wellness"
+)
+
+func wellnessCmd() {
+	if len(os.Args) < 3 {
+		wellnessHelp()
+		return
+	}
+
+	subcommand := os.Args[2]
+	user := ""
+	days := 30
+	var files []string
+	args := os.Args[3:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--user":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --user requires a value")
+				return
+			}
+			user = args[i+1]
+			i++
+		case "--days":
+			if i+1 >= len(args) {
+				fmt.Println("Error: --days requires a value")
+				return
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n <= 0 {
+				fmt.Printf("Error: invalid --days value %q\n", args[i+1])
+				return
+			}
+			days = n
+			i++
+		default:
+			files = append(files, args[i])
+		}
+	}
+	if user == "" {
+		fmt.Println("Error: --user is required (the user's sender ID on the chat channel)")
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return
+	}
+	store := wellness.NewStore(cfg.WorkspacePath())
+
+	switch subcommand {
+	case "import":
+		if len(files) == 0 {
+			fmt.Println("Usage: picoclaw wellness import <file>... --user <id>")
+			return
+		}
+		for _, file := range files {
+			records, err := wellness.ImportFile(file)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			added, err := store.Add(user, records...)
+			if err != nil {
+				fmt.Printf("Error saving %s: %v\n", file, err)
+				return
+			}
+			fmt.Printf("✓ %s: %d records read, %d new\n", file, len(records), added)
+		}
+	case "report":
+		records, err := store.Records(user)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		if len(records) == 0 {
+			fmt.Println("No health data for this user.")
+			return
+		}
+		now := time.Now()
+		if note := wellness.Note(records, now); note != "" {
+			fmt.Printf("%s\n\n", note)
+		}
+		for _, kind := range []string{wellness.Sleep, wellness.Weight, wellness.Workout} {
+			fmt.Println(wellness.ComputeTrend(records, kind, days, now).Format())
+		}
+	default:
+		fmt.Printf("Unknown wellness command: %s\n", subcommand)
+		wellnessHelp()
+	}
+}
+
+func wellnessHelp() {
+	fmt.Println("\nWellness commands:")
+	fmt.Println("  import <file>...    Import an Apple Health export (.zip/.xml) or Garmin activities CSV")
+	fmt.Println("  report              Show sleep, weight and workout trends")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --user <id>         Whose data: the user's sender ID on the chat channel (required)")
+	fmt.Println("  --days <N>          Report window in days (default: 30)")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  picoclaw wellness import export.zip --user 123456789")
+	fmt.Println("  picoclaw wellness import Activities.csv --user 123456789")
+	fmt.Println("  picoclaw wellness report --user 123456789 --days 90")
+}
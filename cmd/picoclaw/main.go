@@ -11,51 +11,23 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"runtime"
+	"strings"
 
 	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/resources"
 	"github.com/sipeed/picoclaw/pkg/skills"
-)
-
-var (
-	version   = "dev"
-	gitCommit string
-	buildTime string
-	goVersion string
+	"github.com/sipeed/picoclaw/pkg/version"
 )
 
 const logo = "🦞"
 
-// formatVersion returns the version string with optional git commit
-func formatVersion() string {
-	v := version
-	if gitCommit != "" {
-		v += fmt.Sprintf(" (git: %s)", gitCommit)
-	}
-	return v
-}
-
-// formatBuildInfo returns build time and go version info
-func formatBuildInfo() (build string, goVer string) {
-	if buildTime != "" {
-		build = buildTime
-	}
-	goVer = goVersion
-	if goVer == "" {
-		goVer = runtime.Version()
-	}
-	return
-}
-
 func printVersion() {
-	fmt.Printf("%s picoclaw %s\n", logo, formatVersion())
-	build, goVer := formatBuildInfo()
-	if build != "" {
-		fmt.Printf("  Build: %s\n", build)
-	}
-	if goVer != "" {
-		fmt.Printf("  Go: %s\n", goVer)
+	fmt.Printf("%s picoclaw %s\n", logo, version.String())
+	if version.BuildTime != "" {
+		fmt.Printf("  Build: %s\n", version.BuildTime)
 	}
+	fmt.Printf("  Go: %s\n", version.Go())
 }
 
 func copyDirectory(src, dst string) error {
@@ -115,6 +87,28 @@ func main() {
 		authCmd()
 	case "cron":
 		cronCmd()
+	case "memory":
+		memoryCmd()
+	case "user":
+		userCmd()
+	case "pair":
+		pairCmd()
+	case "identity":
+		identityCmd()
+	case "announce":
+		announceCmd()
+	case "maintenance":
+		maintenanceCmd()
+	case "canary":
+		canaryCmd()
+	case "feedback":
+		feedbackCmd()
+	case "review":
+		reviewCmd()
+	case "debug":
+		debugCmd()
+	case "wellness":
+		wellnessCmd()
 	case "skills":
 		if len(os.Args) < 3 {
 			skillsHelp()
@@ -136,6 +130,7 @@ func main() {
 		globalSkillsDir := filepath.Join(globalDir, "skills")
 		builtinSkillsDir := filepath.Join(globalDir, "picoclaw", "skills")
 		skillsLoader := skills.NewSkillsLoader(workspace, globalSkillsDir, builtinSkillsDir)
+		skillsLoader.SetShadowing(cfg.Tools.Skills.ShadowWarnings, cfg.Tools.Skills.Protected)
 
 		switch subcommand {
 		case "list":
@@ -174,7 +169,7 @@ func main() {
 }
 
 func printHelp() {
-	fmt.Printf("%s picoclaw - Personal AI Assistant v%s\n\n", logo, version)
+	fmt.Printf("%s picoclaw - Personal AI Assistant v%s\n\n", logo, version.Version)
 	fmt.Println("Usage: picoclaw <command>")
 	fmt.Println()
 	fmt.Println("Commands:")
@@ -184,16 +179,80 @@ func printHelp() {
 	fmt.Println("  gateway     Start picoclaw gateway")
 	fmt.Println("  status      Show picoclaw status")
 	fmt.Println("  cron        Manage scheduled tasks")
+	fmt.Println("  memory      Browse and curate agent memory")
+	fmt.Println("  user        Manage stored user data (purge)")
+	fmt.Println("  pair        Approve a user's pairing code, or list requests")
+	fmt.Println("  identity    Link a user's accounts on different channels")
+	fmt.Println("  announce    Broadcast a message to opted-in chats")
+	fmt.Println("  maintenance Pause processing and queue messages (on, off, status)")
+	fmt.Println("  canary      Compare the default model with a canary model")
+	fmt.Println("  feedback    List or export user 👍/👎 feedback")
+	fmt.Println("  review      Run the self-review and approve proposed edits")
+	fmt.Println("  debug       List turns and replay one from the audit and wire logs")
+	fmt.Println("  wellness    Import health data and show trends")
 	fmt.Println("  migrate     Migrate from OpenClaw to PicoClaw")
 	fmt.Println("  skills      Manage skills (install, list, remove)")
 	fmt.Println("  version     Show version information")
 }
 
 func getConfigPath() string {
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".picoclaw", "config.json")
+	return config.ConfigPath()
 }
 
 func loadConfig() (*config.Config, error) {
-	return config.LoadConfig(getConfigPath())
+	cfg, err := config.LoadConfig(getConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	logger.SetJSON(cfg.Log.Format == "json")
+	return cfg, nil
+}
+
+// printStatus prints a line of startup or shutdown output. With JSON logs
+// it is logged at level instead, so stdout stays one JSON entry per line.
+func printStatus(level logger.LogLevel, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if !logger.JSON() {
+		fmt.Println(msg)
+		return
+	}
+	msg = strings.TrimLeft(msg, "\n ✓⚠️🔍")
+	switch level {
+	case logger.ERROR:
+		logger.ErrorC("gateway", msg)
+	case logger.WARN:
+		logger.WarnC("gateway", msg)
+	default:
+		logger.InfoC("gateway", msg)
+	}
+}
+
+// guardResources turns on low-memory mode when the machine needs it, for
+// the commands that run the agent.
+func guardResources(cfg *config.Config) {
+	r := resources.Guard(cfg)
+	if !r.LowMemory {
+		return
+	}
+	// JSON logs get only the log entries below
+	if !logger.JSON() {
+		if r.Detected {
+			fmt.Printf("🪶 Low-memory mode (%d MB, %s)\n", r.Memory.MB(), r.Memory.Source)
+		} else {
+			fmt.Println("🪶 Low-memory mode")
+		}
+	}
+	logger.InfoCF("resources", "Low-memory mode", map[string]interface{}{
+		"memory_mb": r.Memory.MB(),
+		"source":    r.Memory.Source,
+		"changes":   r.Changes,
+	})
+	for _, w := range r.Warnings {
+		if !logger.JSON() {
+			fmt.Printf("  ⚠️  %s\n", w)
+		}
+		logger.WarnCF("resources", "Heavy feature in low-memory mode", map[string]interface{}{
+			"warning": w,
+		})
+	}
 }
@@ -0,0 +1,329 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/version"
+)
+
+// adminCommands are the commands only users in admin.users may run. They
+// are typed as "!status" or "/status", and answered privately.
+var adminCommands = map[string]bool{
+	"status":   true,
+	"reload":   true,
+	"allow":    true,
+	"revoke":   true,
+	"skills":   true,
+	"model":    true,
+	"selftest": true,
+}
+
+// parseAdminCommand splits an admin command into its name and arguments.
+func parseAdminCommand(content string) (string, []string, bool) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 || len(fields[0]) < 2 {
+		return "", nil, false
+	}
+	if prefix := fields[0][0]; prefix != '!' && prefix != '/' {
+		return "", nil, false
+	}
+	name := strings.ToLower(fields[0][1:])
+	if !adminCommands[name] {
+		return "", nil, false
+	}
+	return name, fields[1:], true
+}
+
+// handleAdminCommand runs an admin command and sends the result to its
+// sender alone, reporting whether msg was one.
+func (al *AgentLoop) handleAdminCommand(ctx context.Context, msg bus.InboundMessage) bool {
+	name, args, ok := parseAdminCommand(msg.Content)
+	if !ok {
+		return false
+	}
+
+	reply := "Only admins can use this command."
+	if isAdmin(al.cfg.Admin.Users, msg) {
+		logger.InfoCF("agent", "Admin command", map[string]interface{}{
+			"command":   name,
+			"channel":   msg.Channel,
+			"sender_id": msg.SenderID,
+		})
+		reply = al.runAdminCommand(ctx, msg, name, args)
+	}
+
+	al.bus.PublishOutbound(bus.OutboundMessage{
+		Channel:   msg.Channel,
+		ChatID:    msg.ChatID,
+		Content:   reply,
+		PrivateTo: senderUserID(msg),
+	})
+	return true
+}
+
+func (al *AgentLoop) runAdminCommand(ctx context.Context, msg bus.InboundMessage, name string, args []string) string {
+	switch name {
+	case "status":
+		return al.adminStatus()
+	case "reload":
+		return al.adminReload()
+	case "allow":
+		return al.adminAllow(msg, args)
+	case "revoke":
+		return al.adminRevoke(msg, args)
+	case "skills":
+		if len(args) > 0 && args[0] != "list" {
+			return "Usage: !skills list"
+		}
+		return al.adminSkills()
+	case "model":
+		return al.adminModel(args)
+	case "selftest":
+		return al.adminSelfTest(ctx)
+	}
+	return ""
+}
+
+func (al *AgentLoop) adminStatus() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "picoclaw %s, up %s\n", version.String(), time.Since(al.started).Round(time.Second))
+	if agent := al.registry.GetDefaultAgent(); agent != nil {
+		_, model := agent.CurrentModel()
+		fmt.Fprintf(&sb, "Model: %s\n", model)
+	}
+	fmt.Fprintf(&sb, "Agents: %s\n", strings.Join(al.registry.ListAgentIDs(), ", "))
+	if al.channelManager != nil {
+		channels := al.channelManager.GetEnabledChannels()
+		sort.Strings(channels)
+		fmt.Fprintf(&sb, "Channels: %s\n", strings.Join(channels, ", "))
+	}
+	if al.maintenance != nil && al.maintenance.Status().Enabled {
+		sb.WriteString("Maintenance: on\n")
+	}
+	if u := al.usageOn(time.Now()); u != nil {
+		fmt.Fprintf(&sb, "Usage today: %d requests, %d prompt and %d completion tokens\n", u[0], u[1], u[2])
+	}
+	for _, k := range providers.KeyUsage() {
+		fmt.Fprintf(&sb, "Key %s %s: %d requests, %d failed, %d tokens", k.Provider, k.Key, k.Requests, k.Failures, k.PromptTokens+k.CompletionTokens)
+		switch {
+		case k.Rejected:
+			sb.WriteString(", rejected")
+		case !k.RestingUntil.IsZero():
+			fmt.Fprintf(&sb, ", resting for %s", time.Until(k.RestingUntil).Round(time.Second))
+		case k.Current:
+			sb.WriteString(", in use")
+		}
+		sb.WriteString("\n")
+	}
+	if al.configPath != "" {
+		fmt.Fprintf(&sb, "Config: %s", al.configPath)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// adminReload re-reads the config file and applies what can change while
+// running: the admin users and the channels' allowlists.
+func (al *AgentLoop) adminReload() string {
+	if al.configPath == "" {
+		return "Reload is only available in the gateway."
+	}
+	cfg, err := config.LoadConfig(al.configPath)
+	if err != nil {
+		return fmt.Sprintf("Failed to reload %s: %v", al.configPath, err)
+	}
+	al.cfg.Admin = cfg.Admin
+
+	var updated []string
+	if al.channelManager != nil {
+		updated = al.channelManager.ReloadAllowLists(&cfg.Channels)
+	}
+	reply := fmt.Sprintf("Reloaded admin users (%d)", len(cfg.Admin.Users))
+	if len(updated) > 0 {
+		reply += " and the allowlists of " + strings.Join(updated, ", ")
+	}
+	keys, err := providers.ReloadKeys(cfg)
+	if len(keys) > 0 {
+		reply += ", and the API keys of " + strings.Join(keys, ", ")
+	}
+	reply += ". Other settings take effect after a restart."
+	if err != nil {
+		reply += fmt.Sprintf("\nFailed to reload some API keys: %v", err)
+	}
+	return reply
+}
+
+// adminAllow lets a user in, or lists the allowlist without arguments.
+func (al *AgentLoop) adminAllow(msg bus.InboundMessage, args []string) string {
+	if len(args) > 2 {
+		return "Usage: !allow [user ID] [channel]"
+	}
+	if al.channelManager == nil {
+		return "Channel manager not initialized"
+	}
+	if len(args) == 0 {
+		allow, err := al.channelManager.AllowList(msg.Channel)
+		switch {
+		case err != nil:
+			return err.Error()
+		case len(allow) == 0:
+			return fmt.Sprintf("%s has no allowlist: everyone can use the bot.", msg.Channel)
+		}
+		return fmt.Sprintf("Allowed on %s: %s", msg.Channel, strings.Join(allow, ", "))
+	}
+
+	userID, channel := adminUserArgs(msg, args)
+	added, err := al.channelManager.AddAllowedUser(channel, userID)
+	switch {
+	case added && err != nil:
+		return fmt.Sprintf("Allowed %s on %s, but the config wasn't saved: %v", userID, channel, err)
+	case err != nil:
+		return fmt.Sprintf("Can't allow %s: %v", userID, err)
+	case !added:
+		return fmt.Sprintf("%s can already use %s.", userID, channel)
+	}
+	return fmt.Sprintf("Allowed %s on %s.", userID, channel)
+}
+
+func (al *AgentLoop) adminRevoke(msg bus.InboundMessage, args []string) string {
+	if len(args) == 0 || len(args) > 2 {
+		return "Usage: !revoke <user ID> [channel]"
+	}
+	if al.channelManager == nil {
+		return "Channel manager not initialized"
+	}
+
+	userID, channel := adminUserArgs(msg, args)
+	removed, err := al.channelManager.RemoveAllowedUser(channel, userID)
+	switch {
+	case removed && err != nil:
+		return fmt.Sprintf("Removed %s from %s, but the config wasn't saved: %v", userID, channel, err)
+	case err != nil:
+		return fmt.Sprintf("Can't remove %s: %v", userID, err)
+	case !removed:
+		return fmt.Sprintf("%s isn't in the allowlist of %s.", userID, channel)
+	}
+	return fmt.Sprintf("Removed %s from %s.", userID, channel)
+}
+
+// adminUserArgs reads "<user ID> [channel]", defaulting to the channel the
+// command came from.
+func adminUserArgs(msg bus.InboundMessage, args []string) (string, string) {
+	channel := msg.Channel
+	if len(args) == 2 {
+		channel = args[1]
+	}
+	return strings.TrimPrefix(args[0], "@"), channel
+}
+
+func (al *AgentLoop) adminSkills() string {
+	agent := al.registry.GetDefaultAgent()
+	if agent == nil {
+		return "No default agent configured"
+	}
+	skills := agent.ContextBuilder.ListSkills()
+	if len(skills) == 0 {
+		return "No skills installed."
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d skills:", len(skills))
+	for _, s := range skills {
+		fmt.Fprintf(&sb, "\n- %s", s.Name)
+		if s.Version != "" {
+			fmt.Fprintf(&sb, " %s", s.Version)
+		}
+		fmt.Fprintf(&sb, " (%s)", s.Source)
+		if s.Description != "" {
+			fmt.Fprintf(&sb, ": %s", s.Description)
+		}
+	}
+	return sb.String()
+}
+
+// adminModel switches the default agent's model. Models from model_list
+// get their own provider, so one on another API works too.
+func (al *AgentLoop) adminModel(args []string) string {
+	agent := al.registry.GetDefaultAgent()
+	if agent == nil {
+		return "No default agent configured"
+	}
+	current, old := agent.CurrentModel()
+	if len(args) == 0 {
+		return fmt.Sprintf("Current model: %s", old)
+	}
+	name := args[0]
+
+	provider, model := current, name
+	if len(al.cfg.ModelList) > 0 {
+		modelCfg, err := al.cfg.GetModelConfig(name)
+		if err != nil {
+			return fmt.Sprintf("Unknown model %s: %v", name, err)
+		}
+		if modelCfg.Workspace == "" {
+			modelCfg.Workspace = al.cfg.WorkspacePath()
+		}
+		created, modelID, err := providers.CreateProviderFromConfig(modelCfg)
+		if err != nil {
+			return fmt.Sprintf("Failed to set up %s: %v", name, err)
+		}
+		provider = providers.WrapWireLog(created, al.cfg.WireLog, al.cfg.WorkspacePath())
+		if agent.Minimal {
+			provider = providers.WithPromptedTools(provider)
+		}
+		model = modelID
+	}
+
+	agent.SwitchModel(provider, model)
+	return fmt.Sprintf("Switched model from %s to %s until the next restart.", old, model)
+}
+
+// unverifiedSenders are the channels whose callers name the sender
+// themselves, so no sender on them can be an admin.
+var unverifiedSenders = map[string]bool{
+	"webhook":   true,
+	"websocket": true,
+	"mqtt":      true,
+	"api":       true,
+}
+
+// isAdmin reports whether the sender of msg is listed in admins as
+// "channel:id", matching the user ID the platform gives, never a username
+// (which can change hands) or metadata. People relayed by a bridge bot,
+// whose IDs carry the bridge's name, don't qualify either.
+func isAdmin(admins []string, msg bus.InboundMessage) bool {
+	if unverifiedSenders[msg.Channel] {
+		return false
+	}
+	id, _, _ := strings.Cut(msg.SenderID, "|")
+	if id == "" || strings.Contains(id, ":") {
+		return false
+	}
+	for _, entry := range admins {
+		channel, entryID, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if ok && channel == msg.Channel && entryID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// senderUserID returns the platform user ID of the sender of msg.
+func senderUserID(msg bus.InboundMessage) string {
+	if id := msg.Metadata["user_id"]; id != "" {
+		return id
+	}
+	id, _, _ := strings.Cut(msg.SenderID, "|")
+	return id
+}
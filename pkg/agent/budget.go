@@ -0,0 +1,150 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sipeed/picoclaw/pkg/privacy"
+)
+
+// BudgetSection is one part of the context sent with every request.
+type BudgetSection struct {
+	Name   string `json:"name"`
+	Tokens int    `json:"tokens"`
+}
+
+// ContextBudget reports how a session's next request fills the context
+// window, in estimated tokens. Reserve is the headroom kept free by
+// summarizing the history once it passes 75% of the window.
+type ContextBudget struct {
+	Agent         string          `json:"agent"`
+	SessionKey    string          `json:"session_key,omitempty"`
+	ContextWindow int             `json:"context_window"`
+	Sections      []BudgetSection `json:"sections"`
+	Used          int             `json:"used"`
+	Reserve       int             `json:"reserve"`
+	Free          int             `json:"free"`
+}
+
+// estimateTextTokens estimates tokens the way estimateTokens does.
+func estimateTextTokens(s string) int {
+	return utf8.RuneCountInString(s) * 2 / 5
+}
+
+// contextBudget measures each part of the prompt the agent would build for
+// a session with persona's bootstrap files.
+func (al *AgentLoop) contextBudget(agent *AgentInstance, sessionKey, persona string) ContextBudget {
+	cb := agent.ContextBuilder
+	toolList := cb.buildToolsSection()
+	toolDefs, _ := json.Marshal(agent.Tools.ToProviderDefs())
+	memory := privacy.LoadTombstones(cb.workspace).Redact(cb.memory.GetMemoryContext())
+
+	sections := []BudgetSection{
+		{Name: "system prompt", Tokens: estimateTextTokens(cb.getIdentity()) - estimateTextTokens(toolList)},
+		{Name: "tool list", Tokens: estimateTextTokens(toolList)},
+		{Name: "tool definitions", Tokens: estimateTextTokens(string(toolDefs))},
+		{Name: "bootstrap files", Tokens: estimateTextTokens(cb.LoadBootstrapFiles(persona))},
+		{Name: "skills summary", Tokens: estimateTextTokens(cb.skillsLoader.BuildSkillsSummary())},
+		{Name: "memory", Tokens: estimateTextTokens(memory)},
+	}
+	if sessionKey != "" {
+		sections = append(sections,
+			BudgetSection{Name: "conversation summary", Tokens: estimateTextTokens(agent.Sessions.GetSummary(sessionKey))},
+			BudgetSection{Name: "history", Tokens: al.estimateTokens(agent.Sessions.GetHistory(sessionKey))},
+		)
+	}
+
+	budget := ContextBudget{
+		Agent:         agent.ID,
+		SessionKey:    sessionKey,
+		ContextWindow: agent.ContextWindow,
+		Sections:      sections,
+		Reserve:       agent.ContextWindow - agent.ContextWindow*75/100,
+	}
+	for _, s := range sections {
+		budget.Used += s.Tokens
+	}
+	budget.Free = max(0, budget.ContextWindow-budget.Used-budget.Reserve)
+	return budget
+}
+
+// String lays the budget out as a table for chat.
+func (b ContextBudget) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Context budget of %s (~tokens of %d):\n```\n", b.Agent, b.ContextWindow)
+	row := func(name string, tokens int) {
+		pct := 0.0
+		if b.ContextWindow > 0 {
+			pct = float64(tokens) * 100 / float64(b.ContextWindow)
+		}
+		fmt.Fprintf(&sb, "%-22s %7d %5.1f%%\n", name, tokens, pct)
+	}
+	for _, s := range b.Sections {
+		row(s.Name, s.Tokens)
+	}
+	row("used", b.Used)
+	row("reserve", b.Reserve)
+	row("free", b.Free)
+	sb.WriteString("```")
+	if b.Used+b.Reserve > b.ContextWindow {
+		sb.WriteString("\nThe prompt already reaches into the reserve, leaving little room for the conversation. Lower bootstrap_max_chars or trim the largest sections.")
+	}
+	return sb.String()
+}
+
+// isBudgetCommand reports whether content is a !budget command.
+func isBudgetCommand(content string) bool {
+	fields := strings.Fields(content)
+	return len(fields) == 1 && strings.EqualFold(fields[0], "!budget")
+}
+
+// ContextBudget reports the context budget of an agent ("" for the
+// default), for a session when sessionKey is set.
+func (al *AgentLoop) ContextBudget(agentID, sessionKey, persona string) (ContextBudget, error) {
+	agent := al.registry.GetDefaultAgent()
+	if agentID != "" {
+		var ok bool
+		if agent, ok = al.registry.GetAgent(agentID); !ok {
+			return ContextBudget{}, fmt.Errorf("unknown agent %s", agentID)
+		}
+	}
+	if agent == nil {
+		return ContextBudget{}, fmt.Errorf("no default agent configured")
+	}
+	return al.contextBudget(agent, sessionKey, persona), nil
+}
+
+// BudgetHandler serves GET /admin/budget?agent=&session=&persona= with
+// the context budget as JSON. Requests must carry token as a bearer token.
+func (al *AgentLoop) BudgetHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+		budget, err := al.ContextBudget(q.Get("agent"), q.Get("session"), q.Get("persona"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(budget)
+	})
+}
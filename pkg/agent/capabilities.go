@@ -0,0 +1,60 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"sort"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// capabilities reports what agent can do for the get_capabilities tool.
+// Only settings picked here are shown, so secrets in the config can't leak.
+func (al *AgentLoop) capabilities(agent *AgentInstance) tools.Capabilities {
+	defaults := al.cfg.Agents.Defaults
+
+	channels := []string{"cli"}
+	if al.channelManager != nil {
+		channels = al.channelManager.GetEnabledChannels()
+		sort.Strings(channels)
+	}
+
+	toolNames := agent.Tools.List()
+	sort.Strings(toolNames)
+
+	var skillNames []string
+	for _, s := range agent.ContextBuilder.ListSkills() {
+		skillNames = append(skillNames, s.Name)
+	}
+
+	_, model := agent.CurrentModel()
+	return tools.Capabilities{
+		Agent:          agent.ID,
+		Model:          model,
+		FallbackModels: agent.Fallbacks,
+		Channels:       channels,
+		Tools:          toolNames,
+		Skills:         skillNames,
+		Limits: map[string]interface{}{
+			"max_tool_iterations":   agent.MaxIterations,
+			"max_tokens":            agent.MaxTokens,
+			"context_window":        agent.ContextWindow,
+			"turn_timeout_seconds":  al.cfg.Timeouts.Turn,
+			"turn_max_calls":        defaults.TurnLimits.MaxCalls,
+			"turn_max_cost_usd":     defaults.TurnLimits.MaxCostUSD,
+			"messages_per_minute":   al.cfg.Channels.RateLimit.UserPerMinute,
+			"restrict_to_workspace": defaults.RestrictToWorkspace,
+		},
+		Features: map[string]interface{}{
+			"citations":               al.cfg.Tools.Web.Citations.Enabled,
+			"topic_checkpoints":       defaults.TopicCheckpoints,
+			"quote_guard":             defaults.QuoteGuard,
+			"tools_needing_approval":  []string(al.cfg.Tools.Confirm.Tools),
+			"proactive_default_level": al.cfg.Proactive.DefaultLevel,
+			"audit_log":               al.cfg.Audit.Enabled,
+		},
+	}
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// chatSummaryPrompts summarize a chat transcript whose lines are
+// "[date time] name: text".
+var chatSummaryPrompts = tools.SummaryPrompts{
+	Part: `Summarize this part of a group chat. Each line is "[date time] name: message".
+List the topics discussed in order with who said what that matters, and note any decisions, questions left open and action items with their owners.%s
+
+CHAT:
+%s`,
+	Merge: `These are summaries of consecutive parts of one group chat. Combine them into one structured summary in Markdown with these sections:
+
+## TL;DR
+One or two sentences.
+
+## Topics
+One bullet per topic, in order, with the main points and who made them.
+
+## Decisions
+Bullets, or "None".
+
+## Open Questions
+Bullets, or "None".
+
+## Action Items
+"- Owner: task" bullets, or "None".%s
+
+PART SUMMARIES:
+%s`,
+}
+
+// summarizeChat summarizes the transcript of a bus.ControlSummarize
+// event. The summary isn't added to the session.
+func (al *AgentLoop) summarizeChat(ctx context.Context, agent *AgentInstance, msg bus.InboundMessage) string {
+	lines := strings.Split(strings.TrimSpace(msg.Content), "\n")
+	if len(lines) == 0 || lines[0] == "" {
+		return "There's nothing here to summarize."
+	}
+
+	summaryCtx, cancel := al.turnContext(ctx)
+	defer cancel()
+	summary, err := al.summarizeLines(summaryCtx, agent, lines, "")
+	if err != nil {
+		return "Sorry, I couldn't summarize the conversation."
+	}
+
+	scope := msg.Metadata["summary_scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("the last %d messages", len(lines))
+	}
+	return fmt.Sprintf("**Summary of %s**\n\n%s", scope, summary)
+}
+
+// SummarizeTranscript summarizes a chat transcript with the default agent,
+// for channels that summarize on their own schedule such as the Discord
+// moderator digest. focus, if set, is an extra instruction for the summary.
+func (al *AgentLoop) SummarizeTranscript(ctx context.Context, transcript, focus string) (string, error) {
+	agent := al.registry.GetDefaultAgent()
+	if agent == nil {
+		return "", fmt.Errorf("no agent configured")
+	}
+	lines := strings.Split(strings.TrimSpace(transcript), "\n")
+	if len(lines) == 0 || lines[0] == "" {
+		return "", fmt.Errorf("empty transcript")
+	}
+	summaryCtx, cancel := al.turnContext(ctx)
+	defer cancel()
+	return al.summarizeLines(summaryCtx, agent, lines, focus)
+}
+
+func (al *AgentLoop) summarizeLines(ctx context.Context, agent *AgentInstance, lines []string, focus string) (string, error) {
+	provider, model := agent.CurrentModel()
+	summary, err := tools.SummarizeMultipart(ctx, provider, model, tools.SplitLines(lines, tools.SummaryPartChars), chatSummaryPrompts, focus)
+	if err != nil {
+		logger.WarnCF("agent", "Chat summary failed", map[string]interface{}{
+			"agent_id": agent.ID,
+			"messages": len(lines),
+			"error":    err.Error(),
+		})
+	}
+	return summary, err
+}
@@ -0,0 +1,138 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+var (
+	// searchResultRe matches one web_search result: "3. Title" with the
+	// URL on the next line.
+	searchResultRe = regexp.MustCompile(`(?m)^(\d+)\. (.*)\n\s+(https?://\S+)`)
+	citationRe     = regexp.MustCompile(`\[(\d+)\]`)
+)
+
+// citations numbers the web pages a turn searched for or fetched, asks the
+// model to cite them as [n] after the claims they support, and lists the
+// cited ones under the reply.
+type citations struct {
+	max     int
+	sources []citation
+}
+
+type citation struct {
+	url     string
+	title   string
+	fetched bool
+}
+
+// newCitations returns nil when citations are off.
+func newCitations(cfg config.CitationsConfig) *citations {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &citations{max: cfg.MaxSources}
+}
+
+// observe numbers the sources in a web tool's result and returns the
+// result as the model should see it.
+func (c *citations) observe(tc providers.ToolCall, result *tools.ToolResult, content string) string {
+	if c == nil || result.IsError {
+		return content
+	}
+	switch tc.Name {
+	case "web_fetch":
+		url, _ := tc.Arguments["url"].(string)
+		if url == "" {
+			return content
+		}
+		n := c.add(url, "", true)
+		return fmt.Sprintf("%s\n[This page is source [%d]; cite it as [%d] after claims based on it.]", content, n, n)
+	case "web_search":
+		found := false
+		content = searchResultRe.ReplaceAllStringFunc(content, func(m string) string {
+			sub := searchResultRe.FindStringSubmatch(m)
+			n := c.add(sub[3], strings.TrimSpace(sub[2]), false)
+			found = true
+			return strings.Replace(m, sub[1]+". ", "["+strconv.Itoa(n)+"] ", 1)
+		})
+		if found {
+			content += "\n[Cite results as [n] after claims based on them.]"
+		}
+	}
+	return content
+}
+
+// add records a source, returning its number.
+func (c *citations) add(url, title string, fetched bool) int {
+	for i := range c.sources {
+		if c.sources[i].url == url {
+			c.sources[i].fetched = c.sources[i].fetched || fetched
+			if c.sources[i].title == "" {
+				c.sources[i].title = title
+			}
+			return i + 1
+		}
+	}
+	c.sources = append(c.sources, citation{url: url, title: title, fetched: fetched})
+	return len(c.sources)
+}
+
+// annotate appends the sources the reply is based on: the ones it cites,
+// or when it cites none, the pages fetched during the turn. Sources whose
+// URL is already in the reply aren't repeated.
+func (c *citations) annotate(reply string) string {
+	if c == nil || len(c.sources) == 0 || strings.TrimSpace(reply) == "" {
+		return reply
+	}
+
+	var numbers []int
+	seen := map[int]bool{}
+	for _, m := range citationRe.FindAllStringSubmatch(reply, -1) {
+		n, _ := strconv.Atoi(m[1])
+		if n >= 1 && n <= len(c.sources) && !seen[n] {
+			seen[n] = true
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Ints(numbers)
+	if len(numbers) == 0 {
+		for i, s := range c.sources {
+			if s.fetched {
+				numbers = append(numbers, i+1)
+			}
+		}
+	}
+
+	var lines []string
+	for _, n := range numbers {
+		if c.max > 0 && len(lines) == c.max {
+			break
+		}
+		s := c.sources[n-1]
+		if len(seen) == 0 && strings.Contains(reply, s.url) {
+			continue
+		}
+		line := fmt.Sprintf("[%d] %s", n, s.url)
+		if s.title != "" {
+			line = fmt.Sprintf("[%d] %s - %s", n, s.title, s.url)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return reply
+	}
+	return strings.TrimRight(reply, "\n") + "\n\nSources:\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,60 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/commands"
+)
+
+// Commands lists the slash commands of the default agent for channel
+// autocomplete: the builtins, then one per tool and one per skill. Skills
+// are read from disk, so the list changes as skills are installed.
+func (al *AgentLoop) Commands() []commands.Command {
+	cmds := commands.Builtin()
+	agent := al.registry.GetDefaultAgent()
+	if agent == nil {
+		return cmds
+	}
+
+	names := agent.Tools.List()
+	sort.Strings(names)
+	for _, name := range names {
+		if tool, ok := agent.Tools.Get(name); ok {
+			cmds = append(cmds, commands.FromTool(name, tool.Description(), tool.Parameters()))
+		}
+	}
+	for _, s := range agent.ContextBuilder.ListSkills() {
+		cmds = append(cmds, commands.FromSkill(s.Name, s.Description))
+	}
+	return commands.Dedupe(cmds)
+}
+
+// expandToolCommand turns "/<tool or skill> args", as sent by a channel's
+// slash command, into a request to use it. It reports false for anything
+// else, including the builtin commands.
+func (al *AgentLoop) expandToolCommand(content string) (string, bool) {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "/") {
+		return "", false
+	}
+	name, rest, _ := strings.Cut(content[1:], " ")
+	// Telegram appends the bot's username in groups: /weather@picoclaw_bot
+	name, _, _ = strings.Cut(name, "@")
+
+	cmd, ok := commands.Find(al.Commands(), strings.ToLower(name))
+	if !ok || cmd.Kind == commands.KindBuiltin {
+		return "", false
+	}
+	request := fmt.Sprintf("Use the %s %s", cmd.Target, cmd.Kind)
+	if rest = strings.TrimSpace(rest); rest != "" {
+		return request + " for this: " + rest, true
+	}
+	return request + ".", true
+}
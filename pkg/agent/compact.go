@@ -0,0 +1,154 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// compactedMarker starts every placeholder, so compacted text is never
+// compacted again.
+const compactedMarker = "[compacted: "
+
+// compactToolHistory shrinks tool call arguments and tool results longer
+// than maxChars in a finished session's history. The model needed them
+// verbatim while the turn ran; afterwards a reference such as "wrote 14KB
+// to notes.md" is enough, and the file itself can be read again.
+func (al *AgentLoop) compactToolHistory(agent *AgentInstance, sessionKey string) {
+	maxChars := al.cfg.Agents.Defaults.ToolHistoryMaxChars
+	if maxChars <= 0 {
+		return
+	}
+	history, saved := compactToolMessages(agent.Sessions.GetHistory(sessionKey), maxChars)
+	if saved == 0 {
+		return
+	}
+	agent.Sessions.SetHistory(sessionKey, history)
+	logger.DebugCF("agent", "Compacted tool history", map[string]interface{}{
+		"session_key": sessionKey,
+		"saved_chars": saved,
+	})
+}
+
+// compactToolMessages returns history with bulky tool arguments and
+// results replaced by short references, and how many characters it saved.
+func compactToolMessages(history []providers.Message, maxChars int) ([]providers.Message, int) {
+	out := make([]providers.Message, len(history))
+	copy(out, history)
+
+	saved := 0
+	calls := make(map[string]compactCall) // tool call ID -> call, to describe results
+	for i, msg := range out {
+		switch {
+		case msg.Role == "assistant" && len(msg.ToolCalls) > 0:
+			toolCalls := make([]providers.ToolCall, len(msg.ToolCalls))
+			for j, tc := range msg.ToolCalls {
+				call := compactCall{name: tc.Name}
+				if tc.Function != nil {
+					fn := *tc.Function
+					call.name = fn.Name
+					var args map[string]interface{}
+					if json.Unmarshal([]byte(fn.Arguments), &args) == nil {
+						call.path, _ = args["path"].(string)
+						if n := compactArgs(call.name, call.path, args, maxChars); n > 0 {
+							raw, _ := json.Marshal(args)
+							fn.Arguments = string(raw)
+							saved += n
+						}
+					}
+					tc.Function = &fn
+				}
+				if tc.Arguments != nil {
+					args := make(map[string]interface{}, len(tc.Arguments))
+					for k, v := range tc.Arguments {
+						args[k] = v
+					}
+					if call.path == "" {
+						call.path, _ = args["path"].(string)
+					}
+					saved += compactArgs(call.name, call.path, args, maxChars)
+					tc.Arguments = args
+				}
+				calls[tc.ID] = call
+				toolCalls[j] = tc
+			}
+			out[i].ToolCalls = toolCalls
+
+		case msg.Role == "tool" && len(msg.Content) > maxChars && !strings.HasPrefix(msg.Content, compactedMarker):
+			call := calls[msg.ToolCallID]
+			out[i].Content = compactResult(call, msg.Content, maxChars)
+			saved += len(msg.Content) - len(out[i].Content)
+		}
+	}
+	return out, saved
+}
+
+// compactCall is what a result's description needs to know of its call.
+type compactCall struct {
+	name string
+	path string
+}
+
+// compactArgs replaces string arguments longer than maxChars in place and
+// returns how many characters that saved.
+func compactArgs(tool, path string, args map[string]interface{}, maxChars int) int {
+	saved := 0
+	for key, v := range args {
+		s, ok := v.(string)
+		if !ok || len(s) <= maxChars || strings.HasPrefix(s, compactedMarker) {
+			continue
+		}
+		var ref string
+		switch {
+		case path != "" && key == "content" && (tool == "write_file" || tool == "append_file"):
+			verb := "wrote"
+			if tool == "append_file" {
+				verb = "appended"
+			}
+			ref = fmt.Sprintf("%s%s %s to %s]", compactedMarker, verb, formatSize(len(s)), path)
+		case path != "":
+			ref = fmt.Sprintf("%s%s of %s for %s]", compactedMarker, formatSize(len(s)), key, path)
+		default:
+			ref = fmt.Sprintf("%s%s of %s omitted]", compactedMarker, formatSize(len(s)), key)
+		}
+		args[key] = ref
+		saved += len(s) - len(ref)
+	}
+	return saved
+}
+
+// compactResult keeps the start of a tool result and a note of what was
+// dropped.
+func compactResult(call compactCall, content string, maxChars int) string {
+	head := content
+	if keep := maxChars / 4; len(head) > keep {
+		head = head[:keep]
+		for !utf8.ValidString(head) {
+			head = head[:len(head)-1]
+		}
+	}
+	what := formatSize(len(content))
+	if call.path != "" {
+		what += " from " + call.path
+	}
+	if call.name != "" {
+		what += " (" + call.name + ")"
+	}
+	return fmt.Sprintf("%sresult of %s, read it again if needed]\n%s…", compactedMarker, what, head)
+}
+
+func formatSize(n int) string {
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+	return fmt.Sprintf("%dKB", (n+512)/1024)
+}
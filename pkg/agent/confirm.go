@@ -0,0 +1,106 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/channels"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/tools"
+	"github.com/sipeed/picoclaw/pkg/utils"
+	"github.com/sipeed/picoclaw/pkg/workflow"
+)
+
+// confirmTool asks the user to approve a call to a tool listed in
+// tools.confirm. It returns nil when the call may run, or the result to
+// give the LLM instead. Calls run unasked on channels without buttons.
+func (al *AgentLoop) confirmTool(ctx context.Context, tc providers.ToolCall, opts processOptions) *tools.ToolResult {
+	if !al.cfg.Tools.Confirm.Requires(tc.Name) || al.channelManager == nil {
+		return nil
+	}
+	ch, ok := al.channelManager.GetChannel(opts.Channel)
+	if !ok {
+		return nil
+	}
+	confirmer, ok := ch.(channels.ConfirmingChannel)
+	if !ok {
+		return nil
+	}
+
+	timeout := time.Duration(al.cfg.Tools.Confirm.Timeout) * time.Second
+	confirmCtx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+
+	approved, err := confirmer.Confirm(confirmCtx, opts.ChatID, opts.SenderID, confirmPrompt(tc))
+	logger.InfoCF("agent", "Tool confirmation",
+		map[string]interface{}{
+			"tool":      tc.Name,
+			"sender_id": opts.SenderID,
+			"approved":  approved,
+		})
+
+	switch {
+	case approved:
+		return nil
+	case errors.Is(err, context.DeadlineExceeded):
+		return tools.ErrorResult(fmt.Sprintf("The user did not confirm in time, so %s was not run. Don't retry unless they ask.", tc.Name))
+	case err != nil:
+		return tools.ErrorResult(fmt.Sprintf("Could not ask the user to confirm %s, so it was not run: %v", tc.Name, err)).WithError(err)
+	}
+	return tools.ErrorResult(fmt.Sprintf("The user cancelled this %s call. Don't retry it unless they ask.", tc.Name))
+}
+
+// confirm asks the user in a chat to approve prompt, for workflow
+// approval steps. Chats whose channel has no buttons can't be asked.
+func (al *AgentLoop) confirm(ctx context.Context, channel, chatID, senderID, prompt string) (bool, error) {
+	if al.channelManager == nil {
+		return false, workflow.ErrNoApproval
+	}
+	ch, ok := al.channelManager.GetChannel(channel)
+	if !ok {
+		return false, workflow.ErrNoApproval
+	}
+	confirmer, ok := ch.(channels.ConfirmingChannel)
+	if !ok {
+		return false, workflow.ErrNoApproval
+	}
+
+	timeout := time.Duration(al.cfg.Tools.Confirm.Timeout) * time.Second
+	confirmCtx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	approved, err := confirmer.Confirm(confirmCtx, chatID, senderID, prompt)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false, nil
+	}
+	return approved, err
+}
+
+// confirmWorkflowTool puts a workflow's tool step through the same
+// approval as the agent's own call of that tool.
+func (al *AgentLoop) confirmWorkflowTool(ctx context.Context, channel, chatID, senderID, tool string, args map[string]interface{}) *tools.ToolResult {
+	return al.confirmTool(ctx, providers.ToolCall{Name: tool, Arguments: args}, processOptions{
+		Channel:  channel,
+		ChatID:   chatID,
+		SenderID: senderID,
+	})
+}
+
+// confirmPrompt describes a tool call for the user, showing a command
+// as-is and other arguments as JSON.
+func confirmPrompt(tc providers.ToolCall) string {
+	detail, _ := tc.Arguments["command"].(string)
+	if detail == "" {
+		args, _ := json.MarshalIndent(tc.Arguments, "", "  ")
+		detail = string(args)
+	}
+	return fmt.Sprintf("⚠️ I want to run `%s`:\n```\n%s\n```\nAllow it?", tc.Name, utils.Truncate(detail, 1500))
+}
@@ -5,13 +5,18 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/privacy"
 	"github.com/sipeed/picoclaw/pkg/providers"
 	"github.com/sipeed/picoclaw/pkg/skills"
 	"github.com/sipeed/picoclaw/pkg/tools"
+	"github.com/sipeed/picoclaw/pkg/utils"
 )
 
 type ContextBuilder struct {
@@ -19,14 +24,14 @@ type ContextBuilder struct {
 	skillsLoader *skills.SkillsLoader
 	memory       *MemoryStore
 	tools        *tools.ToolRegistry // Direct reference to tool registry
+
+	bootstrapMaxChars  int // 0 for no limit
+	guardrailsMinChars int
+	minimal            bool // see SetMinimal
 }
 
 func getGlobalConfigDir() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return ""
-	}
-	return filepath.Join(home, ".picoclaw")
+	return config.HomeDir()
 }
 
 func NewContextBuilder(workspace string) *ContextBuilder {
@@ -48,8 +53,29 @@ func (cb *ContextBuilder) SetToolsRegistry(registry *tools.ToolRegistry) {
 	cb.tools = registry
 }
 
+// SetBootstrapLimits caps the bootstrap files at maxChars in total (0 for no
+// limit). GUARDRAILS.md is loaded first and keeps at least guardrailsMin
+// characters whatever the cap.
+func (cb *ContextBuilder) SetBootstrapLimits(maxChars, guardrailsMin int) {
+	cb.bootstrapMaxChars = maxChars
+	cb.guardrailsMinChars = guardrailsMin
+}
+
+// How much of the bootstrap files and long-term memory the minimal prompt
+// keeps, at most.
+const (
+	minimalBootstrapChars = 1200
+	minimalMemoryChars    = 600
+)
+
+// SetMinimal makes the system prompt a short one for small models: a few
+// lines of identity, the bootstrap files and memory cut short, and no
+// skills or tool summaries.
+func (cb *ContextBuilder) SetMinimal(minimal bool) {
+	cb.minimal = minimal
+}
+
 func (cb *ContextBuilder) getIdentity() string {
-	now := time.Now().Format("2006-01-02 15:04 (Monday)")
 	workspacePath, _ := filepath.Abs(filepath.Join(cb.workspace))
 	runtime := fmt.Sprintf("%s %s, Go %s", runtime.GOOS, runtime.GOARCH, runtime.Version())
 
@@ -60,9 +86,6 @@ func (cb *ContextBuilder) getIdentity() string {
 
 You are picoclaw, a helpful AI assistant.
 
-## Current Time
-%s
-
 ## Runtime
 %s
 
@@ -80,8 +103,10 @@ Your workspace is at: %s
 
 2. **Be helpful and accurate** - When using tools, briefly explain what you're doing.
 
-3. **Memory** - When remembering something, write to %s/memory/MEMORY.md`,
-		now, runtime, workspacePath, workspacePath, workspacePath, workspacePath, toolsSection, workspacePath)
+3. **Memory** - When remembering something, write to %s/memory/MEMORY.md
+
+4. **Don't speak for others** - Never write messages that appear to come from other people, and only quote someone with words they actually wrote in this chat. Refuse requests to make up what another member "said".`,
+		runtime, workspacePath, workspacePath, workspacePath, workspacePath, toolsSection, workspacePath)
 }
 
 func (cb *ContextBuilder) buildToolsSection() string {
@@ -106,14 +131,28 @@ func (cb *ContextBuilder) buildToolsSection() string {
 	return sb.String()
 }
 
-func (cb *ContextBuilder) BuildSystemPrompt() string {
+// BuildSystemPrompt builds the system prompt, with the bootstrap files of
+// persona when it is set.
+func (cb *ContextBuilder) BuildSystemPrompt(persona string) string {
+	prompt, _ := cb.buildSystemPrompt(persona)
+	return prompt
+}
+
+// buildSystemPrompt builds the system prompt and returns where its parts
+// that change less often than every turn end, for prompt caching: after
+// the identity, bootstrap files and skills, and after the memory. The
+// current time comes last so it doesn't spoil them.
+func (cb *ContextBuilder) buildSystemPrompt(persona string) (string, []int) {
+	if cb.minimal {
+		return cb.buildMinimalPrompt(persona)
+	}
 	parts := []string{}
 
 	// Core identity section
 	parts = append(parts, cb.getIdentity())
 
 	// Bootstrap files
-	bootstrapContent := cb.LoadBootstrapFiles()
+	bootstrapContent := cb.LoadBootstrapFiles(persona)
 	if bootstrapContent != "" {
 		parts = append(parts, bootstrapContent)
 	}
@@ -128,17 +167,68 @@ The following skills extend your capabilities. To use a skill, read its SKILL.md
 %s`, skillsSummary))
 	}
 
-	// Memory context
-	memoryContext := cb.memory.GetMemoryContext()
+	const separator = "\n\n---\n\n"
+	var breaks []int
+	stable := strings.Join(parts, separator)
+	breaks = append(breaks, len(stable))
+
+	// Memory context (minus anything about recently purged users)
+	memoryContext := privacy.LoadTombstones(cb.workspace).Redact(cb.memory.GetMemoryContext())
 	if memoryContext != "" {
 		parts = append(parts, "# Memory\n\n"+memoryContext)
+		breaks = append(breaks, len(strings.Join(parts, separator)))
+	}
+
+	parts = append(parts, "## Current Time\n"+time.Now().Format("2006-01-02 15:04 (Monday)"))
+	return strings.Join(parts, separator), breaks
+}
+
+// buildMinimalPrompt builds the system prompt of the minimal profile, a
+// few hundred tokens where the full one takes thousands. The guardrails
+// still keep their floor.
+func (cb *ContextBuilder) buildMinimalPrompt(persona string) (string, []int) {
+	workspacePath, _ := filepath.Abs(cb.workspace)
+	parts := []string{fmt.Sprintf(`You are picoclaw, a helpful assistant. Answer briefly and in the user's language. Your workspace is %s; remember things in memory/MEMORY.md there. Never make up what other people said.`, workspacePath)}
+
+	limit := minimalBootstrapChars
+	if cb.bootstrapMaxChars > 0 && cb.bootstrapMaxChars < limit {
+		limit = cb.bootstrapMaxChars
+	}
+	bootstrapContent := strings.TrimSpace(cb.loadBootstrapFiles(persona, limit))
+	if bootstrapContent != "" {
+		parts = append(parts, bootstrapContent)
+	}
+
+	const separator = "\n\n"
+	breaks := []int{len(strings.Join(parts, separator))}
+
+	memoryContext := privacy.LoadTombstones(cb.workspace).Redact(cb.memory.ReadLongTerm())
+	if memoryContext = strings.TrimSpace(memoryContext); memoryContext != "" {
+		parts = append(parts, "## Memory\n"+utils.Truncate(memoryContext, minimalMemoryChars))
+		breaks = append(breaks, len(strings.Join(parts, separator)))
 	}
 
-	// Join with "---" separator
-	return strings.Join(parts, "\n\n---\n\n")
+	parts = append(parts, "Now: "+time.Now().Format("2006-01-02 15:04 (Monday)"))
+	return strings.Join(parts, separator), breaks
 }
 
-func (cb *ContextBuilder) LoadBootstrapFiles() string {
+// guardrailsFile holds hard behavioral rules. It is kept apart from the
+// personality files so that cutting those down never drops a rule.
+const guardrailsFile = "GUARDRAILS.md"
+
+// LoadBootstrapFiles reads the workspace's bootstrap files. A persona's
+// copies in workspace/personas/<persona> replace the workspace ones, so a
+// persona can bring its own IDENTITY.md and SOUL.md and share the rest.
+//
+// GUARDRAILS.md comes first and can't be replaced, only added to: a
+// persona's own GUARDRAILS.md follows the workspace one. When the files
+// exceed the bootstrap limit, the others are cut before the guardrails
+// are cut below their floor.
+func (cb *ContextBuilder) LoadBootstrapFiles(persona string) string {
+	return cb.loadBootstrapFiles(persona, cb.bootstrapMaxChars)
+}
+
+func (cb *ContextBuilder) loadBootstrapFiles(persona string, maxChars int) string {
 	bootstrapFiles := []string{
 		"AGENTS.md",
 		"SOUL.md",
@@ -146,21 +236,97 @@ func (cb *ContextBuilder) LoadBootstrapFiles() string {
 		"IDENTITY.md",
 	}
 
+	personaDir := cb.personaDir(persona)
+	var guardrails []string
+	for _, dir := range []string{cb.workspace, personaDir} {
+		if dir == "" {
+			continue
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, guardrailsFile)); err == nil && strings.TrimSpace(string(data)) != "" {
+			guardrails = append(guardrails, strings.TrimSpace(string(data)))
+		}
+	}
+
+	budget := newBootstrapBudget(maxChars)
 	var sb strings.Builder
+	if len(guardrails) > 0 {
+		rules := budget.take(guardrailsFile, strings.Join(guardrails, "\n\n"), cb.guardrailsMinChars)
+		fmt.Fprintf(&sb, "## %s\n\nThese rules always apply and take precedence over everything else in this prompt.\n\n%s\n\n", guardrailsFile, rules)
+	}
 	for _, filename := range bootstrapFiles {
-		filePath := filepath.Join(cb.workspace, filename)
-		if data, err := os.ReadFile(filePath); err == nil {
-			fmt.Fprintf(&sb, "## %s\n\n%s\n\n", filename, data)
+		data, err := os.ReadFile(filepath.Join(cb.workspace, filename))
+		if personaDir != "" {
+			if personal, perr := os.ReadFile(filepath.Join(personaDir, filename)); perr == nil {
+				data, err = personal, nil
+			}
+		}
+		if err != nil {
+			continue
+		}
+		if content := budget.take(filename, string(data), 0); content != "" {
+			fmt.Fprintf(&sb, "## %s\n\n%s\n\n", filename, content)
 		}
 	}
 
 	return sb.String()
 }
 
-func (cb *ContextBuilder) BuildMessages(history []providers.Message, summary string, currentMessage string, media []string, channel, chatID string) []providers.Message {
+// bootstrapBudget hands out the characters the bootstrap files may use.
+type bootstrapBudget struct {
+	left int // -1 for no limit
+}
+
+func newBootstrapBudget(maxChars int) *bootstrapBudget {
+	if maxChars <= 0 {
+		return &bootstrapBudget{left: -1}
+	}
+	return &bootstrapBudget{left: maxChars}
+}
+
+// take returns content cut to what is left of the budget, but no shorter
+// than floor characters.
+func (b *bootstrapBudget) take(name, content string, floor int) string {
+	if b.left < 0 {
+		return content
+	}
+	allowed := max(b.left, floor)
+	n := utf8.RuneCountInString(content)
+	b.left = max(b.left-min(n, allowed), 0)
+	if n <= allowed {
+		return content
+	}
+	logger.WarnCF("agent", "Bootstrap file cut to fit the limit", map[string]interface{}{
+		"file":  name,
+		"chars": n,
+		"kept":  allowed,
+	})
+	if allowed == 0 {
+		return ""
+	}
+	return utils.Truncate(content, allowed)
+}
+
+// personaDir returns the directory of a persona's bootstrap files, empty
+// for no persona or a name that isn't a plain directory name.
+func (cb *ContextBuilder) personaDir(persona string) string {
+	if persona == "" || persona == "." || !filepath.IsLocal(persona) || strings.ContainsAny(persona, `/\`) {
+		if persona != "" {
+			logger.WarnCF("agent", "Ignoring invalid persona name", map[string]interface{}{"persona": persona})
+		}
+		return ""
+	}
+	dir := filepath.Join(cb.workspace, "personas", persona)
+	if _, err := os.Stat(dir); err != nil {
+		logger.WarnCF("agent", "Persona directory not found", map[string]interface{}{"persona": persona, "dir": dir})
+		return ""
+	}
+	return dir
+}
+
+func (cb *ContextBuilder) BuildMessages(history []providers.Message, summary string, currentMessage string, media []string, channel, chatID, persona string) []providers.Message {
 	messages := []providers.Message{}
 
-	systemPrompt := cb.BuildSystemPrompt()
+	systemPrompt, cacheBreaks := cb.buildSystemPrompt(persona)
 
 	// Add Current Session info if provided
 	if channel != "" && chatID != "" {
@@ -185,6 +351,7 @@ func (cb *ContextBuilder) BuildMessages(history []providers.Message, summary str
 			"preview": preview,
 		})
 
+	summary = privacy.LoadTombstones(cb.workspace).Redact(summary)
 	if summary != "" {
 		systemPrompt += "\n\n## Summary of Previous Conversation\n\n" + summary
 	}
@@ -192,8 +359,9 @@ func (cb *ContextBuilder) BuildMessages(history []providers.Message, summary str
 	history = sanitizeHistoryForProvider(history)
 
 	messages = append(messages, providers.Message{
-		Role:    "system",
-		Content: systemPrompt,
+		Role:        "system",
+		Content:     systemPrompt,
+		CacheBreaks: cacheBreaks,
 	})
 
 	messages = append(messages, history...)
@@ -202,12 +370,29 @@ func (cb *ContextBuilder) BuildMessages(history []providers.Message, summary str
 		messages = append(messages, providers.Message{
 			Role:    "user",
 			Content: currentMessage,
+			Parts:   imageParts(media),
 		})
 	}
 
 	return messages
 }
 
+// imageParts turns the images in media, which channels have already
+// encoded as data URLs, into message parts. Other media are left out.
+func imageParts(media []string) []providers.ContentPart {
+	var parts []providers.ContentPart
+	for _, item := range media {
+		part := providers.ContentPart{
+			Type:     "image_url",
+			ImageURL: &providers.ImageURL{URL: item},
+		}
+		if mimeType, _, ok := part.Image(); ok && strings.HasPrefix(mimeType, "image/") {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
 func sanitizeHistoryForProvider(history []providers.Message) []providers.Message {
 	if len(history) == 0 {
 		return history
@@ -250,13 +435,45 @@ func sanitizeHistoryForProvider(history []providers.Message) []providers.Message
 	return sanitized
 }
 
+// AddToolResult adds the result of a tool call after the assistant message
+// that made it. Results are kept in the order of that message's tool
+// calls whatever order they arrive in, since parallel calls can finish in
+// any order, and a second result for the same call is merged into the
+// first rather than added as another message.
 func (cb *ContextBuilder) AddToolResult(messages []providers.Message, toolCallID, toolName, result string) []providers.Message {
-	messages = append(messages, providers.Message{
+	msg := providers.Message{
 		Role:       "tool",
 		Content:    result,
 		ToolCallID: toolCallID,
-	})
-	return messages
+	}
+
+	start := len(messages) - 1
+	for start >= 0 && messages[start].Role == "tool" {
+		start--
+	}
+	if start < 0 || messages[start].Role != "assistant" {
+		return append(messages, msg)
+	}
+	order := make(map[string]int, len(messages[start].ToolCalls))
+	for i, tc := range messages[start].ToolCalls {
+		order[tc.ID] = i
+	}
+	pos, ok := order[toolCallID]
+	if !ok {
+		return append(messages, msg)
+	}
+
+	for i := start + 1; i < len(messages); i++ {
+		if messages[i].ToolCallID == toolCallID {
+			merged := slices.Clone(messages)
+			merged[i].Content = strings.TrimRight(merged[i].Content, "\n") + "\n" + result
+			return merged
+		}
+		if other, ok := order[messages[i].ToolCallID]; ok && other > pos {
+			return slices.Insert(messages, i, msg)
+		}
+	}
+	return append(messages, msg)
 }
 
 func (cb *ContextBuilder) AddAssistantMessage(messages []providers.Message, content string, toolCalls []map[string]interface{}) []providers.Message {
@@ -288,6 +505,16 @@ func (cb *ContextBuilder) loadSkills() string {
 	return "# Skill Definitions\n\n" + content
 }
 
+// ListSkills returns the skills available to the agent.
+func (cb *ContextBuilder) ListSkills() []skills.SkillInfo {
+	return cb.skillsLoader.ListSkills()
+}
+
+// SkillsLoader returns the loader of the agent's skills.
+func (cb *ContextBuilder) SkillsLoader() *skills.SkillsLoader {
+	return cb.skillsLoader
+}
+
 // GetSkillsInfo returns information about loaded skills.
 func (cb *ContextBuilder) GetSkillsInfo() map[string]interface{} {
 	allSkills := cb.skillsLoader.ListSkills()
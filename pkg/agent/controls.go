@@ -0,0 +1,28 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import "strings"
+
+// rewindLastTurn drops the latest user message and everything after it
+// from the session, so it can be answered again, and returns that
+// message. reply is the text of the reply to regenerate; the turn is only
+// rewound if reply answers the latest user message, since rewinding for
+// an older reply would drop the turns after it. It reports false when
+// the session has no such turn.
+func (al *AgentLoop) rewindLastTurn(agent *AgentInstance, sessionKey, reply string) (string, bool) {
+	history := agent.Sessions.GetHistory(sessionKey)
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == "user" {
+			if fixTarget(history[i+1:], strings.TrimSpace(reply)) < 0 {
+				return "", false
+			}
+			agent.Sessions.SetHistory(sessionKey, history[:i])
+			return history[i].Content, true
+		}
+	}
+	return "", false
+}
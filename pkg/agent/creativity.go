@@ -0,0 +1,86 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// creativityPreset is the sampling a chat asked for with !creativity.
+// TopP of 0 leaves it to the provider.
+type creativityPreset struct {
+	Temperature float64
+	TopP        float64
+}
+
+// creativityPresets are the levels besides "normal", which uses the
+// agent's configured temperature.
+var creativityPresets = map[string]creativityPreset{
+	"low":  {Temperature: 0.1, TopP: 0.5},
+	"high": {Temperature: 1.0, TopP: 0.95},
+}
+
+// isCreativityCommand reports whether content is a !creativity command.
+func isCreativityCommand(content string) bool {
+	fields := strings.Fields(content)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "!creativity")
+}
+
+// handleCreativity shows or sets the creativity level of a session.
+func (al *AgentLoop) handleCreativity(agent *AgentInstance, sessionKey, content string) string {
+	fields := strings.Fields(content)
+	if len(fields) < 2 {
+		level := agent.Sessions.GetCreativity(sessionKey)
+		if level == "" {
+			level = "normal"
+		}
+		return fmt.Sprintf("Creativity: %s (send !creativity low|normal|high to change it)", level)
+	}
+
+	level := strings.ToLower(fields[1])
+	switch level {
+	case "normal", "default":
+		agent.Sessions.SetCreativity(sessionKey, "")
+	case "low", "high":
+		agent.Sessions.SetCreativity(sessionKey, level)
+	default:
+		return "Usage: !creativity low|normal|high"
+	}
+	if err := agent.Sessions.Save(sessionKey); err != nil {
+		logger.WarnCF("agent", "Failed to save creativity level", map[string]interface{}{
+			"session_key": sessionKey,
+			"error":       err.Error(),
+		})
+	}
+
+	switch level {
+	case "low":
+		return "Creativity set to low: I'll stick to precise, predictable answers in this chat."
+	case "high":
+		return "Creativity set to high: expect more varied, imaginative answers in this chat."
+	default:
+		return "Creativity back to normal."
+	}
+}
+
+// llmOptions returns the options for the agent's LLM calls in a session,
+// with the session's creativity preset applied.
+func (al *AgentLoop) llmOptions(agent *AgentInstance, sessionKey string) map[string]interface{} {
+	options := map[string]interface{}{
+		"max_tokens":  agent.MaxTokens,
+		"temperature": agent.Temperature,
+	}
+	if preset, ok := creativityPresets[agent.Sessions.GetCreativity(sessionKey)]; ok {
+		options["temperature"] = preset.Temperature
+		if preset.TopP > 0 {
+			options["top_p"] = preset.TopP
+		}
+	}
+	return options
+}
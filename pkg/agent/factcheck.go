@@ -0,0 +1,72 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// factCheckNote goes in front of a message answered in fact-check mode.
+const factCheckNote = "[Fact-check mode: before answering, verify every factual claim with web_search, web_fetch or other tools rather than from memory. " +
+	"Mark any statement you could not verify with \"(unverified)\", and say so plainly when sources disagree or you found nothing.]"
+
+// verifyPrefix starts a single message to be answered in fact-check mode.
+const verifyPrefix = "!verify"
+
+// isFactCheckCommand reports whether content is a !factcheck command.
+func isFactCheckCommand(content string) bool {
+	fields := strings.Fields(content)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "!factcheck")
+}
+
+// handleFactCheck shows or sets fact-check mode for a session.
+func (al *AgentLoop) handleFactCheck(agent *AgentInstance, sessionKey, content string) string {
+	fields := strings.Fields(content)
+	if len(fields) < 2 {
+		if agent.Sessions.GetFactCheck(sessionKey) {
+			return "Fact-check mode is on (send !factcheck off to turn it off)."
+		}
+		return "Fact-check mode is off (send !factcheck on to turn it on, or start a single message with !verify)."
+	}
+
+	var on bool
+	switch strings.ToLower(fields[1]) {
+	case "on":
+		on = true
+	case "off":
+	default:
+		return "Usage: !factcheck on|off"
+	}
+	agent.Sessions.SetFactCheck(sessionKey, on)
+	if err := agent.Sessions.Save(sessionKey); err != nil {
+		logger.WarnCF("agent", "Failed to save fact-check mode", map[string]interface{}{
+			"session_key": sessionKey,
+			"error":       err.Error(),
+		})
+	}
+
+	if on {
+		return "Fact-check mode on: I'll check claims with web search before answering and mark what I couldn't verify. Answers will take longer."
+	}
+	return "Fact-check mode off."
+}
+
+// stripVerifyPrefix reports whether content asks for a fact-checked answer
+// with !verify, and returns it without the prefix.
+func stripVerifyPrefix(content string) (string, bool) {
+	trimmed := strings.TrimSpace(content)
+	if len(trimmed) < len(verifyPrefix) || !strings.EqualFold(trimmed[:len(verifyPrefix)], verifyPrefix) {
+		return content, false
+	}
+	rest := trimmed[len(verifyPrefix):]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\n' && rest[0] != '\t' {
+		// !verifying is not the command
+		return content, false
+	}
+	return strings.TrimSpace(rest), true
+}
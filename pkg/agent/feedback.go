@@ -0,0 +1,170 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/audit"
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// feedbackFollowUpWindow is how long a 👎 waits for its "what was wrong?"
+// answer before the next message is treated as a normal message again.
+const feedbackFollowUpWindow = 10 * time.Minute
+
+// pendingFeedback is a 👎 waiting for the user's explanation.
+type pendingFeedback struct {
+	turn  audit.Entry
+	asked time.Time
+}
+
+// recordTurn appends a finished turn to the audit log. Heartbeat turns are
+// skipped since they have no user to give feedback.
+func (al *AgentLoop) recordTurn(agent *AgentInstance, opts processOptions, response string, iterations int, err error) {
+	if al.audit == nil || opts.NoHistory {
+		return
+	}
+
+	_, model := agent.CurrentModel()
+	if opts.Turn != nil {
+		model = opts.Turn.Model
+	}
+	entry := audit.Entry{
+		AgentID:     agent.ID,
+		SessionKey:  opts.SessionKey,
+		Channel:     opts.Channel,
+		ChatID:      opts.ChatID,
+		SenderID:    opts.SenderID,
+		Model:       model,
+		UserMessage: opts.UserMessage,
+		Response:    response,
+		Iterations:  iterations,
+	}
+	if opts.ToolCalls != nil {
+		entry.ToolCalls = *opts.ToolCalls
+	}
+	if opts.Stopped != nil {
+		entry.Stopped = *opts.Stopped
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	if err := al.audit.RecordTurn(entry); err != nil {
+		logger.WarnCF("audit", "Failed to record turn", map[string]interface{}{
+			"session_key": opts.SessionKey,
+			"error":       err.Error(),
+		})
+	}
+}
+
+// handleFeedback captures a bare 👍/👎 as a rating of the chat's last turn
+// and, after a 👎, the same user's answer to "what was wrong?". A 👍 is
+// still passed on to the agent, since it's as often a "yes" as a rating.
+func (al *AgentLoop) handleFeedback(msg bus.InboundMessage) (string, bool) {
+	if al.audit == nil || !al.cfg.Feedback.Enabled {
+		return "", false
+	}
+
+	key := pendingFeedbackKey(msg)
+	content := strings.TrimSpace(msg.Content)
+
+	if v, ok := al.feedback.LoadAndDelete(key); ok {
+		pending := v.(pendingFeedback)
+		if time.Since(pending.asked) < feedbackFollowUpWindow {
+			if content == "/skip" {
+				return "No problem.", true
+			}
+			if _, isRating := parseRatingEmoji(content); !isRating && !strings.HasPrefix(content, "/") {
+				if err := al.audit.RecordFeedback(pending.turn, msg.SenderID, "", content); err != nil {
+					return "Sorry, I couldn't save that feedback.", true
+				}
+				return "Thanks, that helps me improve.", true
+			}
+		}
+	}
+
+	if rating, ok := parseRatingEmoji(content); ok {
+		reply := al.recordRating(msg, rating, "")
+		if rating == audit.RatingUp {
+			return "", false
+		}
+		return reply, true
+	}
+	return "", false
+}
+
+// pendingFeedbackKey identifies whose answer a 👎 waits for.
+func pendingFeedbackKey(msg bus.InboundMessage) string {
+	return msg.Channel + ":" + msg.ChatID + ":" + msg.SenderID
+}
+
+// handleFeedbackCommand handles /feedback up|down [comment].
+func (al *AgentLoop) handleFeedbackCommand(msg bus.InboundMessage, args []string) string {
+	if al.audit == nil || !al.cfg.Feedback.Enabled {
+		return "Feedback capture is disabled"
+	}
+	if len(args) == 0 {
+		return "Usage: /feedback [up|down] [what was wrong]"
+	}
+
+	var rating string
+	switch strings.ToLower(args[0]) {
+	case "up", "good", "+":
+		rating = audit.RatingUp
+	case "down", "bad", "-":
+		rating = audit.RatingDown
+	default:
+		return "Usage: /feedback [up|down] [what was wrong]"
+	}
+	return al.recordRating(msg, rating, strings.Join(args[1:], " "))
+}
+
+func (al *AgentLoop) recordRating(msg bus.InboundMessage, rating, comment string) string {
+	turn, ok := al.audit.LastTurn(msg.Channel, msg.ChatID)
+	if !ok {
+		return "There's no recent reply here to give feedback on."
+	}
+
+	if err := al.audit.RecordFeedback(turn, msg.SenderID, rating, comment); err != nil {
+		logger.WarnCF("audit", "Failed to record feedback", map[string]interface{}{
+			"turn_id": turn.TurnID,
+			"error":   err.Error(),
+		})
+		return "Sorry, I couldn't save that feedback."
+	}
+	if al.canary != nil {
+		al.canary.RecordFeedback(msg.Channel, msg.ChatID, rating == audit.RatingUp)
+	}
+
+	if rating == audit.RatingDown && comment == "" && al.cfg.Feedback.FollowUp {
+		al.feedback.Store(pendingFeedbackKey(msg), pendingFeedback{turn: turn, asked: time.Now()})
+		return "Thanks for the feedback. What was wrong? Reply with a short note, or /skip."
+	}
+	return "Thanks for the feedback!"
+}
+
+// parseRatingEmoji recognizes a message consisting only of 👍 or 👎,
+// ignoring skin tone modifiers and variation selectors.
+func parseRatingEmoji(content string) (string, bool) {
+	stripped := strings.Map(func(r rune) rune {
+		if r == '\uFE0F' || (r >= 0x1F3FB && r <= 0x1F3FF) {
+			return -1
+		}
+		return r
+	}, content)
+
+	switch stripped {
+	case "👍":
+		return audit.RatingUp, true
+	case "👎":
+		return audit.RatingDown, true
+	}
+	return "", false
+}
@@ -0,0 +1,206 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/structured"
+)
+
+// fixPrefix starts a correction of the bot's previous reply.
+const fixPrefix = "!fix"
+
+// correctionsHeading is the section of MEMORY.md lessons from !fix go to.
+const correctionsHeading = "## Corrections"
+
+const fixPrompt = `You replied to a user, and they say the reply was wrong. Rewrite the reply with their correction applied, keeping what was right and the same tone and language. Also write the lesson: one short, general sentence to remember so the same mistake isn't made again (a fact, a preference of the user, or how to do something), or an empty string if there's nothing worth keeping beyond this reply.
+
+Their message:
+%s
+
+Your reply:
+%s
+
+Their correction:
+%s`
+
+var fixSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"reply":  map[string]interface{}{"type": "string"},
+		"lesson": map[string]interface{}{"type": "string"},
+	},
+	"required": []interface{}{"reply", "lesson"},
+}
+
+// parseFixCommand reports whether content is a !fix command, and returns
+// the correction that follows it.
+func parseFixCommand(content string) (string, bool) {
+	trimmed := strings.TrimSpace(content)
+	if len(trimmed) < len(fixPrefix) || !strings.EqualFold(trimmed[:len(fixPrefix)], fixPrefix) {
+		return "", false
+	}
+	rest := trimmed[len(fixPrefix):]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\n' && rest[0] != '\t' {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// fixReply applies a correction to the bot's reply the user answered with
+// !fix, or to its latest reply in the session: the reply is rewritten in
+// the session, the lesson goes to long-term memory, and the original
+// message is edited where the channel can. Discord sets metadata
+// "reply_to_message_id" and "reply_to_text" when the command answers one
+// of the bot's messages; elsewhere callers can set metadata freely, so it
+// is ignored. Long-term memory is shared by every chat, so only admins and
+// DMs can add lessons to it; in groups the reply is still corrected.
+func (al *AgentLoop) fixReply(ctx context.Context, agent *AgentInstance, sessionKey string, msg bus.InboundMessage, correction string) string {
+	if correction == "" {
+		return "Usage: !fix <what was wrong>, as a reply to my message or right after it."
+	}
+
+	var pointed, pointedID string
+	if msg.Channel == "discord" {
+		pointed = strings.TrimSpace(msg.Metadata["reply_to_text"])
+		pointedID = msg.Metadata["reply_to_message_id"]
+	}
+	history := agent.Sessions.GetHistory(sessionKey)
+	idx := fixTarget(history, pointed)
+	original, question := pointed, ""
+	if idx >= 0 {
+		original = history[idx].Content
+		for i := idx - 1; i >= 0; i-- {
+			if history[i].Role == "user" {
+				question = history[i].Content
+				break
+			}
+		}
+	}
+	if original == "" {
+		return "There's no reply of mine here to fix."
+	}
+
+	fixCtx, cancel := al.turnContext(ctx)
+	defer cancel()
+	var parsed struct {
+		Reply  string `json:"reply"`
+		Lesson string `json:"lesson"`
+	}
+	prompt := []providers.Message{{Role: "user", Content: fmt.Sprintf(fixPrompt, question, original, correction)}}
+	provider, model := agent.CurrentModel()
+	err := structured.Chat(fixCtx, provider, model, prompt, structured.Request{
+		Name:    "correction",
+		Schema:  fixSchema,
+		Options: map[string]interface{}{"max_tokens": agent.MaxTokens, "temperature": 0.3},
+	}, &parsed)
+	reply := strings.TrimSpace(parsed.Reply)
+	if err != nil || reply == "" {
+		logger.WarnCF("agent", "Correction failed", map[string]interface{}{
+			"agent_id": agent.ID,
+			"error":    fmt.Sprint(err),
+		})
+		return "Sorry, I couldn't apply that correction."
+	}
+
+	if idx >= 0 {
+		history[idx].Content = reply
+		agent.Sessions.SetHistory(sessionKey, history)
+		if err := agent.Sessions.Save(sessionKey); err != nil {
+			logger.WarnCF("agent", "Failed to save corrected session", map[string]interface{}{
+				"session_key": sessionKey,
+				"error":       err.Error(),
+			})
+		}
+	}
+
+	lesson := strings.TrimSpace(parsed.Lesson)
+	if msg.Metadata["peer_kind"] != "direct" && !isAdmin(al.cfg.Admin.Users, msg) {
+		lesson = ""
+	}
+	if lesson != "" {
+		if err := appendCorrection(NewMemoryStore(agent.Workspace), lesson); err != nil {
+			logger.WarnCF("agent", "Failed to save correction to memory", map[string]interface{}{
+				"agent_id": agent.ID,
+				"error":    err.Error(),
+			})
+			lesson = ""
+		}
+	}
+	logger.InfoCF("agent", "Applied correction", map[string]interface{}{
+		"agent_id":    agent.ID,
+		"session_key": sessionKey,
+		"in_session":  idx >= 0,
+		"lesson":      lesson,
+	})
+
+	noted := ""
+	if lesson != "" {
+		noted = fmt.Sprintf(" Noted for next time: %s", lesson)
+	}
+	if pointedID != "" && pointed != "" {
+		al.bus.PublishOutbound(bus.OutboundMessage{
+			Channel: msg.Channel,
+			ChatID:  msg.ChatID,
+			Content: reply,
+			Edit:    pointedID,
+		})
+		return "✏️ Corrected my message." + noted
+	}
+	if noted != "" {
+		noted = "\n\n_(" + strings.TrimSpace(noted) + ")_"
+	}
+	return "✏️ " + reply + noted
+}
+
+// fixTarget returns the index of the assistant message in history that
+// pointed is the text of, or of the latest assistant reply when pointed is
+// empty, or -1. A long reply is sent in chunks, so the text of its first
+// message is only a prefix of it.
+func fixTarget(history []providers.Message, pointed string) int {
+	for i := len(history) - 1; i >= 0; i-- {
+		m := history[i]
+		if m.Role != "assistant" || len(m.ToolCalls) > 0 || strings.TrimSpace(m.Content) == "" {
+			continue
+		}
+		if pointed == "" || strings.HasPrefix(strings.TrimSpace(m.Content), pointed) {
+			return i
+		}
+	}
+	return -1
+}
+
+// appendCorrection adds lesson to the corrections section of long-term
+// memory, which every later conversation sees in its system prompt.
+func appendCorrection(ms *MemoryStore, lesson string) error {
+	content := strings.TrimRight(ms.ReadLongTerm(), "\n")
+	line := fmt.Sprintf("- %s (%s)", lesson, time.Now().Format("2006-01-02"))
+	if i := strings.Index(content, correctionsHeading+"\n"); i >= 0 {
+		// Add to the end of the existing section
+		end := len(content)
+		if next := strings.Index(content[i+len(correctionsHeading):], "\n## "); next >= 0 {
+			end = i + len(correctionsHeading) + next
+		}
+		rest := content[end:]
+		if rest != "" {
+			rest = "\n" + rest
+		}
+		content = strings.TrimRight(content[:end], "\n") + "\n" + line + rest
+	} else {
+		if content != "" {
+			content += "\n\n"
+		}
+		content += correctionsHeading + "\n\n" + line
+	}
+	return ms.WriteLongTerm(content + "\n")
+}
@@ -0,0 +1,35 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/followup"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// resumeFollowUps hands the tasks parked with ask_user in this chat back to
+// the agent, in front of the reply that should answer them.
+func (al *AgentLoop) resumeFollowUps(agent *AgentInstance, msg bus.InboundMessage, content string) string {
+	store := followup.NewStore(agent.Workspace, al.cfg.Tools.FollowUps.Expire())
+	tasks, err := store.Take(msg.Channel, msg.ChatID, time.Now())
+	if err != nil {
+		logger.WarnCF("agent", "Failed to update parked tasks", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	if len(tasks) == 0 {
+		return content
+	}
+
+	logger.InfoCF("agent", "Resuming parked tasks", map[string]interface{}{
+		"chat_id": msg.ChatID,
+		"tasks":   len(tasks),
+	})
+	return followup.ResumeNote(tasks) + "\n" + content
+}
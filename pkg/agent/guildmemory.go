@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/privacy"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// maxGuildMemoryChars caps a guild's shared memory, which goes into the
+// system prompt of every turn in the guild.
+const maxGuildMemoryChars = 8000
+
+// guildID returns the Discord guild msg was sent in, or "". Only the
+// Discord channels set guild_id themselves; on other channels it may come
+// from whoever sent the message.
+func guildID(msg bus.InboundMessage) string {
+	if msg.Channel != "discord" && !strings.HasPrefix(msg.Channel, "discord_") {
+		return ""
+	}
+	return msg.Metadata["guild_id"]
+}
+
+// guildMemoryPath returns the shared memory file of a guild,
+// memory/guilds/<guildID>.md, or "" for an ID that isn't a plain name.
+func guildMemoryPath(workspace, guildID string) string {
+	if guildID == "" || !filepath.IsLocal(guildID) || strings.ContainsAny(guildID, `/\.`) {
+		return ""
+	}
+	return filepath.Join(workspace, "memory", "guilds", guildID+".md")
+}
+
+// rememberMessage saves a community message to its guild's shared memory
+// with attribution, for a bus.ControlRemember event.
+func (al *AgentLoop) rememberMessage(agent *AgentInstance, msg bus.InboundMessage) string {
+	text := strings.TrimSpace(msg.Content)
+	path := guildMemoryPath(agent.Workspace, guildID(msg))
+	if text == "" || path == "" {
+		return ""
+	}
+
+	link := msg.Metadata["message_link"]
+	existing, _ := os.ReadFile(path)
+	if link != "" && strings.Contains(string(existing), link) {
+		return "🧠 That message is already in the server's memory."
+	}
+
+	author := msg.Metadata["author"]
+	if author == "" {
+		author = "unknown"
+	}
+	note := fmt.Sprintf("## %s in #%s (%s)\n\n%s\n\nSaved by %s", author, msg.Metadata["channel_name"], time.Now().Format("2006-01-02"), text, msg.Metadata["saved_by"])
+	if link != "" {
+		note += " · " + link
+	}
+	note += "\n"
+
+	if len(existing) == 0 {
+		note = "# Server Memory\n\n" + note
+	} else {
+		note = "\n" + note
+	}
+	if len(existing)+len(note) > maxGuildMemoryChars {
+		return fmt.Sprintf("The server's memory is full (%d characters). Ask the bot's owner to trim it first.", maxGuildMemoryChars)
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err == nil {
+		var f *os.File
+		if f, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
+			_, err = f.WriteString(note)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	if err != nil {
+		logger.WarnCF("agent", "Failed to save message to guild memory", map[string]interface{}{
+			"agent_id": agent.ID,
+			"guild_id": guildID(msg),
+			"error":    err.Error(),
+		})
+		return "Sorry, I couldn't save that to the server's memory."
+	}
+	return fmt.Sprintf("🧠 Saved %s's message to the server's memory.", author)
+}
+
+// withGuildMemory adds the guild's shared memory to the system prompt of
+// messages, for turns in a guild that has one. A file edited past the cap
+// is cut to its latest part.
+func (cb *ContextBuilder) withGuildMemory(messages []providers.Message, guildID string) []providers.Message {
+	path := guildMemoryPath(cb.workspace, guildID)
+	if path == "" || len(messages) == 0 || messages[0].Role != "system" {
+		return messages
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return messages
+	}
+	content := strings.TrimSpace(privacy.LoadTombstones(cb.workspace).Redact(string(data)))
+	if content == "" {
+		return messages
+	}
+	if len(content) > maxGuildMemoryChars {
+		content = strings.ToValidUTF8(content[len(content)-maxGuildMemoryChars:], "")
+	}
+	messages[0].Content += "\n\n## Community Knowledge\n\nMessages this server's admins saved for you to remember. Credit the author when you use one.\n\n" + content
+	return messages
+}
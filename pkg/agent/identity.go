@@ -0,0 +1,96 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/identity"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// parseLinkCommand splits "!link [code]" and "!unlink".
+func parseLinkCommand(content string) (string, string, bool) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", "", false
+	}
+	switch cmd := strings.ToLower(fields[0]); cmd {
+	case "!link":
+		if len(fields) == 2 {
+			return cmd, fields[1], true
+		}
+		return cmd, "", true
+	case "!unlink":
+		return cmd, "", len(fields) == 1
+	}
+	return "", "", false
+}
+
+// handleLinkCommand lets users link their accounts on different channels,
+// so their DMs share a session: "!link" on one channel gives a code, and
+// "!link <code>" on another redeems it. Codes only work in DMs, so nobody
+// in a group can take one and read the conversation.
+func (al *AgentLoop) handleLinkCommand(msg bus.InboundMessage) (string, bool) {
+	cmd, code, ok := parseLinkCommand(msg.Content)
+	if !ok || !al.cfg.Session.AccountLinking {
+		return "", false
+	}
+	if msg.Metadata["peer_kind"] != "direct" {
+		return "Send me " + cmd + " in a direct message.", true
+	}
+
+	account := identity.Account(msg.Channel, msg.SenderID)
+	switch {
+	case cmd == "!unlink":
+		unlinked, err := al.identities.Unlink(account)
+		switch {
+		case err != nil:
+			return fmt.Sprintf("Failed to unlink: %v", err), true
+		case !unlinked:
+			return "This account isn't linked to any other.", true
+		}
+		return "Unlinked. Conversations here are separate from your other accounts again.", true
+
+	case code == "":
+		issued, err := al.identities.RequestCode(account)
+		if err != nil {
+			return fmt.Sprintf("Failed to create a link code: %v", err), true
+		}
+		reply := fmt.Sprintf("To continue this conversation on another channel, send me `!link %s` there in a direct message within 15 minutes.", issued)
+		if id, ok := al.identities.Lookup(account); ok {
+			reply += "\nAlready linked: " + strings.Join(otherAccounts(id, account), ", ")
+		}
+		return reply, true
+	}
+
+	id, err := al.identities.Redeem(code, account)
+	switch {
+	case errors.Is(err, identity.ErrUnknownCode), errors.Is(err, identity.ErrSameAccount), errors.Is(err, identity.ErrTooManyAttempts):
+		return err.Error() + ".", true
+	case err != nil:
+		return fmt.Sprintf("Failed to link: %v", err), true
+	}
+	logger.InfoCF("agent", "Linked accounts", map[string]interface{}{
+		"identity": id.Name,
+		"accounts": id.Accounts,
+	})
+	return "Linked with " + strings.Join(otherAccounts(id, account), ", ") + ". We can carry on where you left off.", true
+}
+
+// otherAccounts returns the accounts of id other than account.
+func otherAccounts(id identity.Identity, account string) []string {
+	var others []string
+	for _, a := range id.Accounts {
+		if a != account {
+			others = append(others, a)
+		}
+	}
+	return others
+}
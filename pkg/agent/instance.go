@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 
 	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/providers"
@@ -14,6 +15,9 @@ import (
 
 // AgentInstance represents a fully configured agent with its own workspace,
 // session manager, context builder, and tool registry.
+//
+// Model and Provider are the configured ones and don't change once the
+// agent is running; !model switches what CurrentModel returns instead.
 type AgentInstance struct {
 	ID             string
 	Name           string
@@ -31,8 +35,41 @@ type AgentInstance struct {
 	Subagents      *config.SubagentsConfig
 	SkillsFilter   []string
 	Candidates     []providers.FallbackCandidate
+	// Tenant is the owner this agent serves in multi-tenant mode, nil for
+	// other agents.
+	Tenant *config.TenantConfig
+	// Minimal is set for agents with the minimal profile, whose provider
+	// gets its tools in the prompt.
+	Minimal bool
+
+	switched atomic.Pointer[modelChoice]
 }
 
+// modelChoice is a provider and the model to ask it for, switched as one
+// so no turn pairs a provider with another one's model.
+type modelChoice struct {
+	provider providers.LLMProvider
+	model    string
+}
+
+// CurrentModel returns the provider and model turns should use. Read it
+// once per turn, as !model may switch it at any time.
+func (a *AgentInstance) CurrentModel() (providers.LLMProvider, string) {
+	if c := a.switched.Load(); c != nil {
+		return c.provider, c.model
+	}
+	return a.Provider, a.Model
+}
+
+// SwitchModel makes turns that start from now on use model on provider.
+func (a *AgentInstance) SwitchModel(provider providers.LLMProvider, model string) {
+	a.switched.Store(&modelChoice{provider: provider, model: model})
+}
+
+// minimalTools are the tools agents with the minimal profile keep: each
+// one a small model can use without instructions beyond its description.
+var minimalTools = []string{"read_file", "write_file", "list_dir", "web_search", "web_fetch"}
+
 // NewAgentInstance creates an agent instance from config.
 func NewAgentInstance(
 	agentCfg *config.AgentConfig,
@@ -60,6 +97,8 @@ func NewAgentInstance(
 
 	contextBuilder := NewContextBuilder(workspace)
 	contextBuilder.SetToolsRegistry(toolsRegistry)
+	contextBuilder.SetBootstrapLimits(defaults.BootstrapMaxChars, defaults.GuardrailsMinChars)
+	contextBuilder.SkillsLoader().SetShadowing(cfg.Tools.Skills.ShadowWarnings, cfg.Tools.Skills.Protected)
 
 	agentID := routing.DefaultAgentID
 	agentName := ""
@@ -88,6 +127,13 @@ func NewAgentInstance(
 		temperature = *defaults.Temperature
 	}
 
+	minimal := resolveAgentProfile(agentCfg, defaults) == config.AgentProfileMinimal
+	if minimal {
+		toolsRegistry.Restrict(minimalTools...)
+		contextBuilder.SetMinimal(true)
+		provider = providers.WithPromptedTools(provider)
+	}
+
 	// Resolve fallback candidates
 	modelCfg := providers.ModelConfig{
 		Primary:   model,
@@ -112,6 +158,7 @@ func NewAgentInstance(
 		Subagents:      subagents,
 		SkillsFilter:   skillsFilter,
 		Candidates:     candidates,
+		Minimal:        minimal,
 	}
 }
 
@@ -123,9 +170,8 @@ func resolveAgentWorkspace(agentCfg *config.AgentConfig, defaults *config.AgentD
 	if agentCfg == nil || agentCfg.Default || agentCfg.ID == "" || routing.NormalizeAgentID(agentCfg.ID) == "main" {
 		return expandHome(defaults.Workspace)
 	}
-	home, _ := os.UserHomeDir()
 	id := routing.NormalizeAgentID(agentCfg.ID)
-	return filepath.Join(home, ".picoclaw", "workspace-"+id)
+	return filepath.Join(config.HomeDir(), "workspace-"+id)
 }
 
 // resolveAgentModel resolves the primary model for an agent.
@@ -144,6 +190,14 @@ func resolveAgentFallbacks(agentCfg *config.AgentConfig, defaults *config.AgentD
 	return defaults.ModelFallbacks
 }
 
+// resolveAgentProfile resolves the profile of an agent.
+func resolveAgentProfile(agentCfg *config.AgentConfig, defaults *config.AgentDefaults) string {
+	if agentCfg != nil && strings.TrimSpace(agentCfg.Profile) != "" {
+		return strings.ToLower(strings.TrimSpace(agentCfg.Profile))
+	}
+	return strings.ToLower(strings.TrimSpace(defaults.Profile))
+}
+
 func expandHome(path string) string {
 	if path == "" {
 		return path
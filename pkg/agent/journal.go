@@ -0,0 +1,28 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/journal"
+)
+
+// journalContext notes an unanswered evening journal prompt in front of
+// content. The prompt goes out from the heartbeat, outside the session, so
+// without the note a reply to it would read as an ordinary message.
+func (al *AgentLoop) journalContext(agent *AgentInstance, msg bus.InboundMessage, content string) string {
+	u := journal.User{Channel: msg.Channel, ChatID: msg.ChatID, SenderID: msg.SenderID}
+	day, ok := journal.NewStore(agent.Workspace).Pending(u, time.Now())
+	if !ok {
+		return content
+	}
+	return fmt.Sprintf("[The evening journal prompt for %s asked: %s If this message answers it, save the answers with the journal tool.]\n%s",
+		day.Format("2006-01-02"), strings.Join(al.cfg.Tools.Journal.Questions, " "), content)
+}
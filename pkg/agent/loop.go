@@ -9,24 +9,46 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 	"unicode/utf8"
 
+	"github.com/sipeed/picoclaw/pkg/announce"
+	"github.com/sipeed/picoclaw/pkg/audit"
+	"github.com/sipeed/picoclaw/pkg/bookmarks"
 	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/canary"
 	"github.com/sipeed/picoclaw/pkg/channels"
 	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/constants"
+	"github.com/sipeed/picoclaw/pkg/cron"
+	"github.com/sipeed/picoclaw/pkg/expenses"
+	"github.com/sipeed/picoclaw/pkg/focus"
+	"github.com/sipeed/picoclaw/pkg/followup"
+	"github.com/sipeed/picoclaw/pkg/habits"
+	"github.com/sipeed/picoclaw/pkg/identity"
+	"github.com/sipeed/picoclaw/pkg/journal"
+	"github.com/sipeed/picoclaw/pkg/kv"
+	"github.com/sipeed/picoclaw/pkg/links"
 	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/maintenance"
+	"github.com/sipeed/picoclaw/pkg/privacy"
+	"github.com/sipeed/picoclaw/pkg/proactive"
 	"github.com/sipeed/picoclaw/pkg/providers"
 	"github.com/sipeed/picoclaw/pkg/routing"
+	"github.com/sipeed/picoclaw/pkg/session"
 	"github.com/sipeed/picoclaw/pkg/skills"
 	"github.com/sipeed/picoclaw/pkg/state"
+	"github.com/sipeed/picoclaw/pkg/templates"
 	"github.com/sipeed/picoclaw/pkg/tools"
 	"github.com/sipeed/picoclaw/pkg/utils"
+	"github.com/sipeed/picoclaw/pkg/voice"
+	"github.com/sipeed/picoclaw/pkg/wellness"
 )
 
 type AgentLoop struct {
@@ -34,53 +56,161 @@ type AgentLoop struct {
 	cfg            *config.Config
 	registry       *AgentRegistry
 	state          *state.Manager
+	states         sync.Map // workspace -> *state.Manager, for agents outside the default workspace
+	chatWorkspaces sync.Map // "channel:chatID" -> workspace of the agent its last message went to
 	running        atomic.Bool
 	summarizing    sync.Map
+	topicChecks    sync.Map // agentID:sessionKey -> in progress
 	fallback       *providers.FallbackChain
 	channelManager *channels.Manager
+	announcements  *announce.Store
+	maintenance    *maintenance.Store
+	canary         *canary.Experiment
+	audit          *audit.Log
+	feedback       sync.Map // "channel:chatID:senderID" -> pendingFeedback
+	links          *links.Dispatcher
+	configPath     string // config file, for !reload
+	started        time.Time
+	store          kv.Store // usage counters, nil outside the gateway
+	identities     *identity.Store
+	cronService    *cron.CronService // for !reminders, nil outside the gateway
+	cheapOnce      sync.Once
+	cheap          *cheapRoute // cheap model from model_list, nil for the agent's provider
+	onToolCheck    func(checks []ToolCheck)
 }
 
 // processOptions configures how a message is processed
 type processOptions struct {
-	SessionKey      string // Session identifier for history/context
-	Channel         string // Target channel for tool execution
-	ChatID          string // Target chat ID for tool execution
-	UserMessage     string // User message content (may include prefix)
-	DefaultResponse string // Response when LLM returns empty
-	EnableSummary   bool   // Whether to trigger summarization
-	SendResponse    bool   // Whether to send response via bus
-	NoHistory       bool   // If true, don't load session history (for heartbeat)
+	SessionKey      string       // Session identifier for history/context
+	Channel         string       // Target channel for tool execution
+	ChatID          string       // Target chat ID for tool execution
+	UserMessage     string       // User message content (may include prefix)
+	DefaultResponse string       // Response when LLM returns empty
+	EnableSummary   bool         // Whether to trigger summarization
+	SendResponse    bool         // Whether to send response via bus
+	Stream          bool         // Whether to publish the reply as partials while it's generated
+	NoHistory       bool         // If true, don't load session history (for heartbeat)
+	Turn            *canary.Turn // Canary experiment arm for this turn (nil when no experiment runs)
+	SenderID        string       // Sender of the user message (for the audit log)
+	ToolCalls       *[]string    // Collects the names of tools called during the turn
+	Media           []string     // Attachments; images arrive as data URLs
+	MaxIterations   int          // Lower tool iteration cap for this request (0 for the agent's)
+	Stopped         *string      // Set to why loop detection ended the turn early
+	Persona         string       // Bootstrap file set in workspace/personas to use ("" for the workspace's)
+	GuildID         string       // Guild whose shared memory to include ("" for none)
 }
 
 func NewAgentLoop(cfg *config.Config, msgBus *bus.MessageBus, provider providers.LLMProvider) *AgentLoop {
 	registry := NewAgentRegistry(cfg, provider)
 
+	// The audit log lives in the default agent's workspace
+	defaultAgent := registry.GetDefaultAgent()
+	var auditLog *audit.Log
+	if defaultAgent != nil && cfg.Audit.Enabled {
+		auditLog = audit.NewLog(defaultAgent.Workspace)
+	}
+
 	// Register shared tools to all agents
-	registerSharedTools(cfg, msgBus, registry, provider)
+	registerSharedTools(cfg, msgBus, registry, provider, auditLog)
 
 	// Set up shared fallback chain
 	cooldown := providers.NewCooldownTracker()
 	fallbackChain := providers.NewFallbackChain(cooldown)
 
 	// Create state manager using default agent's workspace for channel recording
-	defaultAgent := registry.GetDefaultAgent()
 	var stateManager *state.Manager
+	var announcements *announce.Store
+	var maintenanceStore *maintenance.Store
+	var experiment *canary.Experiment
 	if defaultAgent != nil {
 		stateManager = state.NewManager(defaultAgent.Workspace)
+		announcements = announce.NewStore(defaultAgent.Workspace)
+		maintenanceStore = maintenance.NewStore(defaultAgent.Workspace)
+		if cfg.Canary.Enabled {
+			experiment = newCanaryExperiment(cfg, defaultAgent.Workspace)
+		}
+	}
+
+	al := &AgentLoop{
+		bus:           msgBus,
+		cfg:           cfg,
+		registry:      registry,
+		state:         stateManager,
+		summarizing:   sync.Map{},
+		fallback:      fallbackChain,
+		announcements: announcements,
+		maintenance:   maintenanceStore,
+		canary:        experiment,
+		audit:         auditLog,
+		links:         links.NewDispatcher(cfg.Tools.Links),
+		started:       time.Now(),
+		identities:    identity.NewStore(cfg.WorkspacePath()),
+	}
+	registry.resolver.SetIdentities(al.identities)
+
+	for _, agentID := range registry.ListAgentIDs() {
+		agent, _ := registry.GetAgent(agentID)
+		agent.Tools.Register(tools.NewCapabilitiesTool(func() tools.Capabilities {
+			return al.capabilities(agent)
+		}))
+	}
+
+	return al
+}
+
+// newCanaryExperiment creates the provider for the canary model. A bad
+// canary config is logged and disables the experiment instead of failing
+// startup.
+func newCanaryExperiment(cfg *config.Config, workspace string) *canary.Experiment {
+	if cfg.Canary.Model == "" || cfg.Canary.Percent <= 0 {
+		logger.WarnC("canary", "Canary enabled without a model or percent, experiment disabled")
+		return nil
 	}
 
-	return &AgentLoop{
-		bus:         msgBus,
-		cfg:         cfg,
-		registry:    registry,
-		state:       stateManager,
-		summarizing: sync.Map{},
-		fallback:    fallbackChain,
+	modelCfg, err := cfg.GetModelConfig(cfg.Canary.Model)
+	if err != nil {
+		logger.ErrorCF("canary", "Canary model not found, experiment disabled", map[string]interface{}{
+			"model": cfg.Canary.Model,
+			"error": err.Error(),
+		})
+		return nil
+	}
+	if modelCfg.Workspace == "" {
+		modelCfg.Workspace = cfg.WorkspacePath()
 	}
+
+	provider, modelID, err := providers.CreateProviderFromConfig(modelCfg)
+	if err != nil {
+		logger.ErrorCF("canary", "Failed to create canary provider, experiment disabled", map[string]interface{}{
+			"model": cfg.Canary.Model,
+			"error": err.Error(),
+		})
+		return nil
+	}
+
+	logger.InfoCF("canary", "Canary experiment enabled", map[string]interface{}{
+		"model":   modelID,
+		"percent": cfg.Canary.Percent,
+	})
+	provider = providers.WrapWireLog(provider, cfg.WireLog, cfg.WorkspacePath())
+	return canary.NewExperiment(cfg.Canary, provider, modelID, workspace)
 }
 
 // registerSharedTools registers tools that are shared across all agents (web, message, spawn).
-func registerSharedTools(cfg *config.Config, msgBus *bus.MessageBus, registry *AgentRegistry, provider providers.LLMProvider) {
+// auditLog is the live audit log, if any, which the purger of an agent in
+// the same workspace must share so its cache of last turns is purged too.
+func registerSharedTools(cfg *config.Config, msgBus *bus.MessageBus, registry *AgentRegistry, provider providers.LLMProvider, auditLog *audit.Log) {
+	// Long-audio summarization needs both transcription and ffmpeg
+	var transcriber *voice.GroqTranscriber
+	var converter *voice.AudioConverter
+	if cfg.Providers.Groq.APIKey != "" {
+		converter = voice.NewAudioConverter(cfg.Voice)
+		if converter.CanConvert() {
+			transcriber = voice.NewGroqTranscriber(cfg.Providers.Groq.APIKey, cfg.Timeouts.TranscriptionTimeout())
+			transcriber.SetConverter(converter)
+		}
+	}
+
 	for _, agentID := range registry.ListAgentIDs() {
 		agent, ok := registry.GetAgent(agentID)
 		if !ok {
@@ -101,6 +231,42 @@ func registerSharedTools(cfg *config.Config, msgBus *bus.MessageBus, registry *A
 			agent.Tools.Register(searchTool)
 		}
 		agent.Tools.Register(tools.NewWebFetchTool(50000))
+		if cfg.Tools.Bookmarks.Enabled {
+			bookmarkStore := bookmarks.NewStore(agent.Workspace)
+			agent.Tools.Register(tools.NewBookmarkAddTool(bookmarkStore, agent.Provider, agent.Model))
+			agent.Tools.Register(tools.NewBookmarkListTool(bookmarkStore))
+		}
+		if cfg.Tools.Habits.Enabled {
+			habitStore := habits.NewStore(agent.Workspace)
+			agent.Tools.Register(tools.NewHabitTrackTool(habitStore))
+			agent.Tools.Register(tools.NewHabitReportTool(habitStore))
+		}
+		if cfg.Tools.Expenses.Enabled {
+			expenseStore := expenses.NewStore(agent.Workspace)
+			agent.Tools.Register(tools.NewExpenseLogTool(expenseStore, cfg.Tools.Expenses.Currency))
+			agent.Tools.Register(tools.NewExpenseReportTool(expenseStore))
+		}
+		if cfg.Tools.Wellness.Enabled {
+			wellnessStore := wellness.NewStore(agent.Workspace)
+			agent.Tools.Register(tools.NewHealthLogTool(wellnessStore))
+			agent.Tools.Register(tools.NewHealthReportTool(wellnessStore))
+		}
+		if cfg.Tools.Journal.Enabled {
+			agent.Tools.Register(tools.NewJournalTool(journal.NewStore(agent.Workspace), cfg.Tools.Journal.Questions))
+		}
+		agent.Tools.Register(tools.NewProactiveFrequencyTool(proactive.NewEngine(agent.Workspace, cfg.Proactive)))
+		if cfg.Tools.Focus.Enabled {
+			agent.Tools.Register(tools.NewFocusTool(focus.NewStore(agent.Workspace), cfg.Tools.Focus.Minutes, msgBus))
+		}
+		if cfg.Tools.FollowUps.Enabled {
+			agent.Tools.Register(tools.NewAskUserTool(followup.NewStore(agent.Workspace, cfg.Tools.FollowUps.Expire()), msgBus))
+		}
+		if cfg.Tools.Workflows.Enabled {
+			agent.Tools.Register(tools.NewRunWorkflowTool(agent.Workspace, agent.Provider, agent.Model, agent.Tools))
+		}
+		if transcriber != nil {
+			agent.Tools.Register(tools.NewSummarizeAudioTool(agent.Provider, agent.Model, transcriber, converter, agent.Workspace, cfg.Agents.Defaults.RestrictToWorkspace))
+		}
 
 		// Hardware tools (I2C, SPI) - Linux only, returns error on other platforms
 		agent.Tools.Register(tools.NewI2CTool())
@@ -116,7 +282,17 @@ func registerSharedTools(cfg *config.Config, msgBus *bus.MessageBus, registry *A
 			})
 			return nil
 		})
+		messageTool.SetEmbedCallback(func(channel, chatID, content string, embed *bus.Embed) error {
+			msgBus.PublishOutbound(bus.OutboundMessage{
+				Channel: channel,
+				ChatID:  chatID,
+				Content: content,
+				Embed:   embed,
+			})
+			return nil
+		})
 		agent.Tools.Register(messageTool)
+		agent.Tools.Register(tools.NewSendLaterTool(msgBus))
 
 		// Skill discovery and installation tools
 		registryMgr := skills.NewRegistryManagerFromConfig(skills.RegistryConfig{
@@ -127,9 +303,21 @@ func registerSharedTools(cfg *config.Config, msgBus *bus.MessageBus, registry *A
 		agent.Tools.Register(tools.NewFindSkillsTool(registryMgr, searchCache))
 		agent.Tools.Register(tools.NewInstallSkillTool(registryMgr, agent.Workspace))
 
+		// User data deletion
+		var liveLog *audit.Log
+		if auditLog != nil && agent.Workspace == registry.GetDefaultAgent().Workspace {
+			liveLog = auditLog
+		}
+		forgetTool := tools.NewForgetTool(NewUserPurger(agent, liveLog))
+		forgetTool.SetAdminCheck(func(channel, senderID string) bool {
+			return isAdmin(cfg.Admin.Users, bus.InboundMessage{Channel: channel, SenderID: senderID})
+		})
+		agent.Tools.Register(forgetTool)
+
 		// Spawn tool with allowlist checker
 		subagentManager := tools.NewSubagentManager(provider, agent.Model, agent.Workspace, msgBus)
 		subagentManager.SetLLMOptions(agent.MaxTokens, agent.Temperature)
+		subagentManager.SetMaxRunning(cfg.Agents.Defaults.MaxSubagents)
 		spawnTool := tools.NewSpawnTool(subagentManager)
 		currentAgentID := agentID
 		spawnTool.SetAllowlistChecker(func(targetAgentID string) bool {
@@ -155,9 +343,21 @@ func (al *AgentLoop) Run(ctx context.Context) error {
 				continue
 			}
 
+			if al.holdForMaintenance(msg) {
+				continue
+			}
+
 			response, err := al.processMessage(ctx, msg)
+			alert := ""
 			if err != nil {
-				response = fmt.Sprintf("Error processing message: %v", err)
+				response = templates.Render(al.cfg.WorkspacePath(), templates.Message{
+					Kind:    templates.KindError,
+					Channel: msg.Channel,
+					ChatID:  msg.ChatID,
+					Content: fmt.Sprintf("Error processing message: %v", err),
+					Data:    err.Error(),
+				})
+				alert = bus.AlertError
 			}
 
 			if response != "" {
@@ -176,12 +376,16 @@ func (al *AgentLoop) Run(ctx context.Context) error {
 
 				if !alreadySent {
 					al.bus.PublishOutbound(bus.OutboundMessage{
-						Channel: msg.Channel,
-						ChatID:  msg.ChatID,
-						Content: response,
+						Channel:  msg.Channel,
+						ChatID:   msg.ChatID,
+						Content:  response,
+						Alert:    alert,
+						EndsTurn: true,
 					})
+					continue
 				}
 			}
+			al.bus.PublishOutbound(bus.OutboundMessage{Channel: msg.Channel, ChatID: msg.ChatID, EndsTurn: true})
 		}
 	}
 
@@ -202,6 +406,27 @@ func (al *AgentLoop) RegisterTool(tool tools.Tool) {
 
 func (al *AgentLoop) SetChannelManager(cm *channels.Manager) {
 	al.channelManager = cm
+
+	// Workflow approval steps, and tool steps of tools needing approval,
+	// ask through the channel's buttons
+	for _, agentID := range al.registry.ListAgentIDs() {
+		agent, _ := al.registry.GetAgent(agentID)
+		if t, ok := agent.Tools.Get("run_workflow"); ok {
+			t.(*tools.RunWorkflowTool).SetConfirm(al.confirm)
+			t.(*tools.RunWorkflowTool).SetToolGate(al.confirmWorkflowTool)
+		}
+	}
+}
+
+// SetConfigPath tells the loop which file its config came from, so admins
+// can reload it from chat.
+func (al *AgentLoop) SetConfigPath(path string) {
+	al.configPath = path
+}
+
+// GetRegistry returns the agent registry.
+func (al *AgentLoop) GetRegistry() *AgentRegistry {
+	return al.registry
 }
 
 // RecordLastChannel records the last active channel for this workspace.
@@ -213,6 +438,63 @@ func (al *AgentLoop) RecordLastChannel(channel string) error {
 	return al.state.SetLastChannel(channel)
 }
 
+// stateFor returns the state of agent's workspace, so an agent with a
+// workspace of its own, such as a tenant's, never becomes the default
+// workspace's last channel that the heartbeat and alerts go to.
+func (al *AgentLoop) stateFor(agent *AgentInstance) *state.Manager {
+	if al.state == nil {
+		return nil
+	}
+	if def := al.registry.GetDefaultAgent(); agent == nil || def == nil || agent.Workspace == def.Workspace {
+		return al.state
+	}
+	if sm, ok := al.states.Load(agent.Workspace); ok {
+		return sm.(*state.Manager)
+	}
+	sm, _ := al.states.LoadOrStore(agent.Workspace, state.NewManager(agent.Workspace))
+	return sm.(*state.Manager)
+}
+
+// WorkspaceFor returns the workspace of the agent that handles a chat: the
+// one its last message was routed to, or the default agent's. Services
+// use it to find what the agent's tools stored for the chat.
+func (al *AgentLoop) WorkspaceFor(channel, chatID string) string {
+	if ws, ok := al.chatWorkspaces.Load(channel + ":" + chatID); ok {
+		return ws.(string)
+	}
+	if agent := al.registry.GetDefaultAgent(); agent != nil {
+		return agent.Workspace
+	}
+	return al.cfg.WorkspacePath()
+}
+
+// Workspaces returns the distinct workspaces of the agents, the default
+// agent's first, for services that run in each of them.
+func (al *AgentLoop) Workspaces() []string {
+	var workspaces []string
+	seen := make(map[string]bool)
+	add := func(ws string) {
+		if ws != "" && !seen[ws] {
+			seen[ws] = true
+			workspaces = append(workspaces, ws)
+		}
+	}
+	if agent := al.registry.GetDefaultAgent(); agent != nil {
+		add(agent.Workspace)
+	}
+	ids := al.registry.ListAgentIDs()
+	sort.Strings(ids)
+	for _, id := range ids {
+		if agent, ok := al.registry.GetAgent(id); ok {
+			add(agent.Workspace)
+		}
+	}
+	if len(workspaces) == 0 {
+		add(al.cfg.WorkspacePath())
+	}
+	return workspaces
+}
+
 // RecordLastChatID records the last active chat ID for this workspace.
 // This uses the atomic state save mechanism to prevent data loss on crash.
 func (al *AgentLoop) RecordLastChatID(chatID string) error {
@@ -275,9 +557,34 @@ func (al *AgentLoop) processMessage(ctx context.Context, msg bus.InboundMessage)
 		return al.processSystemMessage(ctx, msg)
 	}
 
-	// Check for commands
-	if response, handled := al.handleCommand(ctx, msg); handled {
-		return response, nil
+	// Control events carry a reply's text, not user input
+	if msg.Control == "" {
+		// Check for feedback (👍/👎 and follow-up comments)
+		if response, handled := al.handleFeedback(msg); handled {
+			return response, nil
+		}
+
+		if isVersionCommand(msg.Content) {
+			return al.versionReply(), nil
+		}
+
+		// Admin commands answer their sender privately
+		if al.handleAdminCommand(ctx, msg) {
+			return "", nil
+		}
+
+		if response, handled := al.handleLinkCommand(msg); handled {
+			return response, nil
+		}
+
+		if response, handled := al.handleRemindersCommand(msg); handled {
+			return response, nil
+		}
+
+		// Check for commands
+		if response, handled := al.handleCommand(ctx, msg); handled {
+			return response, nil
+		}
 	}
 
 	// Route to determine agent and session key
@@ -286,14 +593,18 @@ func (al *AgentLoop) processMessage(ctx context.Context, msg bus.InboundMessage)
 		AccountID:  msg.Metadata["account_id"],
 		Peer:       extractPeer(msg),
 		ParentPeer: extractParentPeer(msg),
-		GuildID:    msg.Metadata["guild_id"],
+		GuildID:    guildID(msg),
 		TeamID:     msg.Metadata["team_id"],
+		SenderID:   msg.SenderID,
 	})
 
 	agent, ok := al.registry.GetAgent(route.AgentID)
 	if !ok {
 		agent = al.registry.GetDefaultAgent()
 	}
+	if !constants.IsInternalChannel(msg.Channel) {
+		al.chatWorkspaces.Store(msg.Channel+":"+msg.ChatID, agent.Workspace)
+	}
 
 	// Use routed session key, but honor pre-set agent-scoped keys (for ProcessDirect/cron)
 	sessionKey := route.SessionKey
@@ -308,14 +619,105 @@ func (al *AgentLoop) processMessage(ctx context.Context, msg bus.InboundMessage)
 			"matched_by":  route.MatchedBy,
 		})
 
+	// !creativity, !budget, !routing, !factcheck and !pin are per session, so they are handled
+	// once the session is known
+	if msg.Control == "" && isCreativityCommand(msg.Content) {
+		return al.handleCreativity(agent, sessionKey, msg.Content), nil
+	}
+
+	if msg.Control == "" && isBudgetCommand(msg.Content) {
+		return al.contextBudget(agent, sessionKey, msg.Metadata["persona"]).String(), nil
+	}
+
+	if msg.Control == "" && isRoutingCommand(msg.Content) {
+		return al.handleRouting(agent, sessionKey, msg.Content), nil
+	}
+
+	if msg.Control == "" && isFactCheckCommand(msg.Content) {
+		return al.handleFactCheck(agent, sessionKey, msg.Content), nil
+	}
+
+	if msg.Control == "" && isPinCommand(msg.Content) {
+		return al.handlePinCommand(agent, sessionKey, msg), nil
+	}
+	factCheck := agent.Sessions.GetFactCheck(sessionKey)
+	if msg.Control == "" {
+		if rest, ok := stripVerifyPrefix(msg.Content); ok {
+			msg.Content, factCheck = rest, true
+		}
+	}
+
+	// /prompt expands into the user's message
+	if msg.Control == "" && isPromptCommand(msg.Content) {
+		expanded, reply := expandPrompt(agent.Workspace, msg.Content)
+		if reply != "" {
+			return reply, nil
+		}
+		msg.Content = expanded
+	} else if msg.Control == "" {
+		// Slash commands registered for a tool or skill ask the agent to use it
+		if expanded, ok := al.expandToolCommand(msg.Content); ok {
+			msg.Content = expanded
+		}
+	}
+
+	if reply, over := al.overQuota(agent); over {
+		return reply, nil
+	}
+
+	if msg.Control == "" {
+		if correction, ok := parseFixCommand(msg.Content); ok {
+			return al.fixReply(ctx, agent, sessionKey, msg, correction), nil
+		}
+	}
+
+	content := msg.Content
+	switch msg.Control {
+	case bus.ControlPin:
+		return al.pinReply(agent, sessionKey, msg), nil
+	case bus.ControlRemember:
+		return al.rememberMessage(agent, msg), nil
+	case bus.ControlSummarize:
+		return al.summarizeChat(ctx, agent, msg), nil
+	case bus.ControlRegenerate:
+		// The saved user message already includes its expanded links
+		previous, ok := al.rewindLastTurn(agent, sessionKey, msg.Content)
+		if !ok {
+			return "Only my latest reply here can be regenerated.", nil
+		}
+		content = previous
+	default:
+		if al.links != nil {
+			// Link content counts as a web fetch for timeout purposes
+			linkCtx, cancel := withTimeout(ctx, al.cfg.Timeouts.ToolTimeout("web_fetch"))
+			content += al.links.Expand(linkCtx, msg.Content)
+			cancel()
+		}
+		if al.cfg.Tools.Journal.Enabled {
+			content = al.journalContext(agent, msg, content)
+		}
+		if al.cfg.Tools.FollowUps.Enabled {
+			content = al.resumeFollowUps(agent, msg, content)
+		}
+		if factCheck {
+			content = factCheckNote + "\n" + content
+		}
+	}
+
 	return al.runAgentLoop(ctx, agent, processOptions{
 		SessionKey:      sessionKey,
 		Channel:         msg.Channel,
 		ChatID:          msg.ChatID,
-		UserMessage:     msg.Content,
+		UserMessage:     content,
 		DefaultResponse: "I've completed processing but have no response to give.",
 		EnableSummary:   true,
 		SendResponse:    false,
+		Stream:          al.streams(),
+		SenderID:        msg.SenderID,
+		Media:           msg.Media,
+		MaxIterations:   requestMaxIterations(msg.Metadata, agent.MaxIterations),
+		Persona:         msg.Metadata["persona"],
+		GuildID:         guildID(msg),
 	})
 }
 
@@ -382,37 +784,65 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, agent *AgentInstance, opt
 		// Don't record internal channels (cli, system, subagent)
 		if !constants.IsInternalChannel(opts.Channel) {
 			channelKey := fmt.Sprintf("%s:%s", opts.Channel, opts.ChatID)
-			if err := al.RecordLastChannel(channelKey); err != nil {
-				logger.WarnCF("agent", "Failed to record last channel", map[string]interface{}{"error": err.Error()})
+			if sm := al.stateFor(agent); sm != nil {
+				if err := sm.SetLastChannel(channelKey); err != nil {
+					logger.WarnCF("agent", "Failed to record last channel", map[string]interface{}{"error": err.Error()})
+				}
 			}
 		}
 	}
 
 	// 1. Update tool contexts
-	al.updateToolContexts(agent, opts.Channel, opts.ChatID)
+	al.updateToolContexts(agent, opts.Channel, opts.ChatID, opts.SenderID)
 
 	// 2. Build messages (skip history for heartbeat)
 	var history []providers.Message
 	var summary string
+	var pins []session.Pin
 	if !opts.NoHistory {
 		history = agent.Sessions.GetHistory(opts.SessionKey)
 		summary = agent.Sessions.GetSummary(opts.SessionKey)
+		pins = agent.Sessions.GetPins(opts.SessionKey)
 	}
 	messages := agent.ContextBuilder.BuildMessages(
 		history,
 		summary,
 		opts.UserMessage,
-		nil,
+		opts.Media,
 		opts.Channel,
 		opts.ChatID,
+		opts.Persona,
 	)
+	messages = withPins(messages, pins, history, privacy.LoadTombstones(agent.Workspace))
+	messages = agent.ContextBuilder.withGuildMemory(messages, opts.GuildID)
 
 	// 3. Save user message to session
 	agent.Sessions.AddMessage(opts.SessionKey, "user", opts.UserMessage)
 
-	// 4. Run LLM iteration loop
-	finalContent, iteration, err := al.runLLMIteration(ctx, agent, messages, opts)
+	// 4. Run LLM iteration loop, assigning user turns to a canary arm
+	if al.canary != nil && opts.Turn == nil && !constants.IsInternalChannel(opts.Channel) {
+		_, model := agent.CurrentModel()
+		opts.Turn = al.canary.Begin(opts.Channel, opts.ChatID, model)
+	}
+	var toolCalls []string
+	if opts.ToolCalls == nil {
+		opts.ToolCalls = &toolCalls
+	}
+	var stopped string
+	if opts.Stopped == nil {
+		opts.Stopped = &stopped
+	}
+	turnCtx, cancelTurn := al.turnContext(ctx)
+	defer cancelTurn()
+	finalContent, iteration, err := al.runLLMIteration(turnCtx, agent, messages, opts)
+	if err != nil && errors.Is(turnCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("turn timed out after %s: %w", al.cfg.Timeouts.TurnTimeout(), err)
+	}
+	if opts.Turn != nil {
+		al.canary.Finish(opts.Turn, iteration, err)
+	}
 	if err != nil {
+		al.recordTurn(agent, opts, "", iteration, err)
 		return "", err
 	}
 
@@ -423,13 +853,17 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, agent *AgentInstance, opt
 	if finalContent == "" {
 		finalContent = opts.DefaultResponse
 	}
+	finalContent = al.guardQuotes(agent, opts.SessionKey, finalContent)
 
-	// 6. Save final assistant message to session
+	// 6. Save final assistant message to session and the audit log
 	agent.Sessions.AddMessage(opts.SessionKey, "assistant", finalContent)
+	al.compactToolHistory(agent, opts.SessionKey)
 	agent.Sessions.Save(opts.SessionKey)
+	al.recordTurn(agent, opts, finalContent, iteration, nil)
 
-	// 7. Optional: summarization
+	// 7. Optional: topic checkpoint and summarization
 	if opts.EnableSummary {
+		al.maybeCheckpointTopic(agent, opts.SessionKey, opts.Channel)
 		al.maybeSummarize(agent, opts.SessionKey, opts.Channel, opts.ChatID)
 	}
 
@@ -459,30 +893,64 @@ func (al *AgentLoop) runAgentLoop(ctx context.Context, agent *AgentInstance, opt
 func (al *AgentLoop) runLLMIteration(ctx context.Context, agent *AgentInstance, messages []providers.Message, opts processOptions) (string, int, error) {
 	iteration := 0
 	var finalContent string
+	provider, model := agent.CurrentModel()
+	// The fallback chain is for the configured model, not one !model chose
+	useFallbacks := len(agent.Candidates) > 1 && al.fallback != nil && provider == agent.Provider && model == agent.Model
 
-	for iteration < agent.MaxIterations {
+	maxIterations := agent.MaxIterations
+	if opts.MaxIterations > 0 && opts.MaxIterations < maxIterations {
+		maxIterations = opts.MaxIterations
+	}
+	guard := newLoopGuard(al.cfg.Agents.Defaults.LoopDetection)
+	budget := newTurnBudget(al.cfg.Agents.Defaults.TurnLimits)
+	// Calls and tools stop at the time limit; only the wrap-up runs past it
+	workCtx, cancelWork := budget.context(ctx)
+	defer cancelWork()
+	cites := newCitations(al.cfg.Tools.Web.Citations)
+	wrapUp := ""
+	cheap, escalate := al.routeTurn(agent, messages, opts)
+
+	for iteration < maxIterations {
 		iteration++
 
 		logger.DebugCF("agent", "LLM iteration",
 			map[string]interface{}{
 				"agent_id":  agent.ID,
 				"iteration": iteration,
-				"max":       agent.MaxIterations,
+				"max":       maxIterations,
 			})
 
 		// Build tool definitions
 		providerToolDefs := agent.Tools.ToProviderDefs()
+		llmOpts := al.llmOptions(agent, opts.SessionKey)
+
+		// A turn over its limits gets one last call, without tools
+		callCtx := workCtx
+		if reason := budget.exceeded(messages); reason != "" {
+			wrapUp = reason
+			var cancelWrapUp context.CancelFunc
+			callCtx, cancelWrapUp = context.WithTimeout(ctx, wrapUpGrace)
+			defer cancelWrapUp()
+			providerToolDefs = nil
+			messages = append(messages, providers.Message{Role: "user", Content: fmt.Sprintf(wrapUpPrompt, reason)})
+			logger.WarnCF("agent", "Turn limit reached, wrapping up",
+				map[string]interface{}{
+					"agent_id":  agent.ID,
+					"reason":    reason,
+					"iteration": iteration,
+				})
+		}
 
 		// Log LLM request details
 		logger.DebugCF("agent", "LLM request",
 			map[string]interface{}{
 				"agent_id":          agent.ID,
 				"iteration":         iteration,
-				"model":             agent.Model,
+				"model":             model,
 				"messages_count":    len(messages),
 				"tools_count":       len(providerToolDefs),
 				"max_tokens":        agent.MaxTokens,
-				"temperature":       agent.Temperature,
+				"temperature":       llmOpts["temperature"],
 				"system_prompt_len": len(messages[0].Content),
 			})
 
@@ -499,13 +967,25 @@ func (al *AgentLoop) runLLMIteration(ctx context.Context, agent *AgentInstance,
 		var err error
 
 		callLLM := func() (*providers.LLMResponse, error) {
-			if len(agent.Candidates) > 1 && al.fallback != nil {
-				fbResult, fbErr := al.fallback.Execute(ctx, agent.Candidates,
-					func(ctx context.Context, provider, model string) (*providers.LLMResponse, error) {
-						return agent.Provider.Chat(ctx, messages, providerToolDefs, model, map[string]interface{}{
-							"max_tokens":  agent.MaxTokens,
-							"temperature": agent.Temperature,
-						})
+			if opts.Turn.IsCanary() {
+				callCtx, cancel := al.providerContext(callCtx)
+				defer cancel()
+				return al.chat(callCtx, opts.Turn.Provider, messages, providerToolDefs, opts.Turn.Model, llmOpts, opts)
+			}
+			if cheap != nil {
+				callCtx, cancel := al.providerContext(callCtx)
+				defer cancel()
+				// A reply that may yet be escalated isn't shown early
+				cheapOpts := opts
+				cheapOpts.Stream = opts.Stream && !escalate
+				return al.chat(callCtx, cheap.provider, messages, providerToolDefs, cheap.model, llmOpts, cheapOpts)
+			}
+			if useFallbacks {
+				fbResult, fbErr := al.fallback.Execute(callCtx, agent.Candidates,
+					func(ctx context.Context, _, candidate string) (*providers.LLMResponse, error) {
+						callCtx, cancel := al.providerContext(ctx)
+						defer cancel()
+						return al.chat(callCtx, provider, messages, providerToolDefs, candidate, llmOpts, opts)
 					},
 				)
 				if fbErr != nil {
@@ -518,17 +998,16 @@ func (al *AgentLoop) runLLMIteration(ctx context.Context, agent *AgentInstance,
 				}
 				return fbResult.Response, nil
 			}
-			return agent.Provider.Chat(ctx, messages, providerToolDefs, agent.Model, map[string]interface{}{
-				"max_tokens":  agent.MaxTokens,
-				"temperature": agent.Temperature,
-			})
+			callCtx, cancel := al.providerContext(callCtx)
+			defer cancel()
+			return al.chat(callCtx, provider, messages, providerToolDefs, model, llmOpts, opts)
 		}
 
 		// Retry loop for context/token errors
 		maxRetries := 2
 		for retry := 0; retry <= maxRetries; retry++ {
 			response, err = callLLM()
-			if err == nil {
+			if err == nil || callCtx.Err() != nil {
 				break
 			}
 
@@ -557,13 +1036,20 @@ func (al *AgentLoop) runLLMIteration(ctx context.Context, agent *AgentInstance,
 				newSummary := agent.Sessions.GetSummary(opts.SessionKey)
 				messages = agent.ContextBuilder.BuildMessages(
 					newHistory, newSummary, "",
-					nil, opts.Channel, opts.ChatID,
+					nil, opts.Channel, opts.ChatID, opts.Persona,
 				)
+				messages = withPins(messages, agent.Sessions.GetPins(opts.SessionKey), newHistory, privacy.LoadTombstones(agent.Workspace))
+				messages = agent.ContextBuilder.withGuildMemory(messages, opts.GuildID)
 				continue
 			}
 			break
 		}
 
+		// A call cut off by the time limit makes way for the wrap-up
+		if err != nil && wrapUp == "" && workCtx.Err() != nil && ctx.Err() == nil {
+			iteration--
+			continue
+		}
 		if err != nil {
 			logger.ErrorCF("agent", "LLM call failed",
 				map[string]interface{}{
@@ -573,6 +1059,39 @@ func (al *AgentLoop) runLLMIteration(ctx context.Context, agent *AgentInstance,
 				})
 			return "", iteration, fmt.Errorf("LLM call failed after retries: %w", err)
 		}
+		opts.Turn.AddUsage(response.Usage)
+		al.recordUsage(agent, response.Usage)
+		if u := response.Usage; u != nil && (u.CacheReadTokens > 0 || u.CacheWriteTokens > 0) {
+			logger.DebugCF("agent", "Prompt cache usage",
+				map[string]interface{}{
+					"agent_id":      agent.ID,
+					"prompt_tokens": u.PromptTokens,
+					"cache_read":    u.CacheReadTokens,
+					"cache_write":   u.CacheWriteTokens,
+				})
+		}
+		budget.record(response.Usage, messages, response.Content)
+
+		// A simple turn that turns out to need tools goes to the agent's
+		// model, which makes this call again
+		if cheap != nil && escalate && len(response.ToolCalls) > 0 && wrapUp == "" {
+			logger.InfoCF("agent", "Escalating turn from the cheap model",
+				map[string]interface{}{
+					"agent_id": agent.ID,
+					"model":    cheap.model,
+				})
+			cheap = nil
+			iteration--
+			continue
+		}
+
+		if wrapUp != "" {
+			finalContent = response.Content + wrapUpNote(wrapUp)
+			if opts.Stopped != nil {
+				*opts.Stopped = "reached " + wrapUp
+			}
+			break
+		}
 
 		// Check if no tool calls - we're done
 		if len(response.ToolCalls) == 0 {
@@ -596,6 +1115,9 @@ func (al *AgentLoop) runLLMIteration(ctx context.Context, agent *AgentInstance,
 		for _, tc := range normalizedToolCalls {
 			toolNames = append(toolNames, tc.Name)
 		}
+		if opts.ToolCalls != nil {
+			*opts.ToolCalls = append(*opts.ToolCalls, toolNames...)
+		}
 		logger.InfoCF("agent", "LLM requested tool calls",
 			map[string]interface{}{
 				"agent_id":  agent.ID,
@@ -636,8 +1158,17 @@ func (al *AgentLoop) runLLMIteration(ctx context.Context, agent *AgentInstance,
 		// Save assistant message with tool calls to session
 		agent.Sessions.AddFullMessage(opts.SessionKey, assistantMsg)
 
-		// Execute tool calls
-		for _, tc := range normalizedToolCalls {
+		// Check the calls in order, then run them, several at once when the
+		// model asked for more than one, and add the results in call order.
+		loopReason := ""
+		results := make([]*tools.ToolResult, len(normalizedToolCalls))
+		skip := make([]bool, len(normalizedToolCalls))
+		for i, tc := range normalizedToolCalls {
+			if loopReason != "" {
+				skip[i] = true
+				continue
+			}
+
 			argsJSON, _ := json.Marshal(tc.Arguments)
 			argsPreview := utils.Truncate(string(argsJSON), 200)
 			logger.InfoCF("agent", fmt.Sprintf("Tool call: %s(%s)", tc.Name, argsPreview),
@@ -647,23 +1178,33 @@ func (al *AgentLoop) runLLMIteration(ctx context.Context, agent *AgentInstance,
 					"iteration": iteration,
 				})
 
-			// Create async callback for tools that implement AsyncTool
-			// NOTE: Following openclaw's design, async tools do NOT send results directly to users.
-			// Instead, they notify the agent via PublishInbound, and the agent decides
-			// whether to forward the result to the user (in processSystemMessage).
-			asyncCallback := func(callbackCtx context.Context, result *tools.ToolResult) {
-				// Log the async completion but don't send directly to user
-				// The agent will handle user notification via processSystemMessage
-				if !result.Silent && result.ForUser != "" {
-					logger.InfoCF("agent", "Async tool completed, agent will handle notification",
-						map[string]interface{}{
-							"tool":        tc.Name,
-							"content_len": len(result.ForUser),
-						})
-				}
+			if content, ok := tc.Arguments["content"].(string); ok && tc.Name == "message" {
+				tc.Arguments["content"] = al.guardQuotes(agent, opts.SessionKey, content)
 			}
+			results[i] = al.confirmTool(ctx, tc, opts)
 
-			toolResult := agent.Tools.ExecuteWithContext(ctx, tc.Name, tc.Arguments, opts.Channel, opts.ChatID, asyncCallback)
+			if reason := guard.observe(tc); reason != "" {
+				loopReason = reason
+				logger.WarnCF("agent", "Tool loop detected, stopping the turn",
+					map[string]interface{}{
+						"agent_id":  agent.ID,
+						"reason":    reason,
+						"iteration": iteration,
+					})
+			}
+		}
+
+		al.executeToolCalls(workCtx, agent, normalizedToolCalls, results, skip, opts)
+
+		for i, tc := range normalizedToolCalls {
+			if skip[i] {
+				// Every call needs a result for the history to stay valid
+				skipped := providers.Message{Role: "tool", Content: "Not run: the turn was stopped.", ToolCallID: tc.ID}
+				messages = agent.ContextBuilder.AddToolResult(messages, tc.ID, tc.Name, skipped.Content)
+				agent.Sessions.AddFullMessage(opts.SessionKey, skipped)
+				continue
+			}
+			toolResult := results[i]
 
 			// Send ForUser content to user immediately if not Silent
 			if !toolResult.Silent && toolResult.ForUser != "" && opts.SendResponse {
@@ -684,38 +1225,94 @@ func (al *AgentLoop) runLLMIteration(ctx context.Context, agent *AgentInstance,
 			if contentForLLM == "" && toolResult.Err != nil {
 				contentForLLM = toolResult.Err.Error()
 			}
+			contentForLLM = cites.observe(tc, toolResult, contentForLLM)
+
+			messages = agent.ContextBuilder.AddToolResult(messages, tc.ID, tc.Name, contentForLLM)
 
-			toolResultMsg := providers.Message{
+			// Save tool result message to session
+			agent.Sessions.AddFullMessage(opts.SessionKey, providers.Message{
 				Role:       "tool",
 				Content:    contentForLLM,
 				ToolCallID: tc.ID,
-			}
-			messages = append(messages, toolResultMsg)
+			})
+		}
 
-			// Save tool result message to session
-			agent.Sessions.AddFullMessage(opts.SessionKey, toolResultMsg)
+		if loopReason != "" {
+			if opts.Stopped != nil {
+				*opts.Stopped = loopReason
+			}
+			return loopStoppedReply(loopReason), iteration, nil
 		}
 	}
 
-	return finalContent, iteration, nil
+	return cites.annotate(finalContent), iteration, nil
 }
 
-// updateToolContexts updates the context for tools that need channel/chatID info.
-func (al *AgentLoop) updateToolContexts(agent *AgentInstance, channel, chatID string) {
-	// Use ContextualTool interface instead of type assertions
-	if tool, ok := agent.Tools.Get("message"); ok {
-		if mt, ok := tool.(tools.ContextualTool); ok {
-			mt.SetContext(channel, chatID)
+// executeToolCalls runs the calls that are neither skipped nor already
+// answered by confirmation, in order, and stores each result at the call's
+// index. With agents.defaults.max_parallel_tools above 1, calls of tools
+// that declare themselves concurrent run together up to that many at
+// once; any other call waits for the ones before it and runs alone, so a
+// read after a write sees the write.
+func (al *AgentLoop) executeToolCalls(ctx context.Context, agent *AgentInstance, calls []providers.ToolCall, results []*tools.ToolResult, skip []bool, opts processOptions) {
+	limit := al.cfg.Agents.Defaults.MaxParallelTools
+	sem := make(chan struct{}, max(limit, 1))
+	var wg sync.WaitGroup
+	for i, tc := range calls {
+		if skip[i] || results[i] != nil {
+			continue
+		}
+		if limit <= 1 || !agent.Tools.Concurrent(tc.Name) {
+			wg.Wait()
+			results[i] = al.executeTool(ctx, agent, tc, opts)
+			continue
 		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, tc providers.ToolCall) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = al.executeTool(ctx, agent, tc, opts)
+		}(i, tc)
 	}
-	if tool, ok := agent.Tools.Get("spawn"); ok {
-		if st, ok := tool.(tools.ContextualTool); ok {
-			st.SetContext(channel, chatID)
+	wg.Wait()
+}
+
+// executeTool runs one tool call with its timeout.
+func (al *AgentLoop) executeTool(ctx context.Context, agent *AgentInstance, tc providers.ToolCall, opts processOptions) *tools.ToolResult {
+	// Create async callback for tools that implement AsyncTool
+	// NOTE: Following openclaw's design, async tools do NOT send results directly to users.
+	// Instead, they notify the agent via PublishInbound, and the agent decides
+	// whether to forward the result to the user (in processSystemMessage).
+	asyncCallback := func(callbackCtx context.Context, result *tools.ToolResult) {
+		// Log the async completion but don't send directly to user
+		// The agent will handle user notification via processSystemMessage
+		if !result.Silent && result.ForUser != "" {
+			logger.InfoCF("agent", "Async tool completed, agent will handle notification",
+				map[string]interface{}{
+					"tool":        tc.Name,
+					"content_len": len(result.ForUser),
+				})
 		}
 	}
-	if tool, ok := agent.Tools.Get("subagent"); ok {
-		if st, ok := tool.(tools.ContextualTool); ok {
-			st.SetContext(channel, chatID)
+
+	toolCtx, cancelTool := al.toolContext(ctx, agent, tc.Name)
+	defer cancelTool()
+	return agent.Tools.ExecuteWithContext(toolCtx, tc.Name, tc.Arguments, opts.Channel, opts.ChatID, asyncCallback)
+}
+
+// updateToolContexts updates the context for tools that need channel/chatID
+// or sender info, once per turn. Each call also carries its chat in its
+// context (tools.WithChat), which the tools prefer.
+func (al *AgentLoop) updateToolContexts(agent *AgentInstance, channel, chatID, senderID string) {
+	for _, name := range agent.Tools.List() {
+		if tool, ok := agent.Tools.Get(name); ok {
+			if ct, ok := tool.(tools.ContextualTool); ok {
+				ct.SetContext(channel, chatID)
+			}
+			if st, ok := tool.(tools.SenderContextualTool); ok {
+				st.SetSender(senderID)
+			}
 		}
 	}
 }
@@ -920,7 +1517,8 @@ func (al *AgentLoop) summarizeSession(agent *AgentInstance, sessionKey string) {
 		s2, _ := al.summarizeBatch(ctx, agent, part2, "")
 
 		mergePrompt := fmt.Sprintf("Merge these two conversation summaries into one cohesive summary:\n\n1: %s\n\n2: %s", s1, s2)
-		resp, err := agent.Provider.Chat(ctx, []providers.Message{{Role: "user", Content: mergePrompt}}, nil, agent.Model, map[string]interface{}{
+		provider, model := agent.CurrentModel()
+		resp, err := provider.Chat(ctx, []providers.Message{{Role: "user", Content: mergePrompt}}, nil, model, map[string]interface{}{
 			"max_tokens":  1024,
 			"temperature": 0.3,
 		})
@@ -933,6 +1531,9 @@ func (al *AgentLoop) summarizeSession(agent *AgentInstance, sessionKey string) {
 		finalSummary, _ = al.summarizeBatch(ctx, agent, validMessages, summary)
 	}
 
+	// Don't let a summary carry forward anything about purged users
+	finalSummary = privacy.LoadTombstones(agent.Workspace).Redact(finalSummary)
+
 	if omitted && finalSummary != "" {
 		finalSummary += "\n[Note: Some oversized messages were omitted from this summary for efficiency.]"
 	}
@@ -959,7 +1560,8 @@ func (al *AgentLoop) summarizeBatch(ctx context.Context, agent *AgentInstance, b
 	}
 	prompt := sb.String()
 
-	response, err := agent.Provider.Chat(ctx, []providers.Message{{Role: "user", Content: prompt}}, nil, agent.Model, map[string]interface{}{
+	provider, model := agent.CurrentModel()
+	response, err := provider.Chat(ctx, []providers.Message{{Role: "user", Content: prompt}}, nil, model, map[string]interface{}{
 		"max_tokens":  1024,
 		"temperature": 0.3,
 	})
@@ -1006,7 +1608,8 @@ func (al *AgentLoop) handleCommand(ctx context.Context, msg bus.InboundMessage)
 			if defaultAgent == nil {
 				return "No default agent configured", true
 			}
-			return fmt.Sprintf("Current model: %s", defaultAgent.Model), true
+			_, model := defaultAgent.CurrentModel()
+			return fmt.Sprintf("Current model: %s", model), true
 		case "channel":
 			return fmt.Sprintf("Current channel: %s", msg.Channel), true
 		case "agents":
@@ -1052,8 +1655,8 @@ func (al *AgentLoop) handleCommand(ctx context.Context, msg bus.InboundMessage)
 			if defaultAgent == nil {
 				return "No default agent configured", true
 			}
-			oldModel := defaultAgent.Model
-			defaultAgent.Model = value
+			provider, oldModel := defaultAgent.CurrentModel()
+			defaultAgent.SwitchModel(provider, value)
 			return fmt.Sprintf("Switched model from %s to %s", oldModel, value), true
 		case "channel":
 			if al.channelManager == nil {
@@ -1066,11 +1669,150 @@ func (al *AgentLoop) handleCommand(ctx context.Context, msg bus.InboundMessage)
 		default:
 			return fmt.Sprintf("Unknown switch target: %s", target), true
 		}
+
+	case "/announcements":
+		return al.handleAnnouncementsCommand(msg, args), true
+
+	case "/maintenance":
+		if !isAdmin(al.cfg.Admin.Users, msg) {
+			return "Only admins can use this command.", true
+		}
+		return al.handleMaintenanceCommand(args), true
+
+	case "/feedback":
+		return al.handleFeedbackCommand(msg, args), true
 	}
 
 	return "", false
 }
 
+// handleAnnouncementsCommand lets a chat opt in or out of owner broadcasts.
+func (al *AgentLoop) handleAnnouncementsCommand(msg bus.InboundMessage, args []string) string {
+	if al.announcements == nil {
+		return "Announcements are not available"
+	}
+	if constants.IsInternalChannel(msg.Channel) {
+		return "Announcements are only delivered to chat channels"
+	}
+
+	action := ""
+	if len(args) > 0 {
+		action = strings.ToLower(args[0])
+	}
+
+	switch action {
+	case "on":
+		added, err := al.announcements.Subscribe(msg.Channel, msg.ChatID, msg.SenderID)
+		if err != nil {
+			return fmt.Sprintf("Failed to subscribe: %v", err)
+		}
+		if !added {
+			return "This chat already receives announcements."
+		}
+		return "This chat will now receive announcements. Send /announcements off to stop."
+	case "off":
+		removed, err := al.announcements.Unsubscribe(msg.Channel, msg.ChatID)
+		if err != nil {
+			return fmt.Sprintf("Failed to unsubscribe: %v", err)
+		}
+		if !removed {
+			return "This chat was not receiving announcements."
+		}
+		return "This chat will no longer receive announcements."
+	case "", "status":
+		if al.announcements.IsSubscribed(msg.Channel, msg.ChatID) {
+			return "Announcements: on (send /announcements off to stop)"
+		}
+		return "Announcements: off (send /announcements on to opt in)"
+	default:
+		return "Usage: /announcements [on|off|status]"
+	}
+}
+
+// holdForMaintenance queues msg if maintenance mode is on and sends the away
+// message the first time a chat is queued. Admins' /maintenance and other
+// admin commands always go through, so maintenance can be checked on and
+// ended from chat.
+func (al *AgentLoop) holdForMaintenance(msg bus.InboundMessage) bool {
+	if al.maintenance == nil {
+		return false
+	}
+	status := al.maintenance.Status()
+	if !status.Enabled {
+		return false
+	}
+	if isAdmin(al.cfg.Admin.Users, msg) {
+		if fields := strings.Fields(msg.Content); len(fields) > 0 && fields[0] == "/maintenance" {
+			return false
+		}
+		if _, _, ok := parseAdminCommand(msg.Content); ok {
+			return false
+		}
+	}
+
+	first, err := al.maintenance.Enqueue(msg)
+	if err != nil {
+		logger.ErrorCF("maintenance", "Failed to queue message", map[string]interface{}{
+			"channel": msg.Channel,
+			"chat_id": msg.ChatID,
+			"error":   err.Error(),
+		})
+	}
+
+	if first && !constants.IsInternalChannel(msg.Channel) {
+		away := status.Message
+		if away == "" {
+			away = al.cfg.Maintenance.Message
+		}
+		if away != "" {
+			al.bus.PublishOutbound(bus.OutboundMessage{
+				Channel: msg.Channel,
+				ChatID:  msg.ChatID,
+				Content: away,
+			})
+		}
+	}
+	return true
+}
+
+// handleMaintenanceCommand turns maintenance mode on or off from chat.
+func (al *AgentLoop) handleMaintenanceCommand(args []string) string {
+	if al.maintenance == nil {
+		return "Maintenance mode is not available"
+	}
+
+	action := ""
+	if len(args) > 0 {
+		action = strings.ToLower(args[0])
+	}
+
+	switch action {
+	case "on":
+		if err := al.maintenance.Enable(strings.Join(args[1:], " ")); err != nil {
+			return fmt.Sprintf("Failed to enable maintenance mode: %v", err)
+		}
+		return "Maintenance mode on. Incoming messages will be queued until /maintenance off."
+	case "off":
+		n, err := al.maintenance.Disable()
+		if err != nil {
+			return fmt.Sprintf("Failed to disable maintenance mode: %v", err)
+		}
+		if n == 0 {
+			return "Maintenance mode off."
+		}
+		return fmt.Sprintf("Maintenance mode off. Processing %d queued message(s) shortly.", n)
+	case "", "status":
+		status := al.maintenance.Status()
+		if !status.Enabled {
+			return "Maintenance mode: off"
+		}
+		return fmt.Sprintf("Maintenance mode: on since %s, %d message(s) queued",
+			status.Since.Format("2006-01-02 15:04"), len(status.Queue))
+	default:
+		return "Usage: /maintenance [on [message]|off|status]"
+	}
+}
+
 // extractPeer extracts the routing peer from inbound message metadata.
 func extractPeer(msg bus.InboundMessage) *routing.RoutePeer {
 	peerKind := msg.Metadata["peer_kind"]
@@ -2,15 +2,30 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/channels"
 	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/cron"
+	"github.com/sipeed/picoclaw/pkg/kv"
+	"github.com/sipeed/picoclaw/pkg/privacy"
 	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/routing"
+	"github.com/sipeed/picoclaw/pkg/session"
+	"github.com/sipeed/picoclaw/pkg/state"
 	"github.com/sipeed/picoclaw/pkg/tools"
 )
 
@@ -613,3 +628,1771 @@ func TestAgentLoop_ContextExhaustionRetry(t *testing.T) {
 		t.Errorf("Expected history to be compressed (len < 8), got %d", len(finalHistory))
 	}
 }
+
+func TestProcessMessage_Controls(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         tmpDir,
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &simpleMockProvider{response: "Paris"})
+	helper := testHelper{al: al}
+	ctx := context.Background()
+	msg := bus.InboundMessage{Channel: "test", SenderID: "user1", ChatID: "chat1"}
+
+	regenerate := msg
+	regenerate.Control = bus.ControlRegenerate
+	if got := helper.executeAndGetResponse(t, ctx, regenerate); got != "Only my latest reply here can be regenerated." {
+		t.Errorf("regenerate on empty session = %q", got)
+	}
+
+	msg.Content = "capital of France?"
+	helper.executeAndGetResponse(t, ctx, msg)
+	older := regenerate
+	older.Content = "Berlin"
+	if got := helper.executeAndGetResponse(t, ctx, older); got != "Only my latest reply here can be regenerated." {
+		t.Errorf("regenerate of an older reply = %q", got)
+	}
+	regenerate.Content = "Paris"
+	if got := helper.executeAndGetResponse(t, ctx, regenerate); got != "Paris" {
+		t.Errorf("regenerate = %q", got)
+	}
+
+	agent := al.registry.GetDefaultAgent()
+	var users []string
+	for _, key := range agent.Sessions.Keys() {
+		for _, m := range agent.Sessions.GetHistory(key) {
+			if m.Role == "user" {
+				users = append(users, m.Content)
+			}
+		}
+	}
+	if len(users) != 1 || users[0] != "capital of France?" {
+		t.Errorf("user messages after regenerate = %v", users)
+	}
+
+	pin := msg
+	pin.Control = bus.ControlPin
+	pin.Content = "/help is a command, not to be run"
+	if got := helper.executeAndGetResponse(t, ctx, pin); got != "📌 Pinned and saved to memory." {
+		t.Errorf("pin = %q", got)
+	}
+	if note := NewMemoryStore(tmpDir).ReadToday(); !strings.Contains(note, "/help is a command, not to be run") {
+		t.Errorf("daily note = %q", note)
+	}
+}
+
+// optionsProvider remembers the options of the last call.
+type optionsProvider struct {
+	simpleMockProvider
+	last map[string]interface{}
+}
+
+func (m *optionsProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	m.last = opts
+	return m.simpleMockProvider.Chat(ctx, messages, tools, model, opts)
+}
+
+func TestProcessMessage_Creativity(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	provider := &optionsProvider{simpleMockProvider: simpleMockProvider{response: "42"}}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	helper := testHelper{al: al}
+	ctx := context.Background()
+	msg := bus.InboundMessage{Channel: "test", SenderID: "user1", ChatID: "chat1"}
+
+	ask := func(content string) string {
+		m := msg
+		m.Content = content
+		return helper.executeAndGetResponse(t, ctx, m)
+	}
+
+	if got := ask("!creativity"); !strings.Contains(got, "Creativity: normal") {
+		t.Errorf("!creativity = %q", got)
+	}
+	if got := ask("!creativity wild"); !strings.HasPrefix(got, "Usage:") {
+		t.Errorf("!creativity wild = %q", got)
+	}
+
+	ask("!creativity low")
+	ask("what is six times seven?")
+	if provider.last["temperature"] != 0.1 || provider.last["top_p"] != 0.5 {
+		t.Errorf("low options = %v", provider.last)
+	}
+
+	ask("!creativity normal")
+	ask("what is six times seven?")
+	if provider.last["temperature"] != 0.7 || provider.last["top_p"] != nil {
+		t.Errorf("normal options = %v", provider.last)
+	}
+}
+
+// userMessageProvider remembers the last user message it was sent.
+type userMessageProvider struct {
+	simpleMockProvider
+	last string
+}
+
+func (p *userMessageProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	for _, m := range messages {
+		if m.Role == "user" {
+			p.last = m.Content
+		}
+	}
+	return p.simpleMockProvider.Chat(ctx, messages, tools, model, opts)
+}
+
+func TestProcessMessage_FactCheck(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	provider := &userMessageProvider{simpleMockProvider: simpleMockProvider{response: "Paris"}}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	helper := testHelper{al: al}
+	ctx := context.Background()
+	msg := bus.InboundMessage{Channel: "test", SenderID: "user1", ChatID: "chat1"}
+
+	ask := func(content string) string {
+		m := msg
+		m.Content = content
+		return helper.executeAndGetResponse(t, ctx, m)
+	}
+
+	ask("capital of France?")
+	if strings.Contains(provider.last, "Fact-check mode") {
+		t.Errorf("plain message got the fact-check note: %q", provider.last)
+	}
+	ask("!verify capital of France?")
+	if provider.last != factCheckNote+"\ncapital of France?" {
+		t.Errorf("!verify message = %q", provider.last)
+	}
+
+	if got := ask("!factcheck on"); !strings.HasPrefix(got, "Fact-check mode on") {
+		t.Errorf("!factcheck on = %q", got)
+	}
+	ask("capital of Spain?")
+	if !strings.HasPrefix(provider.last, factCheckNote) {
+		t.Errorf("message in fact-check mode = %q", provider.last)
+	}
+	ask("!factcheck off")
+	if got := ask("!factcheck"); !strings.HasPrefix(got, "Fact-check mode is off") {
+		t.Errorf("!factcheck = %q", got)
+	}
+
+	if _, ok := stripVerifyPrefix("!verifying things"); ok {
+		t.Error("!verifying was taken for !verify")
+	}
+}
+
+// scriptedToolProvider requests the tool calls of calls[i] on its i-th
+// call, then answers "done". Offered no tools, it answers "best guess".
+type scriptedToolProvider struct {
+	calls [][]providers.ToolCall
+	n     int
+}
+
+func (m *scriptedToolProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	defer func() { m.n++ }()
+	if len(tools) == 0 {
+		return &providers.LLMResponse{Content: "best guess"}, nil
+	}
+	if m.n < len(m.calls) {
+		return &providers.LLMResponse{ToolCalls: m.calls[m.n]}, nil
+	}
+	return &providers.LLMResponse{Content: "done"}, nil
+}
+
+func (m *scriptedToolProvider) GetDefaultModel() string { return "mock-model" }
+
+func TestRunLLMIteration_LoopDetection(t *testing.T) {
+	call := func(id, query string) []providers.ToolCall {
+		return []providers.ToolCall{{ID: id, Name: "mock_custom", Arguments: map[string]interface{}{"q": query}}}
+	}
+	for _, tc := range []struct {
+		name    string
+		calls   [][]providers.ToolCall
+		stopped string
+	}{
+		{"repeats", [][]providers.ToolCall{call("1", "a"), call("2", "a"), call("3", "a"), call("4", "a")},
+			"I called mock_custom with the same arguments 3 times in a row"},
+		{"alternates", [][]providers.ToolCall{call("1", "a"), call("2", "b"), call("3", "a"), call("4", "b"), call("5", "a"), call("6", "b")},
+			"I alternated between the same two mock_custom calls 3 times"},
+		{"progress", [][]providers.ToolCall{call("1", "a"), call("2", "b"), call("3", "c"), call("4", "d")}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Agents.Defaults.Workspace = t.TempDir()
+			provider := &scriptedToolProvider{calls: tc.calls}
+			al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+			al.RegisterTool(&mockCustomTool{})
+
+			var stopped string
+			got, err := al.runAgentLoop(context.Background(), al.registry.GetDefaultAgent(), processOptions{
+				SessionKey:  "loop-test",
+				Channel:     "test",
+				ChatID:      "chat1",
+				UserMessage: "search until you find it",
+				Stopped:     &stopped,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stopped != tc.stopped {
+				t.Errorf("stopped = %q, want %q", stopped, tc.stopped)
+			}
+			if tc.stopped == "" && got != "done" {
+				t.Errorf("reply = %q", got)
+			}
+			if tc.stopped != "" && !strings.HasPrefix(got, tc.stopped) {
+				t.Errorf("reply = %q", got)
+			}
+		})
+	}
+}
+
+// barrierTool blocks each call until `want` calls are running at once,
+// so a test only passes when they run in parallel.
+type barrierTool struct {
+	want    int
+	arrived chan struct{}
+}
+
+func (b *barrierTool) Name() string        { return "barrier" }
+func (b *barrierTool) Description() string { return "Waits for its siblings" }
+func (b *barrierTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+}
+
+func (b *barrierTool) Concurrent() bool { return true }
+
+func (b *barrierTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	b.arrived <- struct{}{}
+	for len(b.arrived) < b.want {
+		select {
+		case <-ctx.Done():
+			return tools.ErrorResult("ran alone")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	return tools.SilentResult(fmt.Sprintf("result %v", args["n"]))
+}
+
+func TestRunLLMIteration_ParallelToolCalls(t *testing.T) {
+	var calls []providers.ToolCall
+	for _, id := range []string{"a", "b", "c"} {
+		calls = append(calls, providers.ToolCall{ID: id, Name: "barrier", Arguments: map[string]interface{}{"n": id}})
+	}
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Agents.Defaults.MaxParallelTools = 3
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &scriptedToolProvider{calls: [][]providers.ToolCall{calls}})
+	al.RegisterTool(&barrierTool{want: 3, arrived: make(chan struct{}, 3)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	agent := al.registry.GetDefaultAgent()
+	got, err := al.runAgentLoop(ctx, agent, processOptions{
+		SessionKey:  "parallel-test",
+		Channel:     "test",
+		ChatID:      "chat1",
+		UserMessage: "do three things",
+	})
+	if err != nil || got != "done" {
+		t.Fatalf("runAgentLoop = %q, %v", got, err)
+	}
+
+	var results []string
+	for _, m := range agent.Sessions.GetHistory("parallel-test") {
+		if m.Role == "tool" {
+			results = append(results, m.ToolCallID+"="+m.Content)
+		}
+	}
+	want := []string{"a=result a", "b=result b", "c=result c"}
+	if !slices.Equal(results, want) {
+		t.Errorf("tool results = %v, want %v", results, want)
+	}
+}
+
+// overlapTool records how many of its calls ran at once.
+type overlapTool struct {
+	running, most atomic.Int32
+}
+
+func (o *overlapTool) Name() string        { return "overlap" }
+func (o *overlapTool) Description() string { return "Counts overlapping calls" }
+func (o *overlapTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+}
+
+func (o *overlapTool) Execute(ctx context.Context, args map[string]interface{}) *tools.ToolResult {
+	n := o.running.Add(1)
+	defer o.running.Add(-1)
+	for {
+		most := o.most.Load()
+		if n <= most || o.most.CompareAndSwap(most, n) {
+			break
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	return tools.SilentResult("ok")
+}
+
+func TestRunLLMIteration_ToolsNotConcurrentRunInTurn(t *testing.T) {
+	var calls []providers.ToolCall
+	for _, id := range []string{"a", "b", "c"} {
+		calls = append(calls, providers.ToolCall{ID: id, Name: "overlap", Arguments: map[string]interface{}{}})
+	}
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Agents.Defaults.MaxParallelTools = 3
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &scriptedToolProvider{calls: [][]providers.ToolCall{calls}})
+	tool := &overlapTool{}
+	al.RegisterTool(tool)
+
+	if _, err := al.runAgentLoop(context.Background(), al.registry.GetDefaultAgent(), processOptions{
+		SessionKey:  "sequential-test",
+		Channel:     "test",
+		ChatID:      "chat1",
+		UserMessage: "do three things",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if most := tool.most.Load(); most != 1 {
+		t.Errorf("%d calls ran at once, want 1 for a tool that isn't concurrent", most)
+	}
+}
+
+func TestAddToolResult_KeepsCallOrder(t *testing.T) {
+	cb := NewContextBuilder(t.TempDir())
+	messages := []providers.Message{
+		{Role: "user", Content: "go"},
+		{Role: "assistant", ToolCalls: []providers.ToolCall{{ID: "1"}, {ID: "2"}, {ID: "3"}}},
+	}
+	messages = cb.AddToolResult(messages, "3", "t", "third")
+	messages = cb.AddToolResult(messages, "1", "t", "first")
+	messages = cb.AddToolResult(messages, "2", "t", "second")
+	messages = cb.AddToolResult(messages, "1", "t", "more")
+
+	var got []string
+	for _, m := range messages[2:] {
+		got = append(got, m.ToolCallID+":"+m.Content)
+	}
+	want := []string{"1:first\nmore", "2:second", "3:third"}
+	if !slices.Equal(got, want) {
+		t.Errorf("results = %q, want %q", got, want)
+	}
+}
+
+func TestRunLLMIteration_TurnLimits(t *testing.T) {
+	call := func(id, query string) []providers.ToolCall {
+		return []providers.ToolCall{{ID: id, Name: "mock_custom", Arguments: map[string]interface{}{"q": query}}}
+	}
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Agents.Defaults.TurnLimits = config.TurnLimitsConfig{MaxCalls: 2}
+	provider := &scriptedToolProvider{calls: [][]providers.ToolCall{call("1", "a"), call("2", "b"), call("3", "c")}}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	al.RegisterTool(&mockCustomTool{})
+
+	var stopped string
+	got, err := al.runAgentLoop(context.Background(), al.registry.GetDefaultAgent(), processOptions{
+		SessionKey:  "limits-test",
+		Channel:     "test",
+		ChatID:      "chat1",
+		UserMessage: "research this thoroughly",
+		Stopped:     &stopped,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got, "best guess") || !strings.Contains(got, "limit of 2 model calls") {
+		t.Errorf("reply = %q", got)
+	}
+	if stopped != "reached its limit of 2 model calls" || provider.n != 3 {
+		t.Errorf("stopped = %q after %d calls", stopped, provider.n)
+	}
+
+	budget := newTurnBudget(config.TurnLimitsConfig{MaxCostUSD: 0.5, InputPrice: 3, OutputPrice: 15})
+	budget.record(&providers.UsageInfo{PromptTokens: 100000, CompletionTokens: 2000, TotalTokens: 102000}, nil, "")
+	if reason := budget.exceeded(nil); reason != "" {
+		t.Errorf("exceeded at $%.2f: %s", budget.cost, reason)
+	}
+	// $0.33 spent: a next call with a 50k-token prompt would cross $0.50
+	small := []providers.Message{{Role: "user", Content: strings.Repeat("x", 2500)}}
+	large := []providers.Message{{Role: "user", Content: strings.Repeat("x", 125000)}}
+	if reason := budget.exceeded(small); reason != "" {
+		t.Errorf("small next call: exceeded = %q", reason)
+	}
+	if reason := budget.exceeded(large); reason != "its cost limit of $0.50" {
+		t.Errorf("large next call: exceeded = %q", reason)
+	}
+}
+
+// stallingProvider hangs until its context ends while tools are offered,
+// like a slow call started just before the time limit.
+type stallingProvider struct{ calls int }
+
+func (p *stallingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	p.calls++
+	if len(tools) == 0 {
+		return &providers.LLMResponse{Content: "best guess"}, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (p *stallingProvider) GetDefaultModel() string { return "mock-model" }
+
+func TestRunLLMIteration_TimeLimitCutsCallShort(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Agents.Defaults.TurnLimits = config.TurnLimitsConfig{MaxSeconds: 1}
+	provider := &stallingProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	al.RegisterTool(&mockCustomTool{})
+
+	start := time.Now()
+	got, err := al.runAgentLoop(context.Background(), al.registry.GetDefaultAgent(), processOptions{
+		SessionKey:  "time-limit-test",
+		Channel:     "test",
+		ChatID:      "chat1",
+		UserMessage: "research this thoroughly",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("turn took %s, want it cut off at its 1s limit", elapsed)
+	}
+	if !strings.HasPrefix(got, "best guess") || !strings.Contains(got, "time limit of 1s") || provider.calls != 2 {
+		t.Errorf("reply = %q after %d calls", got, provider.calls)
+	}
+}
+
+func TestRequestMaxIterations(t *testing.T) {
+	for meta, want := range map[string]int{"": 20, "5": 5, "50": 20, "-1": 20, "many": 20} {
+		if got := requestMaxIterations(map[string]string{"max_tool_iterations": meta}, 20); got != want {
+			t.Errorf("max_tool_iterations=%q: got %d, want %d", meta, got, want)
+		}
+	}
+}
+
+func TestCompactToolMessages(t *testing.T) {
+	big := strings.Repeat("x", 14*1024)
+	history := []providers.Message{
+		{Role: "user", Content: "save my notes"},
+		{Role: "assistant", ToolCalls: []providers.ToolCall{
+			{ID: "1", Type: "function", Name: "write_file", Function: &providers.FunctionCall{
+				Name:      "write_file",
+				Arguments: fmt.Sprintf(`{"path":"notes.md","content":%q}`, big),
+			}},
+			{ID: "2", Type: "function", Name: "read_file", Function: &providers.FunctionCall{
+				Name:      "read_file",
+				Arguments: `{"path":"todo.md"}`,
+			}},
+		}},
+		{Role: "tool", Content: "ok", ToolCallID: "1"},
+		{Role: "tool", Content: big, ToolCallID: "2"},
+		{Role: "assistant", Content: "Done."},
+	}
+
+	out, saved := compactToolMessages(history, 2000)
+	if saved <= 2*len(big)-4000 {
+		t.Errorf("saved %d chars", saved)
+	}
+	if got := out[1].ToolCalls[0].Function.Arguments; got != `{"content":"[compacted: wrote 14KB to notes.md]","path":"notes.md"}` {
+		t.Errorf("write_file arguments = %s", got)
+	}
+	if got := out[1].ToolCalls[1].Function.Arguments; got != `{"path":"todo.md"}` {
+		t.Errorf("read_file arguments = %s", got)
+	}
+	if got := out[3].Content; !strings.HasPrefix(got, "[compacted: result of 14KB from todo.md (read_file)") || len(got) > 1000 {
+		t.Errorf("read_file result = %.100s", got)
+	}
+	if history[1].ToolCalls[0].Function.Arguments == out[1].ToolCalls[0].Function.Arguments || history[3].Content != big {
+		t.Error("original history was modified")
+	}
+
+	if _, saved := compactToolMessages(out, 2000); saved != 0 {
+		t.Errorf("second pass saved %d chars, want 0", saved)
+	}
+}
+
+func TestParseTopicReply(t *testing.T) {
+	topic, changed, summary := parseTopicReply("TOPIC: Trip to Lisbon\nNEW: Yes\nSUMMARY: Picked the 14:05 train.\nBudget is 400 EUR.")
+	if topic != "Trip to Lisbon" || !changed || summary != "Picked the 14:05 train.\nBudget is 400 EUR." {
+		t.Errorf("got %q, %v, %q", topic, changed, summary)
+	}
+
+	topic, changed, summary = parseTopicReply("**TOPIC:** Garden\n**NEW:** no\n**SUMMARY:**")
+	if topic != "Garden" || changed || summary != "" {
+		t.Errorf("got %q, %v, %q", topic, changed, summary)
+	}
+}
+
+func TestCheckpointTopic(t *testing.T) {
+	workspace := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = workspace
+	provider := &simpleMockProvider{response: "TOPIC: Dinner recipes\nNEW: yes\nSUMMARY: Booked the 14:05 train to Porto on Friday."}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	agent := al.registry.GetDefaultAgent()
+
+	agent.Sessions.AddMessage("s", "user", "Book the 14:05 train to Porto on Friday")
+	agent.Sessions.AddMessage("s", "assistant", "Booked.")
+	agent.Sessions.SetTopic("s", "Train to Porto")
+	agent.Sessions.AddMessage("s", "user", "What should I cook tonight?")
+	agent.Sessions.AddMessage("s", "assistant", "How about a risotto?")
+
+	al.checkpointTopic(agent, "s", "telegram", agent.Sessions.GetHistory("s"))
+
+	if got := agent.Sessions.GetTopic("s"); got != "Dinner recipes" {
+		t.Errorf("topic = %q", got)
+	}
+	note := NewMemoryStore(workspace).ReadToday()
+	if !strings.Contains(note, "## Train to Porto (telegram,") || !strings.Contains(note, "Booked the 14:05 train") {
+		t.Errorf("daily note = %q", note)
+	}
+}
+
+func TestCitations(t *testing.T) {
+	c := newCitations(config.CitationsConfig{Enabled: true, MaxSources: 5})
+	ok := &tools.ToolResult{}
+
+	search := providers.ToolCall{Name: "web_search", Arguments: map[string]interface{}{"query": "tides"}}
+	got := c.observe(search, ok, "Results for: tides\n1. Tide tables\n   https://tides.example/oslo\n   Daily tides\n2. Harbour\n   https://harbour.example")
+	if !strings.Contains(got, "[1] Tide tables\n") || !strings.Contains(got, "[2] Harbour\n") {
+		t.Errorf("search result = %q", got)
+	}
+	fetch := providers.ToolCall{Name: "web_fetch", Arguments: map[string]interface{}{"url": "https://harbour.example"}}
+	if got := c.observe(fetch, ok, "Fetched 10 bytes"); !strings.Contains(got, "source [2]") {
+		t.Errorf("fetch result = %q", got)
+	}
+	fetch = providers.ToolCall{Name: "web_fetch", Arguments: map[string]interface{}{"url": "https://weather.example"}}
+	c.observe(fetch, ok, "Fetched 10 bytes")
+
+	if got := c.annotate("High tide is at 14:02 [1]. The harbour opens at 6 [2]."); !strings.HasSuffix(got, "\n\nSources:\n[1] Tide tables - https://tides.example/oslo\n[2] Harbour - https://harbour.example") {
+		t.Errorf("cited reply = %q", got)
+	}
+	// Without markers, the fetched pages are listed
+	if got := c.annotate("It will rain, see https://weather.example."); !strings.HasSuffix(got, "Sources:\n[2] Harbour - https://harbour.example") {
+		t.Errorf("uncited reply = %q", got)
+	}
+
+	var off *citations
+	if got := off.annotate("Hi [1]"); got != "Hi [1]" {
+		t.Errorf("disabled annotate = %q", got)
+	}
+}
+
+func TestLoadBootstrapFiles_Persona(t *testing.T) {
+	workspace := t.TempDir()
+	write := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(workspace, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("IDENTITY.md", "I am picoclaw.")
+	write("USER.md", "The user lives in Oslo.")
+	write("personas/pirate/IDENTITY.md", "I be Cap'n Claw.")
+	cb := NewContextBuilder(workspace)
+
+	got := cb.LoadBootstrapFiles("pirate")
+	if !strings.Contains(got, "I be Cap'n Claw.") || strings.Contains(got, "I am picoclaw.") || !strings.Contains(got, "lives in Oslo") {
+		t.Errorf("pirate bootstrap = %q", got)
+	}
+	for _, persona := range []string{"", "missing", "../pirate"} {
+		if got := cb.LoadBootstrapFiles(persona); !strings.Contains(got, "I am picoclaw.") {
+			t.Errorf("persona %q bootstrap = %q", persona, got)
+		}
+	}
+}
+
+func TestLoadBootstrapFiles_Guardrails(t *testing.T) {
+	workspace := t.TempDir()
+	rules := "- Never share API keys.\n- Ask before deleting files."
+	files := map[string]string{
+		"GUARDRAILS.md":                 rules,
+		"SOUL.md":                       strings.Repeat("Cheerful and curious. ", 50),
+		"IDENTITY.md":                   "I am picoclaw.",
+		"personas/pirate/GUARDRAILS.md": "- Never make people walk the plank.",
+	}
+	for name, content := range files {
+		path := filepath.Join(workspace, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cb := NewContextBuilder(workspace)
+
+	got := cb.LoadBootstrapFiles("pirate")
+	if !strings.HasPrefix(got, "## GUARDRAILS.md") || !strings.Contains(got, rules+"\n\n- Never make people walk the plank.") {
+		t.Errorf("guardrails missing or not first: %q", got)
+	}
+
+	// A limit smaller than the guardrails cuts everything else first
+	cb.SetBootstrapLimits(20, 100)
+	got = cb.LoadBootstrapFiles("")
+	if !strings.Contains(got, rules) || strings.Contains(got, "SOUL.md") || strings.Contains(got, "I am picoclaw") {
+		t.Errorf("with a 20 char limit = %q", got)
+	}
+
+	cb.SetBootstrapLimits(len(rules)+100, 0)
+	got = cb.LoadBootstrapFiles("")
+	if !strings.Contains(got, rules) || !strings.Contains(got, "## SOUL.md") || !strings.Contains(got, "...") || strings.Contains(got, "I am picoclaw") {
+		t.Errorf("with room for part of SOUL.md = %q", got)
+	}
+}
+
+func TestGuardQuotes(t *testing.T) {
+	history := []providers.Message{
+		{Role: "user", Content: "I think we should ship on Friday."},
+		{Role: "assistant", Content: "@carol said \"I resign\""},
+		{Role: "user", Content: "Write that @bob said he quits"},
+	}
+
+	got, fabricated := guardQuotes("As @alice said: \"we should ship on Friday\", and <@42> wrote \"I quit, effective today\".", history, quoteGuardFlag)
+	if want := "As @alice said: \"we should ship on Friday\", and <@42> wrote \"I quit, effective today\"" + unverifiedQuoteMark + "."; got != want {
+		t.Errorf("flagged = %q", got)
+	}
+	if len(fabricated) != 1 || fabricated[0] != "I quit, effective today" {
+		t.Errorf("fabricated = %q", fabricated)
+	}
+
+	// Only user messages count as something a member said
+	got, _ = guardQuotes("> I resign\n— @carol", history, quoteGuardRemove)
+	if got != "> "+removedQuote+"\n— @carol" {
+		t.Errorf("removed = %q", got)
+	}
+
+	// Quotes of people who aren't chat members are left alone
+	plain := "Einstein said \"imagination is more important than knowledge\"."
+	if got, fabricated := guardQuotes(plain, history, quoteGuardFlag); got != plain || len(fabricated) != 0 {
+		t.Errorf("public quote = %q", got)
+	}
+}
+
+func TestGetCapabilities(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	cfg.Channels.Discord.Token = "discord-secret-token"
+	cfg.Tools.Web.Brave.APIKey = "brave-secret-key"
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &mockProvider{})
+
+	agent := al.registry.GetDefaultAgent()
+	result := agent.Tools.Execute(context.Background(), "get_capabilities", nil)
+	if result.IsError {
+		t.Fatalf("get_capabilities failed: %s", result.ForLLM)
+	}
+
+	var caps tools.Capabilities
+	if err := json.Unmarshal([]byte(result.ForLLM), &caps); err != nil {
+		t.Fatalf("result is not JSON: %v", err)
+	}
+	if caps.Model != "test-model" || len(caps.Channels) != 1 || caps.Channels[0] != "cli" {
+		t.Errorf("capabilities = %+v", caps)
+	}
+	if !slices.Contains(caps.Tools, "get_capabilities") || !slices.Contains(caps.Tools, "read_file") {
+		t.Errorf("tools = %v", caps.Tools)
+	}
+	if strings.Contains(result.ForLLM, "secret") {
+		t.Errorf("capabilities leak a secret: %s", result.ForLLM)
+	}
+}
+
+// blockingProvider records the model of each call and, while gate is
+// set, holds the call until gate is closed.
+type blockingProvider struct {
+	mu      sync.Mutex
+	models  []string
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (p *blockingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	p.mu.Lock()
+	p.models = append(p.models, model)
+	gate := p.gate
+	p.gate = nil
+	p.mu.Unlock()
+	if gate != nil {
+		close(p.started)
+		<-gate
+	}
+	return &providers.LLMResponse{Content: "done"}, nil
+}
+
+func (p *blockingProvider) GetDefaultModel() string {
+	return "mock-model"
+}
+
+func TestAdminModel_DuringTurn(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace: t.TempDir(),
+				Model:     "test-model",
+			},
+		},
+		Admin: config.AdminConfig{Users: config.FlexibleStringSlice{"discord:42"}},
+	}
+	provider := &blockingProvider{started: make(chan struct{}), gate: make(chan struct{})}
+	gate := provider.gate
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+
+	done := make(chan error)
+	go func() {
+		_, err := al.ProcessDirectWithChannel(context.Background(), "hello", "cron-1", "telegram", "1")
+		done <- err
+	}()
+	<-provider.started
+
+	// The switch lands while the turn waits for its provider
+	if reply := al.adminModel([]string{"other-model"}); !strings.Contains(reply, "other-model") {
+		t.Errorf("!model reply = %q", reply)
+	}
+	al.adminStatus()
+	close(gate)
+	if err := <-done; err != nil {
+		t.Fatalf("turn: %v", err)
+	}
+
+	if _, err := al.ProcessDirectWithChannel(context.Background(), "again", "cron-2", "telegram", "1"); err != nil {
+		t.Fatalf("second turn: %v", err)
+	}
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if want := []string{"test-model", "other-model"}; !slices.Equal(provider.models, want) {
+		t.Errorf("models asked = %v, want %v", provider.models, want)
+	}
+}
+
+func TestAdminCommands(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace: t.TempDir(),
+				Model:     "test-model",
+			},
+		},
+		Admin: config.AdminConfig{Users: config.FlexibleStringSlice{"discord:42", "telegram:7", "alice", "webhook:42"}},
+	}
+	msgBus := bus.NewMessageBus()
+	al := NewAgentLoop(cfg, msgBus, &mockProvider{})
+
+	run := func(channel, sender, content string) bus.OutboundMessage {
+		t.Helper()
+		msg := bus.InboundMessage{Channel: channel, SenderID: sender, ChatID: "chat1", Content: content}
+		if response, err := al.processMessage(context.Background(), msg); err != nil || response != "" {
+			t.Fatalf("processMessage(%q) = %q, %v; want the reply on the bus", content, response, err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		out, ok := msgBus.SubscribeOutbound(ctx)
+		if !ok {
+			t.Fatalf("no reply to %q", content)
+		}
+		return out
+	}
+
+	out := run("discord", "42", "!model other-model")
+	if out.PrivateTo != "42" || !strings.Contains(out.Content, "other-model") {
+		t.Errorf("!model reply = %+v", out)
+	}
+	if _, got := al.registry.GetDefaultAgent().CurrentModel(); got != "other-model" {
+		t.Errorf("model = %q, want other-model", got)
+	}
+
+	out = run("telegram", "7|alice", "/status")
+	if !strings.Contains(out.Content, "Model: other-model") {
+		t.Errorf("/status reply = %q", out.Content)
+	}
+
+	// discord:42 is only an admin on Discord, usernames don't count, and
+	// neither do senders that name themselves or are relayed by a bridge
+	for _, sender := range []string{"telegram:42", "telegram:99|bob", "telegram:8|alice", "slack:alice", "webhook:42", "discord:matterbridge:42"} {
+		channel, id, _ := strings.Cut(sender, ":")
+		if out := run(channel, id, "!model evil"); !strings.Contains(out.Content, "Only admins") {
+			t.Errorf("%s: reply = %q, want a refusal", sender, out.Content)
+		}
+	}
+	if _, got := al.registry.GetDefaultAgent().CurrentModel(); got != "other-model" {
+		t.Errorf("model = %q after a refused command", got)
+	}
+
+	if _, _, ok := parseAdminCommand("!statusx"); ok {
+		t.Error("parseAdminCommand accepted an unknown command")
+	}
+
+	var reported []ToolCheck
+	al.OnToolCheck(func(checks []ToolCheck) { reported = checks })
+	if out := run("discord", "42", "!selftest"); !strings.Contains(out.Content, "tools ok") {
+		t.Errorf("!selftest reply = %q", out.Content)
+	}
+	al.RegisterTool(&mockCustomTool{})
+	al.RegisterTool(&brokenTool{})
+	out = run("discord", "42", "!selftest")
+	if !strings.Contains(out.Content, "1 of") || !strings.Contains(out.Content, "- broken: printer on fire") {
+		t.Errorf("!selftest reply = %q", out.Content)
+	}
+	if ok, summary := ToolCheckSummary(reported); ok || !strings.Contains(summary, "broken: printer on fire") {
+		t.Errorf("reported %v, %q", ok, summary)
+	}
+}
+
+// brokenTool fails its self-test.
+type brokenTool struct{ mockCustomTool }
+
+func (t *brokenTool) Name() string                       { return "broken" }
+func (t *brokenTool) SelfTest(ctx context.Context) error { return fmt.Errorf("printer on fire") }
+
+func TestLinkCommand_SharesSessionAcrossChannels(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace: t.TempDir(),
+				Model:     "test-model",
+			},
+		},
+		Session: config.SessionConfig{DMScope: "per-peer", AccountLinking: true},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &mockProvider{})
+
+	dm := func(channel, sender, content string) bus.InboundMessage {
+		return bus.InboundMessage{
+			Channel:  channel,
+			SenderID: sender,
+			ChatID:   sender,
+			Content:  content,
+			Metadata: map[string]string{"peer_kind": "direct"},
+		}
+	}
+	sessionOf := func(msg bus.InboundMessage) string {
+		return al.registry.ResolveRoute(routing.RouteInput{Channel: msg.Channel, Peer: extractPeer(msg)}).SessionKey
+	}
+	if sessionOf(dm("discord", "123", "hi")) == sessionOf(dm("telegram", "456", "hi")) {
+		t.Fatal("unlinked accounts share a session")
+	}
+
+	group := dm("discord", "123", "!link")
+	group.Metadata["peer_kind"] = "group"
+	if reply, _ := al.handleLinkCommand(group); !strings.Contains(reply, "direct message") {
+		t.Errorf("!link in a group = %q, want a refusal", reply)
+	}
+
+	reply, handled := al.handleLinkCommand(dm("discord", "123", "!link"))
+	m := regexp.MustCompile("!link ([A-Z0-9]{10})").FindStringSubmatch(reply)
+	if !handled || m == nil {
+		t.Fatalf("!link = %q, %v", reply, handled)
+	}
+	code := m[1]
+	if reply, _ := al.handleLinkCommand(dm("telegram", "456", "!link "+code)); !strings.Contains(reply, "discord:123") {
+		t.Errorf("!link <code> = %q", reply)
+	}
+	if sessionOf(dm("discord", "123", "hi")) != sessionOf(dm("telegram", "456", "hi")) {
+		t.Error("linked accounts don't share a session")
+	}
+
+	al.handleLinkCommand(dm("telegram", "456", "!unlink"))
+	if sessionOf(dm("discord", "123", "hi")) == sessionOf(dm("telegram", "456", "hi")) {
+		t.Error("unlinked accounts still share a session")
+	}
+}
+
+// systemPromptProvider remembers the last system prompt it was sent.
+type systemPromptProvider struct {
+	simpleMockProvider
+	last string
+}
+
+func (p *systemPromptProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	if len(messages) > 0 && messages[0].Role == "system" {
+		p.last = messages[0].Content
+	}
+	return p.simpleMockProvider.Chat(ctx, messages, tools, model, opts)
+}
+
+// tinyModelProvider answers like a small model given its tools in the
+// prompt: a JSON tool call first, then a reply with the tool's result.
+type tinyModelProvider struct {
+	systems []string
+	tools   int
+}
+
+func (p *tinyModelProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	p.systems = append(p.systems, messages[0].Content)
+	p.tools += len(tools)
+	last := messages[len(messages)-1].Content
+	if strings.HasPrefix(last, "Result of read_file:") {
+		return &providers.LLMResponse{Content: "The note says: " + strings.TrimSpace(strings.TrimPrefix(last, "Result of read_file:"))}, nil
+	}
+	return &providers.LLMResponse{Content: `{"tool": "read_file", "arguments": {"path": "note.txt"}}`}, nil
+}
+
+func (p *tinyModelProvider) GetDefaultModel() string { return "tiny" }
+
+func TestProcessMessage_MinimalProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "note.txt"), []byte("buy milk"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "AGENTS.md"), []byte(strings.Repeat("Be kind. ", 500)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         tmpDir,
+				Model:             "tiny",
+				MaxTokens:         1024,
+				MaxToolIterations: 5,
+				Profile:           config.AgentProfileMinimal,
+			},
+		},
+	}
+	provider := &tinyModelProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	agent := al.registry.GetDefaultAgent()
+	if !agent.Minimal {
+		t.Fatal("agent is not minimal")
+	}
+	for _, name := range agent.Tools.List() {
+		if !slices.Contains(minimalTools, name) {
+			t.Errorf("minimal agent has tool %s", name)
+		}
+	}
+
+	helper := testHelper{al: al}
+	got := helper.executeAndGetResponse(t, context.Background(), bus.InboundMessage{
+		Channel: "test", SenderID: "u1", ChatID: "c1", Content: "what's in note.txt?",
+	})
+	if got != "The note says: buy milk" {
+		t.Errorf("reply = %q", got)
+	}
+	if provider.tools != 0 {
+		t.Error("tools were sent through the API")
+	}
+	system := provider.systems[0]
+	if !strings.Contains(system, "- read_file(") || strings.Contains(system, "# Skills") || strings.Contains(system, "## Available Tools") {
+		t.Errorf("system prompt = %q", system)
+	}
+	if n := len(system); n > 3000 {
+		t.Errorf("system prompt is %d characters", n)
+	}
+}
+
+func TestProcessMessage_PinTurn(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         tmpDir,
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	provider := &systemPromptProvider{simpleMockProvider: simpleMockProvider{response: "We go with Postgres."}}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	helper := testHelper{al: al}
+	ctx := context.Background()
+	send := func(content string) string {
+		return helper.executeAndGetResponse(t, ctx, bus.InboundMessage{Channel: "test", SenderID: "u1", ChatID: "c1", Content: content})
+	}
+
+	if got := send("!pin"); got != "There's no reply of mine here to pin." {
+		t.Errorf("pin without a reply = %q", got)
+	}
+	send("which database?")
+	if got := send("!pin"); got != "📌 Pinned and saved to memory." {
+		t.Errorf("pin = %q", got)
+	}
+	if got := send("!pin"); got != "📌 That's already pinned." {
+		t.Errorf("pin again = %q", got)
+	}
+	if note := NewMemoryStore(tmpDir).ReadToday(); !strings.Contains(note, "> which database?") || !strings.Contains(note, "We go with Postgres.") {
+		t.Errorf("daily note = %q", note)
+	}
+	if got := send("!pins"); !strings.Contains(got, "1. We go with Postgres.") {
+		t.Errorf("pins = %q", got)
+	}
+
+	// The pin outlives the history, as after a summary
+	agent := al.registry.GetDefaultAgent()
+	keys := agent.Sessions.Keys()
+	if len(keys) != 1 {
+		t.Fatalf("sessions = %v", keys)
+	}
+	agent.Sessions.TruncateHistory(keys[0], 0)
+	send("remind me of our decision")
+	if !strings.Contains(provider.last, "## Pinned Exchanges") || !strings.Contains(provider.last, "User: which database?\nYou: We go with Postgres.") {
+		t.Errorf("system prompt lacks the pin: %q", provider.last)
+	}
+
+	if got := send("!unpin 2"); got != "There's no pin 2 here, see !pins." {
+		t.Errorf("unpin missing = %q", got)
+	}
+	if got := send("!unpin 1"); got != "Unpinned: We go with Postgres." {
+		t.Errorf("unpin = %q", got)
+	}
+	send("and now?")
+	if strings.Contains(provider.last, "## Pinned Exchanges") {
+		t.Error("unpinned exchange still in the prompt")
+	}
+}
+
+func TestWithPins_LeavesOutForgottenUsers(t *testing.T) {
+	workspace := t.TempDir()
+	tombstones := privacy.LoadTombstones(workspace)
+	if err := tombstones.Add("12345", []string{"12345", "Alice"}); err != nil {
+		t.Fatal(err)
+	}
+	pins := []session.Pin{
+		{User: "Alice here, which database?", Assistant: "We go with Postgres."},
+		{User: "which queue?", Assistant: "We go with NATS."},
+	}
+	messages := withPins([]providers.Message{{Role: "system", Content: "You are a bot."}}, pins, nil, tombstones)
+	if got := messages[0].Content; strings.Contains(got, "Postgres") || !strings.Contains(got, "You: We go with NATS.") {
+		t.Errorf("system prompt = %q", got)
+	}
+}
+
+func TestProcessMessage_GuildMemory(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         tmpDir,
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	provider := &systemPromptProvider{simpleMockProvider: simpleMockProvider{response: "ok"}}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+	helper := testHelper{al: al}
+	ctx := context.Background()
+
+	remember := bus.InboundMessage{
+		Channel:  "discord",
+		SenderID: "admin1",
+		ChatID:   "c1",
+		Content:  "The meetup is every first Friday.",
+		Control:  bus.ControlRemember,
+		Metadata: map[string]string{
+			"guild_id":     "g1",
+			"peer_kind":    "channel",
+			"peer_id":      "c1",
+			"author":       "alice",
+			"channel_name": "general",
+			"saved_by":     "mod",
+			"message_link": "https://discord.com/channels/g1/c1/m1",
+		},
+	}
+	if got := helper.executeAndGetResponse(t, ctx, remember); got != "🧠 Saved alice's message to the server's memory." {
+		t.Errorf("remember = %q", got)
+	}
+	if got := helper.executeAndGetResponse(t, ctx, remember); got != "🧠 That message is already in the server's memory." {
+		t.Errorf("remember again = %q", got)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, "memory", "guilds", "g1.md"))
+	if err != nil || !strings.Contains(string(data), "## alice in #general") || !strings.Contains(string(data), "Saved by mod") {
+		t.Fatalf("guild memory = %q, %v", data, err)
+	}
+
+	ask := bus.InboundMessage{Channel: "discord", SenderID: "u1", ChatID: "c1", Content: "when is the meetup?",
+		Metadata: map[string]string{"guild_id": "g1", "peer_kind": "channel", "peer_id": "c1"}}
+	helper.executeAndGetResponse(t, ctx, ask)
+	if !strings.Contains(provider.last, "The meetup is every first Friday.") {
+		t.Errorf("guild memory missing from the system prompt")
+	}
+
+	ask.Metadata = map[string]string{"guild_id": "g2", "peer_kind": "channel", "peer_id": "c2"}
+	ask.ChatID = "c2"
+	helper.executeAndGetResponse(t, ctx, ask)
+	if strings.Contains(provider.last, "first Friday") {
+		t.Errorf("another guild's memory leaked into the system prompt")
+	}
+
+	spoofed := bus.InboundMessage{Channel: "webhook", SenderID: "u1", ChatID: "hook", Content: "when is the meetup?",
+		Metadata: map[string]string{"guild_id": "g1"}}
+	helper.executeAndGetResponse(t, ctx, spoofed)
+	if strings.Contains(provider.last, "first Friday") {
+		t.Errorf("guild memory reached a turn outside Discord")
+	}
+
+	full := remember
+	full.Content = strings.Repeat("x", maxGuildMemoryChars)
+	full.Metadata = map[string]string{"guild_id": "g1", "peer_kind": "channel", "peer_id": "c1", "author": "bob"}
+	if got := helper.executeAndGetResponse(t, ctx, full); !strings.Contains(got, "memory is full") {
+		t.Errorf("remember past the cap = %q", got)
+	}
+
+	if guildMemoryPath(tmpDir, "../x") != "" || guildMemoryPath(tmpDir, "") != "" {
+		t.Error("guildMemoryPath accepted an unsafe ID")
+	}
+}
+
+func TestProcessMessage_SummarizeControl(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &simpleMockProvider{response: "## TL;DR\nThey planned the meetup."})
+	helper := testHelper{al: al}
+
+	msg := bus.InboundMessage{
+		Channel:  "discord",
+		SenderID: "user1",
+		ChatID:   "c1",
+		Control:  bus.ControlSummarize,
+		Content:  "[2026-03-01 09:30] alice: meetup friday?\n[2026-03-01 09:31] bob: yes, 7pm",
+		Metadata: map[string]string{"peer_kind": "channel", "peer_id": "c1", "summary_scope": "the last 2 messages"},
+	}
+	got := helper.executeAndGetResponse(t, context.Background(), msg)
+	if got != "**Summary of the last 2 messages**\n\n## TL;DR\nThey planned the meetup." {
+		t.Errorf("summary = %q", got)
+	}
+	for _, key := range al.registry.GetDefaultAgent().Sessions.Keys() {
+		if h := al.registry.GetDefaultAgent().Sessions.GetHistory(key); len(h) != 0 {
+			t.Errorf("summary was added to session %s: %v", key, h)
+		}
+	}
+}
+
+func TestRemindersCommand(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace: t.TempDir(),
+				Model:     "test-model",
+			},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &mockProvider{})
+	cs := cron.NewCronService(filepath.Join(t.TempDir(), "jobs.json"), nil)
+	al.SetCronService(cs)
+	at := time.Now().Add(time.Hour).UnixMilli()
+	job, _ := cs.AddJob("tea", cron.CronSchedule{Kind: "at", AtMS: &at}, "tea is ready", true, "telegram", "42")
+
+	msg := func(content string) bus.InboundMessage {
+		return bus.InboundMessage{Channel: "telegram", SenderID: "42", ChatID: "42", Content: content}
+	}
+	if reply, handled := al.handleRemindersCommand(msg("!reminders")); !handled || !strings.Contains(reply, job.ID) {
+		t.Errorf("!reminders = %q, %v", reply, handled)
+	}
+	if reply, _ := al.handleRemindersCommand(msg("!reminders cancel " + job.ID)); !strings.Contains(reply, "Cancelled") {
+		t.Errorf("!reminders cancel = %q", reply)
+	}
+	if reply, _ := al.handleRemindersCommand(msg("!reminders")); reply != "No pending reminders." {
+		t.Errorf("!reminders after cancel = %q", reply)
+	}
+}
+
+// streamingMockProvider streams its reply in the given pieces.
+type streamingMockProvider struct {
+	simpleMockProvider
+	pieces []string
+}
+
+func (m *streamingMockProvider) ChatStream(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (<-chan providers.StreamChunk, error) {
+	chunks := make(chan providers.StreamChunk, len(m.pieces)+1)
+	for _, p := range m.pieces {
+		chunks <- providers.StreamChunk{Delta: p}
+	}
+	chunks <- providers.StreamChunk{Response: &providers.LLMResponse{Content: strings.Join(m.pieces, "")}}
+	close(chunks)
+	return chunks, nil
+}
+
+func TestProcessMessage_StreamsPartials(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+				Stream:            true,
+			},
+		},
+	}
+	msgBus := bus.NewMessageBus()
+	al := NewAgentLoop(cfg, msgBus, &streamingMockProvider{pieces: []string{"Hel", "lo"}})
+
+	response, err := al.processMessage(context.Background(), bus.InboundMessage{
+		Channel: "discord", SenderID: "42", ChatID: "chat1", Content: "hi",
+	})
+	if err != nil || response != "Hello" {
+		t.Fatalf("processMessage = %q, %v; want Hello", response, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	out, ok := msgBus.SubscribeOutbound(ctx)
+	if !ok || !out.Partial || out.Content != "Hel" || out.ChatID != "chat1" {
+		t.Errorf("partial = %+v, %v; want Hel", out, ok)
+	}
+}
+
+func TestProcessMessage_QuoteGuardStopsStreaming(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+				Stream:            true,
+				QuoteGuard:        "flag",
+			},
+		},
+	}
+	msgBus := bus.NewMessageBus()
+	al := NewAgentLoop(cfg, msgBus, &streamingMockProvider{pieces: []string{"Hel", "lo"}})
+
+	if _, err := al.processMessage(context.Background(), bus.InboundMessage{
+		Channel: "discord", SenderID: "42", ChatID: "chat1", Content: "hi",
+	}); err != nil {
+		t.Fatalf("processMessage() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if out, ok := msgBus.SubscribeOutbound(ctx); ok {
+		t.Errorf("published %+v with the quote guard on", out)
+	}
+}
+
+// routingMockProvider records the models it is called with. The cheap
+// model asks for a tool when the message mentions one.
+type routingMockProvider struct {
+	models []string
+}
+
+func (m *routingMockProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	m.models = append(m.models, model)
+	if model != "cheap-model" {
+		return &providers.LLMResponse{Content: "smart"}, nil
+	}
+	if strings.Contains(messages[len(messages)-1].Content, "tool") {
+		return &providers.LLMResponse{ToolCalls: []providers.ToolCall{{ID: "1", Name: "mock_custom", Arguments: map[string]interface{}{}}}}, nil
+	}
+	return &providers.LLMResponse{Content: "cheap"}, nil
+}
+
+func (m *routingMockProvider) GetDefaultModel() string {
+	return "test-model"
+}
+
+func TestProcessMessage_RoutesByComplexity(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+				Routing: config.RoutingConfig{
+					Enabled:          true,
+					CheapModel:       "cheap-model",
+					MaxChars:         20,
+					MaxContextTokens: 2000,
+				},
+			},
+		},
+	}
+	provider := &routingMockProvider{}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), provider)
+
+	tests := []struct {
+		content string
+		want    string
+		models  []string
+	}{
+		{"hi", "cheap", []string{"cheap-model"}},
+		{"tell me everything about the history of Rome", "smart", []string{"test-model"}},
+		{"use a tool", "smart", []string{"cheap-model", "test-model"}},
+		{"!routing smart", "", nil},
+		{"hi", "smart", []string{"test-model"}},
+	}
+	for _, tt := range tests {
+		provider.models = nil
+		response, err := al.processMessage(context.Background(), bus.InboundMessage{
+			Channel: "cli", SenderID: "42", ChatID: "chat1", Content: tt.content,
+		})
+		if err != nil {
+			t.Fatalf("processMessage(%q): %v", tt.content, err)
+		}
+		if tt.want != "" && response != tt.want {
+			t.Errorf("processMessage(%q) = %q, want %q", tt.content, response, tt.want)
+		}
+		if !slices.Equal(provider.models, tt.models) {
+			t.Errorf("processMessage(%q) called %v, want %v", tt.content, provider.models, tt.models)
+		}
+	}
+}
+
+func TestContextBudget(t *testing.T) {
+	workspace := t.TempDir()
+	os.WriteFile(filepath.Join(workspace, "AGENTS.md"), []byte(strings.Repeat("Be brief. ", 500)), 0644)
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         workspace,
+				Model:             "test-model",
+				MaxTokens:         40000,
+				MaxToolIterations: 10,
+			},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &mockProvider{})
+
+	reply, err := al.processMessage(context.Background(), bus.InboundMessage{
+		Channel: "cli", SenderID: "42", ChatID: "chat1", Content: "!budget",
+	})
+	if err != nil {
+		t.Fatalf("processMessage: %v", err)
+	}
+	for _, want := range []string{"bootstrap files", "history", "reserve", "free"} {
+		if !strings.Contains(reply, want) {
+			t.Errorf("!budget reply lacks %q:\n%s", want, reply)
+		}
+	}
+
+	budget, err := al.ContextBudget("", "", "")
+	if err != nil {
+		t.Fatalf("ContextBudget: %v", err)
+	}
+	var bootstrap int
+	for _, s := range budget.Sections {
+		if s.Name == "bootstrap files" {
+			bootstrap = s.Tokens
+		}
+	}
+	if bootstrap < 1500 || budget.Reserve != 10000 || budget.Used+budget.Reserve+budget.Free != 40000 {
+		t.Errorf("budget = %+v", budget)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/budget", nil)
+	rec := httptest.NewRecorder()
+	al.BudgetHandler("secret").ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("request without token = %d", rec.Code)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	al.BudgetHandler("secret").ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"context_window":40000`) {
+		t.Errorf("budget endpoint = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// confirmChannel is a channel with buttons that answers every
+// confirmation with answer, or never when block is set.
+type confirmChannel struct {
+	answer  bool
+	block   bool
+	prompts []string
+	senders []string
+}
+
+func (c *confirmChannel) Name() string                                            { return "buttons" }
+func (c *confirmChannel) Start(ctx context.Context) error                         { return nil }
+func (c *confirmChannel) Stop(ctx context.Context) error                          { return nil }
+func (c *confirmChannel) Send(ctx context.Context, msg bus.OutboundMessage) error { return nil }
+func (c *confirmChannel) IsRunning() bool                                         { return true }
+func (c *confirmChannel) IsAllowed(senderID string) bool                          { return true }
+
+func (c *confirmChannel) Confirm(ctx context.Context, chatID, senderID, prompt string) (bool, error) {
+	c.prompts = append(c.prompts, prompt)
+	c.senders = append(c.senders, senderID)
+	if c.block {
+		<-ctx.Done()
+		return false, ctx.Err()
+	}
+	return c.answer, nil
+}
+
+func TestConfirmTool(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Tools.Confirm.Timeout = 1
+	msgBus := bus.NewMessageBus()
+	al := NewAgentLoop(cfg, msgBus, &simpleMockProvider{response: "ok"})
+
+	cm, err := channels.NewManager(cfg, msgBus)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	ch := &confirmChannel{answer: true}
+	cm.RegisterChannel("buttons", ch)
+	al.SetChannelManager(cm)
+
+	exec := providers.ToolCall{Name: "exec", Arguments: map[string]interface{}{"command": "rm -rf build"}}
+	opts := processOptions{Channel: "buttons", ChatID: "chat1", SenderID: "user1"}
+	ctx := context.Background()
+
+	if res := al.confirmTool(ctx, exec, opts); res != nil {
+		t.Errorf("approved call was blocked: %s", res.ForLLM)
+	}
+	if len(ch.prompts) != 1 || !strings.Contains(ch.prompts[0], "rm -rf build") || ch.senders[0] != "user1" {
+		t.Errorf("prompts = %q, senders = %q", ch.prompts, ch.senders)
+	}
+
+	ch.answer = false
+	if res := al.confirmTool(ctx, exec, opts); res == nil || !strings.Contains(res.ForLLM, "cancelled") {
+		t.Errorf("cancelled call result = %+v", res)
+	}
+
+	ch.block = true
+	if res := al.confirmTool(ctx, exec, opts); res == nil || !strings.Contains(res.ForLLM, "in time") {
+		t.Errorf("timed out call result = %+v", res)
+	}
+
+	// Tools not listed, and channels without buttons, run unasked
+	asked := len(ch.prompts)
+	if res := al.confirmTool(ctx, providers.ToolCall{Name: "read_file"}, opts); res != nil {
+		t.Errorf("unlisted tool was blocked: %s", res.ForLLM)
+	}
+	opts.Channel = "cli"
+	if res := al.confirmTool(ctx, exec, opts); res != nil {
+		t.Errorf("call on a channel without buttons was blocked: %s", res.ForLLM)
+	}
+	if len(ch.prompts) != asked {
+		t.Errorf("asked for confirmation %d extra times", len(ch.prompts)-asked)
+	}
+}
+
+func TestBuildMessages_ImageParts(t *testing.T) {
+	cb := NewContextBuilder(t.TempDir())
+	png := providers.ImagePart("image/png", []byte("png")).ImageURL.URL
+	media := []string{png, "/tmp/voice.ogg", "data:text/plain;base64,aGk=", "https://example.com/a.jpg"}
+
+	messages := cb.BuildMessages(nil, "", "what is this?", media, "telegram", "1", "")
+	user := messages[len(messages)-1]
+	if user.Role != "user" || user.Content != "what is this?" {
+		t.Fatalf("last message = %+v", user)
+	}
+	if len(user.Parts) != 1 || user.Parts[0].ImageURL.URL != png {
+		t.Errorf("parts = %+v, want only the PNG", user.Parts)
+	}
+}
+
+func TestBuildMessages_CacheBreaks(t *testing.T) {
+	cb := NewContextBuilder(t.TempDir())
+	messages := cb.BuildMessages(nil, "", "hi", nil, "telegram", "1", "")
+	system := messages[0]
+	if len(system.CacheBreaks) == 0 {
+		t.Fatal("system prompt has no cache breaks")
+	}
+	last := system.CacheBreaks[len(system.CacheBreaks)-1]
+	if last > len(system.Content) || strings.Contains(system.Content[:last], "Current Time") {
+		t.Errorf("cached prefix includes the current time: %q", system.Content[:last])
+	}
+	if !strings.Contains(system.Content[last:], "Current Time") {
+		t.Error("current time missing after the cached prefix")
+	}
+}
+
+type usageMockProvider struct{}
+
+func (m *usageMockProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	return &providers.LLMResponse{
+		Content: "ok",
+		Usage:   &providers.UsageInfo{PromptTokens: 60, CompletionTokens: 40},
+	}, nil
+}
+
+func (m *usageMockProvider) GetDefaultModel() string { return "mock-model" }
+
+func TestProcessMessage_Tenants(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Tenants = config.TenantsConfig{
+		Enabled: true,
+		List: []config.TenantConfig{
+			{ID: "alex", Workspace: t.TempDir(), Members: []string{"telegram:7"}, DailyTokens: 150},
+		},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &usageMockProvider{})
+	al.SetStore(kv.NewMemory())
+
+	send := func(sender, content string) string {
+		t.Helper()
+		reply, err := al.processMessage(context.Background(), bus.InboundMessage{
+			Channel: "telegram", SenderID: sender, ChatID: sender, Content: content,
+			Metadata: map[string]string{"peer_kind": "direct"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply
+	}
+
+	if got := send("7", "my secret is tulips"); got != "ok" {
+		t.Fatalf("first reply = %q", got)
+	}
+	if got := send("7", "again"); got != "ok" {
+		t.Fatalf("second reply = %q", got)
+	}
+	if got := state.NewManager(cfg.WorkspacePath()).GetLastChannel(); got != "" {
+		t.Errorf("owner's last channel = %q after only tenant messages", got)
+	}
+	if got := send("7", "once more"); !strings.Contains(got, "quota") {
+		t.Errorf("over quota reply = %q", got)
+	}
+	if got := send("8", "hello"); got != "ok" {
+		t.Errorf("owner reply = %q, quota should be per tenant", got)
+	}
+
+	tenant, _ := al.registry.GetAgent("tenant-alex")
+	main := al.registry.GetDefaultAgent()
+	if tenant.Workspace == main.Workspace {
+		t.Fatal("tenant shares the owner's workspace")
+	}
+	if len(tenant.Sessions.Keys()) == 0 {
+		t.Error("tenant messages were not kept in the tenant's sessions")
+	}
+	if got := state.NewManager(tenant.Workspace).GetLastChannel(); got != "telegram:7" {
+		t.Errorf("tenant's last channel = %q", got)
+	}
+	for _, key := range main.Sessions.Keys() {
+		for _, m := range main.Sessions.GetHistory(key) {
+			if strings.Contains(m.Content, "tulips") {
+				t.Errorf("tenant message leaked into the owner's session %s", key)
+			}
+		}
+	}
+}
+
+// fixProvider answers with a wrong pier, and with the corrected reply when
+// asked for JSON.
+type fixProvider struct{}
+
+func (m *fixProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	if _, ok := opts["response_format"]; ok {
+		return &providers.LLMResponse{Content: `{"reply": "The ferry leaves from pier 3.", "lesson": "The ferry leaves from pier 3."}`}, nil
+	}
+	return &providers.LLMResponse{Content: "The ferry leaves from pier 5."}, nil
+}
+
+func (m *fixProvider) GetDefaultModel() string { return "mock-model" }
+
+func TestProcessMessage_Fix(t *testing.T) {
+	cfg := config.DefaultConfig()
+	workspace := t.TempDir()
+	cfg.Agents.Defaults.Workspace = workspace
+	msgBus := bus.NewMessageBus()
+	al := NewAgentLoop(cfg, msgBus, &fixProvider{})
+
+	send := func(content string, metadata map[string]string) string {
+		t.Helper()
+		metadata["peer_kind"] = "direct"
+		reply, err := al.processMessage(context.Background(), bus.InboundMessage{
+			Channel: "discord", SenderID: "7", ChatID: "c1", Content: content, Metadata: metadata,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply
+	}
+
+	if got := send("!fix", map[string]string{}); !strings.HasPrefix(got, "Usage") {
+		t.Errorf("empty !fix = %q", got)
+	}
+	if got := send("!fix it's pier 3", map[string]string{}); !strings.Contains(got, "no reply") {
+		t.Errorf("!fix without a reply = %q", got)
+	}
+
+	send("where does the ferry leave?", map[string]string{})
+	got := send("!fix it's pier 3", map[string]string{
+		"reply_to_message_id": "m1",
+		"reply_to_text":       "The ferry leaves from pier 5.",
+	})
+	if !strings.HasPrefix(got, "✏️ Corrected my message.") {
+		t.Errorf("reply = %q", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	out, ok := msgBus.SubscribeOutbound(ctx)
+	if !ok || out.Edit != "m1" || out.Content != "The ferry leaves from pier 3." {
+		t.Errorf("edit = %+v", out)
+	}
+
+	agent := al.registry.GetDefaultAgent()
+	for _, key := range agent.Sessions.Keys() {
+		for _, m := range agent.Sessions.GetHistory(key) {
+			if strings.Contains(m.Content, "pier 5") {
+				t.Errorf("session %s still has the wrong reply", key)
+			}
+		}
+	}
+	if memory := NewMemoryStore(workspace).ReadLongTerm(); !strings.Contains(memory, "## Corrections\n\n- The ferry leaves from pier 3.") {
+		t.Errorf("MEMORY.md = %q", memory)
+	}
+}
+
+func TestProcessMessage_FixInGroup(t *testing.T) {
+	cfg := config.DefaultConfig()
+	workspace := t.TempDir()
+	cfg.Agents.Defaults.Workspace = workspace
+	msgBus := bus.NewMessageBus()
+	al := NewAgentLoop(cfg, msgBus, &fixProvider{})
+
+	send := func(content string, metadata map[string]string) string {
+		t.Helper()
+		metadata["peer_kind"] = "group"
+		reply, err := al.processMessage(context.Background(), bus.InboundMessage{
+			Channel: "telegram", SenderID: "7", ChatID: "g1", Content: content, Metadata: metadata,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply
+	}
+
+	send("where does the ferry leave?", map[string]string{})
+	got := send("!fix it's pier 3", map[string]string{
+		"reply_to_message_id": "m1",
+		"reply_to_text":       "Something the bot never said.",
+	})
+	if got != "✏️ The ferry leaves from pier 3." {
+		t.Errorf("reply = %q", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if out, ok := msgBus.SubscribeOutbound(ctx); ok {
+		t.Errorf("edited a message outside Discord: %+v", out)
+	}
+	if memory := NewMemoryStore(workspace).ReadLongTerm(); strings.Contains(memory, "pier 3") {
+		t.Errorf("a group member wrote to MEMORY.md: %q", memory)
+	}
+}
+
+func TestAppendCorrection(t *testing.T) {
+	ms := NewMemoryStore(t.TempDir())
+	ms.WriteLongTerm("# Memory\n\n## Corrections\n\n- first (2026-01-01)\n\n## People\n\n- Bob\n")
+	if err := appendCorrection(ms, "second"); err != nil {
+		t.Fatal(err)
+	}
+	memory := ms.ReadLongTerm()
+	want := "## Corrections\n\n- first (2026-01-01)\n- second ("
+	if !strings.Contains(memory, want) || !strings.Contains(memory, ")\n\n## People\n\n- Bob\n") {
+		t.Errorf("MEMORY.md = %q", memory)
+	}
+}
+
+func TestMaintenanceCommand_AdminsOnly(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Admin.Users = config.FlexibleStringSlice{"telegram:1"}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &mockProvider{})
+	msg := func(sender, content string) bus.InboundMessage {
+		return bus.InboundMessage{Channel: "telegram", SenderID: sender, ChatID: "chat1", Content: content}
+	}
+
+	if reply, _ := al.handleCommand(context.Background(), msg("2", "/maintenance on")); !strings.Contains(reply, "Only admins") {
+		t.Fatalf("non-admin reply = %q", reply)
+	}
+	if al.maintenance.Status().Enabled {
+		t.Fatal("a non-admin turned maintenance on")
+	}
+	if reply, _ := al.handleCommand(context.Background(), msg("1", "/maintenance on")); !strings.Contains(reply, "Maintenance mode on") {
+		t.Fatalf("admin reply = %q", reply)
+	}
+	if !al.holdForMaintenance(msg("2", "/maintenance off")) {
+		t.Error("a non-admin's /maintenance got past the hold")
+	}
+	if al.holdForMaintenance(msg("1", "/maintenance off")) {
+		t.Error("the admin's /maintenance was held")
+	}
+	if al.holdForMaintenance(msg("1", "!status")) {
+		t.Error("the admin's !status was held")
+	}
+	if !al.holdForMaintenance(msg("2", "!status")) {
+		t.Error("a non-admin's !status got past the hold")
+	}
+}
+
+func TestHandleFeedback_CommentFromTheRater(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace:         t.TempDir(),
+				Model:             "test-model",
+				MaxTokens:         4096,
+				MaxToolIterations: 10,
+			},
+		},
+		Audit:    config.AuditConfig{Enabled: true},
+		Feedback: config.FeedbackConfig{Enabled: true, FollowUp: true},
+	}
+	al := NewAgentLoop(cfg, bus.NewMessageBus(), &simpleMockProvider{response: "Shall I go on?"})
+	msg := func(sender, content string) bus.InboundMessage {
+		return bus.InboundMessage{Channel: "telegram", SenderID: sender, ChatID: "group", Content: content}
+	}
+	if _, err := al.processMessage(context.Background(), msg("alice", "summarize the thread")); err != nil {
+		t.Fatalf("processMessage() error = %v", err)
+	}
+
+	if _, handled := al.handleFeedback(msg("alice", "👍")); handled {
+		t.Error("a bare 👍 was kept from the agent")
+	}
+	if reply, handled := al.handleFeedback(msg("alice", "👎")); !handled || !strings.Contains(reply, "What was wrong") {
+		t.Fatalf("👎 = %q, %v", reply, handled)
+	}
+	if _, handled := al.handleFeedback(msg("bob", "what's for lunch?")); handled {
+		t.Error("another member's message was taken as the comment")
+	}
+	if reply, handled := al.handleFeedback(msg("alice", "too long")); !handled || !strings.Contains(reply, "Thanks") {
+		t.Errorf("comment = %q, %v", reply, handled)
+	}
+}
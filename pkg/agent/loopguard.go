@@ -0,0 +1,101 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// loopGuard spots a turn going in circles before it reaches the flat
+// iteration cap: the same tool called with identical arguments several
+// times in a row, or two calls alternating back and forth.
+type loopGuard struct {
+	maxRepeats      int
+	maxAlternations int
+	calls           []string // "name(args)" of every call so far
+}
+
+// newLoopGuard returns nil when loop detection is off.
+func newLoopGuard(cfg config.LoopDetectionConfig) *loopGuard {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &loopGuard{maxRepeats: cfg.MaxRepeats, maxAlternations: cfg.MaxAlternations}
+}
+
+// observe records a tool call and describes the loop it closes, if any.
+func (g *loopGuard) observe(tc providers.ToolCall) string {
+	if g == nil {
+		return ""
+	}
+	args, _ := json.Marshal(tc.Arguments)
+	g.calls = append(g.calls, tc.Name+"("+string(args)+")")
+
+	if n := g.maxRepeats; n > 1 && g.repeats() >= n {
+		return fmt.Sprintf("I called %s with the same arguments %d times in a row", tc.Name, n)
+	}
+	if n := g.maxAlternations; n > 1 {
+		if other, ok := g.alternating(n); ok {
+			if other == tc.Name {
+				return fmt.Sprintf("I alternated between the same two %s calls %d times", tc.Name, n)
+			}
+			return fmt.Sprintf("I went back and forth between %s and %s %d times", other, tc.Name, n)
+		}
+	}
+	return ""
+}
+
+// repeats counts how many calls at the end are identical.
+func (g *loopGuard) repeats() int {
+	last := g.calls[len(g.calls)-1]
+	n := 0
+	for i := len(g.calls) - 1; i >= 0 && g.calls[i] == last; i-- {
+		n++
+	}
+	return n
+}
+
+// alternating reports whether the last 2n calls alternate between two
+// different calls, and names the tool of the other one.
+func (g *loopGuard) alternating(n int) (string, bool) {
+	if len(g.calls) < 2*n {
+		return "", false
+	}
+	tail := g.calls[len(g.calls)-2*n:]
+	a, b := tail[0], tail[1]
+	if a == b {
+		return "", false
+	}
+	for i, call := range tail {
+		if (i%2 == 0 && call != a) || (i%2 == 1 && call != b) {
+			return "", false
+		}
+	}
+	name, _, _ := strings.Cut(a, "(")
+	return name, true
+}
+
+// loopStoppedReply explains to the user why the turn ended early.
+func loopStoppedReply(reason string) string {
+	return reason + " without getting anywhere, so I stopped to avoid looping. " +
+		"Could you rephrase the request or give me more details?"
+}
+
+// requestMaxIterations reads a per-request "max_tool_iterations" from
+// message metadata. It can only lower the agent's cap.
+func requestMaxIterations(metadata map[string]string, agentMax int) int {
+	n, err := strconv.Atoi(metadata["max_tool_iterations"])
+	if err != nil || n <= 0 || n > agentMax {
+		return agentMax
+	}
+	return n
+}
@@ -7,9 +7,11 @@
 package agent
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -41,10 +43,7 @@ func NewMemoryStore(workspace string) *MemoryStore {
 
 // getTodayFile returns the path to today's daily note file (memory/YYYYMM/YYYYMMDD.md).
 func (ms *MemoryStore) getTodayFile() string {
-	today := time.Now().Format("20060102") // YYYYMMDD
-	monthDir := today[:6]                  // YYYYMM
-	filePath := filepath.Join(ms.memoryDir, monthDir, today+".md")
-	return filePath
+	return ms.DailyNotePath(time.Now())
 }
 
 // ReadLongTerm reads the long-term memory (MEMORY.md).
@@ -98,6 +97,123 @@ func (ms *MemoryStore) AppendToday(content string) error {
 	return os.WriteFile(todayFile, []byte(newContent), 0644)
 }
 
+// DailyNote describes a single daily note file on disk.
+type DailyNote struct {
+	Date time.Time
+	Path string
+	Size int64
+}
+
+// MemoryMatch is a single line in a memory file that matched a search.
+type MemoryMatch struct {
+	Path string
+	Line int
+	Text string
+}
+
+// MemoryFile returns the path to the long-term memory file (MEMORY.md).
+func (ms *MemoryStore) MemoryFile() string {
+	return ms.memoryFile
+}
+
+// DailyNotePath returns the path of the daily note for the given date.
+func (ms *MemoryStore) DailyNotePath(date time.Time) string {
+	dateStr := date.Format("20060102")
+	return filepath.Join(ms.memoryDir, dateStr[:6], dateStr+".md")
+}
+
+// ListDailyNotes returns all daily notes found under memory/YYYYMM/, newest first.
+// Files that don't follow the YYYYMMDD.md naming scheme are ignored.
+func (ms *MemoryStore) ListDailyNotes() []DailyNote {
+	var notes []DailyNote
+
+	monthDirs, err := os.ReadDir(ms.memoryDir)
+	if err != nil {
+		return notes
+	}
+
+	for _, monthDir := range monthDirs {
+		if !monthDir.IsDir() || len(monthDir.Name()) != 6 {
+			continue
+		}
+		dirPath := filepath.Join(ms.memoryDir, monthDir.Name())
+		entries, err := os.ReadDir(dirPath)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || filepath.Ext(name) != ".md" {
+				continue
+			}
+			date, err := time.ParseInLocation("20060102", strings.TrimSuffix(name, ".md"), time.Local)
+			if err != nil {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			notes = append(notes, DailyNote{
+				Date: date,
+				Path: filepath.Join(dirPath, name),
+				Size: info.Size(),
+			})
+		}
+	}
+
+	sort.Slice(notes, func(i, j int) bool {
+		return notes[i].Date.After(notes[j].Date)
+	})
+	return notes
+}
+
+// Search performs a case-insensitive substring search over MEMORY.md and
+// all daily notes. Long-term memory matches come first, followed by daily
+// notes from newest to oldest.
+func (ms *MemoryStore) Search(query string) []MemoryMatch {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	files := []string{ms.memoryFile}
+	for _, note := range ms.ListDailyNotes() {
+		files = append(files, note.Path)
+	}
+
+	var matches []MemoryMatch
+	for _, path := range files {
+		matches = append(matches, searchFile(path, query)...)
+	}
+	return matches
+}
+
+// searchFile returns every line in path containing the (lowercased) query.
+func searchFile(path, query string) []MemoryMatch {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var matches []MemoryMatch
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if strings.Contains(strings.ToLower(line), query) {
+			matches = append(matches, MemoryMatch{
+				Path: path,
+				Line: lineNum,
+				Text: strings.TrimSpace(line),
+			})
+		}
+	}
+	return matches
+}
+
 // GetRecentDailyNotes returns daily notes from the last N days.
 // Contents are joined with "---" separator.
 func (ms *MemoryStore) GetRecentDailyNotes(days int) string {
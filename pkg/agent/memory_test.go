@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeDailyNote(t *testing.T, ms *MemoryStore, date time.Time, content string) {
+	t.Helper()
+	path := ms.DailyNotePath(date)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write daily note: %v", err)
+	}
+}
+
+func TestMemoryStore_ListDailyNotes_NewestFirst(t *testing.T) {
+	ms := NewMemoryStore(t.TempDir())
+
+	jan := time.Date(2026, 1, 15, 0, 0, 0, 0, time.Local)
+	feb := time.Date(2026, 2, 3, 0, 0, 0, 0, time.Local)
+	writeDailyNote(t, ms, jan, "# 2026-01-15\n\nold")
+	writeDailyNote(t, ms, feb, "# 2026-02-03\n\nnew")
+
+	// Files that don't match the YYYYMMDD.md layout are ignored
+	os.WriteFile(filepath.Join(filepath.Dir(ms.DailyNotePath(feb)), "notes.md"), []byte("x"), 0644)
+
+	notes := ms.ListDailyNotes()
+	if len(notes) != 2 {
+		t.Fatalf("len(notes) = %d, want 2", len(notes))
+	}
+	if !notes[0].Date.Equal(feb) || !notes[1].Date.Equal(jan) {
+		t.Errorf("notes not sorted newest first: %v, %v", notes[0].Date, notes[1].Date)
+	}
+	if notes[0].Size == 0 {
+		t.Error("expected non-zero size")
+	}
+}
+
+func TestMemoryStore_Search(t *testing.T) {
+	ms := NewMemoryStore(t.TempDir())
+	if err := ms.WriteLongTerm("# Memory\n\nFavorite color: Blue\n"); err != nil {
+		t.Fatalf("WriteLongTerm: %v", err)
+	}
+	writeDailyNote(t, ms, time.Date(2026, 2, 3, 0, 0, 0, 0, time.Local), "# 2026-02-03\n\nBought blue shoes\nNothing else")
+
+	matches := ms.Search("BLUE")
+	if len(matches) != 2 {
+		t.Fatalf("len(matches) = %d, want 2", len(matches))
+	}
+	if matches[0].Path != ms.MemoryFile() || matches[0].Line != 3 {
+		t.Errorf("first match = %+v, want MEMORY.md line 3", matches[0])
+	}
+	if matches[1].Text != "Bought blue shoes" {
+		t.Errorf("second match text = %q", matches[1].Text)
+	}
+
+	if got := ms.Search("   "); len(got) != 0 {
+		t.Errorf("empty query returned %d matches", len(got))
+	}
+}
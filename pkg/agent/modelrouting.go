@@ -0,0 +1,135 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// cheapRoute is the provider and model simple turns go to.
+type cheapRoute struct {
+	provider providers.LLMProvider
+	model    string
+}
+
+// isRoutingCommand reports whether content is a !routing command.
+func isRoutingCommand(content string) bool {
+	fields := strings.Fields(content)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "!routing")
+}
+
+// handleRouting shows or sets the model choice of a session.
+func (al *AgentLoop) handleRouting(agent *AgentInstance, sessionKey, content string) string {
+	cfg := al.cfg.Agents.Defaults.Routing
+	_, model := agent.CurrentModel()
+	if !cfg.Enabled || cfg.CheapModel == "" {
+		return fmt.Sprintf("Model routing is off; every turn uses %s.", model)
+	}
+
+	fields := strings.Fields(content)
+	if len(fields) < 2 {
+		mode := agent.Sessions.GetRouting(sessionKey)
+		if mode == "" {
+			mode = "auto"
+		}
+		return fmt.Sprintf("Routing: %s (send !routing auto|cheap|smart to change it)", mode)
+	}
+
+	mode := strings.ToLower(fields[1])
+	switch mode {
+	case "auto":
+		agent.Sessions.SetRouting(sessionKey, "")
+	case "cheap", "smart":
+		agent.Sessions.SetRouting(sessionKey, mode)
+	default:
+		return "Usage: !routing auto|cheap|smart"
+	}
+	if err := agent.Sessions.Save(sessionKey); err != nil {
+		logger.WarnCF("agent", "Failed to save routing mode", map[string]interface{}{
+			"session_key": sessionKey,
+			"error":       err.Error(),
+		})
+	}
+
+	switch mode {
+	case "cheap":
+		return fmt.Sprintf("Routing set to cheap: every turn in this chat uses %s.", cfg.CheapModel)
+	case "smart":
+		return fmt.Sprintf("Routing set to smart: every turn in this chat uses %s.", model)
+	default:
+		return fmt.Sprintf("Routing back to auto: simple messages use %s, the rest %s.", cfg.CheapModel, model)
+	}
+}
+
+// routeTurn decides whether a turn starts on the cheap model. escalate is
+// false when the session asked for the cheap model, so its tool calls
+// stay there too.
+func (al *AgentLoop) routeTurn(agent *AgentInstance, messages []providers.Message, opts processOptions) (route *cheapRoute, escalate bool) {
+	cfg := al.cfg.Agents.Defaults.Routing
+	if !cfg.Enabled || cfg.CheapModel == "" || opts.Turn.IsCanary() {
+		return nil, false
+	}
+
+	switch agent.Sessions.GetRouting(opts.SessionKey) {
+	case "smart":
+		return nil, false
+	case "cheap":
+		return al.cheapRoute(agent), false
+	}
+
+	if len(opts.Media) > 0 {
+		return nil, false
+	}
+	if cfg.MaxChars > 0 && utf8.RuneCountInString(opts.UserMessage) > cfg.MaxChars {
+		return nil, false
+	}
+	// The system prompt is the same for every turn, so only the
+	// conversation counts
+	if cfg.MaxContextTokens > 0 && len(messages) > 1 && al.estimateTokens(messages[1:]) > cfg.MaxContextTokens {
+		return nil, false
+	}
+	return al.cheapRoute(agent), true
+}
+
+// cheapRoute resolves the cheap model. A model from model_list gets its
+// own provider; any other name goes to the agent's provider.
+func (al *AgentLoop) cheapRoute(agent *AgentInstance) *cheapRoute {
+	al.cheapOnce.Do(func() {
+		name := al.cfg.Agents.Defaults.Routing.CheapModel
+		if len(al.cfg.ModelList) == 0 {
+			return
+		}
+		modelCfg, err := al.cfg.GetModelConfig(name)
+		if err != nil {
+			return
+		}
+		if modelCfg.Workspace == "" {
+			modelCfg.Workspace = al.cfg.WorkspacePath()
+		}
+		provider, modelID, err := providers.CreateProviderFromConfig(modelCfg)
+		if err != nil {
+			logger.WarnCF("agent", "Failed to set up the cheap model, using the agent's provider", map[string]interface{}{
+				"model": name,
+				"error": err.Error(),
+			})
+			return
+		}
+		al.cheap = &cheapRoute{
+			provider: providers.WrapWireLog(provider, al.cfg.WireLog, al.cfg.WorkspacePath()),
+			model:    modelID,
+		}
+	})
+	if al.cheap != nil {
+		return al.cheap
+	}
+	provider, _ := agent.CurrentModel()
+	return &cheapRoute{provider: provider, model: al.cfg.Agents.Defaults.Routing.CheapModel}
+}
@@ -0,0 +1,167 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/privacy"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/session"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// maxSessionPins caps the pins of a chat, since all of them go into every
+// prompt once the conversation has moved past them.
+const maxSessionPins = 20
+
+// isPinCommand reports whether content is !pin, !pins or !unpin.
+func isPinCommand(content string) bool {
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToLower(fields[0]) {
+	case "!pin", "!pins", "!unpin":
+		return true
+	}
+	return false
+}
+
+// handlePinCommand pins the reply the command answers, or the latest one,
+// lists the pins of the session, or removes one.
+func (al *AgentLoop) handlePinCommand(agent *AgentInstance, sessionKey string, msg bus.InboundMessage) string {
+	fields := strings.Fields(msg.Content)
+	switch strings.ToLower(fields[0]) {
+	case "!pins":
+		return listPins(agent.Sessions.GetPins(sessionKey))
+	case "!unpin":
+		if len(fields) < 2 {
+			return "Usage: !unpin <number>, see !pins"
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return "Usage: !unpin <number>, see !pins"
+		}
+		pin, ok := agent.Sessions.RemovePin(sessionKey, n-1)
+		if !ok {
+			return fmt.Sprintf("There's no pin %d here, see !pins.", n)
+		}
+		al.savePins(agent, sessionKey)
+		return "Unpinned: " + utils.Truncate(pin.Assistant, 80)
+	}
+	return al.pinTurn(agent, sessionKey, msg.Channel, strings.TrimSpace(msg.Metadata["reply_to_text"]))
+}
+
+// pinReply pins a reply the user reacted to with 📌.
+func (al *AgentLoop) pinReply(agent *AgentInstance, sessionKey string, msg bus.InboundMessage) string {
+	text := strings.TrimSpace(msg.Content)
+	if text == "" {
+		return "There's no text in that reply to save."
+	}
+	return al.pinTurn(agent, sessionKey, msg.Channel, text)
+}
+
+// pinTurn pins the exchange of the reply whose text is pointed, or of the
+// latest reply when pointed is empty: the reply and the message it
+// answered stay in the session's prompt through summaries and pruning,
+// and go to today's daily note for the memory to pick up. A reply no
+// longer in the session is pinned on its own.
+func (al *AgentLoop) pinTurn(agent *AgentInstance, sessionKey, channel, pointed string) string {
+	history := agent.Sessions.GetHistory(sessionKey)
+	pin := session.Pin{Assistant: pointed}
+	if idx := fixTarget(history, pointed); idx >= 0 {
+		pin.Assistant = strings.TrimSpace(history[idx].Content)
+		for i := idx - 1; i >= 0; i-- {
+			if history[i].Role == "user" {
+				pin.User = strings.TrimSpace(history[i].Content)
+				break
+			}
+		}
+	}
+	if pin.Assistant == "" {
+		return "There's no reply of mine here to pin."
+	}
+	if len(agent.Sessions.GetPins(sessionKey)) >= maxSessionPins {
+		return fmt.Sprintf("This chat already has %d pins; !unpin one first.", maxSessionPins)
+	}
+	if !agent.Sessions.AddPin(sessionKey, pin) {
+		return "📌 That's already pinned."
+	}
+	al.savePins(agent, sessionKey)
+
+	var note strings.Builder
+	fmt.Fprintf(&note, "## Pinned from %s (%s)\n\n", channel, time.Now().Format("15:04"))
+	if pin.User != "" {
+		fmt.Fprintf(&note, "> %s\n\n", strings.ReplaceAll(pin.User, "\n", "\n> "))
+	}
+	note.WriteString(pin.Assistant + "\n")
+	if err := NewMemoryStore(agent.Workspace).AppendToday(note.String()); err != nil {
+		logger.WarnCF("agent", "Failed to save pin to memory", map[string]interface{}{
+			"agent_id": agent.ID,
+			"error":    err.Error(),
+		})
+		return "📌 Pinned in this chat, but I couldn't save it to memory."
+	}
+	return "📌 Pinned and saved to memory."
+}
+
+func (al *AgentLoop) savePins(agent *AgentInstance, sessionKey string) {
+	if err := agent.Sessions.Save(sessionKey); err != nil {
+		logger.WarnCF("agent", "Failed to save pins", map[string]interface{}{
+			"session_key": sessionKey,
+			"error":       err.Error(),
+		})
+	}
+}
+
+func listPins(pins []session.Pin) string {
+	if len(pins) == 0 {
+		return "Nothing is pinned here. Send !pin after a reply, or react to it with 📌, to keep it."
+	}
+	var sb strings.Builder
+	sb.WriteString("📌 Pinned in this chat:")
+	for i, p := range pins {
+		fmt.Fprintf(&sb, "\n%d. %s", i+1, utils.Truncate(strings.ReplaceAll(p.Assistant, "\n", " "), 80))
+	}
+	sb.WriteString("\n\n!unpin <number> removes one.")
+	return sb.String()
+}
+
+// withPins adds the session's pinned exchanges to the system prompt of
+// messages, leaving out those still in history and those mentioning a
+// purged user.
+func withPins(messages []providers.Message, pins []session.Pin, history []providers.Message, tombstones *privacy.Tombstones) []providers.Message {
+	if len(pins) == 0 || len(messages) == 0 || messages[0].Role != "system" {
+		return messages
+	}
+	inHistory := make(map[string]bool)
+	for _, m := range history {
+		if m.Role == "assistant" {
+			inHistory[strings.TrimSpace(m.Content)] = true
+		}
+	}
+	var sb strings.Builder
+	for _, p := range pins {
+		if inHistory[p.Assistant] || tombstones.Mentions(p.User) || tombstones.Mentions(p.Assistant) {
+			continue
+		}
+		if p.User != "" {
+			fmt.Fprintf(&sb, "\nUser: %s\n", p.User)
+		}
+		fmt.Fprintf(&sb, "You: %s\n", p.Assistant)
+	}
+	if sb.Len() == 0 {
+		return messages
+	}
+	messages[0].Content += "\n\n## Pinned Exchanges\n\nThe user pinned these earlier parts of this conversation. Keep to what was decided in them.\n" + strings.TrimRight(sb.String(), "\n")
+	return messages
+}
@@ -0,0 +1,66 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/prompts"
+)
+
+// expandPrompt handles "/prompt <name> key=value ...". It returns the
+// expanded prompt to process as the user's message, or a reply to send
+// instead: the library listing for a bare "/prompt", or what went wrong.
+func expandPrompt(workspace, content string) (expanded, reply string) {
+	args := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(content), "/prompt"))
+	dir := prompts.Dir(workspace)
+
+	name, rest := args, ""
+	if i := strings.IndexAny(args, " \t\r\n"); i >= 0 {
+		name, rest = args[:i], args[i:]
+	}
+	if name == "" || name == "list" {
+		return "", listPrompts(dir)
+	}
+
+	p, err := prompts.Find(dir, name)
+	if err != nil {
+		return "", fmt.Sprintf("%v. Send /prompt to see the library.", err)
+	}
+	expanded, err = p.ExpandArgs(rest)
+	if err != nil {
+		return "", fmt.Sprintf("Prompt %s: %v", p.Name, err)
+	}
+	return expanded, ""
+}
+
+// isPromptCommand reports whether content invokes the prompts library.
+func isPromptCommand(content string) bool {
+	content = strings.TrimSpace(content)
+	return content == "/prompt" || strings.HasPrefix(content, "/prompt ") || strings.HasPrefix(content, "/prompt\n")
+}
+
+func listPrompts(dir string) string {
+	library, err := prompts.Load(dir)
+	if err != nil {
+		return fmt.Sprintf("Failed to load prompts: %v", err)
+	}
+	if len(library) == 0 {
+		return "No prompts yet. Add markdown files to workspace/prompts to build your library."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Prompts:\n")
+	for _, p := range library {
+		sb.WriteString("• " + p.Usage())
+		if p.Description != "" {
+			sb.WriteString(" — " + p.Description)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,33 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"github.com/sipeed/picoclaw/pkg/audit"
+	"github.com/sipeed/picoclaw/pkg/expenses"
+	"github.com/sipeed/picoclaw/pkg/habits"
+	"github.com/sipeed/picoclaw/pkg/journal"
+	"github.com/sipeed/picoclaw/pkg/privacy"
+	"github.com/sipeed/picoclaw/pkg/wellness"
+)
+
+// NewUserPurger creates the purger that forgets a user in the agent's
+// workspace: its sessions and memory, the audit log, and the per-user
+// stores of the tools (health records, expenses, habits and journal
+// subscriptions). auditLog is the live log when the agent writes to one;
+// nil opens the workspace's.
+func NewUserPurger(a *AgentInstance, auditLog *audit.Log) *privacy.Purger {
+	purger := privacy.NewPurger(a.Workspace, a.Sessions)
+	if auditLog == nil {
+		auditLog = audit.NewLog(a.Workspace)
+	}
+	purger.AddStore(auditLog)
+	purger.AddStore(wellness.NewStore(a.Workspace))
+	purger.AddStore(expenses.NewStore(a.Workspace))
+	purger.AddStore(habits.NewStore(a.Workspace))
+	purger.AddStore(journal.NewStore(a.Workspace))
+	return purger
+}
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/expenses"
+	"github.com/sipeed/picoclaw/pkg/habits"
+	"github.com/sipeed/picoclaw/pkg/journal"
+	"github.com/sipeed/picoclaw/pkg/wellness"
+)
+
+func TestNewUserPurger_PurgesToolStores(t *testing.T) {
+	cfg := &config.Config{
+		Agents: config.AgentsConfig{
+			Defaults: config.AgentDefaults{
+				Workspace: t.TempDir(),
+				Model:     "test-model",
+			},
+		},
+	}
+	agent := NewAgentInstance(nil, &cfg.Agents.Defaults, cfg, &mockProvider{})
+	ws := agent.Workspace
+	now := time.Now()
+
+	health := wellness.NewStore(ws)
+	health.Add("12345", wellness.Record{Date: now.Format("2006-01-02"), Kind: wellness.Sleep, Value: 7})
+	health.Add("99999", wellness.Record{Date: now.Format("2006-01-02"), Kind: wellness.Weight, Value: 70})
+	spending := expenses.NewStore(ws)
+	spending.Add(expenses.Entry{Date: now, Amount: 12, Category: "pharmacy", User: "12345"})
+	spending.Add(expenses.Entry{Date: now, Amount: 3, Category: "coffee", User: "99999"})
+	habitStore := habits.NewStore(ws)
+	habitStore.Define(habits.User{Channel: "telegram", ChatID: "12345", SenderID: "12345"}, "meds", habits.Daily, "")
+	journalStore := journal.NewStore(ws)
+	journalStore.Subscribe(journal.User{Channel: "telegram", ChatID: "12345", SenderID: "12345"})
+
+	report, err := NewUserPurger(agent, nil).Purge("12345", nil)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	for name, want := range map[string]int{"wellness": 1, "expenses": 1, "habits": 1, "journal subscriptions": 1} {
+		if report.Stores[name] != want {
+			t.Errorf("%s: purged %d record(s), want %d", name, report.Stores[name], want)
+		}
+	}
+
+	if records, _ := health.Records("12345"); len(records) != 0 {
+		t.Errorf("health records left: %+v", records)
+	}
+	if records, _ := health.Records("99999"); len(records) != 1 {
+		t.Errorf("another user's health records = %+v", records)
+	}
+	if entries, _ := spending.Month(now, "12345"); len(entries) != 0 {
+		t.Errorf("expenses left: %+v", entries)
+	}
+	if entries, _ := spending.Month(now, "99999"); len(entries) != 1 {
+		t.Errorf("another user's expenses = %+v", entries)
+	}
+	if h := habitStore.Habits(habits.User{Channel: "telegram", SenderID: "12345"}); len(h) != 0 {
+		t.Errorf("habits left: %+v", h)
+	}
+	if removed, _ := journalStore.Unsubscribe(journal.User{Channel: "telegram", SenderID: "12345"}); removed {
+		t.Error("still subscribed to the journal")
+	}
+}
@@ -0,0 +1,126 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// Quote guard modes, see AgentDefaults.QuoteGuard.
+const (
+	quoteGuardOff    = "off"
+	quoteGuardFlag   = "flag"
+	quoteGuardRemove = "remove"
+)
+
+const (
+	unverifiedQuoteMark = " ⚠️ (unverified quote)"
+	removedQuote        = "[quote removed: not found in this chat]"
+)
+
+// A member is named by a mention, <@123> or @name; quoting public figures
+// by name is fine.
+const quoteSpeaker = `(?:<@!?\d+>|@[\w.\-]{2,32})`
+
+// quotePatterns find quotes attributed to chat members. The quoted text is
+// always the first capture group.
+var quotePatterns = []*regexp.Regexp{
+	// @bob said: "text", <@123>: "text"
+	regexp.MustCompile(quoteSpeaker + `\s*(?:said|says|wrote)?\s*:?\s*["“]([^"”\n]{3,})["”]`),
+	// "text" — @bob
+	regexp.MustCompile(`["“]([^"”\n]{3,})["”]\s*[—–-]{1,2}\s*` + quoteSpeaker),
+	// > text
+	// — @bob
+	regexp.MustCompile(`(?m)^>\s*(.{3,})\n\s*[—–-]{1,2}\s*` + quoteSpeaker),
+}
+
+// guardQuotes checks quotes that text attributes to chat members against
+// what was actually said in the session, and flags or removes the ones it
+// can't find, so the agent can't be talked into putting words in other
+// people's mouths.
+func (al *AgentLoop) guardQuotes(agent *AgentInstance, sessionKey, text string) string {
+	mode := al.cfg.Agents.Defaults.QuoteGuard
+	if mode == quoteGuardOff || mode == "" {
+		return text
+	}
+	guarded, fabricated := guardQuotes(text, agent.Sessions.GetHistory(sessionKey), mode)
+	if len(fabricated) > 0 {
+		logger.WarnCF("agent", "Unverified quotes of chat members in reply", map[string]interface{}{
+			"agent_id":    agent.ID,
+			"session_key": sessionKey,
+			"quotes":      fabricated,
+			"mode":        mode,
+		})
+	}
+	return guarded
+}
+
+// guardQuotes returns text with quotes not found in the user messages of
+// history flagged or removed, and the quotes it changed.
+func guardQuotes(text string, history []providers.Message, mode string) (string, []string) {
+	type span struct{ start, end int }
+	var spans []span
+	for _, re := range quotePatterns {
+		for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+			spans = append(spans, span{m[2], m[3]})
+		}
+	}
+	if len(spans) == 0 {
+		return text, nil
+	}
+
+	var said strings.Builder
+	for _, m := range history {
+		if m.Role == "user" {
+			said.WriteString(normalizeQuote(m.Content))
+			said.WriteString("\n")
+		}
+	}
+	heard := said.String()
+
+	// Replace from the end so earlier offsets stay valid
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start > spans[j].start })
+	var fabricated []string
+	end := len(text) + 1
+	for _, s := range spans {
+		if s.end > end {
+			continue // overlaps a quote already handled
+		}
+		quote := text[s.start:s.end]
+		if q := normalizeQuote(quote); q == "" || strings.Contains(heard, q) {
+			continue
+		}
+		fabricated = append(fabricated, quote)
+		end = s.start
+		if mode == quoteGuardRemove {
+			text = text[:s.start] + removedQuote + text[s.end:]
+			continue
+		}
+		// Mark after the closing quote mark, or the line for a blockquote
+		after := s.end
+		if after < len(text) && text[after] != '\n' {
+			_, size := utf8.DecodeRuneInString(text[after:])
+			after += size
+		}
+		text = text[:after] + unverifiedQuoteMark + text[after:]
+	}
+	return text, fabricated
+}
+
+// normalizeQuote reduces text to lowercase words, so a quote matches the
+// message it came from despite punctuation and spacing.
+func normalizeQuote(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
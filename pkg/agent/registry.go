@@ -51,6 +51,10 @@ func NewAgentRegistry(
 		}
 	}
 
+	if cfg.Tenants.Enabled {
+		registry.registerTenants(cfg, provider)
+	}
+
 	return registry
 }
 
@@ -89,6 +93,10 @@ func (r *AgentRegistry) CanSpawnSubagent(parentAgentID, targetAgentID string) bo
 		return false
 	}
 	targetNorm := routing.NormalizeAgentID(targetAgentID)
+	if routing.IsTenantAgentID(targetNorm) {
+		// A tenant's workspace is reachable only by its own members
+		return false
+	}
 	for _, allowed := range parent.Subagents.AllowAgents {
 		if allowed == "*" {
 			return true
@@ -100,6 +108,29 @@ func (r *AgentRegistry) CanSpawnSubagent(parentAgentID, targetAgentID string) bo
 	return false
 }
 
+// DefaultWorkspace returns the workspace of the agent GetDefaultAgent
+// picks, for commands that share its files without an agent loop.
+func DefaultWorkspace(cfg *config.Config) string {
+	agents := cfg.Agents.List
+	if len(agents) == 0 {
+		return resolveAgentWorkspace(nil, &cfg.Agents.Defaults)
+	}
+	defaultID := routing.NewRouteResolver(cfg).DefaultAgentID()
+	var fallback *config.AgentConfig
+	for i := range agents {
+		switch routing.NormalizeAgentID(agents[i].ID) {
+		case "main":
+			return resolveAgentWorkspace(&agents[i], &cfg.Agents.Defaults)
+		case defaultID:
+			fallback = &agents[i]
+		}
+	}
+	if fallback == nil {
+		fallback = &agents[0]
+	}
+	return resolveAgentWorkspace(fallback, &cfg.Agents.Defaults)
+}
+
 // GetDefaultAgent returns the default agent instance.
 func (r *AgentRegistry) GetDefaultAgent() *AgentInstance {
 	r.mu.RLock()
@@ -107,8 +138,13 @@ func (r *AgentRegistry) GetDefaultAgent() *AgentInstance {
 	if agent, ok := r.agents["main"]; ok {
 		return agent
 	}
-	for _, agent := range r.agents {
+	if agent, ok := r.agents[r.resolver.DefaultAgentID()]; ok {
 		return agent
 	}
+	for id, agent := range r.agents {
+		if !routing.IsTenantAgentID(id) {
+			return agent
+		}
+	}
 	return nil
 }
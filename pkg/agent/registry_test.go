@@ -98,10 +98,24 @@ func TestAgentRegistry_GetDefaultAgent(t *testing.T) {
 	})
 	registry := NewAgentRegistry(cfg, &mockRegistryProvider{})
 
-	// GetDefaultAgent first checks for "main", then returns any
+	// With no "main", the agent marked default wins
 	agent := registry.GetDefaultAgent()
-	if agent == nil {
-		t.Fatal("expected a default agent")
+	if agent == nil || agent.ID != "beta" {
+		t.Fatalf("default agent = %+v, want beta", agent)
+	}
+}
+
+func TestDefaultWorkspace(t *testing.T) {
+	cfg := testCfg([]config.AgentConfig{
+		{ID: "alpha", Workspace: "/tmp/picoclaw-alpha"},
+		{ID: "beta", Default: true, Workspace: "/tmp/picoclaw-beta"},
+	})
+	if got := DefaultWorkspace(cfg); got != "/tmp/picoclaw-beta" {
+		t.Errorf("DefaultWorkspace = %q, want the default agent's", got)
+	}
+	registry := NewAgentRegistry(cfg, &mockRegistryProvider{})
+	if got := registry.GetDefaultAgent().Workspace; got != DefaultWorkspace(cfg) {
+		t.Errorf("registry default workspace %q differs from DefaultWorkspace %q", got, DefaultWorkspace(cfg))
 	}
 }
 
@@ -197,3 +211,37 @@ func TestAgentInstance_FallbackExplicitEmpty(t *testing.T) {
 		t.Errorf("expected 0 fallbacks (explicit empty), got %d: %v", len(agent.Fallbacks), agent.Fallbacks)
 	}
 }
+
+func TestNewAgentRegistry_Tenants(t *testing.T) {
+	cfg := testCfg(nil)
+	temp := 0.2
+	cfg.Tenants = config.TenantsConfig{
+		Enabled: true,
+		List: []config.TenantConfig{
+			{ID: "alex", Workspace: t.TempDir(), MaxTokens: 1024, Temperature: &temp, Members: []string{"telegram:1"}},
+			{ID: "Kim", Workspace: t.TempDir(), Model: &config.AgentModelConfig{Primary: "cheap"}},
+		},
+	}
+	registry := NewAgentRegistry(cfg, &mockRegistryProvider{})
+
+	alex, ok := registry.GetAgent("tenant-alex")
+	if !ok || alex.Tenant == nil || alex.Tenant.ID != "alex" {
+		t.Fatalf("tenant agent = %+v, %v", alex, ok)
+	}
+	if alex.MaxTokens != 1024 || alex.Temperature != 0.2 || alex.Model != "gpt-4" {
+		t.Errorf("overlay: max_tokens %d, temperature %v, model %q", alex.MaxTokens, alex.Temperature, alex.Model)
+	}
+	kim, ok := registry.GetAgent("tenant-kim")
+	if !ok || kim.Model != "cheap" || kim.Workspace == alex.Workspace {
+		t.Errorf("kim = %+v", kim)
+	}
+
+	if d := registry.GetDefaultAgent(); d == nil || d.ID != "main" {
+		t.Errorf("default agent = %+v", d)
+	}
+	main, _ := registry.GetAgent("main")
+	main.Subagents = &config.SubagentsConfig{AllowAgents: []string{"*"}}
+	if registry.CanSpawnSubagent("main", "tenant-alex") {
+		t.Error("main should not reach a tenant's agent")
+	}
+}
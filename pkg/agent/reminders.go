@@ -0,0 +1,42 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/cron"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// SetCronService lets users list and cancel the reminders of their chat
+// with !reminders.
+func (al *AgentLoop) SetCronService(cs *cron.CronService) {
+	al.cronService = cs
+}
+
+// handleRemindersCommand answers "!reminders" with the chat's pending
+// reminders and "!reminders cancel <id>" by cancelling one of them.
+func (al *AgentLoop) handleRemindersCommand(msg bus.InboundMessage) (string, bool) {
+	fields := strings.Fields(msg.Content)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "!reminders") || al.cronService == nil {
+		return "", false
+	}
+
+	switch {
+	case len(fields) == 1:
+		return tools.FormatReminders(tools.ChatReminders(al.cronService, al.bus, msg.Channel, msg.ChatID)), true
+	case len(fields) == 3 && strings.EqualFold(fields[1], "cancel"):
+		job, ok := tools.CancelReminder(al.cronService, al.bus, msg.Channel, msg.ChatID, fields[2])
+		if !ok {
+			return fmt.Sprintf("There's no pending reminder %s here.", fields[2]), true
+		}
+		return fmt.Sprintf("Cancelled: %s", job.Name), true
+	}
+	return "Usage: !reminders, or !reminders cancel <id>", true
+}
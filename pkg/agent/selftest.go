@@ -0,0 +1,107 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// toolSelfTestTimeout bounds the self-test of one agent's tools.
+const toolSelfTestTimeout = 30 * time.Second
+
+// ToolCheck is the self-test result of a tool of one agent.
+type ToolCheck struct {
+	Agent string
+	tools.ToolCheck
+}
+
+// Label names the tool, with its agent unless that is the default one.
+func (c ToolCheck) Label() string {
+	if c.Agent == "" {
+		return c.Name
+	}
+	return c.Agent + "/" + c.Name
+}
+
+// OnToolCheck sets fn to be called with the results of every tool
+// self-test, e.g. to report them on the gateway's status endpoint.
+func (al *AgentLoop) OnToolCheck(fn func(checks []ToolCheck)) {
+	al.onToolCheck = fn
+}
+
+// SelfTestTools checks the tools of every agent: that their schemas are
+// ones the providers accept, and that what they depend on is there. It
+// logs the broken ones, so they show up before the model first calls
+// them.
+func (al *AgentLoop) SelfTestTools(ctx context.Context) []ToolCheck {
+	defaultAgent := al.registry.GetDefaultAgent()
+	var checks []ToolCheck
+	for _, agentID := range al.registry.ListAgentIDs() {
+		agent, ok := al.registry.GetAgent(agentID)
+		if !ok {
+			continue
+		}
+		label := agent.ID
+		if agent == defaultAgent {
+			label = ""
+		}
+		testCtx, cancel := context.WithTimeout(ctx, toolSelfTestTimeout)
+		for _, c := range agent.Tools.SelfTest(testCtx) {
+			checks = append(checks, ToolCheck{Agent: label, ToolCheck: c})
+			if !c.OK() {
+				logger.WarnCF("agent", "Tool self-test failed", map[string]interface{}{
+					"agent_id": agent.ID,
+					"tool":     c.Name,
+					"problem":  c.Problem,
+				})
+			}
+		}
+		cancel()
+	}
+	if al.onToolCheck != nil {
+		al.onToolCheck(checks)
+	}
+	return checks
+}
+
+// ToolCheckSummary reports whether every tool passed, and lists the ones
+// that didn't.
+func ToolCheckSummary(checks []ToolCheck) (bool, string) {
+	var broken []string
+	for _, c := range checks {
+		if !c.OK() {
+			broken = append(broken, c.Label()+": "+c.Problem)
+		}
+	}
+	if len(broken) == 0 {
+		return true, fmt.Sprintf("%d tools ok", len(checks))
+	}
+	return false, fmt.Sprintf("%d of %d tools broken: %s", len(broken), len(checks), strings.Join(broken, "; "))
+}
+
+// adminSelfTest runs the tool self-test on demand.
+func (al *AgentLoop) adminSelfTest(ctx context.Context) string {
+	checks := al.SelfTestTools(ctx)
+	ok, summary := ToolCheckSummary(checks)
+	if ok {
+		return "✅ " + summary
+	}
+	var sb strings.Builder
+	broken := 0
+	for _, c := range checks {
+		if !c.OK() {
+			broken++
+			fmt.Fprintf(&sb, "\n- %s: %s", c.Label(), c.Problem)
+		}
+	}
+	return fmt.Sprintf("⚠️ %d of %d tools broken:%s", broken, len(checks), sb.String())
+}
@@ -0,0 +1,59 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/constants"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// streamPartialInterval is the least time between two partial replies
+// published while a reply streams; channels may show them less often.
+const streamPartialInterval = 300 * time.Millisecond
+
+// streams reports whether user turns publish their reply while it's
+// generated. Quotes are only checked in the complete reply, so a turn
+// doesn't stream while the quote guard is on.
+func (al *AgentLoop) streams() bool {
+	defaults := al.cfg.Agents.Defaults
+	return defaults.Stream && (defaults.QuoteGuard == "" || defaults.QuoteGuard == quoteGuardOff)
+}
+
+// chat calls the provider. For turns with opts.Stream and a provider that
+// can stream, the reply so far is published as partial outbound messages
+// while it's generated, for channels that show progress. Partials stop
+// once the reply turns out to call tools.
+func (al *AgentLoop) chat(ctx context.Context, provider providers.LLMProvider, messages []providers.Message, toolDefs []providers.ToolDefinition, model string, llmOpts map[string]interface{}, opts processOptions) (*providers.LLMResponse, error) {
+	sp, ok := provider.(providers.StreamingProvider)
+	if !ok || !opts.Stream || opts.ChatID == "" || constants.IsInternalChannel(opts.Channel) {
+		return provider.Chat(ctx, messages, toolDefs, model, llmOpts)
+	}
+
+	chunks, err := sp.ChatStream(ctx, messages, toolDefs, model, llmOpts)
+	if err != nil {
+		return nil, err
+	}
+	var content strings.Builder
+	var published time.Time
+	return providers.CollectStream(chunks, func(delta string) {
+		content.WriteString(delta)
+		if time.Since(published) < streamPartialInterval {
+			return
+		}
+		published = time.Now()
+		al.bus.PublishOutbound(bus.OutboundMessage{
+			Channel: opts.Channel,
+			ChatID:  opts.ChatID,
+			Content: content.String(),
+			Partial: true,
+		})
+	})
+}
@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/routing"
+)
+
+// registerTenants adds an agent for each tenant, with the tenant's
+// overrides of the agent defaults and its own workspace.
+func (r *AgentRegistry) registerTenants(cfg *config.Config, provider providers.LLMProvider) {
+	for i := range cfg.Tenants.List {
+		t := cfg.Tenants.List[i]
+		if t.ID == "" {
+			logger.WarnC("agent", "Skipping tenant without an id")
+			continue
+		}
+		for _, m := range t.Members {
+			if ch, id, ok := strings.Cut(m, ":"); !ok || ch == "" || id == "" {
+				logger.WarnCF("agent", "Tenant member isn't channel:sender_id and matches no one", map[string]interface{}{
+					"tenant": t.ID,
+					"member": m,
+				})
+			}
+		}
+
+		defaults := cfg.Agents.Defaults
+		if t.MaxTokens > 0 {
+			defaults.MaxTokens = t.MaxTokens
+		}
+		if t.Temperature != nil {
+			defaults.Temperature = t.Temperature
+		}
+		if t.MaxToolIterations > 0 {
+			defaults.MaxToolIterations = t.MaxToolIterations
+		}
+
+		name := t.Name
+		if name == "" {
+			name = t.ID
+		}
+		agentCfg := &config.AgentConfig{
+			ID:        routing.TenantAgentID(t.ID),
+			Name:      name,
+			Workspace: t.Workspace,
+			Model:     t.Model,
+		}
+		tenantProvider := provider
+		if p, modelID, ok := newTenantProvider(cfg, &t, resolveAgentModel(agentCfg, &defaults)); ok {
+			tenantProvider = p
+			agentCfg.Model = &config.AgentModelConfig{
+				Primary:   modelID,
+				Fallbacks: resolveAgentFallbacks(agentCfg, &defaults),
+			}
+		}
+
+		instance := NewAgentInstance(agentCfg, &defaults, cfg, tenantProvider)
+		instance.Tenant = &t
+		r.agents[instance.ID] = instance
+		logger.InfoCF("agent", "Registered tenant",
+			map[string]interface{}{
+				"tenant":    t.ID,
+				"agent_id":  instance.ID,
+				"workspace": instance.Workspace,
+				"model":     instance.Model,
+				"own_key":   tenantProvider != provider,
+			})
+	}
+}
+
+// newTenantProvider creates a provider for model with the tenant's own key
+// for it. ok is false when the tenant maps no key to model, or the model
+// isn't in model_list, so the tenant shares the default provider.
+func newTenantProvider(cfg *config.Config, t *config.TenantConfig, model string) (providers.LLMProvider, string, bool) {
+	key, ok := t.APIKeys[model]
+	if !ok || key == "" {
+		return nil, "", false
+	}
+	modelCfg, err := cfg.GetModelConfig(model)
+	if err != nil {
+		logger.WarnCF("agent", "Tenant key is for a model not in model_list, using the default provider", map[string]interface{}{
+			"tenant": t.ID,
+			"model":  model,
+		})
+		return nil, "", false
+	}
+	own := *modelCfg
+	own.APIKey = key
+	own.APIKeys = nil
+	own.KeyPool = providers.TenantKeyPool(t.ID, own.KeyPool)
+	if own.Workspace == "" {
+		own.Workspace = cfg.WorkspacePath()
+	}
+	provider, modelID, err := providers.CreateProviderFromConfig(&own)
+	if err != nil {
+		logger.ErrorCF("agent", "Failed to create tenant provider, using the default provider", map[string]interface{}{
+			"tenant": t.ID,
+			"model":  model,
+			"error":  err.Error(),
+		})
+		return nil, "", false
+	}
+	return providers.WrapWireLog(provider, cfg.WireLog, cfg.WorkspacePath()), modelID, true
+}
+
+// tenantUsageKey is the key of a tenant's daily usage counter.
+func tenantUsageKey(agentID string, day time.Time, counter string) string {
+	return "usage:" + agentID + ":" + day.Format("2006-01-02") + ":" + counter
+}
+
+// overQuota returns the reply for a tenant that has used up today's
+// tokens or requests. Agents of no tenant, and all agents without a state
+// store, have no quota.
+func (al *AgentLoop) overQuota(agent *AgentInstance) (string, bool) {
+	t := agent.Tenant
+	if t == nil || al.store == nil || (t.DailyTokens <= 0 && t.DailyRequests <= 0) {
+		return "", false
+	}
+	now := time.Now()
+	count := func(counter string) int64 {
+		data, ok, err := al.store.Get(tenantUsageKey(agent.ID, now, counter))
+		if err != nil || !ok {
+			return 0
+		}
+		n, _ := strconv.ParseInt(string(data), 10, 64)
+		return n
+	}
+	tokens := count("prompt_tokens") + count("completion_tokens")
+	requests := count("requests")
+	if (t.DailyTokens > 0 && tokens >= t.DailyTokens) || (t.DailyRequests > 0 && requests >= t.DailyRequests) {
+		logger.InfoCF("agent", "Tenant quota reached", map[string]interface{}{
+			"tenant":   t.ID,
+			"tokens":   tokens,
+			"requests": requests,
+		})
+		return fmt.Sprintf("You've used today's quota (%d tokens, %d requests). It resets at midnight.", tokens, requests), true
+	}
+	return "", false
+}
@@ -0,0 +1,45 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// withTimeout derives a context bounded by d, or just cancelable when d is
+// zero. Because it derives from ctx, the result never outlives an earlier
+// deadline such as the turn's.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// turnContext bounds a whole turn; provider and tool calls derive from it.
+func (al *AgentLoop) turnContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, al.cfg.Timeouts.TurnTimeout())
+}
+
+// providerContext bounds one LLM call within the turn.
+func (al *AgentLoop) providerContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, al.cfg.Timeouts.ProviderTimeout())
+}
+
+// toolContext bounds one tool call within the turn. Async tools keep
+// working after the turn ends by design, so they get a context that is
+// detached from the turn's cancellation and deadline.
+func (al *AgentLoop) toolContext(ctx context.Context, agent *AgentInstance, name string) (context.Context, context.CancelFunc) {
+	if tool, ok := agent.Tools.Get(name); ok {
+		if _, async := tool.(tools.AsyncTool); async {
+			return context.WithoutCancel(ctx), func() {}
+		}
+	}
+	return withTimeout(ctx, al.cfg.Timeouts.ToolTimeout(name))
+}
@@ -0,0 +1,161 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/constants"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/privacy"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// topicWindow is how many recent messages the topic check reads.
+const topicWindow = 20
+
+const topicPrompt = `You keep notes on a chat between a user and their assistant.
+
+Current topic: %s
+
+%s
+Label what the user's latest message is about in a few words, say whether it moves on to a different subject than the current topic, and if it does, summarize what was said and decided about the current topic in 1-3 sentences, keeping names, numbers and decisions. Answer exactly in this form:
+TOPIC: <label>
+NEW: yes or no
+SUMMARY: <summary, or empty>`
+
+// maybeCheckpointTopic runs checkpointTopic in the background when topic
+// checkpoints are on, one at a time per session.
+func (al *AgentLoop) maybeCheckpointTopic(agent *AgentInstance, sessionKey, channel string) {
+	if !al.cfg.Agents.Defaults.TopicCheckpoints || constants.IsInternalChannel(channel) {
+		return
+	}
+	key := agent.ID + ":" + sessionKey
+	if _, running := al.topicChecks.LoadOrStore(key, true); running {
+		return
+	}
+	// Snapshot now: summarization may truncate the session meanwhile
+	history := agent.Sessions.GetHistory(sessionKey)
+	go func() {
+		defer al.topicChecks.Delete(key)
+		al.checkpointTopic(agent, sessionKey, channel, history)
+	}()
+}
+
+// checkpointTopic asks the model whether the turn just finished moved the
+// conversation to a new topic, and if so writes a short summary of the
+// topic it left to today's daily note. Summarization and pruning drop old
+// messages; this way what mattered in them is remembered first. history is
+// the session as of the end of the turn.
+func (al *AgentLoop) checkpointTopic(agent *AgentInstance, sessionKey, channel string, history []providers.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	current := agent.Sessions.GetTopic(sessionKey)
+	transcript := topicTranscript(agent.Sessions.GetSummary(sessionKey), history)
+	if transcript == "" {
+		return
+	}
+	label := current
+	if label == "" {
+		label = "(none yet)"
+	}
+	prompt := fmt.Sprintf(topicPrompt, label, transcript)
+
+	provider, model := agent.CurrentModel()
+	resp, err := provider.Chat(ctx, []providers.Message{{Role: "user", Content: prompt}}, nil, model, map[string]interface{}{
+		"max_tokens":  300,
+		"temperature": 0.2,
+	})
+	if err != nil {
+		logger.WarnCF("agent", "Topic check failed", map[string]interface{}{
+			"session_key": sessionKey,
+			"error":       err.Error(),
+		})
+		return
+	}
+
+	topic, changed, summary := parseTopicReply(resp.Content)
+	if topic == "" {
+		return
+	}
+	summary = privacy.LoadTombstones(agent.Workspace).Redact(summary)
+	if changed && current != "" && summary != "" {
+		note := fmt.Sprintf("## %s (%s, %s)\n\n%s\n", current, channel, time.Now().Format("15:04"), summary)
+		if err := NewMemoryStore(agent.Workspace).AppendToday(note); err != nil {
+			logger.WarnCF("agent", "Failed to checkpoint topic", map[string]interface{}{
+				"session_key": sessionKey,
+				"error":       err.Error(),
+			})
+			return
+		}
+		logger.InfoCF("agent", "Checkpointed topic to daily note", map[string]interface{}{
+			"session_key": sessionKey,
+			"topic":       current,
+			"next_topic":  topic,
+		})
+	}
+	if changed || current == "" {
+		agent.Sessions.SetTopic(sessionKey, topic)
+		agent.Sessions.Save(sessionKey)
+	}
+}
+
+// topicTranscript renders the conversation the topic check reads: the
+// session summary, then the latest user and assistant messages.
+func topicTranscript(summary string, history []providers.Message) string {
+	var lines []string
+	for i := len(history) - 1; i >= 0 && len(lines) < topicWindow; i-- {
+		m := history[i]
+		if (m.Role != "user" && m.Role != "assistant") || strings.TrimSpace(m.Content) == "" {
+			continue
+		}
+		lines = append(lines, m.Role+": "+utils.Truncate(m.Content, 1000))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	if summary != "" {
+		sb.WriteString("Earlier in the conversation: ")
+		sb.WriteString(summary)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("Conversation, oldest first:\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		sb.WriteString(lines[i])
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// parseTopicReply reads the model's answer to topicPrompt. The summary
+// runs to the end of the reply.
+func parseTopicReply(reply string) (topic string, changed bool, summary string) {
+	lines := strings.Split(reply, "\n")
+	for i, line := range lines {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(strings.TrimLeft(value, "*"))
+		switch strings.ToUpper(strings.Trim(key, "*# ")) {
+		case "TOPIC":
+			topic = value
+		case "NEW":
+			changed = strings.HasPrefix(strings.ToLower(value), "yes")
+		case "SUMMARY":
+			rest := append([]string{value}, lines[i+1:]...)
+			return topic, changed, strings.TrimSpace(strings.Join(rest, "\n"))
+		}
+	}
+	return topic, changed, summary
+}
@@ -0,0 +1,93 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// wrapUpPrompt asks for the best answer so far once a turn hit a limit.
+const wrapUpPrompt = "[System: This turn has reached %s. Don't call any more tools. " +
+	"Reply now with your best answer from what you have found so far, and say briefly what is still missing.]"
+
+// wrapUpGrace is how long the last call of a turn that ran out of time
+// may take.
+const wrapUpGrace = 30 * time.Second
+
+// turnBudget tracks one turn against the configured turn limits.
+type turnBudget struct {
+	cfg     config.TurnLimitsConfig
+	start   time.Time
+	calls   int
+	cost    float64
+	lastOut int // completion tokens of the last call
+}
+
+func newTurnBudget(cfg config.TurnLimitsConfig) *turnBudget {
+	return &turnBudget{cfg: cfg, start: time.Now()}
+}
+
+// context bounds the turn's provider calls and tools by its time limit.
+func (b *turnBudget) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b.cfg.MaxSeconds <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, b.start.Add(time.Duration(b.cfg.MaxSeconds)*time.Second))
+}
+
+// record adds one provider call. Without reported usage, tokens are
+// estimated from the request and the reply.
+func (b *turnBudget) record(usage *providers.UsageInfo, messages []providers.Message, reply string) {
+	b.calls++
+	var in, out int
+	if usage != nil && (usage.PromptTokens > 0 || usage.CompletionTokens > 0) {
+		in, out = usage.PromptTokens, usage.CompletionTokens
+	} else {
+		in, out = estimatePromptTokens(messages), len([]rune(reply))*2/5
+	}
+	b.lastOut = out
+	b.cost += b.price(in, out)
+}
+
+func (b *turnBudget) price(in, out int) float64 {
+	return (float64(in)*b.cfg.InputPrice + float64(out)*b.cfg.OutputPrice) / 1e6
+}
+
+// estimatePromptTokens guesses the prompt size of messages at 2.5
+// characters per token.
+func estimatePromptTokens(messages []providers.Message) int {
+	chars := 0
+	for _, m := range messages {
+		chars += len([]rune(m.Content))
+	}
+	return chars * 2 / 5
+}
+
+// exceeded names the limit the turn has reached, or returns "". The cost
+// limit counts the next call with messages too, guessing it replies at
+// the length of the last one, so the call that would cross the limit is
+// the wrap-up instead.
+func (b *turnBudget) exceeded(messages []providers.Message) string {
+	switch {
+	case b.cfg.MaxSeconds > 0 && time.Since(b.start) >= time.Duration(b.cfg.MaxSeconds)*time.Second:
+		return fmt.Sprintf("its time limit of %s", time.Duration(b.cfg.MaxSeconds)*time.Second)
+	case b.cfg.MaxCalls > 0 && b.calls >= b.cfg.MaxCalls:
+		return fmt.Sprintf("its limit of %d model calls", b.cfg.MaxCalls)
+	case b.cfg.MaxCostUSD > 0 && b.cost+b.price(estimatePromptTokens(messages), b.lastOut) >= b.cfg.MaxCostUSD:
+		return fmt.Sprintf("its cost limit of $%.2f", b.cfg.MaxCostUSD)
+	}
+	return ""
+}
+
+// wrapUpNote is appended to an answer cut short by a limit.
+func wrapUpNote(reason string) string {
+	return fmt.Sprintf("\n\n_(Stopped early: this request reached %s.)_", reason)
+}
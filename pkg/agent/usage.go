@@ -0,0 +1,85 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/kv"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// usageTTL is how long daily usage counters are kept.
+const usageTTL = 90 * 24 * time.Hour
+
+// usageCounters are the daily counters, in the order !status shows them.
+var usageCounters = []string{"requests", "prompt_tokens", "completion_tokens"}
+
+// SetStore keeps daily usage counters in store; !status shows today's.
+func (al *AgentLoop) SetStore(store kv.Store) {
+	al.store = store
+}
+
+func usageKey(day time.Time, counter string) string {
+	return "usage:" + day.Format("2006-01-02") + ":" + counter
+}
+
+// recordUsage counts a model call in today's counters, and in the
+// tenant's own when agent serves one.
+func (al *AgentLoop) recordUsage(agent *AgentInstance, usage *providers.UsageInfo) {
+	if al.store == nil {
+		return
+	}
+	var u providers.UsageInfo
+	if usage != nil {
+		u = *usage
+	}
+	now := time.Now()
+	for i, n := range []int{1, u.PromptTokens, u.CompletionTokens} {
+		keys := []string{usageKey(now, usageCounters[i])}
+		if agent != nil && agent.Tenant != nil {
+			keys = append(keys, tenantUsageKey(agent.ID, now, usageCounters[i]))
+		}
+		for _, key := range keys {
+			if _, err := al.store.Incr(key, int64(n), usageTTL); err != nil {
+				logger.WarnCF("agent", "Failed to count usage", map[string]interface{}{
+					"error": err.Error(),
+				})
+				return
+			}
+		}
+	}
+}
+
+// usageOn returns the counters of a day, or nil without a store.
+func (al *AgentLoop) usageOn(day time.Time) []int64 {
+	if al.store == nil {
+		return nil
+	}
+	counts := make([]int64, len(usageCounters))
+	for i, counter := range usageCounters {
+		data, ok, err := al.store.Get(usageKey(day, counter))
+		if err != nil {
+			return nil
+		}
+		if ok {
+			counts[i], _ = strconv.ParseInt(string(data), 10, 64)
+		}
+	}
+	return counts
+}
+
+// UsageToday returns today's request and token counts, zero without a
+// store.
+func (al *AgentLoop) UsageToday() (requests, promptTokens, completionTokens int64) {
+	u := al.usageOn(time.Now())
+	if len(u) != len(usageCounters) {
+		return 0, 0, 0
+	}
+	return u[0], u[1], u[2]
+}
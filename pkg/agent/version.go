@@ -0,0 +1,47 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/version"
+)
+
+// isVersionCommand reports whether content is a !version command.
+func isVersionCommand(content string) bool {
+	fields := strings.Fields(content)
+	return len(fields) == 1 && strings.EqualFold(fields[0], "!version")
+}
+
+// versionReply describes the running build, and a newer release if the
+// last update check found one. It doesn't check again: that happens when
+// the gateway starts.
+func (al *AgentLoop) versionReply() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "picoclaw %s\n", version.String())
+	if version.BuildTime != "" {
+		fmt.Fprintf(&sb, "Built: %s\n", version.BuildTime)
+	}
+	fmt.Fprintf(&sb, "Go: %s\n", version.Go())
+
+	channel := al.cfg.Updates.Channel
+	if channel == "" || channel == version.ChannelOff {
+		sb.WriteString("Update checks: off")
+		return sb.String()
+	}
+	check := version.LastCheck(al.cfg.WorkspacePath())
+	switch {
+	case check.Available():
+		fmt.Fprintf(&sb, "Update available on the %s channel: %s %s", check.Channel, check.Latest, check.URL)
+	case check != nil:
+		fmt.Fprintf(&sb, "Up to date on the %s channel (checked %s)", check.Channel, check.CheckedAt.Format("2006-01-02 15:04"))
+	default:
+		fmt.Fprintf(&sb, "Release channel: %s (not checked yet)", channel)
+	}
+	return sb.String()
+}
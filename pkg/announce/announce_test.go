@@ -0,0 +1,69 @@
+package announce
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+func TestStore_SubscribeUnsubscribe(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	if added, err := store.Subscribe("telegram", "123", "123"); err != nil || !added {
+		t.Fatalf("Subscribe = %v, %v", added, err)
+	}
+	if added, _ := store.Subscribe("telegram", "123", "123"); added {
+		t.Error("duplicate subscribe reported as added")
+	}
+	if !store.IsSubscribed("telegram", "123") {
+		t.Error("expected subscribed")
+	}
+	if removed, _ := store.Unsubscribe("telegram", "123"); !removed {
+		t.Error("expected unsubscribe to remove")
+	}
+	if store.IsSubscribed("telegram", "123") {
+		t.Error("still subscribed after unsubscribe")
+	}
+}
+
+func TestService_DeliverPending(t *testing.T) {
+	ws := t.TempDir()
+	store := NewStore(ws)
+	store.Subscribe("telegram", "1", "1")
+	store.Subscribe("discord", "2", "2")
+
+	// A second store on the same workspace (e.g. the CLI) queues the message
+	if _, err := NewStore(ws).Queue("Maintenance tonight at 22:00"); err != nil {
+		t.Fatalf("Queue: %v", err)
+	}
+
+	msgBus := bus.NewMessageBus()
+	svc := NewService(store, msgBus)
+	if n := svc.DeliverPending(); n != 1 {
+		t.Fatalf("DeliverPending = %d, want 1", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		msg, ok := msgBus.SubscribeOutbound(ctx)
+		if !ok {
+			t.Fatalf("expected outbound message %d", i)
+		}
+		if msg.Content != "📢 Maintenance tonight at 22:00" {
+			t.Errorf("content = %q", msg.Content)
+		}
+	}
+
+	if len(store.Pending()) != 0 {
+		t.Error("announcement still pending after delivery")
+	}
+	if got := store.List()[0].Recipients; got != 2 {
+		t.Errorf("recipients = %d, want 2", got)
+	}
+	if n := svc.DeliverPending(); n != 0 {
+		t.Errorf("second DeliverPending = %d, want 0", n)
+	}
+}
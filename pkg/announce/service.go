@@ -0,0 +1,102 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package announce
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const pollInterval = 30 * time.Second
+
+// Service delivers queued announcements to all subscribers.
+type Service struct {
+	store    *Store
+	bus      *bus.MessageBus
+	mu       sync.Mutex
+	stopChan chan struct{}
+}
+
+// NewService creates a delivery service for a store.
+func NewService(store *Store, msgBus *bus.MessageBus) *Service {
+	return &Service{
+		store: store,
+		bus:   msgBus,
+	}
+}
+
+// Start begins polling the store for pending announcements.
+func (s *Service) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopChan != nil {
+		return nil
+	}
+	s.stopChan = make(chan struct{})
+	go s.runLoop(s.stopChan)
+	return nil
+}
+
+// Stop stops the delivery loop.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopChan == nil {
+		return
+	}
+	close(s.stopChan)
+	s.stopChan = nil
+}
+
+func (s *Service) runLoop(stopChan chan struct{}) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			s.DeliverPending()
+		}
+	}
+}
+
+// DeliverPending sends every pending announcement to every subscriber and
+// returns the number of announcements delivered.
+func (s *Service) DeliverPending() int {
+	pending := s.store.Pending()
+	if len(pending) == 0 {
+		return 0
+	}
+
+	subscribers := s.store.Subscribers()
+	for _, a := range pending {
+		for _, sub := range subscribers {
+			s.bus.PublishOutbound(bus.OutboundMessage{
+				Channel: sub.Channel,
+				ChatID:  sub.ChatID,
+				Content: "📢 " + a.Content,
+			})
+		}
+		if err := s.store.MarkDelivered(a.ID, len(subscribers)); err != nil {
+			logger.ErrorCF("announce", "Failed to mark announcement delivered", map[string]interface{}{
+				"id":    a.ID,
+				"error": err.Error(),
+			})
+		}
+		logger.InfoCF("announce", "Announcement delivered", map[string]interface{}{
+			"id":         a.ID,
+			"recipients": len(subscribers),
+		})
+	}
+	return len(pending)
+}
@@ -0,0 +1,191 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package announce lets the owner broadcast a message (e.g. a downtime
+// notice) to every chat that opted in to announcements.
+package announce
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// Subscriber is a chat that opted in to announcements.
+type Subscriber struct {
+	Channel  string    `json:"channel"`
+	ChatID   string    `json:"chat_id"`
+	SenderID string    `json:"sender_id,omitempty"`
+	Since    time.Time `json:"since"`
+}
+
+// Announcement is a queued or delivered broadcast.
+type Announcement struct {
+	ID          string     `json:"id"`
+	Content     string     `json:"content"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	Recipients  int        `json:"recipients,omitempty"`
+}
+
+type storeData struct {
+	Subscribers   []Subscriber   `json:"subscribers"`
+	Announcements []Announcement `json:"announcements"`
+}
+
+// Store persists subscribers and announcements in
+// workspace/state/announcements.json. The file is re-read on every
+// operation so the CLI and a running gateway can share it.
+type Store struct {
+	path string
+	mu   *sync.Mutex // shared with the delivery service
+}
+
+// NewStore creates an announcement store for a workspace.
+func NewStore(workspace string) *Store {
+	path := filepath.Join(workspace, "state", "announcements.json")
+	return &Store{path: path, mu: utils.FileLock(path)}
+}
+
+// Subscribe opts a chat in. It returns false if the chat was already subscribed.
+func (s *Store) Subscribe(channel, chatID, senderID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := s.load()
+	for _, sub := range data.Subscribers {
+		if sub.Channel == channel && sub.ChatID == chatID {
+			return false, nil
+		}
+	}
+	data.Subscribers = append(data.Subscribers, Subscriber{
+		Channel:  channel,
+		ChatID:   chatID,
+		SenderID: senderID,
+		Since:    time.Now(),
+	})
+	return true, s.save(data)
+}
+
+// Unsubscribe opts a chat out. It returns false if the chat was not subscribed.
+func (s *Store) Unsubscribe(channel, chatID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := s.load()
+	kept := data.Subscribers[:0]
+	found := false
+	for _, sub := range data.Subscribers {
+		if sub.Channel == channel && sub.ChatID == chatID {
+			found = true
+			continue
+		}
+		kept = append(kept, sub)
+	}
+	if !found {
+		return false, nil
+	}
+	data.Subscribers = kept
+	return true, s.save(data)
+}
+
+// IsSubscribed reports whether a chat has opted in.
+func (s *Store) IsSubscribed(channel, chatID string) bool {
+	for _, sub := range s.Subscribers() {
+		if sub.Channel == channel && sub.ChatID == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribers returns all opted-in chats.
+func (s *Store) Subscribers() []Subscriber {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load().Subscribers
+}
+
+// Queue adds an announcement for delivery.
+func (s *Store) Queue(content string) (*Announcement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	a := Announcement{
+		ID:        fmt.Sprintf("%d", now.UnixNano()),
+		Content:   content,
+		CreatedAt: now,
+	}
+	data := s.load()
+	data.Announcements = append(data.Announcements, a)
+	if err := s.save(data); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// List returns all announcements, oldest first.
+func (s *Store) List() []Announcement {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load().Announcements
+}
+
+// Pending returns announcements that have not been delivered yet.
+func (s *Store) Pending() []Announcement {
+	var pending []Announcement
+	for _, a := range s.List() {
+		if a.DeliveredAt == nil {
+			pending = append(pending, a)
+		}
+	}
+	return pending
+}
+
+// MarkDelivered records that an announcement was sent to n recipients.
+func (s *Store) MarkDelivered(id string, n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := s.load()
+	for i := range data.Announcements {
+		if data.Announcements[i].ID == id {
+			now := time.Now()
+			data.Announcements[i].DeliveredAt = &now
+			data.Announcements[i].Recipients = n
+			return s.save(data)
+		}
+	}
+	return fmt.Errorf("announcement %s not found", id)
+}
+
+func (s *Store) load() storeData {
+	var data storeData
+	if raw, err := os.ReadFile(s.path); err == nil {
+		json.Unmarshal(raw, &data)
+	}
+	return data
+}
+
+func (s *Store) save(data storeData) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
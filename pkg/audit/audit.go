@@ -0,0 +1,223 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package audit keeps an append-only log of conversation turns and the
+// feedback users gave on them, one JSONL file per day under
+// workspace/audit. Old files are pruned by the retention service.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	KindTurn     = "turn"
+	KindFeedback = "feedback"
+
+	RatingUp   = "up"
+	RatingDown = "down"
+
+	dayFormat = "2006-01-02"
+)
+
+// Entry is one line of the audit log.
+type Entry struct {
+	Kind        string    `json:"kind"`
+	Time        time.Time `json:"time"`
+	TurnID      string    `json:"turn_id"`
+	AgentID     string    `json:"agent_id,omitempty"`
+	SessionKey  string    `json:"session_key,omitempty"`
+	Channel     string    `json:"channel,omitempty"`
+	ChatID      string    `json:"chat_id,omitempty"`
+	SenderID    string    `json:"sender_id,omitempty"`
+	Model       string    `json:"model,omitempty"`
+	UserMessage string    `json:"user_message,omitempty"`
+	Response    string    `json:"response,omitempty"`
+	ToolCalls   []string  `json:"tool_calls,omitempty"`
+	Iterations  int       `json:"iterations,omitempty"`
+	Stopped     string    `json:"stopped,omitempty"` // why the turn ended early, e.g. a tool loop
+	Error       string    `json:"error,omitempty"`
+	Rating      string    `json:"rating,omitempty"`
+	Comment     string    `json:"comment,omitempty"`
+}
+
+// Log appends entries to workspace/audit/YYYY-MM-DD.jsonl.
+type Log struct {
+	dir string
+
+	mu       sync.Mutex
+	lastTurn map[string]Entry // "channel:chatID" -> most recent turn
+}
+
+// NewLog creates an audit log for a workspace.
+func NewLog(workspace string) *Log {
+	return &Log{
+		dir:      filepath.Join(workspace, "audit"),
+		lastTurn: make(map[string]Entry),
+	}
+}
+
+// Dir returns the directory holding the daily log files.
+func (l *Log) Dir() string {
+	return l.dir
+}
+
+// NewTurnID returns a unique ID for a turn.
+func NewTurnID() string {
+	return fmt.Sprintf("t%d", time.Now().UnixNano())
+}
+
+// RecordTurn appends a completed turn and remembers it as the latest turn
+// of its chat, so feedback can be linked to it.
+func (l *Log) RecordTurn(e Entry) error {
+	e.Kind = KindTurn
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.TurnID == "" {
+		e.TurnID = NewTurnID()
+	}
+
+	l.mu.Lock()
+	l.lastTurn[e.Channel+":"+e.ChatID] = e
+	l.mu.Unlock()
+
+	return l.append(e)
+}
+
+// LastTurn returns the most recent turn recorded for a chat by this process.
+func (l *Log) LastTurn(channel, chatID string) (Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.lastTurn[channel+":"+chatID]
+	return e, ok
+}
+
+// RecordFeedback appends a rating (and optional comment) for a turn.
+func (l *Log) RecordFeedback(turn Entry, senderID, rating, comment string) error {
+	return l.append(Entry{
+		Kind:       KindFeedback,
+		Time:       time.Now(),
+		TurnID:     turn.TurnID,
+		AgentID:    turn.AgentID,
+		SessionKey: turn.SessionKey,
+		Channel:    turn.Channel,
+		ChatID:     turn.ChatID,
+		SenderID:   senderID,
+		Rating:     rating,
+		Comment:    comment,
+	})
+}
+
+func (l *Log) append(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(l.dir, e.Time.Format(dayFormat)+".jsonl")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line, err := jsonLine(e)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(line)
+	return err
+}
+
+func jsonLine(e Entry) ([]byte, error) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// ReadDay returns all entries logged on the given day.
+func (l *Log) ReadDay(day time.Time) ([]Entry, error) {
+	return readFile(filepath.Join(l.dir, day.Format(dayFormat)+".jsonl"))
+}
+
+// ReadSince returns all entries logged at or after since, oldest first.
+func (l *Log) ReadSince(since time.Time) ([]Entry, error) {
+	files, err := l.files()
+	if err != nil {
+		return nil, err
+	}
+
+	sinceDay := since.Format(dayFormat)
+	var entries []Entry
+	for _, name := range files {
+		if strings.TrimSuffix(name, ".jsonl") < sinceDay {
+			continue
+		}
+		dayEntries, err := readFile(filepath.Join(l.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range dayEntries {
+			if !e.Time.Before(since) {
+				entries = append(entries, e)
+			}
+		}
+	}
+	return entries, nil
+}
+
+func (l *Log) files() ([]string, error) {
+	dirEntries, err := os.ReadDir(l.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []string
+	for _, de := range dirEntries {
+		if !de.IsDir() && strings.HasSuffix(de.Name(), ".jsonl") {
+			files = append(files, de.Name())
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func readFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
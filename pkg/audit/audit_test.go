@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLog_FeedbackExamples(t *testing.T) {
+	log := NewLog(t.TempDir())
+
+	if err := log.RecordTurn(Entry{Channel: "telegram", ChatID: "1", SenderID: "42", UserMessage: "weather?", Response: "sunny"}); err != nil {
+		t.Fatalf("RecordTurn: %v", err)
+	}
+	turn, ok := log.LastTurn("telegram", "1")
+	if !ok || turn.TurnID == "" {
+		t.Fatalf("LastTurn = %+v, %v", turn, ok)
+	}
+
+	log.RecordFeedback(turn, "42", RatingDown, "")
+	log.RecordFeedback(turn, "42", "", "it was raining")
+
+	entries, err := log.ReadSince(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("ReadSince: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+
+	examples := FeedbackExamples(entries)
+	if len(examples) != 1 {
+		t.Fatalf("got %d examples, want 1", len(examples))
+	}
+	ex := examples[0]
+	if ex.Rating != RatingDown || ex.Comment != "it was raining" || ex.UserMessage != "weather?" || ex.Response != "sunny" {
+		t.Errorf("unexpected example: %+v", ex)
+	}
+}
+
+func TestLog_Purge(t *testing.T) {
+	log := NewLog(t.TempDir())
+	log.RecordTurn(Entry{Channel: "telegram", ChatID: "1", SenderID: "42|alice", SessionKey: "agent:main:telegram:direct:42"})
+	log.RecordTurn(Entry{Channel: "telegram", ChatID: "2", SenderID: "7", SessionKey: "agent:main:telegram:direct:7"})
+
+	n, err := log.Purge("42", true)
+	if err != nil || n != 1 {
+		t.Fatalf("dry-run Purge = %d, %v; want 1", n, err)
+	}
+	if entries, _ := log.ReadDay(time.Now()); len(entries) != 2 {
+		t.Fatalf("dry run removed entries")
+	}
+
+	if n, _ := log.Purge("42", false); n != 1 {
+		t.Fatalf("Purge = %d, want 1", n)
+	}
+	entries, _ := log.ReadDay(time.Now())
+	if len(entries) != 1 || entries[0].SenderID != "7" {
+		t.Errorf("remaining entries = %+v", entries)
+	}
+	if _, ok := log.LastTurn("telegram", "1"); ok {
+		t.Error("purged user's last turn still cached")
+	}
+}
@@ -0,0 +1,165 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FeedbackExample joins a feedback entry with the turn it rates. A list of
+// these is the exportable dataset for prompt and skill improvement.
+type FeedbackExample struct {
+	TurnID      string    `json:"turn_id"`
+	Time        time.Time `json:"time"`
+	Rating      string    `json:"rating"`
+	Comment     string    `json:"comment,omitempty"`
+	AgentID     string    `json:"agent_id,omitempty"`
+	Model       string    `json:"model,omitempty"`
+	UserMessage string    `json:"user_message"`
+	Response    string    `json:"response"`
+	ToolCalls   []string  `json:"tool_calls,omitempty"`
+}
+
+// FeedbackExamples pairs each feedback entry with its turn. When a turn was
+// rated more than once the latest rating wins, and a later comment-only
+// follow-up is merged into it.
+func FeedbackExamples(entries []Entry) []FeedbackExample {
+	turns := make(map[string]Entry)
+	for _, e := range entries {
+		if e.Kind == KindTurn {
+			turns[e.TurnID] = e
+		}
+	}
+
+	index := make(map[string]int)
+	var examples []FeedbackExample
+	for _, e := range entries {
+		if e.Kind != KindFeedback {
+			continue
+		}
+		turn, ok := turns[e.TurnID]
+		if !ok {
+			continue
+		}
+
+		if i, seen := index[e.TurnID]; seen {
+			if e.Rating != "" {
+				examples[i].Rating = e.Rating
+			}
+			if e.Comment != "" {
+				examples[i].Comment = e.Comment
+			}
+			examples[i].Time = e.Time
+			continue
+		}
+
+		index[e.TurnID] = len(examples)
+		examples = append(examples, FeedbackExample{
+			TurnID:      e.TurnID,
+			Time:        e.Time,
+			Rating:      e.Rating,
+			Comment:     e.Comment,
+			AgentID:     turn.AgentID,
+			Model:       turn.Model,
+			UserMessage: turn.UserMessage,
+			Response:    turn.Response,
+			ToolCalls:   turn.ToolCalls,
+		})
+	}
+	return examples
+}
+
+// Name implements privacy.Store.
+func (l *Log) Name() string {
+	return "audit log"
+}
+
+// Purge implements privacy.Store by dropping every entry sent by userID or
+// belonging to one of the user's sessions.
+func (l *Log) Purge(userID string, dryRun bool) (int, error) {
+	files, err := l.files()
+	if err != nil {
+		return 0, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	total := 0
+	for _, name := range files {
+		path := filepath.Join(l.dir, name)
+		entries, err := readFile(path)
+		if err != nil {
+			return total, err
+		}
+
+		kept := make([]Entry, 0, len(entries))
+		for _, e := range entries {
+			if entryBelongsTo(e, userID) {
+				total++
+				continue
+			}
+			kept = append(kept, e)
+		}
+		if dryRun || len(kept) == len(entries) {
+			continue
+		}
+		if err := rewriteFile(path, kept); err != nil {
+			return total, err
+		}
+	}
+
+	if !dryRun {
+		for key, e := range l.lastTurn {
+			if entryBelongsTo(e, userID) {
+				delete(l.lastTurn, key)
+			}
+		}
+	}
+	return total, nil
+}
+
+func entryBelongsTo(e Entry, userID string) bool {
+	if e.SenderID == userID || strings.HasPrefix(e.SenderID, userID+"|") {
+		return true
+	}
+	for _, part := range strings.Split(e.SessionKey, ":") {
+		if part == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func rewriteFile(path string, entries []Entry) error {
+	if len(entries) == 0 {
+		return os.Remove(path)
+	}
+
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		line, err := jsonLine(e)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := f.Write(line); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
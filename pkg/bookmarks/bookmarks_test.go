@@ -0,0 +1,84 @@
+package bookmarks
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+func TestStore_AddListMarkRead(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	first, added, err := store.Add(Bookmark{URL: "https://a.example", Tags: []string{"go"}, Channel: "telegram", ChatID: "1"})
+	if err != nil || !added || first.ID != 1 {
+		t.Fatalf("Add = %+v, %v, %v", first, added, err)
+	}
+	if dup, added, _ := store.Add(Bookmark{URL: "https://a.example", Channel: "telegram", ChatID: "1"}); added || dup.ID != 1 {
+		t.Errorf("duplicate URL added as %+v", dup)
+	}
+	store.Add(Bookmark{URL: "https://a.example", Channel: "discord", ChatID: "2"})
+	store.Add(Bookmark{URL: "https://b.example", Tags: []string{"Rust"}, Channel: "telegram", ChatID: "1"})
+
+	list := store.List(Filter{Channel: "telegram", ChatID: "1"})
+	if len(list) != 2 || list[0].URL != "https://b.example" {
+		t.Fatalf("List = %+v", list)
+	}
+	if got := store.List(Filter{Tag: "rust"}); len(got) != 1 {
+		t.Errorf("tag filter returned %d bookmarks", len(got))
+	}
+
+	if err := store.MarkRead("discord", "2", 1); err == nil {
+		t.Error("marking another chat's bookmark should fail")
+	}
+	if err := store.MarkRead("telegram", "1", 1); err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+	if got := store.List(Filter{Channel: "telegram", ChatID: "1", UnreadOnly: true}); len(got) != 1 || got[0].ID == 1 {
+		t.Errorf("unread = %+v", got)
+	}
+}
+
+func TestDigestService_SendDigest(t *testing.T) {
+	ws := t.TempDir()
+	store := NewStore(ws)
+	store.Add(Bookmark{URL: "https://a.example", Title: "A", Summary: "About A.", Channel: "telegram", ChatID: "1"})
+	store.Add(Bookmark{URL: "https://b.example", Channel: "telegram", ChatID: "1"})
+	store.Add(Bookmark{URL: "https://c.example", Channel: "cli", ChatID: "direct"})
+	read, _, _ := store.Add(Bookmark{URL: "https://d.example", Channel: "discord", ChatID: "2"})
+	store.MarkRead("discord", "2", read.ID)
+
+	msgBus := bus.NewMessageBus()
+	svc := NewDigestService(store, ws, "Sun", 18, msgBus)
+
+	// The scheduled run, then a second gateway's run the same week
+	sunday := time.Date(2026, 10, 18, 18, 0, 0, 0, time.Local)
+	svc.send(sunday)
+	svc.send(sunday.Add(time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, ok := msgBus.SubscribeOutbound(ctx)
+	if !ok || msg.Channel != "telegram" || msg.ChatID != "1" {
+		t.Fatalf("digest = %+v, %v", msg, ok)
+	}
+	if !strings.Contains(msg.Content, "2 unread") || !strings.Contains(msg.Content, "#1 A\nhttps://a.example\nAbout A.") {
+		t.Errorf("digest content = %q", msg.Content)
+	}
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel2()
+	if extra, ok := msgBus.SubscribeOutbound(ctx2); ok {
+		t.Errorf("unexpected second digest: %+v", extra)
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	for in, want := range map[string]time.Weekday{"monday": time.Monday, " Fri ": time.Friday, "": time.Sunday, "someday": time.Sunday} {
+		if got := ParseWeekday(in); got != want {
+			t.Errorf("ParseWeekday(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package bookmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/constants"
+	"github.com/sipeed/picoclaw/pkg/cron"
+	"github.com/sipeed/picoclaw/pkg/kv"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/templates"
+)
+
+// digestMaxItems caps how many bookmarks one digest lists per chat.
+const digestMaxItems = 10
+
+// DigestService sends each chat a weekly list of its unread bookmarks, on
+// a configured weekday and local hour, as a job of the cron service. The
+// week of the last digest is kept in workspace/state/bookmarks_digest.json,
+// or the kv store, so gateways sharing the store send it once.
+type DigestService struct {
+	store     *Store
+	workspace string
+	weekday   time.Weekday
+	hour      int
+	bus       *bus.MessageBus
+	state     kv.Store
+	stateKey  string
+}
+
+// SetStore keeps the week of the last digest in store rather than in
+// workspace/state/bookmarks_digest.json. namespace tells apart the
+// services of several workspaces sharing one store; the default
+// workspace's service passes "".
+func (s *DigestService) SetStore(store kv.Store, namespace string) {
+	s.state = store
+	s.stateKey = digestStateKey
+	if namespace != "" {
+		s.stateKey = "scheduler:bookmarks_digest:" + namespace + ":last_week"
+	}
+}
+
+// NewDigestService creates a weekly digest service. day is a weekday name
+// such as "sunday"; an unknown name falls back to Sunday.
+func NewDigestService(store *Store, workspace, day string, hour int, msgBus *bus.MessageBus) *DigestService {
+	if hour < 0 || hour > 23 {
+		hour = 18
+	}
+	return &DigestService{
+		store:     store,
+		workspace: workspace,
+		weekday:   ParseWeekday(day),
+		hour:      hour,
+		bus:       msgBus,
+	}
+}
+
+// ParseWeekday parses a weekday name or its three-letter abbreviation,
+// defaulting to Sunday.
+func ParseWeekday(day string) time.Weekday {
+	day = strings.ToLower(strings.TrimSpace(day))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if day == name || day == name[:3] {
+			return d
+		}
+	}
+	return time.Sunday
+}
+
+// Schedule registers the weekly digest with the cron service.
+func (s *DigestService) Schedule(cs *cron.CronService) error {
+	expr := fmt.Sprintf("0 %d * * %d", s.hour, s.weekday)
+	if err := cs.Schedule("bookmark digest "+s.workspace, expr, s.send); err != nil {
+		return err
+	}
+	logger.InfoCF("bookmarks", "Weekly bookmark digest scheduled", map[string]interface{}{
+		"weekday": s.weekday.String(),
+		"hour":    s.hour,
+	})
+	return nil
+}
+
+// send sends the digest unless this week's went out already.
+func (s *DigestService) send(now time.Time) {
+	year, week := now.ISOWeek()
+	thisWeek := fmt.Sprintf("%d-W%02d", year, week)
+	if s.lastWeek() == thisWeek {
+		return
+	}
+	s.saveLastWeek(thisWeek)
+
+	n := s.SendDigest()
+	logger.InfoCF("bookmarks", "Sent weekly bookmark digest", map[string]interface{}{
+		"chats": n,
+	})
+}
+
+// SendDigest sends the unread-bookmark digest to every chat that has
+// unread bookmarks and returns the number of chats it was sent to.
+func (s *DigestService) SendDigest() int {
+	if s.bus == nil {
+		return 0
+	}
+
+	type chatKey struct{ channel, chatID string }
+	var order []chatKey
+	unread := make(map[chatKey][]Bookmark)
+	for _, b := range s.store.List(Filter{UnreadOnly: true}) {
+		if constants.IsInternalChannel(b.Channel) {
+			continue
+		}
+		key := chatKey{b.Channel, b.ChatID}
+		if _, seen := unread[key]; !seen {
+			order = append(order, key)
+		}
+		unread[key] = append(unread[key], b)
+	}
+
+	for _, key := range order {
+		s.bus.PublishOutbound(bus.OutboundMessage{
+			Channel: key.channel,
+			ChatID:  key.chatID,
+			Content: templates.Render(s.workspace, templates.Message{
+				Kind:    templates.KindBookmarkDigest,
+				Channel: key.channel,
+				ChatID:  key.chatID,
+				Content: FormatDigest(unread[key]),
+				Data:    unread[key],
+			}),
+			Proactive: bus.ProactiveDigest,
+		})
+	}
+	return len(order)
+}
+
+// FormatDigest renders the weekly digest for one chat's unread bookmarks,
+// oldest first since those have waited longest.
+func FormatDigest(unread []Bookmark) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📚 Weekly reading list: %d unread bookmark(s)\n", len(unread))
+	for i := len(unread) - 1; i >= 0 && len(unread)-i <= digestMaxItems; i-- {
+		b := unread[i]
+		title := b.Title
+		if title == "" {
+			title = b.URL
+		}
+		fmt.Fprintf(&sb, "\n#%d %s\n%s\n", b.ID, title, b.URL)
+		if b.Summary != "" {
+			fmt.Fprintf(&sb, "%s\n", b.Summary)
+		}
+	}
+	if len(unread) > digestMaxItems {
+		fmt.Fprintf(&sb, "\n...and %d more.\n", len(unread)-digestMaxItems)
+	}
+	sb.WriteString("\nTell me which ones you've read and I'll mark them.")
+	return sb.String()
+}
+
+// digestStateKey holds the week of the last digest in a kv store.
+const digestStateKey = "scheduler:bookmarks_digest:last_week"
+
+type digestState struct {
+	LastWeek string `json:"last_week"`
+}
+
+func (s *DigestService) statePath() string {
+	return filepath.Join(s.workspace, "state", "bookmarks_digest.json")
+}
+
+func (s *DigestService) lastWeek() string {
+	if s.state != nil {
+		if data, ok, err := s.state.Get(s.stateKey); err == nil && ok {
+			return string(data)
+		}
+	}
+	data, err := os.ReadFile(s.statePath())
+	if err != nil {
+		return ""
+	}
+	var st digestState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return ""
+	}
+	return st.LastWeek
+}
+
+func (s *DigestService) saveLastWeek(week string) {
+	if s.state != nil {
+		if err := s.state.Set(s.stateKey, []byte(week), 0); err == nil {
+			os.Remove(s.statePath())
+			return
+		}
+	}
+	data, _ := json.Marshal(digestState{LastWeek: week})
+	os.MkdirAll(filepath.Dir(s.statePath()), 0755)
+	if err := os.WriteFile(s.statePath(), data, 0644); err != nil {
+		logger.WarnCF("bookmarks", "Failed to save digest state", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
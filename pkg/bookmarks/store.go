@@ -0,0 +1,168 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package bookmarks keeps a read-later list of links saved from chats,
+// each with a short summary and tags, and sends a weekly digest of the
+// ones still unread.
+package bookmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// Bookmark is a saved link. Channel and ChatID record where it was saved;
+// listing and the digest are scoped to that chat.
+type Bookmark struct {
+	ID      int        `json:"id"`
+	URL     string     `json:"url"`
+	Title   string     `json:"title,omitempty"`
+	Summary string     `json:"summary,omitempty"`
+	Tags    []string   `json:"tags,omitempty"`
+	Note    string     `json:"note,omitempty"`
+	Channel string     `json:"channel"`
+	ChatID  string     `json:"chat_id"`
+	AddedAt time.Time  `json:"added_at"`
+	ReadAt  *time.Time `json:"read_at,omitempty"`
+}
+
+// HasTag reports whether the bookmark carries tag, ignoring case.
+func (b Bookmark) HasTag(tag string) bool {
+	for _, t := range b.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter selects bookmarks in List. Empty fields match everything.
+type Filter struct {
+	Channel    string
+	ChatID     string
+	Tag        string
+	UnreadOnly bool
+}
+
+type storeData struct {
+	NextID    int        `json:"next_id"`
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
+
+// Store persists bookmarks in workspace/bookmarks/bookmarks.json. The file
+// is re-read on every operation so the CLI and a running gateway can
+// share it.
+type Store struct {
+	path string
+	mu   *sync.Mutex // shared with the digest service
+}
+
+// NewStore creates a bookmark store for a workspace.
+func NewStore(workspace string) *Store {
+	path := filepath.Join(workspace, "bookmarks", "bookmarks.json")
+	return &Store{path: path, mu: utils.FileLock(path)}
+}
+
+// Add saves b, assigning its ID and AddedAt. A URL already saved in the
+// same chat is not added twice; the existing bookmark is returned with
+// false instead.
+func (s *Store) Add(b Bookmark) (*Bookmark, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := s.load()
+	for _, existing := range data.Bookmarks {
+		if existing.URL == b.URL && existing.Channel == b.Channel && existing.ChatID == b.ChatID {
+			return &existing, false, nil
+		}
+	}
+
+	if data.NextID == 0 {
+		data.NextID = 1
+	}
+	b.ID = data.NextID
+	b.AddedAt = time.Now()
+	data.NextID++
+	data.Bookmarks = append(data.Bookmarks, b)
+	if err := s.save(data); err != nil {
+		return nil, false, err
+	}
+	return &b, true, nil
+}
+
+// List returns the bookmarks matching f, newest first.
+func (s *Store) List(f Filter) []Bookmark {
+	s.mu.Lock()
+	all := s.load().Bookmarks
+	s.mu.Unlock()
+
+	var result []Bookmark
+	for i := len(all) - 1; i >= 0; i-- {
+		b := all[i]
+		if f.Channel != "" && b.Channel != f.Channel {
+			continue
+		}
+		if f.ChatID != "" && b.ChatID != f.ChatID {
+			continue
+		}
+		if f.Tag != "" && !b.HasTag(f.Tag) {
+			continue
+		}
+		if f.UnreadOnly && b.ReadAt != nil {
+			continue
+		}
+		result = append(result, b)
+	}
+	return result
+}
+
+// MarkRead marks the bookmark with id in the given chat as read.
+func (s *Store) MarkRead(channel, chatID string, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := s.load()
+	for i := range data.Bookmarks {
+		b := &data.Bookmarks[i]
+		if b.ID == id && b.Channel == channel && b.ChatID == chatID {
+			if b.ReadAt == nil {
+				now := time.Now()
+				b.ReadAt = &now
+			}
+			return s.save(data)
+		}
+	}
+	return fmt.Errorf("bookmark #%d not found", id)
+}
+
+func (s *Store) load() storeData {
+	var data storeData
+	if raw, err := os.ReadFile(s.path); err == nil {
+		json.Unmarshal(raw, &data)
+	}
+	return data
+}
+
+func (s *Store) save(data storeData) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
@@ -2,22 +2,46 @@ package bus
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
 )
 
 type MessageBus struct {
 	inbound  chan InboundMessage
 	outbound chan OutboundMessage
 	handlers map[string]MessageHandler
+	held     map[string]*heldMessage // messages waiting for DeliverAt, by ID
+	heldPath string                  // where held messages are saved, if anywhere
 	closed   bool
 	mu       sync.RWMutex
 }
 
+// HeldMessage is a message the bus holds until its DeliverAt.
+type HeldMessage struct {
+	ID      string          `json:"id"`
+	Message OutboundMessage `json:"message"`
+}
+
+type heldMessage struct {
+	HeldMessage
+	timer *time.Timer
+}
+
 func NewMessageBus() *MessageBus {
 	return &MessageBus{
 		inbound:  make(chan InboundMessage, 100),
 		outbound: make(chan OutboundMessage, 100),
 		handlers: make(map[string]MessageHandler),
+		held:     make(map[string]*heldMessage),
 	}
 }
 
@@ -39,7 +63,13 @@ func (mb *MessageBus) ConsumeInbound(ctx context.Context) (InboundMessage, bool)
 	}
 }
 
+// PublishOutbound queues msg for the channels, or holds it until
+// msg.DeliverAt when that is in the future.
 func (mb *MessageBus) PublishOutbound(msg OutboundMessage) {
+	if time.Until(msg.DeliverAt) > 0 {
+		mb.Hold(msg)
+		return
+	}
 	mb.mu.RLock()
 	defer mb.mu.RUnlock()
 	if mb.closed {
@@ -48,6 +78,143 @@ func (mb *MessageBus) PublishOutbound(msg OutboundMessage) {
 	mb.outbound <- msg
 }
 
+// Hold keeps msg until msg.DeliverAt and returns the ID to cancel it by.
+// A message that is already due is published right away, with no ID.
+func (mb *MessageBus) Hold(msg OutboundMessage) string {
+	if time.Until(msg.DeliverAt) <= 0 {
+		msg.DeliverAt = time.Time{}
+		mb.PublishOutbound(msg)
+		return ""
+	}
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	if mb.closed {
+		return ""
+	}
+	id := newHeldID()
+	mb.holdLocked(HeldMessage{ID: id, Message: msg})
+	mb.saveHeldLocked()
+	return id
+}
+
+// holdLocked starts the timer that publishes h at its DeliverAt, or at
+// once when that has passed.
+func (mb *MessageBus) holdLocked(h HeldMessage) {
+	mb.held[h.ID] = &heldMessage{
+		HeldMessage: h,
+		timer: time.AfterFunc(time.Until(h.Message.DeliverAt), func() {
+			mb.mu.Lock()
+			_, ok := mb.held[h.ID]
+			if ok {
+				delete(mb.held, h.ID)
+				mb.saveHeldLocked()
+			}
+			mb.mu.Unlock()
+			if ok {
+				msg := h.Message
+				msg.DeliverAt = time.Time{}
+				mb.PublishOutbound(msg)
+			}
+		}),
+	}
+}
+
+// Held returns the messages held for a chat, soonest first.
+func (mb *MessageBus) Held(channel, chatID string) []HeldMessage {
+	mb.mu.RLock()
+	defer mb.mu.RUnlock()
+	var held []HeldMessage
+	for _, h := range mb.held {
+		if h.Message.Channel == channel && h.Message.ChatID == chatID {
+			held = append(held, h.HeldMessage)
+		}
+	}
+	sort.Slice(held, func(a, b int) bool {
+		return held[a].Message.DeliverAt.Before(held[b].Message.DeliverAt)
+	})
+	return held
+}
+
+// CancelHeld drops a held message so it is never delivered.
+func (mb *MessageBus) CancelHeld(id string) (HeldMessage, bool) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	h, ok := mb.held[id]
+	if !ok || !h.timer.Stop() {
+		return HeldMessage{}, false
+	}
+	delete(mb.held, id)
+	mb.saveHeldLocked()
+	return h.HeldMessage, true
+}
+
+// PersistHeld keeps held messages in the file at path so they survive a
+// restart, and holds again the ones a previous run left there. Those
+// that fell due while the gateway was down are delivered right away.
+func (mb *MessageBus) PersistHeld(path string) error {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	mb.heldPath = path
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var left []HeldMessage
+	if err := json.Unmarshal(data, &left); err != nil {
+		return fmt.Errorf("reading held messages: %w", err)
+	}
+	for _, h := range left {
+		if _, ok := mb.held[h.ID]; !ok && !mb.closed {
+			mb.holdLocked(h)
+		}
+	}
+	return nil
+}
+
+// saveHeldLocked writes the held messages to heldPath, or removes the
+// file when none are left.
+func (mb *MessageBus) saveHeldLocked() {
+	if mb.heldPath == "" {
+		return
+	}
+	if len(mb.held) == 0 {
+		if err := os.Remove(mb.heldPath); err != nil && !os.IsNotExist(err) {
+			logger.WarnCF("bus", "Failed to clear held messages", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		return
+	}
+	held := make([]HeldMessage, 0, len(mb.held))
+	for _, h := range mb.held {
+		held = append(held, h.HeldMessage)
+	}
+	data, err := json.Marshal(held)
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(mb.heldPath), 0755); err == nil {
+			tmp := mb.heldPath + ".tmp"
+			if err = os.WriteFile(tmp, data, 0600); err == nil {
+				err = os.Rename(tmp, mb.heldPath)
+			}
+		}
+	}
+	if err != nil {
+		logger.WarnCF("bus", "Failed to save held messages", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
+
+func newHeldID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
 func (mb *MessageBus) SubscribeOutbound(ctx context.Context) (OutboundMessage, bool) {
 	select {
 	case msg := <-mb.outbound:
@@ -77,6 +244,11 @@ func (mb *MessageBus) Close() {
 		return
 	}
 	mb.closed = true
+	// The file keeps the held messages for the next run
+	for _, h := range mb.held {
+		h.timer.Stop()
+	}
+	clear(mb.held)
 	close(mb.inbound)
 	close(mb.outbound)
 }
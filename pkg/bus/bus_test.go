@@ -0,0 +1,73 @@
+package bus
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPublishOutbound_DeliverAt(t *testing.T) {
+	mb := NewMessageBus()
+	defer mb.Close()
+
+	mb.PublishOutbound(OutboundMessage{Channel: "telegram", ChatID: "1", Content: "later", DeliverAt: time.Now().Add(100 * time.Millisecond)})
+	mb.PublishOutbound(OutboundMessage{Channel: "telegram", ChatID: "1", Content: "now"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for _, want := range []string{"now", "later"} {
+		msg, ok := mb.SubscribeOutbound(ctx)
+		if !ok || msg.Content != want {
+			t.Fatalf("got %+v, want %q", msg, want)
+		}
+		if !msg.DeliverAt.IsZero() {
+			t.Errorf("%q still has DeliverAt %v", want, msg.DeliverAt)
+		}
+	}
+}
+
+func TestClose_DropsHeldMessages(t *testing.T) {
+	mb := NewMessageBus()
+	mb.PublishOutbound(OutboundMessage{Channel: "telegram", ChatID: "1", Content: "later", DeliverAt: time.Now().Add(50 * time.Millisecond)})
+	mb.Close()
+
+	// Publishing to a closed bus would panic on the closed channel
+	time.Sleep(100 * time.Millisecond)
+	if len(mb.held) != 0 {
+		t.Errorf("%d messages still held", len(mb.held))
+	}
+}
+
+func TestPersistHeld_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "held.json")
+	mb := NewMessageBus()
+	if err := mb.PersistHeld(path); err != nil {
+		t.Fatalf("PersistHeld: %v", err)
+	}
+	mb.PublishOutbound(OutboundMessage{Channel: "telegram", ChatID: "1", Content: "later", DeliverAt: time.Now().Add(200 * time.Millisecond)})
+	id := mb.Hold(OutboundMessage{Channel: "telegram", ChatID: "1", Content: "cancelled", DeliverAt: time.Now().Add(time.Hour)})
+	if _, ok := mb.CancelHeld(id); !ok {
+		t.Fatalf("CancelHeld(%s) found nothing", id)
+	}
+	mb.Close()
+
+	// The next run holds what is left and sends it when due
+	mb = NewMessageBus()
+	defer mb.Close()
+	if err := mb.PersistHeld(path); err != nil {
+		t.Fatalf("PersistHeld after restart: %v", err)
+	}
+	if held := mb.Held("telegram", "1"); len(held) != 1 || held[0].Message.Content != "later" {
+		t.Fatalf("held after restart = %+v", held)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if msg, ok := mb.SubscribeOutbound(ctx); !ok || msg.Content != "later" {
+		t.Fatalf("got %+v, want the held message", msg)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if held := mb.Held("telegram", "1"); len(held) != 0 {
+		t.Errorf("still held after delivery: %+v", held)
+	}
+}
@@ -1,5 +1,10 @@
 package bus
 
+import (
+	"strings"
+	"time"
+)
+
 type InboundMessage struct {
 	Channel    string            `json:"channel"`
 	SenderID   string            `json:"sender_id"`
@@ -8,12 +13,129 @@ type InboundMessage struct {
 	Media      []string          `json:"media,omitempty"`
 	SessionKey string            `json:"session_key"`
 	Metadata   map[string]string `json:"metadata,omitempty"`
+	// Control marks the message as a control event on an earlier reply
+	// rather than a user message, see the Control* constants. Content
+	// then carries the text of the reply it applies to.
+	Control string `json:"control,omitempty"`
 }
 
+// Control events a channel can raise, e.g. from a reaction on a reply.
+const (
+	// ControlRegenerate asks for the chat's latest reply to be answered again.
+	ControlRegenerate = "regenerate"
+	// ControlPin asks for the reply in Content to be pinned in its chat and
+	// saved to memory.
+	ControlPin = "pin"
+	// ControlRemember asks for the message in Content to be saved to its
+	// guild's shared memory, with the author from the metadata.
+	ControlRemember = "remember"
+	// ControlSummarize asks for the chat transcript in Content to be
+	// summarized; metadata "summary_scope" says what it covers.
+	ControlSummarize = "summarize"
+)
+
 type OutboundMessage struct {
 	Channel string `json:"channel"`
 	ChatID  string `json:"chat_id"`
 	Content string `json:"content"`
+	Embed   *Embed `json:"embed,omitempty"`
+	// Partial marks a reply that is still being generated; Content holds
+	// everything generated so far. Only channels that can show progress
+	// receive partials, and the final reply follows as a normal message.
+	Partial bool `json:"partial,omitempty"`
+	// Proactive marks a message the agent sends on its own rather than in
+	// reply, see the Proactive* kinds. The channel manager drops it when
+	// the chat has had all the proactive messages it wants today.
+	Proactive string `json:"proactive,omitempty"`
+	// DeliverAt holds the message in the bus until the given time. Held
+	// messages survive a restart once the bus persists them, see
+	// MessageBus.PersistHeld.
+	DeliverAt time.Time `json:"deliver_at,omitzero"`
+	// PrivateTo is the ID of the only user who should see the message.
+	// Channels that can reply privately (Discord: ephemerally to a slash
+	// command, else by DM) do; the others send it to the chat as usual.
+	PrivateTo string `json:"private_to,omitempty"`
+	// Alert marks a message worth a push notification, see the Alert*
+	// kinds. The channel manager also forwards it to the notifier channels
+	// (ntfy, Pushover, Gotify) that subscribe to its kind.
+	Alert string `json:"alert,omitempty"`
+	// Edit is the ID of an earlier message of the bot that Content
+	// replaces. Channels that can edit their messages (Discord) do; the
+	// others send Content as a new message.
+	Edit string `json:"edit,omitempty"`
+	// EndsTurn marks the agent's last message in answer to an inbound
+	// message. When the turn ended without a reply of its own, e.g. the
+	// message tool answered, Content is empty and only channels that track
+	// turns get the message.
+	EndsTurn bool `json:"ends_turn,omitempty"`
+	// OnSent, when set, is called once the channel has sent the message.
+	// It isn't called for messages the channel manager holds back or
+	// fails to send, nor for a message restored after a restart.
+	OnSent func() `json:"-"`
+}
+
+// TurnEnd reports whether msg only marks the end of a turn, with nothing
+// to send.
+func (msg OutboundMessage) TurnEnd() bool {
+	return msg.EndsTurn && msg.Content == "" && msg.Embed == nil
+}
+
+// Kinds of proactive messages.
+const (
+	ProactiveBriefing = "briefing"
+	ProactiveFollowUp = "follow_up"
+	ProactiveNudge    = "nudge"
+	ProactiveDigest   = "digest"
+	ProactiveStarter  = "starter"
+)
+
+// Kinds of alerts.
+const (
+	AlertError    = "error"
+	AlertReminder = "reminder"
+)
+
+// Embed is an optional structured form of an outbound message. Channels
+// that can render it (Discord) send the embed instead of Content; all
+// others send Content, which falls back to Embed.Text() when empty.
+type Embed struct {
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	URL         string       `json:"url,omitempty"`
+	Fields      []EmbedField `json:"fields,omitempty"`
+	Footer      string       `json:"footer,omitempty"`
+	Color       int          `json:"color,omitempty"` // 0xRRGGBB
+}
+
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// Text renders the embed as plain text for channels without embeds.
+func (e *Embed) Text() string {
+	var parts []string
+	if e.Title != "" {
+		parts = append(parts, e.Title)
+	}
+	if e.Description != "" {
+		parts = append(parts, e.Description)
+	}
+	if len(e.Fields) > 0 {
+		lines := make([]string, 0, len(e.Fields))
+		for _, f := range e.Fields {
+			lines = append(lines, f.Name+": "+f.Value)
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	if e.URL != "" {
+		parts = append(parts, e.URL)
+	}
+	if e.Footer != "" {
+		parts = append(parts, e.Footer)
+	}
+	return strings.Join(parts, "\n\n")
 }
 
 type MessageHandler func(InboundMessage) error
@@ -0,0 +1,211 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package canary routes a share of conversation turns to an alternate model
+// and records latency, token usage, cost and user feedback per arm, so a
+// model switch can be evaluated on real traffic before it is made.
+package canary
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+const (
+	ArmControl = "control"
+	ArmCanary  = "canary"
+
+	KindTurn     = "turn"
+	KindFeedback = "feedback"
+)
+
+// Record is one line of the experiment log.
+type Record struct {
+	Kind             string    `json:"kind"`
+	Time             time.Time `json:"time"`
+	TurnID           string    `json:"turn_id"`
+	Arm              string    `json:"arm"`
+	Model            string    `json:"model,omitempty"`
+	Channel          string    `json:"channel,omitempty"`
+	ChatID           string    `json:"chat_id,omitempty"`
+	LatencyMS        int64     `json:"latency_ms,omitempty"`
+	Iterations       int       `json:"iterations,omitempty"`
+	PromptTokens     int       `json:"prompt_tokens,omitempty"`
+	CompletionTokens int       `json:"completion_tokens,omitempty"`
+	CostUSD          float64   `json:"cost_usd,omitempty"`
+	Error            string    `json:"error,omitempty"`
+	Positive         *bool     `json:"positive,omitempty"`
+}
+
+// Turn tracks a single conversation turn while it runs.
+type Turn struct {
+	ID               string
+	Arm              string
+	Model            string
+	Channel          string
+	ChatID           string
+	Provider         providers.LLMProvider
+	start            time.Time
+	promptTokens     int
+	completionTokens int
+}
+
+// IsCanary reports whether the turn should use the alternate model.
+func (t *Turn) IsCanary() bool {
+	return t != nil && t.Arm == ArmCanary
+}
+
+// AddUsage accumulates token usage from one LLM call.
+func (t *Turn) AddUsage(usage *providers.UsageInfo) {
+	if t == nil || usage == nil {
+		return
+	}
+	t.promptTokens += usage.PromptTokens
+	t.completionTokens += usage.CompletionTokens
+}
+
+// Experiment assigns turns to arms and appends results to
+// workspace/state/canary.jsonl.
+type Experiment struct {
+	cfg      config.CanaryConfig
+	provider providers.LLMProvider
+	model    string
+	path     string
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	lastTurn map[string]string // "channel:chatID" -> turn ID
+}
+
+// NewExperiment creates an experiment that sends cfg.Percent of turns to
+// model on provider.
+func NewExperiment(cfg config.CanaryConfig, provider providers.LLMProvider, model, workspace string) *Experiment {
+	return &Experiment{
+		cfg:      cfg,
+		provider: provider,
+		model:    model,
+		path:     LogPath(workspace),
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		lastTurn: make(map[string]string),
+	}
+}
+
+// LogPath returns the experiment log location for a workspace.
+func LogPath(workspace string) string {
+	return filepath.Join(workspace, "state", "canary.jsonl")
+}
+
+// Model returns the canary model ID.
+func (e *Experiment) Model() string {
+	return e.model
+}
+
+// Begin assigns a new turn to an arm. controlModel is the model the turn
+// would use without the experiment.
+func (e *Experiment) Begin(channel, chatID, controlModel string) *Turn {
+	e.mu.Lock()
+	canary := e.rng.Intn(100) < e.cfg.Percent
+	id := fmt.Sprintf("%d", time.Now().UnixNano())
+	e.lastTurn[channel+":"+chatID] = id
+	e.mu.Unlock()
+
+	turn := &Turn{
+		ID:      id,
+		Arm:     ArmControl,
+		Model:   controlModel,
+		Channel: channel,
+		ChatID:  chatID,
+		start:   time.Now(),
+	}
+	if canary {
+		turn.Arm = ArmCanary
+		turn.Model = e.model
+		turn.Provider = e.provider
+	}
+	return turn
+}
+
+// Finish records the outcome of a turn.
+func (e *Experiment) Finish(turn *Turn, iterations int, err error) {
+	if turn == nil {
+		return
+	}
+
+	rec := Record{
+		Kind:             KindTurn,
+		Time:             time.Now(),
+		TurnID:           turn.ID,
+		Arm:              turn.Arm,
+		Model:            turn.Model,
+		Channel:          turn.Channel,
+		ChatID:           turn.ChatID,
+		LatencyMS:        time.Since(turn.start).Milliseconds(),
+		Iterations:       iterations,
+		PromptTokens:     turn.promptTokens,
+		CompletionTokens: turn.completionTokens,
+		CostUSD:          e.cost(turn),
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	e.append(rec)
+}
+
+// RecordFeedback attributes a thumbs up/down to the most recent turn in a
+// chat. It returns false if no turn from this process matches.
+func (e *Experiment) RecordFeedback(channel, chatID string, positive bool) bool {
+	e.mu.Lock()
+	id, ok := e.lastTurn[channel+":"+chatID]
+	e.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	e.append(Record{
+		Kind:     KindFeedback,
+		Time:     time.Now(),
+		TurnID:   id,
+		Channel:  channel,
+		ChatID:   chatID,
+		Positive: &positive,
+	})
+	return true
+}
+
+func (e *Experiment) cost(turn *Turn) float64 {
+	in, out := e.cfg.ControlInputPrice, e.cfg.ControlOutputPrice
+	if turn.IsCanary() {
+		in, out = e.cfg.CanaryInputPrice, e.cfg.CanaryOutputPrice
+	}
+	return (float64(turn.promptTokens)*in + float64(turn.completionTokens)*out) / 1e6
+}
+
+func (e *Experiment) append(rec Record) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(e.path), 0755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(e.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	f.Write(append(line, '\n'))
+}
@@ -0,0 +1,81 @@
+package canary
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+func TestExperiment_BeginRespectsPercent(t *testing.T) {
+	tests := []struct {
+		percent    int
+		wantCanary bool
+	}{
+		{0, false},
+		{100, true},
+	}
+
+	for _, tt := range tests {
+		exp := NewExperiment(config.CanaryConfig{Percent: tt.percent}, nil, "alt-model", t.TempDir())
+		for i := 0; i < 20; i++ {
+			turn := exp.Begin("telegram", "1", "main-model")
+			if turn.IsCanary() != tt.wantCanary {
+				t.Fatalf("percent=%d: IsCanary = %v", tt.percent, turn.IsCanary())
+			}
+			wantModel := "main-model"
+			if tt.wantCanary {
+				wantModel = "alt-model"
+			}
+			if turn.Model != wantModel {
+				t.Errorf("percent=%d: Model = %q, want %q", tt.percent, turn.Model, wantModel)
+			}
+		}
+	}
+}
+
+func TestExperiment_RecordAndSummarize(t *testing.T) {
+	ws := t.TempDir()
+	cfg := config.CanaryConfig{
+		Percent:           100,
+		CanaryInputPrice:  1,
+		CanaryOutputPrice: 2,
+	}
+	exp := NewExperiment(cfg, nil, "alt-model", ws)
+
+	turn := exp.Begin("telegram", "1", "main-model")
+	turn.AddUsage(&providers.UsageInfo{PromptTokens: 1000, CompletionTokens: 500})
+	exp.Finish(turn, 2, nil)
+
+	failed := exp.Begin("telegram", "2", "main-model")
+	exp.Finish(failed, 1, errors.New("boom"))
+
+	if !exp.RecordFeedback("telegram", "1", true) {
+		t.Fatal("RecordFeedback found no turn")
+	}
+	if exp.RecordFeedback("telegram", "unknown", false) {
+		t.Error("RecordFeedback matched an unknown chat")
+	}
+
+	records, err := LoadRecords(LogPath(ws))
+	if err != nil {
+		t.Fatalf("LoadRecords: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+
+	summaries := Summarize(records, time.Time{})
+	canary := summaries[1]
+	if canary.Arm != ArmCanary || canary.Turns != 2 || canary.Errors != 1 || canary.ThumbsUp != 1 {
+		t.Errorf("unexpected canary summary: %+v", canary)
+	}
+	if want := (1000*1.0 + 500*2.0) / 1e6; canary.CostUSD != want {
+		t.Errorf("CostUSD = %v, want %v", canary.CostUSD, want)
+	}
+	if summaries[0].Turns != 0 {
+		t.Errorf("control turns = %d, want 0", summaries[0].Turns)
+	}
+}
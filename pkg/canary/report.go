@@ -0,0 +1,125 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package canary
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+)
+
+// ArmSummary aggregates the results of one arm.
+type ArmSummary struct {
+	Arm              string
+	Models           []string
+	Turns            int
+	Errors           int
+	AvgLatency       time.Duration
+	P95Latency       time.Duration
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64
+	ThumbsUp         int
+	ThumbsDown       int
+}
+
+// LoadRecords reads the experiment log. A missing log yields no records.
+func LoadRecords(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// Summarize groups records by arm. Records before since are ignored when
+// since is non-zero. Feedback is attributed to the arm of its turn.
+func Summarize(records []Record, since time.Time) []ArmSummary {
+	arms := map[string]*ArmSummary{
+		ArmControl: {Arm: ArmControl},
+		ArmCanary:  {Arm: ArmCanary},
+	}
+	latencies := make(map[string][]int64)
+	turnArm := make(map[string]string)
+	models := make(map[string]map[string]bool)
+
+	for _, rec := range records {
+		if rec.Kind != KindTurn || (!since.IsZero() && rec.Time.Before(since)) {
+			continue
+		}
+		s, ok := arms[rec.Arm]
+		if !ok {
+			continue
+		}
+		turnArm[rec.TurnID] = rec.Arm
+		s.Turns++
+		if rec.Error != "" {
+			s.Errors++
+		}
+		s.PromptTokens += rec.PromptTokens
+		s.CompletionTokens += rec.CompletionTokens
+		s.CostUSD += rec.CostUSD
+		latencies[rec.Arm] = append(latencies[rec.Arm], rec.LatencyMS)
+		if models[rec.Arm] == nil {
+			models[rec.Arm] = make(map[string]bool)
+		}
+		if rec.Model != "" {
+			models[rec.Arm][rec.Model] = true
+		}
+	}
+
+	for _, rec := range records {
+		if rec.Kind != KindFeedback || rec.Positive == nil {
+			continue
+		}
+		s, ok := arms[turnArm[rec.TurnID]]
+		if !ok {
+			continue
+		}
+		if *rec.Positive {
+			s.ThumbsUp++
+		} else {
+			s.ThumbsDown++
+		}
+	}
+
+	result := make([]ArmSummary, 0, len(arms))
+	for _, name := range []string{ArmControl, ArmCanary} {
+		s := arms[name]
+		if l := latencies[name]; len(l) > 0 {
+			sort.Slice(l, func(i, j int) bool { return l[i] < l[j] })
+			var total int64
+			for _, v := range l {
+				total += v
+			}
+			s.AvgLatency = time.Duration(total/int64(len(l))) * time.Millisecond
+			s.P95Latency = time.Duration(l[(len(l)*95-1)/100]) * time.Millisecond
+		}
+		for m := range models[name] {
+			s.Models = append(s.Models, m)
+		}
+		sort.Strings(s.Models)
+		result = append(result, *s)
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package channels
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// allowListRequest is the body of POST and DELETE /admin/allowlist.
+type allowListRequest struct {
+	Channel string `json:"channel"`
+	UserID  string `json:"user_id"`
+}
+
+type allowListResponse struct {
+	Channel   string   `json:"channel"`
+	AllowFrom []string `json:"allow_from"`
+	Changed   bool     `json:"changed"`
+	Warning   string   `json:"warning,omitempty"`
+}
+
+// AllowListHandler serves /admin/allowlist for operators: GET
+// ?channel=<name> lists a channel's allowlist, POST adds and DELETE
+// removes {"channel": ..., "user_id": ...}. Changes are saved to the config
+// file like the !allow and !revoke commands. Requests need
+// "Authorization: Bearer <token>".
+func (m *Manager) AllowListHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req allowListRequest
+		switch r.Method {
+		case http.MethodGet:
+			req.Channel = r.URL.Query().Get("channel")
+		case http.MethodPost, http.MethodDelete:
+			if err := json.NewDecoder(io.LimitReader(r.Body, apiMaxBody)).Decode(&req); err != nil {
+				http.Error(w, "invalid JSON body", http.StatusBadRequest)
+				return
+			}
+			if req.UserID == "" {
+				http.Error(w, "user_id is required", http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if req.Channel == "" {
+			http.Error(w, "channel is required", http.StatusBadRequest)
+			return
+		}
+
+		resp := allowListResponse{Channel: req.Channel}
+		var err error
+		switch r.Method {
+		case http.MethodPost:
+			resp.Changed, err = m.AddAllowedUser(req.Channel, req.UserID)
+		case http.MethodDelete:
+			resp.Changed, err = m.RemoveAllowedUser(req.Channel, req.UserID)
+		}
+		switch {
+		case resp.Changed && err != nil:
+			// Applied, but not saved to the config file
+			resp.Warning = err.Error()
+		case errors.Is(err, ErrLastAllowedUser):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		if resp.AllowFrom, err = m.AllowList(req.Channel); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if resp.AllowFrom == nil {
+			resp.AllowFrom = []string{}
+		}
+		writeAPIJSON(w, http.StatusOK, resp)
+	})
+}
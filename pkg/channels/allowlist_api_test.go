@@ -0,0 +1,81 @@
+package channels
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func TestAllowListHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg := config.DefaultConfig()
+	cfg.Channels.API.Enabled = true
+	cfg.Channels.API.Token = "api-token"
+	cfg.Channels.API.AllowFrom = config.FlexibleStringSlice{"111"}
+	if err := config.SaveConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManager(cfg, bus.NewMessageBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetConfigPath(path)
+	t.Setenv("PICOCLAW_CHANNELS_TELEGRAM_TOKEN", "env-secret")
+	handler := m.AllowListHandler("secret")
+
+	call := func(method, body, token string) (*httptest.ResponseRecorder, allowListResponse) {
+		t.Helper()
+		req := httptest.NewRequest(method, "/admin/allowlist?channel=api", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var resp allowListResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec, resp
+	}
+	saved := func() []string {
+		t.Helper()
+		cfg, err := config.LoadConfig(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cfg.Channels.API.AllowFrom
+	}
+
+	if rec, _ := call(http.MethodGet, "", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status %d", rec.Code)
+	}
+
+	rec, resp := call(http.MethodPost, `{"channel":"api","user_id":"222"}`, "secret")
+	if rec.Code != http.StatusOK || !resp.Changed || len(resp.AllowFrom) != 2 {
+		t.Fatalf("POST = %d %+v", rec.Code, resp)
+	}
+	if got := saved(); len(got) != 2 || got[1] != "222" {
+		t.Errorf("saved allow_from = %v", got)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "env-secret") {
+		t.Error("saving the allowlist wrote a secret from the environment to the file")
+	}
+
+	rec, resp = call(http.MethodDelete, `{"channel":"api","user_id":"111"}`, "secret")
+	if rec.Code != http.StatusOK || !resp.Changed {
+		t.Fatalf("DELETE = %d %+v", rec.Code, resp)
+	}
+	if got := saved(); len(got) != 1 || got[0] != "222" {
+		t.Errorf("saved allow_from = %v", got)
+	}
+
+	if rec, _ := call(http.MethodDelete, `{"channel":"api","user_id":"222"}`, "secret"); rec.Code != http.StatusConflict {
+		t.Errorf("removing the last user: status %d", rec.Code)
+	}
+	if rec, _ := call(http.MethodPost, `{"channel":"nope","user_id":"1"}`, "secret"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown channel: status %d", rec.Code)
+	}
+}
@@ -0,0 +1,293 @@
+package channels
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const (
+	apiChatPath  = "/api/chat"
+	apiTasksPath = "/api/chat/tasks/"
+	apiMaxBody   = 1 << 20
+	// apiTaskTTL is how long finished tasks can be polled, and how long
+	// an unanswered one holds its session.
+	apiTaskTTL = time.Hour
+)
+
+// APIChannel serves POST /api/chat on the gateway, so scripts and other
+// services can talk to the agent over plain HTTP. A request waits for the
+// agent's reply and returns it, or with "async" returns a task ID right
+// away to poll at /api/chat/tasks/<id>.
+//
+// The session ID is the chat ID, so requests with the same session_id
+// share a conversation; it defaults to the user ID. A session handles one
+// request at a time: its task is done when the agent's turn on it ends,
+// with the last message of the turn as the reply.
+type APIChannel struct {
+	*BaseChannel
+	config  config.APIConfig
+	timeout time.Duration
+
+	mu      sync.Mutex
+	tasks   map[string]*apiTask // task ID -> task
+	pending map[string]*apiTask // session ID -> task waiting for its reply
+}
+
+// apiTask is one request and, once the agent answered, its reply.
+type apiTask struct {
+	ID        string `json:"task_id"`
+	SessionID string `json:"session_id"`
+	Status    string `json:"status"` // "pending", "done" or "dropped"
+	Reply     string `json:"reply,omitempty"`
+
+	created time.Time
+	done    chan struct{}
+}
+
+// apiChatRequest is the JSON body of POST /api/chat.
+type apiChatRequest struct {
+	UserID    string   `json:"user_id"`
+	Message   string   `json:"message"`
+	SessionID string   `json:"session_id"`
+	Media     []string `json:"media"`
+	Async     bool     `json:"async"`
+}
+
+func NewAPIChannel(cfg config.APIConfig, messageBus *bus.MessageBus) (*APIChannel, error) {
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("api channel needs a token")
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	return &APIChannel{
+		BaseChannel: NewBaseChannel("api", cfg, messageBus, cfg.AllowFrom),
+		config:      cfg,
+		timeout:     timeout,
+		tasks:       make(map[string]*apiTask),
+		pending:     make(map[string]*apiTask),
+	}, nil
+}
+
+func (c *APIChannel) Start(ctx context.Context) error {
+	c.setRunning(true)
+	logger.InfoC("api", "API channel started")
+	return nil
+}
+
+func (c *APIChannel) Stop(ctx context.Context) error {
+	c.setRunning(false)
+	return nil
+}
+
+// Routes serves the chat endpoint and task polling.
+func (c *APIChannel) Routes() map[string]http.Handler {
+	return map[string]http.Handler{
+		apiChatPath:  http.HandlerFunc(c.handleChat),
+		apiTasksPath: http.HandlerFunc(c.handleTask),
+	}
+}
+
+// Send records a message of the turn answering the task waiting on
+// msg.ChatID, and completes the task with the message that ends the turn.
+// Messages the agent sends on its own, and those for sessions with no
+// request in flight, have nowhere to go and are dropped.
+func (c *APIChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	content := msg.Content
+	if content == "" && msg.Embed != nil {
+		content = msg.Embed.Text()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	task, ok := c.pending[msg.ChatID]
+	if !ok || msg.Proactive != "" {
+		logger.DebugCF("api", "No request waiting for message, dropped", map[string]any{
+			"session_id": msg.ChatID,
+		})
+		return nil
+	}
+	task.Reply = content
+	if msg.EndsTurn {
+		c.finishLocked(task, "done")
+	}
+	return nil
+}
+
+// EndTurn completes the task of a turn that ended without a reply of its
+// own with the last message sent during it, if any.
+func (c *APIChannel) EndTurn(chatID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if task, ok := c.pending[chatID]; ok {
+		c.finishLocked(task, "done")
+	}
+}
+
+// finishLocked ends task and frees its session for the next request.
+func (c *APIChannel) finishLocked(task *apiTask, status string) {
+	if c.pending[task.SessionID] == task {
+		delete(c.pending, task.SessionID)
+	}
+	if task.Status == "pending" {
+		task.Status = status
+		close(task.done)
+	}
+}
+
+func (c *APIChannel) handleChat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !c.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req apiChatRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, apiMaxBody)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Message) == "" && len(req.Media) == 0 {
+		http.Error(w, "empty message", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == "" {
+		req.UserID = "api"
+	}
+	if req.SessionID == "" {
+		req.SessionID = req.UserID
+	}
+	if !c.IsAllowed(req.UserID) {
+		http.Error(w, "user not allowed", http.StatusForbidden)
+		return
+	}
+
+	task, err := c.newTask(req.SessionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	logger.InfoCF("api", "Received API message", map[string]any{
+		"user_id":    req.UserID,
+		"session_id": req.SessionID,
+		"async":      req.Async,
+	})
+	metadata := map[string]string{"peer_kind": "direct", "peer_id": req.UserID}
+	go func() {
+		// A message the channel drops, e.g. over the rate limit, starts
+		// no turn that could end the task
+		if !c.HandleMessage(req.UserID, req.SessionID, req.Message, callerMedia("api", req.Media), metadata) {
+			c.mu.Lock()
+			c.finishLocked(task, "dropped")
+			c.mu.Unlock()
+		}
+	}()
+
+	if req.Async {
+		writeAPIJSON(w, http.StatusAccepted, c.snapshot(task))
+		return
+	}
+
+	// The gateway's write timeout is far shorter than a turn
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(c.timeout + 10*time.Second))
+	select {
+	case <-task.done:
+		writeAPIJSON(w, http.StatusOK, c.snapshot(task))
+	case <-time.After(c.timeout):
+		// The task keeps going; the caller can poll for the reply
+		writeAPIJSON(w, http.StatusGatewayTimeout, c.snapshot(task))
+	case <-r.Context().Done():
+	}
+}
+
+func (c *APIChannel) handleTask(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !c.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, apiTasksPath)
+
+	c.mu.Lock()
+	task, ok := c.tasks[id]
+	c.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, c.snapshot(task))
+}
+
+// newTask registers a request for session, unless the session is still
+// waiting for the reply to an earlier one.
+func (c *APIChannel) newTask(session string) (*apiTask, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pruneLocked(time.Now())
+	if _, busy := c.pending[session]; busy {
+		return nil, fmt.Errorf("session %q is still waiting for a reply", session)
+	}
+	task := &apiTask{
+		ID:        uuid.NewString(),
+		SessionID: session,
+		Status:    "pending",
+		created:   time.Now(),
+		done:      make(chan struct{}),
+	}
+	c.tasks[task.ID] = task
+	c.pending[session] = task
+	return task, nil
+}
+
+// pruneLocked forgets tasks older than apiTaskTTL.
+func (c *APIChannel) pruneLocked(now time.Time) {
+	for id, task := range c.tasks {
+		if now.Sub(task.created) < apiTaskTTL {
+			continue
+		}
+		delete(c.tasks, id)
+		c.finishLocked(task, "dropped")
+	}
+}
+
+// snapshot copies task under the lock so it can be encoded safely.
+func (c *APIChannel) snapshot(task *apiTask) apiTask {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return apiTask{ID: task.ID, SessionID: task.SessionID, Status: task.Status, Reply: task.Reply}
+}
+
+// authorized checks the bearer token.
+func (c *APIChannel) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(c.config.Token)) == 1
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,160 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func startTestAPI(t *testing.T, cfg config.APIConfig) (*APIChannel, *bus.MessageBus, *httptest.Server) {
+	t.Helper()
+	mb := bus.NewMessageBus()
+	c, err := NewAPIChannel(cfg, mb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Start(context.Background())
+	mux := http.NewServeMux()
+	for pattern, h := range c.Routes() {
+		mux.Handle(pattern, h)
+	}
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return c, mb, srv
+}
+
+func postChat(t *testing.T, url, token, body string) (int, apiTask) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url+apiChatPath, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var task apiTask
+	json.NewDecoder(resp.Body).Decode(&task)
+	return resp.StatusCode, task
+}
+
+func getTask(t *testing.T, url, token, id string) (int, apiTask) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url+apiTasksPath+id, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var task apiTask
+	json.NewDecoder(resp.Body).Decode(&task)
+	return resp.StatusCode, task
+}
+
+// replyTo answers the next inbound message with reply, then passes the
+// message on.
+func replyTo(c *APIChannel, mb *bus.MessageBus, reply string) <-chan bus.InboundMessage {
+	got := make(chan bus.InboundMessage, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		msg, ok := mb.ConsumeInbound(ctx)
+		if !ok {
+			close(got)
+			return
+		}
+		c.Send(ctx, bus.OutboundMessage{Channel: "api", ChatID: msg.ChatID, Content: reply, EndsTurn: true})
+		got <- msg
+	}()
+	return got
+}
+
+func TestAPIChannel_Sync(t *testing.T) {
+	c, mb, srv := startTestAPI(t, config.APIConfig{Token: "s3cret"})
+
+	if code, _ := postChat(t, srv.URL, "wrong", `{"message":"hi"}`); code != http.StatusUnauthorized {
+		t.Errorf("wrong token: HTTP %d", code)
+	}
+	if code, _ := postChat(t, srv.URL, "s3cret", `{"user_id":"alice"}`); code != http.StatusBadRequest {
+		t.Errorf("empty message: HTTP %d", code)
+	}
+
+	inbound := replyTo(c, mb, "Pasta with pesto")
+	code, task := postChat(t, srv.URL, "s3cret", `{"user_id":"alice","message":"what's for dinner?","session_id":"kitchen"}`)
+	if code != http.StatusOK || task.Status != "done" || task.Reply != "Pasta with pesto" || task.SessionID != "kitchen" {
+		t.Fatalf("HTTP %d, task %+v", code, task)
+	}
+	msg := <-inbound
+	if msg.Channel != "api" || msg.SenderID != "alice" || msg.ChatID != "kitchen" || msg.Content != "what's for dinner?" {
+		t.Errorf("inbound = %+v", msg)
+	}
+}
+
+func TestNewAPIChannel_RequiresToken(t *testing.T) {
+	if _, err := NewAPIChannel(config.APIConfig{}, bus.NewMessageBus()); err == nil {
+		t.Error("API channel without a token accepted")
+	}
+}
+
+func TestAPIChannel_Async(t *testing.T) {
+	c, mb, srv := startTestAPI(t, config.APIConfig{Token: "s3cret"})
+
+	code, task := postChat(t, srv.URL, "s3cret", `{"user_id":"bob","message":"summarize my week","async":true}`)
+	if code != http.StatusAccepted || task.Status != "pending" || task.ID == "" || task.SessionID != "bob" {
+		t.Fatalf("HTTP %d, task %+v", code, task)
+	}
+	if code, _ := postChat(t, srv.URL, "s3cret", `{"user_id":"bob","message":"and next week?"}`); code != http.StatusConflict {
+		t.Errorf("second request on a busy session: HTTP %d", code)
+	}
+
+	<-replyTo(c, mb, "A quiet week.")
+	if _, polled := getTask(t, srv.URL, "s3cret", task.ID); polled.Status != "done" || polled.Reply != "A quiet week." {
+		t.Errorf("polled task = %+v", polled)
+	}
+	if code, _ := getTask(t, srv.URL, "wrong", task.ID); code != http.StatusUnauthorized {
+		t.Errorf("poll with the wrong token: HTTP %d", code)
+	}
+	if code, _ := getTask(t, srv.URL, "s3cret", "nope"); code != http.StatusNotFound {
+		t.Errorf("unknown task: HTTP %d", code)
+	}
+}
+
+func TestAPIChannel_CompletesWhenTheTurnEnds(t *testing.T) {
+	c, mb, srv := startTestAPI(t, config.APIConfig{Token: "s3cret"})
+	ctx := context.Background()
+
+	_, task := postChat(t, srv.URL, "s3cret", `{"user_id":"bob","message":"plan my trip","async":true}`)
+	waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if _, ok := mb.ConsumeInbound(waitCtx); !ok {
+		t.Fatal("no inbound message published")
+	}
+
+	// Messages before the end of the turn, and the agent's own, don't
+	// complete the task
+	c.Send(ctx, bus.OutboundMessage{ChatID: "bob", Content: "Good morning!", Proactive: bus.ProactiveBriefing})
+	c.Send(ctx, bus.OutboundMessage{ChatID: "bob", Content: "Looking up trains..."})
+	c.Send(ctx, bus.OutboundMessage{ChatID: "bob", Content: "Take the 9:12 to Leeds."})
+	if _, polled := getTask(t, srv.URL, "s3cret", task.ID); polled.Status != "pending" {
+		t.Fatalf("task = %+v before the turn ended", polled)
+	}
+
+	// A turn that ends without a reply of its own answers with the last
+	// message sent during it, and frees the session
+	c.EndTurn("bob")
+	if _, polled := getTask(t, srv.URL, "s3cret", task.ID); polled.Status != "done" || polled.Reply != "Take the 9:12 to Leeds." {
+		t.Errorf("task = %+v after the turn ended", polled)
+	}
+	if code, _ := postChat(t, srv.URL, "s3cret", `{"user_id":"bob","message":"thanks","async":true}`); code != http.StatusAccepted {
+		t.Errorf("next request: HTTP %d", code)
+	}
+}
@@ -2,9 +2,17 @@ package channels
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/commands"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/kv"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/voice"
 )
 
 type Channel interface {
@@ -16,12 +24,77 @@ type Channel interface {
 	IsAllowed(senderID string) bool
 }
 
+// ProgressiveChannel is implemented by channels that can show a reply
+// while it is being generated, e.g. by editing one message in place.
+// Partial outbound messages are dropped for all other channels.
+type ProgressiveChannel interface {
+	Channel
+	SendPartial(ctx context.Context, msg bus.OutboundMessage) error
+}
+
+// TurnChannel is implemented by channels that need to know when the agent
+// is done answering a message, such as the API channel returning the reply
+// to a waiting request. EndTurn is called for a turn that ended without a
+// reply of its own; a reply that ends a turn has EndsTurn set.
+type TurnChannel interface {
+	Channel
+	EndTurn(chatID string)
+}
+
+// ConfirmingChannel is implemented by channels that can ask a user to
+// approve an action with buttons. Confirm blocks until senderID answers or
+// ctx ends, and reports whether they approved.
+type ConfirmingChannel interface {
+	Channel
+	Confirm(ctx context.Context, chatID, senderID, prompt string) (bool, error)
+}
+
+// HTTPChannel is implemented by channels that receive messages over HTTP
+// on the gateway's server rather than listening on their own port.
+type HTTPChannel interface {
+	Channel
+	// Routes maps URL patterns (as for http.ServeMux) to their handlers.
+	Routes() map[string]http.Handler
+}
+
+// CommandChannel is implemented by channels that can offer the agent's
+// slash commands for autocomplete. SetCommands replaces the whole list
+// and is called again whenever it changes.
+type CommandChannel interface {
+	Channel
+	SetCommands(ctx context.Context, cmds []commands.Command) error
+}
+
+// AllowListChannel is implemented by channels whose allowlist can change
+// while they run. AddAllowedUser reports whether the user was added, false
+// when they were already allowed; RemoveAllowedUser whether they had an
+// entry to remove.
+type AllowListChannel interface {
+	Channel
+	AddAllowedUser(userID string) bool
+	RemoveAllowedUser(userID string) (bool, error)
+	SetAllowList(allowList []string)
+	AllowList() []string
+}
+
+// ErrLastAllowedUser is returned for removing the only entry of an
+// allowlist, since an empty allowlist lets everyone in.
+var ErrLastAllowedUser = errors.New("can't remove the last allowed user: an empty allowlist lets everyone in")
+
 type BaseChannel struct {
 	config    interface{}
 	bus       *bus.MessageBus
 	running   bool
 	name      string
+	allowMu   sync.RWMutex
 	allowList []string
+	video     *voice.VideoProcessor
+	vision    *config.VisionConfig
+	limiter   *rateLimiter
+	pairing   *PairingStore
+	bridges   []bridge
+	dedupe    *dedupeCache
+	store     kv.Store
 }
 
 func NewBaseChannel(name string, config interface{}, bus *bus.MessageBus, allowList []string) *BaseChannel {
@@ -31,6 +104,7 @@ func NewBaseChannel(name string, config interface{}, bus *bus.MessageBus, allowL
 		name:      name,
 		allowList: allowList,
 		running:   false,
+		dedupe:    newDedupeCache(dedupeSize),
 	}
 }
 
@@ -42,7 +116,22 @@ func (c *BaseChannel) IsRunning() bool {
 	return c.running
 }
 
+// admitsMessage reports whether a message from senderID should go on to
+// HandleMessage: the sender is allowed, or is a bridge bot, whose message
+// HandleMessage checks for the person it relays. Channels use it to skip
+// downloads for rejected senders; everything else, such as commands,
+// checks IsAllowed.
+func (c *BaseChannel) admitsMessage(senderID string) bool {
+	if _, ok := c.bridgeFor(senderID); ok {
+		return true
+	}
+	return c.IsAllowed(senderID)
+}
+
 func (c *BaseChannel) IsAllowed(senderID string) bool {
+	c.allowMu.RLock()
+	defer c.allowMu.RUnlock()
+
 	if len(c.allowList) == 0 {
 		return true
 	}
@@ -78,13 +167,96 @@ func (c *BaseChannel) IsAllowed(senderID string) bool {
 		}
 	}
 
-	return false
+	return c.pairing != nil && c.pairing.IsPaired(c.name, idPart)
 }
 
-func (c *BaseChannel) HandleMessage(senderID, chatID, content string, media []string, metadata map[string]string) {
-	if !c.IsAllowed(senderID) {
-		return
+// AddAllowedUser adds a user to the allowlist. Users already allowed are
+// not added, which includes everyone on a channel without an allowlist.
+func (c *BaseChannel) AddAllowedUser(userID string) bool {
+	if c.IsAllowed(userID) {
+		return false
+	}
+	c.allowMu.Lock()
+	defer c.allowMu.Unlock()
+	c.allowList = append(c.allowList, userID)
+	return true
+}
+
+// RemoveAllowedUser removes a user's entries from the allowlist, matching
+// "id|username" entries by either part, and unpairs them, so nothing lets
+// them back in.
+func (c *BaseChannel) RemoveAllowedUser(userID string) (bool, error) {
+	c.allowMu.Lock()
+	defer c.allowMu.Unlock()
+
+	kept, removed := removeAllowEntries(c.allowList, userID)
+	if removed && len(kept) == 0 {
+		return false, ErrLastAllowedUser
+	}
+	unpaired := false
+	if c.pairing != nil {
+		var err error
+		if unpaired, err = c.pairing.Unpair(c.name, strings.TrimPrefix(userID, "@")); err != nil {
+			return false, err
+		}
+	}
+	if removed {
+		c.allowList = kept
 	}
+	return removed || unpaired, nil
+}
+
+// AllowList returns a copy of the allowlist.
+func (c *BaseChannel) AllowList() []string {
+	c.allowMu.RLock()
+	defer c.allowMu.RUnlock()
+	return append([]string(nil), c.allowList...)
+}
+
+// removeAllowEntries returns allowList without the entries naming userID.
+func removeAllowEntries(allowList []string, userID string) ([]string, bool) {
+	userID = strings.TrimPrefix(userID, "@")
+	kept := make([]string, 0, len(allowList))
+	for _, entry := range allowList {
+		id, name, _ := strings.Cut(strings.TrimPrefix(entry, "@"), "|")
+		if id == userID || (name != "" && name == userID) {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept, len(kept) < len(allowList)
+}
+
+// SetAllowList replaces the allowlist, e.g. after the config was reloaded.
+func (c *BaseChannel) SetAllowList(allowList []string) {
+	c.allowMu.Lock()
+	defer c.allowMu.Unlock()
+	c.allowList = allowList
+}
+
+// SetPairing lets users paired through store in as if they were in the
+// allowlist.
+func (c *BaseChannel) SetPairing(store *PairingStore) {
+	c.pairing = store
+}
+
+// HandleMessage publishes a message from a user to the agent, reporting
+// whether it did: messages from senders not allowed, repeats and those
+// over the rate limit are dropped. With a video processor set, messages
+// with videos are published once the videos have been processed.
+func (c *BaseChannel) HandleMessage(senderID, chatID, content string, media []string, metadata map[string]string) bool {
+	senderID, content, metadata, ok := c.unbridge(senderID, content, metadata)
+	if !ok || !c.IsAllowed(senderID) {
+		return false
+	}
+	if c.duplicate(chatID, metadata) {
+		return false
+	}
+	if c.rateLimited(senderID, chatID) {
+		return false
+	}
+
+	content, media = c.encodeImages(content, media)
 
 	msg := bus.InboundMessage{
 		Channel:  c.name,
@@ -95,7 +267,50 @@ func (c *BaseChannel) HandleMessage(senderID, chatID, content string, media []st
 		Metadata: metadata,
 	}
 
+	if videos := c.copyVideos(media); len(videos) > 0 {
+		// Processing takes a while; the channel keeps receiving meanwhile
+		go c.publishWithVideos(msg, videos)
+		return true
+	}
 	c.bus.PublishInbound(msg)
+	return true
+}
+
+// HandleControl publishes a control event (bus.ControlRegenerate,
+// bus.ControlPin, bus.ControlRemember) for an earlier message whose text
+// is content.
+func (c *BaseChannel) HandleControl(senderID, chatID, control, content string, metadata map[string]string) {
+	if !c.IsAllowed(senderID) {
+		return
+	}
+
+	c.bus.PublishInbound(bus.InboundMessage{
+		Channel:  c.name,
+		SenderID: senderID,
+		ChatID:   chatID,
+		Content:  content,
+		Metadata: metadata,
+		Control:  control,
+	})
+}
+
+// duplicate reports whether the message with metadata's message_id was
+// already handled. IDs are only unique per chat on some platforms
+// (Telegram), so the chat is part of the key.
+func (c *BaseChannel) duplicate(chatID string, metadata map[string]string) bool {
+	id := metadata["message_id"]
+	if id == "" || c.dedupe == nil {
+		return false
+	}
+	if c.dedupe.seenBefore(c.name + "\x00" + chatID + "\x00" + id) {
+		logger.DebugCF("channels", "Dropped duplicate message", map[string]interface{}{
+			"channel":    c.name,
+			"chat_id":    chatID,
+			"message_id": id,
+		})
+		return true
+	}
+	return false
 }
 
 func (c *BaseChannel) setRunning(running bool) {
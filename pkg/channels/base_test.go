@@ -1,6 +1,15 @@
 package channels
 
-import "testing"
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/kv"
+)
 
 func TestBaseChannelIsAllowed(t *testing.T) {
 	tests := []struct {
@@ -50,3 +59,198 @@ func TestBaseChannelIsAllowed(t *testing.T) {
 		})
 	}
 }
+
+func TestBaseChannelIsAllowed_Pairing(t *testing.T) {
+	store := NewPairingStore(t.TempDir())
+	ch := NewBaseChannel("discord", nil, nil, []string{"111"})
+	ch.SetPairing(store)
+
+	if ch.IsAllowed("222") {
+		t.Fatal("unpaired user allowed")
+	}
+	code, issued, err := store.Request("discord", "222", "bob")
+	if err != nil || !issued || len(code) != 6 {
+		t.Fatalf("Request = %q, %v, %v", code, issued, err)
+	}
+	if again, issued, _ := store.Request("discord", "222", "bob"); again != code || issued {
+		t.Errorf("second Request = %q, %v; want the pending code", again, issued)
+	}
+
+	if _, err := store.Redeem("000000x"); err != ErrUnknownPairingCode {
+		t.Errorf("Redeem(bad) error = %v", err)
+	}
+	req, err := store.Redeem(code)
+	if err != nil || req.UserID != "222" {
+		t.Fatalf("Redeem = %+v, %v", req, err)
+	}
+	if !ch.IsAllowed("222") {
+		t.Error("paired user not allowed")
+	}
+	if _, err := store.Redeem(code); err != ErrUnknownPairingCode {
+		t.Error("code redeemed twice")
+	}
+	// Pairings are per channel
+	other := NewBaseChannel("telegram", nil, nil, []string{"111"})
+	other.SetPairing(store)
+	if other.IsAllowed("222") {
+		t.Error("paired user allowed on another channel")
+	}
+}
+
+func TestPairingStore_CapsPending(t *testing.T) {
+	store := NewPairingStore(t.TempDir())
+	for i := 0; i < maxPendingPairings; i++ {
+		if _, _, err := store.Request("discord", strconv.Itoa(i), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := store.Request("discord", "late", ""); err != ErrTooManyPairingRequests {
+		t.Errorf("Request over the cap: err = %v", err)
+	}
+	if _, issued, err := store.Request("discord", "3", ""); err != nil || issued {
+		t.Errorf("pending user's Request = %v, %v", issued, err)
+	}
+	if _, _, err := store.Request("telegram", "late", ""); err != nil {
+		t.Errorf("cap applied across channels: %v", err)
+	}
+}
+
+func TestManagerSharesPairingStore(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Channels.Discord.Enabled = true
+	cfg.Channels.Discord.Token = "token-a"
+	cfg.Channels.Discord.Pairing = true
+	cfg.Channels.DiscordBots = []config.DiscordConfig{{Name: "second", Enabled: true, Token: "token-b", Pairing: true}}
+	m, err := NewManager(cfg, bus.NewMessageBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := m.GetChannel("discord")
+	second, _ := m.GetChannel("discord_second")
+	if first == nil || second == nil {
+		t.Fatal("Discord bots not initialized")
+	}
+	if a, b := first.(*DiscordChannel).pairing, second.(*DiscordChannel).pairing; a == nil || a != b {
+		t.Errorf("bots use pairing stores %p and %p, want one shared", a, b)
+	}
+}
+
+func TestBaseChannelAllowUser(t *testing.T) {
+	ch := NewBaseChannel("discord", nil, nil, []string{"111"})
+	if !ch.AddAllowedUser("222") || !ch.IsAllowed("222") {
+		t.Fatal("AddAllowedUser didn't let the user in")
+	}
+	if ch.AddAllowedUser("222") {
+		t.Error("AddAllowedUser added an allowed user twice")
+	}
+
+	ch.SetAllowList([]string{"333"})
+	if ch.IsAllowed("222") || !ch.IsAllowed("333") {
+		t.Error("SetAllowList didn't replace the allowlist")
+	}
+
+	// Adding to an empty allowlist would lock everyone else out
+	open := NewBaseChannel("discord", nil, nil, nil)
+	if open.AddAllowedUser("222") || !open.IsAllowed("999") {
+		t.Error("AddAllowedUser restricted a channel open to everyone")
+	}
+}
+
+func TestBaseChannelRemoveAllowedUser(t *testing.T) {
+	ch := NewBaseChannel("telegram", nil, nil, []string{"111|alice", "@bob", "333"})
+	if removed, err := ch.RemoveAllowedUser("alice"); !removed || err != nil {
+		t.Fatalf("RemoveAllowedUser(alice) = %v, %v", removed, err)
+	}
+	if removed, _ := ch.RemoveAllowedUser("bob"); !removed || ch.IsAllowed("999|bob") {
+		t.Error("@bob not removed")
+	}
+	if removed, _ := ch.RemoveAllowedUser("444"); removed {
+		t.Error("removed a user without an entry")
+	}
+	if _, err := ch.RemoveAllowedUser("333"); err != ErrLastAllowedUser {
+		t.Errorf("removing the last entry: err = %v", err)
+	}
+	if got := ch.AllowList(); len(got) != 1 || got[0] != "333" {
+		t.Errorf("AllowList() = %v", got)
+	}
+}
+
+func TestBaseChannelRemoveAllowedUser_Unpairs(t *testing.T) {
+	store := NewPairingStore(t.TempDir())
+	code, _, _ := store.Request("discord", "555", "carol")
+	if _, err := store.Redeem(code); err != nil {
+		t.Fatal(err)
+	}
+	ch := NewBaseChannel("discord", nil, nil, []string{"111"})
+	ch.SetPairing(store)
+	if !ch.IsAllowed("555") {
+		t.Fatal("paired user not allowed")
+	}
+
+	if removed, err := ch.RemoveAllowedUser("555"); !removed || err != nil {
+		t.Fatalf("RemoveAllowedUser(555) = %v, %v", removed, err)
+	}
+	if ch.IsAllowed("555") {
+		t.Error("revoked user still allowed through their pairing")
+	}
+	if got := ch.AllowList(); len(got) != 1 || got[0] != "111" {
+		t.Errorf("AllowList() = %v", got)
+	}
+}
+
+func TestHandleMessage_Dedupe(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	c := NewBaseChannel("test", nil, msgBus, nil)
+
+	c.HandleMessage("alice", "1", "hi", nil, map[string]string{"message_id": "42"})
+	c.HandleMessage("alice", "1", "hi", nil, map[string]string{"message_id": "42"}) // replayed
+	c.HandleMessage("bob", "2", "other chat", nil, map[string]string{"message_id": "42"})
+	c.HandleMessage("alice", "1", "no id", nil, nil)
+	c.HandleMessage("alice", "1", "no id", nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var got []string
+	for {
+		msg, ok := msgBus.ConsumeInbound(ctx)
+		if !ok {
+			break
+		}
+		got = append(got, msg.Content)
+	}
+	if len(got) != 4 || got[0] != "hi" || got[1] != "other chat" {
+		t.Errorf("inbound = %q, want the replay dropped", got)
+	}
+}
+
+func TestDedupeCache_Store(t *testing.T) {
+	store := kv.NewMemory()
+	first := newDedupeCache(2)
+	first.store = store
+	if first.seenBefore("a") {
+		t.Error("new key reported as seen")
+	}
+
+	// A restarted gateway on the same store still knows the ID
+	second := newDedupeCache(2)
+	second.store = store
+	if !second.seenBefore("a") {
+		t.Error("key seen before the restart forgotten")
+	}
+}
+
+func TestDedupeCache_Evicts(t *testing.T) {
+	d := newDedupeCache(2)
+	for _, key := range []string{"a", "b", "c"} {
+		if d.seenBefore(key) {
+			t.Errorf("%s reported as seen", key)
+		}
+	}
+	if d.seenBefore("a") {
+		t.Error("oldest key not evicted")
+	}
+	if !d.seenBefore("c") {
+		t.Error("recent key forgotten")
+	}
+}
@@ -0,0 +1,114 @@
+package channels
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// bridge is a bridge bot account whose messages are relayed from people
+// on other platforms.
+type bridge struct {
+	name     string
+	senders  []string
+	patterns []*regexp.Regexp
+}
+
+// SetBridges sets the bridge bots of the channel from cfgs, skipping the
+// ones for other channels and patterns that don't compile or lack a
+// "user" group.
+func (c *BaseChannel) SetBridges(cfgs []config.BridgeConfig) {
+	var bridges []bridge
+	for _, cfg := range cfgs {
+		if cfg.Channel != "" && cfg.Channel != c.name {
+			continue
+		}
+		b := bridge{name: cfg.Name, senders: cfg.SenderIDs}
+		if b.name == "" {
+			b.name = "bridge"
+		}
+		for _, p := range cfg.Patterns {
+			re, err := regexp.Compile(p)
+			if err != nil || re.SubexpIndex("user") < 0 {
+				logger.WarnCF("channels", "Ignoring invalid bridge pattern", map[string]interface{}{
+					"channel": c.name,
+					"bridge":  b.name,
+					"pattern": p,
+				})
+				continue
+			}
+			b.patterns = append(b.patterns, re)
+		}
+		if len(b.senders) > 0 && len(b.patterns) > 0 {
+			bridges = append(bridges, b)
+		}
+	}
+
+	c.allowMu.Lock()
+	c.bridges = bridges
+	c.allowMu.Unlock()
+}
+
+// bridgeFor returns the bridge whose bot sent a message as senderID, which
+// may be in "id|username" form.
+func (c *BaseChannel) bridgeFor(senderID string) (bridge, bool) {
+	c.allowMu.RLock()
+	defer c.allowMu.RUnlock()
+
+	id, user, _ := strings.Cut(senderID, "|")
+	for _, b := range c.bridges {
+		for _, s := range b.senders {
+			s = strings.TrimPrefix(s, "@")
+			if s == senderID || s == id || (user != "" && s == user) {
+				return b, true
+			}
+		}
+	}
+	return bridge{}, false
+}
+
+// unbridge replaces a bridge bot as the sender of a message with the
+// person it relayed, and strips the bridge's prefix from the content.
+// ok is false for a bridged message that names no one, which is dropped.
+func (c *BaseChannel) unbridge(senderID, content string, metadata map[string]string) (string, string, map[string]string, bool) {
+	b, isBridge := c.bridgeFor(senderID)
+	if !isBridge {
+		return senderID, content, metadata, true
+	}
+
+	for _, re := range b.patterns {
+		m := re.FindStringSubmatch(content)
+		if m == nil {
+			continue
+		}
+		user := strings.TrimSpace(m[re.SubexpIndex("user")])
+		if user == "" {
+			continue
+		}
+		relayed := b.name + ":" + user
+		if i := re.SubexpIndex("network"); i >= 0 && m[i] != "" {
+			relayed = b.name + ":" + strings.ToLower(strings.TrimSpace(m[i])) + ":" + user
+		}
+		if i := re.SubexpIndex("text"); i >= 0 {
+			content = m[i]
+		}
+
+		out := make(map[string]string, len(metadata)+3)
+		for k, v := range metadata {
+			out[k] = v
+		}
+		out["bridge"] = b.name
+		out["bridge_sender_id"] = senderID
+		out["user_name"] = user
+		return relayed, content, out, true
+	}
+
+	logger.DebugCF("channels", "Dropped bridged message without a relayed author", map[string]interface{}{
+		"channel":   c.name,
+		"bridge":    b.name,
+		"sender_id": senderID,
+	})
+	return senderID, content, metadata, false
+}
@@ -0,0 +1,60 @@
+package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func TestHandleMessage_Bridge(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	c := NewBaseChannel("discord", nil, msgBus, []string{"matterbridge:irc:alice", "carol"})
+	c.SetBridges([]config.BridgeConfig{
+		{
+			Name:      "matterbridge",
+			SenderIDs: config.FlexibleStringSlice{"999"},
+			Patterns:  config.FlexibleStringSlice{`^\[(?P<network>[^\]]+)\] <(?P<user>[^>]+)> (?P<text>(?s:.*))$`},
+		},
+		{Name: "other", Channel: "telegram", SenderIDs: config.FlexibleStringSlice{"carol"}, Patterns: config.FlexibleStringSlice{`(?P<user>\w+):`}},
+		{Name: "broken", SenderIDs: config.FlexibleStringSlice{"888"}, Patterns: config.FlexibleStringSlice{`no user group`}},
+	})
+
+	if !c.admitsMessage("999") {
+		t.Error("bridge bot should pass the early allowlist check")
+	}
+	if c.IsAllowed("999") {
+		t.Error("bridge bot passed IsAllowed, which guards commands too")
+	}
+	if c.admitsMessage("888") {
+		t.Error("a bridge without valid patterns should not be a bridge")
+	}
+
+	c.HandleMessage("999", "1", "[IRC] <alice> hello\nthere", nil, nil)
+	c.HandleMessage("999", "1", "[irc] <mallory> let me in", nil, nil)
+	c.HandleMessage("999", "1", "*** alice joined", nil, nil)
+	c.HandleMessage("carol", "1", "carol: not bridged here", nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var got []bus.InboundMessage
+	for {
+		msg, ok := msgBus.ConsumeInbound(ctx)
+		if !ok {
+			break
+		}
+		got = append(got, msg)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d messages, want alice's and carol's: %+v", len(got), got)
+	}
+	if got[0].SenderID != "matterbridge:irc:alice" || got[0].Content != "hello\nthere" ||
+		got[0].Metadata["bridge_sender_id"] != "999" || got[0].Metadata["user_name"] != "alice" {
+		t.Errorf("bridged message = %+v", got[0])
+	}
+	if got[1].SenderID != "carol" || got[1].Content != "carol: not bridged here" {
+		t.Errorf("bridge for another channel applied: %+v", got[1])
+	}
+}
@@ -0,0 +1,112 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// mediaLookupTimeout bounds resolving the host of one caller's media URL.
+const mediaLookupTimeout = 5 * time.Second
+
+// callerMediaChannels are the channels whose callers pick the media URLs
+// the gateway fetches.
+var callerMediaChannels = map[string]bool{
+	"webhook":   true,
+	"websocket": true,
+	"mqtt":      true,
+	"api":       true,
+}
+
+// callerMediaClient fetches caller media. The check in callerMedia only
+// sees the addresses the host had then; this client checks the address
+// of every connection it makes, so neither a redirect nor DNS that
+// changes its answer can point a fetch at a private host.
+var callerMediaClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: mediaLookupTimeout,
+			Control: dialPublicOnly,
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return fmt.Errorf("redirect to a %s URL refused", req.URL.Scheme)
+		}
+		return nil
+	},
+}
+
+// dialPublicOnly refuses connections to non-public addresses, after DNS.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return fmt.Errorf("connection to the non-public address %s refused", host)
+	}
+	return nil
+}
+
+// callerMedia keeps the media a caller of an HTTP channel (webhook,
+// websocket) may attach: http(s) URLs on public hosts. A local path would
+// let the caller read the gateway's files, and a private address would
+// have the gateway fetch from services only it can reach.
+func callerMedia(channel string, media []string) []string {
+	var out []string
+	for _, item := range media {
+		if err := checkCallerMedia(item); err != nil {
+			logger.WarnCF(channel, "Media refused", map[string]any{
+				"media": item,
+				"error": err.Error(),
+			})
+			continue
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+func checkCallerMedia(item string) error {
+	u, err := url.Parse(item)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("only http(s) URLs are accepted")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), mediaLookupTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return fmt.Errorf("%s resolves to the non-public address %s", u.Hostname(), addr.IP)
+		}
+	}
+	return nil
+}
+
+// publicIP reports whether ip is reachable on the internet, not loopback,
+// link-local (such as cloud metadata at 169.254.169.254), private or
+// shared address space.
+func publicIP(ip net.IP) bool {
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 100 && ip4[1]&0xc0 == 64 {
+		return false // 100.64.0.0/10, carrier-grade NAT
+	}
+	return true
+}
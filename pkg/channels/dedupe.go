@@ -0,0 +1,62 @@
+package channels
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/kv"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// dedupeSize is how many recent message IDs a channel remembers in memory.
+const dedupeSize = 1024
+
+// dedupeTTL is how long a message ID is remembered in a kv store, which
+// outlives restarts and, with Redis, is shared by several gateways.
+const dedupeTTL = 24 * time.Hour
+
+// dedupeCache remembers the IDs of recent inbound messages, so one
+// delivered twice (a webhook retried by the platform, events replayed
+// after a gateway reconnect) is only answered once.
+type dedupeCache struct {
+	store kv.Store // nil to remember IDs in memory only
+
+	mu    sync.Mutex
+	seen  map[string]struct{}
+	order []string // ring of keys in seen, oldest at next
+	next  int
+}
+
+func newDedupeCache(size int) *dedupeCache {
+	return &dedupeCache{
+		seen:  make(map[string]struct{}, size),
+		order: make([]string, size),
+	}
+}
+
+// seenBefore records key and reports whether it was already recorded.
+func (d *dedupeCache) seenBefore(key string) bool {
+	if d.store != nil {
+		added, err := d.store.Add("dedupe:"+key, nil, dedupeTTL)
+		if err == nil {
+			return !added
+		}
+		logger.WarnCF("channels", "Dedupe store failed, using memory", map[string]any{
+			"error": err.Error(),
+		})
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.seen[key]; ok {
+		return true
+	}
+	if old := d.order[d.next]; old != "" {
+		delete(d.seen, old)
+	}
+	d.order[d.next] = key
+	d.next = (d.next + 1) % len(d.order)
+	d.seen[key] = struct{}{}
+	return false
+}
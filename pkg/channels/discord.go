@@ -11,15 +11,16 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/sipeed/picoclaw/pkg/bus"
 	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/knowledge"
 	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/markdown"
 	"github.com/sipeed/picoclaw/pkg/utils"
 	"github.com/sipeed/picoclaw/pkg/voice"
 )
 
-const (
-	transcriptionTimeout = 30 * time.Second
-	sendTimeout          = 10 * time.Second
-)
+// discordChunkLen is where long replies are split: Discord allows 2000
+// characters, and flattening tables adds some.
+const discordChunkLen = 1900
 
 type DiscordChannel struct {
 	*BaseChannel
@@ -28,8 +29,23 @@ type DiscordChannel struct {
 	transcriber *voice.GroqTranscriber
 	ctx         context.Context
 	typingMu    sync.Mutex
-	typingStop  map[string]chan struct{} // chatID → stop signal
-	botUserID   string                   // stored for mention checking
+	typing      map[string]*discordTyping // chatID → typing indicator keepalive
+	botUserID   string                    // stored for mention checking
+	threadMu    sync.Mutex
+	exchanges   map[string]discordExchange // "channelID:userID" → recent turns, for thread_mode "auto"
+	streamMu    sync.Mutex
+	streams     map[string]*discordStream // chatID → reply being streamed
+	confirmMu   sync.Mutex
+	confirms    map[string]*discordConfirm // confirmation id → prompt awaiting a click
+	private     discordPrivate
+	admins      []string         // admin.users, who may approve pairing codes
+	knowledge   *knowledge.Index // nil unless the Q&A knowledge base is on
+	summarizer  Summarizer       // for the moderator digests
+	digestMu    sync.Mutex
+	digestSent  map[string]string // state key → period of the last digest, without a store
+	observeMu   sync.Mutex
+	observeDir  string // where observed channels are logged
+	queued      bool   // sends go through the manager's outbox
 }
 
 func NewDiscordChannel(cfg config.DiscordConfig, bus *bus.MessageBus) (*DiscordChannel, error) {
@@ -38,7 +54,7 @@ func NewDiscordChannel(cfg config.DiscordConfig, bus *bus.MessageBus) (*DiscordC
 		return nil, fmt.Errorf("failed to create discord session: %w", err)
 	}
 
-	base := NewBaseChannel("discord", cfg, bus, cfg.AllowFrom)
+	base := NewBaseChannel(cfg.ChannelName(), cfg, bus, cfg.AllowFrom)
 
 	return &DiscordChannel{
 		BaseChannel: base,
@@ -46,7 +62,11 @@ func NewDiscordChannel(cfg config.DiscordConfig, bus *bus.MessageBus) (*DiscordC
 		config:      cfg,
 		transcriber: nil,
 		ctx:         context.Background(),
-		typingStop:  make(map[string]chan struct{}),
+		typing:      make(map[string]*discordTyping),
+		exchanges:   make(map[string]discordExchange),
+		streams:     make(map[string]*discordStream),
+		confirms:    make(map[string]*discordConfirm),
+		digestSent:  make(map[string]string),
 	}, nil
 }
 
@@ -74,12 +94,23 @@ func (c *DiscordChannel) Start(ctx context.Context) error {
 	c.botUserID = botUser.ID
 
 	c.session.AddHandler(c.handleMessage)
+	c.session.AddHandler(c.handleInteraction)
+	if c.config.ReactionControls || c.config.MemoryEmoji != "" {
+		c.session.AddHandler(c.handleReaction)
+	}
+	if c.knowledge != nil {
+		c.session.AddHandler(c.handleGuildCreate)
+		c.session.AddHandler(c.handlePinsUpdate)
+		c.session.AddHandler(c.handleMessageUpdate)
+		c.session.AddHandler(c.handleMessageDelete)
+	}
 
 	if err := c.session.Open(); err != nil {
 		return fmt.Errorf("failed to open discord session: %w", err)
 	}
 
 	c.setRunning(true)
+	c.startDigests(ctx)
 
 	logger.InfoCF("discord", "Discord bot connected", map[string]any{
 		"username": botUser.Username,
@@ -95,12 +126,18 @@ func (c *DiscordChannel) Stop(ctx context.Context) error {
 
 	// Stop all typing goroutines before closing session
 	c.typingMu.Lock()
-	for chatID, stop := range c.typingStop {
-		close(stop)
-		delete(c.typingStop, chatID)
+	for chatID, t := range c.typing {
+		t.cancel()
+		delete(c.typing, chatID)
 	}
 	c.typingMu.Unlock()
 
+	if c.knowledge != nil {
+		if err := c.knowledge.Flush(); err != nil {
+			logger.WarnCF("discord", "Failed to save knowledge index", map[string]any{"error": err.Error()})
+		}
+	}
+
 	if err := c.session.Close(); err != nil {
 		return fmt.Errorf("failed to close discord session: %w", err)
 	}
@@ -119,16 +156,51 @@ func (c *DiscordChannel) Send(ctx context.Context, msg bus.OutboundMessage) erro
 	if channelID == "" {
 		return fmt.Errorf("channel ID is empty")
 	}
+	if msg.PrivateTo != "" && msg.Content != "" {
+		return c.sendPrivate(ctx, msg)
+	}
+	if c.observedChat(channelID) {
+		logger.WarnCF("discord", "Dropped message to an observed channel", map[string]any{
+			"channel_id": channelID,
+		})
+		return nil
+	}
+	msg.Content = discordGuildEmoji(msg.Content, c.guildEmojis(channelID))
+
+	if stream := c.takeStream(channelID); stream != nil {
+		if msg.Embed == nil && msg.Content != "" {
+			return c.finishStream(ctx, channelID, stream, msg.Content)
+		}
+		c.discardStream(channelID, stream)
+	}
+
+	if msg.Edit != "" && msg.Embed == nil {
+		if done := c.editMessage(ctx, channelID, msg.Edit, msg.Content); done {
+			return nil
+		}
+	}
+
+	if msg.Embed != nil {
+		embed := discordEmbed(msg.Embed)
+		return c.sendWithContext(ctx, func() error {
+			_, err := c.session.ChannelMessageSendEmbed(channelID, embed, c.firstRequestOptions()...)
+			return err
+		})
+	}
 
 	runes := []rune(msg.Content)
 	if len(runes) == 0 {
 		return nil
 	}
 
-	chunks := utils.SplitMessage(msg.Content, 2000) // Split messages into chunks, Discord length limit: 2000 chars
+	chunks := markdown.Split(msg.Content, markdown.Discord, discordChunkLen)
 
-	for _, chunk := range chunks {
-		if err := c.sendChunk(ctx, channelID, chunk); err != nil {
+	for i, chunk := range chunks {
+		var opts []discordgo.RequestOption
+		if i == 0 {
+			opts = c.firstRequestOptions()
+		}
+		if err := c.sendChunk(ctx, channelID, chunk, opts...); err != nil {
 			return err
 		}
 	}
@@ -136,15 +208,58 @@ func (c *DiscordChannel) Send(ctx context.Context, msg bus.OutboundMessage) erro
 	return nil
 }
 
-func (c *DiscordChannel) sendChunk(ctx context.Context, channelID, content string) error {
-	// Use the passed ctx for timeout control
-	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
-	defer cancel()
+// SetQueued tells the channel its messages are sent from an outbox, which
+// waits out rate limits for the whole channel.
+func (c *DiscordChannel) SetQueued(queued bool) {
+	c.queued = queued
+}
 
+// firstRequestOptions are for the first request of a message. When queued,
+// a rate limit on it comes back as an error with Discord's retry delay for
+// the outbox to wait out, rather than being slept through inside the send
+// where other messages keep piling up. Later requests keep discordgo's
+// waiting, since retrying the message would repeat its sent chunks.
+func (c *DiscordChannel) firstRequestOptions() []discordgo.RequestOption {
+	if !c.queued {
+		return nil
+	}
+	return []discordgo.RequestOption{discordgo.WithRetryOnRatelimit(false)}
+}
+
+// editMessage replaces the text of one of the bot's messages, and reports
+// whether it did. Text too long for one message, or a message that is gone,
+// is left to be sent anew.
+func (c *DiscordChannel) editMessage(ctx context.Context, channelID, messageID, content string) bool {
+	if content == "" || len([]rune(content)) > discordChunkLen {
+		return false
+	}
+	err := c.sendWithContext(ctx, func() error {
+		_, err := c.session.ChannelMessageEdit(channelID, messageID, content)
+		return err
+	})
+	if err != nil {
+		logger.WarnCF("discord", "Failed to edit message, sending it anew", map[string]any{
+			"message_id": messageID,
+			"error":      err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+func (c *DiscordChannel) sendChunk(ctx context.Context, channelID, content string, opts ...discordgo.RequestOption) error {
+	return c.sendWithContext(ctx, func() error {
+		_, err := c.session.ChannelMessageSend(channelID, content, opts...)
+		return err
+	})
+}
+
+// sendWithContext runs a blocking discordgo send call, giving up when ctx
+// ends. The manager bounds ctx with the configured send timeout.
+func (c *DiscordChannel) sendWithContext(ctx context.Context, send func() error) error {
 	done := make(chan error, 1)
 	go func() {
-		_, err := c.session.ChannelMessageSend(channelID, content)
-		done <- err
+		done <- send()
 	}()
 
 	select {
@@ -153,9 +268,61 @@ func (c *DiscordChannel) sendChunk(ctx context.Context, channelID, content strin
 			return fmt.Errorf("failed to send discord message: %w", err)
 		}
 		return nil
-	case <-sendCtx.Done():
-		return fmt.Errorf("send message timeout: %w", sendCtx.Err())
+	case <-ctx.Done():
+		return fmt.Errorf("send message timeout: %w", ctx.Err())
+	}
+}
+
+// Discord embed limits
+const (
+	discordEmbedTitleMax       = 256
+	discordEmbedDescriptionMax = 4096
+	discordEmbedFieldsMax      = 25
+	discordEmbedFieldNameMax   = 256
+	discordEmbedFieldValueMax  = 1024
+	discordEmbedFooterMax      = 2048
+)
+
+// discordEmbed converts a bus embed, truncating every part to Discord's
+// limits so an oversized reply is shortened rather than rejected.
+func discordEmbed(e *bus.Embed) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       truncateRunes(e.Title, discordEmbedTitleMax),
+		Description: truncateRunes(e.Description, discordEmbedDescriptionMax),
+		URL:         e.URL,
+		Color:       e.Color,
+	}
+	for i, f := range e.Fields {
+		if i == discordEmbedFieldsMax {
+			break
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   truncateRunes(nonEmpty(f.Name), discordEmbedFieldNameMax),
+			Value:  truncateRunes(nonEmpty(f.Value), discordEmbedFieldValueMax),
+			Inline: f.Inline,
+		})
+	}
+	if e.Footer != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: truncateRunes(e.Footer, discordEmbedFooterMax)}
 	}
+	return embed
+}
+
+// nonEmpty substitutes a zero-width space, since Discord rejects empty
+// field names and values.
+func nonEmpty(s string) string {
+	if s == "" {
+		return "\u200b"
+	}
+	return s
+}
+
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
 }
 
 // appendContent safely appends content to existing text
@@ -174,18 +341,45 @@ func (c *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 	if m.Author.ID == s.State.User.ID {
 		return
 	}
+	c.indexFAQMessage(m.Message)
+
+	// Observed channels are read from everyone but never answered
+	if c.observes(m.GuildID, m.ChannelID) {
+		c.observe(m.Message)
+		return
+	}
 
 	// Check allowlist first to avoid downloading attachments and transcribing for rejected users
-	if !c.IsAllowed(m.Author.ID) {
+	if !c.admitsMessage(m.Author.ID) {
 		logger.DebugCF("discord", "Message rejected by allowlist", map[string]any{
 			"user_id": m.Author.ID,
 		})
+		c.offerPairing(m)
+		return
+	}
+
+	// !summarize works without mentioning the bot
+	if c.handleSummarizeCommand(m, c.stripBotMention(m.Content)) {
 		return
 	}
 
+	// Threads the bot started continue the parent channel's conversation
+	threadParent := ""
+	if m.GuildID != "" {
+		threadParent = c.botThreadParent(m.ChannelID)
+	}
+
+	// Questions the knowledge base answers get its sources
+	var sources []knowledge.Result
+	if m.GuildID != "" && !c.isFAQChannel(m.ChannelID) {
+		sources = c.knowledgeMatches(m.GuildID, m.ChannelID, m.Content)
+	}
+
 	// If configured to only respond to mentions, check if bot is mentioned
-	// Skip this check for DMs (GuildID is empty) - DMs should always be responded to
-	if c.config.MentionOnly && m.GuildID != "" {
+	// Skip this check for DMs (GuildID is empty) - DMs should always be responded to,
+	// and for threads the bot started, which exist to carry on a conversation.
+	// Questions the knowledge base is confident about are answered too.
+	if c.config.MentionOnly && m.GuildID != "" && threadParent == "" {
 		isMentioned := false
 		for _, mention := range m.Mentions {
 			if mention.ID == c.botUserID {
@@ -193,7 +387,12 @@ func (c *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 				break
 			}
 		}
-		if !isMentioned {
+		if !isMentioned && len(sources) > 0 && isQuestion(m.Content) {
+			logger.DebugCF("discord", "Answering question from the knowledge base", map[string]any{
+				"user_id": m.Author.ID,
+				"sources": len(sources),
+			})
+		} else if !isMentioned {
 			logger.DebugCF("discord", "Message ignored - bot not mentioned", map[string]any{
 				"user_id": m.Author.ID,
 			})
@@ -209,6 +408,10 @@ func (c *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 
 	content := m.Content
 	content = c.stripBotMention(content)
+	if c.handlePairCommand(m, content) {
+		return
+	}
+	content = discordReadableEmoji(content, m.StickerItems)
 	mediaPaths := make([]string, 0, len(m.Attachments))
 	localFiles := make([]string, 0, len(m.Attachments))
 
@@ -234,9 +437,7 @@ func (c *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 
 				transcribedText := ""
 				if c.transcriber != nil && c.transcriber.IsAvailable() {
-					ctx, cancel := context.WithTimeout(c.getContext(), transcriptionTimeout)
-					result, err := c.transcriber.Transcribe(ctx, localPath)
-					cancel() // Release context resources immediately to avoid leaks in for loop
+					result, err := c.transcriber.Transcribe(c.getContext(), localPath)
 
 					if err != nil {
 						logger.ErrorCF("discord", "Voice transcription failed", map[string]any{
@@ -262,6 +463,19 @@ func (c *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 				mediaPaths = append(mediaPaths, attachment.URL)
 				content = appendContent(content, fmt.Sprintf("[attachment: %s]", attachment.URL))
 			}
+		} else if c.vision != nil && utils.IsImageFile(attachment.Filename, attachment.ContentType) {
+			// HandleMessage downloads it and passes it to the model
+			mediaPaths = append(mediaPaths, attachment.URL)
+			content = appendContent(content, fmt.Sprintf("[image: %s]", attachment.Filename))
+		} else if c.video != nil && utils.IsVideoFile(attachment.Filename, attachment.ContentType) {
+			// HandleMessage processes the downloaded file
+			if localPath := c.downloadAttachment(attachment.URL, attachment.Filename); localPath != "" {
+				localFiles = append(localFiles, localPath)
+				mediaPaths = append(mediaPaths, localPath)
+			} else {
+				mediaPaths = append(mediaPaths, attachment.URL)
+			}
+			content = appendContent(content, fmt.Sprintf("[attachment: %s]", attachment.URL))
 		} else {
 			mediaPaths = append(mediaPaths, attachment.URL)
 			content = appendContent(content, fmt.Sprintf("[attachment: %s]", attachment.URL))
@@ -275,9 +489,17 @@ func (c *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 	if content == "" {
 		content = "[media only]"
 	}
+	if len(sources) > 0 {
+		content = appendContent(content, knowledgeContext(sources))
+	}
+
+	chatID := c.threadTarget(m, senderName, content)
+	if chatID != m.ChannelID {
+		threadParent = m.ChannelID
+	}
 
 	// Start typing after all early returns — guaranteed to have a matching Send()
-	c.startTyping(m.ChannelID)
+	c.startTyping(chatID)
 
 	logger.DebugCF("discord", "Received message", map[string]any{
 		"sender_name": senderName,
@@ -287,6 +509,9 @@ func (c *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 
 	peerKind := "channel"
 	peerID := m.ChannelID
+	if threadParent != "" {
+		peerID = threadParent
+	}
 	if m.GuildID == "" {
 		peerKind = "direct"
 		peerID = senderID
@@ -303,41 +528,74 @@ func (c *DiscordChannel) handleMessage(s *discordgo.Session, m *discordgo.Messag
 		"peer_kind":    peerKind,
 		"peer_id":      peerID,
 	}
+	if threadParent != "" {
+		metadata["thread_id"] = chatID
+	}
+	if ref := m.ReferencedMessage; ref != nil && ref.Author != nil && ref.Author.ID == c.botUserID && ref.ChannelID == chatID {
+		// A reply to the bot, e.g. !fix, can act on the message it answers
+		metadata["reply_to_message_id"] = ref.ID
+		metadata["reply_to_text"] = discordMessageText(ref)
+	}
+	c.setPersona(metadata, m.GuildID, peerID)
+
+	c.HandleMessage(senderID, chatID, content, mediaPaths, metadata)
+}
 
-	c.HandleMessage(senderID, m.ChannelID, content, mediaPaths, metadata)
+// setPersona adds the persona configured for the guild or channel to a
+// message's metadata.
+func (c *DiscordChannel) setPersona(metadata map[string]string, guildID, channelID string) {
+	if persona := c.config.PersonaFor(guildID, channelID); persona != "" {
+		metadata["persona"] = persona
+	}
 }
 
-// startTyping starts a continuous typing indicator loop for the given chatID.
-// It stops any existing typing loop for that chatID before starting a new one.
+// discordTypingInterval re-sends the typing indicator before Discord's
+// ~10 second expiry.
+var discordTypingInterval = 8 * time.Second
+
+// discordTyping is a running typing indicator keepalive.
+type discordTyping struct {
+	cancel context.CancelFunc
+}
+
+// startTyping shows the typing indicator in chatID until the reply is sent,
+// the channel stops or typing_timeout elapses, whichever comes first. It
+// replaces any indicator already running for chatID.
 func (c *DiscordChannel) startTyping(chatID string) {
+	timeout := time.Duration(c.config.TypingTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(c.getContext(), timeout)
+	t := &discordTyping{cancel: cancel}
+
 	c.typingMu.Lock()
-	// Stop existing loop for this chatID if any
-	if stop, ok := c.typingStop[chatID]; ok {
-		close(stop)
+	if prev, ok := c.typing[chatID]; ok {
+		prev.cancel()
 	}
-	stop := make(chan struct{})
-	c.typingStop[chatID] = stop
+	c.typing[chatID] = t
 	c.typingMu.Unlock()
 
 	go func() {
-		if err := c.session.ChannelTyping(chatID); err != nil {
-			logger.DebugCF("discord", "ChannelTyping error", map[string]interface{}{"chatID": chatID, "err": err})
-		}
-		ticker := time.NewTicker(8 * time.Second)
+		defer func() {
+			cancel()
+			c.typingMu.Lock()
+			if c.typing[chatID] == t {
+				delete(c.typing, chatID)
+			}
+			c.typingMu.Unlock()
+		}()
+
+		ticker := time.NewTicker(discordTypingInterval)
 		defer ticker.Stop()
-		timeout := time.After(5 * time.Minute)
 		for {
+			if err := c.session.ChannelTyping(chatID, discordgo.WithContext(ctx)); err != nil && ctx.Err() == nil {
+				logger.DebugCF("discord", "ChannelTyping error", map[string]interface{}{"chatID": chatID, "err": err})
+			}
 			select {
-			case <-stop:
-				return
-			case <-timeout:
-				return
-			case <-c.ctx.Done():
+			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				if err := c.session.ChannelTyping(chatID); err != nil {
-					logger.DebugCF("discord", "ChannelTyping error", map[string]interface{}{"chatID": chatID, "err": err})
-				}
 			}
 		}
 	}()
@@ -347,9 +605,9 @@ func (c *DiscordChannel) startTyping(chatID string) {
 func (c *DiscordChannel) stopTyping(chatID string) {
 	c.typingMu.Lock()
 	defer c.typingMu.Unlock()
-	if stop, ok := c.typingStop[chatID]; ok {
-		close(stop)
-		delete(c.typingStop, chatID)
+	if t, ok := c.typing[chatID]; ok {
+		t.cancel()
+		delete(c.typing, chatID)
 	}
 }
 
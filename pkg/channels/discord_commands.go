@@ -0,0 +1,139 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/sipeed/picoclaw/pkg/commands"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// discordMaxCommands is the most global slash commands an app can have.
+const discordMaxCommands = 100
+
+var discordOptionTypes = map[string]discordgo.ApplicationCommandOptionType{
+	commands.TypeString:  discordgo.ApplicationCommandOptionString,
+	commands.TypeInteger: discordgo.ApplicationCommandOptionInteger,
+	commands.TypeNumber:  discordgo.ApplicationCommandOptionNumber,
+	commands.TypeBoolean: discordgo.ApplicationCommandOptionBoolean,
+}
+
+// SetCommands registers the agent's commands as global slash commands,
+// replacing the app's previous ones. Discord can take a while to show
+// changes in clients.
+func (c *DiscordChannel) SetCommands(ctx context.Context, cmds []commands.Command) error {
+	if c.botUserID == "" {
+		return fmt.Errorf("discord bot user not known yet")
+	}
+	if len(cmds) > discordMaxCommands {
+		cmds = cmds[:discordMaxCommands]
+	}
+	c.private.setCommands(cmds)
+	appCmds := discordSlashCommands(cmds)
+	if c.config.Summarize {
+		// Message commands have a limit of their own
+		appCmds = append(appCmds, &discordgo.ApplicationCommand{
+			Name: discordSummarizeMenu,
+			Type: discordgo.MessageApplicationCommand,
+		})
+	}
+	_, err := c.session.ApplicationCommandBulkOverwrite(c.botUserID, "", appCmds, discordgo.WithContext(ctx))
+	return err
+}
+
+func discordSlashCommands(cmds []commands.Command) []*discordgo.ApplicationCommand {
+	out := make([]*discordgo.ApplicationCommand, 0, len(cmds))
+	for _, cmd := range cmds {
+		ac := &discordgo.ApplicationCommand{
+			Name:        cmd.Name,
+			Description: cmd.Description,
+		}
+		for _, opt := range cmd.Options {
+			ac.Options = append(ac.Options, &discordgo.ApplicationCommandOption{
+				Type:        discordOptionTypes[opt.Type],
+				Name:        opt.Name,
+				Description: opt.Description,
+				Required:    opt.Required,
+			})
+		}
+		out = append(out, ac)
+	}
+	return out
+}
+
+// handleSlashCommand turns a slash command into a message for the agent,
+// written as it would be typed: "/weather Berlin" for a command with one
+// option, "/web_search query=golang count=3" for more.
+func (c *DiscordChannel) handleSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID, username := "", ""
+	if i.Member != nil && i.Member.User != nil {
+		userID, username = i.Member.User.ID, i.Member.User.Username
+	} else if i.User != nil {
+		userID, username = i.User.ID, i.User.Username
+	}
+	if !c.IsAllowed(userID) {
+		c.respondEphemeral(i.Interaction, "You're not allowed to use this bot.")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	content := discordCommandText(data)
+
+	// Interactions must be answered within 3 seconds; show the command so
+	// the reply that follows has context, or keep it between the bot and
+	// the user for private commands
+	var err error
+	private := c.private.isPrivate(data.Name)
+	if private {
+		err = c.deferPrivate(i.Interaction, userID)
+	} else {
+		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "> " + content},
+		})
+	}
+	if err != nil {
+		logger.WarnCF("discord", "Failed to respond to slash command", map[string]any{
+			"error": err.Error(),
+		})
+	}
+
+	peerKind, peerID := "channel", i.ChannelID
+	if i.GuildID == "" {
+		peerKind, peerID = "direct", userID
+	}
+	metadata := map[string]string{
+		"user_id":    userID,
+		"username":   username,
+		"guild_id":   i.GuildID,
+		"channel_id": i.ChannelID,
+		"is_dm":      fmt.Sprintf("%t", i.GuildID == ""),
+		"peer_kind":  peerKind,
+		"peer_id":    peerID,
+	}
+	c.setPersona(metadata, i.GuildID, i.ChannelID)
+
+	if !private {
+		c.startTyping(i.ChannelID)
+	}
+	c.HandleMessage(userID, i.ChannelID, content, nil, metadata)
+}
+
+func discordCommandText(data discordgo.ApplicationCommandInteractionData) string {
+	parts := []string{"/" + data.Name}
+	for _, opt := range data.Options {
+		value := fmt.Sprint(opt.Value)
+		if len(data.Options) == 1 {
+			parts = append(parts, value)
+			continue
+		}
+		if strings.ContainsAny(value, " \t\n\"") {
+			value = fmt.Sprintf("%q", value)
+		}
+		parts = append(parts, opt.Name+"="+value)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,199 @@
+package channels
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// discordConfirmPrefix marks the custom IDs of confirmation buttons:
+// "confirm:<id>:yes" or "confirm:<id>:no".
+const discordConfirmPrefix = "confirm:"
+
+// discordConfirmPromptMax leaves room in Discord's 2000 character limit
+// for the status line added once the prompt is answered.
+const discordConfirmPromptMax = 1800
+
+// discordConfirm is a confirmation prompt waiting for its button click.
+type discordConfirm struct {
+	userID string
+	prompt string
+	answer chan bool
+}
+
+// Confirm sends prompt with Confirm/Cancel buttons and waits until senderID
+// clicks one or ctx ends. Clicks by other users are refused; without a
+// sender (scheduled turns) any allowed user may answer.
+func (c *DiscordChannel) Confirm(ctx context.Context, chatID, senderID, prompt string) (bool, error) {
+	if !c.IsRunning() {
+		return false, fmt.Errorf("discord bot not running")
+	}
+
+	id, err := newConfirmID()
+	if err != nil {
+		return false, err
+	}
+	pending := &discordConfirm{
+		userID: senderID,
+		prompt: truncateRunes(prompt, discordConfirmPromptMax),
+		answer: make(chan bool, 1),
+	}
+
+	c.confirmMu.Lock()
+	c.confirms[id] = pending
+	c.confirmMu.Unlock()
+	defer func() {
+		c.confirmMu.Lock()
+		delete(c.confirms, id)
+		c.confirmMu.Unlock()
+	}()
+
+	msg, err := c.session.ChannelMessageSendComplex(chatID, &discordgo.MessageSend{
+		Content:    pending.prompt,
+		Components: confirmButtons(id),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to send confirmation: %w", err)
+	}
+
+	select {
+	case ok := <-pending.answer:
+		return ok, nil
+	case <-ctx.Done():
+		// Nobody answered: take the buttons away so a late click can't
+		// look like it did something
+		edit := discordgo.NewMessageEdit(chatID, msg.ID).SetContent(pending.prompt + "\n\n⌛ Timed out, not run.")
+		edit.Components = &[]discordgo.MessageComponent{}
+		if _, err := c.session.ChannelMessageEditComplex(edit); err != nil {
+			logger.WarnCF("discord", "Failed to close confirmation", map[string]any{
+				"message_id": msg.ID,
+				"error":      err.Error(),
+			})
+		}
+		return false, ctx.Err()
+	}
+}
+
+// handleInteraction answers clicks on confirmation buttons, slash
+// commands and the summarize context-menu command.
+func (c *DiscordChannel) handleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i == nil || i.Interaction == nil {
+		return
+	}
+	if i.Type == discordgo.InteractionApplicationCommand {
+		if i.ApplicationCommandData().CommandType == discordgo.MessageApplicationCommand {
+			c.handleSummarizeMenu(i)
+			return
+		}
+		c.handleSlashCommand(s, i)
+		return
+	}
+	if i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+	id, approved, ok := parseConfirmID(i.MessageComponentData().CustomID)
+	if !ok {
+		return
+	}
+
+	userID := ""
+	if i.Member != nil && i.Member.User != nil {
+		userID = i.Member.User.ID
+	} else if i.User != nil {
+		userID = i.User.ID
+	}
+
+	c.confirmMu.Lock()
+	pending := c.confirms[id]
+	c.confirmMu.Unlock()
+
+	switch {
+	case pending == nil:
+		c.respondEphemeral(i.Interaction, "This confirmation has expired.")
+		return
+	case !c.IsAllowed(userID) || (pending.userID != "" && userID != pending.userID):
+		c.respondEphemeral(i.Interaction, "Only the person who asked can answer this.")
+		return
+	}
+
+	status := "❌ Cancelled."
+	if approved {
+		status = "✅ Confirmed."
+	}
+	select {
+	case pending.answer <- approved:
+	default:
+		// Already answered by a double click
+		return
+	}
+
+	logger.InfoCF("discord", "Confirmation answered", map[string]any{
+		"user_id":  userID,
+		"approved": approved,
+	})
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    pending.prompt + "\n\n" + status,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		logger.WarnCF("discord", "Failed to update confirmation", map[string]any{
+			"error": err.Error(),
+		})
+	}
+}
+
+func (c *DiscordChannel) respondEphemeral(i *discordgo.Interaction, content string) {
+	err := c.session.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		logger.WarnCF("discord", "Failed to respond to interaction", map[string]any{
+			"error": err.Error(),
+		})
+	}
+}
+
+func confirmButtons(id string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Confirm", Style: discordgo.DangerButton, CustomID: discordConfirmPrefix + id + ":yes"},
+				discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: discordConfirmPrefix + id + ":no"},
+			},
+		},
+	}
+}
+
+// parseConfirmID splits a confirmation button's custom ID.
+func parseConfirmID(customID string) (id string, approved bool, ok bool) {
+	rest, found := strings.CutPrefix(customID, discordConfirmPrefix)
+	if !found {
+		return "", false, false
+	}
+	id, answer, found := strings.Cut(rest, ":")
+	if !found || id == "" || (answer != "yes" && answer != "no") {
+		return "", false, false
+	}
+	return id, answer == "yes", true
+}
+
+func newConfirmID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate confirmation id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,247 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sipeed/picoclaw/pkg/bookmarks"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/markdown"
+)
+
+// discordDigestInterval is how often the moderator digest schedule is
+// checked.
+const discordDigestInterval = 5 * time.Minute
+
+// discordDigestFocus steers the summary of a moderator digest.
+const discordDigestFocus = `the transcript covers several channels, each starting with a "## #channel" line. Group topics by channel, and flag anything moderators should look at: conflicts, rule-breaking, spam, reports and questions nobody answered.`
+
+// Summarizer summarizes a chat transcript whose lines are
+// "[date time] name: text"; focus, if set, is an extra instruction.
+type Summarizer func(ctx context.Context, transcript, focus string) (string, error)
+
+// SetSummarizer lets the channel summarize on its own schedule, for the
+// moderator digests.
+func (c *DiscordChannel) SetSummarizer(summarize Summarizer) {
+	c.summarizer = summarize
+}
+
+// startDigests begins checking the moderator digest schedule until the
+// channel stops.
+func (c *DiscordChannel) startDigests(ctx context.Context) {
+	if len(c.config.Digests) == 0 {
+		return
+	}
+	if c.summarizer == nil {
+		logger.WarnC("discord", "Moderator digests are configured but no summarizer is set")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(discordDigestInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.maybeSendDigests(ctx, time.Now())
+			}
+		}
+	}()
+	logger.InfoCF("discord", "Moderator digests scheduled", map[string]any{
+		"guilds": len(c.config.Digests),
+	})
+}
+
+func (c *DiscordChannel) maybeSendDigests(ctx context.Context, now time.Time) {
+	for _, d := range c.config.Digests {
+		period := digestPeriod(d, now)
+		if period == "" || d.GuildID == "" || len(d.Moderators) == 0 {
+			continue
+		}
+		key := fmt.Sprintf("scheduler:discord_digest:%s:%s", c.Name(), d.GuildID)
+		if c.lastDigest(key) == period {
+			continue
+		}
+		c.saveLastDigest(key, period)
+		c.sendDigest(ctx, d, now)
+	}
+}
+
+// digestPeriod returns the day ("2006-01-02") or ISO week ("2026-W42") a
+// digest is due in at now, or "" if it isn't due.
+func digestPeriod(d config.DiscordDigestConfig, now time.Time) string {
+	hour := d.Hour
+	if hour < 0 || hour > 23 {
+		hour = 9
+	}
+	if now.Hour() != hour {
+		return ""
+	}
+	if strings.EqualFold(d.Frequency, "weekly") {
+		if now.Weekday() != bookmarks.ParseWeekday(d.Day) {
+			return ""
+		}
+		year, week := now.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return now.Format("2006-01-02")
+}
+
+// sendDigest summarizes the past day or week of a guild's channels and
+// DMs it to the moderators.
+func (c *DiscordChannel) sendDigest(ctx context.Context, d config.DiscordDigestConfig, now time.Time) {
+	since, label := now.Add(-24*time.Hour), "Daily"
+	if strings.EqualFold(d.Frequency, "weekly") {
+		since, label = now.Add(-7*24*time.Hour), "Weekly"
+	}
+	limit := d.MaxMessages
+	if limit <= 0 {
+		limit = c.summarizeLimit()
+	}
+
+	var sections, names []string
+	total := 0
+	for _, channelID := range c.digestChannels(d) {
+		msgs, err := c.messagesSince(channelID, since, limit)
+		if err != nil {
+			logger.DebugCF("discord", "Failed to fetch messages for digest", map[string]any{
+				"channel_id": channelID,
+				"error":      err.Error(),
+			})
+			continue
+		}
+		msgs = c.withoutOwnMessages(msgs)
+		transcript := discordTranscript(msgs)
+		if transcript == "" {
+			continue
+		}
+		name := c.channelName(channelID)
+		names = append(names, "#"+name)
+		sections = append(sections, fmt.Sprintf("## #%s\n%s", name, transcript))
+		total += len(msgs)
+	}
+	if total == 0 {
+		logger.InfoCF("discord", "No activity for moderator digest", map[string]any{
+			"guild_id": d.GuildID,
+		})
+		return
+	}
+
+	summary, err := c.summarizer(ctx, strings.Join(sections, "\n"), discordDigestFocus)
+	if err != nil {
+		logger.WarnCF("discord", "Failed to summarize moderator digest", map[string]any{
+			"guild_id": d.GuildID,
+			"error":    err.Error(),
+		})
+		return
+	}
+	content := fmt.Sprintf("**%s digest for %s** (%d messages in %s)\n\n%s",
+		label, c.guildName(d.GuildID), total, strings.Join(names, ", "), summary)
+
+	sent := 0
+	for _, userID := range d.Moderators {
+		dm, err := c.session.UserChannelCreate(userID)
+		if err != nil {
+			logger.WarnCF("discord", "Failed to open DM for moderator digest", map[string]any{
+				"user_id": userID,
+				"error":   err.Error(),
+			})
+			continue
+		}
+		for _, chunk := range markdown.Split(content, markdown.Discord, discordChunkLen) {
+			if err := c.sendChunk(ctx, dm.ID, chunk); err != nil {
+				logger.WarnCF("discord", "Failed to send moderator digest", map[string]any{
+					"user_id": userID,
+					"error":   err.Error(),
+				})
+				break
+			}
+		}
+		sent++
+	}
+	logger.InfoCF("discord", "Sent moderator digest", map[string]any{
+		"guild_id":   d.GuildID,
+		"messages":   total,
+		"moderators": sent,
+	})
+}
+
+// digestChannels returns the channels a digest covers: the configured
+// ones, or else every text channel of the guild.
+func (c *DiscordChannel) digestChannels(d config.DiscordDigestConfig) []string {
+	if len(d.Channels) > 0 {
+		return d.Channels
+	}
+	var channels []*discordgo.Channel
+	if c.session.State != nil {
+		if g, err := c.session.State.Guild(d.GuildID); err == nil {
+			channels = g.Channels
+		}
+	}
+	if channels == nil {
+		var err error
+		if channels, err = c.session.GuildChannels(d.GuildID); err != nil {
+			logger.WarnCF("discord", "Failed to list guild channels for digest", map[string]any{
+				"guild_id": d.GuildID,
+				"error":    err.Error(),
+			})
+			return nil
+		}
+	}
+	var ids []string
+	for _, ch := range channels {
+		if ch.Type == discordgo.ChannelTypeGuildText || ch.Type == discordgo.ChannelTypeGuildNews {
+			ids = append(ids, ch.ID)
+		}
+	}
+	return ids
+}
+
+// withoutOwnMessages drops the bot's own messages from msgs.
+func (c *DiscordChannel) withoutOwnMessages(msgs []*discordgo.Message) []*discordgo.Message {
+	out := msgs[:0]
+	for _, m := range msgs {
+		if m.Author != nil && m.Author.ID == c.botUserID {
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// guildName returns a guild's name, or its ID if it isn't known.
+func (c *DiscordChannel) guildName(guildID string) string {
+	if c.session.State != nil {
+		if g, err := c.session.State.Guild(guildID); err == nil && g.Name != "" {
+			return g.Name
+		}
+	}
+	return guildID
+}
+
+func (c *DiscordChannel) lastDigest(key string) string {
+	if c.store != nil {
+		if data, ok, err := c.store.Get(key); err == nil && ok {
+			return string(data)
+		}
+	}
+	c.digestMu.Lock()
+	defer c.digestMu.Unlock()
+	return c.digestSent[key]
+}
+
+func (c *DiscordChannel) saveLastDigest(key, period string) {
+	if c.store != nil {
+		if err := c.store.Set(key, []byte(period), 0); err == nil {
+			return
+		}
+	}
+	c.digestMu.Lock()
+	defer c.digestMu.Unlock()
+	c.digestSent[key] = period
+}
@@ -0,0 +1,81 @@
+package channels
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	// discordCustomEmojiRe matches a custom emoji as Discord sends it,
+	// <:name:id>, or <a:name:id> when animated.
+	discordCustomEmojiRe = regexp.MustCompile(`<a?:(\w{2,32}):\d+>`)
+	// discordEmojiNameRe matches :name: in a reply, along with the parts
+	// that make it a custom emoji already, so those can be left alone.
+	discordEmojiNameRe = regexp.MustCompile(`(<a?)?:(\w{2,32}):(\d+>)?`)
+	// discordEmojiTokenRe matches the readable token inbound emoji are
+	// turned into, which the agent may echo back.
+	discordEmojiTokenRe = regexp.MustCompile(`\[emoji: (\w{2,32})\]`)
+)
+
+// discordReadableEmoji replaces custom emoji in a message with readable
+// tokens such as "[emoji: partyparrot]", and adds one for each sticker.
+func discordReadableEmoji(content string, stickers []*discordgo.StickerItem) string {
+	content = discordCustomEmojiRe.ReplaceAllString(content, "[emoji: $1]")
+	for _, s := range stickers {
+		if s != nil && s.Name != "" {
+			content = appendContent(content, fmt.Sprintf("[sticker: %s]", s.Name))
+		}
+	}
+	return content
+}
+
+// discordGuildEmoji turns :name: and [emoji: name] in a reply into the
+// guild's custom emoji of that name, so the agent can answer with the emoji
+// it sees users post. Names the guild doesn't have are left as typed.
+func discordGuildEmoji(content string, emojis []*discordgo.Emoji) string {
+	if len(emojis) == 0 || !strings.Contains(content, ":") {
+		return content
+	}
+	byName := make(map[string]*discordgo.Emoji, len(emojis))
+	for _, e := range emojis {
+		if e != nil && e.ID != "" {
+			byName[e.Name] = e
+		}
+	}
+	content = discordEmojiTokenRe.ReplaceAllStringFunc(content, func(m string) string {
+		if e, ok := byName[discordEmojiTokenRe.FindStringSubmatch(m)[1]]; ok {
+			return e.MessageFormat()
+		}
+		return m
+	})
+	return discordEmojiNameRe.ReplaceAllStringFunc(content, func(m string) string {
+		sub := discordEmojiNameRe.FindStringSubmatch(m)
+		if sub[1] != "" || sub[3] != "" {
+			return m
+		}
+		if e, ok := byName[sub[2]]; ok {
+			return e.MessageFormat()
+		}
+		return m
+	})
+}
+
+// guildEmojis returns the custom emoji of the guild channelID belongs to,
+// from the session state; none for DMs or channels not cached yet.
+func (c *DiscordChannel) guildEmojis(channelID string) []*discordgo.Emoji {
+	if c.session.State == nil {
+		return nil
+	}
+	ch, err := c.session.State.Channel(channelID)
+	if err != nil || ch.GuildID == "" {
+		return nil
+	}
+	guild, err := c.session.State.Guild(ch.GuildID)
+	if err != nil {
+		return nil
+	}
+	return guild.Emojis
+}
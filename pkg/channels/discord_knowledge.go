@@ -0,0 +1,279 @@
+package channels
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sipeed/picoclaw/pkg/knowledge"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// discordFAQHistory is how many recent messages of an FAQ channel are
+// indexed when the bot joins a guild.
+const discordFAQHistory = 100
+
+// discordKnowledgeSourceChars caps the text of each source passed to the
+// agent.
+const discordKnowledgeSourceChars = 1000
+
+// SetKnowledge turns on the Q&A knowledge base: the guild's pinned
+// messages and FAQ channels are indexed into idx, and questions they
+// answer get the matching sources.
+func (c *DiscordChannel) SetKnowledge(idx *knowledge.Index) {
+	c.knowledge = idx
+}
+
+// isFAQChannel reports whether a channel is one of the configured FAQ
+// channels.
+func (c *DiscordChannel) isFAQChannel(channelID string) bool {
+	return slices.Contains(c.config.Knowledge.FAQChannels, channelID)
+}
+
+// handleGuildCreate indexes a guild's pins and FAQ channels when the bot
+// joins it or reconnects.
+func (c *DiscordChannel) handleGuildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	if g == nil || g.Guild == nil || g.Unavailable {
+		return
+	}
+	go func() {
+		for _, ch := range g.Channels {
+			if ch.Type != discordgo.ChannelTypeGuildText && ch.Type != discordgo.ChannelTypeGuildNews {
+				continue
+			}
+			c.indexPins(g.ID, ch.ID)
+			if c.isFAQChannel(ch.ID) {
+				c.indexFAQHistory(g.ID, ch.ID)
+			}
+		}
+		logger.InfoCF("discord", "Indexed guild knowledge", map[string]any{
+			"guild_id": g.ID,
+			"docs":     c.knowledge.Len(g.ID),
+		})
+	}()
+}
+
+// handlePinsUpdate re-indexes a channel's pins after one was added or
+// removed.
+func (c *DiscordChannel) handlePinsUpdate(s *discordgo.Session, p *discordgo.ChannelPinsUpdate) {
+	if p == nil || p.GuildID == "" {
+		return
+	}
+	go c.indexPins(p.GuildID, p.ChannelID)
+}
+
+// handleMessageUpdate keeps edited FAQ, pinned and observed messages
+// current.
+func (c *DiscordChannel) handleMessageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	if m == nil || m.Message == nil || m.Author == nil || m.GuildID == "" {
+		return
+	}
+	if c.isFAQChannel(m.ChannelID) {
+		c.putKnowledge(knowledge.KindFAQ, m.Message)
+	}
+	if m.Pinned {
+		c.putKnowledge(knowledge.KindPinned, m.Message)
+	}
+	if c.observes(m.GuildID, m.ChannelID) {
+		c.putKnowledge(knowledge.KindObserved, m.Message)
+	}
+}
+
+// handleMessageDelete drops a deleted message from the index.
+func (c *DiscordChannel) handleMessageDelete(s *discordgo.Session, m *discordgo.MessageDelete) {
+	if m == nil || m.Message == nil {
+		return
+	}
+	for _, kind := range []string{knowledge.KindFAQ, knowledge.KindPinned, knowledge.KindObserved} {
+		if err := c.knowledge.Delete(kind + ":" + m.ID); err != nil {
+			logger.WarnCF("discord", "Failed to update knowledge index", map[string]any{"error": err.Error()})
+		}
+	}
+}
+
+// indexPins replaces the indexed pins of a channel with its current ones.
+func (c *DiscordChannel) indexPins(guildID, channelID string) {
+	pins, err := c.session.ChannelMessagesPinned(channelID)
+	if err != nil {
+		logger.DebugCF("discord", "Failed to fetch pinned messages", map[string]any{
+			"channel_id": channelID,
+			"error":      err.Error(),
+		})
+		return
+	}
+	var docs []knowledge.Doc
+	for _, m := range pins {
+		m.GuildID = guildID
+		if d, ok := c.knowledgeDoc(knowledge.KindPinned, m); ok {
+			docs = append(docs, d)
+		}
+	}
+	if err := c.knowledge.Replace(channelID, knowledge.KindPinned, docs); err != nil {
+		logger.WarnCF("discord", "Failed to update knowledge index", map[string]any{"error": err.Error()})
+	}
+}
+
+// indexFAQHistory indexes the recent messages of an FAQ channel.
+func (c *DiscordChannel) indexFAQHistory(guildID, channelID string) {
+	msgs, err := c.session.ChannelMessages(channelID, discordFAQHistory, "", "", "")
+	if err != nil {
+		logger.DebugCF("discord", "Failed to fetch FAQ channel history", map[string]any{
+			"channel_id": channelID,
+			"error":      err.Error(),
+		})
+		return
+	}
+	var docs []knowledge.Doc
+	for _, m := range msgs {
+		m.GuildID = guildID
+		if d, ok := c.knowledgeDoc(knowledge.KindFAQ, m); ok {
+			docs = append(docs, d)
+		}
+	}
+	if err := c.knowledge.Replace(channelID, knowledge.KindFAQ, docs); err != nil {
+		logger.WarnCF("discord", "Failed to update knowledge index", map[string]any{"error": err.Error()})
+	}
+}
+
+// indexFAQMessage indexes a new message posted in an FAQ channel.
+func (c *DiscordChannel) indexFAQMessage(m *discordgo.Message) {
+	if c.knowledge == nil || m.GuildID == "" || !c.isFAQChannel(m.ChannelID) {
+		return
+	}
+	c.putKnowledge(knowledge.KindFAQ, m)
+}
+
+func (c *DiscordChannel) putKnowledge(kind string, m *discordgo.Message) {
+	d, ok := c.knowledgeDoc(kind, m)
+	if !ok {
+		return
+	}
+	if err := c.knowledge.Put(d); err != nil {
+		logger.WarnCF("discord", "Failed to update knowledge index", map[string]any{"error": err.Error()})
+	}
+}
+
+// knowledgeDoc turns a message into an index doc; messages without text
+// are left out.
+func (c *DiscordChannel) knowledgeDoc(kind string, m *discordgo.Message) (knowledge.Doc, bool) {
+	text := strings.TrimSpace(discordMessageText(m))
+	if text == "" {
+		return knowledge.Doc{}, false
+	}
+	d := knowledge.Doc{
+		ID:        kind + ":" + m.ID,
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		Channel:   c.channelName(m.ChannelID),
+		Kind:      kind,
+		Text:      text,
+		Link:      fmt.Sprintf("https://discord.com/channels/%s/%s/%s", m.GuildID, m.ChannelID, m.ID),
+		Public:    c.everyoneCanView(m.ChannelID),
+	}
+	if m.Author != nil {
+		d.Author = m.Author.Username
+	}
+	return d, true
+}
+
+// everyoneCanView reports whether every member of the guild can read a
+// channel, going by the @everyone role and its overwrites in the channel
+// (or a public thread's parent). Channels not in the session state count
+// as private.
+func (c *DiscordChannel) everyoneCanView(channelID string) bool {
+	if c.session.State == nil {
+		return false
+	}
+	ch, err := c.session.State.Channel(channelID)
+	if err != nil || ch.GuildID == "" || ch.Type == discordgo.ChannelTypeGuildPrivateThread {
+		return false
+	}
+	if ch.IsThread() {
+		if ch, err = c.session.State.Channel(ch.ParentID); err != nil {
+			return false
+		}
+	}
+	everyone, err := c.session.State.Role(ch.GuildID, ch.GuildID)
+	if err != nil {
+		return false
+	}
+	perms := everyone.Permissions
+	if perms&discordgo.PermissionAdministrator != 0 {
+		return true
+	}
+	for _, o := range ch.PermissionOverwrites {
+		if o.Type == discordgo.PermissionOverwriteTypeRole && o.ID == ch.GuildID {
+			perms = perms&^o.Deny | o.Allow
+		}
+	}
+	return perms&discordgo.PermissionViewChannel != 0
+}
+
+// knowledgeMatches returns the indexed sources that answer a message in a
+// guild channel with at least the configured confidence, one per message.
+// Sources from private channels only answer questions asked in them.
+func (c *DiscordChannel) knowledgeMatches(guildID, channelID, content string) []knowledge.Result {
+	if c.knowledge == nil || guildID == "" {
+		return nil
+	}
+	cfg := c.config.Knowledge
+	if cfg.MinConfidence <= 0 {
+		cfg.MinConfidence = 0.6
+	}
+	if cfg.MaxSources <= 0 {
+		cfg.MaxSources = 3
+	}
+	results := c.knowledge.Search(guildID, channelID, content, cfg.MinConfidence, cfg.MaxSources*2)
+	var matches []knowledge.Result
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if seen[r.Doc.Link] {
+			continue
+		}
+		seen[r.Doc.Link] = true
+		matches = append(matches, r)
+		if len(matches) == cfg.MaxSources {
+			break
+		}
+	}
+	return matches
+}
+
+// knowledgeContext lists matched sources for the agent, asking it to
+// answer from them and cite them.
+func knowledgeContext(matches []knowledge.Result) string {
+	if len(matches) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("[From the server's knowledge base. If these sources answer the question, answer from them and cite each one you use by its link.")
+	for i, r := range matches {
+		where := "Posted in"
+		if r.Doc.Kind == knowledge.KindPinned {
+			where = "Pinned in"
+		}
+		fmt.Fprintf(&sb, "\n%d. %s #%s", i+1, where, r.Doc.Channel)
+		if r.Doc.Author != "" {
+			fmt.Fprintf(&sb, " by %s", r.Doc.Author)
+		}
+		fmt.Fprintf(&sb, " (%s): %s", r.Doc.Link, utils.Truncate(r.Doc.Text, discordKnowledgeSourceChars))
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// isQuestion reports whether a message reads like a question.
+func isQuestion(content string) bool {
+	content = strings.TrimSpace(strings.ToLower(content))
+	if strings.Contains(content, "?") {
+		return true
+	}
+	first, _, _ := strings.Cut(content, " ")
+	switch first {
+	case "how", "what", "where", "when", "why", "who", "which", "can", "does", "is", "are", "do":
+		return true
+	}
+	return false
+}
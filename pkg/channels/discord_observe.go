@@ -0,0 +1,139 @@
+package channels
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/knowledge"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// discordObservedDocs caps the messages of one observed channel kept in
+// the knowledge index; older ones stay in the log only.
+const discordObservedDocs = 500
+
+// observedMessage is one line of the observed channels' log.
+type observedMessage struct {
+	Time        time.Time `json:"time"`
+	GuildID     string    `json:"guild_id"`
+	ChannelID   string    `json:"channel_id"`
+	Channel     string    `json:"channel,omitempty"`
+	MessageID   string    `json:"message_id"`
+	AuthorID    string    `json:"author_id,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	Text        string    `json:"text"`
+	Attachments int       `json:"attachments,omitempty"`
+}
+
+// SetObserveLog sets the directory the messages of observed channels are
+// logged to, a JSON line each in <dir>/<bot>-<date>.jsonl.
+func (c *DiscordChannel) SetObserveLog(dir string) {
+	c.observeDir = dir
+}
+
+// observes reports whether the bot only reads a guild channel. Threads
+// follow their parent channel unless they have an entry of their own.
+func (c *DiscordChannel) observes(guildID, channelID string) bool {
+	if guildID == "" || !c.hasObserved() {
+		return false
+	}
+	if _, ok := c.config.Channels[channelID]; !ok {
+		if ch := c.lookupChannel(channelID); ch != nil && ch.IsThread() && ch.ParentID != "" {
+			channelID = ch.ParentID
+		}
+	}
+	return c.config.ModeFor(guildID, channelID) == config.DiscordModeObserve
+}
+
+// observedChat reports whether a chat the agent sends to is observed.
+func (c *DiscordChannel) observedChat(channelID string) bool {
+	if !c.hasObserved() {
+		return false
+	}
+	ch := c.lookupChannel(channelID)
+	return ch != nil && c.observes(ch.GuildID, channelID)
+}
+
+// hasObserved reports whether any guild or channel is observed, so the
+// others never pay for a channel lookup.
+func (c *DiscordChannel) hasObserved() bool {
+	for _, ch := range c.config.Channels {
+		if ch.Mode == config.DiscordModeObserve {
+			return true
+		}
+	}
+	return false
+}
+
+// observe logs a message of an observed channel and indexes it for the
+// knowledge base, without passing it to the agent.
+func (c *DiscordChannel) observe(m *discordgo.Message) {
+	text := strings.TrimSpace(discordReadableEmoji(discordMessageText(m), m.StickerItems))
+	if text == "" && len(m.Attachments) == 0 {
+		return
+	}
+	logger.DebugCF("discord", "Observed message", map[string]any{
+		"channel_id": m.ChannelID,
+		"preview":    utils.Truncate(text, 50),
+	})
+
+	entry := observedMessage{
+		Time:        m.Timestamp,
+		GuildID:     m.GuildID,
+		ChannelID:   m.ChannelID,
+		Channel:     c.channelName(m.ChannelID),
+		MessageID:   m.ID,
+		Text:        text,
+		Attachments: len(m.Attachments),
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if m.Author != nil {
+		entry.AuthorID = m.Author.ID
+		entry.Author = m.Author.Username
+	}
+	if err := c.logObserved(entry); err != nil {
+		logger.WarnCF("discord", "Failed to log observed message", map[string]any{"error": err.Error()})
+	}
+
+	if c.knowledge == nil {
+		return
+	}
+	c.putKnowledge(knowledge.KindObserved, m)
+	if err := c.knowledge.Trim(m.ChannelID, knowledge.KindObserved, discordObservedDocs); err != nil {
+		logger.WarnCF("discord", "Failed to update knowledge index", map[string]any{"error": err.Error()})
+	}
+}
+
+// logObserved appends entry to the day's log.
+func (c *DiscordChannel) logObserved(entry observedMessage) error {
+	if c.observeDir == "" {
+		return nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	c.observeMu.Lock()
+	defer c.observeMu.Unlock()
+	if err := os.MkdirAll(c.observeDir, 0o755); err != nil {
+		return err
+	}
+	name := c.Name() + "-" + time.Now().Format("2006-01-02") + ".jsonl"
+	f, err := os.OpenFile(filepath.Join(c.observeDir, name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
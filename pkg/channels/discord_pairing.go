@@ -0,0 +1,103 @@
+package channels
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// offerPairing answers a DM from a user not in allow_from with a pairing
+// code, once per code.
+func (c *DiscordChannel) offerPairing(m *discordgo.MessageCreate) {
+	if c.pairing == nil || m.GuildID != "" {
+		return
+	}
+	code, issued, err := c.pairing.Request(c.name, m.Author.ID, m.Author.Username)
+	if errors.Is(err, ErrTooManyPairingRequests) {
+		logger.WarnCF("discord", "Pairing request dropped, too many pending", map[string]any{
+			"user_id": m.Author.ID,
+		})
+		return
+	}
+	if err != nil {
+		logger.ErrorCF("discord", "Failed to issue pairing code", map[string]any{
+			"user_id": m.Author.ID,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if !issued {
+		return
+	}
+	logger.InfoCF("discord", "Issued pairing code", map[string]any{
+		"user_id":  m.Author.ID,
+		"username": m.Author.Username,
+	})
+	reply := fmt.Sprintf("Hi! I don't know you yet. To pair, ask my owner to run `picoclaw pair %s` or send me `!pair %s`. The code is valid for %d minutes.",
+		code, code, int(pairingCodeTTL.Minutes()))
+	if _, err := c.session.ChannelMessageSend(m.ChannelID, reply); err != nil {
+		logger.ErrorCF("discord", "Failed to send pairing code", map[string]any{
+			"user_id": m.Author.ID,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// SetAdmins sets the admin.users entries ("channel:id") allowed to
+// approve pairing codes with !pair and delete any reply with 🗑️.
+func (c *DiscordChannel) SetAdmins(users []string) {
+	c.admins = users
+}
+
+// isAdmin reports whether userID is listed in admin.users for this bot.
+func (c *DiscordChannel) isAdmin(userID string) bool {
+	for _, entry := range c.admins {
+		if strings.TrimSpace(entry) == c.name+":"+userID {
+			return true
+		}
+	}
+	return false
+}
+
+// handlePairCommand redeems "!pair <code>" sent by an admin, and reports
+// whether content was that command.
+func (c *DiscordChannel) handlePairCommand(m *discordgo.MessageCreate, content string) bool {
+	fields := strings.Fields(content)
+	if c.pairing == nil || len(fields) == 0 || !strings.EqualFold(fields[0], "!pair") {
+		return false
+	}
+	if !c.isAdmin(m.Author.ID) {
+		c.session.ChannelMessageSend(m.ChannelID, "Only admins can approve pairing codes.")
+		return true
+	}
+	if len(fields) != 2 {
+		c.session.ChannelMessageSend(m.ChannelID, "Usage: !pair <code>")
+		return true
+	}
+
+	req, err := c.pairing.Redeem(fields[1])
+	if err != nil {
+		c.session.ChannelMessageSend(m.ChannelID, "That pairing code is unknown or has expired.")
+		return true
+	}
+	logger.InfoCF("discord", "Paired user", map[string]any{
+		"user_id":     req.UserID,
+		"username":    req.Username,
+		"approved_by": m.Author.ID,
+	})
+	c.session.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Paired %s (%s).", req.Username, req.UserID))
+	c.notifyPaired(req.UserID)
+	return true
+}
+
+// notifyPaired tells a newly paired user they can start chatting.
+func (c *DiscordChannel) notifyPaired(userID string) {
+	dm, err := c.session.UserChannelCreate(userID)
+	if err != nil {
+		return
+	}
+	c.session.ChannelMessageSend(dm.ID, "You're paired! Send me a message any time.")
+}
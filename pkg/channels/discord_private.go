@@ -0,0 +1,123 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/commands"
+	"github.com/sipeed/picoclaw/pkg/markdown"
+)
+
+// discordInteractionTTL is how long Discord accepts follow-ups to an
+// interaction.
+const discordInteractionTTL = 15 * time.Minute
+
+// discordPrivate tracks slash commands answered only to their user.
+type discordPrivate struct {
+	mu       sync.Mutex
+	commands map[string]bool
+	// pending holds the interactions of private commands awaiting their
+	// reply, keyed by "channelID:userID".
+	pending map[string]discordPendingReply
+}
+
+type discordPendingReply struct {
+	interaction *discordgo.Interaction
+	created     time.Time
+}
+
+func (p *discordPrivate) setCommands(cmds []commands.Command) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.commands = make(map[string]bool)
+	for _, cmd := range cmds {
+		if cmd.Private {
+			p.commands[cmd.Name] = true
+		}
+	}
+}
+
+func (p *discordPrivate) isPrivate(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.commands[name]
+}
+
+func (p *discordPrivate) hold(channelID, userID string, i *discordgo.Interaction) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pending == nil {
+		p.pending = make(map[string]discordPendingReply)
+	}
+	for key, r := range p.pending {
+		if time.Since(r.created) > discordInteractionTTL {
+			delete(p.pending, key)
+		}
+	}
+	p.pending[channelID+":"+userID] = discordPendingReply{interaction: i, created: time.Now()}
+}
+
+// take returns the interaction a private reply answers, if it can still
+// be followed up.
+func (p *discordPrivate) take(channelID, userID string) *discordgo.Interaction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, ok := p.pending[channelID+":"+userID]
+	if !ok {
+		return nil
+	}
+	delete(p.pending, channelID+":"+userID)
+	if time.Since(r.created) > discordInteractionTTL {
+		return nil
+	}
+	return r.interaction
+}
+
+// deferPrivate acknowledges a private slash command with an ephemeral
+// "thinking" state, which the reply replaces.
+func (c *DiscordChannel) deferPrivate(i *discordgo.Interaction, userID string) error {
+	err := c.session.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err == nil {
+		c.private.hold(i.ChannelID, userID, i)
+	}
+	return err
+}
+
+// sendPrivate sends msg to msg.PrivateTo alone: as an ephemeral follow-up
+// to their slash command, or else by DM.
+func (c *DiscordChannel) sendPrivate(ctx context.Context, msg bus.OutboundMessage) error {
+	chunks := markdown.Split(msg.Content, markdown.Discord, discordChunkLen)
+	if i := c.private.take(msg.ChatID, msg.PrivateTo); i != nil {
+		return c.sendWithContext(ctx, func() error {
+			for _, chunk := range chunks {
+				_, err := c.session.FollowupMessageCreate(i, true, &discordgo.WebhookParams{
+					Content: chunk,
+					Flags:   discordgo.MessageFlagsEphemeral,
+				}, discordgo.WithContext(ctx))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	dm, err := c.session.UserChannelCreate(msg.PrivateTo, discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("opening DM with %s: %w", msg.PrivateTo, err)
+	}
+	for _, chunk := range chunks {
+		if err := c.sendChunk(ctx, dm.ID, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package channels
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// discordReactionDelete removes the reply on Discord only; the other
+// reaction actions are bus control events handled by the agent.
+const discordReactionDelete = "delete"
+
+// reactionAction maps a reaction emoji to its control action, or "" for
+// reactions that are not controls.
+func reactionAction(emoji string) string {
+	switch strings.TrimSuffix(emoji, "\uFE0F") {
+	case "🔁":
+		return bus.ControlRegenerate
+	case "🗑":
+		return discordReactionDelete
+	case "📌":
+		return bus.ControlPin
+	}
+	return ""
+}
+
+// handleReaction lets users react to the bot's replies: 🔁 regenerates the
+// reply if it's the chat's latest, 🗑️ deletes the reply, for the user it
+// answered and admins only, and 📌 pins it. Server admins reacting with
+// the memory emoji save any message to the guild's memory instead.
+func (c *DiscordChannel) handleReaction(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	if r == nil || r.MessageReaction == nil || r.UserID == c.botUserID {
+		return
+	}
+
+	if c.isMemoryEmoji(r.Emoji.Name) {
+		c.rememberMessage(r)
+		return
+	}
+	if !c.config.ReactionControls {
+		return
+	}
+
+	action := reactionAction(r.Emoji.Name)
+	if action == "" || !c.IsAllowed(r.UserID) {
+		return
+	}
+
+	msg := c.lookupMessage(r.ChannelID, r.MessageID)
+	if msg == nil || msg.Author == nil || msg.Author.ID != c.botUserID {
+		return
+	}
+
+	logger.InfoCF("discord", "Reaction control", map[string]any{
+		"action":     action,
+		"user_id":    r.UserID,
+		"channel_id": r.ChannelID,
+		"message_id": r.MessageID,
+	})
+
+	if action == discordReactionDelete {
+		if !c.mayDelete(r) {
+			logger.DebugCF("discord", "Delete reaction from someone else ignored", map[string]any{
+				"user_id":    r.UserID,
+				"message_id": r.MessageID,
+			})
+			return
+		}
+		if err := c.session.ChannelMessageDelete(r.ChannelID, r.MessageID); err != nil {
+			logger.WarnCF("discord", "Failed to delete message", map[string]any{
+				"message_id": r.MessageID,
+				"error":      err.Error(),
+			})
+		}
+		return
+	}
+
+	// Route the event like a message in the same chat, so it reaches the
+	// session the reply came from
+	peerKind := "channel"
+	peerID := r.ChannelID
+	metadata := map[string]string{
+		"message_id": r.MessageID,
+		"user_id":    r.UserID,
+		"guild_id":   r.GuildID,
+		"channel_id": r.ChannelID,
+		"is_dm":      fmt.Sprintf("%t", r.GuildID == ""),
+	}
+	if r.GuildID == "" {
+		peerKind = "direct"
+		peerID = r.UserID
+	} else if parent := c.botThreadParent(r.ChannelID); parent != "" {
+		peerID = parent
+		metadata["thread_id"] = r.ChannelID
+	}
+	metadata["peer_kind"] = peerKind
+	metadata["peer_id"] = peerID
+	c.setPersona(metadata, r.GuildID, peerID)
+
+	if action == bus.ControlRegenerate {
+		c.startTyping(r.ChannelID)
+	}
+	c.HandleControl(r.UserID, r.ChannelID, action, discordMessageText(msg), metadata)
+}
+
+// mayDelete reports whether the user who reacted may delete the reply:
+// in a DM, or as the user it answered, or as an admin (admin.users or the
+// server's managers).
+func (c *DiscordChannel) mayDelete(r *discordgo.MessageReactionAdd) bool {
+	if r.GuildID == "" || c.isAdmin(r.UserID) {
+		return true
+	}
+	if requester := c.replyRequester(r.ChannelID, r.MessageID); requester != "" && requester == r.UserID {
+		return true
+	}
+	return c.isServerAdmin(r.UserID, r.ChannelID)
+}
+
+// replyRequester returns who the bot's reply answered: the author of the
+// latest message before it that isn't the bot's, or "" if there is none
+// among the few before it.
+func (c *DiscordChannel) replyRequester(channelID, messageID string) string {
+	before, err := c.session.ChannelMessages(channelID, 10, messageID, "", "")
+	if err != nil {
+		logger.DebugCF("discord", "Failed to look up messages before reply", map[string]any{
+			"message_id": messageID,
+			"error":      err.Error(),
+		})
+		return ""
+	}
+	// Newest first
+	for _, m := range before {
+		if m.Author != nil && m.Author.ID != c.botUserID {
+			return m.Author.ID
+		}
+	}
+	return ""
+}
+
+// isMemoryEmoji reports whether emoji is the configured memory emoji.
+func (c *DiscordChannel) isMemoryEmoji(emoji string) bool {
+	want := strings.TrimSuffix(c.config.MemoryEmoji, "\uFE0F")
+	return want != "" && strings.TrimSuffix(emoji, "\uFE0F") == want
+}
+
+// rememberMessage saves a guild message to the guild's memory, with its
+// author and a link to it, when a server admin reacted to it.
+func (c *DiscordChannel) rememberMessage(r *discordgo.MessageReactionAdd) {
+	if r.GuildID == "" || !c.isServerAdmin(r.UserID, r.ChannelID) {
+		return
+	}
+
+	msg := c.lookupMessage(r.ChannelID, r.MessageID)
+	if msg == nil || msg.Author == nil {
+		return
+	}
+	text := strings.TrimSpace(discordMessageText(msg))
+	if text == "" {
+		return
+	}
+
+	logger.InfoCF("discord", "Saving message to guild memory", map[string]any{
+		"user_id":    r.UserID,
+		"guild_id":   r.GuildID,
+		"channel_id": r.ChannelID,
+		"message_id": r.MessageID,
+	})
+
+	peerID := r.ChannelID
+	metadata := map[string]string{
+		"message_id":   r.MessageID,
+		"user_id":      r.UserID,
+		"guild_id":     r.GuildID,
+		"channel_id":   r.ChannelID,
+		"is_dm":        "false",
+		"peer_kind":    "channel",
+		"author":       msg.Author.Username,
+		"author_id":    msg.Author.ID,
+		"channel_name": c.channelName(r.ChannelID),
+		"message_link": fmt.Sprintf("https://discord.com/channels/%s/%s/%s", r.GuildID, r.ChannelID, r.MessageID),
+		"saved_by":     c.displayName(r),
+	}
+	if parent := c.botThreadParent(r.ChannelID); parent != "" {
+		peerID = parent
+		metadata["thread_id"] = r.ChannelID
+	}
+	metadata["peer_id"] = peerID
+	c.setPersona(metadata, r.GuildID, peerID)
+
+	c.HandleControl(r.UserID, r.ChannelID, bus.ControlRemember, text, metadata)
+}
+
+// isServerAdmin reports whether the user can manage the server the
+// channel belongs to.
+func (c *DiscordChannel) isServerAdmin(userID, channelID string) bool {
+	perms, err := c.session.UserChannelPermissions(userID, channelID)
+	if err != nil {
+		logger.DebugCF("discord", "Failed to look up permissions", map[string]any{
+			"user_id":    userID,
+			"channel_id": channelID,
+			"error":      err.Error(),
+		})
+		return false
+	}
+	return perms&(discordgo.PermissionAdministrator|discordgo.PermissionManageGuild) != 0
+}
+
+// channelName returns the name of a channel, or its ID when it isn't known.
+func (c *DiscordChannel) channelName(channelID string) string {
+	if c.session.State != nil {
+		if ch, err := c.session.State.Channel(channelID); err == nil && ch.Name != "" {
+			return ch.Name
+		}
+	}
+	return channelID
+}
+
+// displayName returns the name of the user who reacted.
+func (c *DiscordChannel) displayName(r *discordgo.MessageReactionAdd) string {
+	if r.Member != nil && r.Member.User != nil {
+		if r.Member.Nick != "" {
+			return r.Member.Nick
+		}
+		return r.Member.User.Username
+	}
+	return r.UserID
+}
+
+// lookupMessage returns the message from the session state, falling back
+// to the REST API.
+func (c *DiscordChannel) lookupMessage(channelID, messageID string) *discordgo.Message {
+	if c.session.State != nil {
+		if m, err := c.session.State.Message(channelID, messageID); err == nil {
+			return m
+		}
+	}
+	m, err := c.session.ChannelMessage(channelID, messageID)
+	if err != nil {
+		logger.DebugCF("discord", "Failed to look up message", map[string]any{
+			"message_id": messageID,
+			"error":      err.Error(),
+		})
+		return nil
+	}
+	return m
+}
+
+// discordMessageText returns the text of a reply, which is in an embed
+// when the reply was sent as one.
+func discordMessageText(m *discordgo.Message) string {
+	if m.Content != "" {
+		return m.Content
+	}
+	var parts []string
+	for _, e := range m.Embeds {
+		if e.Title != "" {
+			parts = append(parts, e.Title)
+		}
+		if e.Description != "" {
+			parts = append(parts, e.Description)
+		}
+		for _, f := range e.Fields {
+			parts = append(parts, f.Name+": "+f.Value)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
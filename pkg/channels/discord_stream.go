@@ -0,0 +1,134 @@
+package channels
+
+import (
+	"context"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/markdown"
+)
+
+// discordStreamEditInterval is the minimum time between edits of a reply
+// being streamed, which keeps well inside Discord's rate limits.
+const discordStreamEditInterval = time.Second
+
+// discordStreamTTL is how long a streamed placeholder waits for its next
+// update; after that the next reply in the chat starts a new message.
+const discordStreamTTL = 5 * time.Minute
+
+// discordStreamCursor is shown at the end of a reply while it is streaming.
+const discordStreamCursor = " ▍"
+
+// discordStream is a placeholder message that is edited as a reply streams in.
+type discordStream struct {
+	messageID string
+	shown     string
+	updated   time.Time
+}
+
+// SendPartial shows a reply that is still being generated. The first
+// partial sends a placeholder message, later ones edit it at most once per
+// discordStreamEditInterval, and Send replaces it with the final reply.
+func (c *DiscordChannel) SendPartial(ctx context.Context, msg bus.OutboundMessage) error {
+	if !c.config.StreamReplies || !c.IsRunning() || msg.ChatID == "" {
+		return nil
+	}
+	preview := streamPreview(msg.Content)
+	if preview == "" {
+		return nil
+	}
+
+	c.streamMu.Lock()
+	stream := c.streams[msg.ChatID]
+	if stream != nil && time.Since(stream.updated) > discordStreamTTL {
+		stream = nil
+	}
+	c.streamMu.Unlock()
+
+	if stream == nil {
+		c.stopTyping(msg.ChatID)
+		var messageID string
+		err := c.sendWithContext(ctx, func() error {
+			m, err := c.session.ChannelMessageSend(msg.ChatID, preview)
+			if err == nil {
+				messageID = m.ID
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		c.streamMu.Lock()
+		c.streams[msg.ChatID] = &discordStream{messageID: messageID, shown: preview, updated: time.Now()}
+		c.streamMu.Unlock()
+		return nil
+	}
+
+	if preview == stream.shown || time.Since(stream.updated) < discordStreamEditInterval {
+		return nil
+	}
+	err := c.sendWithContext(ctx, func() error {
+		_, err := c.session.ChannelMessageEdit(msg.ChatID, stream.messageID, preview)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	c.streamMu.Lock()
+	stream.shown = preview
+	stream.updated = time.Now()
+	c.streamMu.Unlock()
+	return nil
+}
+
+// takeStream removes and returns the chat's streamed placeholder, if any.
+func (c *DiscordChannel) takeStream(chatID string) *discordStream {
+	c.streamMu.Lock()
+	defer c.streamMu.Unlock()
+
+	stream := c.streams[chatID]
+	delete(c.streams, chatID)
+	if stream != nil && time.Since(stream.updated) > discordStreamTTL {
+		return nil
+	}
+	return stream
+}
+
+// finishStream puts the final reply into the placeholder, sending any
+// text past Discord's length limit as further messages.
+func (c *DiscordChannel) finishStream(ctx context.Context, channelID string, stream *discordStream, content string) error {
+	chunks := markdown.Split(content, markdown.Discord, discordChunkLen)
+	err := c.sendWithContext(ctx, func() error {
+		_, err := c.session.ChannelMessageEdit(channelID, stream.messageID, chunks[0])
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks[1:] {
+		if err := c.sendChunk(ctx, channelID, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discardStream deletes a placeholder that the final reply will not reuse.
+func (c *DiscordChannel) discardStream(channelID string, stream *discordStream) {
+	if err := c.session.ChannelMessageDelete(channelID, stream.messageID); err != nil {
+		logger.DebugCF("discord", "Failed to delete streamed placeholder", map[string]any{
+			"message_id": stream.messageID,
+			"error":      err.Error(),
+		})
+	}
+}
+
+// streamPreview renders partial content for display, cut to fit one
+// Discord message.
+func streamPreview(content string) string {
+	if content == "" {
+		return ""
+	}
+	return truncateRunes(content, 2000-len([]rune(discordStreamCursor))) + discordStreamCursor
+}
@@ -0,0 +1,283 @@
+package channels
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const (
+	// discordSummarizeDefault is how many messages !summarize covers
+	// outside a thread when no count is given.
+	discordSummarizeDefault = 50
+	// discordSummarizeMax caps the messages of one summary when
+	// summarize_max isn't set.
+	discordSummarizeMax = 500
+	// discordSummarizeMenu is the name of the message context-menu command.
+	discordSummarizeMenu = "Summarize from here"
+)
+
+// parseSummarizeCommand parses "!summarize [count]". A count of 0 means
+// the default: the whole thread, or the latest messages elsewhere.
+func parseSummarizeCommand(content string) (count int, ok bool) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "!summarize") {
+		return 0, false
+	}
+	if len(fields) > 1 {
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 {
+			return -1, true
+		}
+		return n, true
+	}
+	return 0, true
+}
+
+// summarizeLimit returns the most messages a summary may cover.
+func (c *DiscordChannel) summarizeLimit() int {
+	if c.config.SummarizeMax > 0 {
+		return c.config.SummarizeMax
+	}
+	return discordSummarizeMax
+}
+
+// handleSummarizeCommand answers !summarize, whether or not the bot was
+// mentioned. Like messages, it counts against the rate limits. It reports
+// whether content was the command.
+func (c *DiscordChannel) handleSummarizeCommand(m *discordgo.MessageCreate, content string) bool {
+	if !c.config.Summarize {
+		return false
+	}
+	count, ok := parseSummarizeCommand(content)
+	if !ok {
+		return false
+	}
+	if count < 0 {
+		c.session.ChannelMessageSend(m.ChannelID, "Usage: !summarize [number of messages]")
+		return true
+	}
+	if c.rateLimited(m.Author.ID, m.ChannelID) {
+		return true
+	}
+
+	thread := count == 0 && c.isThread(m.ChannelID)
+	if count == 0 && !thread {
+		count = discordSummarizeDefault
+	}
+	if limit := c.summarizeLimit(); thread || count > limit {
+		count = limit
+	}
+
+	msgs, err := c.messagesBefore(m.ChannelID, m.ID, count)
+	if err != nil {
+		logger.WarnCF("discord", "Failed to fetch messages to summarize", map[string]any{
+			"channel_id": m.ChannelID,
+			"error":      err.Error(),
+		})
+		c.session.ChannelMessageSend(m.ChannelID, "Sorry, I couldn't read the messages here.")
+		return true
+	}
+	scope := fmt.Sprintf("the last %d messages", len(msgs))
+	if thread && len(msgs) < count {
+		scope = "the whole thread"
+	} else if thread {
+		scope += " of the thread"
+	}
+	c.requestSummary(m.Author.ID, m.GuildID, m.ChannelID, scope, msgs)
+	return true
+}
+
+// handleSummarizeMenu answers the "Summarize from here" context-menu
+// command: the target message and those after it are summarized.
+func (c *DiscordChannel) handleSummarizeMenu(i *discordgo.InteractionCreate) {
+	userID := ""
+	if i.Member != nil && i.Member.User != nil {
+		userID = i.Member.User.ID
+	} else if i.User != nil {
+		userID = i.User.ID
+	}
+	if !c.IsAllowed(userID) {
+		c.respondEphemeral(i.Interaction, "You're not allowed to use this bot.")
+		return
+	}
+	if c.rateLimited(userID, i.ChannelID) {
+		c.respondEphemeral(i.Interaction, "You're sending requests too fast; try again shortly.")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	msgs, err := c.messagesFrom(i.ChannelID, data.TargetID, c.summarizeLimit())
+	if err != nil || len(msgs) == 0 {
+		c.respondEphemeral(i.Interaction, "Sorry, I couldn't read the messages here.")
+		return
+	}
+	link := fmt.Sprintf("https://discord.com/channels/%s/%s/%s", i.GuildID, i.ChannelID, data.TargetID)
+	if i.GuildID == "" {
+		link = fmt.Sprintf("https://discord.com/channels/@me/%s/%s", i.ChannelID, data.TargetID)
+	}
+	err = c.session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: fmt.Sprintf("> Summarizing %d messages from %s", len(msgs), link)},
+	})
+	if err != nil {
+		logger.WarnCF("discord", "Failed to respond to context-menu command", map[string]any{
+			"error": err.Error(),
+		})
+	}
+	c.requestSummary(userID, i.GuildID, i.ChannelID, fmt.Sprintf("%d messages starting at %s", len(msgs), link), msgs)
+}
+
+// requestSummary asks the agent to summarize msgs, oldest first.
+func (c *DiscordChannel) requestSummary(userID, guildID, channelID, scope string, msgs []*discordgo.Message) {
+	transcript := discordTranscript(msgs)
+	if transcript == "" {
+		c.session.ChannelMessageSend(channelID, "There's nothing here to summarize.")
+		return
+	}
+
+	peerKind, peerID := "channel", channelID
+	if guildID == "" {
+		peerKind, peerID = "direct", userID
+	} else if parent := c.botThreadParent(channelID); parent != "" {
+		peerID = parent
+	}
+	metadata := map[string]string{
+		"user_id":       userID,
+		"guild_id":      guildID,
+		"channel_id":    channelID,
+		"is_dm":         fmt.Sprintf("%t", guildID == ""),
+		"peer_kind":     peerKind,
+		"peer_id":       peerID,
+		"summary_scope": scope,
+	}
+	c.setPersona(metadata, guildID, peerID)
+
+	c.startTyping(channelID)
+	c.HandleControl(userID, channelID, bus.ControlSummarize, transcript, metadata)
+}
+
+// isThread reports whether a channel is a thread.
+func (c *DiscordChannel) isThread(channelID string) bool {
+	var ch *discordgo.Channel
+	if c.session.State != nil {
+		ch, _ = c.session.State.Channel(channelID)
+	}
+	if ch == nil {
+		var err error
+		if ch, err = c.session.Channel(channelID); err != nil {
+			return false
+		}
+	}
+	return ch.IsThread()
+}
+
+// messagesBefore returns up to limit messages posted before beforeID,
+// oldest first.
+func (c *DiscordChannel) messagesBefore(channelID, beforeID string, limit int) ([]*discordgo.Message, error) {
+	var out []*discordgo.Message
+	for len(out) < limit {
+		batch, err := c.session.ChannelMessages(channelID, min(limit-len(out), 100), beforeID, "", "")
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, batch...)
+		if len(batch) < 100 {
+			break
+		}
+		sortMessages(batch)
+		beforeID = batch[0].ID
+	}
+	sortMessages(out)
+	return out, nil
+}
+
+// messagesFrom returns the message fromID and up to limit-1 messages
+// after it, oldest first.
+func (c *DiscordChannel) messagesFrom(channelID, fromID string, limit int) ([]*discordgo.Message, error) {
+	first, err := c.session.ChannelMessage(channelID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	out := []*discordgo.Message{first}
+	afterID := fromID
+	for len(out) < limit {
+		batch, err := c.session.ChannelMessages(channelID, min(limit-len(out), 100), "", afterID, "")
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		out = append(out, batch...)
+		sortMessages(batch)
+		afterID = batch[len(batch)-1].ID
+		if len(batch) < 100 {
+			break
+		}
+	}
+	sortMessages(out)
+	return out, nil
+}
+
+// messagesSince returns up to limit of the latest messages posted after
+// since, oldest first.
+func (c *DiscordChannel) messagesSince(channelID string, since time.Time, limit int) ([]*discordgo.Message, error) {
+	var out []*discordgo.Message
+	beforeID := ""
+	for len(out) < limit {
+		batch, err := c.session.ChannelMessages(channelID, min(limit-len(out), 100), beforeID, "", "")
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		sortMessages(batch)
+		done := len(batch) < 100
+		for _, m := range batch {
+			if m.Timestamp.After(since) {
+				out = append(out, m)
+			} else {
+				done = true
+			}
+		}
+		if done {
+			break
+		}
+		beforeID = batch[0].ID
+	}
+	sortMessages(out)
+	return out, nil
+}
+
+// sortMessages orders messages oldest first by their snowflake IDs.
+func sortMessages(msgs []*discordgo.Message) {
+	sort.Slice(msgs, func(i, j int) bool {
+		a, b := msgs[i].ID, msgs[j].ID
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+}
+
+// discordTranscript writes messages as "[2006-01-02 15:04] name: text"
+// lines, skipping ones without text.
+func discordTranscript(msgs []*discordgo.Message) string {
+	var lines []string
+	for _, m := range msgs {
+		text := strings.TrimSpace(discordMessageText(m))
+		if text == "" || m.Author == nil {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("[%s] %s: %s", m.Timestamp.UTC().Format("2006-01-02 15:04"), m.Author.Username, text))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,496 @@
+package channels
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/knowledge"
+)
+
+func TestDiscordCountExchange(t *testing.T) {
+	ch, err := NewDiscordChannel(config.DiscordConfig{Token: "t", ThreadAfter: 3}, bus.NewMessageBus())
+	if err != nil {
+		t.Fatalf("NewDiscordChannel: %v", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		if ch.countExchange("c1", "u1") {
+			t.Fatalf("turn %d should not start a thread", i)
+		}
+	}
+	if ch.countExchange("c1", "u2") {
+		t.Error("another user's turn should count separately")
+	}
+	if !ch.countExchange("c1", "u1") {
+		t.Error("third turn should start a thread")
+	}
+	if ch.countExchange("c1", "u1") {
+		t.Error("count should reset after a thread is started")
+	}
+
+	ch.exchanges["c1:u3"] = discordExchange{turns: 2, last: time.Now().Add(-time.Hour)}
+	if ch.countExchange("c1", "u3") {
+		t.Error("a stale exchange should start over")
+	}
+}
+
+func TestDiscordThreadName(t *testing.T) {
+	if got := discordThreadName("alice", "  how do\nI fix this?  "); got != "alice: how do I fix this?" {
+		t.Errorf("got %q", got)
+	}
+	long := discordThreadName("alice", strings.Repeat("word ", 50))
+	if n := len([]rune(long)); n > 90 || !strings.HasSuffix(long, "…") {
+		t.Errorf("long name has %d runes: %q", n, long)
+	}
+}
+
+func TestDiscordEmbedLimits(t *testing.T) {
+	e := &bus.Embed{
+		Title:  strings.Repeat("t", 300),
+		Footer: "footer",
+	}
+	for i := 0; i < 30; i++ {
+		e.Fields = append(e.Fields, bus.EmbedField{Name: "n", Value: ""})
+	}
+
+	embed := discordEmbed(e)
+	if n := len([]rune(embed.Title)); n != discordEmbedTitleMax {
+		t.Errorf("title has %d runes", n)
+	}
+	if len(embed.Fields) != discordEmbedFieldsMax {
+		t.Errorf("got %d fields", len(embed.Fields))
+	}
+	if embed.Fields[0].Value == "" {
+		t.Error("empty field value should be replaced")
+	}
+	if embed.Footer == nil || embed.Footer.Text != "footer" {
+		t.Errorf("footer = %+v", embed.Footer)
+	}
+	if got := e.Text(); !strings.HasPrefix(got, strings.Repeat("t", 300)+"\n\nn: \nn: ") {
+		t.Errorf("Text() = %q", got[:320])
+	}
+}
+
+func TestReactionAction(t *testing.T) {
+	tests := map[string]string{
+		"🔁":      bus.ControlRegenerate,
+		"📌":      bus.ControlPin,
+		"🗑️":     discordReactionDelete,
+		"🗑":      discordReactionDelete,
+		"👍":      "",
+		"custom": "",
+	}
+	for emoji, want := range tests {
+		if got := reactionAction(emoji); got != want {
+			t.Errorf("reactionAction(%q) = %q, want %q", emoji, got, want)
+		}
+	}
+}
+
+func TestDiscordIsMemoryEmoji(t *testing.T) {
+	c := &DiscordChannel{config: config.DiscordConfig{MemoryEmoji: "🗒️"}}
+	if !c.isMemoryEmoji("🗒") || !c.isMemoryEmoji("🗒️") || c.isMemoryEmoji("📌") {
+		t.Error("isMemoryEmoji did not match the configured emoji")
+	}
+	c.config.MemoryEmoji = ""
+	if c.isMemoryEmoji("") {
+		t.Error("isMemoryEmoji matched with no emoji configured")
+	}
+}
+
+func TestDiscordIsAdmin(t *testing.T) {
+	c := &DiscordChannel{BaseChannel: NewBaseChannel("discord_ops", nil, nil, nil)}
+	c.SetAdmins([]string{"discord:1", " discord_ops:2 ", "telegram:3"})
+	if c.isAdmin("1") || !c.isAdmin("2") || c.isAdmin("3") {
+		t.Error("isAdmin matched entries for other channels")
+	}
+}
+
+func TestDiscordMayDelete(t *testing.T) {
+	c := &DiscordChannel{BaseChannel: NewBaseChannel("discord", nil, nil, nil)}
+	c.SetAdmins([]string{"discord:1"})
+	reaction := func(guildID, userID string) *discordgo.MessageReactionAdd {
+		return &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{GuildID: guildID, UserID: userID}}
+	}
+	if !c.mayDelete(reaction("", "2")) {
+		t.Error("a DM reply couldn't be deleted")
+	}
+	if !c.mayDelete(reaction("g1", "1")) {
+		t.Error("an admin couldn't delete a reply")
+	}
+}
+
+func TestDiscordKnowledge(t *testing.T) {
+	idx, err := knowledge.Open(filepath.Join(t.TempDir(), "knowledge.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &DiscordChannel{config: config.DiscordConfig{Knowledge: config.DiscordKnowledgeConfig{FAQChannels: config.FlexibleStringSlice{"faq"}}}}
+	c.session, _ = discordgo.New("Bot t")
+	c.SetKnowledge(idx)
+	if err := c.session.State.GuildAdd(&discordgo.Guild{ID: "g1",
+		Roles: []*discordgo.Role{{ID: "g1", Permissions: discordgo.PermissionViewChannel | discordgo.PermissionSendMessages}},
+		Channels: []*discordgo.Channel{
+			{ID: "faq", GuildID: "g1", Name: "faq", Type: discordgo.ChannelTypeGuildText},
+			{ID: "mods", GuildID: "g1", Name: "mods", Type: discordgo.ChannelTypeGuildText, PermissionOverwrites: []*discordgo.PermissionOverwrite{
+				{ID: "g1", Type: discordgo.PermissionOverwriteTypeRole, Deny: discordgo.PermissionViewChannel},
+			}},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	c.indexFAQMessage(&discordgo.Message{ID: "1", GuildID: "g1", ChannelID: "faq", Author: &discordgo.User{Username: "mod"},
+		Content: "Voice chat opens every Friday evening."})
+	c.indexFAQMessage(&discordgo.Message{ID: "2", GuildID: "g1", ChannelID: "general", Content: "voice chat friday"})
+	if idx.Len("g1") != 1 {
+		t.Fatalf("indexed %d docs, want only the FAQ channel's", idx.Len("g1"))
+	}
+
+	matches := c.knowledgeMatches("g1", "general", "when does voice chat open?")
+	if len(matches) != 1 {
+		t.Fatalf("matches = %+v", matches)
+	}
+	ctx := knowledgeContext(matches)
+	if !strings.Contains(ctx, "Posted in #faq by mod (https://discord.com/channels/g1/faq/1): Voice chat opens") {
+		t.Errorf("knowledgeContext = %q", ctx)
+	}
+	if got := c.knowledgeMatches("g1", "general", "is the pizza good?"); len(got) != 0 {
+		t.Errorf("unrelated question matched %+v", got)
+	}
+
+	// Pins of a channel only moderators can read stay there
+	c.putKnowledge(knowledge.KindPinned, &discordgo.Message{ID: "3", GuildID: "g1", ChannelID: "mods",
+		Content: "The ban appeal password is swordfish."})
+	if got := c.knowledgeMatches("g1", "general", "what is the ban appeal password?"); len(got) != 0 {
+		t.Errorf("private pin answered a public channel: %+v", got)
+	}
+	if got := c.knowledgeMatches("g1", "mods", "what is the ban appeal password?"); len(got) != 1 {
+		t.Errorf("private pin in its own channel = %+v", got)
+	}
+}
+
+func TestIsQuestion(t *testing.T) {
+	for content, want := range map[string]bool{
+		"when is the meetup?": true,
+		"How do I join voice": true,
+		"nice one":            false,
+		"":                    false,
+	} {
+		if got := isQuestion(content); got != want {
+			t.Errorf("isQuestion(%q) = %v", content, got)
+		}
+	}
+}
+
+func TestParseSummarizeCommand(t *testing.T) {
+	tests := []struct {
+		content string
+		count   int
+		ok      bool
+	}{
+		{"!summarize", 0, true},
+		{"!Summarize 120", 120, true},
+		{"!summarize lots", -1, true},
+		{"!summarize 0", -1, true},
+		{"summarize this", 0, false},
+	}
+	for _, tt := range tests {
+		count, ok := parseSummarizeCommand(tt.content)
+		if count != tt.count || ok != tt.ok {
+			t.Errorf("parseSummarizeCommand(%q) = %d, %v", tt.content, count, ok)
+		}
+	}
+}
+
+func TestDiscordTranscript(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	msgs := []*discordgo.Message{
+		{ID: "1000", Author: &discordgo.User{Username: "bob"}, Content: "second", Timestamp: at.Add(time.Minute)},
+		{ID: "999", Author: &discordgo.User{Username: "alice"}, Content: "first", Timestamp: at},
+		{ID: "1001", Author: &discordgo.User{Username: "carol"}, Timestamp: at},
+	}
+	sortMessages(msgs)
+	got := discordTranscript(msgs)
+	want := "[2026-03-01 09:30] alice: first\n[2026-03-01 09:31] bob: second"
+	if got != want {
+		t.Errorf("discordTranscript = %q, want %q", got, want)
+	}
+}
+
+func TestDiscordMessageText(t *testing.T) {
+	m := &discordgo.Message{Embeds: []*discordgo.MessageEmbed{{
+		Title:       "Weather",
+		Description: "Sunny",
+		Fields:      []*discordgo.MessageEmbedField{{Name: "High", Value: "24°C"}},
+	}}}
+	if got := discordMessageText(m); got != "Weather\n\nSunny\n\nHigh: 24°C" {
+		t.Errorf("embed text = %q", got)
+	}
+	m.Content = "plain"
+	if got := discordMessageText(m); got != "plain" {
+		t.Errorf("content text = %q", got)
+	}
+}
+
+func TestStreamPreview(t *testing.T) {
+	if got := streamPreview(""); got != "" {
+		t.Errorf("empty content preview = %q", got)
+	}
+	if got := streamPreview("Hello"); got != "Hello"+discordStreamCursor {
+		t.Errorf("preview = %q", got)
+	}
+	long := streamPreview(strings.Repeat("a", 3000))
+	if n := len([]rune(long)); n != 2000 || !strings.HasSuffix(long, "…"+discordStreamCursor) {
+		t.Errorf("long preview has %d runes", n)
+	}
+}
+
+func TestDiscordTakeStream(t *testing.T) {
+	ch, err := NewDiscordChannel(config.DiscordConfig{Token: "t"}, bus.NewMessageBus())
+	if err != nil {
+		t.Fatalf("NewDiscordChannel: %v", err)
+	}
+
+	ch.streams["fresh"] = &discordStream{messageID: "1", updated: time.Now()}
+	ch.streams["stale"] = &discordStream{messageID: "2", updated: time.Now().Add(-discordStreamTTL - time.Second)}
+
+	if s := ch.takeStream("fresh"); s == nil || s.messageID != "1" {
+		t.Errorf("fresh stream = %+v", s)
+	}
+	if s := ch.takeStream("fresh"); s != nil {
+		t.Error("stream should be removed once taken")
+	}
+	if s := ch.takeStream("stale"); s != nil {
+		t.Error("stale stream should not be reused")
+	}
+}
+
+func TestParseConfirmID(t *testing.T) {
+	buttons := confirmButtons("abc123")
+	row := buttons[0].(discordgo.ActionsRow)
+	yes := row.Components[0].(discordgo.Button).CustomID
+	no := row.Components[1].(discordgo.Button).CustomID
+
+	if id, approved, ok := parseConfirmID(yes); !ok || id != "abc123" || !approved {
+		t.Errorf("parseConfirmID(%q) = %q, %v, %v", yes, id, approved, ok)
+	}
+	if id, approved, ok := parseConfirmID(no); !ok || id != "abc123" || approved {
+		t.Errorf("parseConfirmID(%q) = %q, %v, %v", no, id, approved, ok)
+	}
+	for _, bad := range []string{"", "confirm:", "confirm:abc", "confirm:abc:maybe", "other:abc:yes"} {
+		if _, _, ok := parseConfirmID(bad); ok {
+			t.Errorf("parseConfirmID(%q) should fail", bad)
+		}
+	}
+}
+
+func TestDiscordCommandText(t *testing.T) {
+	for _, tc := range []struct {
+		data discordgo.ApplicationCommandInteractionData
+		want string
+	}{
+		{discordgo.ApplicationCommandInteractionData{Name: "show", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "target", Type: discordgo.ApplicationCommandOptionString, Value: "model"},
+		}}, "/show model"},
+		{discordgo.ApplicationCommandInteractionData{Name: "web_search", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "query", Type: discordgo.ApplicationCommandOptionString, Value: "raspberry pi"},
+			{Name: "count", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(3)},
+		}}, `/web_search query="raspberry pi" count=3`},
+		{discordgo.ApplicationCommandInteractionData{Name: "weather"}, "/weather"},
+	} {
+		if got := discordCommandText(tc.data); got != tc.want {
+			t.Errorf("discordCommandText = %q, want %q", got, tc.want)
+		}
+	}
+}
+
+// typingCounter counts typing indicator requests instead of sending them.
+type typingCounter struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (tc *typingCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(req.URL.Path, "/typing") {
+		tc.mu.Lock()
+		tc.n++
+		tc.mu.Unlock()
+	}
+	return &http.Response{StatusCode: http.StatusNoContent, Body: io.NopCloser(strings.NewReader("")), Header: http.Header{}, Request: req}, nil
+}
+
+func (tc *typingCounter) count() int {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.n
+}
+
+func TestDiscordTypingKeepalive(t *testing.T) {
+	defer func(d time.Duration) { discordTypingInterval = d }(discordTypingInterval)
+	discordTypingInterval = 20 * time.Millisecond
+
+	ch, err := NewDiscordChannel(config.DiscordConfig{Token: "t"}, bus.NewMessageBus())
+	if err != nil {
+		t.Fatalf("NewDiscordChannel: %v", err)
+	}
+	counter := &typingCounter{}
+	ch.session.Client = &http.Client{Transport: counter}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch.ctx = ctx
+
+	ch.startTyping("c1")
+	time.Sleep(110 * time.Millisecond)
+	if n := counter.count(); n < 3 {
+		t.Fatalf("sent typing %d times, want it re-sent", n)
+	}
+	ch.stopTyping("c1")
+	time.Sleep(30 * time.Millisecond)
+	stopped := counter.count()
+	time.Sleep(60 * time.Millisecond)
+	if n := counter.count(); n != stopped {
+		t.Errorf("typing sent %d more times after stopTyping", n-stopped)
+	}
+
+	// Cancelling the channel's context ends the keepalive too
+	ch.startTyping("c2")
+	cancel()
+	time.Sleep(30 * time.Millisecond)
+	ch.typingMu.Lock()
+	_, running := ch.typing["c2"]
+	ch.typingMu.Unlock()
+	if running {
+		t.Error("keepalive still registered after the context was cancelled")
+	}
+}
+
+func TestDiscordReadableEmoji(t *testing.T) {
+	got := discordReadableEmoji("ship it <:partyparrot:123456> <a:dance:789>", []*discordgo.StickerItem{{ID: "1", Name: "Wave"}})
+	if want := "ship it [emoji: partyparrot] [emoji: dance]\n[sticker: Wave]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := discordReadableEmoji("", []*discordgo.StickerItem{{ID: "1", Name: "Wave"}}); got != "[sticker: Wave]" {
+		t.Errorf("sticker only = %q", got)
+	}
+}
+
+func TestDiscordGuildEmoji(t *testing.T) {
+	emojis := []*discordgo.Emoji{
+		{ID: "123", Name: "partyparrot"},
+		{ID: "456", Name: "dance", Animated: true},
+	}
+	got := discordGuildEmoji("Done :partyparrot: [emoji: dance] :smile: <:partyparrot:123> at 10:30:00", emojis)
+	if want := "Done <:partyparrot:123> <a:dance:456> :smile: <:partyparrot:123> at 10:30:00"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDigestPeriod(t *testing.T) {
+	// 2026-10-15 is a Thursday
+	at := func(hour int) time.Time { return time.Date(2026, 10, 15, hour, 30, 0, 0, time.Local) }
+	tests := []struct {
+		name string
+		d    config.DiscordDigestConfig
+		now  time.Time
+		want string
+	}{
+		{"daily at hour", config.DiscordDigestConfig{Hour: 9}, at(9), "2026-10-15"},
+		{"daily other hour", config.DiscordDigestConfig{Hour: 9}, at(10), ""},
+		{"bad hour defaults to 9", config.DiscordDigestConfig{Hour: 42}, at(9), "2026-10-15"},
+		{"weekly on day", config.DiscordDigestConfig{Frequency: "weekly", Day: "thu", Hour: 18}, at(18), "2026-W42"},
+		{"weekly other day", config.DiscordDigestConfig{Frequency: "weekly", Day: "monday", Hour: 18}, at(18), ""},
+	}
+	for _, tt := range tests {
+		if got := digestPeriod(tt.d, tt.now); got != tt.want {
+			t.Errorf("%s: digestPeriod = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDiscordDigestSentOncePerPeriod(t *testing.T) {
+	ch, err := NewDiscordChannel(config.DiscordConfig{Token: "t"}, bus.NewMessageBus())
+	if err != nil {
+		t.Fatalf("NewDiscordChannel: %v", err)
+	}
+	key := "scheduler:discord_digest:discord:123"
+	if got := ch.lastDigest(key); got != "" {
+		t.Fatalf("lastDigest = %q, want empty", got)
+	}
+	ch.saveLastDigest(key, "2026-10-15")
+	if got := ch.lastDigest(key); got != "2026-10-15" {
+		t.Errorf("lastDigest = %q, want 2026-10-15", got)
+	}
+}
+
+func TestDiscordObserve(t *testing.T) {
+	ws := t.TempDir()
+	idx, err := knowledge.Open(filepath.Join(ws, "knowledge.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb := bus.NewMessageBus()
+	c, err := NewDiscordChannel(config.DiscordConfig{
+		Token: "t",
+		Channels: map[string]config.DiscordChannelConfig{
+			"g1":       {Mode: config.DiscordModeObserve},
+			"helpdesk": {Mode: config.DiscordModeReply},
+		},
+	}, mb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetKnowledge(idx)
+	c.SetObserveLog(filepath.Join(ws, "observed"))
+	c.session.State.User = &discordgo.User{ID: "bot"}
+	if err := c.session.State.GuildAdd(&discordgo.Guild{ID: "g1", Channels: []*discordgo.Channel{
+		{ID: "general", GuildID: "g1", Name: "general", Type: discordgo.ChannelTypeGuildText},
+		{ID: "helpdesk", GuildID: "g1", Name: "helpdesk", Type: discordgo.ChannelTypeGuildText},
+		{ID: "thread", GuildID: "g1", ParentID: "helpdesk", Type: discordgo.ChannelTypeGuildPublicThread},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.observes("g1", "general") || c.observes("g1", "helpdesk") || c.observes("g1", "thread") || c.observes("", "dm") {
+		t.Fatal("observes resolved the wrong channels")
+	}
+
+	c.handleMessage(c.session, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID: "1", GuildID: "g1", ChannelID: "general", Author: &discordgo.User{ID: "u1", Username: "sam"},
+		Content: "The build server restarts every night at two.",
+	}})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if msg, ok := mb.ConsumeInbound(ctx); ok {
+		t.Fatalf("observed message reached the agent: %+v", msg)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(ws, "observed", "discord-*.jsonl"))
+	if len(matches) != 1 {
+		t.Fatalf("observed logs = %v", matches)
+	}
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"channel":"general"`) || !strings.Contains(string(data), `"author":"sam"`) {
+		t.Errorf("observed log = %s", data)
+	}
+	if got := c.knowledgeMatches("g1", "general", "when does the build server restart?"); len(got) != 1 || got[0].Doc.Kind != knowledge.KindObserved {
+		t.Errorf("knowledge matches = %+v", got)
+	}
+
+	c.setRunning(true)
+	if err := c.Send(context.Background(), bus.OutboundMessage{ChatID: "general", Content: "hi"}); err != nil {
+		t.Errorf("Send to an observed channel = %v, want it dropped", err)
+	}
+}
@@ -0,0 +1,141 @@
+package channels
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// Discord thread modes, see config.DiscordConfig.ThreadMode.
+const (
+	discordThreadOff    = "off"
+	discordThreadAuto   = "auto"
+	discordThreadAlways = "always"
+)
+
+// discordExchangeWindow is how long a gap may be between messages that
+// still count towards the same exchange in thread_mode "auto".
+const discordExchangeWindow = 15 * time.Minute
+
+type discordExchange struct {
+	turns int
+	last  time.Time
+}
+
+// botThreadParent returns the parent channel ID when channelID is a thread
+// the bot started, and "" otherwise. Replies in such threads continue the
+// parent channel's conversation and do not need a mention.
+func (c *DiscordChannel) botThreadParent(channelID string) string {
+	ch := c.lookupChannel(channelID)
+	if ch == nil || !ch.IsThread() || ch.OwnerID != c.botUserID {
+		return ""
+	}
+	return ch.ParentID
+}
+
+// threadTarget decides where the reply to m goes. When the channel's thread
+// mode calls for it, a thread is started on m and its ID is returned;
+// otherwise the reply stays in m's channel.
+func (c *DiscordChannel) threadTarget(m *discordgo.MessageCreate, senderName, content string) string {
+	if m.GuildID == "" {
+		return m.ChannelID
+	}
+	if ch := c.lookupChannel(m.ChannelID); ch != nil && ch.IsThread() {
+		return m.ChannelID
+	}
+
+	switch c.config.ThreadModeFor(m.GuildID, m.ChannelID) {
+	case discordThreadAlways:
+	case discordThreadAuto:
+		if !c.countExchange(m.ChannelID, m.Author.ID) {
+			return m.ChannelID
+		}
+	default:
+		return m.ChannelID
+	}
+
+	thread, err := c.session.MessageThreadStartComplex(m.ChannelID, m.ID, &discordgo.ThreadStart{
+		Name:                discordThreadName(senderName, content),
+		AutoArchiveDuration: 1440,
+	})
+	if err != nil {
+		logger.WarnCF("discord", "Failed to start thread", map[string]any{
+			"channel_id": m.ChannelID,
+			"error":      err.Error(),
+		})
+		return m.ChannelID
+	}
+
+	logger.InfoCF("discord", "Moved conversation into a thread", map[string]any{
+		"channel_id": m.ChannelID,
+		"thread_id":  thread.ID,
+	})
+	return thread.ID
+}
+
+// countExchange records a message from userID in channelID and reports
+// whether the exchange has become long enough to move into a thread.
+func (c *DiscordChannel) countExchange(channelID, userID string) bool {
+	threshold := c.config.ThreadAfter
+	if threshold <= 0 {
+		threshold = 3
+	}
+
+	c.threadMu.Lock()
+	defer c.threadMu.Unlock()
+
+	key := channelID + ":" + userID
+	now := time.Now()
+	ex := c.exchanges[key]
+	if now.Sub(ex.last) > discordExchangeWindow {
+		ex.turns = 0
+	}
+	ex.turns++
+	ex.last = now
+
+	if ex.turns >= threshold {
+		delete(c.exchanges, key)
+		return true
+	}
+	c.exchanges[key] = ex
+	return false
+}
+
+// lookupChannel returns the channel from the session state, falling back
+// to the REST API for channels the state has not seen.
+func (c *DiscordChannel) lookupChannel(channelID string) *discordgo.Channel {
+	if c.session.State != nil {
+		if ch, err := c.session.State.Channel(channelID); err == nil {
+			return ch
+		}
+	}
+	ch, err := c.session.Channel(channelID)
+	if err != nil {
+		logger.DebugCF("discord", "Failed to look up channel", map[string]any{
+			"channel_id": channelID,
+			"error":      err.Error(),
+		})
+		return nil
+	}
+	if c.session.State != nil {
+		c.session.State.ChannelAdd(ch)
+	}
+	return ch
+}
+
+// discordThreadName builds a thread title from the opening message;
+// Discord caps thread names at 100 characters.
+func discordThreadName(senderName, content string) string {
+	title := strings.Join(strings.Fields(content), " ")
+	if title == "" {
+		title = "conversation"
+	}
+	name := fmt.Sprintf("%s: %s", senderName, title)
+	if runes := []rune(name); len(runes) > 90 {
+		name = string(runes[:89]) + "…"
+	}
+	return name
+}
@@ -0,0 +1,606 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+const (
+	emailDialTimeout    = 30 * time.Second
+	emailMinPoll        = 10 * time.Second
+	emailDefaultSubject = "Message from PicoClaw"
+	emailMaxBodyChars   = 20000
+)
+
+// emailThread remembers the last message received from a correspondent so
+// replies can carry proper threading headers.
+type emailThread struct {
+	messageID  string
+	references string
+	subject    string
+}
+
+// parsedEmail is the subset of an inbound message the channel uses.
+// AuthResults holds the Authentication-Results headers, topmost first.
+type parsedEmail struct {
+	From        string
+	FromName    string
+	Subject     string
+	MessageID   string
+	References  string
+	Body        string
+	AuthResults []string
+}
+
+// EmailChannel implements the Channel interface for email. It polls an IMAP
+// mailbox for unseen mail from allowlisted senders and replies over SMTP.
+type EmailChannel struct {
+	*BaseChannel
+	config  config.EmailConfig
+	threads sync.Map // sender address -> emailThread
+	// skipped are unseen messages poll rejected, left unread for the
+	// mailbox's owner
+	skipped map[uint32]bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// NewEmailChannel creates a new email channel instance.
+func NewEmailChannel(cfg config.EmailConfig, messageBus *bus.MessageBus) (*EmailChannel, error) {
+	if cfg.IMAPHost == "" || cfg.SMTPHost == "" || cfg.Username == "" {
+		return nil, fmt.Errorf("email imap_host, smtp_host and username are required")
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+	if cfg.Mailbox == "" {
+		cfg.Mailbox = "INBOX"
+	}
+
+	base := NewBaseChannel("email", cfg, messageBus, emailAllowList(cfg.AllowFrom))
+
+	return &EmailChannel{
+		BaseChannel: base,
+		config:      cfg,
+		skipped:     make(map[uint32]bool),
+	}, nil
+}
+
+// emailAllowList lowercases allowlisted addresses, as senders are matched
+// lowercased.
+func emailAllowList(addrs []string) []string {
+	allowFrom := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		allowFrom = append(allowFrom, strings.ToLower(strings.TrimSpace(addr)))
+	}
+	return allowFrom
+}
+
+func (c *EmailChannel) AddAllowedUser(addr string) bool {
+	return c.BaseChannel.AddAllowedUser(strings.ToLower(strings.TrimSpace(addr)))
+}
+
+func (c *EmailChannel) RemoveAllowedUser(addr string) (bool, error) {
+	return c.BaseChannel.RemoveAllowedUser(strings.ToLower(strings.TrimSpace(addr)))
+}
+
+func (c *EmailChannel) SetAllowList(addrs []string) {
+	c.BaseChannel.SetAllowList(emailAllowList(addrs))
+}
+
+// Start begins polling the mailbox.
+func (c *EmailChannel) Start(ctx context.Context) error {
+	logger.InfoCF("email", "Starting email channel", map[string]interface{}{
+		"imap_host": c.config.IMAPHost,
+		"mailbox":   c.config.Mailbox,
+	})
+
+	c.ctx, c.cancel = context.WithCancel(ctx)
+
+	go c.pollLoop()
+
+	c.setRunning(true)
+	logger.InfoC("email", "Email channel started")
+	return nil
+}
+
+// Stop stops polling.
+func (c *EmailChannel) Stop(ctx context.Context) error {
+	logger.InfoC("email", "Stopping email channel")
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	c.setRunning(false)
+	logger.InfoC("email", "Email channel stopped")
+	return nil
+}
+
+func (c *EmailChannel) pollLoop() {
+	interval := time.Duration(c.config.PollInterval) * time.Second
+	if interval < emailMinPoll {
+		interval = emailMinPoll
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.poll(); err != nil {
+			logger.ErrorCF("email", "Mailbox poll failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches unseen messages, hands allowlisted ones to the agent and
+// marks them seen. Mail from other senders is left unread.
+func (c *EmailChannel) poll() error {
+	client, err := dialIMAP(c.config.IMAPHost, c.config.IMAPPort, c.config.IMAPPort != 143, emailDialTimeout)
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Login(c.config.Username, c.config.Password); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	defer client.Logout()
+
+	if err := client.Select(c.config.Mailbox); err != nil {
+		return fmt.Errorf("select %s: %w", c.config.Mailbox, err)
+	}
+
+	uids, err := client.SearchUnseen()
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+	c.forgetSkipped(uids)
+
+	for _, uid := range uids {
+		if c.skipped[uid] {
+			continue
+		}
+
+		raw, err := client.FetchRaw(uid)
+		if err != nil {
+			logger.WarnCF("email", "Failed to fetch message", map[string]interface{}{
+				"uid":   uid,
+				"error": err.Error(),
+			})
+			continue
+		}
+
+		email, err := parseEmail(raw)
+		if err != nil {
+			logger.WarnCF("email", "Failed to parse message", map[string]interface{}{
+				"uid":   uid,
+				"error": err.Error(),
+			})
+			c.skipped[uid] = true
+			continue
+		}
+
+		if !c.admitsMessage(email.From) {
+			logger.DebugCF("email", "Ignoring mail from sender not in allow_from", map[string]interface{}{
+				"from": email.From,
+			})
+			c.skipped[uid] = true
+			continue
+		}
+		if !senderAuthenticated(email.AuthResults, email.From, c.config.AuthServID) {
+			logger.WarnCF("email", "Ignoring mail whose sender isn't authenticated", map[string]interface{}{
+				"from": email.From,
+			})
+			c.skipped[uid] = true
+			continue
+		}
+
+		if err := client.MarkSeen(uid); err != nil {
+			logger.WarnCF("email", "Failed to mark message seen", map[string]interface{}{
+				"uid":   uid,
+				"error": err.Error(),
+			})
+		}
+
+		c.handleEmail(email)
+	}
+	return nil
+}
+
+// forgetSkipped drops the skipped messages that are no longer unseen,
+// because they were read or deleted, so the set never outgrows the
+// mailbox's unseen mail.
+func (c *EmailChannel) forgetSkipped(unseen []uint32) {
+	still := make(map[uint32]bool, len(unseen))
+	for _, uid := range unseen {
+		still[uid] = true
+	}
+	for uid := range c.skipped {
+		if !still[uid] {
+			delete(c.skipped, uid)
+		}
+	}
+}
+
+func (c *EmailChannel) handleEmail(email *parsedEmail) {
+	references := strings.TrimSpace(email.References + " " + email.MessageID)
+	c.threads.Store(email.From, emailThread{
+		messageID:  email.MessageID,
+		references: references,
+		subject:    email.Subject,
+	})
+
+	body := utils.Truncate(email.Body, emailMaxBodyChars)
+	content := body
+	if email.Subject != "" {
+		content = fmt.Sprintf("Subject: %s\n\n%s", email.Subject, body)
+	}
+
+	metadata := map[string]string{
+		"platform":   "email",
+		"message_id": email.MessageID,
+		"subject":    email.Subject,
+		"peer_kind":  "direct",
+		"peer_id":    email.From,
+		"user_name":  email.FromName,
+	}
+
+	logger.DebugCF("email", "Received email", map[string]interface{}{
+		"from":    email.From,
+		"subject": email.Subject,
+	})
+
+	c.HandleMessage(email.From, email.From, content, nil, metadata)
+}
+
+// Send replies to the sender's last message, threading with In-Reply-To and
+// References when available.
+func (c *EmailChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	if !c.IsRunning() {
+		return fmt.Errorf("email channel not running")
+	}
+
+	var thread emailThread
+	if v, ok := c.threads.Load(msg.ChatID); ok {
+		thread = v.(emailThread)
+	}
+
+	raw := buildEmail(c.config.From, msg.ChatID, msg.Content, thread, time.Now())
+	if err := c.sendMail(msg.ChatID, raw); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	logger.DebugCF("email", "Email sent", map[string]interface{}{
+		"to": msg.ChatID,
+	})
+	return nil
+}
+
+func (c *EmailChannel) sendMail(to string, raw []byte) error {
+	addr := net.JoinHostPort(c.config.SMTPHost, strconv.Itoa(c.config.SMTPPort))
+	envelopeFrom := c.config.From
+	if parsed, err := mail.ParseAddress(c.config.From); err == nil {
+		envelopeFrom = parsed.Address
+	}
+
+	var auth smtp.Auth
+	if c.config.Password != "" {
+		auth = smtp.PlainAuth("", c.config.Username, c.config.Password, c.config.SMTPHost)
+	}
+
+	// Port 465 uses implicit TLS, which smtp.SendMail does not support
+	if c.config.SMTPPort != 465 {
+		return smtp.SendMail(addr, auth, envelopeFrom, []string{to}, raw)
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: emailDialTimeout}, "tcp", addr,
+		&tls.Config{ServerName: c.config.SMTPHost})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, c.config.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(envelopeFrom); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildEmail renders a plain-text reply with threading headers.
+func buildEmail(from, to, body string, thread emailThread, now time.Time) []byte {
+	subject := thread.subject
+	if subject == "" {
+		subject = emailDefaultSubject
+	} else if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", newMessageID(from))
+	if thread.messageID != "" {
+		fmt.Fprintf(&buf, "In-Reply-To: %s\r\n", thread.messageID)
+	}
+	if thread.references != "" {
+		fmt.Fprintf(&buf, "References: %s\r\n", thread.references)
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	qp.Close()
+	return buf.Bytes()
+}
+
+func newMessageID(from string) string {
+	domain := "picoclaw.local"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domain = addr.Address[at+1:]
+		}
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), domain)
+}
+
+// parseEmail extracts the sender, subject, threading headers and the
+// plain-text body of a raw RFC 822 message.
+func parseEmail(raw []byte) (*parsedEmail, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid From header: %w", err)
+	}
+
+	decoder := new(mime.WordDecoder)
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	body, err := extractTextBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsedEmail{
+		From:        strings.ToLower(from.Address),
+		FromName:    from.Name,
+		Subject:     strings.TrimSpace(subject),
+		MessageID:   strings.TrimSpace(msg.Header.Get("Message-ID")),
+		References:  strings.TrimSpace(msg.Header.Get("References")),
+		Body:        stripQuotedReply(body),
+		AuthResults: msg.Header["Authentication-Results"],
+	}, nil
+}
+
+// senderAuthenticated reports whether the receiving server found that
+// mail from the address from passed DMARC, or DKIM or SPF for a domain
+// aligned with from's. Anyone can write a From header, and senders can add
+// Authentication-Results headers of their own, so only the topmost one is
+// trusted, or with authServID set, the topmost one that server added.
+func senderAuthenticated(authResults []string, from, authServID string) bool {
+	_, fromDomain, ok := strings.Cut(from, "@")
+	if !ok {
+		return false
+	}
+
+	for _, header := range authResults {
+		id, results, _ := strings.Cut(stripHeaderComments(header), ";")
+		// The authserv-id may be followed by a version
+		idFields := strings.Fields(id)
+		if len(idFields) == 0 || (authServID != "" && !strings.EqualFold(idFields[0], authServID)) {
+			continue
+		}
+		return authResultsPass(results, fromDomain)
+	}
+	return false
+}
+
+// authResultsPass checks the "method=result property=value" clauses of an
+// Authentication-Results header.
+func authResultsPass(results, fromDomain string) bool {
+	for _, clause := range strings.Split(results, ";") {
+		fields := strings.Fields(strings.ToLower(clause))
+		if len(fields) == 0 {
+			continue
+		}
+		method, result, _ := strings.Cut(fields[0], "=")
+		if result != "pass" {
+			continue
+		}
+		props := make(map[string]string)
+		for _, f := range fields[1:] {
+			if k, v, ok := strings.Cut(f, "="); ok {
+				props[k] = strings.Trim(v, `"`)
+			}
+		}
+
+		var domain string
+		switch method {
+		case "dmarc":
+			domain = props["header.from"]
+			if domain == "" {
+				domain = fromDomain
+			}
+		case "dkim":
+			domain = props["header.d"]
+			if domain == "" {
+				domain = addressDomain(props["header.i"])
+			}
+		case "spf":
+			domain = addressDomain(props["smtp.mailfrom"])
+		}
+		if domain != "" && domainsAligned(domain, fromDomain) {
+			return true
+		}
+	}
+	return false
+}
+
+// domainsAligned is DMARC's relaxed alignment, approximated: the domains
+// are equal or one is a subdomain of the other.
+func domainsAligned(a, b string) bool {
+	return a == b || strings.HasSuffix(a, "."+b) || strings.HasSuffix(b, "."+a)
+}
+
+// addressDomain returns the part of an address after the @, or the whole
+// value if it has none.
+func addressDomain(addr string) string {
+	if _, domain, ok := strings.Cut(addr, "@"); ok {
+		return domain
+	}
+	return addr
+}
+
+// stripHeaderComments removes (comments) from a structured header.
+func stripHeaderComments(header string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range header {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// extractTextBody returns the first text/plain part, descending into
+// multipart containers.
+func extractTextBody(contentType, encoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return "", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := extractTextBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err == nil && text != "" {
+				return text, nil
+			}
+		}
+	}
+
+	if mediaType != "text/plain" {
+		return "", nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(string(data), "\r\n", "\n"), nil
+}
+
+// stripQuotedReply drops quoted history ("> ..." lines and the
+// "On ... wrote:" line that introduces them) so only the new text reaches
+// the agent.
+func stripQuotedReply(body string) string {
+	lines := strings.Split(body, "\n")
+	kept := make([]string, 0, len(lines))
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "On ") && strings.HasSuffix(trimmed, "wrote:") &&
+			strings.HasPrefix(nextNonBlank(lines[i+1:]), ">") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+func nextNonBlank(lines []string) string {
+	for _, line := range lines {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
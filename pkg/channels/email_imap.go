@@ -0,0 +1,206 @@
+package channels
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// imapClient is a minimal IMAP4rev1 client covering what the email channel
+// needs: login, select, searching for unseen mail, fetching raw messages and
+// setting the \Seen flag.
+type imapClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    io.Writer
+	tag  int
+}
+
+// imapResponse is one untagged server response with any literals it carried.
+type imapResponse struct {
+	Text     string
+	Literals [][]byte
+}
+
+func dialIMAP(host string, port int, useTLS bool, timeout time.Duration) (*imapClient, error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	var err error
+	if useTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	c := newIMAPClient(conn, conn)
+	c.conn = conn
+	greeting, err := c.readLine()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting, "* OK") && !strings.HasPrefix(greeting, "* PREAUTH") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected IMAP greeting: %s", greeting)
+	}
+	return c, nil
+}
+
+func newIMAPClient(r io.Reader, w io.Writer) *imapClient {
+	return &imapClient{r: bufio.NewReader(r), w: w}
+}
+
+func (c *imapClient) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+func (c *imapClient) Login(username, password string) error {
+	_, err := c.command("LOGIN %s %s", imapQuote(username), imapQuote(password))
+	return err
+}
+
+func (c *imapClient) Select(mailbox string) error {
+	_, err := c.command("SELECT %s", imapQuote(mailbox))
+	return err
+}
+
+// SearchUnseen returns the UIDs of unseen messages.
+func (c *imapClient) SearchUnseen() ([]uint32, error) {
+	resps, err := c.command("UID SEARCH UNSEEN")
+	if err != nil {
+		return nil, err
+	}
+
+	var uids []uint32
+	for _, resp := range resps {
+		fields := strings.Fields(resp.Text)
+		if len(fields) < 2 || !strings.EqualFold(fields[1], "SEARCH") {
+			continue
+		}
+		for _, f := range fields[2:] {
+			if n, err := strconv.ParseUint(f, 10, 32); err == nil {
+				uids = append(uids, uint32(n))
+			}
+		}
+	}
+	return uids, nil
+}
+
+// FetchRaw returns the full RFC 822 message for a UID without marking it seen.
+func (c *imapClient) FetchRaw(uid uint32) ([]byte, error) {
+	resps, err := c.command("UID FETCH %d BODY.PEEK[]", uid)
+	if err != nil {
+		return nil, err
+	}
+	for _, resp := range resps {
+		if strings.Contains(strings.ToUpper(resp.Text), "FETCH") && len(resp.Literals) > 0 {
+			return resp.Literals[0], nil
+		}
+	}
+	return nil, fmt.Errorf("message %d not returned by server", uid)
+}
+
+func (c *imapClient) MarkSeen(uid uint32) error {
+	_, err := c.command("UID STORE %d +FLAGS.SILENT (\\Seen)", uid)
+	return err
+}
+
+func (c *imapClient) Logout() error {
+	_, err := c.command("LOGOUT")
+	return err
+}
+
+// command sends a tagged command and collects untagged responses until the
+// tagged completion. A NO or BAD completion is returned as an error.
+func (c *imapClient) command(format string, args ...interface{}) ([]imapResponse, error) {
+	c.tag++
+	tag := fmt.Sprintf("a%03d", c.tag)
+	if _, err := fmt.Fprintf(c.w, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, err
+	}
+
+	var resps []imapResponse
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(resp.Text, tag+" ") {
+			status := strings.TrimPrefix(resp.Text, tag+" ")
+			if strings.HasPrefix(strings.ToUpper(status), "OK") {
+				return resps, nil
+			}
+			return nil, fmt.Errorf("imap: %s", status)
+		}
+		resps = append(resps, resp)
+	}
+}
+
+// readResponse reads one logical response line, following any {n} literals.
+func (c *imapClient) readResponse() (imapResponse, error) {
+	var resp imapResponse
+	var text strings.Builder
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return resp, err
+		}
+		text.WriteString(line)
+
+		n, ok := imapLiteralSize(line)
+		if !ok {
+			break
+		}
+		literal := make([]byte, n)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return resp, err
+		}
+		resp.Literals = append(resp.Literals, literal)
+	}
+	resp.Text = text.String()
+	return resp, nil
+}
+
+func (c *imapClient) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// imapLiteralSize reports whether line ends with a literal marker {n}.
+func imapLiteralSize(line string) (int, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false
+	}
+	open := strings.LastIndex(line, "{")
+	if open < 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(line[open+1 : len(line)-1])
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+func imapQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,198 @@
+package channels
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEmail(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		wantFrom    string
+		wantSubject string
+		wantBody    string
+	}{
+		{
+			name: "plain text with quoted reply",
+			raw: "From: Alice <Alice@Example.com>\r\n" +
+				"Subject: =?utf-8?q?Caf=C3=A9_plans?=\r\n" +
+				"Message-ID: <m2@example.com>\r\n" +
+				"References: <m1@example.com>\r\n" +
+				"Content-Type: text/plain; charset=utf-8\r\n\r\n" +
+				"Sounds good.\r\n\r\nOn Mon, Bot wrote:\r\n\r\n> earlier text\r\n",
+			wantFrom:    "alice@example.com",
+			wantSubject: "Café plans",
+			wantBody:    "Sounds good.",
+		},
+		{
+			name: "multipart alternative picks text/plain",
+			raw: "From: bob@example.com\r\n" +
+				"Subject: Hi\r\n" +
+				"Content-Type: multipart/alternative; boundary=XYZ\r\n\r\n" +
+				"--XYZ\r\nContent-Type: text/html\r\n\r\n<p>html</p>\r\n" +
+				"--XYZ\r\nContent-Type: text/plain\r\nContent-Transfer-Encoding: base64\r\n\r\n" +
+				"aGVsbG8gd29ybGQ=\r\n--XYZ--\r\n",
+			wantFrom:    "bob@example.com",
+			wantSubject: "Hi",
+			wantBody:    "hello world",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email, err := parseEmail([]byte(tt.raw))
+			if err != nil {
+				t.Fatalf("parseEmail: %v", err)
+			}
+			if email.From != tt.wantFrom {
+				t.Errorf("From = %q, want %q", email.From, tt.wantFrom)
+			}
+			if email.Subject != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", email.Subject, tt.wantSubject)
+			}
+			if email.Body != tt.wantBody {
+				t.Errorf("Body = %q, want %q", email.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSenderAuthenticated(t *testing.T) {
+	tests := []struct {
+		name       string
+		headers    []string
+		from       string
+		authServID string
+		want       bool
+	}{
+		{
+			name:    "dmarc pass",
+			headers: []string{"mx.example.net; dkim=pass header.d=example.com; spf=pass smtp.mailfrom=example.com; dmarc=pass (p=REJECT) header.from=example.com"},
+			from:    "alice@example.com",
+			want:    true,
+		},
+		{
+			name:    "dkim pass for a subdomain",
+			headers: []string{"mx.example.net; dkim=pass (2048-bit key) header.d=mail.example.com"},
+			from:    "alice@example.com",
+			want:    true,
+		},
+		{
+			name:    "spf pass for another domain",
+			headers: []string{"mx.example.net; spf=pass smtp.mailfrom=bounce@attacker.test; dmarc=fail header.from=example.com"},
+			from:    "alice@example.com",
+		},
+		{
+			name:    "no results",
+			headers: nil,
+			from:    "alice@example.com",
+		},
+		{
+			name: "forged header below the server's",
+			headers: []string{
+				"mx.example.net; dkim=none; spf=softfail smtp.mailfrom=attacker.test",
+				"mx.example.net; dmarc=pass header.from=example.com",
+			},
+			from: "alice@example.com",
+		},
+		{
+			name: "forged header above a named server's",
+			headers: []string{
+				"evil.test; dmarc=pass header.from=example.com",
+				"mx.example.net 1; dmarc=fail header.from=example.com",
+			},
+			from:       "alice@example.com",
+			authServID: "mx.example.net",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := senderAuthenticated(tt.headers, tt.from, tt.authServID); got != tt.want {
+				t.Errorf("senderAuthenticated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildEmailThreadingHeaders(t *testing.T) {
+	thread := emailThread{
+		messageID:  "<m2@example.com>",
+		references: "<m1@example.com> <m2@example.com>",
+		subject:    "Weekend",
+	}
+	raw := string(buildEmail("bot@example.com", "alice@example.com", "See you", thread, time.Now()))
+
+	for _, want := range []string{
+		"Subject: Re: Weekend\r\n",
+		"In-Reply-To: <m2@example.com>\r\n",
+		"References: <m1@example.com> <m2@example.com>\r\n",
+		"To: alice@example.com\r\n",
+	} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("missing %q in:\n%s", want, raw)
+		}
+	}
+	if !strings.Contains(raw, "@example.com>\r\n") || !strings.Contains(raw, "Message-ID: <") {
+		t.Error("missing Message-ID")
+	}
+}
+
+func TestEmailForgetSkipped(t *testing.T) {
+	c := &EmailChannel{skipped: map[uint32]bool{3: true, 7: true, 9: true}}
+	c.forgetSkipped([]uint32{7, 8, 9})
+	if len(c.skipped) != 2 || !c.skipped[7] || !c.skipped[9] {
+		t.Errorf("skipped = %v, want only the still unseen 7 and 9", c.skipped)
+	}
+	c.forgetSkipped(nil)
+	if len(c.skipped) != 0 {
+		t.Errorf("skipped = %v after the mailbox was read", c.skipped)
+	}
+}
+
+func TestIMAPClientFetch(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	message := "From: a@example.com\r\n\r\nhi\r\n"
+	go func() {
+		buf := make([]byte, 1024)
+		replies := []string{
+			"* SEARCH 7 9\r\na001 OK done\r\n",
+			"* 1 FETCH (UID 7 BODY[] {" + strconv.Itoa(len(message)) + "}\r\n" + message + ")\r\na002 OK done\r\n",
+			"a003 NO [NONEXISTENT] no such message\r\n",
+		}
+		for _, reply := range replies {
+			if _, err := server.Read(buf); err != nil {
+				return
+			}
+			server.Write([]byte(reply))
+		}
+	}()
+
+	c := newIMAPClient(client, client)
+	uids, err := c.SearchUnseen()
+	if err != nil {
+		t.Fatalf("SearchUnseen: %v", err)
+	}
+	if len(uids) != 2 || uids[0] != 7 || uids[1] != 9 {
+		t.Errorf("uids = %v", uids)
+	}
+
+	raw, err := c.FetchRaw(7)
+	if err != nil {
+		t.Fatalf("FetchRaw: %v", err)
+	}
+	if !bytes.Equal(raw, []byte(message)) {
+		t.Errorf("raw = %q", raw)
+	}
+
+	if _, err := c.FetchRaw(8); err == nil {
+		t.Error("expected error for NO response")
+	}
+}
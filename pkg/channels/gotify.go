@@ -0,0 +1,60 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+// GotifyChannel posts messages to a Gotify server. Gotify delivers to every
+// client of the application, so the chat ID is ignored.
+type GotifyChannel struct {
+	notifier
+	server   string
+	appToken string
+}
+
+// NewGotifyChannel creates a Gotify channel.
+func NewGotifyChannel(cfg config.GotifyConfig, messageBus *bus.MessageBus) (*GotifyChannel, error) {
+	if cfg.Server == "" || cfg.AppToken == "" {
+		return nil, fmt.Errorf("gotify server and app_token are required")
+	}
+	return &GotifyChannel{
+		notifier: newNotifier("gotify", cfg, messageBus, cfg.Alerts),
+		server:   strings.TrimRight(cfg.Server, "/"),
+		appToken: cfg.AppToken,
+	}, nil
+}
+
+func (c *GotifyChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	// Gotify priorities run 0-10; clients ring from 4 and insist from 8
+	priority := 5
+	if msg.Alert == bus.AlertError {
+		priority = 8
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"title":    alertTitle(msg.Alert),
+		"message":  msg.Content,
+		"priority": priority,
+		"extras": map[string]interface{}{
+			"client::display": map[string]string{"contentType": "text/markdown"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.server+"/message", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", c.appToken)
+	return c.do(req)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/sipeed/picoclaw/pkg/bus"
 	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/markdown"
 	"github.com/sipeed/picoclaw/pkg/utils"
 )
 
@@ -30,8 +31,14 @@ const (
 	lineBotInfoEndpoint  = lineAPIBase + "/info"
 	lineLoadingEndpoint  = lineAPIBase + "/chat/loading/start"
 	lineReplyTokenMaxAge = 25 * time.Second
+	lineMaxTextLength    = 5000 // characters per text message
+	lineMaxMessages      = 5    // messages per reply/push request
 )
 
+// lineStickerImageURL serves the static image of a sticker by its ID.
+// It is a variable so tests can point it at a local server.
+var lineStickerImageURL = "https://stickershop.line-scdn.net/stickershop/v1/sticker/%s/android/sticker.png"
+
 type replyTokenEntry struct {
 	token     string
 	timestamp time.Time
@@ -253,8 +260,12 @@ type lineMessage struct {
 		Mentionees []lineMentionee `json:"mentionees"`
 	} `json:"mention"`
 	ContentProvider struct {
-		Type string `json:"type"`
+		Type               string `json:"type"` // "line" or "external"
+		OriginalContentURL string `json:"originalContentUrl"`
 	} `json:"contentProvider"`
+	FileName  string   `json:"fileName"`
+	StickerID string   `json:"stickerId"`
+	Keywords  []string `json:"keywords"`
 }
 
 type lineMentionee struct {
@@ -328,30 +339,50 @@ func (c *LINEChannel) processEvent(event lineEvent) {
 			content = c.stripBotMention(content, msg)
 		}
 	case "image":
-		localPath := c.downloadContent(msg.ID, "image.jpg")
+		localPath := c.downloadMedia(msg, "image.jpg")
 		if localPath != "" {
 			localFiles = append(localFiles, localPath)
 			mediaPaths = append(mediaPaths, localPath)
 			content = "[image]"
 		}
 	case "audio":
-		localPath := c.downloadContent(msg.ID, "audio.m4a")
+		localPath := c.downloadMedia(msg, "audio.m4a")
 		if localPath != "" {
 			localFiles = append(localFiles, localPath)
 			mediaPaths = append(mediaPaths, localPath)
 			content = "[audio]"
 		}
 	case "video":
-		localPath := c.downloadContent(msg.ID, "video.mp4")
+		localPath := c.downloadMedia(msg, "video.mp4")
 		if localPath != "" {
 			localFiles = append(localFiles, localPath)
 			mediaPaths = append(mediaPaths, localPath)
 			content = "[video]"
 		}
 	case "file":
-		content = "[file]"
+		name := msg.FileName
+		if name == "" {
+			name = "file"
+		}
+		content = fmt.Sprintf("[file: %s]", name)
+		if localPath := c.downloadContent(msg.ID, name); localPath != "" {
+			localFiles = append(localFiles, localPath)
+			mediaPaths = append(mediaPaths, localPath)
+		}
 	case "sticker":
+		// Keywords describe the sticker's meaning (e.g. "Happy", "OK"),
+		// which is usually all the agent needs to respond sensibly.
 		content = "[sticker]"
+		if len(msg.Keywords) > 0 {
+			content = fmt.Sprintf("[sticker: %s]", strings.Join(msg.Keywords, ", "))
+		}
+		if msg.StickerID != "" {
+			url := fmt.Sprintf(lineStickerImageURL, msg.StickerID)
+			if localPath := utils.DownloadFile(url, "sticker.png", utils.DownloadOptions{LoggerPrefix: "line"}); localPath != "" {
+				localFiles = append(localFiles, localPath)
+				mediaPaths = append(mediaPaths, localPath)
+			}
+		}
 	default:
 		content = fmt.Sprintf("[%s]", msg.Type)
 	}
@@ -531,11 +562,33 @@ func buildTextMessage(content, quoteToken string) map[string]string {
 	return msg
 }
 
+// buildTextMessages splits content into LINE text messages. Only the first
+// message quotes the original. Content beyond what one request can carry
+// is truncated.
+func buildTextMessages(content, quoteToken string) []map[string]string {
+	chunks := markdown.Split(content, markdown.Plain, lineMaxTextLength)
+	if len(chunks) == 0 {
+		chunks = []string{content}
+	}
+	if len(chunks) > lineMaxMessages {
+		chunks = chunks[:lineMaxMessages]
+	}
+
+	messages := make([]map[string]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		if i > 0 {
+			quoteToken = ""
+		}
+		messages = append(messages, buildTextMessage(chunk, quoteToken))
+	}
+	return messages
+}
+
 // sendReply sends a message using the LINE Reply API.
 func (c *LINEChannel) sendReply(ctx context.Context, replyToken, content, quoteToken string) error {
 	payload := map[string]interface{}{
 		"replyToken": replyToken,
-		"messages":   []map[string]string{buildTextMessage(content, quoteToken)},
+		"messages":   buildTextMessages(content, quoteToken),
 	}
 
 	return c.callAPI(ctx, lineReplyEndpoint, payload)
@@ -545,7 +598,7 @@ func (c *LINEChannel) sendReply(ctx context.Context, replyToken, content, quoteT
 func (c *LINEChannel) sendPush(ctx context.Context, to, content, quoteToken string) error {
 	payload := map[string]interface{}{
 		"to":       to,
-		"messages": []map[string]string{buildTextMessage(content, quoteToken)},
+		"messages": buildTextMessages(content, quoteToken),
 	}
 
 	return c.callAPI(ctx, linePushEndpoint, payload)
@@ -594,6 +647,18 @@ func (c *LINEChannel) callAPI(ctx context.Context, endpoint string, payload inte
 	return nil
 }
 
+// downloadMedia downloads an image, video or audio message. Content sent
+// through an external provider is fetched from its original URL instead of
+// the LINE content API.
+func (c *LINEChannel) downloadMedia(msg lineMessage, filename string) string {
+	if msg.ContentProvider.Type == "external" && msg.ContentProvider.OriginalContentURL != "" {
+		return utils.DownloadFile(msg.ContentProvider.OriginalContentURL, filename, utils.DownloadOptions{
+			LoggerPrefix: "line",
+		})
+	}
+	return c.downloadContent(msg.ID, filename)
+}
+
 // downloadContent downloads media content from the LINE API.
 func (c *LINEChannel) downloadContent(messageID, filename string) string {
 	url := fmt.Sprintf(lineContentEndpoint, messageID)
@@ -0,0 +1,74 @@
+package channels
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func TestLINEStickerMappedToMedia(t *testing.T) {
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		w.Write([]byte("\x89PNG"))
+	}))
+	defer server.Close()
+
+	orig := lineStickerImageURL
+	lineStickerImageURL = server.URL + "/sticker/%s.png"
+	defer func() { lineStickerImageURL = orig }()
+
+	msgBus := bus.NewMessageBus()
+	ch, err := NewLINEChannel(config.LINEConfig{ChannelSecret: "s", ChannelAccessToken: "t"}, msgBus)
+	if err != nil {
+		t.Fatalf("NewLINEChannel: %v", err)
+	}
+
+	ch.processEvent(lineEvent{
+		Type:    "message",
+		Source:  lineSource{Type: "user", UserID: "U1"},
+		Message: []byte(`{"id":"m1","type":"sticker","packageId":"446","stickerId":"1988","keywords":["Happy","Yay"]}`),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, ok := msgBus.ConsumeInbound(ctx)
+	if !ok {
+		t.Fatal("expected an inbound message")
+	}
+	if msg.Content != "[sticker: Happy, Yay]" {
+		t.Errorf("content = %q", msg.Content)
+	}
+	if len(msg.Media) != 1 {
+		t.Errorf("media = %v, want the sticker image", msg.Media)
+	}
+	if requested != "/sticker/1988.png" {
+		t.Errorf("sticker URL path = %q", requested)
+	}
+}
+
+func TestLINEBuildTextMessagesSplitsLongReplies(t *testing.T) {
+	short := buildTextMessages("hello", "q1")
+	if len(short) != 1 || short[0]["quoteToken"] != "q1" {
+		t.Fatalf("short reply = %v", short)
+	}
+
+	long := buildTextMessages(strings.Repeat("line of text\n", 1200), "q1")
+	if len(long) < 2 || len(long) > lineMaxMessages {
+		t.Fatalf("got %d messages", len(long))
+	}
+	for i, m := range long {
+		if len(m["text"]) > lineMaxTextLength {
+			t.Errorf("message %d has %d chars", i, len(m["text"]))
+		}
+		if i > 0 && m["quoteToken"] != "" {
+			t.Errorf("message %d should not quote", i)
+		}
+	}
+}
@@ -8,20 +8,37 @@ package channels
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/commands"
 	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/constants"
+	"github.com/sipeed/picoclaw/pkg/focus"
+	"github.com/sipeed/picoclaw/pkg/knowledge"
+	"github.com/sipeed/picoclaw/pkg/kv"
 	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/proactive"
+	"github.com/sipeed/picoclaw/pkg/voice"
 )
 
 type Manager struct {
 	channels     map[string]Channel
 	bus          *bus.MessageBus
 	config       *config.Config
+	workspaceFor func(channel, chatID string) string
+	pairing      *PairingStore // shared by the bots that allow pairing, as they share its file
 	dispatchTask *asyncTask
+	outboxes     map[string]*outbox // channel name → send queue, when retries or the throttle are on
+	configPath   string             // where allowlist changes are saved, if set
+	configMu     sync.Mutex
 	mu           sync.RWMutex
 }
 
@@ -40,6 +57,15 @@ func NewManager(cfg *config.Config, messageBus *bus.MessageBus) (*Manager, error
 		return nil, err
 	}
 
+	for _, channel := range m.channels {
+		if rc, ok := channel.(interface{ SetRateLimit(config.RateLimitConfig) }); ok {
+			rc.SetRateLimit(cfg.Channels.RateLimit)
+		}
+		if bc, ok := channel.(interface{ SetBridges([]config.BridgeConfig) }); ok && len(cfg.Channels.Bridges) > 0 {
+			bc.SetBridges(cfg.Channels.Bridges)
+		}
+	}
+
 	return m, nil
 }
 
@@ -86,16 +112,19 @@ func (m *Manager) initChannels() error {
 	}
 
 	if m.config.Channels.Discord.Enabled && m.config.Channels.Discord.Token != "" {
-		logger.DebugC("channels", "Attempting to initialize Discord channel")
-		discord, err := NewDiscordChannel(m.config.Channels.Discord, m.bus)
-		if err != nil {
-			logger.ErrorCF("channels", "Failed to initialize Discord channel", map[string]interface{}{
-				"error": err.Error(),
+		m.initDiscord(m.config.Channels.Discord)
+	}
+	for _, bot := range m.config.Channels.DiscordBots {
+		if !bot.Enabled || bot.Token == "" {
+			continue
+		}
+		if !discordBotNameRe.MatchString(bot.Name) || m.channels[bot.ChannelName()] != nil {
+			logger.ErrorCF("channels", "Skipping Discord bot without a unique name", map[string]interface{}{
+				"name": bot.Name,
 			})
-		} else {
-			m.channels["discord"] = discord
-			logger.InfoC("channels", "Discord channel enabled successfully")
+			continue
 		}
+		m.initDiscord(bot)
 	}
 
 	if m.config.Channels.MaixCam.Enabled {
@@ -202,6 +231,149 @@ func (m *Manager) initChannels() error {
 		}
 	}
 
+	if m.config.Channels.WhatsAppCloud.Enabled && m.config.Channels.WhatsAppCloud.AccessToken != "" {
+		logger.DebugC("channels", "Attempting to initialize WhatsApp Cloud API channel")
+		waCloud, err := NewWhatsAppCloudChannel(m.config.Channels.WhatsAppCloud, m.bus)
+		if err != nil {
+			logger.ErrorCF("channels", "Failed to initialize WhatsApp Cloud API channel", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			m.channels["whatsapp_cloud"] = waCloud
+			logger.InfoC("channels", "WhatsApp Cloud API channel enabled successfully")
+		}
+	}
+
+	if m.config.Channels.Signal.Enabled && m.config.Channels.Signal.Account != "" {
+		logger.DebugC("channels", "Attempting to initialize Signal channel")
+		signalCh, err := NewSignalChannel(m.config.Channels.Signal, m.bus)
+		if err != nil {
+			logger.ErrorCF("channels", "Failed to initialize Signal channel", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			m.channels["signal"] = signalCh
+			logger.InfoC("channels", "Signal channel enabled successfully")
+		}
+	}
+
+	if m.config.Channels.Email.Enabled && m.config.Channels.Email.IMAPHost != "" {
+		logger.DebugC("channels", "Attempting to initialize email channel")
+		emailCh, err := NewEmailChannel(m.config.Channels.Email, m.bus)
+		if err != nil {
+			logger.ErrorCF("channels", "Failed to initialize email channel", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			m.channels["email"] = emailCh
+			logger.InfoC("channels", "Email channel enabled successfully")
+		}
+	}
+
+	if m.config.Channels.Mastodon.Enabled && m.config.Channels.Mastodon.AccessToken != "" {
+		logger.DebugC("channels", "Attempting to initialize Mastodon channel")
+		mastodonCh, err := NewMastodonChannel(m.config.Channels.Mastodon, m.bus)
+		if err != nil {
+			logger.ErrorCF("channels", "Failed to initialize Mastodon channel", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			m.channels["mastodon"] = mastodonCh
+			logger.InfoC("channels", "Mastodon channel enabled successfully")
+		}
+	}
+
+	if m.config.Channels.Webhook.Enabled && len(m.config.Channels.Webhook.Hooks) > 0 {
+		logger.DebugC("channels", "Attempting to initialize webhook channel")
+		webhookCh, err := NewWebhookChannel(m.config.Channels.Webhook, m.bus)
+		if err != nil {
+			logger.ErrorCF("channels", "Failed to initialize webhook channel", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			m.channels["webhook"] = webhookCh
+			logger.InfoC("channels", "Webhook channel enabled successfully")
+		}
+	}
+
+	if m.config.Channels.WebSocket.Enabled {
+		logger.DebugC("channels", "Attempting to initialize WebSocket channel")
+		wsCh, err := NewWebSocketChannel(m.config.Channels.WebSocket, m.bus)
+		if err != nil {
+			logger.ErrorCF("channels", "Failed to initialize WebSocket channel", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			m.channels["websocket"] = wsCh
+			logger.InfoC("channels", "WebSocket channel enabled successfully")
+		}
+	}
+
+	if m.config.Channels.API.Enabled {
+		logger.DebugC("channels", "Attempting to initialize API channel")
+		apiCh, err := NewAPIChannel(m.config.Channels.API, m.bus)
+		if err != nil {
+			logger.ErrorCF("channels", "Failed to initialize API channel", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			m.channels["api"] = apiCh
+			logger.InfoC("channels", "API channel enabled successfully")
+		}
+	}
+
+	if m.config.Channels.MQTT.Enabled && m.config.Channels.MQTT.Broker != "" {
+		logger.DebugC("channels", "Attempting to initialize MQTT channel")
+		mqttCh, err := NewMQTTChannel(m.config.Channels.MQTT, m.bus)
+		if err != nil {
+			logger.ErrorCF("channels", "Failed to initialize MQTT channel", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			m.channels["mqtt"] = mqttCh
+			logger.InfoC("channels", "MQTT channel enabled successfully")
+		}
+	}
+
+	if m.config.Channels.Ntfy.Enabled {
+		logger.DebugC("channels", "Attempting to initialize ntfy channel")
+		ntfyCh, err := NewNtfyChannel(m.config.Channels.Ntfy, m.bus)
+		if err != nil {
+			logger.ErrorCF("channels", "Failed to initialize ntfy channel", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			m.channels["ntfy"] = ntfyCh
+			logger.InfoC("channels", "ntfy channel enabled successfully")
+		}
+	}
+
+	if m.config.Channels.Pushover.Enabled {
+		logger.DebugC("channels", "Attempting to initialize Pushover channel")
+		pushoverCh, err := NewPushoverChannel(m.config.Channels.Pushover, m.bus)
+		if err != nil {
+			logger.ErrorCF("channels", "Failed to initialize Pushover channel", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			m.channels["pushover"] = pushoverCh
+			logger.InfoC("channels", "Pushover channel enabled successfully")
+		}
+	}
+
+	if m.config.Channels.Gotify.Enabled {
+		logger.DebugC("channels", "Attempting to initialize Gotify channel")
+		gotifyCh, err := NewGotifyChannel(m.config.Channels.Gotify, m.bus)
+		if err != nil {
+			logger.ErrorCF("channels", "Failed to initialize Gotify channel", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			m.channels["gotify"] = gotifyCh
+			logger.InfoC("channels", "Gotify channel enabled successfully")
+		}
+	}
+
 	logger.InfoCF("channels", "Channel initialization completed", map[string]interface{}{
 		"enabled_channels": len(m.channels),
 	})
@@ -209,6 +381,49 @@ func (m *Manager) initChannels() error {
 	return nil
 }
 
+// discordBotNameRe matches the names of extra Discord bots, which become
+// part of their channel name.
+var discordBotNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// initDiscord starts the Discord channel of one bot account.
+func (m *Manager) initDiscord(cfg config.DiscordConfig) {
+	name := cfg.ChannelName()
+	logger.DebugCF("channels", "Attempting to initialize Discord channel", map[string]interface{}{
+		"channel": name,
+	})
+	discord, err := NewDiscordChannel(cfg, m.bus)
+	if err != nil {
+		logger.ErrorCF("channels", "Failed to initialize Discord channel", map[string]interface{}{
+			"channel": name,
+			"error":   err.Error(),
+		})
+		return
+	}
+	discord.SetAdmins(m.config.Admin.Users)
+	if cfg.Pairing {
+		if m.pairing == nil {
+			m.pairing = NewPairingStore(m.config.WorkspacePath())
+		}
+		discord.SetPairing(m.pairing)
+	}
+	if cfg.Knowledge.Enabled {
+		idx, err := knowledge.Open(filepath.Join(m.config.WorkspacePath(), "knowledge", name+".json"))
+		if err != nil {
+			logger.ErrorCF("channels", "Failed to open knowledge index", map[string]interface{}{
+				"channel": name,
+				"error":   err.Error(),
+			})
+		} else {
+			discord.SetKnowledge(idx)
+		}
+	}
+	discord.SetObserveLog(filepath.Join(m.config.WorkspacePath(), "observed"))
+	m.channels[name] = discord
+	logger.InfoCF("channels", "Discord channel enabled successfully", map[string]interface{}{
+		"channel": name,
+	})
+}
+
 func (m *Manager) StartAll(ctx context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -223,6 +438,23 @@ func (m *Manager) StartAll(ctx context.Context) error {
 	dispatchCtx, cancel := context.WithCancel(ctx)
 	m.dispatchTask = &asyncTask{cancel: cancel}
 
+	m.outboxes = make(map[string]*outbox)
+	if retry, throttle := m.config.Channels.Retry, m.config.Channels.Throttle; retry.Enabled || throttle.Enabled {
+		if !retry.Enabled {
+			// Queued only for the throttle: one attempt per message
+			retry = config.RetryConfig{MaxAttempts: 1}
+		}
+		dir := filepath.Join(m.config.WorkspacePath(), "state", "outbox")
+		for name, channel := range m.channels {
+			ob := newOutbox(name, channel, dir, retry, m.send)
+			ob.throttle = throttleFor(name, throttle)
+			m.outboxes[name] = ob
+			if qc, ok := channel.(interface{ SetQueued(bool) }); ok {
+				qc.SetQueued(true)
+			}
+		}
+	}
+
 	go m.dispatchOutbound(dispatchCtx)
 
 	for name, channel := range m.channels {
@@ -237,6 +469,11 @@ func (m *Manager) StartAll(ctx context.Context) error {
 		}
 	}
 
+	// Started after the channels so messages left from the last run can go out
+	for _, ob := range m.outboxes {
+		go ob.run(dispatchCtx)
+	}
+
 	logger.InfoC("channels", "All channels started")
 	return nil
 }
@@ -282,6 +519,11 @@ func (m *Manager) dispatchOutbound(ctx context.Context) {
 				continue
 			}
 
+			if m.heldBack(msg) {
+				continue
+			}
+			m.forwardAlert(ctx, msg)
+
 			// Silently skip internal channels
 			if constants.IsInternalChannel(msg.Channel) {
 				continue
@@ -292,13 +534,28 @@ func (m *Manager) dispatchOutbound(ctx context.Context) {
 			m.mu.RUnlock()
 
 			if !exists {
-				logger.WarnCF("channels", "Unknown channel for outbound message", map[string]interface{}{
-					"channel": msg.Channel,
-				})
+				if !msg.TurnEnd() {
+					logger.WarnCF("channels", "Unknown channel for outbound message", map[string]interface{}{
+						"channel": msg.Channel,
+					})
+				}
+				continue
+			}
+			// A turn end goes the way of the replies before it, so it
+			// can't overtake one still queued
+			if _, tracksTurns := channel.(TurnChannel); msg.TurnEnd() && !tracksTurns {
+				continue
+			}
+
+			m.mu.RLock()
+			ob := m.outboxes[msg.Channel]
+			m.mu.RUnlock()
+			if ob != nil {
+				ob.push(msg)
 				continue
 			}
 
-			if err := channel.Send(ctx, msg); err != nil {
+			if err := m.send(ctx, channel, msg); err != nil {
 				logger.ErrorCF("channels", "Error sending message to channel", map[string]interface{}{
 					"channel": msg.Channel,
 					"error":   err.Error(),
@@ -308,6 +565,78 @@ func (m *Manager) dispatchOutbound(ctx context.Context) {
 	}
 }
 
+// SetWorkspaceResolver sets how the workspace of the agent that handles a
+// chat is found, for the focus sessions and frequency settings its tools
+// keep there.
+func (m *Manager) SetWorkspaceResolver(fn func(channel, chatID string) string) {
+	m.workspaceFor = fn
+}
+
+// chatWorkspace returns the workspace of the agent that handles a chat.
+func (m *Manager) chatWorkspace(channel, chatID string) string {
+	if m.workspaceFor != nil {
+		return m.workspaceFor(channel, chatID)
+	}
+	return m.config.WorkspacePath()
+}
+
+// heldBack reports whether a proactive message isn't sent now: during a
+// focus session in its chat it is queued until the session ends, and over
+// the chat's frequency setting it is dropped. Held back messages aren't
+// forwarded as alerts either.
+func (m *Manager) heldBack(msg bus.OutboundMessage) bool {
+	if msg.Proactive == "" {
+		return false
+	}
+	workspace := m.chatWorkspace(msg.Channel, msg.ChatID)
+	if m.config.Tools.Focus.Enabled {
+		if focus.NewStore(workspace).Hold(msg, time.Now()) {
+			logger.InfoCF("channels", "Proactive message held until the focus session ends", map[string]interface{}{
+				"channel": msg.Channel,
+				"kind":    msg.Proactive,
+			})
+			return true
+		}
+	}
+	if !proactive.NewEngine(workspace, m.config.Proactive).Allow(msg.Channel, msg.ChatID, time.Now()) {
+		logger.InfoCF("channels", "Proactive message held back by the chat's frequency setting", map[string]interface{}{
+			"channel": msg.Channel,
+			"kind":    msg.Proactive,
+		})
+		return true
+	}
+	return false
+}
+
+// forwardAlert sends a copy of an alert to every notifier channel that
+// subscribes to its kind, other than the one it was sent to.
+func (m *Manager) forwardAlert(ctx context.Context, msg bus.OutboundMessage) {
+	if msg.Alert == "" || msg.Partial {
+		return
+	}
+
+	m.mu.RLock()
+	var notifiers []AlertChannel
+	for name, ch := range m.channels {
+		if ac, ok := ch.(AlertChannel); ok && name != msg.Channel && ac.ForwardsAlert(msg.Alert) {
+			notifiers = append(notifiers, ac)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, ac := range notifiers {
+		fwd := msg
+		fwd.Channel, fwd.ChatID, fwd.OnSent = ac.Name(), "", nil
+		if err := m.send(ctx, ac, fwd); err != nil {
+			logger.ErrorCF("channels", "Error forwarding alert", map[string]interface{}{
+				"channel": ac.Name(),
+				"kind":    msg.Alert,
+				"error":   err.Error(),
+			})
+		}
+	}
+}
+
 func (m *Manager) GetChannel(name string) (Channel, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -315,6 +644,131 @@ func (m *Manager) GetChannel(name string) (Channel, bool) {
 	return channel, ok
 }
 
+// SetConfigPath makes allowlist changes persist to the config file at
+// path, so they survive a restart.
+func (m *Manager) SetConfigPath(path string) {
+	m.configMu.Lock()
+	defer m.configMu.Unlock()
+	m.configPath = path
+}
+
+// AddAllowedUser lets a user in on a running channel and adds them to the
+// channel's allow_from in the config file. When saving fails the user is
+// still let in, and the error says so.
+func (m *Manager) AddAllowedUser(name, userID string) (bool, error) {
+	ac, err := m.allowListChannel(name)
+	if err != nil {
+		return false, err
+	}
+	if !ac.AddAllowedUser(userID) {
+		return false, nil
+	}
+	err = m.saveAllowFrom(name, func(allow []string) []string {
+		for _, entry := range allow {
+			if entry == userID {
+				return allow
+			}
+		}
+		return append(allow, userID)
+	})
+	return true, err
+}
+
+// RemoveAllowedUser takes a user off a running channel's allowlist and
+// its allow_from in the config file.
+func (m *Manager) RemoveAllowedUser(name, userID string) (bool, error) {
+	ac, err := m.allowListChannel(name)
+	if err != nil {
+		return false, err
+	}
+	removed, err := ac.RemoveAllowedUser(userID)
+	if !removed || err != nil {
+		return removed, err
+	}
+	err = m.saveAllowFrom(name, func(allow []string) []string {
+		kept, _ := removeAllowEntries(allow, userID)
+		return kept
+	})
+	return true, err
+}
+
+// AllowList returns the allowlist of a running channel.
+func (m *Manager) AllowList(name string) ([]string, error) {
+	ac, err := m.allowListChannel(name)
+	if err != nil {
+		return nil, err
+	}
+	return ac.AllowList(), nil
+}
+
+func (m *Manager) allowListChannel(name string) (AllowListChannel, error) {
+	m.mu.RLock()
+	channel, ok := m.channels[name]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("channel %s is not running", name)
+	}
+	ac, ok := channel.(AllowListChannel)
+	if !ok {
+		return nil, fmt.Errorf("channel %s has no allowlist", name)
+	}
+	return ac, nil
+}
+
+// saveAllowFrom applies edit to a channel's allow_from in the loaded
+// config and, with a config path set, in the file. Only the allow_from in
+// the file changes, so edits made to it since the start are kept and
+// secrets from the environment stay out of it.
+func (m *Manager) saveAllowFrom(name string, edit func([]string) []string) error {
+	m.configMu.Lock()
+	defer m.configMu.Unlock()
+
+	if allow := m.config.Channels.AllowFrom(name); allow != nil {
+		*allow = edit(*allow)
+	}
+	if m.configPath == "" {
+		return nil
+	}
+
+	var saved []string
+	err := config.EditFile(m.configPath, []string{"channels", name, "allow_from"}, func(current json.RawMessage) (any, error) {
+		var allow config.FlexibleStringSlice
+		if err := json.Unmarshal(current, &allow); err != nil {
+			return nil, err
+		}
+		saved = edit(allow)
+		return saved, nil
+	})
+	if err != nil {
+		return fmt.Errorf("applied until restart, but saving %s failed: %w", m.configPath, err)
+	}
+	logger.InfoCF("channels", "Saved allowlist", map[string]any{
+		"channel": name,
+		"entries": len(saved),
+	})
+	return nil
+}
+
+// ReloadAllowLists gives the running channels the allowlists in cfg and
+// returns the names of the channels updated.
+func (m *Manager) ReloadAllowLists(cfg *config.ChannelsConfig) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var names []string
+	for name, channel := range m.channels {
+		ac, ok := channel.(AllowListChannel)
+		allow := cfg.AllowFrom(name)
+		if !ok || allow == nil {
+			continue
+		}
+		ac.SetAllowList(*allow)
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (m *Manager) GetStatus() map[string]interface{} {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -340,6 +794,97 @@ func (m *Manager) GetEnabledChannels() []string {
 	return names
 }
 
+// SetVideoProcessor enables video transcripts and keyframes on every
+// channel built on BaseChannel.
+func (m *Manager) SetVideoProcessor(p *voice.VideoProcessor) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, channel := range m.channels {
+		if vc, ok := channel.(interface{ SetVideoProcessor(*voice.VideoProcessor) }); ok {
+			vc.SetVideoProcessor(p)
+		}
+	}
+}
+
+// SetVision enables forwarding image attachments to the model on every
+// channel built on BaseChannel.
+func (m *Manager) SetVision(cfg config.VisionConfig) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, channel := range m.channels {
+		if vc, ok := channel.(interface{ SetVision(config.VisionConfig) }); ok {
+			vc.SetVision(cfg)
+		}
+	}
+}
+
+// SetStore keeps seen message IDs and rate limit buckets of every channel
+// built on BaseChannel in store.
+func (m *Manager) SetStore(store kv.Store) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, channel := range m.channels {
+		if sc, ok := channel.(interface{ SetStore(kv.Store) }); ok {
+			sc.SetStore(store)
+		}
+	}
+}
+
+// SetSummarizer lets channels that summarize on their own schedule, such
+// as Discord's moderator digests, use summarize.
+func (m *Manager) SetSummarizer(summarize Summarizer) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, channel := range m.channels {
+		if sc, ok := channel.(interface{ SetSummarizer(Summarizer) }); ok {
+			sc.SetSummarizer(summarize)
+		}
+	}
+}
+
+// RegisterRoutes mounts the routes of every HTTPChannel with handle,
+// e.g. the gateway server's Handle.
+func (m *Manager) RegisterRoutes(handle func(pattern string, handler http.Handler)) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, channel := range m.channels {
+		if hc, ok := channel.(HTTPChannel); ok {
+			for pattern, handler := range hc.Routes() {
+				handle(pattern, handler)
+			}
+		}
+	}
+}
+
+// SetCommands offers cmds for autocomplete on every CommandChannel.
+func (m *Manager) SetCommands(ctx context.Context, cmds []commands.Command) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for name, channel := range m.channels {
+		cc, ok := channel.(CommandChannel)
+		if !ok || !channel.IsRunning() {
+			continue
+		}
+		if err := cc.SetCommands(ctx, cmds); err != nil {
+			logger.WarnCF("channels", "Failed to register commands", map[string]interface{}{
+				"channel": name,
+				"error":   err.Error(),
+			})
+			continue
+		}
+		logger.DebugCF("channels", "Registered commands", map[string]interface{}{
+			"channel":  name,
+			"commands": len(cmds),
+		})
+	}
+}
+
 func (m *Manager) RegisterChannel(name string, channel Channel) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -367,5 +912,38 @@ func (m *Manager) SendToChannel(ctx context.Context, channelName, chatID, conten
 		Content: content,
 	}
 
-	return channel.Send(ctx, msg)
+	return m.send(ctx, channel, msg)
+}
+
+// send delivers msg bounded by the channel's configured send timeout.
+// Embed-only messages get a text rendering for channels without embeds.
+func (m *Manager) send(ctx context.Context, channel Channel, msg bus.OutboundMessage) error {
+	if msg.TurnEnd() {
+		if tc, ok := channel.(TurnChannel); ok {
+			tc.EndTurn(msg.ChatID)
+		}
+		return nil
+	}
+	progressive, canShowPartial := channel.(ProgressiveChannel)
+	if msg.Partial && !canShowPartial {
+		return nil
+	}
+	if msg.Embed != nil && msg.Content == "" {
+		msg.Content = msg.Embed.Text()
+	}
+	if d := m.config.Timeouts.SendTimeout(msg.Channel); d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	if msg.Partial {
+		return progressive.SendPartial(ctx, msg)
+	}
+	if err := channel.Send(ctx, msg); err != nil {
+		return err
+	}
+	if msg.OnSent != nil {
+		msg.OnSent()
+	}
+	return nil
 }
@@ -0,0 +1,434 @@
+package channels
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/markdown"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+const (
+	mastodonReconnectDelay    = 5 * time.Second
+	mastodonMaxReconnectDelay = 2 * time.Minute
+	mastodonDefaultMaxChars   = 500
+)
+
+var (
+	mastodonBreakRe = regexp.MustCompile(`(?i)<br\s*/?>`)
+	mastodonParaRe  = regexp.MustCompile(`(?i)</p>\s*<p[^>]*>`)
+	mastodonTagRe   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// MastodonChannel implements the Channel interface for a Mastodon account.
+// Mentions and direct messages arrive as notifications on the streaming API;
+// replies are posted in-thread with the same visibility as the mention.
+type MastodonChannel struct {
+	*BaseChannel
+	config   config.MastodonConfig
+	instance string
+	maxChars int
+	client   *http.Client
+	self     mastodonAccount
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	mu      sync.Mutex
+	threads map[string]mastodonThread // chatID (acct) -> status to reply to
+}
+
+// mastodonThread is where the next reply to a chat goes.
+type mastodonThread struct {
+	statusID   string
+	visibility string
+}
+
+type mastodonAccount struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	Acct        string `json:"acct"`
+	DisplayName string `json:"display_name"`
+}
+
+type mastodonNotification struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Account mastodonAccount `json:"account"`
+	Status  *mastodonStatus `json:"status"`
+}
+
+type mastodonStatus struct {
+	ID          string          `json:"id"`
+	Content     string          `json:"content"`
+	SpoilerText string          `json:"spoiler_text"`
+	Visibility  string          `json:"visibility"`
+	Media       []mastodonMedia `json:"media_attachments"`
+}
+
+type mastodonMedia struct {
+	Type        string `json:"type"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+// NewMastodonChannel creates a new Mastodon channel instance.
+func NewMastodonChannel(cfg config.MastodonConfig, messageBus *bus.MessageBus) (*MastodonChannel, error) {
+	if cfg.Instance == "" || cfg.AccessToken == "" {
+		return nil, fmt.Errorf("mastodon instance and access_token are required")
+	}
+
+	instance := strings.TrimRight(cfg.Instance, "/")
+	if !strings.HasPrefix(instance, "http://") && !strings.HasPrefix(instance, "https://") {
+		instance = "https://" + instance
+	}
+
+	maxChars := cfg.MaxChars
+	if maxChars <= 0 {
+		maxChars = mastodonDefaultMaxChars
+	}
+
+	base := NewBaseChannel("mastodon", cfg, messageBus, cfg.AllowFrom)
+
+	return &MastodonChannel{
+		BaseChannel: base,
+		config:      cfg,
+		instance:    instance,
+		maxChars:    maxChars,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		threads:     make(map[string]mastodonThread),
+	}, nil
+}
+
+// Start verifies the access token and connects to the notification stream.
+func (c *MastodonChannel) Start(ctx context.Context) error {
+	logger.InfoCF("mastodon", "Starting Mastodon channel", map[string]interface{}{
+		"instance": c.instance,
+	})
+
+	c.ctx, c.cancel = context.WithCancel(ctx)
+
+	if err := c.api(c.ctx, http.MethodGet, "/api/v1/accounts/verify_credentials", nil, &c.self); err != nil {
+		c.cancel()
+		return fmt.Errorf("failed to verify mastodon credentials: %w", err)
+	}
+
+	go c.streamLoop()
+
+	c.setRunning(true)
+	logger.InfoCF("mastodon", "Mastodon channel started", map[string]interface{}{
+		"account": c.self.Acct,
+	})
+	return nil
+}
+
+// Stop disconnects from the stream.
+func (c *MastodonChannel) Stop(ctx context.Context) error {
+	logger.InfoC("mastodon", "Stopping Mastodon channel")
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	c.setRunning(false)
+	logger.InfoC("mastodon", "Mastodon channel stopped")
+	return nil
+}
+
+// streamLoop keeps the streaming connection open, reconnecting with backoff.
+func (c *MastodonChannel) streamLoop() {
+	delay := mastodonReconnectDelay
+	for {
+		connected, err := c.readStream()
+		if c.ctx.Err() != nil {
+			return
+		}
+		if connected {
+			delay = mastodonReconnectDelay
+		}
+		if err != nil {
+			logger.WarnCF("mastodon", "Stream disconnected", map[string]interface{}{
+				"error":       err.Error(),
+				"retry_after": delay.String(),
+			})
+		}
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > mastodonMaxReconnectDelay {
+			delay = mastodonMaxReconnectDelay
+		}
+	}
+}
+
+// readStream reads the notification stream until it ends. connected
+// reports whether the stream was opened, which restarts the backoff.
+func (c *MastodonChannel) readStream() (connected bool, err error) {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, c.instance+"/api/v1/streaming/user/notification", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+
+	// The stream is long-lived, so don't use the client timeout
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("stream returned status %d", resp.StatusCode)
+	}
+
+	logger.InfoC("mastodon", "Connected to Mastodon streaming API")
+
+	reader := bufio.NewReader(resp.Body)
+	var event string
+	var data strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return true, fmt.Errorf("stream closed")
+			}
+			return true, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "":
+			if event == "notification" && data.Len() > 0 {
+				c.handleNotification([]byte(data.String()))
+			}
+			event = ""
+			data.Reset()
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+}
+
+func (c *MastodonChannel) handleNotification(data []byte) {
+	var n mastodonNotification
+	if err := json.Unmarshal(data, &n); err != nil {
+		logger.DebugCF("mastodon", "Ignoring unparseable notification", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Direct messages are delivered as mentions with "direct" visibility
+	if n.Type != "mention" || n.Status == nil || n.Account.ID == c.self.ID {
+		return
+	}
+	status := n.Status
+
+	// Use the "id|alias" form so allow_from may list either the account ID
+	// or the handle (user@instance).
+	senderID := n.Account.ID + "|" + n.Account.Acct
+	if !c.admitsMessage(senderID) {
+		logger.DebugCF("mastodon", "Mention rejected by allowlist", map[string]interface{}{
+			"sender_id": senderID,
+		})
+		return
+	}
+
+	content := c.stripSelfMention(mastodonHTMLToText(status.Content))
+	if status.SpoilerText != "" {
+		content = fmt.Sprintf("[content warning: %s]\n%s", status.SpoilerText, content)
+	}
+
+	var mediaPaths []string
+	for _, media := range status.Media {
+		if media.URL == "" {
+			continue
+		}
+		filename := path.Base(media.URL)
+		if localPath := utils.DownloadFile(media.URL, filename, utils.DownloadOptions{LoggerPrefix: "mastodon"}); localPath != "" {
+			mediaPaths = append(mediaPaths, localPath)
+		}
+		if media.Description != "" {
+			content += fmt.Sprintf("\n[%s: %s]", media.Type, media.Description)
+		} else {
+			content += fmt.Sprintf("\n[%s]", media.Type)
+		}
+	}
+
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return
+	}
+
+	chatID := n.Account.Acct
+	c.mu.Lock()
+	c.threads[chatID] = mastodonThread{statusID: status.ID, visibility: status.Visibility}
+	c.mu.Unlock()
+
+	peerKind := "group"
+	if status.Visibility == "direct" {
+		peerKind = "direct"
+	}
+
+	metadata := map[string]string{
+		"platform":   "mastodon",
+		"status_id":  status.ID,
+		"visibility": status.Visibility,
+		"peer_kind":  peerKind,
+		"peer_id":    n.Account.ID,
+		"user_name":  n.Account.DisplayName,
+	}
+
+	logger.DebugCF("mastodon", "Received mention", map[string]interface{}{
+		"sender_id":  senderID,
+		"visibility": status.Visibility,
+		"preview":    utils.Truncate(content, 50),
+	})
+
+	c.HandleMessage(senderID, chatID, content, mediaPaths, metadata)
+}
+
+// stripSelfMention removes the bot's own @handle from the post text,
+// keeping line breaks intact.
+func (c *MastodonChannel) stripSelfMention(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		var kept []string
+		for _, word := range strings.Fields(line) {
+			if !c.isSelfMention(word) {
+				kept = append(kept, word)
+			}
+		}
+		lines[i] = strings.Join(kept, " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func (c *MastodonChannel) isSelfMention(word string) bool {
+	handle, ok := strings.CutPrefix(word, "@")
+	if !ok || c.self.Username == "" {
+		return false
+	}
+	return handle == c.self.Username || handle == c.self.Acct || strings.HasPrefix(handle, c.self.Username+"@")
+}
+
+// mastodonHTMLToText converts status HTML to plain text, keeping paragraph
+// and line breaks.
+func mastodonHTMLToText(s string) string {
+	s = mastodonParaRe.ReplaceAllString(s, "\n\n")
+	s = mastodonBreakRe.ReplaceAllString(s, "\n")
+	s = mastodonTagRe.ReplaceAllString(s, "")
+	return strings.TrimSpace(html.UnescapeString(s))
+}
+
+// Send posts the reply as a thread under the chat's latest mention, split
+// into posts that fit the instance's character limit. Without a mention to
+// reply to, the message goes out as a direct post.
+func (c *MastodonChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	if !c.IsRunning() {
+		return fmt.Errorf("mastodon channel not running")
+	}
+
+	c.mu.Lock()
+	thread, ok := c.threads[msg.ChatID]
+	c.mu.Unlock()
+	if !ok {
+		thread = mastodonThread{visibility: "direct"}
+	}
+
+	prefix := "@" + msg.ChatID + " "
+	chunkLen := c.maxChars - len([]rune(prefix))
+	if chunkLen < 50 {
+		chunkLen = 50
+	}
+
+	replyTo := thread.statusID
+	for _, chunk := range markdown.Split(msg.Content, markdown.Plain, chunkLen) {
+		body := map[string]interface{}{
+			"status":     prefix + chunk,
+			"visibility": thread.visibility,
+		}
+		if replyTo != "" {
+			body["in_reply_to_id"] = replyTo
+		}
+
+		var posted mastodonStatus
+		if err := c.api(ctx, http.MethodPost, "/api/v1/statuses", body, &posted); err != nil {
+			return fmt.Errorf("failed to post mastodon reply: %w", err)
+		}
+		// Chain the remaining chunks under the post just made
+		replyTo = posted.ID
+	}
+
+	if replyTo != "" {
+		c.mu.Lock()
+		c.threads[msg.ChatID] = mastodonThread{statusID: replyTo, visibility: thread.visibility}
+		c.mu.Unlock()
+	}
+
+	logger.DebugCF("mastodon", "Reply posted", map[string]interface{}{
+		"chat_id":    msg.ChatID,
+		"visibility": thread.visibility,
+	})
+	return nil
+}
+
+// api performs an authenticated REST call and decodes the JSON response.
+func (c *MastodonChannel) api(ctx context.Context, method, endpoint string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.instance+endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("mastodon API returned status %d: %s", resp.StatusCode, utils.Truncate(string(respBody), 200))
+	}
+	if out != nil {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func newTestMastodonChannel(t *testing.T, instance string, maxChars int, allowFrom ...string) (*MastodonChannel, *bus.MessageBus) {
+	t.Helper()
+	msgBus := bus.NewMessageBus()
+	ch, err := NewMastodonChannel(config.MastodonConfig{
+		Instance:    instance,
+		AccessToken: "token",
+		MaxChars:    maxChars,
+		AllowFrom:   allowFrom,
+	}, msgBus)
+	if err != nil {
+		t.Fatalf("NewMastodonChannel: %v", err)
+	}
+	ch.self = mastodonAccount{ID: "1", Username: "picoclaw", Acct: "picoclaw"}
+	ch.ctx = context.Background()
+	return ch, msgBus
+}
+
+func TestMastodonHandleNotification(t *testing.T) {
+	ch, msgBus := newTestMastodonChannel(t, "https://example.social", 0, "alice@other.social")
+
+	ch.handleNotification([]byte(`{"id":"n1","type":"mention",
+		"account":{"id":"42","username":"alice","acct":"alice@other.social","display_name":"Alice"},
+		"status":{"id":"s1","visibility":"direct",
+			"content":"<p><span class=\"h-card\"><a href=\"https://example.social/@picoclaw\" class=\"u-url mention\">@<span>picoclaw</span></a></span> what&#39;s up?</p><p>second line</p>"}}`))
+	ch.handleNotification([]byte(`{"type":"favourite","account":{"id":"42","acct":"alice@other.social"},"status":{"id":"s2","content":"x"}}`))
+	ch.handleNotification([]byte(`{"type":"mention","account":{"id":"7","acct":"mallory"},"status":{"id":"s3","content":"hi"}}`))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	msg, ok := msgBus.ConsumeInbound(ctx)
+	if !ok {
+		t.Fatal("expected an inbound message")
+	}
+	if msg.ChatID != "alice@other.social" || msg.Content != "what's up?\n\nsecond line" {
+		t.Errorf("got chat %q content %q", msg.ChatID, msg.Content)
+	}
+	if msg.Metadata["peer_kind"] != "direct" {
+		t.Errorf("peer_kind = %q, want direct", msg.Metadata["peer_kind"])
+	}
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel2()
+	if extra, ok := msgBus.ConsumeInbound(ctx2); ok {
+		t.Errorf("unexpected extra message: %+v", extra)
+	}
+}
+
+func TestMastodonSendThreadsChunks(t *testing.T) {
+	var posts []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/statuses" || r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		posts = append(posts, body)
+		fmt.Fprintf(w, `{"id":"p%d"}`, len(posts))
+	}))
+	defer server.Close()
+
+	ch, _ := newTestMastodonChannel(t, server.URL, 100)
+	ch.setRunning(true)
+	ch.threads["alice@other.social"] = mastodonThread{statusID: "s1", visibility: "unlisted"}
+
+	long := strings.Repeat("word ", 60)
+	if err := ch.Send(context.Background(), bus.OutboundMessage{ChatID: "alice@other.social", Content: long}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if len(posts) < 2 {
+		t.Fatalf("expected the reply to be split, got %d posts", len(posts))
+	}
+	for i, p := range posts {
+		status := p["status"].(string)
+		if !strings.HasPrefix(status, "@alice@other.social ") || len([]rune(status)) > 100 {
+			t.Errorf("post %d = %q", i, status)
+		}
+		if p["visibility"] != "unlisted" {
+			t.Errorf("post %d visibility = %v", i, p["visibility"])
+		}
+		want := "s1"
+		if i > 0 {
+			want = fmt.Sprintf("p%d", i)
+		}
+		if p["in_reply_to_id"] != want {
+			t.Errorf("post %d in_reply_to_id = %v, want %s", i, p["in_reply_to_id"], want)
+		}
+	}
+}
+
+func TestMastodonReadStream_Connected(t *testing.T) {
+	up := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(":thump\n\n"))
+	}))
+	defer server.Close()
+
+	ch, _ := newTestMastodonChannel(t, server.URL, 500)
+	if connected, err := ch.readStream(); !connected || err == nil {
+		t.Errorf("stream that opened then closed: connected = %v, err = %v", connected, err)
+	}
+	up = false
+	if connected, err := ch.readStream(); connected || err == nil {
+		t.Errorf("refused stream: connected = %v, err = %v", connected, err)
+	}
+}
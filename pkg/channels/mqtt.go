@@ -0,0 +1,178 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const mqttTimeout = 10 * time.Second
+
+// MQTTChannel talks to the agent over an MQTT broker, for embedded devices
+// and Home Assistant automations. It subscribes to InTopic, e.g.
+// "picoclaw/in/+", and publishes each reply as plain text to
+// "<OutTopic>/<id>", where id is the part of the topic the wildcard
+// matched: a message on picoclaw/in/kitchen is answered on
+// picoclaw/out/kitchen.
+//
+// Payloads are the message text, or JSON in the webhook channel's format
+// ({"text": ..., "sender_id": ...}). The id is the chat ID, and the sender
+// unless the payload names one.
+type MQTTChannel struct {
+	*BaseChannel
+	config config.MQTTConfig
+	client mqtt.Client
+}
+
+func NewMQTTChannel(cfg config.MQTTConfig, messageBus *bus.MessageBus) (*MQTTChannel, error) {
+	if cfg.Broker == "" {
+		return nil, fmt.Errorf("mqtt broker is required")
+	}
+	if cfg.InTopic == "" || cfg.OutTopic == "" {
+		return nil, fmt.Errorf("mqtt in_topic and out_topic are required")
+	}
+	if cfg.QoS < 0 || cfg.QoS > 2 {
+		return nil, fmt.Errorf("mqtt qos must be 0, 1 or 2")
+	}
+
+	c := &MQTTChannel{
+		BaseChannel: NewBaseChannel("mqtt", cfg, messageBus, cfg.AllowFrom),
+		config:      cfg,
+	}
+
+	clientID := cfg.ClientID
+	if clientID == "" {
+		clientID = "picoclaw"
+	}
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(clientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectTimeout(mqttTimeout).
+		SetOnConnectHandler(c.subscribe).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			logger.WarnCF("mqtt", "Connection to broker lost, reconnecting", map[string]any{
+				"error": err.Error(),
+			})
+		})
+	c.client = mqtt.NewClient(opts)
+	return c, nil
+}
+
+func (c *MQTTChannel) Start(ctx context.Context) error {
+	logger.InfoCF("mqtt", "Starting MQTT channel", map[string]any{
+		"broker": c.config.Broker,
+		"topic":  c.config.InTopic,
+	})
+	token := c.client.Connect()
+	if !token.WaitTimeout(mqttTimeout) {
+		c.client.Disconnect(0)
+		return fmt.Errorf("mqtt connect to %s timed out", c.config.Broker)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("mqtt connect: %w", err)
+	}
+	c.setRunning(true)
+	logger.InfoC("mqtt", "MQTT channel started")
+	return nil
+}
+
+func (c *MQTTChannel) Stop(ctx context.Context) error {
+	c.client.Disconnect(250)
+	c.setRunning(false)
+	logger.InfoC("mqtt", "MQTT channel stopped")
+	return nil
+}
+
+// Send publishes the reply to "<OutTopic>/<chat ID>".
+func (c *MQTTChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	if !c.IsRunning() {
+		return fmt.Errorf("mqtt channel not running")
+	}
+	content := msg.Content
+	if content == "" && msg.Embed != nil {
+		content = msg.Embed.Text()
+	}
+
+	topic := strings.TrimSuffix(c.config.OutTopic, "/") + "/" + msg.ChatID
+	token := c.client.Publish(topic, byte(c.config.QoS), false, content)
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// subscribe runs on every (re)connect, since the broker forgets the
+// subscription of a clean session.
+func (c *MQTTChannel) subscribe(client mqtt.Client) {
+	token := client.Subscribe(c.config.InTopic, byte(c.config.QoS), c.handleMessage)
+	if token.WaitTimeout(mqttTimeout) && token.Error() == nil {
+		return
+	}
+	err := token.Error()
+	if err == nil {
+		err = fmt.Errorf("timed out")
+	}
+	logger.ErrorCF("mqtt", "Failed to subscribe", map[string]any{
+		"topic": c.config.InTopic,
+		"error": err.Error(),
+	})
+}
+
+func (c *MQTTChannel) handleMessage(_ mqtt.Client, m mqtt.Message) {
+	id := mqttTopicID(c.config.InTopic, m.Topic())
+	if id == "" {
+		return
+	}
+	req := parseWebhookRequest("", m.Payload())
+	if strings.TrimSpace(req.Text) == "" && len(req.Media) == 0 {
+		return
+	}
+	senderID := req.SenderID
+	if senderID == "" {
+		senderID = id
+	}
+
+	logger.InfoCF("mqtt", "Received MQTT message", map[string]any{
+		"topic":     m.Topic(),
+		"sender_id": senderID,
+	})
+	metadata := map[string]string{"topic": m.Topic(), "peer_kind": "direct", "peer_id": senderID}
+	// paho delivers messages in order on one goroutine; don't hold it up
+	go func() {
+		c.HandleMessage(senderID, id, req.Text, callerMedia("mqtt", req.Media), metadata)
+	}()
+}
+
+// mqttTopicID returns the part of topic matched by the last wildcard in
+// filter, or the topic's last level when filter has none.
+func mqttTopicID(filter, topic string) string {
+	fl := strings.Split(filter, "/")
+	tl := strings.Split(topic, "/")
+	id := tl[len(tl)-1]
+	for i, f := range fl {
+		switch {
+		case f == "#":
+			if i < len(tl) {
+				return strings.Join(tl[i:], "/")
+			}
+			return id
+		case i >= len(tl):
+			return id
+		case f == "+":
+			id = tl[i]
+		}
+	}
+	return id
+}
@@ -0,0 +1,65 @@
+package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func TestMQTTTopicID(t *testing.T) {
+	for _, tc := range []struct {
+		filter, topic, want string
+	}{
+		{"picoclaw/in/+", "picoclaw/in/kitchen", "kitchen"},
+		{"home/+/say", "home/garage/say", "garage"},
+		{"picoclaw/in/#", "picoclaw/in/floor1/hall", "floor1/hall"},
+		{"picoclaw/in", "picoclaw/in", "in"},
+	} {
+		if got := mqttTopicID(tc.filter, tc.topic); got != tc.want {
+			t.Errorf("mqttTopicID(%q, %q) = %q, want %q", tc.filter, tc.topic, got, tc.want)
+		}
+	}
+}
+
+// fakeMQTTMessage is the part of mqtt.Message the channel reads.
+type fakeMQTTMessage struct {
+	mqtt.Message
+	topic   string
+	payload string
+}
+
+func (m fakeMQTTMessage) Topic() string   { return m.topic }
+func (m fakeMQTTMessage) Payload() []byte { return []byte(m.payload) }
+
+func TestMQTTChannel_HandleMessage(t *testing.T) {
+	mb := bus.NewMessageBus()
+	c, err := NewMQTTChannel(config.MQTTConfig{
+		Broker:   "tcp://localhost:1883",
+		InTopic:  "picoclaw/in/+",
+		OutTopic: "picoclaw/out",
+		QoS:      1,
+	}, mb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for _, tc := range []struct {
+		payload, sender, content string
+	}{
+		{"Is the garage door open?", "kitchen", "Is the garage door open?"},
+		{`{"text": "Lights off", "sender_id": "ha"}`, "ha", "Lights off"},
+	} {
+		c.handleMessage(nil, fakeMQTTMessage{topic: "picoclaw/in/kitchen", payload: tc.payload})
+		msg, ok := mb.ConsumeInbound(ctx)
+		if !ok || msg.Channel != "mqtt" || msg.ChatID != "kitchen" || msg.SenderID != tc.sender || msg.Content != tc.content {
+			t.Errorf("payload %q: inbound = %+v", tc.payload, msg)
+		}
+	}
+}
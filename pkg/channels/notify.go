@@ -0,0 +1,87 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+// AlertChannel is implemented by the outbound-only notifier channels
+// (ntfy, Pushover, Gotify). The channel manager forwards alerts of the
+// kinds a notifier subscribes to, whichever chat they were sent to, so
+// errors and reminders reach a phone even when no one watches the chat.
+type AlertChannel interface {
+	Channel
+	ForwardsAlert(kind string) bool
+}
+
+// notifier is the part the notifier channels share: they only send, so
+// starting one just marks it running.
+type notifier struct {
+	*BaseChannel
+	alerts []string
+	client *http.Client
+}
+
+func newNotifier(name string, cfg interface{}, messageBus *bus.MessageBus, alerts []string) notifier {
+	return notifier{
+		BaseChannel: NewBaseChannel(name, cfg, messageBus, nil),
+		alerts:      alerts,
+		client:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (n *notifier) Start(ctx context.Context) error {
+	n.setRunning(true)
+	return nil
+}
+
+func (n *notifier) Stop(ctx context.Context) error {
+	n.setRunning(false)
+	return nil
+}
+
+// IsAllowed rejects everyone, since notifiers receive no messages.
+func (n *notifier) IsAllowed(senderID string) bool {
+	return false
+}
+
+func (n *notifier) ForwardsAlert(kind string) bool {
+	for _, k := range n.alerts {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// do sends req and turns a non-2xx answer into an error with the start
+// of its body.
+func (n *notifier) do(req *http.Request) error {
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", n.Name(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: HTTP %d: %s", n.Name(), resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// alertTitle is the notification title for an alert kind.
+func alertTitle(kind string) string {
+	switch kind {
+	case bus.AlertError:
+		return "PicoClaw error"
+	case bus.AlertReminder:
+		return "PicoClaw reminder"
+	}
+	return "PicoClaw"
+}
@@ -0,0 +1,181 @@
+package channels
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/focus"
+	"github.com/sipeed/picoclaw/pkg/proactive"
+)
+
+type capturedRequest struct {
+	path   string
+	header http.Header
+	body   string
+}
+
+func captureServer(t *testing.T) (*httptest.Server, chan capturedRequest) {
+	t.Helper()
+	requests := make(chan capturedRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- capturedRequest{path: r.URL.Path, header: r.Header, body: string(body)}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+func TestNotifierChannels(t *testing.T) {
+	srv, requests := captureServer(t)
+	ctx := context.Background()
+	alert := bus.OutboundMessage{Content: "disk full", Alert: bus.AlertError}
+
+	ntfy, err := NewNtfyChannel(config.NtfyConfig{Server: srv.URL, Topic: "alerts", Token: "tk"}, bus.NewMessageBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ntfy.Send(ctx, alert); err != nil {
+		t.Fatalf("ntfy: %v", err)
+	}
+	req := <-requests
+	if req.path != "/alerts" || req.body != "disk full" || req.header.Get("Priority") != "high" ||
+		req.header.Get("Authorization") != "Bearer tk" || req.header.Get("Title") != "PicoClaw error" {
+		t.Errorf("ntfy request = %+v", req)
+	}
+
+	pushover, err := NewPushoverChannel(config.PushoverConfig{AppToken: "app", UserKey: "user"}, bus.NewMessageBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pushover.apiURL = srv.URL + "/1/messages.json"
+	if err := pushover.Send(ctx, bus.OutboundMessage{Content: "**stretch**", Alert: bus.AlertReminder}); err != nil {
+		t.Fatalf("pushover: %v", err)
+	}
+	req = <-requests
+	form, _ := url.ParseQuery(req.body)
+	if form.Get("token") != "app" || form.Get("user") != "user" || form.Get("message") != "stretch" || form.Get("title") != "PicoClaw reminder" {
+		t.Errorf("pushover form = %v", form)
+	}
+
+	gotify, err := NewGotifyChannel(config.GotifyConfig{Server: srv.URL + "/", AppToken: "key"}, bus.NewMessageBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gotify.Send(ctx, alert); err != nil {
+		t.Fatalf("gotify: %v", err)
+	}
+	req = <-requests
+	if req.path != "/message" || req.header.Get("X-Gotify-Key") != "key" || !strings.Contains(req.body, `"priority":8`) {
+		t.Errorf("gotify request = %+v", req)
+	}
+
+	if _, err := NewNtfyChannel(config.NtfyConfig{}, bus.NewMessageBus()); err == nil {
+		t.Error("expected an error for ntfy without a topic")
+	}
+}
+
+func TestForwardAlert(t *testing.T) {
+	srv, requests := captureServer(t)
+	cfg := config.DefaultConfig()
+	cfg.Channels.Ntfy = config.NtfyConfig{Enabled: true, Server: srv.URL, Topic: "phone", Alerts: config.FlexibleStringSlice{"error"}}
+	m, err := NewManager(cfg, bus.NewMessageBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.forwardAlert(context.Background(), bus.OutboundMessage{Channel: "discord", ChatID: "1", Content: "done"})
+	m.forwardAlert(context.Background(), bus.OutboundMessage{Channel: "discord", ChatID: "1", Content: "stretch", Alert: bus.AlertReminder})
+	m.forwardAlert(context.Background(), bus.OutboundMessage{Channel: "discord", ChatID: "1", Content: "boom", Alert: bus.AlertError})
+
+	select {
+	case req := <-requests:
+		if req.path != "/phone" || req.body != "boom" {
+			t.Errorf("forwarded %+v, want the error alert", req)
+		}
+	default:
+		t.Fatal("error alert was not forwarded")
+	}
+	if len(requests) != 0 {
+		t.Errorf("%d unexpected forwards", len(requests))
+	}
+}
+
+func TestManagerHeldBack(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	m, err := NewManager(cfg, bus.NewMessageBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Chat 1 is handled by an agent with a workspace of its own
+	agentWorkspace := t.TempDir()
+	m.SetWorkspaceResolver(func(channel, chatID string) string {
+		if chatID == "1" {
+			return agentWorkspace
+		}
+		return cfg.WorkspacePath()
+	})
+	if err := proactive.NewEngine(agentWorkspace, cfg.Proactive).SetLevel("discord", "1", proactive.Off); err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.heldBack(bus.OutboundMessage{Channel: "discord", ChatID: "1", Content: "stretch", Proactive: bus.ProactiveNudge, Alert: bus.AlertReminder}) {
+		t.Error("nudge to a chat that turned them off wasn't held back")
+	}
+	if m.heldBack(bus.OutboundMessage{Channel: "discord", ChatID: "2", Content: "stretch", Proactive: bus.ProactiveNudge}) {
+		t.Error("nudge to another chat was held back")
+	}
+	if m.heldBack(bus.OutboundMessage{Channel: "discord", ChatID: "1", Content: "boom", Alert: bus.AlertError}) {
+		t.Error("a reply was held back")
+	}
+
+	// During focus, nudges wait for the session to end
+	cfg.Tools.Focus.Enabled = true
+	store := focus.NewStore(cfg.WorkspacePath())
+	if _, err := store.Start("discord", "2", "", time.Minute, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if !m.heldBack(bus.OutboundMessage{Channel: "discord", ChatID: "2", Content: "stretch", Proactive: bus.ProactiveNudge}) {
+		t.Error("nudge during focus wasn't held back")
+	}
+	done := store.Finish(time.Now().Add(2 * time.Minute))
+	if len(done) != 1 || len(done[0].Held) != 1 || done[0].Held[0].Content != "stretch" {
+		t.Errorf("ended sessions = %+v, want the nudge held", done)
+	}
+}
+
+func TestManagerSend_OnSent(t *testing.T) {
+	srv, requests := captureServer(t)
+	cfg := config.DefaultConfig()
+	cfg.Channels.Ntfy = config.NtfyConfig{Enabled: true, Server: srv.URL, Topic: "phone", Alerts: config.FlexibleStringSlice{"error"}}
+	m, err := NewManager(cfg, bus.NewMessageBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ntfy, ok := m.GetChannel("ntfy")
+	if !ok {
+		t.Fatal("ntfy channel not created")
+	}
+
+	calls := 0
+	onSent := func() { calls++ }
+	if err := m.send(context.Background(), ntfy, bus.OutboundMessage{Channel: "ntfy", Content: "hi", OnSent: onSent}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("OnSent called %d times after a send", calls)
+	}
+	// A forwarded copy isn't the message itself
+	m.forwardAlert(context.Background(), bus.OutboundMessage{Channel: "discord", ChatID: "1", Content: "boom", Alert: bus.AlertError, OnSent: onSent})
+	if len(requests) != 2 || calls != 1 {
+		t.Errorf("requests = %d, OnSent calls = %d", len(requests), calls)
+	}
+}
@@ -0,0 +1,60 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+// NtfyChannel publishes messages as ntfy notifications. The chat ID is the
+// topic, and an empty one means the configured topic.
+type NtfyChannel struct {
+	notifier
+	server string
+	topic  string
+	token  string
+}
+
+// NewNtfyChannel creates an ntfy channel.
+func NewNtfyChannel(cfg config.NtfyConfig, messageBus *bus.MessageBus) (*NtfyChannel, error) {
+	if cfg.Topic == "" {
+		return nil, fmt.Errorf("ntfy topic is required")
+	}
+	server := strings.TrimRight(cfg.Server, "/")
+	if server == "" {
+		server = "https://ntfy.sh"
+	}
+	return &NtfyChannel{
+		notifier: newNotifier("ntfy", cfg, messageBus, cfg.Alerts),
+		server:   server,
+		topic:    cfg.Topic,
+		token:    cfg.Token,
+	}, nil
+}
+
+func (c *NtfyChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	topic := msg.ChatID
+	if topic == "" {
+		topic = c.topic
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.server+"/"+url.PathEscape(topic), strings.NewReader(msg.Content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", alertTitle(msg.Alert))
+	req.Header.Set("Markdown", "yes")
+	if msg.Alert == bus.AlertError {
+		req.Header.Set("Priority", "high")
+		req.Header.Set("Tags", "warning")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return c.do(req)
+}
@@ -658,9 +658,7 @@ func (c *OneBotChannel) parseMessageSegments(raw json.RawMessage, selfID int64)
 					if localPath != "" {
 						localFiles = append(localFiles, localPath)
 						if c.transcriber != nil && c.transcriber.IsAvailable() {
-							tctx, tcancel := context.WithTimeout(c.ctx, 30*time.Second)
-							result, err := c.transcriber.Transcribe(tctx, localPath)
-							tcancel()
+							result, err := c.transcriber.Transcribe(c.ctx, localPath)
 							if err != nil {
 								logger.WarnCF("onebot", "Voice transcription failed", map[string]interface{}{
 									"error": err.Error(),
@@ -712,7 +710,7 @@ func (c *OneBotChannel) handleRawEvent(raw *oneBotRawEvent) {
 	switch raw.PostType {
 	case "message":
 		if userID, err := parseJSONInt64(raw.UserID); err == nil && userID > 0 {
-			if !c.IsAllowed(strconv.FormatInt(userID, 10)) {
+			if !c.admitsMessage(strconv.FormatInt(userID, 10)) {
 				logger.DebugCF("onebot", "Message rejected by allowlist", map[string]interface{}{
 					"user_id": userID,
 				})
@@ -0,0 +1,336 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/mymmrac/telego/telegoapi"
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/slack-go/slack"
+)
+
+// outbox queues one channel's outbound messages in a queue per chat and
+// sends each chat's in order, retrying failed sends with exponential
+// backoff, so a flaky API or a rate limit delays replies instead of losing
+// them. A chat whose next message waits for a retry or for the throttle
+// doesn't hold up the others, and a send the platform refuses with a retry
+// delay is tried again after it without counting as an attempt. The queue
+// is saved to disk as messages come in, and whatever is left at shutdown
+// or after a crash is sent on the next start.
+type outbox struct {
+	name     string
+	channel  Channel
+	path     string
+	cfg      config.RetryConfig
+	send     func(context.Context, Channel, bus.OutboundMessage) error
+	throttle *throttle
+
+	mu    sync.Mutex
+	queue []outboxItem // the chats' queues, interleaved in arrival order
+	saved bool         // the queue is on disk
+	wake  chan struct{}
+}
+
+type outboxItem struct {
+	Message  bus.OutboundMessage `json:"message"`
+	Attempts int                 `json:"attempts"`
+	RetryAt  time.Time           `json:"-"` // after a failed attempt; a restart retries at once
+}
+
+// newOutbox returns the outbox of a channel, with any messages a previous
+// run left in dir.
+func newOutbox(name string, channel Channel, dir string, cfg config.RetryConfig,
+	send func(context.Context, Channel, bus.OutboundMessage) error,
+) *outbox {
+	o := &outbox{
+		name:     name,
+		channel:  channel,
+		path:     filepath.Join(dir, name+".json"),
+		cfg:      cfg,
+		send:     send,
+		throttle: newThrottle(limit{}, limit{}),
+		wake:     make(chan struct{}, 1),
+	}
+	if data, err := os.ReadFile(o.path); err == nil {
+		o.saved = true
+		if err := json.Unmarshal(data, &o.queue); err != nil {
+			logger.WarnCF("channels", "Ignoring unreadable outbox", map[string]interface{}{
+				"channel": name,
+				"error":   err.Error(),
+			})
+		} else if len(o.queue) > 0 {
+			logger.InfoCF("channels", "Resending messages left from last run", map[string]interface{}{
+				"channel": name,
+				"count":   len(o.queue),
+			})
+		}
+	}
+	return o
+}
+
+// push queues a message for sending. Partial replies aren't saved, as
+// they are stale after a restart.
+func (o *outbox) push(msg bus.OutboundMessage) {
+	o.mu.Lock()
+	o.queue = append(o.queue, outboxItem{Message: msg})
+	if !msg.Partial {
+		o.saveLocked()
+	}
+	o.mu.Unlock()
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run sends queued messages until ctx ends.
+func (o *outbox) run(ctx context.Context) {
+	for {
+		i, item, wait, ok := o.next()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-o.wake:
+				continue
+			}
+		}
+		if wait > 0 {
+			// A new message may be for a chat that can go now
+			select {
+			case <-ctx.Done():
+				o.save()
+				return
+			case <-o.wake:
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		err := o.send(ctx, o.channel, item.Message)
+		if err == nil {
+			o.pop(i)
+			continue
+		}
+		if ctx.Err() != nil {
+			o.save()
+			return
+		}
+
+		if delay, limited := retryAfter(err); limited {
+			o.throttle.pause(delay)
+			logger.WarnCF("channels", "Rate limited by the platform, pausing sends", map[string]interface{}{
+				"channel":  o.name,
+				"chat_id":  item.Message.ChatID,
+				"retry_in": delay.String(),
+			})
+			if item.Message.Partial {
+				o.pop(i)
+			}
+			continue
+		}
+
+		attempts := o.fail(i)
+		if item.Message.Partial || permanentSendError(err) || attempts >= o.cfg.MaxAttempts {
+			// Partial replies are superseded by the final one anyway
+			logger.ErrorCF("channels", "Error sending message to channel", map[string]interface{}{
+				"channel":  o.name,
+				"chat_id":  item.Message.ChatID,
+				"attempts": attempts,
+				"error":    err.Error(),
+			})
+			o.pop(i)
+			continue
+		}
+
+		delay := o.backoff(attempts)
+		logger.WarnCF("channels", "Send failed, retrying", map[string]interface{}{
+			"channel":  o.name,
+			"chat_id":  item.Message.ChatID,
+			"attempts": attempts,
+			"retry_in": delay.String(),
+			"error":    err.Error(),
+		})
+		o.retryAt(i, time.Now().Add(delay))
+	}
+}
+
+// next picks the message to send: the oldest of the chats' first queued
+// messages that is due for a retry, if it failed before, and that the
+// throttle lets through, taking its token. Partial replies take no token
+// and are dropped when their chat has to wait, as they would be stale by
+// then. With nothing that can go, wait is how long until something can.
+func (o *outbox) next() (i int, item outboxItem, wait time.Duration, ok bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	for j := 0; j < len(o.queue); j++ {
+		msg := o.queue[j].Message
+		if seen[msg.ChatID] {
+			continue
+		}
+		if retryAt := o.queue[j].RetryAt; retryAt.After(now) {
+			seen[msg.ChatID] = true
+			if w := retryAt.Sub(now); wait == 0 || w < wait {
+				wait = w
+			}
+			continue
+		}
+		if msg.Partial {
+			if o.throttle.available(msg.ChatID) == 0 {
+				return j, o.queue[j], 0, true
+			}
+			o.queue = append(o.queue[:j], o.queue[j+1:]...)
+			j--
+			continue
+		}
+		seen[msg.ChatID] = true
+		w := o.throttle.reserve(msg.ChatID)
+		if w == 0 {
+			return j, o.queue[j], 0, true
+		}
+		if wait == 0 || w < wait {
+			wait = w
+		}
+	}
+	return 0, outboxItem{}, wait, wait > 0
+}
+
+// backoff returns how long to wait after a message failed attempts times.
+func (o *outbox) backoff(attempts int) time.Duration {
+	delay := time.Duration(o.cfg.InitialBackoff) * time.Second
+	max := time.Duration(o.cfg.MaxBackoff) * time.Second
+	for i := 1; i < attempts && (max <= 0 || delay < max); i++ {
+		delay *= 2
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// pop removes the i-th message after it was sent or given up on.
+func (o *outbox) pop(i int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.queue = append(o.queue[:i], o.queue[i+1:]...)
+	if o.saved {
+		o.saveLocked()
+	}
+}
+
+// fail counts a failed attempt at the i-th message, returning the
+// attempts so far.
+func (o *outbox) fail(i int) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.queue[i].Attempts++
+	return o.queue[i].Attempts
+}
+
+// retryAt holds the i-th message, and so its chat, back until t, and
+// saves its attempts.
+func (o *outbox) retryAt(i int, t time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.queue[i].RetryAt = t
+	o.saveLocked()
+}
+
+func (o *outbox) save() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.saveLocked()
+}
+
+// saveLocked writes the queue without partial replies, or removes the
+// file when nothing is left.
+func (o *outbox) saveLocked() {
+	var pending []outboxItem
+	for _, item := range o.queue {
+		if !item.Message.Partial {
+			pending = append(pending, item)
+		}
+	}
+	if len(pending) == 0 {
+		o.saved = false
+		if err := os.Remove(o.path); err != nil && !os.IsNotExist(err) {
+			logger.WarnCF("channels", "Failed to clear outbox", map[string]interface{}{
+				"channel": o.name,
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	data, err := json.Marshal(pending)
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(o.path), 0755); err == nil {
+			tmp := o.path + ".tmp"
+			if err = os.WriteFile(tmp, data, 0600); err == nil {
+				err = os.Rename(tmp, o.path)
+			}
+			o.saved = err == nil
+		}
+	}
+	if err != nil {
+		logger.WarnCF("channels", "Failed to save outbox", map[string]interface{}{
+			"channel": o.name,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// permanentSlackErrors are the Slack API errors retrying won't fix.
+var permanentSlackErrors = map[string]bool{
+	"channel_not_found": true,
+	"not_in_channel":    true,
+	"is_archived":       true,
+	"msg_too_long":      true,
+	"no_text":           true,
+	"invalid_blocks":    true,
+	"restricted_action": true,
+	"missing_scope":     true,
+	"not_authed":        true,
+	"invalid_auth":      true,
+	"account_inactive":  true,
+	"token_revoked":     true,
+}
+
+// permanentSendError reports whether a send failed in a way retrying won't
+// fix, such as a missing permission or a deleted chat.
+func permanentSendError(err error) bool {
+	clientError := func(code int) bool {
+		return code >= 400 && code < 500 && code != http.StatusTooManyRequests
+	}
+	var rest *discordgo.RESTError
+	if errors.As(err, &rest) && rest.Response != nil {
+		return clientError(rest.Response.StatusCode)
+	}
+	// Telegram: 400 for a bad request or a missing chat, 403 when blocked
+	var tg *telegoapi.Error
+	if errors.As(err, &tg) {
+		return clientError(tg.ErrorCode)
+	}
+	var slackErr slack.SlackErrorResponse
+	if errors.As(err, &slackErr) {
+		return permanentSlackErrors[slackErr.Err]
+	}
+	var slackStatus slack.StatusCodeError
+	if errors.As(err, &slackStatus) {
+		return clientError(slackStatus.Code)
+	}
+	return false
+}
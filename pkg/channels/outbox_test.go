@@ -0,0 +1,229 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/mymmrac/telego/telegoapi"
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/slack-go/slack"
+)
+
+// flakySender fails the first fails sends, then records what it sends.
+type flakySender struct {
+	mu    sync.Mutex
+	fails int
+	sent  []string
+}
+
+func (f *flakySender) send(ctx context.Context, _ Channel, msg bus.OutboundMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fails > 0 {
+		f.fails--
+		return errors.New("503 Service Unavailable")
+	}
+	f.sent = append(f.sent, msg.Content)
+	return nil
+}
+
+func (f *flakySender) got() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.sent...)
+}
+
+// runOutbox runs ob until the test ends, and waits for it to stop so it
+// doesn't write into the test's removed temp dir.
+func runOutbox(t *testing.T, ob *outbox) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ob.run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestOutbox_RetriesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	sender := &flakySender{fails: 2}
+	ob := newOutbox("discord", nil, dir, config.RetryConfig{Enabled: true, MaxAttempts: 5}, sender.send)
+
+	runOutbox(t, ob)
+	ob.push(bus.OutboundMessage{Channel: "discord", ChatID: "1", Content: "first"})
+	ob.push(bus.OutboundMessage{Channel: "discord", ChatID: "1", Content: "second"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sender.got()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := sender.got(); len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Fatalf("sent = %q, want first then second", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "discord.json")); !os.IsNotExist(err) {
+		t.Error("outbox file left after the queue drained")
+	}
+}
+
+func TestOutbox_GivesUpAfterMaxAttempts(t *testing.T) {
+	sender := &flakySender{fails: 3}
+	ob := newOutbox("discord", nil, t.TempDir(), config.RetryConfig{Enabled: true, MaxAttempts: 3}, sender.send)
+
+	runOutbox(t, ob)
+	ob.push(bus.OutboundMessage{Content: "lost"})
+	ob.push(bus.OutboundMessage{Content: "next"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sender.got()) < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := sender.got(); len(got) != 1 || got[0] != "next" {
+		t.Fatalf("sent = %q, want only next", got)
+	}
+}
+
+func TestOutbox_PersistsUnsent(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.RetryConfig{Enabled: true, MaxAttempts: 5, InitialBackoff: 60}
+	ob := newOutbox("discord", nil, dir, cfg, (&flakySender{fails: 1}).send)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ob.run(ctx)
+		close(done)
+	}()
+	ob.push(bus.OutboundMessage{Content: "pending"})
+	ob.push(bus.OutboundMessage{Content: "draft", Partial: true})
+	// Saved as soon as it is queued, so a crash doesn't lose it
+	if _, err := os.Stat(filepath.Join(dir, "discord.json")); err != nil {
+		t.Fatalf("queued message not saved: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		ob.mu.Lock()
+		failed := len(ob.queue) > 0 && ob.queue[0].Attempts > 0
+		ob.mu.Unlock()
+		if failed {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel() // shut down while waiting to retry
+	<-done
+
+	// The next run sends it; partial replies aren't kept
+	sender := &flakySender{}
+	ob = newOutbox("discord", nil, dir, cfg, sender.send)
+	runOutbox(t, ob)
+	for len(sender.got()) < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := sender.got(); len(got) != 1 || got[0] != "pending" {
+		t.Fatalf("resent = %q, want pending", got)
+	}
+}
+
+func TestOutbox_Backoff(t *testing.T) {
+	ob := &outbox{cfg: config.RetryConfig{InitialBackoff: 2, MaxBackoff: 10}}
+	for attempts, want := range map[int]time.Duration{1: 2 * time.Second, 2: 4 * time.Second, 3: 8 * time.Second, 4: 10 * time.Second, 9: 10 * time.Second} {
+		if got := ob.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestOutbox_ChatOverItsLimitDoesntHoldUpOthers(t *testing.T) {
+	sender := &flakySender{}
+	ob := newOutbox("discord", nil, t.TempDir(), config.RetryConfig{MaxAttempts: 1}, sender.send)
+	ob.throttle = throttleFor("discord", config.ThrottleConfig{Enabled: true, ChatPerMinute: 1, ChatBurst: 1})
+
+	ob.push(bus.OutboundMessage{ChatID: "a", Content: "a1"})
+	ob.push(bus.OutboundMessage{ChatID: "a", Content: "a2"})
+	ob.push(bus.OutboundMessage{ChatID: "b", Content: "b typing", Partial: true})
+	ob.push(bus.OutboundMessage{ChatID: "b", Content: "b1"})
+	runOutbox(t, ob)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sender.got()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// a2 waits a minute for chat a's next token; b's partial takes none
+	if got := sender.got(); len(got) != 3 || got[0] != "a1" || got[1] != "b typing" || got[2] != "b1" {
+		t.Fatalf("sent = %q, want a1, b typing, b1", got)
+	}
+}
+
+func TestOutbox_FailingChatDoesntHoldUpOthers(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string
+	send := func(ctx context.Context, _ Channel, msg bus.OutboundMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if msg.ChatID == "broken" {
+			return errors.New("503 Service Unavailable")
+		}
+		sent = append(sent, msg.Content)
+		return nil
+	}
+	ob := newOutbox("discord", nil, t.TempDir(), config.RetryConfig{Enabled: true, MaxAttempts: 5, InitialBackoff: 60}, send)
+	ob.push(bus.OutboundMessage{ChatID: "broken", Content: "stuck"})
+	ob.push(bus.OutboundMessage{ChatID: "ok", Content: "hello"})
+	runOutbox(t, ob)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(sent)
+		mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 1 || sent[0] != "hello" {
+		t.Fatalf("sent = %q, want the other chat's message while the first waits to retry", sent)
+	}
+}
+
+func TestPermanentSendError(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"discord missing permission", &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusForbidden}}, true},
+		{"discord server error", &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusBadGateway}}, false},
+		{"telegram bot blocked", fmt.Errorf("telego: sendMessage: %w", &telegoapi.Error{ErrorCode: 403, Description: "Forbidden: bot was blocked by the user"}), true},
+		{"telegram flood control", &telegoapi.Error{ErrorCode: 429}, false},
+		{"telegram server error", &telegoapi.Error{ErrorCode: 502}, false},
+		{"slack channel not found", fmt.Errorf("failed to send slack message: %w", slack.SlackErrorResponse{Err: "channel_not_found"}), true},
+		{"slack internal error", slack.SlackErrorResponse{Err: "internal_error"}, false},
+		{"slack 404", slack.StatusCodeError{Code: http.StatusNotFound}, true},
+		{"slack 503", slack.StatusCodeError{Code: http.StatusServiceUnavailable}, false},
+		{"network error", errors.New("connection reset"), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := permanentSendError(tc.err); got != tc.want {
+				t.Errorf("permanentSendError = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package channels
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// pairingCodeTTL is how long a pairing code can be redeemed.
+	pairingCodeTTL = time.Hour
+	// maxPendingPairings caps the codes waiting for approval on a channel,
+	// so strangers messaging the bot can't grow the store without end.
+	maxPendingPairings = 20
+)
+
+var (
+	// ErrUnknownPairingCode is returned for codes that were never issued
+	// or have expired.
+	ErrUnknownPairingCode = errors.New("unknown or expired pairing code")
+	// ErrTooManyPairingRequests is returned when a channel already has
+	// maxPendingPairings codes waiting.
+	ErrTooManyPairingRequests = errors.New("too many pending pairing requests")
+)
+
+// PairingRequest is a user who asked to use the bot, or was paired.
+type PairingRequest struct {
+	Channel  string    `json:"channel"`
+	UserID   string    `json:"user_id"`
+	Username string    `json:"username,omitempty"`
+	Code     string    `json:"code,omitempty"`
+	Created  time.Time `json:"created"`
+}
+
+type pairingState struct {
+	Pending []PairingRequest `json:"pending"`
+	Paired  []PairingRequest `json:"paired"`
+}
+
+// PairingStore lets users not in a channel's allow_from pair with the bot:
+// they get a one-time code, and once the owner redeems it with
+// "picoclaw pair <code>" or !pair in chat they are allowed as if listed.
+// State lives in workspace/state/pairing.json, so codes redeemed by the
+// CLI take effect in a running gateway.
+type PairingStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewPairingStore returns the pairing store of a workspace.
+func NewPairingStore(workspace string) *PairingStore {
+	return &PairingStore{path: filepath.Join(workspace, "state", "pairing.json")}
+}
+
+// Request returns a pairing code for a user, and whether it was just
+// issued rather than one still pending from an earlier message. Codes
+// expire after pairingCodeTTL, and a channel has at most
+// maxPendingPairings of them at a time.
+func (p *PairingStore) Request(channel, userID, username string) (string, bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	st := p.load()
+	pending := 0
+	for _, r := range st.Pending {
+		if r.Channel != channel {
+			continue
+		}
+		if r.UserID == userID {
+			return r.Code, false, nil
+		}
+		pending++
+	}
+	if pending >= maxPendingPairings {
+		return "", false, ErrTooManyPairingRequests
+	}
+
+	code, err := newPairingCode()
+	if err != nil {
+		return "", false, err
+	}
+	st.Pending = append(st.Pending, PairingRequest{
+		Channel:  channel,
+		UserID:   userID,
+		Username: username,
+		Code:     code,
+		Created:  time.Now(),
+	})
+	return code, true, p.save(st)
+}
+
+// Redeem pairs the user a code was issued to.
+func (p *PairingStore) Redeem(code string) (PairingRequest, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	code = strings.TrimSpace(code)
+	st := p.load()
+	for i, r := range st.Pending {
+		if r.Code != code {
+			continue
+		}
+		st.Pending = append(st.Pending[:i], st.Pending[i+1:]...)
+		r.Code = ""
+		r.Created = time.Now()
+		st.Paired = append(st.Paired, r)
+		return r, p.save(st)
+	}
+	return PairingRequest{}, ErrUnknownPairingCode
+}
+
+// IsPaired reports whether a user has been paired on a channel.
+func (p *PairingStore) IsPaired(channel, userID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, r := range p.load().Paired {
+		if r.Channel == channel && r.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Unpair forgets a user's pairing and pending code on a channel, and
+// reports whether there was either.
+func (p *PairingStore) Unpair(channel, userID string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	st := p.load()
+	other := func(r PairingRequest) bool { return r.Channel != channel || r.UserID != userID }
+	pending, paired := filterPairing(st.Pending, other), filterPairing(st.Paired, other)
+	if len(pending) == len(st.Pending) && len(paired) == len(st.Paired) {
+		return false, nil
+	}
+	st.Pending, st.Paired = pending, paired
+	return true, p.save(st)
+}
+
+func filterPairing(requests []PairingRequest, keep func(PairingRequest) bool) []PairingRequest {
+	var kept []PairingRequest
+	for _, r := range requests {
+		if keep(r) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// List returns the pending requests and the paired users.
+func (p *PairingStore) List() (pending, paired []PairingRequest) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	st := p.load()
+	return st.Pending, st.Paired
+}
+
+// load reads the state, dropping expired codes.
+func (p *PairingStore) load() pairingState {
+	var st pairingState
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return st
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return pairingState{}
+	}
+	live := st.Pending[:0]
+	for _, r := range st.Pending {
+		if time.Since(r.Created) < pairingCodeTTL {
+			live = append(live, r)
+		}
+	}
+	st.Pending = live
+	return st
+}
+
+func (p *PairingStore) save(st pairingState) error {
+	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p.path)
+}
+
+// newPairingCode returns a random six-digit code.
+func newPairingCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("generating pairing code: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
@@ -0,0 +1,66 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/markdown"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+const (
+	pushoverAPIURL = "https://api.pushover.net/1/messages.json"
+	// pushoverMaxChars is the longest message Pushover accepts.
+	pushoverMaxChars = 1024
+)
+
+// PushoverChannel sends messages as Pushover notifications. The chat ID is
+// a user or group key, and an empty one means the configured user.
+type PushoverChannel struct {
+	notifier
+	apiURL   string
+	appToken string
+	userKey  string
+}
+
+// NewPushoverChannel creates a Pushover channel.
+func NewPushoverChannel(cfg config.PushoverConfig, messageBus *bus.MessageBus) (*PushoverChannel, error) {
+	if cfg.AppToken == "" || cfg.UserKey == "" {
+		return nil, fmt.Errorf("pushover app_token and user_key are required")
+	}
+	return &PushoverChannel{
+		notifier: newNotifier("pushover", cfg, messageBus, cfg.Alerts),
+		apiURL:   pushoverAPIURL,
+		appToken: cfg.AppToken,
+		userKey:  cfg.UserKey,
+	}, nil
+}
+
+func (c *PushoverChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	user := msg.ChatID
+	if user == "" {
+		user = c.userKey
+	}
+
+	form := url.Values{
+		"token":   {c.appToken},
+		"user":    {user},
+		"title":   {alertTitle(msg.Alert)},
+		"message": {utils.Truncate(markdown.Format(msg.Content, markdown.Plain), pushoverMaxChars)},
+	}
+	if msg.Alert == bus.AlertError {
+		form.Set("priority", "1")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.do(req)
+}
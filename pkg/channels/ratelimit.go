@@ -0,0 +1,259 @@
+package channels
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/kv"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// maxIdleBuckets is how many buckets a limiter keeps before dropping the
+// ones that have refilled, which behave the same as new ones.
+const maxIdleBuckets = 1024
+
+type bucket struct {
+	Tokens  float64
+	Updated time.Time
+	Quiet   time.Time // no more cooldown replies until then, so a flood gets one
+}
+
+// rateLimiter is a token bucket per sender and per chat. A message takes
+// one token from both; each bucket holds up to burst tokens and refills at
+// a steady rate. With a kv store the limits outlive restarts and are
+// shared by the gateways using it, see allowShared.
+type rateLimiter struct {
+	store  kv.Store // nil to keep buckets in memory
+	prefix string   // the channel's name, keeping stored keys apart
+
+	mu        sync.Mutex
+	user      limit
+	chat      limit
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastPrune time.Time
+}
+
+type limit struct {
+	rate  float64 // tokens per second, 0 for no limit
+	burst float64
+}
+
+func newLimit(perMinute, burst int) limit {
+	if perMinute <= 0 {
+		return limit{}
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return limit{rate: float64(perMinute) / 60, burst: float64(burst)}
+}
+
+func newRateLimiter(cfg config.RateLimitConfig) *rateLimiter {
+	return &rateLimiter{
+		user:    newLimit(cfg.UserPerMinute, cfg.UserBurst),
+		chat:    newLimit(cfg.ChatPerMinute, cfg.ChatBurst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// allow takes a token for the message. When over the limit it returns how
+// long until the next message would be let through, and whether the sender
+// should be told (once per cooldown).
+func (l *rateLimiter) allow(senderID, chatID string) (ok bool, wait time.Duration, notify bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if l.store != nil {
+		return l.allowShared(senderID, chatID, now)
+	}
+	l.prune(now)
+
+	var taken, limited []*bucket
+	for _, k := range l.keys(senderID, chatID) {
+		b := l.refill(k.key, k.lim, now)
+		taken = append(taken, b)
+		if b.Tokens < 1 {
+			if w := time.Duration((1 - b.Tokens) / k.lim.rate * float64(time.Second)); w > wait {
+				wait = w
+			}
+			limited = append(limited, b)
+		}
+	}
+
+	if len(limited) == 0 {
+		for _, b := range taken {
+			b.Tokens--
+		}
+		return true, 0, false
+	}
+
+	notify = true
+	for _, b := range limited {
+		if now.Before(b.Quiet) {
+			notify = false
+		}
+	}
+	if notify {
+		for _, b := range limited {
+			b.Quiet = now.Add(wait)
+		}
+	}
+	return false, wait, notify
+}
+
+type keyedLimit struct {
+	key string
+	lim limit
+}
+
+// keys returns the buckets a message takes a token from.
+func (l *rateLimiter) keys(senderID, chatID string) []keyedLimit {
+	var keys []keyedLimit
+	for _, k := range []keyedLimit{{"user:" + senderID, l.user}, {"chat:" + chatID, l.chat}} {
+		if k.lim.rate > 0 {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (l *rateLimiter) refill(key string, lim limit, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{Tokens: lim.burst, Updated: now}
+		l.buckets[key] = b
+	}
+	b.Tokens = math.Min(lim.burst, b.Tokens+now.Sub(b.Updated).Seconds()*lim.rate)
+	b.Updated = now
+	return b
+}
+
+// allowShared is allow for limits kept in the kv store. Reading a bucket
+// and writing it back would lose tokens taken by another gateway in
+// between, so the store counts messages with Incr instead: burst messages
+// per window of burst/rate, which comes to the same rate. A store outage
+// lets messages through rather than blocking them.
+func (l *rateLimiter) allowShared(senderID, chatID string, now time.Time) (ok bool, wait time.Duration, notify bool) {
+	var limited []string
+	counted := make(map[string]time.Duration) // counter key -> its ttl
+	for _, k := range l.keys(senderID, chatID) {
+		window := time.Duration(k.lim.burst / k.lim.rate * float64(time.Second))
+		start := now.Truncate(window)
+		key := fmt.Sprintf("ratelimit:%s%s:%d", l.prefix, k.key, start.Unix())
+		ttl := window + time.Second
+		n, err := l.store.Incr(key, 1, ttl)
+		if err != nil {
+			logger.WarnCF("channels", "Failed to count message for rate limit", map[string]any{
+				"key":   k.key,
+				"error": err.Error(),
+			})
+			continue
+		}
+		counted[key] = ttl
+		if float64(n) > k.lim.burst {
+			limited = append(limited, "ratelimit:"+l.prefix+"quiet:"+k.key)
+			if w := start.Add(window).Sub(now); w > wait {
+				wait = w
+			}
+		}
+	}
+	if len(limited) == 0 {
+		return true, 0, false
+	}
+
+	// A dropped message doesn't count against the limits it was under
+	for key, ttl := range counted {
+		l.store.Incr(key, -1, ttl)
+	}
+	notify = true
+	for _, key := range limited {
+		if added, err := l.store.Add(key, []byte("1"), wait); err != nil || !added {
+			notify = false
+		}
+	}
+	return false, wait, notify
+}
+
+// prune drops full buckets once the map grows past maxIdleBuckets.
+func (l *rateLimiter) prune(now time.Time) {
+	if len(l.buckets) < maxIdleBuckets || now.Sub(l.lastPrune) < time.Minute {
+		return
+	}
+	l.lastPrune = now
+	for key, b := range l.buckets {
+		lim := l.chat
+		if strings.HasPrefix(key, "user:") {
+			lim = l.user
+		}
+		if b.Tokens+now.Sub(b.Updated).Seconds()*lim.rate >= lim.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// SetRateLimit limits how fast each sender and each chat can send
+// messages to the agent. Messages over the limit are dropped with a
+// cooldown reply.
+func (c *BaseChannel) SetRateLimit(cfg config.RateLimitConfig) {
+	if !cfg.Enabled {
+		c.limiter = nil
+		return
+	}
+	c.limiter = newRateLimiter(cfg)
+	c.limiter.store = c.store
+	c.limiter.prefix = c.name + ":"
+}
+
+// SetStore keeps the channel's seen message IDs and rate limit buckets in
+// store, so they survive restarts and are shared with other gateways on
+// the same store.
+func (c *BaseChannel) SetStore(store kv.Store) {
+	c.store = store
+	if c.dedupe != nil {
+		c.dedupe.store = store
+	}
+	if c.limiter != nil {
+		c.limiter.store = store
+	}
+}
+
+// rateLimited reports whether the message is over the limit, replying
+// with a cooldown notice the first time.
+func (c *BaseChannel) rateLimited(senderID, chatID string) bool {
+	if c.limiter == nil {
+		return false
+	}
+	ok, wait, notify := c.limiter.allow(senderID, chatID)
+	if ok {
+		return false
+	}
+
+	logger.InfoCF(c.name, "Message rate limited", map[string]any{
+		"sender_id": senderID,
+		"chat_id":   chatID,
+	})
+	if notify && c.bus != nil {
+		c.bus.PublishOutbound(bus.OutboundMessage{
+			Channel: c.name,
+			ChatID:  chatID,
+			Content: cooldownText(wait),
+		})
+	}
+	return true
+}
+
+func cooldownText(wait time.Duration) string {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("⏳ That's a lot of messages at once. Please wait %d seconds before sending more.", seconds)
+}
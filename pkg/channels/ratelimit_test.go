@@ -0,0 +1,128 @@
+package channels
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/kv"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(config.RateLimitConfig{UserPerMinute: 6, UserBurst: 2, ChatPerMinute: 60, ChatBurst: 3})
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _, _ := l.allow("alice", "group"); !ok {
+			t.Fatalf("message %d within burst was limited", i+1)
+		}
+	}
+	ok, wait, notify := l.allow("alice", "group")
+	if ok || wait != 10*time.Second || !notify {
+		t.Errorf("over limit = %v, %v, %v; want limited for 10s with a notice", ok, wait, notify)
+	}
+	if _, _, notify := l.allow("alice", "group"); notify {
+		t.Error("second notice during the same cooldown")
+	}
+
+	// Bob has a separate sender bucket but shares the chat's, which has one token left
+	if ok, _, _ := l.allow("bob", "group"); !ok {
+		t.Error("bob limited by alice's bucket")
+	}
+	if ok, _, _ := l.allow("bob", "group"); ok {
+		t.Error("chat bucket not applied")
+	}
+
+	now = now.Add(10 * time.Second)
+	if ok, _, _ := l.allow("alice", "dm"); !ok {
+		t.Error("alice still limited after the bucket refilled")
+	}
+}
+
+func TestRateLimiter_Store(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	store := kv.NewMemory()
+	cfg := config.RateLimitConfig{UserPerMinute: 6, UserBurst: 2}
+
+	// Two gateways sharing a store share the buckets
+	var limiters []*rateLimiter
+	for i := 0; i < 2; i++ {
+		l := newRateLimiter(cfg)
+		l.store = store
+		l.prefix = "discord:"
+		l.now = func() time.Time { return now }
+		limiters = append(limiters, l)
+	}
+	limiters[0].allow("alice", "group")
+	limiters[1].allow("alice", "group")
+	// 6 a minute with a burst of 2 is 2 per 20-second window
+	ok, wait, notify := limiters[0].allow("alice", "group")
+	if ok || wait != 20*time.Second || !notify {
+		t.Errorf("third message = %v, %v, %v; want limited for 20s with a notice", ok, wait, notify)
+	}
+	if _, _, notify := limiters[1].allow("alice", "group"); notify {
+		t.Error("second notice during the same cooldown")
+	}
+
+	now = now.Add(20 * time.Second)
+	if ok, _, _ := limiters[1].allow("alice", "group"); !ok {
+		t.Error("alice still limited in the next window")
+	}
+}
+
+func TestRateLimiter_StoreConcurrent(t *testing.T) {
+	store := kv.NewMemory()
+	cfg := config.RateLimitConfig{UserPerMinute: 60, UserBurst: 10}
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	// Messages racing through several gateways still get burst tokens in all
+	var wg sync.WaitGroup
+	var allowed atomic.Int32
+	for i := 0; i < 50; i++ {
+		l := newRateLimiter(cfg)
+		l.store = store
+		l.now = func() time.Time { return now }
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _, _ := l.allow("alice", "group"); ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := allowed.Load(); n != 10 {
+		t.Errorf("%d messages allowed, want 10", n)
+	}
+}
+
+func TestHandleMessage_RateLimited(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	c := NewBaseChannel("test", nil, msgBus, nil)
+	c.SetRateLimit(config.RateLimitConfig{Enabled: true, UserPerMinute: 1, UserBurst: 1})
+
+	c.HandleMessage("alice", "1", "hi", nil, nil)
+	c.HandleMessage("alice", "1", "hi again", nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if msg, ok := msgBus.ConsumeInbound(ctx); !ok || msg.Content != "hi" {
+		t.Errorf("inbound = %+v, %v", msg, ok)
+	}
+	reply, ok := msgBus.SubscribeOutbound(ctx)
+	if !ok || reply.ChatID != "1" || !strings.Contains(reply.Content, "wait 60 seconds") {
+		t.Errorf("cooldown reply = %+v, %v", reply, ok)
+	}
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel2()
+	if msg, ok := msgBus.ConsumeInbound(ctx2); ok {
+		t.Errorf("over-limit message reached the agent: %+v", msg)
+	}
+}
@@ -0,0 +1,403 @@
+package channels
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/markdown"
+	"github.com/sipeed/picoclaw/pkg/utils"
+	"github.com/sipeed/picoclaw/pkg/voice"
+)
+
+const (
+	signalReconnectDelay    = 5 * time.Second
+	signalMaxReconnectDelay = 2 * time.Minute
+	signalGroupPrefix       = "group:"
+)
+
+// SignalChannel implements the Channel interface for Signal through a local
+// signal-cli daemon running in HTTP mode. Incoming messages are read from the
+// daemon's Server-Sent Events stream and replies are sent via JSON-RPC.
+type SignalChannel struct {
+	*BaseChannel
+	config         config.SignalConfig
+	rpcURL         string
+	attachmentsDir string
+	client         *http.Client
+	transcriber    *voice.GroqTranscriber
+	requestID      atomic.Int64
+	ctx            context.Context
+	cancel         context.CancelFunc
+}
+
+// NewSignalChannel creates a new Signal channel instance.
+func NewSignalChannel(cfg config.SignalConfig, messageBus *bus.MessageBus) (*SignalChannel, error) {
+	if cfg.Account == "" {
+		return nil, fmt.Errorf("signal account is required")
+	}
+
+	rpcURL := strings.TrimRight(cfg.RPCURL, "/")
+	if rpcURL == "" {
+		rpcURL = "http://127.0.0.1:8080"
+	}
+
+	attachmentsDir := cfg.AttachmentsDir
+	if strings.HasPrefix(attachmentsDir, "~") {
+		home, _ := os.UserHomeDir()
+		attachmentsDir = filepath.Join(home, attachmentsDir[1:])
+	}
+
+	base := NewBaseChannel("signal", cfg, messageBus, cfg.AllowFrom)
+
+	return &SignalChannel{
+		BaseChannel:    base,
+		config:         cfg,
+		rpcURL:         rpcURL,
+		attachmentsDir: attachmentsDir,
+		client:         &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (c *SignalChannel) SetTranscriber(transcriber *voice.GroqTranscriber) {
+	c.transcriber = transcriber
+}
+
+// Start connects to the signal-cli event stream.
+func (c *SignalChannel) Start(ctx context.Context) error {
+	logger.InfoCF("signal", "Starting Signal channel", map[string]interface{}{
+		"rpc_url": c.rpcURL,
+	})
+
+	c.ctx, c.cancel = context.WithCancel(ctx)
+
+	go c.eventLoop()
+
+	c.setRunning(true)
+	logger.InfoC("signal", "Signal channel started")
+	return nil
+}
+
+// Stop disconnects from the event stream.
+func (c *SignalChannel) Stop(ctx context.Context) error {
+	logger.InfoC("signal", "Stopping Signal channel")
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	c.setRunning(false)
+	logger.InfoC("signal", "Signal channel stopped")
+	return nil
+}
+
+// eventLoop keeps the SSE connection open, reconnecting with backoff.
+func (c *SignalChannel) eventLoop() {
+	delay := signalReconnectDelay
+	for {
+		connected, err := c.readEvents()
+		if c.ctx.Err() != nil {
+			return
+		}
+		if connected {
+			// A drop after a working connection starts the backoff over
+			delay = signalReconnectDelay
+		}
+		if err != nil {
+			logger.WarnCF("signal", "Event stream disconnected", map[string]interface{}{
+				"error":       err.Error(),
+				"retry_after": delay.String(),
+			})
+		}
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > signalMaxReconnectDelay {
+			delay = signalMaxReconnectDelay
+		}
+	}
+}
+
+// readEvents reads the event stream until it ends. connected reports
+// whether the stream was opened at all.
+func (c *SignalChannel) readEvents() (connected bool, err error) {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, c.rpcURL+"/api/v1/events?"+url.Values{"account": {c.config.Account}}.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	// The stream is long-lived, so don't use the client timeout
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("event stream returned status %d", resp.StatusCode)
+	}
+
+	logger.InfoC("signal", "Connected to signal-cli event stream")
+
+	reader := bufio.NewReader(resp.Body)
+	var data strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return true, fmt.Errorf("event stream closed")
+			}
+			return true, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				c.handleEvent([]byte(data.String()))
+				data.Reset()
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+}
+
+// signal-cli receive notification types
+type signalReceive struct {
+	Envelope signalEnvelope `json:"envelope"`
+	Account  string         `json:"account"`
+}
+
+type signalEnvelope struct {
+	Source       string             `json:"source"`
+	SourceNumber string             `json:"sourceNumber"`
+	SourceUUID   string             `json:"sourceUuid"`
+	SourceName   string             `json:"sourceName"`
+	Timestamp    int64              `json:"timestamp"`
+	DataMessage  *signalDataMessage `json:"dataMessage"`
+}
+
+type signalDataMessage struct {
+	Timestamp   int64              `json:"timestamp"`
+	Message     string             `json:"message"`
+	GroupInfo   *signalGroupInfo   `json:"groupInfo"`
+	Attachments []signalAttachment `json:"attachments"`
+}
+
+type signalGroupInfo struct {
+	GroupID string `json:"groupId"`
+}
+
+type signalAttachment struct {
+	ContentType string `json:"contentType"`
+	Filename    string `json:"filename"`
+	ID          string `json:"id"`
+	Size        int64  `json:"size"`
+}
+
+// handleEvent accepts either a bare receive payload or one wrapped in a
+// JSON-RPC notification ({"method":"receive","params":{...}}).
+func (c *SignalChannel) handleEvent(data []byte) {
+	var wrapper struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err == nil && wrapper.Method != "" {
+		if wrapper.Method != "receive" {
+			return
+		}
+		data = wrapper.Params
+	}
+
+	var event signalReceive
+	if err := json.Unmarshal(data, &event); err != nil {
+		logger.DebugCF("signal", "Ignoring unparseable event", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.processEnvelope(event.Envelope)
+}
+
+func (c *SignalChannel) processEnvelope(env signalEnvelope) {
+	// Receipts, typing indicators and sync messages carry no dataMessage
+	if env.DataMessage == nil {
+		return
+	}
+
+	number := env.SourceNumber
+	if number == "" {
+		number = env.Source
+	}
+	if number == "" {
+		number = env.SourceUUID
+	}
+
+	// Use the "id|alias" form so allow_from may list either the phone
+	// number or the account UUID.
+	senderID := number
+	if env.SourceUUID != "" && env.SourceUUID != number {
+		senderID = number + "|" + env.SourceUUID
+	}
+
+	if !c.admitsMessage(senderID) {
+		logger.DebugCF("signal", "Message rejected by allowlist", map[string]interface{}{
+			"sender_id": senderID,
+		})
+		return
+	}
+
+	msg := env.DataMessage
+	chatID := number
+	peerKind := "direct"
+	peerID := number
+	if msg.GroupInfo != nil && msg.GroupInfo.GroupID != "" {
+		chatID = signalGroupPrefix + msg.GroupInfo.GroupID
+		peerKind = "group"
+		peerID = msg.GroupInfo.GroupID
+	}
+
+	content := msg.Message
+	var mediaPaths []string
+	for _, att := range msg.Attachments {
+		path := filepath.Join(c.attachmentsDir, att.ID)
+		if _, err := os.Stat(path); err != nil {
+			content += fmt.Sprintf("\n[attachment: %s (unavailable)]", signalAttachmentName(att))
+			continue
+		}
+		mediaPaths = append(mediaPaths, path)
+
+		if utils.IsAudioFile(signalAttachmentName(att), att.ContentType) && c.transcriber != nil && c.transcriber.IsAvailable() {
+			result, err := c.transcriber.Transcribe(c.ctx, path)
+			if err != nil {
+				logger.ErrorCF("signal", "Voice transcription failed", map[string]interface{}{"error": err.Error()})
+				content += "\n[audio (transcription failed)]"
+			} else {
+				content += fmt.Sprintf("\n[voice transcription: %s]", result.Text)
+			}
+			continue
+		}
+		content += fmt.Sprintf("\n[attachment: %s]", signalAttachmentName(att))
+	}
+
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return
+	}
+
+	metadata := map[string]string{
+		"platform":   "signal",
+		"timestamp":  fmt.Sprintf("%d", msg.Timestamp),
+		"peer_kind":  peerKind,
+		"peer_id":    peerID,
+		"user_name":  env.SourceName,
+		"source_uid": env.SourceUUID,
+	}
+
+	logger.DebugCF("signal", "Received message", map[string]interface{}{
+		"sender_id": senderID,
+		"chat_id":   chatID,
+		"preview":   utils.Truncate(content, 50),
+	})
+
+	c.HandleMessage(senderID, chatID, content, mediaPaths, metadata)
+}
+
+func signalAttachmentName(att signalAttachment) string {
+	if att.Filename != "" {
+		return att.Filename
+	}
+	return att.ID
+}
+
+// Send delivers a message via the signal-cli JSON-RPC "send" method.
+func (c *SignalChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	if !c.IsRunning() {
+		return fmt.Errorf("signal channel not running")
+	}
+
+	params := map[string]interface{}{
+		"account": c.config.Account,
+		"message": markdown.Format(msg.Content, markdown.Plain),
+	}
+	if groupID, ok := strings.CutPrefix(msg.ChatID, signalGroupPrefix); ok {
+		params["groupId"] = groupID
+	} else {
+		params["recipient"] = []string{msg.ChatID}
+	}
+
+	if err := c.call(ctx, "send", params); err != nil {
+		return fmt.Errorf("failed to send signal message: %w", err)
+	}
+
+	logger.DebugCF("signal", "Message sent", map[string]interface{}{
+		"chat_id": msg.ChatID,
+	})
+	return nil
+}
+
+// call performs a JSON-RPC request against the signal-cli daemon.
+func (c *SignalChannel) call(ctx context.Context, method string, params interface{}) error {
+	payload := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+		"id":      c.requestID.Add(1),
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.rpcURL+"/api/v1/rpc", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("signal-cli returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var rpcResp struct {
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &rpcResp); err == nil && rpcResp.Error != nil {
+		return fmt.Errorf("signal-cli error %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func newTestSignalChannel(t *testing.T, rpcURL string, allowFrom ...string) (*SignalChannel, *bus.MessageBus) {
+	t.Helper()
+	msgBus := bus.NewMessageBus()
+	ch, err := NewSignalChannel(config.SignalConfig{
+		Account:        "+15550001",
+		RPCURL:         rpcURL,
+		AttachmentsDir: t.TempDir(),
+		AllowFrom:      allowFrom,
+	}, msgBus)
+	if err != nil {
+		t.Fatalf("NewSignalChannel: %v", err)
+	}
+	ch.ctx = context.Background()
+	return ch, msgBus
+}
+
+func TestSignalHandleEvent(t *testing.T) {
+	tests := []struct {
+		name       string
+		event      string
+		allowFrom  []string
+		wantChatID string
+		wantText   string
+	}{
+		{
+			name:       "direct message",
+			event:      `{"envelope":{"sourceNumber":"+15550100","sourceUuid":"u-1","sourceName":"Alice","dataMessage":{"timestamp":1,"message":"hello"}},"account":"+15550001"}`,
+			wantChatID: "+15550100",
+			wantText:   "hello",
+		},
+		{
+			name:       "group message wrapped in notification",
+			event:      `{"jsonrpc":"2.0","method":"receive","params":{"envelope":{"sourceNumber":"+15550100","dataMessage":{"message":"hi all","groupInfo":{"groupId":"abc=="}}}}}`,
+			wantChatID: "group:abc==",
+			wantText:   "hi all",
+		},
+		{
+			name:       "allowed by uuid",
+			event:      `{"envelope":{"sourceNumber":"+15550100","sourceUuid":"u-1","dataMessage":{"message":"by uuid"}}}`,
+			allowFrom:  []string{"u-1"},
+			wantChatID: "+15550100",
+			wantText:   "by uuid",
+		},
+		{
+			name:      "rejected sender",
+			event:     `{"envelope":{"sourceNumber":"+15550199","dataMessage":{"message":"nope"}}}`,
+			allowFrom: []string{"+15550100"},
+		},
+		{
+			name:  "receipt without data message",
+			event: `{"envelope":{"sourceNumber":"+15550100","receiptMessage":{"isRead":true}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch, msgBus := newTestSignalChannel(t, "", tt.allowFrom...)
+			ch.handleEvent([]byte(tt.event))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			msg, ok := msgBus.ConsumeInbound(ctx)
+
+			if tt.wantChatID == "" {
+				if ok {
+					t.Fatalf("unexpected inbound message: %+v", msg)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("expected inbound message")
+			}
+			if msg.ChatID != tt.wantChatID {
+				t.Errorf("ChatID = %q, want %q", msg.ChatID, tt.wantChatID)
+			}
+			if msg.Content != tt.wantText {
+				t.Errorf("Content = %q, want %q", msg.Content, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestSignalSend(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/rpc" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"jsonrpc":"2.0","result":{"timestamp":1},"id":1}`))
+	}))
+	defer server.Close()
+
+	ch, _ := newTestSignalChannel(t, server.URL)
+	ch.setRunning(true)
+
+	tests := []struct {
+		chatID string
+		check  func(params map[string]interface{}) bool
+	}{
+		{"+15550100", func(p map[string]interface{}) bool {
+			r, ok := p["recipient"].([]interface{})
+			return ok && len(r) == 1 && r[0] == "+15550100"
+		}},
+		{"group:abc==", func(p map[string]interface{}) bool {
+			return p["groupId"] == "abc==" && p["recipient"] == nil
+		}},
+	}
+
+	for _, tt := range tests {
+		got = nil
+		if err := ch.Send(context.Background(), bus.OutboundMessage{Channel: "signal", ChatID: tt.chatID, Content: "pong"}); err != nil {
+			t.Fatalf("Send(%s): %v", tt.chatID, err)
+		}
+		if got["method"] != "send" {
+			t.Errorf("method = %v", got["method"])
+		}
+		params, _ := got["params"].(map[string]interface{})
+		if params["account"] != "+15550001" || params["message"] != "pong" || !tt.check(params) {
+			t.Errorf("unexpected params for %s: %v", tt.chatID, params)
+		}
+	}
+}
+
+func TestSignalSend_RPCError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","error":{"code":-1,"message":"Unregistered user"},"id":1}`))
+	}))
+	defer server.Close()
+
+	ch, _ := newTestSignalChannel(t, server.URL)
+	ch.setRunning(true)
+
+	if err := ch.Send(context.Background(), bus.OutboundMessage{ChatID: "+15550100", Content: "x"}); err == nil {
+		t.Error("expected error from JSON-RPC error response")
+	}
+}
+
+func TestSignalReadEvents_Connected(t *testing.T) {
+	up := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A "+" left unescaped would arrive as a space
+		if account := r.URL.Query().Get("account"); account != "+15550001" {
+			t.Errorf("account = %q, want +15550001", account)
+		}
+		if !up {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {}\n\n"))
+	}))
+	defer server.Close()
+
+	ch, _ := newTestSignalChannel(t, server.URL)
+	if connected, err := ch.readEvents(); !connected || err == nil {
+		t.Errorf("stream that opened then closed: connected = %v, err = %v", connected, err)
+	}
+	up = false
+	if connected, err := ch.readEvents(); connected || err == nil {
+		t.Errorf("refused stream: connected = %v, err = %v", connected, err)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"os"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
@@ -15,10 +14,15 @@ import (
 	"github.com/sipeed/picoclaw/pkg/bus"
 	"github.com/sipeed/picoclaw/pkg/config"
 	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/markdown"
 	"github.com/sipeed/picoclaw/pkg/utils"
 	"github.com/sipeed/picoclaw/pkg/voice"
 )
 
+// slackChunkLen is where long replies are split; Slack truncates messages
+// over 4000 characters.
+const slackChunkLen = 3900
+
 type SlackChannel struct {
 	*BaseChannel
 	config       config.SlackConfig
@@ -119,17 +123,18 @@ func (c *SlackChannel) Send(ctx context.Context, msg bus.OutboundMessage) error
 		return fmt.Errorf("invalid slack chat ID: %s", msg.ChatID)
 	}
 
-	opts := []slack.MsgOption{
-		slack.MsgOptionText(msg.Content, false),
-	}
+	for _, chunk := range markdown.Split(msg.Content, markdown.Slack, slackChunkLen) {
+		opts := []slack.MsgOption{
+			slack.MsgOptionText(chunk, false),
+		}
 
-	if threadTS != "" {
-		opts = append(opts, slack.MsgOptionTS(threadTS))
-	}
+		if threadTS != "" {
+			opts = append(opts, slack.MsgOptionTS(threadTS))
+		}
 
-	_, _, err := c.api.PostMessageContext(ctx, channelID, opts...)
-	if err != nil {
-		return fmt.Errorf("failed to send slack message: %w", err)
+		if _, _, err := c.api.PostMessageContext(ctx, channelID, opts...); err != nil {
+			return fmt.Errorf("failed to send slack message: %w", err)
+		}
 	}
 
 	if ref, ok := c.pendingAcks.LoadAndDelete(msg.ChatID); ok {
@@ -181,15 +186,38 @@ func (c *SlackChannel) handleEventsAPI(event socketmode.Event) {
 		return
 	}
 
+	teamID := eventsAPIEvent.TeamID
+	if teamID == "" {
+		teamID = c.teamID
+	}
+
 	switch ev := eventsAPIEvent.InnerEvent.Data.(type) {
 	case *slackevents.MessageEvent:
-		c.handleMessageEvent(ev)
+		c.handleMessageEvent(teamID, ev)
 	case *slackevents.AppMentionEvent:
-		c.handleAppMention(ev)
+		c.handleAppMention(teamID, ev)
 	}
 }
 
-func (c *SlackChannel) handleMessageEvent(ev *slackevents.MessageEvent) {
+// isConversationAllowed applies the workspace and channel allowlists.
+// Direct messages bypass the channel allowlist but not the workspace one.
+func (c *SlackChannel) isConversationAllowed(teamID, channelID string) bool {
+	if !slackIDAllowed(c.config.AllowWorkspaces, teamID) {
+		logger.DebugCF("slack", "Event rejected by workspace allowlist", map[string]interface{}{
+			"team_id": teamID,
+		})
+		return false
+	}
+	if !isSlackDirectChannel(channelID) && !slackIDAllowed(c.config.AllowChannels, channelID) {
+		logger.DebugCF("slack", "Event rejected by channel allowlist", map[string]interface{}{
+			"channel_id": channelID,
+		})
+		return false
+	}
+	return true
+}
+
+func (c *SlackChannel) handleMessageEvent(teamID string, ev *slackevents.MessageEvent) {
 	if ev.User == c.botUserID || ev.User == "" {
 		return
 	}
@@ -200,8 +228,23 @@ func (c *SlackChannel) handleMessageEvent(ev *slackevents.MessageEvent) {
 		return
 	}
 
+	if !c.isConversationAllowed(teamID, ev.Channel) {
+		return
+	}
+
+	// Outside DMs, mentions are delivered separately as app_mention events;
+	// handling them here too would answer the same message twice.
+	if !isSlackDirectChannel(ev.Channel) {
+		if c.botUserID != "" && strings.Contains(ev.Text, "<@"+c.botUserID+">") {
+			return
+		}
+		if c.config.MentionOnly {
+			return
+		}
+	}
+
 	// 检查白名单，避免为被拒绝的用户下载附件
-	if !c.IsAllowed(ev.User) {
+	if !c.admitsMessage(ev.User) {
 		logger.DebugCF("slack", "Message rejected by allowlist", map[string]interface{}{
 			"user_id": ev.User,
 		})
@@ -213,10 +256,7 @@ func (c *SlackChannel) handleMessageEvent(ev *slackevents.MessageEvent) {
 	threadTS := ev.ThreadTimeStamp
 	messageTS := ev.TimeStamp
 
-	chatID := channelID
-	if threadTS != "" {
-		chatID = channelID + "/" + threadTS
-	}
+	chatID := c.replyChatID(channelID, threadTS, messageTS, false)
 
 	c.api.AddReaction("eyes", slack.ItemRef{
 		Channel:   channelID,
@@ -256,9 +296,7 @@ func (c *SlackChannel) handleMessageEvent(ev *slackevents.MessageEvent) {
 			mediaPaths = append(mediaPaths, localPath)
 
 			if utils.IsAudioFile(file.Name, file.Mimetype) && c.transcriber != nil && c.transcriber.IsAvailable() {
-				ctx, cancel := context.WithTimeout(c.ctx, 30*time.Second)
-				defer cancel()
-				result, err := c.transcriber.Transcribe(ctx, localPath)
+				result, err := c.transcriber.Transcribe(c.ctx, localPath)
 
 				if err != nil {
 					logger.ErrorCF("slack", "Voice transcription failed", map[string]interface{}{"error": err.Error()})
@@ -278,7 +316,7 @@ func (c *SlackChannel) handleMessageEvent(ev *slackevents.MessageEvent) {
 
 	peerKind := "channel"
 	peerID := channelID
-	if strings.HasPrefix(channelID, "D") {
+	if isSlackDirectChannel(channelID) {
 		peerKind = "direct"
 		peerID = senderID
 	}
@@ -290,7 +328,7 @@ func (c *SlackChannel) handleMessageEvent(ev *slackevents.MessageEvent) {
 		"platform":   "slack",
 		"peer_kind":  peerKind,
 		"peer_id":    peerID,
-		"team_id":    c.teamID,
+		"team_id":    teamID,
 	}
 
 	logger.DebugCF("slack", "Received message", map[string]interface{}{
@@ -303,12 +341,16 @@ func (c *SlackChannel) handleMessageEvent(ev *slackevents.MessageEvent) {
 	c.HandleMessage(senderID, chatID, content, mediaPaths, metadata)
 }
 
-func (c *SlackChannel) handleAppMention(ev *slackevents.AppMentionEvent) {
+func (c *SlackChannel) handleAppMention(teamID string, ev *slackevents.AppMentionEvent) {
 	if ev.User == c.botUserID {
 		return
 	}
 
-	if !c.IsAllowed(ev.User) {
+	if !c.isConversationAllowed(teamID, ev.Channel) {
+		return
+	}
+
+	if !c.admitsMessage(ev.User) {
 		logger.DebugCF("slack", "Mention rejected by allowlist", map[string]interface{}{
 			"user_id": ev.User,
 		})
@@ -320,12 +362,7 @@ func (c *SlackChannel) handleAppMention(ev *slackevents.AppMentionEvent) {
 	threadTS := ev.ThreadTimeStamp
 	messageTS := ev.TimeStamp
 
-	var chatID string
-	if threadTS != "" {
-		chatID = channelID + "/" + threadTS
-	} else {
-		chatID = channelID + "/" + messageTS
-	}
+	chatID := c.replyChatID(channelID, threadTS, messageTS, true)
 
 	c.api.AddReaction("eyes", slack.ItemRef{
 		Channel:   channelID,
@@ -345,7 +382,7 @@ func (c *SlackChannel) handleAppMention(ev *slackevents.AppMentionEvent) {
 
 	mentionPeerKind := "channel"
 	mentionPeerID := channelID
-	if strings.HasPrefix(channelID, "D") {
+	if isSlackDirectChannel(channelID) {
 		mentionPeerKind = "direct"
 		mentionPeerID = senderID
 	}
@@ -358,7 +395,7 @@ func (c *SlackChannel) handleAppMention(ev *slackevents.AppMentionEvent) {
 		"is_mention": "true",
 		"peer_kind":  mentionPeerKind,
 		"peer_id":    mentionPeerID,
-		"team_id":    c.teamID,
+		"team_id":    teamID,
 	}
 
 	c.HandleMessage(senderID, chatID, content, nil, metadata)
@@ -374,6 +411,14 @@ func (c *SlackChannel) handleSlashCommand(event socketmode.Event) {
 		c.socketClient.Ack(*event.Request)
 	}
 
+	teamID := cmd.TeamID
+	if teamID == "" {
+		teamID = c.teamID
+	}
+	if !c.isConversationAllowed(teamID, cmd.ChannelID) {
+		return
+	}
+
 	if !c.IsAllowed(cmd.UserID) {
 		logger.DebugCF("slack", "Slash command rejected by allowlist", map[string]interface{}{
 			"user_id": cmd.UserID,
@@ -397,7 +442,7 @@ func (c *SlackChannel) handleSlashCommand(event socketmode.Event) {
 		"trigger_id": cmd.TriggerID,
 		"peer_kind":  "channel",
 		"peer_id":    channelID,
-		"team_id":    c.teamID,
+		"team_id":    teamID,
 	}
 
 	logger.DebugCF("slack", "Slash command received", map[string]interface{}{
@@ -433,6 +478,41 @@ func (c *SlackChannel) stripBotMention(text string) string {
 	return strings.TrimSpace(text)
 }
 
+// replyChatID builds the chat ID replies are sent to. Messages already in a
+// thread are answered there; mentions always start a new thread, and other
+// top-level channel messages do when reply_in_thread is enabled. DMs are
+// answered inline.
+func (c *SlackChannel) replyChatID(channelID, threadTS, messageTS string, mention bool) string {
+	if threadTS != "" {
+		return channelID + "/" + threadTS
+	}
+	if messageTS == "" {
+		return channelID
+	}
+	if mention || (c.config.ReplyInThread && !isSlackDirectChannel(channelID)) {
+		return channelID + "/" + messageTS
+	}
+	return channelID
+}
+
+// isSlackDirectChannel reports whether a conversation ID is a direct message.
+func isSlackDirectChannel(channelID string) bool {
+	return strings.HasPrefix(channelID, "D")
+}
+
+// slackIDAllowed reports whether id is in the allowlist. An empty list allows everything.
+func slackIDAllowed(allowed []string, id string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(strings.TrimSpace(a), id) {
+			return true
+		}
+	}
+	return false
+}
+
 func parseSlackChatID(chatID string) (channelID, threadTS string) {
 	parts := strings.SplitN(chatID, "/", 2)
 	channelID = parts[0]
@@ -172,3 +172,63 @@ func TestSlackChannelIsAllowed(t *testing.T) {
 		}
 	})
 }
+
+func TestSlackReplyChatID(t *testing.T) {
+	tests := []struct {
+		name          string
+		replyInThread bool
+		mention       bool
+		channelID     string
+		threadTS      string
+		messageTS     string
+		want          string
+	}{
+		{"existing thread", false, false, "C123", "111.1", "222.2", "C123/111.1"},
+		{"top-level inline", false, false, "C123", "", "222.2", "C123"},
+		{"top-level threaded", true, false, "C123", "", "222.2", "C123/222.2"},
+		{"mention always threaded", false, true, "C123", "", "222.2", "C123/222.2"},
+		{"DM never threaded", true, false, "D456", "", "222.2", "D456"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := &SlackChannel{config: config.SlackConfig{ReplyInThread: tt.replyInThread}}
+			if got := ch.replyChatID(tt.channelID, tt.threadTS, tt.messageTS, tt.mention); got != tt.want {
+				t.Errorf("replyChatID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlackConversationAllowlists(t *testing.T) {
+	ch := &SlackChannel{config: config.SlackConfig{
+		AllowWorkspaces: []string{"T001"},
+		AllowChannels:   []string{"C100"},
+	}}
+
+	tests := []struct {
+		name      string
+		teamID    string
+		channelID string
+		want      bool
+	}{
+		{"allowed workspace and channel", "T001", "C100", true},
+		{"other channel", "T001", "C200", false},
+		{"DM bypasses channel allowlist", "T001", "D300", true},
+		{"other workspace", "T999", "C100", false},
+		{"DM in other workspace", "T999", "D300", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ch.isConversationAllowed(tt.teamID, tt.channelID); got != tt.want {
+				t.Errorf("isConversationAllowed(%q, %q) = %v, want %v", tt.teamID, tt.channelID, got, tt.want)
+			}
+		})
+	}
+
+	open := &SlackChannel{}
+	if !open.isConversationAllowed("T999", "C999") {
+		t.Error("empty allowlists should allow everything")
+	}
+}
@@ -6,9 +6,13 @@ import (
 	"strings"
 
 	"github.com/mymmrac/telego"
+	"github.com/sipeed/picoclaw/pkg/commands"
 	"github.com/sipeed/picoclaw/pkg/config"
 )
 
+// telegramMaxCommands is the most commands a bot's menu can list.
+const telegramMaxCommands = 100
+
 type TelegramCommander interface {
 	Help(ctx context.Context, message telego.Message) error
 	Start(ctx context.Context, message telego.Message) error
@@ -40,6 +44,9 @@ func (c *cmd) Help(ctx context.Context, message telego.Message) error {
 /help - Show this help message
 /show [model|channel] - Show current configuration
 /list [models|channels] - List available options
+/announcements [on|off] - Opt in to announcements from the owner
+/maintenance [on|off|status] - Pause processing and queue messages
+/feedback [up|down] [comment] - Rate the last reply (or just send 👍/👎)
 	`
 	_, err := c.bot.SendMessage(ctx, &telego.SendMessageParams{
 		ChatID: telego.ChatID{ID: message.Chat.ID},
@@ -133,6 +140,11 @@ func (c *cmd) List(ctx context.Context, message telego.Message) error {
 		if c.config.Channels.Discord.Enabled {
 			enabled = append(enabled, "discord")
 		}
+		for _, bot := range c.config.Channels.DiscordBots {
+			if bot.Enabled {
+				enabled = append(enabled, bot.ChannelName())
+			}
+		}
 		if c.config.Channels.Slack.Enabled {
 			enabled = append(enabled, "slack")
 		}
@@ -151,3 +163,22 @@ func (c *cmd) List(ctx context.Context, message telego.Message) error {
 	})
 	return err
 }
+
+// SetCommands publishes the bot's command menu: the commands the channel
+// answers itself, then the agent's.
+func (c *TelegramChannel) SetCommands(ctx context.Context, cmds []commands.Command) error {
+	menu := []telego.BotCommand{
+		{Command: "start", Description: "Start the bot"},
+		{Command: "help", Description: "Show this help message"},
+	}
+	for _, cmd := range cmds {
+		if len(menu) == telegramMaxCommands {
+			break
+		}
+		if cmd.Name == "start" || cmd.Name == "help" {
+			continue
+		}
+		menu = append(menu, telego.BotCommand{Command: cmd.Name, Description: cmd.Description})
+	}
+	return c.bot.SetMyCommands(ctx, &telego.SetMyCommandsParams{Commands: menu})
+}
@@ -0,0 +1,179 @@
+package channels
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/mymmrac/telego/telegoapi"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/slack-go/slack"
+)
+
+// defaultRetryAfter is how long to pause after a rate limit that came
+// without a delay.
+const defaultRetryAfter = 5 * time.Second
+
+// throttle paces one channel's outbound messages: a token bucket for the
+// channel and one per chat, like the inbound rateLimiter, and a pause
+// holding everything back while the platform has asked to wait.
+type throttle struct {
+	mu        sync.Mutex
+	channel   limit
+	chat      limit
+	all       bucket
+	chats     map[string]*bucket
+	until     time.Time // paused by the platform until then
+	now       func() time.Time
+	lastPrune time.Time
+}
+
+func newThrottle(channel, chat limit) *throttle {
+	t := &throttle{
+		channel: channel,
+		chat:    chat,
+		chats:   make(map[string]*bucket),
+		now:     time.Now,
+	}
+	t.all = bucket{Tokens: channel.burst, Updated: t.now()}
+	return t
+}
+
+// throttleFor returns the throttle of the named channel under cfg.
+func throttleFor(name string, cfg config.ThrottleConfig) *throttle {
+	if !cfg.Enabled {
+		return newThrottle(limit{}, limit{})
+	}
+	return newThrottle(newLimit(cfg.ChannelPerMinute(name), cfg.Burst), newLimit(cfg.ChatPerMinute, cfg.ChatBurst))
+}
+
+// reserve takes a token for a message to chatID, or returns how long until
+// one would be free without taking any.
+func (t *throttle) reserve(chatID string) time.Duration {
+	return t.check(chatID, true)
+}
+
+// available returns how long until a message to chatID could go, like
+// reserve but without taking a token, for partial replies: they only use
+// capacity nothing else needs.
+func (t *throttle) available(chatID string) time.Duration {
+	return t.check(chatID, false)
+}
+
+func (t *throttle) check(chatID string, take bool) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if now.Before(t.until) {
+		return t.until.Sub(now)
+	}
+	t.prune(now)
+
+	var wait time.Duration
+	var taken []*bucket
+	if t.channel.rate > 0 {
+		fill(&t.all, t.channel, now)
+		taken = append(taken, &t.all)
+		wait = max(wait, untilToken(&t.all, t.channel))
+	}
+	if t.chat.rate > 0 {
+		b, ok := t.chats[chatID]
+		if !ok {
+			b = &bucket{Tokens: t.chat.burst, Updated: now}
+			t.chats[chatID] = b
+		}
+		fill(b, t.chat, now)
+		taken = append(taken, b)
+		wait = max(wait, untilToken(b, t.chat))
+	}
+	if wait > 0 || !take {
+		return wait
+	}
+	for _, b := range taken {
+		b.Tokens--
+	}
+	return 0
+}
+
+// pause holds every message back for d.
+func (t *throttle) pause(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := t.now().Add(d); until.After(t.until) {
+		t.until = until
+	}
+}
+
+// prune drops full chat buckets once the map grows past maxIdleBuckets.
+func (t *throttle) prune(now time.Time) {
+	if len(t.chats) < maxIdleBuckets || now.Sub(t.lastPrune) < time.Minute {
+		return
+	}
+	t.lastPrune = now
+	for chatID, b := range t.chats {
+		if b.Tokens+now.Sub(b.Updated).Seconds()*t.chat.rate >= t.chat.burst {
+			delete(t.chats, chatID)
+		}
+	}
+}
+
+func fill(b *bucket, lim limit, now time.Time) {
+	b.Tokens = math.Min(lim.burst, b.Tokens+now.Sub(b.Updated).Seconds()*lim.rate)
+	b.Updated = now
+}
+
+func untilToken(b *bucket, lim limit) time.Duration {
+	if b.Tokens >= 1 {
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - b.Tokens) / lim.rate * float64(time.Second)))
+}
+
+// retryAfter reports whether a send was refused for going over the
+// platform's rate limit, and how long it asked to wait before the next.
+func retryAfter(err error) (time.Duration, bool) {
+	d, limited := platformRetryAfter(err)
+	if limited && d <= 0 {
+		d = defaultRetryAfter
+	}
+	return d, limited
+}
+
+func platformRetryAfter(err error) (time.Duration, bool) {
+	var rl *discordgo.RateLimitError
+	if errors.As(err, &rl) && rl.RateLimit != nil && rl.TooManyRequests != nil {
+		return rl.RetryAfter, true
+	}
+	var rest *discordgo.RESTError
+	if errors.As(err, &rest) && rest.Response != nil && rest.Response.StatusCode == http.StatusTooManyRequests {
+		return headerDelay(rest.Response.Header), true
+	}
+	var tg *telegoapi.Error
+	if errors.As(err, &tg) && tg.ErrorCode == http.StatusTooManyRequests {
+		if tg.Parameters != nil {
+			return time.Duration(tg.Parameters.RetryAfter) * time.Second, true
+		}
+		return 0, true
+	}
+	var slackRL *slack.RateLimitedError
+	if errors.As(err, &slackRL) {
+		return slackRL.RetryAfter, true
+	}
+	return 0, false
+}
+
+// headerDelay reads the wait from Discord's rate limit headers, either of
+// which may be fractional seconds.
+func headerDelay(h http.Header) time.Duration {
+	for _, name := range []string{"Retry-After", "X-RateLimit-Reset-After"} {
+		if secs, err := strconv.ParseFloat(h.Get(name), 64); err == nil && secs > 0 {
+			return time.Duration(secs * float64(time.Second))
+		}
+	}
+	return 0
+}
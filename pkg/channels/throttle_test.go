@@ -0,0 +1,129 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/mymmrac/telego/telegoapi"
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/slack-go/slack"
+)
+
+func TestThrottle_Reserve(t *testing.T) {
+	now := time.Unix(1000, 0)
+	th := throttleFor("discord", config.ThrottleConfig{
+		Enabled: true, PerMinute: 60, Burst: 3, ChatPerMinute: 6, ChatBurst: 2,
+	})
+	th.now = func() time.Time { return now }
+	th.all.Updated = now
+
+	if th.reserve("a") != 0 || th.reserve("a") != 0 {
+		t.Fatal("burst of a chat was throttled")
+	}
+	if wait := th.reserve("a"); wait != 10*time.Second {
+		t.Errorf("third to a waits %v, want 10s for the chat bucket", wait)
+	}
+	if th.reserve("b") != 0 {
+		t.Fatal("first to b was throttled")
+	}
+	if wait := th.reserve("c"); wait != time.Second {
+		t.Errorf("channel over its burst waits %v, want 1s", wait)
+	}
+
+	now = now.Add(time.Second)
+	if th.reserve("c") != 0 {
+		t.Error("c still throttled after the channel refilled")
+	}
+
+	th.pause(30 * time.Second)
+	now = now.Add(20 * time.Second)
+	if wait := th.reserve("d"); wait != 10*time.Second {
+		t.Errorf("paused channel waits %v, want the rest of the pause", wait)
+	}
+}
+
+func TestThrottleFor_ChannelOverride(t *testing.T) {
+	cfg := config.ThrottleConfig{Enabled: true, PerMinute: 60, Burst: 1, Channels: map[string]int{"telegram": 0}}
+	th := throttleFor("telegram", cfg)
+	for i := 0; i < 5; i++ {
+		if th.reserve("1") != 0 {
+			t.Fatal("channel without a limit was throttled")
+		}
+	}
+	if throttleFor("discord", cfg).channel.rate != 1 {
+		t.Error("discord did not get the default per_minute")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "1.5")
+	for _, tc := range []struct {
+		name    string
+		err     error
+		delay   time.Duration
+		limited bool
+	}{
+		{"discord rate limit", fmt.Errorf("failed to send discord message: %w", &discordgo.RateLimitError{RateLimit: &discordgo.RateLimit{
+			TooManyRequests: &discordgo.TooManyRequests{RetryAfter: 3 * time.Second},
+		}}), 3 * time.Second, true},
+		{"discord 429", &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusTooManyRequests, Header: header}}, 1500 * time.Millisecond, true},
+		{"telegram flood control", fmt.Errorf("api: %w", &telegoapi.Error{ErrorCode: 429, Parameters: &telegoapi.ResponseParameters{RetryAfter: 7}}), 7 * time.Second, true},
+		{"429 without a delay", &telegoapi.Error{ErrorCode: 429}, defaultRetryAfter, true},
+		{"slack rate limit", &slack.RateLimitedError{RetryAfter: 30 * time.Second}, 30 * time.Second, true},
+		{"other error", &telegoapi.Error{ErrorCode: 400}, 0, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			delay, limited := retryAfter(tc.err)
+			if delay != tc.delay || limited != tc.limited {
+				t.Errorf("retryAfter = %v, %v; want %v, %v", delay, limited, tc.delay, tc.limited)
+			}
+		})
+	}
+}
+
+func TestOutbox_WaitsOutPlatformRateLimit(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string
+	var calls int
+	send := func(ctx context.Context, _ Channel, msg bus.OutboundMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			return &discordgo.RateLimitError{RateLimit: &discordgo.RateLimit{
+				TooManyRequests: &discordgo.TooManyRequests{RetryAfter: 50 * time.Millisecond},
+			}}
+		}
+		sent = append(sent, msg.Content)
+		return nil
+	}
+	// One attempt per message: the rate limit must not use it up
+	ob := newOutbox("discord", nil, t.TempDir(), config.RetryConfig{MaxAttempts: 1}, send)
+
+	runOutbox(t, ob)
+	ob.push(bus.OutboundMessage{ChatID: "1", Content: "digest"})
+	ob.push(bus.OutboundMessage{ChatID: "2", Content: "digest 2"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(sent)
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 2 || sent[0] != "digest" || sent[1] != "digest 2" {
+		t.Fatalf("sent = %q, want both digests in order", sent)
+	}
+}
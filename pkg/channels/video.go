@@ -0,0 +1,140 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/utils"
+	"github.com/sipeed/picoclaw/pkg/voice"
+)
+
+// SetVideoProcessor enables transcripts for video attachments passed to
+// HandleMessage, and keyframes too when vision is on.
+func (c *BaseChannel) SetVideoProcessor(p *voice.VideoProcessor) {
+	c.video = p
+}
+
+// copyVideos copies the downloaded videos in media to temp files, since
+// channels remove their downloads once the message is handed over. Videos
+// that are only URLs are left alone.
+func (c *BaseChannel) copyVideos(media []string) []string {
+	if c.video == nil {
+		return nil
+	}
+
+	var copies []string
+	for _, item := range media {
+		if !isVideoMedia(item) {
+			continue
+		}
+		path, err := copyToTemp(item)
+		if err != nil {
+			logger.WarnCF(c.name, "Video not processed", map[string]interface{}{
+				"media": item,
+				"error": err.Error(),
+			})
+			continue
+		}
+		copies = append(copies, path)
+	}
+	return copies
+}
+
+// publishWithVideos adds what could be extracted from videos to msg and
+// publishes it, removing the videos afterwards.
+func (c *BaseChannel) publishWithVideos(msg bus.InboundMessage, videos []string) {
+	defer func() {
+		for _, path := range videos {
+			os.Remove(path)
+		}
+	}()
+
+	for _, path := range videos {
+		result, err := c.video.Process(context.Background(), path, c.vision != nil)
+		if err != nil {
+			logger.WarnCF(c.name, "Video processing failed", map[string]interface{}{
+				"media": path,
+				"error": err.Error(),
+			})
+			continue
+		}
+
+		if result.Transcript != "" {
+			msg.Content = appendContent(msg.Content, fmt.Sprintf("[video transcription: %s]", result.Transcript))
+		}
+		if frames := c.encodeFrames(msg.Media, result.Frames); len(frames) > 0 {
+			msg.Content = appendContent(msg.Content, fmt.Sprintf("[video keyframes: %d frames attached]", len(frames)))
+			msg.Media = append(msg.Media, frames...)
+		}
+	}
+	c.bus.PublishInbound(msg)
+}
+
+// encodeFrames turns keyframes into data URLs, up to the images media
+// leaves room for, and removes the frame files.
+func (c *BaseChannel) encodeFrames(media, frames []string) []string {
+	defer func() {
+		for _, frame := range frames {
+			os.Remove(frame)
+		}
+	}()
+	if c.vision == nil {
+		return nil
+	}
+
+	attached := 0
+	for _, item := range media {
+		if strings.HasPrefix(item, "data:") {
+			attached++
+		}
+	}
+	var out []string
+	for _, frame := range frames {
+		if c.vision.MaxImages > 0 && attached >= c.vision.MaxImages {
+			break
+		}
+		dataURL, err := readImage(c.mediaClient(), frame, int64(c.vision.MaxSizeMB)<<20)
+		if err != nil {
+			continue
+		}
+		out = append(out, dataURL)
+		attached++
+	}
+	return out
+}
+
+// isVideoMedia reports whether item is a local video file.
+func isVideoMedia(item string) bool {
+	return !isRemoteMedia(item) && utils.IsVideoFile(strings.ToLower(item), "")
+}
+
+// copyToTemp copies the file at path to a new temp file with the same
+// extension.
+func copyToTemp(path string) (string, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	out, err := os.CreateTemp("", "picoclaw-video-*"+filepath.Ext(path))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return out.Name(), nil
+}
@@ -0,0 +1,55 @@
+package channels
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/voice"
+)
+
+func TestIsVideoMedia(t *testing.T) {
+	tests := []struct {
+		item string
+		want bool
+	}{
+		{"/tmp/picoclaw_media/ab12_video.mp4", true},
+		{"/tmp/picoclaw_media/CLIP.MOV", true},
+		{"https://cdn.discordapp.com/attachments/1/2/clip.MOV?ex=65&is=66", false},
+		{"/tmp/picoclaw_media/voice.ogg", false},
+	}
+	for _, tt := range tests {
+		if got := isVideoMedia(tt.item); got != tt.want {
+			t.Errorf("isVideoMedia(%q) = %v, want %v", tt.item, got, tt.want)
+		}
+	}
+}
+
+func TestCopyVideosWithoutProcessor(t *testing.T) {
+	c := NewBaseChannel("test", nil, nil, nil)
+	if videos := c.copyVideos([]string{"/tmp/clip.mp4"}); videos != nil {
+		t.Errorf("copyVideos() = %v, want nothing without a processor", videos)
+	}
+}
+
+func TestCopyVideos_OnlyLocalFiles(t *testing.T) {
+	dir := t.TempDir()
+	clip := filepath.Join(dir, "clip.mp4")
+	if err := os.WriteFile(clip, []byte("video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := NewBaseChannel("test", nil, nil, nil)
+	c.SetVideoProcessor(&voice.VideoProcessor{})
+
+	videos := c.copyVideos([]string{clip, "https://example.com/clip.mp4", filepath.Join(dir, "photo.jpg")})
+	if len(videos) != 1 {
+		t.Fatalf("copyVideos() = %v, want one copy", videos)
+	}
+	defer os.Remove(videos[0])
+
+	// The copy outlives the channel's own download
+	os.Remove(clip)
+	if data, err := os.ReadFile(videos[0]); err != nil || string(data) != "video" {
+		t.Errorf("copy = %q, %v", data, err)
+	}
+}
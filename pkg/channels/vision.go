@@ -0,0 +1,149 @@
+package channels
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// imageFetchTimeout bounds downloading one image attachment.
+const imageFetchTimeout = 30 * time.Second
+
+// visionImageTypes are the image formats vision models accept.
+var visionImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// SetVision enables passing image attachments to the model. Images given
+// to HandleMessage are read while their files still exist and replaced by
+// data URLs, which the agent sends as image parts.
+func (c *BaseChannel) SetVision(cfg config.VisionConfig) {
+	if !cfg.Enabled {
+		c.vision = nil
+		return
+	}
+	c.vision = &cfg
+}
+
+// encodeImages replaces up to MaxImages images in media (local files or
+// URLs) with data URLs. Images that can't be attached stay as they are,
+// and URLs among them are listed in content so the model can still fetch
+// them.
+func (c *BaseChannel) encodeImages(content string, media []string) (string, []string) {
+	if c.vision == nil {
+		return content, media
+	}
+	maxBytes := int64(c.vision.MaxSizeMB) << 20
+
+	out := make([]string, 0, len(media))
+	attached := 0
+	for _, item := range media {
+		if !isImageMedia(item) {
+			out = append(out, item)
+			continue
+		}
+
+		var dataURL string
+		var err error
+		if c.vision.MaxImages > 0 && attached >= c.vision.MaxImages {
+			err = fmt.Errorf("more than %d images", c.vision.MaxImages)
+		} else {
+			dataURL, err = readImage(c.mediaClient(), item, maxBytes)
+		}
+		if err != nil {
+			logger.WarnCF(c.name, "Image not attached", map[string]any{
+				"media": item,
+				"error": err.Error(),
+			})
+			if isRemoteMedia(item) {
+				content = appendContent(content, fmt.Sprintf("[attachment: %s]", item))
+			}
+			out = append(out, item)
+			continue
+		}
+		out = append(out, dataURL)
+		attached++
+	}
+	return content, out
+}
+
+// mediaClient returns the client to fetch the channel's media URLs with.
+func (c *BaseChannel) mediaClient() *http.Client {
+	if callerMediaChannels[c.name] {
+		return callerMediaClient
+	}
+	return http.DefaultClient
+}
+
+// readImage loads an image file or URL as a data URL, refusing anything
+// larger than maxBytes (0 for no limit) or not in a supported format.
+func readImage(client *http.Client, item string, maxBytes int64) (string, error) {
+	var r io.Reader
+	if isRemoteMedia(item) {
+		ctx, cancel := context.WithTimeout(context.Background(), imageFetchTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, item, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(item)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if maxBytes > 0 {
+		r = io.LimitReader(r, maxBytes+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		return "", fmt.Errorf("image larger than %d MB", maxBytes>>20)
+	}
+
+	mimeType := http.DetectContentType(data)
+	if !visionImageTypes[mimeType] {
+		return "", fmt.Errorf("unsupported image type %s", mimeType)
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// isImageMedia checks a local path or URL, ignoring any query string.
+func isImageMedia(item string) bool {
+	name := item
+	if u, err := url.Parse(item); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		name = u.Path
+	}
+	return utils.IsImageFile(name, "")
+}
+
+func isRemoteMedia(item string) bool {
+	return strings.HasPrefix(item, "http://") || strings.HasPrefix(item, "https://")
+}
@@ -0,0 +1,93 @@
+package channels
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+// pngHeader is enough for http.DetectContentType to report image/png.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestIsImageMedia(t *testing.T) {
+	tests := []struct {
+		item string
+		want bool
+	}{
+		{"/tmp/picoclaw_media/ab12_photo.jpg", true},
+		{"https://cdn.discordapp.com/attachments/1/2/Shot.PNG?ex=65&is=66", true},
+		{"https://example.com/view?img=cat.png", false},
+		{"/tmp/picoclaw_media/clip.mp4", false},
+	}
+	for _, tt := range tests {
+		if got := isImageMedia(tt.item); got != tt.want {
+			t.Errorf("isImageMedia(%q) = %v, want %v", tt.item, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeImages(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.png")
+	large := filepath.Join(dir, "large.png")
+	fake := filepath.Join(dir, "fake.jpg")
+	os.WriteFile(small, pngHeader, 0o644)
+	os.WriteFile(large, append(pngHeader, bytes.Repeat([]byte{0}, 1<<20)...), 0o644)
+	os.WriteFile(fake, []byte("not an image"), 0o644)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	missing := srv.URL + "/gone.png"
+
+	c := NewBaseChannel("test", nil, nil, nil)
+	c.SetVision(config.VisionConfig{Enabled: true, MaxImages: 4, MaxSizeMB: 1})
+	content, media := c.encodeImages("look", []string{small, large, fake, missing, "voice.ogg"})
+
+	if !strings.HasPrefix(media[0], "data:image/png;base64,") {
+		t.Errorf("small image not encoded: %.40s", media[0])
+	}
+	for i, want := range []string{large, fake, missing, "voice.ogg"} {
+		if media[i+1] != want {
+			t.Errorf("media[%d] = %.40s, want %s unchanged", i+1, media[i+1], want)
+		}
+	}
+	if content != "look\n[attachment: "+missing+"]" {
+		t.Errorf("content = %q", content)
+	}
+
+	c.SetVision(config.VisionConfig{Enabled: true, MaxImages: 1})
+	_, media = c.encodeImages("", []string{small, small})
+	if !strings.HasPrefix(media[0], "data:") || media[1] != small {
+		t.Errorf("MaxImages not applied: %.40s, %.40s", media[0], media[1])
+	}
+
+	c.SetVision(config.VisionConfig{})
+	if _, media = c.encodeImages("", []string{small}); media[0] != small {
+		t.Errorf("disabled vision encoded %.40s", media[0])
+	}
+}
+
+func TestReadImage_CallerMediaClientRefusesPrivateHosts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(pngHeader)
+	}))
+	defer srv.Close()
+
+	if _, err := readImage(http.DefaultClient, srv.URL+"/a.png", 0); err != nil {
+		t.Fatalf("default client: %v", err)
+	}
+	if _, err := readImage(callerMediaClient, srv.URL+"/a.png", 0); err == nil {
+		t.Error("caller media client fetched from loopback")
+	}
+	if NewBaseChannel("webhook", nil, nil, nil).mediaClient() != callerMediaClient {
+		t.Error("webhook channel doesn't fetch media with the caller media client")
+	}
+}
@@ -0,0 +1,267 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const (
+	webhookPathPrefix = "/hooks/"
+	webhookMaxBody    = 1 << 20
+	// webhookSignatureHeader carries "sha256=<hex HMAC>", the same form
+	// GitHub uses, on requests and callbacks. The HMAC covers
+	// "<timestamp>.<body>" so a captured request can't be replayed later.
+	webhookSignatureHeader = "X-Picoclaw-Signature"
+	// webhookTimestampHeader carries the Unix time the request was signed.
+	webhookTimestampHeader = "X-Picoclaw-Timestamp"
+	// webhookMaxSkew is how far a signed request's timestamp may be from now.
+	webhookMaxSkew = 5 * time.Minute
+)
+
+// WebhookChannel receives messages as HTTP POSTs to /hooks/<name> on the
+// gateway and delivers replies to each hook's callback URL, for
+// automation tools such as Zapier, n8n or Home Assistant.
+//
+// Chat IDs are "<hook>" or "<hook>:<chat_id>" when the request names a
+// chat, so replies find their way back to the hook they came from. The
+// sender is always the hook: anyone holding its secret speaks as it.
+type WebhookChannel struct {
+	*BaseChannel
+	hooks  map[string]config.WebhookHookConfig
+	client *http.Client
+}
+
+// webhookRequest is the JSON body of an inbound POST. A body that isn't
+// JSON is taken as the message text.
+type webhookRequest struct {
+	Text     string            `json:"text"`
+	Message  string            `json:"message"`   // alias for Text
+	SenderID string            `json:"sender_id"` // MQTT only; a hook is its own sender
+	ChatID   string            `json:"chat_id"`
+	Media    []string          `json:"media"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// webhookCallback is the JSON body POSTed to a hook's callback URL.
+type webhookCallback struct {
+	Hook      string `json:"hook"`
+	ChatID    string `json:"chat_id,omitempty"`
+	Content   string `json:"content"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// NewWebhookChannel creates a webhook channel for the configured hooks.
+func NewWebhookChannel(cfg config.WebhookConfig, messageBus *bus.MessageBus) (*WebhookChannel, error) {
+	hooks := make(map[string]config.WebhookHookConfig, len(cfg.Hooks))
+	for _, h := range cfg.Hooks {
+		if h.Name == "" || strings.ContainsAny(h.Name, "/:") {
+			return nil, fmt.Errorf("webhook name %q must be non-empty and contain no '/' or ':'", h.Name)
+		}
+		if h.Secret == "" {
+			return nil, fmt.Errorf("webhook %q needs a secret", h.Name)
+		}
+		if _, dup := hooks[h.Name]; dup {
+			return nil, fmt.Errorf("duplicate webhook name %q", h.Name)
+		}
+		hooks[h.Name] = h
+	}
+	if len(hooks) == 0 {
+		return nil, fmt.Errorf("webhook channel needs at least one hook")
+	}
+
+	return &WebhookChannel{
+		BaseChannel: NewBaseChannel("webhook", cfg, messageBus, cfg.AllowFrom),
+		hooks:       hooks,
+		client:      &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (c *WebhookChannel) Start(ctx context.Context) error {
+	c.setRunning(true)
+	logger.InfoCF("webhook", "Webhook channel started", map[string]any{
+		"hooks": len(c.hooks),
+	})
+	return nil
+}
+
+func (c *WebhookChannel) Stop(ctx context.Context) error {
+	c.setRunning(false)
+	return nil
+}
+
+// Routes serves every hook under /hooks/.
+func (c *WebhookChannel) Routes() map[string]http.Handler {
+	return map[string]http.Handler{webhookPathPrefix: http.HandlerFunc(c.handleHook)}
+}
+
+// Send POSTs the reply to the callback URL of the hook in msg.ChatID.
+func (c *WebhookChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	name, chatID, _ := strings.Cut(msg.ChatID, ":")
+	hook, ok := c.hooks[name]
+	if !ok {
+		return fmt.Errorf("unknown webhook %q", name)
+	}
+	if hook.CallbackURL == "" {
+		logger.DebugCF("webhook", "Hook has no callback URL, reply dropped", map[string]any{
+			"hook": name,
+		})
+		return nil
+	}
+
+	content := msg.Content
+	if content == "" && msg.Embed != nil {
+		content = msg.Embed.Text()
+	}
+	body, err := json.Marshal(webhookCallback{
+		Hook:      name,
+		ChatID:    chatID,
+		Content:   content,
+		Timestamp: time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(webhookTimestampHeader, ts)
+	req.Header.Set(webhookSignatureHeader, signWebhook(hook.Secret, ts, body))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook callback: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook callback returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *WebhookChannel) handleHook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, webhookPathPrefix)
+	hook, ok := c.hooks[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, webhookMaxBody+1))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > webhookMaxBody {
+		http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !authorizeWebhook(hook.Secret, r, body) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req := parseWebhookRequest(r.Header.Get("Content-Type"), body)
+	if strings.TrimSpace(req.Text) == "" && len(req.Media) == 0 {
+		http.Error(w, "empty message", http.StatusBadRequest)
+		return
+	}
+
+	chatID := name
+	if req.ChatID != "" {
+		chatID = name + ":" + req.ChatID
+	}
+	senderID := name
+	if !c.IsAllowed(senderID) {
+		http.Error(w, "sender not allowed", http.StatusForbidden)
+		return
+	}
+
+	metadata := map[string]string{"hook": name, "peer_kind": "direct", "peer_id": senderID}
+	for _, k := range hook.MetadataKeys {
+		if _, reserved := metadata[k]; !reserved && req.Metadata[k] != "" {
+			metadata[k] = req.Metadata[k]
+		}
+	}
+
+	logger.InfoCF("webhook", "Received webhook message", map[string]any{
+		"hook":      name,
+		"sender_id": senderID,
+	})
+	// Media URLs are checked and downloaded before the message is handled;
+	// answer first so slow lookups and downloads don't time out the caller
+	go func() {
+		c.HandleMessage(senderID, chatID, req.Text, callerMedia("webhook", req.Media), metadata)
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"status": "accepted", "chat_id": chatID})
+}
+
+func parseWebhookRequest(contentType string, body []byte) webhookRequest {
+	var req webhookRequest
+	if strings.Contains(contentType, "json") || json.Valid(body) {
+		if err := json.Unmarshal(body, &req); err == nil {
+			if req.Text == "" {
+				req.Text = req.Message
+			}
+			return req
+		}
+	}
+	return webhookRequest{Text: string(body)}
+}
+
+// authorizeWebhook accepts a request with the secret as a bearer token,
+// or with a valid signature over a timestamp within webhookMaxSkew.
+func authorizeWebhook(secret string, r *http.Request, body []byte) bool {
+	if secret == "" {
+		return false
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+	}
+	if sig := r.Header.Get(webhookSignatureHeader); sig != "" {
+		ts := r.Header.Get(webhookTimestampHeader)
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return false
+		}
+		if skew := time.Since(time.Unix(unix, 0)); skew > webhookMaxSkew || skew < -webhookMaxSkew {
+			return false
+		}
+		return hmac.Equal([]byte(sig), []byte(signWebhook(secret, ts, body)))
+	}
+	return false
+}
+
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
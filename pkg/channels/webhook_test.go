@@ -0,0 +1,163 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func newTestWebhook(t *testing.T, hooks ...config.WebhookHookConfig) (*WebhookChannel, *bus.MessageBus) {
+	t.Helper()
+	mb := bus.NewMessageBus()
+	c, err := NewWebhookChannel(config.WebhookConfig{Enabled: true, Hooks: hooks}, mb)
+	if err != nil {
+		t.Fatalf("NewWebhookChannel: %v", err)
+	}
+	return c, mb
+}
+
+func postHook(c *WebhookChannel, path, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	c.Routes()[webhookPathPrefix].ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNewWebhookChannel_RejectsBadNames(t *testing.T) {
+	mb := bus.NewMessageBus()
+	for _, hooks := range [][]config.WebhookHookConfig{
+		nil,
+		{{Name: ""}},
+		{{Name: "a/b"}},
+		{{Name: "n8n", Secret: "s"}, {Name: "n8n", Secret: "s"}},
+		{{Name: "n8n"}},
+	} {
+		if _, err := NewWebhookChannel(config.WebhookConfig{Hooks: hooks}, mb); err == nil {
+			t.Errorf("hooks %+v accepted", hooks)
+		}
+	}
+}
+
+func TestWebhookChannel_Inbound(t *testing.T) {
+	c, mb := newTestWebhook(t, config.WebhookHookConfig{Name: "n8n", Secret: "s3cret"})
+	body := `{"text":"water the plants","chat_id":"garden"}`
+
+	if rec := postHook(c, "/hooks/n8n", body, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned request: HTTP %d, want 401", rec.Code)
+	}
+	if rec := postHook(c, "/hooks/n8n", body, map[string]string{webhookSignatureHeader: "sha256=00"}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("bad signature: HTTP %d, want 401", rec.Code)
+	}
+	if rec := postHook(c, "/hooks/other", body, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown hook: HTTP %d, want 404", rec.Code)
+	}
+
+	stale := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	if rec := postHook(c, "/hooks/n8n", body, map[string]string{
+		webhookTimestampHeader: stale,
+		webhookSignatureHeader: signWebhook("s3cret", stale, []byte(body)),
+	}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("replayed request: HTTP %d, want 401", rec.Code)
+	}
+	if rec := postHook(c, "/hooks/n8n", body, map[string]string{webhookSignatureHeader: signWebhook("s3cret", "", []byte(body))}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("signature without timestamp: HTTP %d, want 401", rec.Code)
+	}
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	rec := postHook(c, "/hooks/n8n", body, map[string]string{
+		webhookTimestampHeader: now,
+		webhookSignatureHeader: signWebhook("s3cret", now, []byte(body)),
+	})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("signed request: HTTP %d, want 202", rec.Code)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, ok := mb.ConsumeInbound(ctx)
+	if !ok {
+		t.Fatal("no inbound message published")
+	}
+	if msg.Channel != "webhook" || msg.ChatID != "n8n:garden" || msg.SenderID != "n8n" || msg.Content != "water the plants" {
+		t.Errorf("inbound = %+v", msg)
+	}
+
+	// Bearer token and plain-text bodies work too
+	rec = postHook(c, "/hooks/n8n", "hello", map[string]string{"Authorization": "Bearer s3cret", "Content-Type": "text/plain"})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("bearer request: HTTP %d, want 202", rec.Code)
+	}
+	msg, ok = mb.ConsumeInbound(ctx)
+	if !ok || msg.ChatID != "n8n" || msg.Content != "hello" {
+		t.Errorf("plain-text inbound = %+v", msg)
+	}
+}
+
+func TestWebhookChannel_SendSignsCallback(t *testing.T) {
+	got := make(chan webhookCallback, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !authorizeWebhook("s3cret", r, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var cb webhookCallback
+		json.Unmarshal(body, &cb)
+		got <- cb
+	}))
+	defer srv.Close()
+
+	c, _ := newTestWebhook(t, config.WebhookHookConfig{Name: "n8n", Secret: "s3cret", CallbackURL: srv.URL})
+	err := c.Send(context.Background(), bus.OutboundMessage{Channel: "webhook", ChatID: "n8n:garden", Content: "Done"})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	cb := <-got
+	if cb.Hook != "n8n" || cb.ChatID != "garden" || cb.Content != "Done" {
+		t.Errorf("callback = %+v", cb)
+	}
+
+	if err := c.Send(context.Background(), bus.OutboundMessage{ChatID: "missing"}); err == nil {
+		t.Error("Send to unknown hook succeeded")
+	}
+}
+
+func TestWebhookChannel_CallerCantChooseSenderOrMetadata(t *testing.T) {
+	c, mb := newTestWebhook(t, config.WebhookHookConfig{Name: "n8n", Secret: "s3cret", MetadataKeys: []string{"max_tool_iterations"}})
+	body := `{"text":"hi","sender_id":"discord:42","metadata":{"persona":"evil","guild_id":"1","max_tool_iterations":"3","hook":"other"},` +
+		`"media":["/etc/passwd","file:///etc/passwd","http://127.0.0.1:8080/a.png","http://169.254.169.254/latest","http://10.0.0.1/b.png","https://93.184.216.34/c.png"]}`
+	if rec := postHook(c, "/hooks/n8n", body, map[string]string{"Authorization": "Bearer s3cret"}); rec.Code != http.StatusAccepted {
+		t.Fatalf("HTTP %d, want 202", rec.Code)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, ok := mb.ConsumeInbound(ctx)
+	if !ok {
+		t.Fatal("no inbound message published")
+	}
+	if msg.SenderID != "n8n" {
+		t.Errorf("sender = %q, want the hook", msg.SenderID)
+	}
+	if msg.Metadata["persona"] != "" || msg.Metadata["guild_id"] != "" || msg.Metadata["hook"] != "n8n" {
+		t.Errorf("metadata = %v, want only allowed keys", msg.Metadata)
+	}
+	if msg.Metadata["max_tool_iterations"] != "3" {
+		t.Errorf("allowed metadata key dropped: %v", msg.Metadata)
+	}
+	if len(msg.Media) != 1 || msg.Media[0] != "https://93.184.216.34/c.png" {
+		t.Errorf("media = %v, want only the public URL", msg.Media)
+	}
+}
@@ -0,0 +1,338 @@
+package channels
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const (
+	webSocketPath         = "/ws"
+	webSocketPongWait     = 60 * time.Second
+	webSocketPingInterval = 30 * time.Second
+	webSocketWriteWait    = 10 * time.Second
+	webSocketMaxMessage   = 1 << 20
+	// webSocketTokenUser is the user of the shared token.
+	webSocketTokenUser = "web"
+)
+
+// WebSocketChannel serves a chat over WebSocket at /ws on the gateway,
+// for web UIs and scripts. Clients send JSON messages and get the reply
+// back on the same connection, streamed as it is generated.
+//
+// A connection's sender is the user its token belongs to, and its chat is
+// the "chat_id" parameter, or a new ID sent in the "connected" event.
+// Chats belong to their user: internally a chat ID is "<user>:<chat_id>",
+// so nobody can join or read another user's chat. Reconnecting with the
+// same chat_id continues the session; every connection of the user to the
+// chat gets its replies.
+type WebSocketChannel struct {
+	*BaseChannel
+	config   config.WebSocketConfig
+	upgrader websocket.Upgrader
+
+	mu      sync.RWMutex
+	clients map[string]map[*wsClient]struct{} // chatID -> connections
+}
+
+// wsClient is one open connection. gorilla/websocket allows one
+// concurrent writer, so writes go through writeMu.
+type wsClient struct {
+	conn    *websocket.Conn
+	chatID  string // "<user>:<chat_id>"
+	writeMu sync.Mutex
+}
+
+// wsInbound is a message from a client.
+type wsInbound struct {
+	Type    string   `json:"type,omitempty"` // "message" (default)
+	Content string   `json:"content"`
+	Media   []string `json:"media,omitempty"`
+}
+
+// wsOutbound is an event sent to a client: "connected", "partial" (the
+// reply so far), "message" (the complete reply) or "error".
+type wsOutbound struct {
+	Type    string `json:"type"`
+	ChatID  string `json:"chat_id,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+func NewWebSocketChannel(cfg config.WebSocketConfig, messageBus *bus.MessageBus) (*WebSocketChannel, error) {
+	if cfg.Token == "" && len(cfg.Users) == 0 {
+		return nil, fmt.Errorf("websocket channel needs a token or users with tokens")
+	}
+	for user, token := range cfg.Users {
+		if user == "" || strings.Contains(user, ":") || user == webSocketTokenUser {
+			return nil, fmt.Errorf("websocket user %q must be non-empty, not %q and contain no ':'", user, webSocketTokenUser)
+		}
+		if token == "" {
+			return nil, fmt.Errorf("websocket user %q needs a token", user)
+		}
+	}
+	c := &WebSocketChannel{
+		BaseChannel: NewBaseChannel("websocket", cfg, messageBus, cfg.AllowFrom),
+		config:      cfg,
+		clients:     make(map[string]map[*wsClient]struct{}),
+	}
+	c.upgrader = websocket.Upgrader{
+		ReadBufferSize:  4096,
+		WriteBufferSize: 4096,
+		CheckOrigin:     c.checkOrigin,
+	}
+	return c, nil
+}
+
+func (c *WebSocketChannel) Start(ctx context.Context) error {
+	c.setRunning(true)
+	logger.InfoCF("websocket", "WebSocket channel started", map[string]any{
+		"path": webSocketPath,
+	})
+	return nil
+}
+
+func (c *WebSocketChannel) Stop(ctx context.Context) error {
+	c.setRunning(false)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for chatID, conns := range c.clients {
+		for cl := range conns {
+			cl.conn.Close()
+		}
+		delete(c.clients, chatID)
+	}
+	return nil
+}
+
+// Routes serves the WebSocket endpoint.
+func (c *WebSocketChannel) Routes() map[string]http.Handler {
+	return map[string]http.Handler{webSocketPath: http.HandlerFunc(c.handleConnect)}
+}
+
+// Send delivers a complete reply to every connection of the chat.
+func (c *WebSocketChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	content := msg.Content
+	if content == "" && msg.Embed != nil {
+		content = msg.Embed.Text()
+	}
+	return c.broadcast(msg.ChatID, wsOutbound{Type: "message", ChatID: clientChatID(msg.ChatID), Content: content})
+}
+
+// SendPartial delivers the reply generated so far.
+func (c *WebSocketChannel) SendPartial(ctx context.Context, msg bus.OutboundMessage) error {
+	return c.broadcast(msg.ChatID, wsOutbound{Type: "partial", ChatID: clientChatID(msg.ChatID), Content: msg.Content})
+}
+
+// clientChatID returns the chat_id the client knows a chat by.
+func clientChatID(chatID string) string {
+	_, id, _ := strings.Cut(chatID, ":")
+	return id
+}
+
+func (c *WebSocketChannel) broadcast(chatID string, event wsOutbound) error {
+	c.mu.RLock()
+	conns := make([]*wsClient, 0, len(c.clients[chatID]))
+	for cl := range c.clients[chatID] {
+		conns = append(conns, cl)
+	}
+	c.mu.RUnlock()
+
+	if len(conns) == 0 {
+		return fmt.Errorf("no WebSocket client connected for chat %s", chatID)
+	}
+	var lastErr error
+	delivered := false
+	for _, cl := range conns {
+		if err := cl.write(event); err != nil {
+			lastErr = err
+			continue
+		}
+		delivered = true
+	}
+	if !delivered {
+		return lastErr
+	}
+	return nil
+}
+
+func (c *WebSocketChannel) handleConnect(w http.ResponseWriter, r *http.Request) {
+	if !c.IsRunning() {
+		http.Error(w, "channel not running", http.StatusServiceUnavailable)
+		return
+	}
+	senderID, ok := c.authenticate(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !c.IsAllowed(senderID) {
+		http.Error(w, "user not allowed", http.StatusForbidden)
+		return
+	}
+	clientChat := r.URL.Query().Get("chat_id")
+	if clientChat == "" {
+		clientChat = uuid.NewString()
+	}
+	chatID := senderID + ":" + clientChat
+
+	conn, err := c.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied with an error
+		return
+	}
+	cl := &wsClient{conn: conn, chatID: chatID}
+	c.addClient(cl)
+	defer c.removeClient(cl)
+
+	logger.InfoCF("websocket", "Client connected", map[string]any{
+		"chat_id":   chatID,
+		"sender_id": senderID,
+	})
+	if err := cl.write(wsOutbound{Type: "connected", ChatID: clientChat}); err != nil {
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go cl.pinger(done)
+
+	c.readLoop(cl, senderID)
+}
+
+func (c *WebSocketChannel) readLoop(cl *wsClient, senderID string) {
+	conn := cl.conn
+	conn.SetReadLimit(webSocketMaxMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(webSocketPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(webSocketPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.DebugCF("websocket", "Connection closed", map[string]any{
+					"chat_id": cl.chatID,
+					"error":   err.Error(),
+				})
+			}
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(webSocketPongWait))
+
+		var in wsInbound
+		if err := json.Unmarshal(data, &in); err != nil {
+			cl.write(wsOutbound{Type: "error", Content: "messages must be JSON: {\"content\": \"...\"}"})
+			continue
+		}
+		if in.Type != "" && in.Type != "message" {
+			cl.write(wsOutbound{Type: "error", Content: fmt.Sprintf("unknown message type %q", in.Type)})
+			continue
+		}
+		if strings.TrimSpace(in.Content) == "" && len(in.Media) == 0 {
+			continue
+		}
+
+		c.HandleMessage(senderID, cl.chatID, in.Content, callerMedia("websocket", in.Media), map[string]string{
+			"peer_kind": "direct",
+			"peer_id":   senderID,
+		})
+	}
+}
+
+func (c *WebSocketChannel) addClient(cl *wsClient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.clients[cl.chatID] == nil {
+		c.clients[cl.chatID] = make(map[*wsClient]struct{})
+	}
+	c.clients[cl.chatID][cl] = struct{}{}
+}
+
+func (c *WebSocketChannel) removeClient(cl *wsClient) {
+	c.mu.Lock()
+	delete(c.clients[cl.chatID], cl)
+	if len(c.clients[cl.chatID]) == 0 {
+		delete(c.clients, cl.chatID)
+	}
+	c.mu.Unlock()
+	cl.conn.Close()
+}
+
+// authenticate returns the user whose token the request carries, sent as
+// a bearer token or, for browsers, which can't set headers on WebSocket
+// requests, a token query parameter.
+func (c *WebSocketChannel) authenticate(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		return "", false
+	}
+	user := ""
+	if c.config.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(c.config.Token)) == 1 {
+		user = webSocketTokenUser
+	}
+	for name, userToken := range c.config.Users {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(userToken)) == 1 {
+			user = name
+		}
+	}
+	return user, user != ""
+}
+
+// checkOrigin allows non-browser clients (no Origin header), same-origin
+// pages and the configured origins.
+func (c *WebSocketChannel) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range c.config.AllowOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+func (cl *wsClient) write(event wsOutbound) error {
+	cl.writeMu.Lock()
+	defer cl.writeMu.Unlock()
+	_ = cl.conn.SetWriteDeadline(time.Now().Add(webSocketWriteWait))
+	return cl.conn.WriteJSON(event)
+}
+
+func (cl *wsClient) pinger(done <-chan struct{}) {
+	ticker := time.NewTicker(webSocketPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			cl.writeMu.Lock()
+			err := cl.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(webSocketWriteWait))
+			cl.writeMu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
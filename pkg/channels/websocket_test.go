@@ -0,0 +1,132 @@
+package channels
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func startTestWebSocket(t *testing.T, cfg config.WebSocketConfig) (*WebSocketChannel, *bus.MessageBus, string) {
+	t.Helper()
+	mb := bus.NewMessageBus()
+	c, err := NewWebSocketChannel(cfg, mb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Start(context.Background())
+	srv := httptest.NewServer(c.Routes()[webSocketPath])
+	t.Cleanup(func() {
+		c.Stop(context.Background())
+		srv.Close()
+	})
+	return c, mb, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestNewWebSocketChannel_RequiresToken(t *testing.T) {
+	mb := bus.NewMessageBus()
+	for _, cfg := range []config.WebSocketConfig{
+		{},
+		{Users: map[string]string{"alice": ""}},
+		{Users: map[string]string{"a:b": "t"}},
+		{Token: "t", Users: map[string]string{"web": "u"}},
+	} {
+		if _, err := NewWebSocketChannel(cfg, mb); err == nil {
+			t.Errorf("config %+v accepted", cfg)
+		}
+	}
+}
+
+func TestWebSocketChannel_Auth(t *testing.T) {
+	_, _, url := startTestWebSocket(t, config.WebSocketConfig{
+		Token:     "s3cret",
+		Users:     map[string]string{"alice": "alice-token", "mallory": "mallory-token"},
+		AllowFrom: []string{"alice", "web"},
+	})
+
+	for _, tc := range []struct {
+		query string
+		want  int
+	}{
+		{"?user=alice", http.StatusUnauthorized},
+		{"?user=alice&token=wrong", http.StatusUnauthorized},
+		{"?token=mallory-token", http.StatusForbidden},
+	} {
+		_, resp, err := websocket.DefaultDialer.Dial(url+tc.query, nil)
+		if err == nil || resp == nil || resp.StatusCode != tc.want {
+			t.Errorf("%s: err %v, want HTTP %d", tc.query, err, tc.want)
+		}
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer s3cret"}})
+	if err != nil {
+		t.Fatalf("bearer token rejected: %v", err)
+	}
+	conn.Close()
+}
+
+func TestWebSocketChannel_RoundTrip(t *testing.T) {
+	c, mb, url := startTestWebSocket(t, config.WebSocketConfig{Users: map[string]string{"alice": "alice-token", "bob": "bob-token"}})
+
+	// The user comes from the token, not the query
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?user=bob&chat_id=kitchen&token=alice-token", nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	var event wsOutbound
+	if err := conn.ReadJSON(&event); err != nil || event.Type != "connected" || event.ChatID != "kitchen" {
+		t.Fatalf("first event = %+v, %v", event, err)
+	}
+
+	if err := conn.WriteJSON(wsInbound{Content: "what's for dinner?"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	msg, ok := mb.ConsumeInbound(ctx)
+	if !ok || msg.Channel != "websocket" || msg.ChatID != "alice:kitchen" || msg.SenderID != "alice" || msg.Content != "what's for dinner?" {
+		t.Fatalf("inbound = %+v", msg)
+	}
+
+	// Another user's connection to the same chat_id is another chat
+	other, _, err := websocket.DefaultDialer.Dial(url+"?chat_id=kitchen&token=bob-token", nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer other.Close()
+	other.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := other.ReadJSON(&event); err != nil || event.Type != "connected" {
+		t.Fatalf("bob's first event = %+v, %v", event, err)
+	}
+
+	c.SendPartial(ctx, bus.OutboundMessage{ChatID: "alice:kitchen", Content: "Pasta", Partial: true})
+	c.Send(ctx, bus.OutboundMessage{ChatID: "alice:kitchen", Content: "Pasta with pesto"})
+	for _, want := range []wsOutbound{
+		{Type: "partial", ChatID: "kitchen", Content: "Pasta"},
+		{Type: "message", ChatID: "kitchen", Content: "Pasta with pesto"},
+	} {
+		event = wsOutbound{}
+		if err := conn.ReadJSON(&event); err != nil || event != want {
+			t.Errorf("event = %+v, %v; want %+v", event, err, want)
+		}
+	}
+
+	if err := c.Send(ctx, bus.OutboundMessage{ChatID: "alice:elsewhere", Content: "hi"}); err == nil {
+		t.Error("Send to a chat with no connection succeeded")
+	}
+
+	other.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if err := other.ReadJSON(&event); err == nil {
+		t.Errorf("bob got alice's reply: %+v", event)
+	}
+}
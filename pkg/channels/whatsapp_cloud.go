@@ -0,0 +1,463 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/utils"
+	"github.com/sipeed/picoclaw/pkg/voice"
+)
+
+const (
+	whatsAppGraphBase     = "https://graph.facebook.com"
+	whatsAppMaxTextLength = 4096
+)
+
+// WhatsAppCloudChannel implements the Channel interface for the WhatsApp
+// Business Cloud API. Inbound messages arrive as Graph API webhooks and
+// replies are sent through the phone number's /messages endpoint. The
+// webhook is served on the gateway's server.
+type WhatsAppCloudChannel struct {
+	*BaseChannel
+	config      config.WhatsAppCloudConfig
+	apiBase     string
+	transcriber *voice.GroqTranscriber
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// NewWhatsAppCloudChannel creates a new WhatsApp Cloud API channel instance.
+func NewWhatsAppCloudChannel(cfg config.WhatsAppCloudConfig, messageBus *bus.MessageBus) (*WhatsAppCloudChannel, error) {
+	if cfg.PhoneNumberID == "" || cfg.AccessToken == "" {
+		return nil, fmt.Errorf("whatsapp_cloud phone_number_id and access_token are required")
+	}
+	if cfg.VerifyToken == "" {
+		return nil, fmt.Errorf("whatsapp_cloud verify_token is required for webhook verification")
+	}
+	if cfg.AppSecret == "" {
+		return nil, fmt.Errorf("whatsapp_cloud app_secret is required to verify webhook signatures")
+	}
+
+	apiVersion := cfg.APIVersion
+	if apiVersion == "" {
+		apiVersion = "v21.0"
+	}
+
+	base := NewBaseChannel("whatsapp_cloud", cfg, messageBus, whatsAppAllowList(cfg.AllowFrom))
+
+	return &WhatsAppCloudChannel{
+		BaseChannel: base,
+		config:      cfg,
+		apiBase:     whatsAppGraphBase + "/" + apiVersion,
+	}, nil
+}
+
+func (c *WhatsAppCloudChannel) SetTranscriber(transcriber *voice.GroqTranscriber) {
+	c.transcriber = transcriber
+}
+
+// Start marks the channel running; webhooks arrive through Routes.
+func (c *WhatsAppCloudChannel) Start(ctx context.Context) error {
+	c.ctx, c.cancel = context.WithCancel(ctx)
+	c.setRunning(true)
+	logger.InfoCF("whatsapp_cloud", "WhatsApp Cloud API channel started", map[string]interface{}{
+		"path": c.webhookPath(),
+	})
+	return nil
+}
+
+func (c *WhatsAppCloudChannel) Stop(ctx context.Context) error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.setRunning(false)
+	logger.InfoC("whatsapp_cloud", "WhatsApp Cloud API channel stopped")
+	return nil
+}
+
+// Routes serves the webhook on the gateway.
+func (c *WhatsAppCloudChannel) Routes() map[string]http.Handler {
+	return map[string]http.Handler{c.webhookPath(): http.HandlerFunc(c.webhookHandler)}
+}
+
+func (c *WhatsAppCloudChannel) webhookPath() string {
+	if c.config.WebhookPath == "" {
+		return "/webhook/whatsapp"
+	}
+	return c.config.WebhookPath
+}
+
+// webhookHandler answers the subscription handshake (GET) and receives
+// message notifications (POST).
+func (c *WhatsAppCloudChannel) webhookHandler(w http.ResponseWriter, r *http.Request) {
+	if !c.IsRunning() {
+		http.Error(w, "Channel not running", http.StatusServiceUnavailable)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		c.handleVerification(w, r)
+	case http.MethodPost:
+		c.handleNotification(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (c *WhatsAppCloudChannel) handleVerification(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("hub.mode") != "subscribe" || q.Get("hub.verify_token") != c.config.VerifyToken {
+		logger.WarnC("whatsapp_cloud", "Webhook verification failed")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(q.Get("hub.challenge")))
+}
+
+func (c *WhatsAppCloudChannel) handleNotification(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	if !c.verifySignature(body, r.Header.Get("X-Hub-Signature-256")) {
+		logger.WarnC("whatsapp_cloud", "Invalid webhook signature")
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	var payload whatsAppWebhook
+	if err := json.Unmarshal(body, &payload); err != nil {
+		logger.ErrorCF("whatsapp_cloud", "Failed to parse webhook payload", map[string]interface{}{
+			"error": err.Error(),
+		})
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	// Meta retries deliveries that are not acknowledged quickly
+	w.WriteHeader(http.StatusOK)
+
+	for _, entry := range payload.Entry {
+		for _, change := range entry.Changes {
+			if change.Field != "messages" {
+				continue
+			}
+			names := make(map[string]string)
+			for _, contact := range change.Value.Contacts {
+				names[contact.WaID] = contact.Profile.Name
+			}
+			for _, msg := range change.Value.Messages {
+				go c.processMessage(msg, names[msg.From])
+			}
+		}
+	}
+}
+
+// verifySignature validates X-Hub-Signature-256 ("sha256=<hex>") against the
+// app secret.
+func (c *WhatsAppCloudChannel) verifySignature(body []byte, signature string) bool {
+	sig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(c.config.AppSecret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(sig))
+}
+
+// WhatsApp Cloud API webhook payload types
+type whatsAppWebhook struct {
+	Object string `json:"object"`
+	Entry  []struct {
+		ID      string `json:"id"`
+		Changes []struct {
+			Field string `json:"field"`
+			Value struct {
+				Contacts []struct {
+					WaID    string `json:"wa_id"`
+					Profile struct {
+						Name string `json:"name"`
+					} `json:"profile"`
+				} `json:"contacts"`
+				Messages []whatsAppMessage `json:"messages"`
+			} `json:"value"`
+		} `json:"changes"`
+	} `json:"entry"`
+}
+
+type whatsAppMessage struct {
+	From      string `json:"from"`
+	ID        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+	Type      string `json:"type"` // "text", "image", "audio", "video", "document", "sticker", ...
+	Text      *struct {
+		Body string `json:"body"`
+	} `json:"text,omitempty"`
+	Image    *whatsAppMedia `json:"image,omitempty"`
+	Audio    *whatsAppMedia `json:"audio,omitempty"`
+	Video    *whatsAppMedia `json:"video,omitempty"`
+	Document *whatsAppMedia `json:"document,omitempty"`
+	Sticker  *whatsAppMedia `json:"sticker,omitempty"`
+}
+
+type whatsAppMedia struct {
+	ID       string `json:"id"`
+	MimeType string `json:"mime_type"`
+	Caption  string `json:"caption,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Voice    bool   `json:"voice,omitempty"`
+}
+
+func (c *WhatsAppCloudChannel) processMessage(msg whatsAppMessage, profileName string) {
+	senderID := msg.From
+	if !c.admitsMessage(senderID) {
+		logger.DebugCF("whatsapp_cloud", "Message rejected by allowlist", map[string]interface{}{
+			"sender_id": senderID,
+		})
+		return
+	}
+
+	var content string
+	var mediaPaths []string
+	localFiles := []string{}
+
+	defer func() {
+		for _, file := range localFiles {
+			if err := os.Remove(file); err != nil {
+				logger.DebugCF("whatsapp_cloud", "Failed to cleanup temp file", map[string]interface{}{
+					"file":  file,
+					"error": err.Error(),
+				})
+			}
+		}
+	}()
+
+	addMedia := func(media *whatsAppMedia, fallbackName, label string) string {
+		if media == nil {
+			return fmt.Sprintf("[%s]", label)
+		}
+		filename := media.Filename
+		if filename == "" {
+			filename = fallbackName
+		}
+		localPath := c.downloadMedia(media.ID, filename)
+		if localPath == "" {
+			return fmt.Sprintf("[%s (download failed)]", label)
+		}
+		localFiles = append(localFiles, localPath)
+		mediaPaths = append(mediaPaths, localPath)
+
+		text := fmt.Sprintf("[%s: %s]", label, filename)
+		if media.Caption != "" {
+			text = media.Caption + "\n" + text
+		}
+		return text
+	}
+
+	switch msg.Type {
+	case "text":
+		if msg.Text != nil {
+			content = msg.Text.Body
+		}
+	case "image":
+		content = addMedia(msg.Image, "image.jpg", "image")
+	case "video":
+		content = addMedia(msg.Video, "video.mp4", "video")
+	case "document":
+		content = addMedia(msg.Document, "document", "file")
+	case "sticker":
+		content = "[sticker]"
+	case "audio":
+		content = addMedia(msg.Audio, "audio.ogg", "audio")
+		if len(mediaPaths) > 0 && c.transcriber != nil && c.transcriber.IsAvailable() {
+			result, err := c.transcriber.Transcribe(c.ctx, mediaPaths[len(mediaPaths)-1])
+			if err != nil {
+				logger.ErrorCF("whatsapp_cloud", "Voice transcription failed", map[string]interface{}{"error": err.Error()})
+				content = "[audio (transcription failed)]"
+			} else {
+				content = fmt.Sprintf("[voice transcription: %s]", result.Text)
+			}
+		}
+	default:
+		content = fmt.Sprintf("[%s]", msg.Type)
+	}
+
+	if strings.TrimSpace(content) == "" {
+		return
+	}
+
+	metadata := map[string]string{
+		"platform":     "whatsapp_cloud",
+		"message_id":   msg.ID,
+		"message_type": msg.Type,
+		"peer_kind":    "direct",
+		"peer_id":      senderID,
+	}
+	if profileName != "" {
+		metadata["user_name"] = profileName
+	}
+
+	logger.DebugCF("whatsapp_cloud", "Received message", map[string]interface{}{
+		"sender_id":    senderID,
+		"message_type": msg.Type,
+		"preview":      utils.Truncate(content, 50),
+	})
+
+	c.HandleMessage(senderID, senderID, content, mediaPaths, metadata)
+}
+
+// Send delivers a text reply through the Graph API, splitting long messages.
+func (c *WhatsAppCloudChannel) Send(ctx context.Context, msg bus.OutboundMessage) error {
+	if !c.IsRunning() {
+		return fmt.Errorf("whatsapp_cloud channel not running")
+	}
+
+	to := normalizeWhatsAppNumber(msg.ChatID)
+	if to == "" {
+		return fmt.Errorf("invalid whatsapp chat ID: %s", msg.ChatID)
+	}
+
+	for _, chunk := range utils.SplitMessage(msg.Content, whatsAppMaxTextLength) {
+		payload := map[string]interface{}{
+			"messaging_product": "whatsapp",
+			"recipient_type":    "individual",
+			"to":                to,
+			"type":              "text",
+			"text": map[string]interface{}{
+				"preview_url": false,
+				"body":        chunk,
+			},
+		}
+		if err := c.callAPI(ctx, c.apiBase+"/"+c.config.PhoneNumberID+"/messages", payload); err != nil {
+			return fmt.Errorf("failed to send whatsapp message: %w", err)
+		}
+	}
+
+	logger.DebugCF("whatsapp_cloud", "Message sent", map[string]interface{}{
+		"to": to,
+	})
+	return nil
+}
+
+// callAPI makes an authenticated POST request to the Graph API.
+func (c *WhatsAppCloudChannel) callAPI(ctx context.Context, endpoint string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Graph API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// downloadMedia resolves a media ID to its temporary URL and downloads it.
+func (c *WhatsAppCloudChannel) downloadMedia(mediaID, filename string) string {
+	if mediaID == "" {
+		return ""
+	}
+
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, c.apiBase+"/"+mediaID, nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.ErrorCF("whatsapp_cloud", "Failed to resolve media URL", map[string]interface{}{
+			"media_id": mediaID,
+			"error":    err.Error(),
+		})
+		return ""
+	}
+	defer resp.Body.Close()
+
+	var info struct {
+		URL string `json:"url"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&info) != nil || info.URL == "" {
+		logger.ErrorCF("whatsapp_cloud", "Failed to resolve media URL", map[string]interface{}{
+			"media_id": mediaID,
+			"status":   resp.StatusCode,
+		})
+		return ""
+	}
+
+	return utils.DownloadFile(info.URL, filename, utils.DownloadOptions{
+		LoggerPrefix: "whatsapp_cloud",
+		ExtraHeaders: map[string]string{
+			"Authorization": "Bearer " + c.config.AccessToken,
+		},
+	})
+}
+
+// whatsAppAllowList normalizes allowlisted numbers: WhatsApp IDs are bare
+// digits, and "+15551234567" is accepted too.
+func whatsAppAllowList(numbers []string) []string {
+	allowList := make([]string, 0, len(numbers))
+	for _, number := range numbers {
+		allowList = append(allowList, normalizeWhatsAppNumber(number))
+	}
+	return allowList
+}
+
+func (c *WhatsAppCloudChannel) AddAllowedUser(number string) bool {
+	return c.BaseChannel.AddAllowedUser(normalizeWhatsAppNumber(number))
+}
+
+func (c *WhatsAppCloudChannel) RemoveAllowedUser(number string) (bool, error) {
+	return c.BaseChannel.RemoveAllowedUser(normalizeWhatsAppNumber(number))
+}
+
+func (c *WhatsAppCloudChannel) SetAllowList(numbers []string) {
+	c.BaseChannel.SetAllowList(whatsAppAllowList(numbers))
+}
+
+// normalizeWhatsAppNumber strips formatting from a phone number so that
+// "+1 (555) 123-4567" and "15551234567" compare equal.
+func normalizeWhatsAppNumber(number string) string {
+	var sb strings.Builder
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,135 @@
+package channels
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func newTestWhatsAppCloudChannel(t *testing.T, allowFrom ...string) (*WhatsAppCloudChannel, *bus.MessageBus) {
+	t.Helper()
+	msgBus := bus.NewMessageBus()
+	ch, err := NewWhatsAppCloudChannel(config.WhatsAppCloudConfig{
+		PhoneNumberID: "1234",
+		AccessToken:   "token",
+		AppSecret:     "secret",
+		VerifyToken:   "verify-me",
+		AllowFrom:     allowFrom,
+	}, msgBus)
+	if err != nil {
+		t.Fatalf("NewWhatsAppCloudChannel: %v", err)
+	}
+	ch.Start(context.Background())
+	t.Cleanup(func() { ch.Stop(context.Background()) })
+	return ch, msgBus
+}
+
+func TestNewWhatsAppCloudChannel_RequiresCredentials(t *testing.T) {
+	_, err := NewWhatsAppCloudChannel(config.WhatsAppCloudConfig{PhoneNumberID: "1"}, bus.NewMessageBus())
+	if err == nil {
+		t.Error("expected error without access token")
+	}
+	_, err = NewWhatsAppCloudChannel(config.WhatsAppCloudConfig{PhoneNumberID: "1", AccessToken: "token", VerifyToken: "v"}, bus.NewMessageBus())
+	if err == nil {
+		t.Error("expected error without app secret")
+	}
+}
+
+func TestWhatsAppCloudRoutes(t *testing.T) {
+	ch, _ := newTestWhatsAppCloudChannel(t)
+	handler, ok := ch.Routes()["/webhook/whatsapp"]
+	if !ok {
+		t.Fatalf("routes = %v, want the webhook path", ch.Routes())
+	}
+	req := httptest.NewRequest(http.MethodGet, "/webhook/whatsapp?hub.mode=subscribe&hub.verify_token=verify-me&hub.challenge=7", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "7" {
+		t.Errorf("verification through the route: %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWhatsAppCloudVerification(t *testing.T) {
+	ch, _ := newTestWhatsAppCloudChannel(t)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{"valid", "hub.mode=subscribe&hub.verify_token=verify-me&hub.challenge=42", http.StatusOK, "42"},
+		{"wrong token", "hub.mode=subscribe&hub.verify_token=nope&hub.challenge=42", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/webhook/whatsapp?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			ch.webhookHandler(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestWhatsAppCloudNotification(t *testing.T) {
+	ch, msgBus := newTestWhatsAppCloudChannel(t, "+1 555 0100")
+
+	body := `{"object":"whatsapp_business_account","entry":[{"id":"1","changes":[{"field":"messages","value":{
+		"contacts":[{"wa_id":"15550100","profile":{"name":"Alice"}}],
+		"messages":[{"from":"15550100","id":"wamid.1","timestamp":"1700000000","type":"text","text":{"body":"hello"}}]}}]}]}`
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(body))
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	// Bad signature is rejected
+	req := httptest.NewRequest(http.MethodPost, "/webhook/whatsapp", strings.NewReader(body))
+	req.Header.Set("X-Hub-Signature-256", "sha256=deadbeef")
+	rec := httptest.NewRecorder()
+	ch.webhookHandler(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("bad signature status = %d, want 403", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/webhook/whatsapp", strings.NewReader(body))
+	req.Header.Set("X-Hub-Signature-256", signature)
+	rec = httptest.NewRecorder()
+	ch.webhookHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	msg, ok := msgBus.ConsumeInbound(ctx)
+	if !ok {
+		t.Fatal("no inbound message published")
+	}
+	if msg.SenderID != "15550100" || msg.ChatID != "15550100" || msg.Content != "hello" {
+		t.Errorf("inbound = %+v", msg)
+	}
+	if msg.Metadata["user_name"] != "Alice" {
+		t.Errorf("user_name = %q", msg.Metadata["user_name"])
+	}
+}
+
+func TestNormalizeWhatsAppNumber(t *testing.T) {
+	if got := normalizeWhatsAppNumber("+1 (555) 123-4567"); got != "15551234567" {
+		t.Errorf("normalizeWhatsAppNumber = %q", got)
+	}
+}
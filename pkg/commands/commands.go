@@ -0,0 +1,232 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package commands describes the slash commands the agent understands, in
+// a form channels can register for autocomplete: Discord slash commands,
+// the Telegram bot command list.
+package commands
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// MaxNameLength and MaxDescriptionLength are the strictest limits
+	// among the channels (Discord and Telegram both allow 32 character
+	// names; Discord allows 100 character descriptions).
+	MaxNameLength        = 32
+	MaxDescriptionLength = 100
+	// MaxOptions is the most options Discord allows on a command.
+	MaxOptions = 25
+)
+
+// Kinds of command.
+const (
+	KindBuiltin = "builtin"
+	KindTool    = "tool"
+	KindSkill   = "skill"
+)
+
+// Option types, a subset of JSON schema types every channel can show.
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+)
+
+// Command is one slash command.
+type Command struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Kind        string   `json:"kind"`
+	Options     []Option `json:"options,omitempty"`
+	// Target is the tool or skill the command runs, whose name may not be
+	// a valid command name, e.g. skill "github-issues" is
+	// /github_issues.
+	Target string `json:"target,omitempty"`
+	// Private commands are answered only to the user who ran them.
+	Private bool `json:"private,omitempty"`
+}
+
+// Option is one argument of a command.
+type Option struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// Builtin returns the commands the agent handles itself.
+func Builtin() []Command {
+	return []Command{
+		{Name: "show", Description: "Show the current model, channel or agents", Kind: KindBuiltin, Options: []Option{
+			{Name: "target", Description: "model, channel or agents", Type: TypeString, Required: true},
+		}},
+		{Name: "list", Description: "List models, channels or agents", Kind: KindBuiltin, Options: []Option{
+			{Name: "target", Description: "models, channels or agents", Type: TypeString, Required: true},
+		}},
+		{Name: "announcements", Description: "Opt in to announcements from the owner", Kind: KindBuiltin, Options: []Option{
+			{Name: "action", Description: "on, off or status", Type: TypeString},
+		}},
+		{Name: "feedback", Description: "Rate the last reply", Kind: KindBuiltin, Options: []Option{
+			{Name: "rating", Description: "up or down, optionally followed by a comment", Type: TypeString},
+		}},
+		{Name: "prompt", Description: "Run a prompt from the prompt library", Kind: KindBuiltin, Options: []Option{
+			{Name: "name", Description: "Prompt name and its variables, e.g. standup team=infra", Type: TypeString},
+		}},
+		// Admin commands, answered privately
+		{Name: "status", Description: "Admin: show version, uptime, model and channels", Kind: KindBuiltin, Private: true},
+		{Name: "reload", Description: "Admin: reload admin users, allowlists and API keys from the config", Kind: KindBuiltin, Private: true},
+		{Name: "allow", Description: "Admin: let a user use the bot, or list who can", Kind: KindBuiltin, Private: true, Options: []Option{
+			{Name: "user", Description: "User ID, optionally followed by a channel", Type: TypeString},
+		}},
+		{Name: "revoke", Description: "Admin: take a user off the allowlist", Kind: KindBuiltin, Private: true, Options: []Option{
+			{Name: "user", Description: "User ID, optionally followed by a channel", Type: TypeString, Required: true},
+		}},
+		{Name: "skills", Description: "Admin: list installed skills", Kind: KindBuiltin, Private: true},
+		{Name: "selftest", Description: "Admin: check that every tool works", Kind: KindBuiltin, Private: true},
+		{Name: "model", Description: "Admin: show or switch the default model", Kind: KindBuiltin, Private: true, Options: []Option{
+			{Name: "name", Description: "Model to switch to", Type: TypeString},
+		}},
+	}
+}
+
+// FromTool describes a tool as a command whose options are the tool's
+// top-level parameters of simple types.
+func FromTool(name, description string, params map[string]interface{}) Command {
+	cmd := Command{
+		Name:        Normalize(name),
+		Description: Describe(description, name),
+		Kind:        KindTool,
+		Target:      name,
+	}
+
+	props, _ := params["properties"].(map[string]interface{})
+	required := map[string]bool{}
+	switch req := params["required"].(type) {
+	case []string:
+		for _, r := range req {
+			required[r] = true
+		}
+	case []interface{}:
+		for _, r := range req {
+			if s, ok := r.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	for _, pname := range sortedKeys(props) {
+		prop, _ := props[pname].(map[string]interface{})
+		typ, _ := prop["type"].(string)
+		switch typ {
+		case TypeString, TypeInteger, TypeNumber, TypeBoolean:
+		default:
+			// Arrays and objects can't be typed into an autocomplete box
+			continue
+		}
+		desc, _ := prop["description"].(string)
+		cmd.Options = append(cmd.Options, Option{
+			Name:        Normalize(pname),
+			Description: Describe(desc, pname),
+			Type:        typ,
+			Required:    required[pname],
+		})
+	}
+	// Channels list required options first
+	sort.SliceStable(cmd.Options, func(i, j int) bool {
+		return cmd.Options[i].Required && !cmd.Options[j].Required
+	})
+	if len(cmd.Options) > MaxOptions {
+		cmd.Options = cmd.Options[:MaxOptions]
+	}
+	return cmd
+}
+
+// FromSkill describes a skill as a command with one free-text option.
+func FromSkill(name, description string) Command {
+	return Command{
+		Name:        Normalize(name),
+		Description: Describe(description, name),
+		Kind:        KindSkill,
+		Target:      name,
+		Options: []Option{
+			{Name: "request", Description: "What you want done", Type: TypeString},
+		},
+	}
+}
+
+// Dedupe drops commands whose name is already taken by an earlier one,
+// so builtins win over tools and tools over skills.
+func Dedupe(cmds []Command) []Command {
+	seen := make(map[string]bool, len(cmds))
+	out := cmds[:0:0]
+	for _, c := range cmds {
+		if c.Name == "" || seen[c.Name] {
+			continue
+		}
+		seen[c.Name] = true
+		out = append(out, c)
+	}
+	return out
+}
+
+// Find returns the command named name.
+func Find(cmds []Command, name string) (Command, bool) {
+	for _, c := range cmds {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Command{}, false
+}
+
+// Normalize turns a tool or skill name into a valid command name:
+// lowercase letters, digits and underscores, at most MaxNameLength long.
+func Normalize(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			sb.WriteRune(r)
+		case r == '-' || r == ' ' || r == '.':
+			sb.WriteByte('_')
+		}
+	}
+	s := sb.String()
+	if len(s) > MaxNameLength {
+		s = s[:MaxNameLength]
+	}
+	return s
+}
+
+// Describe shortens description to its first sentence within
+// MaxDescriptionLength, falling back to fallback when it is empty.
+func Describe(description, fallback string) string {
+	d := strings.TrimSpace(strings.Join(strings.Fields(description), " "))
+	if d == "" {
+		d = fallback
+	}
+	if i := strings.Index(d, ". "); i > 0 {
+		d = d[:i+1]
+	}
+	runes := []rune(d)
+	if len(runes) > MaxDescriptionLength {
+		d = strings.TrimRightFunc(string(runes[:MaxDescriptionLength-1]), unicode.IsSpace) + "…"
+	}
+	return d
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
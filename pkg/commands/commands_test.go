@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFromTool(t *testing.T) {
+	cmd := FromTool("web_search", "Search the web for current information. Returns titles and URLs.", map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"count": map[string]interface{}{"type": "integer", "description": "Number of results"},
+			"query": map[string]interface{}{"type": "string", "description": "Search query"},
+			"sites": map[string]interface{}{"type": "array"},
+		},
+		"required": []string{"query"},
+	})
+
+	if cmd.Name != "web_search" || cmd.Kind != KindTool || cmd.Target != "web_search" {
+		t.Errorf("cmd = %+v", cmd)
+	}
+	if cmd.Description != "Search the web for current information." {
+		t.Errorf("Description = %q", cmd.Description)
+	}
+	want := []Option{
+		{Name: "query", Description: "Search query", Type: TypeString, Required: true},
+		{Name: "count", Description: "Number of results", Type: TypeInteger},
+	}
+	if len(cmd.Options) != len(want) {
+		t.Fatalf("Options = %+v, want %+v", cmd.Options, want)
+	}
+	for i := range want {
+		if cmd.Options[i] != want[i] {
+			t.Errorf("Options[%d] = %+v, want %+v", i, cmd.Options[i], want[i])
+		}
+	}
+}
+
+func TestNormalizeAndDescribe(t *testing.T) {
+	if got := Normalize("GitHub-Issues.v2"); got != "github_issues_v2" {
+		t.Errorf("Normalize = %q", got)
+	}
+	if got := Normalize(strings.Repeat("a", 40)); len(got) != MaxNameLength {
+		t.Errorf("Normalize kept %d characters", len(got))
+	}
+	if got := Describe("", "weather"); got != "weather" {
+		t.Errorf("Describe fallback = %q", got)
+	}
+	if got := []rune(Describe(strings.Repeat("word ", 40), "x")); len(got) > MaxDescriptionLength {
+		t.Errorf("Describe kept %d characters", len(got))
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	cmds := Dedupe(append(Builtin(), FromTool("show", "A tool called show", nil), FromSkill("weather", "Forecasts")))
+	show, _ := Find(cmds, "show")
+	if show.Kind != KindBuiltin {
+		t.Errorf("show = %+v, want the builtin", show)
+	}
+	if _, ok := Find(cmds, "weather"); !ok {
+		t.Error("weather skill missing")
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 )
@@ -47,16 +49,37 @@ func (f *FlexibleStringSlice) UnmarshalJSON(data []byte) error {
 }
 
 type Config struct {
-	Agents    AgentsConfig    `json:"agents"`
-	Bindings  []AgentBinding  `json:"bindings,omitempty"`
-	Session   SessionConfig   `json:"session,omitempty"`
-	Channels  ChannelsConfig  `json:"channels"`
-	Providers ProvidersConfig `json:"providers,omitempty"`
-	ModelList []ModelConfig   `json:"model_list"` // New model-centric provider configuration
-	Gateway   GatewayConfig   `json:"gateway"`
-	Tools     ToolsConfig     `json:"tools"`
-	Heartbeat HeartbeatConfig `json:"heartbeat"`
-	Devices   DevicesConfig   `json:"devices"`
+	Agents      AgentsConfig      `json:"agents"`
+	Bindings    []AgentBinding    `json:"bindings,omitempty"`
+	Session     SessionConfig     `json:"session,omitempty"`
+	Tenants     TenantsConfig     `json:"tenants"`
+	Channels    ChannelsConfig    `json:"channels"`
+	Providers   ProvidersConfig   `json:"providers,omitempty"`
+	ModelList   []ModelConfig     `json:"model_list"` // New model-centric provider configuration
+	Embeddings  EmbeddingsConfig  `json:"embeddings"`
+	Gateway     GatewayConfig     `json:"gateway"`
+	Tools       ToolsConfig       `json:"tools"`
+	Heartbeat   HeartbeatConfig   `json:"heartbeat"`
+	Devices     DevicesConfig     `json:"devices"`
+	Retention   RetentionConfig   `json:"retention"`
+	Maintenance MaintenanceConfig `json:"maintenance"`
+	Canary      CanaryConfig      `json:"canary"`
+	Audit       AuditConfig       `json:"audit"`
+	Feedback    FeedbackConfig    `json:"feedback"`
+	SelfReview  SelfReviewConfig  `json:"self_review"`
+	WireLog     WireLogConfig     `json:"wire_log"`
+	HealthCheck HealthCheckConfig `json:"health_check"`
+	Timeouts    TimeoutsConfig    `json:"timeouts"`
+	Voice       VoiceConfig       `json:"voice"`
+	Video       VideoConfig       `json:"video"`
+	Vision      VisionConfig      `json:"vision"`
+	Proactive   ProactiveConfig   `json:"proactive"`
+	Starters    StartersConfig    `json:"starters"`
+	Updates     UpdatesConfig     `json:"updates"`
+	Log         LogConfig         `json:"log"`
+	Admin       AdminConfig       `json:"admin"`
+	LowMemory   LowMemoryConfig   `json:"low_memory"`
+	KV          KVConfig          `json:"kv"`
 }
 
 // MarshalJSON implements custom JSON marshaling for Config
@@ -77,7 +100,7 @@ func (c Config) MarshalJSON() ([]byte, error) {
 	}
 
 	// Only include session if not empty
-	if c.Session.DMScope != "" || len(c.Session.IdentityLinks) > 0 {
+	if c.Session.DMScope != "" || len(c.Session.IdentityLinks) > 0 || c.Session.AccountLinking {
 		aux.Session = &c.Session
 	}
 
@@ -136,6 +159,7 @@ type AgentConfig struct {
 	Model     *AgentModelConfig `json:"model,omitempty"`
 	Skills    []string          `json:"skills,omitempty"`
 	Subagents *SubagentsConfig  `json:"subagents,omitempty"`
+	Profile   string            `json:"profile,omitempty"` // overrides defaults.profile
 }
 
 type SubagentsConfig struct {
@@ -161,22 +185,118 @@ type AgentBinding struct {
 	Match   BindingMatch `json:"match"`
 }
 
+// SessionConfig sets how DMs map to sessions. IdentityLinks names the
+// accounts ("channel:id") of one person; with AccountLinking users can link
+// their own accounts with !link. Linked accounts share a DM session on
+// every channel with DMScope "per-peer"; with "main" all DMs share one.
 type SessionConfig struct {
-	DMScope       string              `json:"dm_scope,omitempty"`
-	IdentityLinks map[string][]string `json:"identity_links,omitempty"`
+	DMScope        string              `json:"dm_scope,omitempty"`
+	IdentityLinks  map[string][]string `json:"identity_links,omitempty"`
+	AccountLinking bool                `json:"account_linking,omitempty"`
+}
+
+// TenantsConfig gives each owner in List, e.g. each member of a family,
+// an agent of their own in one running process. A tenant's messages go to
+// its agent whatever the bindings say, and its memory and sessions live in
+// its own workspace, which no other tenant's agent can reach.
+type TenantsConfig struct {
+	Enabled bool           `json:"enabled" env:"PICOCLAW_TENANTS_ENABLED"`
+	List    []TenantConfig `json:"list,omitempty"`
+}
+
+// TenantConfig is one owner. Members are the accounts that belong to the
+// tenant, as "channel:sender_id" with the platform's user ID.
+// Model, MaxTokens, Temperature and MaxToolIterations override the agent
+// defaults. DailyTokens and DailyRequests cap a day's use (0 is no limit).
+// APIKeys maps model_name entries of model_list to the tenant's own keys,
+// so its usage is billed to it.
+type TenantConfig struct {
+	ID                string            `json:"id"`
+	Name              string            `json:"name,omitempty"`
+	Members           []string          `json:"members"`
+	Workspace         string            `json:"workspace,omitempty"`
+	Model             *AgentModelConfig `json:"model,omitempty"`
+	MaxTokens         int               `json:"max_tokens,omitempty"`
+	Temperature       *float64          `json:"temperature,omitempty"`
+	MaxToolIterations int               `json:"max_tool_iterations,omitempty"`
+	DailyTokens       int64             `json:"daily_tokens,omitempty"`
+	DailyRequests     int64             `json:"daily_requests,omitempty"`
+	APIKeys           map[string]string `json:"api_keys,omitempty"`
 }
 
 type AgentDefaults struct {
-	Workspace           string   `json:"workspace" env:"PICOCLAW_AGENTS_DEFAULTS_WORKSPACE"`
-	RestrictToWorkspace bool     `json:"restrict_to_workspace" env:"PICOCLAW_AGENTS_DEFAULTS_RESTRICT_TO_WORKSPACE"`
-	Provider            string   `json:"provider" env:"PICOCLAW_AGENTS_DEFAULTS_PROVIDER"`
-	Model               string   `json:"model" env:"PICOCLAW_AGENTS_DEFAULTS_MODEL"`
-	ModelFallbacks      []string `json:"model_fallbacks,omitempty"`
-	ImageModel          string   `json:"image_model,omitempty" env:"PICOCLAW_AGENTS_DEFAULTS_IMAGE_MODEL"`
-	ImageModelFallbacks []string `json:"image_model_fallbacks,omitempty"`
-	MaxTokens           int      `json:"max_tokens" env:"PICOCLAW_AGENTS_DEFAULTS_MAX_TOKENS"`
-	Temperature         *float64 `json:"temperature,omitempty" env:"PICOCLAW_AGENTS_DEFAULTS_TEMPERATURE"`
-	MaxToolIterations   int      `json:"max_tool_iterations" env:"PICOCLAW_AGENTS_DEFAULTS_MAX_TOOL_ITERATIONS"`
+	Workspace           string              `json:"workspace" env:"PICOCLAW_AGENTS_DEFAULTS_WORKSPACE"`
+	RestrictToWorkspace bool                `json:"restrict_to_workspace" env:"PICOCLAW_AGENTS_DEFAULTS_RESTRICT_TO_WORKSPACE"`
+	Provider            string              `json:"provider" env:"PICOCLAW_AGENTS_DEFAULTS_PROVIDER"`
+	Model               string              `json:"model" env:"PICOCLAW_AGENTS_DEFAULTS_MODEL"`
+	ModelFallbacks      []string            `json:"model_fallbacks,omitempty"`
+	ImageModel          string              `json:"image_model,omitempty" env:"PICOCLAW_AGENTS_DEFAULTS_IMAGE_MODEL"`
+	ImageModelFallbacks []string            `json:"image_model_fallbacks,omitempty"`
+	MaxTokens           int                 `json:"max_tokens" env:"PICOCLAW_AGENTS_DEFAULTS_MAX_TOKENS"`
+	Temperature         *float64            `json:"temperature,omitempty" env:"PICOCLAW_AGENTS_DEFAULTS_TEMPERATURE"`
+	MaxToolIterations   int                 `json:"max_tool_iterations" env:"PICOCLAW_AGENTS_DEFAULTS_MAX_TOOL_ITERATIONS"`
+	ToolHistoryMaxChars int                 `json:"tool_history_max_chars" env:"PICOCLAW_AGENTS_DEFAULTS_TOOL_HISTORY_MAX_CHARS"`
+	TopicCheckpoints    bool                `json:"topic_checkpoints" env:"PICOCLAW_AGENTS_DEFAULTS_TOPIC_CHECKPOINTS"`
+	BootstrapMaxChars   int                 `json:"bootstrap_max_chars" env:"PICOCLAW_AGENTS_DEFAULTS_BOOTSTRAP_MAX_CHARS"`
+	GuardrailsMinChars  int                 `json:"guardrails_min_chars" env:"PICOCLAW_AGENTS_DEFAULTS_GUARDRAILS_MIN_CHARS"`
+	QuoteGuard          string              `json:"quote_guard" env:"PICOCLAW_AGENTS_DEFAULTS_QUOTE_GUARD"`
+	LoopDetection       LoopDetectionConfig `json:"loop_detection"`
+	TurnLimits          TurnLimitsConfig    `json:"turn_limits"`
+	Routing             RoutingConfig       `json:"routing"`
+	// Stream publishes replies while they are generated, for channels that
+	// show progress. It has no effect while QuoteGuard is on, since quotes
+	// are only checked once the reply is complete.
+	Stream bool `json:"stream" env:"PICOCLAW_AGENTS_DEFAULTS_STREAM"`
+	// MaxSubagents caps the subagents running at once; 0 is no limit.
+	MaxSubagents int `json:"max_subagents" env:"PICOCLAW_AGENTS_DEFAULTS_MAX_SUBAGENTS"`
+	// MaxParallelTools caps the tool calls of one reply that run at once.
+	// Only tools declaring themselves concurrent run together; 0 or 1 runs
+	// every call in turn.
+	MaxParallelTools int `json:"max_parallel_tools" env:"PICOCLAW_AGENTS_DEFAULTS_MAX_PARALLEL_TOOLS"`
+	// Profile is AgentProfileFull, or AgentProfileMinimal for small local
+	// models.
+	Profile string `json:"profile" env:"PICOCLAW_AGENTS_DEFAULTS_PROFILE"`
+}
+
+// Agent profiles. The minimal one cuts the system prompt to a few hundred
+// tokens, leaves out the skills and all but a few tools, and describes
+// the tools in the prompt rather than through the API, so models of a few
+// billion parameters can follow it.
+const (
+	AgentProfileFull    = "full"
+	AgentProfileMinimal = "minimal"
+)
+
+// TurnLimitsConfig caps a single turn. Once a limit is reached, the agent
+// makes one last call without tools to answer with what it has so far.
+// Zero means no limit. Cost is estimated from token usage at InputPrice
+// and OutputPrice, in USD per million tokens.
+type TurnLimitsConfig struct {
+	MaxSeconds  int     `json:"max_seconds" env:"PICOCLAW_AGENTS_DEFAULTS_TURN_LIMITS_MAX_SECONDS"`
+	MaxCalls    int     `json:"max_calls" env:"PICOCLAW_AGENTS_DEFAULTS_TURN_LIMITS_MAX_CALLS"`
+	MaxCostUSD  float64 `json:"max_cost_usd" env:"PICOCLAW_AGENTS_DEFAULTS_TURN_LIMITS_MAX_COST_USD"`
+	InputPrice  float64 `json:"input_price" env:"PICOCLAW_AGENTS_DEFAULTS_TURN_LIMITS_INPUT_PRICE"`
+	OutputPrice float64 `json:"output_price" env:"PICOCLAW_AGENTS_DEFAULTS_TURN_LIMITS_OUTPUT_PRICE"`
+}
+
+// RoutingConfig sends simple turns to CheapModel: a message of at most
+// MaxChars without attachments, in a conversation of at most
+// MaxContextTokens (not counting the system prompt). When the cheap model
+// wants to call tools, the turn is escalated to the agent's model.
+type RoutingConfig struct {
+	Enabled          bool   `json:"enabled" env:"PICOCLAW_AGENTS_DEFAULTS_ROUTING_ENABLED"`
+	CheapModel       string `json:"cheap_model" env:"PICOCLAW_AGENTS_DEFAULTS_ROUTING_CHEAP_MODEL"`
+	MaxChars         int    `json:"max_chars" env:"PICOCLAW_AGENTS_DEFAULTS_ROUTING_MAX_CHARS"`
+	MaxContextTokens int    `json:"max_context_tokens" env:"PICOCLAW_AGENTS_DEFAULTS_ROUTING_MAX_CONTEXT_TOKENS"`
+}
+
+// LoopDetectionConfig ends a turn early when the agent goes in circles:
+// the same tool call with identical arguments MaxRepeats times in a row,
+// or two calls alternating MaxAlternations times.
+type LoopDetectionConfig struct {
+	Enabled         bool `json:"enabled" env:"PICOCLAW_AGENTS_DEFAULTS_LOOP_DETECTION_ENABLED"`
+	MaxRepeats      int  `json:"max_repeats" env:"PICOCLAW_AGENTS_DEFAULTS_LOOP_DETECTION_MAX_REPEATS"`
+	MaxAlternations int  `json:"max_alternations" env:"PICOCLAW_AGENTS_DEFAULTS_LOOP_DETECTION_MAX_ALTERNATIONS"`
 }
 
 type ChannelsConfig struct {
@@ -184,14 +304,96 @@ type ChannelsConfig struct {
 	Telegram TelegramConfig `json:"telegram"`
 	Feishu   FeishuConfig   `json:"feishu"`
 	Discord  DiscordConfig  `json:"discord"`
-	MaixCam  MaixCamConfig  `json:"maixcam"`
-	QQ       QQConfig       `json:"qq"`
-	DingTalk DingTalkConfig `json:"dingtalk"`
-	Slack    SlackConfig    `json:"slack"`
-	LINE     LINEConfig     `json:"line"`
-	OneBot   OneBotConfig   `json:"onebot"`
-	WeCom    WeComConfig    `json:"wecom"`
-	WeComApp WeComAppConfig `json:"wecom_app"`
+	// DiscordBots are more Discord bot accounts run next to Discord, each
+	// with its own token, allowlist and settings. A bot is the channel
+	// "discord_<name>", which bindings can route to its own agent.
+	DiscordBots []DiscordConfig `json:"discord_bots,omitempty"`
+	MaixCam     MaixCamConfig   `json:"maixcam"`
+	QQ          QQConfig        `json:"qq"`
+	DingTalk    DingTalkConfig  `json:"dingtalk"`
+	Slack       SlackConfig     `json:"slack"`
+	LINE        LINEConfig      `json:"line"`
+	OneBot      OneBotConfig    `json:"onebot"`
+	WeCom       WeComConfig     `json:"wecom"`
+	WeComApp    WeComAppConfig  `json:"wecom_app"`
+
+	WhatsAppCloud WhatsAppCloudConfig `json:"whatsapp_cloud"`
+	Signal        SignalConfig        `json:"signal"`
+	Email         EmailConfig         `json:"email"`
+	Mastodon      MastodonConfig      `json:"mastodon"`
+	Webhook       WebhookConfig       `json:"webhook"`
+	WebSocket     WebSocketConfig     `json:"websocket"`
+	API           APIConfig           `json:"api"`
+	MQTT          MQTTConfig          `json:"mqtt"`
+	Ntfy          NtfyConfig          `json:"ntfy"`
+	Pushover      PushoverConfig      `json:"pushover"`
+	Gotify        GotifyConfig        `json:"gotify"`
+
+	RateLimit RateLimitConfig `json:"rate_limit"`
+	Retry     RetryConfig     `json:"retry"`
+	Throttle  ThrottleConfig  `json:"throttle"`
+	Bridges   []BridgeConfig  `json:"bridges,omitempty"`
+}
+
+// BridgeConfig describes a bridge bot, such as matterbridge, that relays
+// other platforms' messages into a chat under its own account. Messages
+// from SenderIDs on Channel (every channel when empty) are matched against
+// Patterns, regular expressions with a "user" group for the relayed author
+// and optional "network" and "text" groups. The author then becomes the
+// sender, as "<name>:<network>:<user>" or "<name>:<user>", and text, when
+// captured, the message. Messages matching no pattern are dropped.
+type BridgeConfig struct {
+	Name      string              `json:"name"`
+	Channel   string              `json:"channel"`
+	SenderIDs FlexibleStringSlice `json:"sender_ids"`
+	Patterns  FlexibleStringSlice `json:"patterns"`
+}
+
+// RateLimitConfig limits how fast messages reach the agent, with a token
+// bucket per sender and one per chat: each allows a burst of messages,
+// then PerMinute a minute. A zero PerMinute turns that bucket off. Over
+// the limit, messages are dropped and the sender gets a cooldown reply.
+type RateLimitConfig struct {
+	Enabled       bool `json:"enabled" env:"PICOCLAW_CHANNELS_RATE_LIMIT_ENABLED"`
+	UserPerMinute int  `json:"user_per_minute" env:"PICOCLAW_CHANNELS_RATE_LIMIT_USER_PER_MINUTE"`
+	UserBurst     int  `json:"user_burst" env:"PICOCLAW_CHANNELS_RATE_LIMIT_USER_BURST"`
+	ChatPerMinute int  `json:"chat_per_minute" env:"PICOCLAW_CHANNELS_RATE_LIMIT_CHAT_PER_MINUTE"`
+	ChatBurst     int  `json:"chat_burst" env:"PICOCLAW_CHANNELS_RATE_LIMIT_CHAT_BURST"`
+}
+
+// RetryConfig queues outbound messages per channel and retries failed
+// sends, waiting InitialBackoff seconds and doubling up to MaxBackoff.
+// A message is dropped after MaxAttempts tries. Messages still queued at
+// shutdown are saved to the workspace and sent on the next start.
+type RetryConfig struct {
+	Enabled        bool `json:"enabled" env:"PICOCLAW_CHANNELS_RETRY_ENABLED"`
+	MaxAttempts    int  `json:"max_attempts" env:"PICOCLAW_CHANNELS_RETRY_MAX_ATTEMPTS"`
+	InitialBackoff int  `json:"initial_backoff" env:"PICOCLAW_CHANNELS_RETRY_INITIAL_BACKOFF"`
+	MaxBackoff     int  `json:"max_backoff" env:"PICOCLAW_CHANNELS_RETRY_MAX_BACKOFF"`
+}
+
+// ThrottleConfig paces outbound messages so bulk sends, such as a digest
+// to many chats, stay under the platforms' limits. Each channel gets a
+// token bucket of Burst messages refilling at PerMinute, and each chat one
+// of ChatBurst at ChatPerMinute; Channels overrides PerMinute by channel
+// name. A zero PerMinute turns that bucket off. Messages over the limit
+// wait in the channel's queue, as do all of its messages while the
+// platform has answered with a rate limit and asked to retry later.
+type ThrottleConfig struct {
+	Enabled       bool           `json:"enabled" env:"PICOCLAW_CHANNELS_THROTTLE_ENABLED"`
+	PerMinute     int            `json:"per_minute" env:"PICOCLAW_CHANNELS_THROTTLE_PER_MINUTE"`
+	Burst         int            `json:"burst" env:"PICOCLAW_CHANNELS_THROTTLE_BURST"`
+	ChatPerMinute int            `json:"chat_per_minute" env:"PICOCLAW_CHANNELS_THROTTLE_CHAT_PER_MINUTE"`
+	ChatBurst     int            `json:"chat_burst" env:"PICOCLAW_CHANNELS_THROTTLE_CHAT_BURST"`
+	Channels      map[string]int `json:"channels,omitempty"`
+}
+
+// ChannelPerMinute returns the messages a minute allowed on channel.
+func (c ThrottleConfig) ChannelPerMinute(channel string) int {
+	if n, ok := c.Channels[channel]; ok {
+		return n
+	}
+	return c.PerMinute
 }
 
 type WhatsAppConfig struct {
@@ -200,6 +402,150 @@ type WhatsAppConfig struct {
 	AllowFrom FlexibleStringSlice `json:"allow_from" env:"PICOCLAW_CHANNELS_WHATSAPP_ALLOW_FROM"`
 }
 
+// WhatsAppCloudConfig receives WhatsApp Cloud API webhooks at WebhookPath
+// on the gateway's server. AppSecret is required: it signs every webhook.
+type WhatsAppCloudConfig struct {
+	Enabled       bool                `json:"enabled" env:"PICOCLAW_CHANNELS_WHATSAPP_CLOUD_ENABLED"`
+	PhoneNumberID string              `json:"phone_number_id" env:"PICOCLAW_CHANNELS_WHATSAPP_CLOUD_PHONE_NUMBER_ID"`
+	AccessToken   string              `json:"access_token" env:"PICOCLAW_CHANNELS_WHATSAPP_CLOUD_ACCESS_TOKEN"`
+	AppSecret     string              `json:"app_secret" env:"PICOCLAW_CHANNELS_WHATSAPP_CLOUD_APP_SECRET"`
+	VerifyToken   string              `json:"verify_token" env:"PICOCLAW_CHANNELS_WHATSAPP_CLOUD_VERIFY_TOKEN"`
+	APIVersion    string              `json:"api_version" env:"PICOCLAW_CHANNELS_WHATSAPP_CLOUD_API_VERSION"`
+	WebhookPath   string              `json:"webhook_path" env:"PICOCLAW_CHANNELS_WHATSAPP_CLOUD_WEBHOOK_PATH"`
+	AllowFrom     FlexibleStringSlice `json:"allow_from" env:"PICOCLAW_CHANNELS_WHATSAPP_CLOUD_ALLOW_FROM"`
+}
+
+type SignalConfig struct {
+	Enabled        bool                `json:"enabled" env:"PICOCLAW_CHANNELS_SIGNAL_ENABLED"`
+	Account        string              `json:"account" env:"PICOCLAW_CHANNELS_SIGNAL_ACCOUNT"`
+	RPCURL         string              `json:"rpc_url" env:"PICOCLAW_CHANNELS_SIGNAL_RPC_URL"`
+	AttachmentsDir string              `json:"attachments_dir" env:"PICOCLAW_CHANNELS_SIGNAL_ATTACHMENTS_DIR"`
+	AllowFrom      FlexibleStringSlice `json:"allow_from" env:"PICOCLAW_CHANNELS_SIGNAL_ALLOW_FROM"`
+}
+
+// EmailConfig configures the email channel. New mail is polled over IMAP
+// (implicit TLS unless imap_port is 143) and replies are sent over SMTP
+// (STARTTLS on 587, implicit TLS on 465).
+type EmailConfig struct {
+	Enabled      bool                `json:"enabled" env:"PICOCLAW_CHANNELS_EMAIL_ENABLED"`
+	IMAPHost     string              `json:"imap_host" env:"PICOCLAW_CHANNELS_EMAIL_IMAP_HOST"`
+	IMAPPort     int                 `json:"imap_port" env:"PICOCLAW_CHANNELS_EMAIL_IMAP_PORT"`
+	SMTPHost     string              `json:"smtp_host" env:"PICOCLAW_CHANNELS_EMAIL_SMTP_HOST"`
+	SMTPPort     int                 `json:"smtp_port" env:"PICOCLAW_CHANNELS_EMAIL_SMTP_PORT"`
+	Username     string              `json:"username" env:"PICOCLAW_CHANNELS_EMAIL_USERNAME"`
+	Password     string              `json:"password" env:"PICOCLAW_CHANNELS_EMAIL_PASSWORD"`
+	From         string              `json:"from" env:"PICOCLAW_CHANNELS_EMAIL_FROM"`
+	Mailbox      string              `json:"mailbox" env:"PICOCLAW_CHANNELS_EMAIL_MAILBOX"`
+	PollInterval int                 `json:"poll_interval" env:"PICOCLAW_CHANNELS_EMAIL_POLL_INTERVAL"` // seconds
+	AllowFrom    FlexibleStringSlice `json:"allow_from" env:"PICOCLAW_CHANNELS_EMAIL_ALLOW_FROM"`
+	// AuthServID is the name the mail server uses in its
+	// Authentication-Results headers. Empty trusts the topmost header.
+	AuthServID string `json:"auth_serv_id" env:"PICOCLAW_CHANNELS_EMAIL_AUTH_SERV_ID"`
+}
+
+// MastodonConfig configures the Mastodon channel. The access token needs
+// the read:accounts, read:notifications, read:statuses and write:statuses
+// scopes. MaxChars is the instance's post length limit.
+type MastodonConfig struct {
+	Enabled     bool                `json:"enabled" env:"PICOCLAW_CHANNELS_MASTODON_ENABLED"`
+	Instance    string              `json:"instance" env:"PICOCLAW_CHANNELS_MASTODON_INSTANCE"`
+	AccessToken string              `json:"access_token" env:"PICOCLAW_CHANNELS_MASTODON_ACCESS_TOKEN"`
+	MaxChars    int                 `json:"max_chars" env:"PICOCLAW_CHANNELS_MASTODON_MAX_CHARS"`
+	AllowFrom   FlexibleStringSlice `json:"allow_from" env:"PICOCLAW_CHANNELS_MASTODON_ALLOW_FROM"`
+}
+
+// WebhookConfig turns POSTs to /hooks/<name> on the gateway into messages
+// for the agent. Each hook's replies are POSTed to its CallbackURL. With a
+// Secret, requests must carry it as a bearer token or an HMAC-SHA256
+// signature of the body, and callbacks are signed the same way.
+type WebhookConfig struct {
+	Enabled   bool                `json:"enabled" env:"PICOCLAW_CHANNELS_WEBHOOK_ENABLED"`
+	Hooks     []WebhookHookConfig `json:"hooks"`
+	AllowFrom FlexibleStringSlice `json:"allow_from" env:"PICOCLAW_CHANNELS_WEBHOOK_ALLOW_FROM"`
+}
+
+// WebhookHookConfig is one hook. Its Secret is required, and messages to it
+// come from a sender named after the hook. MetadataKeys lists the keys of a
+// request's metadata that are passed on to the agent; others are dropped.
+type WebhookHookConfig struct {
+	Name         string   `json:"name"`
+	Secret       string   `json:"secret"`
+	CallbackURL  string   `json:"callback_url"`
+	MetadataKeys []string `json:"metadata_keys,omitempty"`
+}
+
+// WebSocketConfig serves a chat over WebSocket at /ws on the gateway.
+// Clients send a token as a bearer token or a token query parameter, and
+// the token decides who they are: Token is the user "web", and Users maps
+// further user names to tokens of their own. At least one is required.
+// AllowOrigins lists the browser origins that may connect; empty allows
+// only same-origin and non-browser clients.
+type WebSocketConfig struct {
+	Enabled      bool                `json:"enabled" env:"PICOCLAW_CHANNELS_WEBSOCKET_ENABLED"`
+	Token        string              `json:"token" env:"PICOCLAW_CHANNELS_WEBSOCKET_TOKEN"`
+	Users        map[string]string   `json:"users,omitempty"`
+	AllowOrigins FlexibleStringSlice `json:"allow_origins" env:"PICOCLAW_CHANNELS_WEBSOCKET_ALLOW_ORIGINS"`
+	AllowFrom    FlexibleStringSlice `json:"allow_from" env:"PICOCLAW_CHANNELS_WEBSOCKET_ALLOW_FROM"`
+}
+
+// APIConfig serves POST /api/chat on the gateway, which returns the
+// agent's reply to a message. Requests must send Token, which is required,
+// as a bearer token. Timeout is how many seconds a request waits for the reply
+// before it answers with a task ID to poll instead.
+type APIConfig struct {
+	Enabled   bool                `json:"enabled" env:"PICOCLAW_CHANNELS_API_ENABLED"`
+	Token     string              `json:"token" env:"PICOCLAW_CHANNELS_API_TOKEN"`
+	Timeout   int                 `json:"timeout" env:"PICOCLAW_CHANNELS_API_TIMEOUT"`
+	AllowFrom FlexibleStringSlice `json:"allow_from" env:"PICOCLAW_CHANNELS_API_ALLOW_FROM"`
+}
+
+// MQTTConfig connects to an MQTT broker, e.g. "tcp://localhost:1883" or
+// "ssl://broker:8883". Messages on InTopic (MQTT wildcards allowed) are
+// answered on "<OutTopic>/<id>", where id is the part of the topic the
+// wildcard matched.
+type MQTTConfig struct {
+	Enabled   bool                `json:"enabled" env:"PICOCLAW_CHANNELS_MQTT_ENABLED"`
+	Broker    string              `json:"broker" env:"PICOCLAW_CHANNELS_MQTT_BROKER"`
+	ClientID  string              `json:"client_id" env:"PICOCLAW_CHANNELS_MQTT_CLIENT_ID"`
+	Username  string              `json:"username" env:"PICOCLAW_CHANNELS_MQTT_USERNAME"`
+	Password  string              `json:"password" env:"PICOCLAW_CHANNELS_MQTT_PASSWORD"`
+	InTopic   string              `json:"in_topic" env:"PICOCLAW_CHANNELS_MQTT_IN_TOPIC"`
+	OutTopic  string              `json:"out_topic" env:"PICOCLAW_CHANNELS_MQTT_OUT_TOPIC"`
+	QoS       int                 `json:"qos" env:"PICOCLAW_CHANNELS_MQTT_QOS"`
+	AllowFrom FlexibleStringSlice `json:"allow_from" env:"PICOCLAW_CHANNELS_MQTT_ALLOW_FROM"`
+}
+
+// NtfyConfig sends push notifications through an ntfy server. Messages to
+// the channel go to Topic, or to the topic named by their chat ID. Alerts
+// lists the kinds of alerts ("error", "reminder") sent to any other chat
+// that are forwarded here too.
+type NtfyConfig struct {
+	Enabled bool                `json:"enabled" env:"PICOCLAW_CHANNELS_NTFY_ENABLED"`
+	Server  string              `json:"server" env:"PICOCLAW_CHANNELS_NTFY_SERVER"`
+	Topic   string              `json:"topic" env:"PICOCLAW_CHANNELS_NTFY_TOPIC"`
+	Token   string              `json:"token" env:"PICOCLAW_CHANNELS_NTFY_TOKEN"`
+	Alerts  FlexibleStringSlice `json:"alerts" env:"PICOCLAW_CHANNELS_NTFY_ALERTS"`
+}
+
+// PushoverConfig sends push notifications through Pushover to UserKey, or
+// to the user or group key named by a message's chat ID. Alerts is as for
+// NtfyConfig.
+type PushoverConfig struct {
+	Enabled  bool                `json:"enabled" env:"PICOCLAW_CHANNELS_PUSHOVER_ENABLED"`
+	AppToken string              `json:"app_token" env:"PICOCLAW_CHANNELS_PUSHOVER_APP_TOKEN"`
+	UserKey  string              `json:"user_key" env:"PICOCLAW_CHANNELS_PUSHOVER_USER_KEY"`
+	Alerts   FlexibleStringSlice `json:"alerts" env:"PICOCLAW_CHANNELS_PUSHOVER_ALERTS"`
+}
+
+// GotifyConfig sends push notifications to a Gotify server as the
+// application AppToken belongs to. Alerts is as for NtfyConfig.
+type GotifyConfig struct {
+	Enabled  bool                `json:"enabled" env:"PICOCLAW_CHANNELS_GOTIFY_ENABLED"`
+	Server   string              `json:"server" env:"PICOCLAW_CHANNELS_GOTIFY_SERVER"`
+	AppToken string              `json:"app_token" env:"PICOCLAW_CHANNELS_GOTIFY_APP_TOKEN"`
+	Alerts   FlexibleStringSlice `json:"alerts" env:"PICOCLAW_CHANNELS_GOTIFY_ALERTS"`
+}
+
 type TelegramConfig struct {
 	Enabled   bool                `json:"enabled" env:"PICOCLAW_CHANNELS_TELEGRAM_ENABLED"`
 	Token     string              `json:"token" env:"PICOCLAW_CHANNELS_TELEGRAM_TOKEN"`
@@ -217,10 +563,189 @@ type FeishuConfig struct {
 }
 
 type DiscordConfig struct {
+	// Name identifies a bot in DiscordBots; the main bot has none.
+	Name        string              `json:"name,omitempty"`
 	Enabled     bool                `json:"enabled" env:"PICOCLAW_CHANNELS_DISCORD_ENABLED"`
 	Token       string              `json:"token" env:"PICOCLAW_CHANNELS_DISCORD_TOKEN"`
 	AllowFrom   FlexibleStringSlice `json:"allow_from" env:"PICOCLAW_CHANNELS_DISCORD_ALLOW_FROM"`
 	MentionOnly bool                `json:"mention_only" env:"PICOCLAW_CHANNELS_DISCORD_MENTION_ONLY"`
+	// ThreadMode is "off", "auto" (move a conversation into a thread once
+	// a user has exchanged ThreadAfter messages with the bot in a channel)
+	// or "always" (reply to every guild message in a new thread).
+	ThreadMode  string                          `json:"thread_mode" env:"PICOCLAW_CHANNELS_DISCORD_THREAD_MODE"`
+	ThreadAfter int                             `json:"thread_after" env:"PICOCLAW_CHANNELS_DISCORD_THREAD_AFTER"`
+	Channels    map[string]DiscordChannelConfig `json:"channels,omitempty"`
+	// ReactionControls lets users react to replies with 🔁 (regenerate),
+	// 🗑️ (delete) or 📌 (save to memory).
+	ReactionControls bool `json:"reaction_controls" env:"PICOCLAW_CHANNELS_DISCORD_REACTION_CONTROLS"`
+	// MemoryEmoji lets server admins react to any message with this emoji
+	// to save it to the guild's shared memory ("" to turn it off).
+	MemoryEmoji string `json:"memory_emoji,omitempty" env:"PICOCLAW_CHANNELS_DISCORD_MEMORY_EMOJI"`
+	// Summarize adds the !summarize command and the "Summarize from here"
+	// message command; SummarizeMax caps the messages one summary covers.
+	Summarize    bool `json:"summarize" env:"PICOCLAW_CHANNELS_DISCORD_SUMMARIZE"`
+	SummarizeMax int  `json:"summarize_max" env:"PICOCLAW_CHANNELS_DISCORD_SUMMARIZE_MAX"`
+	// StreamReplies shows a reply while it is generated by editing a
+	// placeholder message, when the provider streams.
+	StreamReplies bool `json:"stream_replies" env:"PICOCLAW_CHANNELS_DISCORD_STREAM_REPLIES"`
+	// TypingTimeout is how long, in seconds, the typing indicator is kept
+	// up while waiting for a reply.
+	TypingTimeout int `json:"typing_timeout" env:"PICOCLAW_CHANNELS_DISCORD_TYPING_TIMEOUT"`
+	// Pairing sends users not in AllowFrom who DM the bot a code the owner
+	// can redeem to let them in.
+	Pairing bool `json:"pairing" env:"PICOCLAW_CHANNELS_DISCORD_PAIRING"`
+	// Persona is the bot's persona wherever Channels doesn't set one.
+	Persona string `json:"persona,omitempty" env:"PICOCLAW_CHANNELS_DISCORD_PERSONA"`
+	// Knowledge answers repeat questions from the servers' pinned
+	// messages and FAQ channels.
+	Knowledge DiscordKnowledgeConfig `json:"knowledge"`
+	// Digests DMs moderators a scheduled summary of their server's activity.
+	Digests []DiscordDigestConfig `json:"digests,omitempty"`
+}
+
+// DiscordDigestConfig is the moderator digest of one guild: at Hour (local
+// time) every day, or on Day every week, the messages posted since the
+// previous digest in Channels are summarized and DMed to each of
+// Moderators. Empty Channels covers every text channel the bot can read;
+// MaxMessages caps the messages read per channel (summarize_max if 0).
+type DiscordDigestConfig struct {
+	GuildID     string              `json:"guild_id"`
+	Channels    FlexibleStringSlice `json:"channels,omitempty"`
+	Moderators  FlexibleStringSlice `json:"moderators"`
+	Frequency   string              `json:"frequency,omitempty"` // "daily" (default) or "weekly"
+	Day         string              `json:"day,omitempty"`       // Weekday of a weekly digest, default sunday
+	Hour        int                 `json:"hour"`
+	MaxMessages int                 `json:"max_messages,omitempty"`
+}
+
+// DiscordKnowledgeConfig is the Q&A knowledge base mode. Pinned messages
+// and the messages of FAQChannels are indexed in
+// workspace/knowledge/<channel>.json; a question that matches them with at
+// least MinConfidence (0 to 1) is answered citing up to MaxSources of
+// them, even in mention_only mode. Below it the bot stays silent unless
+// it's mentioned.
+type DiscordKnowledgeConfig struct {
+	Enabled       bool                `json:"enabled" env:"PICOCLAW_CHANNELS_DISCORD_KNOWLEDGE_ENABLED"`
+	FAQChannels   FlexibleStringSlice `json:"faq_channels" env:"PICOCLAW_CHANNELS_DISCORD_KNOWLEDGE_FAQ_CHANNELS"`
+	MinConfidence float64             `json:"min_confidence" env:"PICOCLAW_CHANNELS_DISCORD_KNOWLEDGE_MIN_CONFIDENCE"`
+	MaxSources    int                 `json:"max_sources" env:"PICOCLAW_CHANNELS_DISCORD_KNOWLEDGE_MAX_SOURCES"`
+}
+
+// AllowFrom returns the allow_from list of the channel running under name,
+// or nil for channels without one.
+func (c *ChannelsConfig) AllowFrom(name string) *FlexibleStringSlice {
+	switch name {
+	case "whatsapp":
+		return &c.WhatsApp.AllowFrom
+	case "telegram":
+		return &c.Telegram.AllowFrom
+	case "feishu":
+		return &c.Feishu.AllowFrom
+	case "discord":
+		return &c.Discord.AllowFrom
+	case "maixcam":
+		return &c.MaixCam.AllowFrom
+	case "qq":
+		return &c.QQ.AllowFrom
+	case "dingtalk":
+		return &c.DingTalk.AllowFrom
+	case "slack":
+		return &c.Slack.AllowFrom
+	case "line":
+		return &c.LINE.AllowFrom
+	case "onebot":
+		return &c.OneBot.AllowFrom
+	case "wecom":
+		return &c.WeCom.AllowFrom
+	case "wecom_app":
+		return &c.WeComApp.AllowFrom
+	case "whatsapp_cloud":
+		return &c.WhatsAppCloud.AllowFrom
+	case "signal":
+		return &c.Signal.AllowFrom
+	case "email":
+		return &c.Email.AllowFrom
+	case "mastodon":
+		return &c.Mastodon.AllowFrom
+	case "webhook":
+		return &c.Webhook.AllowFrom
+	case "websocket":
+		return &c.WebSocket.AllowFrom
+	case "api":
+		return &c.API.AllowFrom
+	case "mqtt":
+		return &c.MQTT.AllowFrom
+	}
+	for i := range c.DiscordBots {
+		if c.DiscordBots[i].ChannelName() == name {
+			return &c.DiscordBots[i].AllowFrom
+		}
+	}
+	return nil
+}
+
+// ChannelName returns the name the bot runs under: "discord", or
+// "discord_<name>" for one of DiscordBots.
+func (c DiscordConfig) ChannelName() string {
+	if c.Name == "" {
+		return "discord"
+	}
+	return "discord_" + c.Name
+}
+
+// DiscordChannelConfig overrides Discord settings for one guild or channel,
+// keyed by guild or channel ID. A channel entry wins over its guild's.
+type DiscordChannelConfig struct {
+	// Mode "observe" makes the bot read along without ever replying: the
+	// messages are logged to workspace/observed and indexed for the
+	// knowledge base. "reply" turns a channel back on in an observed
+	// guild.
+	Mode       string `json:"mode,omitempty"`
+	ThreadMode string `json:"thread_mode,omitempty"`
+	// Persona picks the bootstrap files in workspace/personas/<name>
+	// over the workspace ones, e.g. a different IDENTITY.md and SOUL.md.
+	Persona string `json:"persona,omitempty"`
+}
+
+// PersonaFor resolves the persona for a channel in a guild, falling back
+// to the bot's, empty for the workspace's own.
+func (c DiscordConfig) PersonaFor(guildID, channelID string) string {
+	if ch, ok := c.Channels[channelID]; ok && ch.Persona != "" {
+		return ch.Persona
+	}
+	if g, ok := c.Channels[guildID]; ok && g.Persona != "" {
+		return g.Persona
+	}
+	return c.Persona
+}
+
+// Discord channel modes.
+const (
+	DiscordModeReply   = "reply"
+	DiscordModeObserve = "observe"
+)
+
+// ModeFor resolves the mode of a channel in a guild, "reply" unless one of
+// them is observed.
+func (c DiscordConfig) ModeFor(guildID, channelID string) string {
+	if ch, ok := c.Channels[channelID]; ok && ch.Mode != "" {
+		return ch.Mode
+	}
+	if g, ok := c.Channels[guildID]; ok && g.Mode != "" {
+		return g.Mode
+	}
+	return DiscordModeReply
+}
+
+// ThreadModeFor resolves the thread mode for a channel in a guild.
+func (c DiscordConfig) ThreadModeFor(guildID, channelID string) string {
+	if ch, ok := c.Channels[channelID]; ok && ch.ThreadMode != "" {
+		return ch.ThreadMode
+	}
+	if g, ok := c.Channels[guildID]; ok && g.ThreadMode != "" {
+		return g.ThreadMode
+	}
+	return c.ThreadMode
 }
 
 type MaixCamConfig struct {
@@ -245,10 +770,14 @@ type DingTalkConfig struct {
 }
 
 type SlackConfig struct {
-	Enabled   bool                `json:"enabled" env:"PICOCLAW_CHANNELS_SLACK_ENABLED"`
-	BotToken  string              `json:"bot_token" env:"PICOCLAW_CHANNELS_SLACK_BOT_TOKEN"`
-	AppToken  string              `json:"app_token" env:"PICOCLAW_CHANNELS_SLACK_APP_TOKEN"`
-	AllowFrom FlexibleStringSlice `json:"allow_from" env:"PICOCLAW_CHANNELS_SLACK_ALLOW_FROM"`
+	Enabled         bool                `json:"enabled" env:"PICOCLAW_CHANNELS_SLACK_ENABLED"`
+	BotToken        string              `json:"bot_token" env:"PICOCLAW_CHANNELS_SLACK_BOT_TOKEN"`
+	AppToken        string              `json:"app_token" env:"PICOCLAW_CHANNELS_SLACK_APP_TOKEN"`
+	AllowFrom       FlexibleStringSlice `json:"allow_from" env:"PICOCLAW_CHANNELS_SLACK_ALLOW_FROM"`
+	AllowWorkspaces FlexibleStringSlice `json:"allow_workspaces,omitempty" env:"PICOCLAW_CHANNELS_SLACK_ALLOW_WORKSPACES"` // team IDs; empty allows all
+	AllowChannels   FlexibleStringSlice `json:"allow_channels,omitempty" env:"PICOCLAW_CHANNELS_SLACK_ALLOW_CHANNELS"`     // channel IDs; empty allows all (DMs are always allowed)
+	MentionOnly     bool                `json:"mention_only" env:"PICOCLAW_CHANNELS_SLACK_MENTION_ONLY"`
+	ReplyInThread   bool                `json:"reply_in_thread" env:"PICOCLAW_CHANNELS_SLACK_REPLY_IN_THREAD"`
 }
 
 type LINEConfig struct {
@@ -301,6 +830,262 @@ type HeartbeatConfig struct {
 	Interval int  `json:"interval" env:"PICOCLAW_HEARTBEAT_INTERVAL"` // minutes, min 5
 }
 
+// RetentionConfig controls how long workspace data is kept.
+// Categories maps a data category (daily_notes, sessions, audit) to the
+// number of days to keep it; categories not listed use DefaultDays.
+// A value of 0 keeps data forever.
+type RetentionConfig struct {
+	Enabled       bool           `json:"enabled" env:"PICOCLAW_RETENTION_ENABLED"`
+	IntervalHours int            `json:"interval_hours" env:"PICOCLAW_RETENTION_INTERVAL_HOURS"`
+	DefaultDays   int            `json:"default_days" env:"PICOCLAW_RETENTION_DEFAULT_DAYS"`
+	Categories    map[string]int `json:"categories" env:"PICOCLAW_RETENTION_CATEGORIES"` // e.g. "sessions:30,audit:7"
+}
+
+// DaysFor returns the retention period in days for a category (0 = forever).
+func (c RetentionConfig) DaysFor(category string) int {
+	if days, ok := c.Categories[category]; ok {
+		return days
+	}
+	return c.DefaultDays
+}
+
+// MaintenanceConfig holds the reply sent while maintenance mode is on.
+type MaintenanceConfig struct {
+	Message string `json:"message" env:"PICOCLAW_MAINTENANCE_MESSAGE"`
+}
+
+// CanaryConfig routes a percentage of conversation turns to an alternate
+// model so it can be compared with the default model on real traffic.
+// Model is a model_name from model_list. Prices are USD per million tokens
+// and are only used to estimate cost in the comparison report.
+type CanaryConfig struct {
+	Enabled            bool    `json:"enabled" env:"PICOCLAW_CANARY_ENABLED"`
+	Model              string  `json:"model" env:"PICOCLAW_CANARY_MODEL"`
+	Percent            int     `json:"percent" env:"PICOCLAW_CANARY_PERCENT"`
+	ControlInputPrice  float64 `json:"control_input_price" env:"PICOCLAW_CANARY_CONTROL_INPUT_PRICE"`
+	ControlOutputPrice float64 `json:"control_output_price" env:"PICOCLAW_CANARY_CONTROL_OUTPUT_PRICE"`
+	CanaryInputPrice   float64 `json:"canary_input_price" env:"PICOCLAW_CANARY_CANARY_INPUT_PRICE"`
+	CanaryOutputPrice  float64 `json:"canary_output_price" env:"PICOCLAW_CANARY_CANARY_OUTPUT_PRICE"`
+}
+
+// AuditConfig controls the per-turn audit log in workspace/audit.
+type AuditConfig struct {
+	Enabled bool `json:"enabled" env:"PICOCLAW_AUDIT_ENABLED"`
+}
+
+// FeedbackConfig controls 👍/👎 and /feedback capture. Feedback is linked
+// to turns in the audit log, so it requires audit.enabled. With FollowUp
+// set, a 👎 is answered with "what was wrong?" and the same user's next
+// message is kept as the comment.
+type FeedbackConfig struct {
+	Enabled  bool `json:"enabled" env:"PICOCLAW_FEEDBACK_ENABLED"`
+	FollowUp bool `json:"follow_up" env:"PICOCLAW_FEEDBACK_FOLLOW_UP"`
+}
+
+// SelfReviewConfig schedules the nightly self-review, in which the agent
+// reads the last day of the audit log, writes a note into memory and
+// proposes prompt or skill edits for the owner to approve. Hour is the
+// local hour (0-23) the review runs at.
+type SelfReviewConfig struct {
+	Enabled bool `json:"enabled" env:"PICOCLAW_SELF_REVIEW_ENABLED"`
+	Hour    int  `json:"hour" env:"PICOCLAW_SELF_REVIEW_HOUR"`
+}
+
+// WireLogConfig enables a log of every provider request and response in
+// workspace/logs/provider-wire.jsonl (or Dir). Level is "full", "redacted"
+// (secrets, emails and phone numbers masked) or "metadata" (sizes, tool
+// names, usage and timing only). The file rotates at MaxSizeMB, keeping
+// MaxFiles old copies.
+type WireLogConfig struct {
+	Enabled   bool   `json:"enabled" env:"PICOCLAW_WIRE_LOG_ENABLED"`
+	Level     string `json:"level" env:"PICOCLAW_WIRE_LOG_LEVEL"`
+	Dir       string `json:"dir" env:"PICOCLAW_WIRE_LOG_DIR"`
+	MaxSizeMB int    `json:"max_size_mb" env:"PICOCLAW_WIRE_LOG_MAX_SIZE_MB"`
+	MaxFiles  int    `json:"max_files" env:"PICOCLAW_WIRE_LOG_MAX_FILES"`
+}
+
+// EmbeddingsConfig picks the provider that turns text into vectors, for
+// memory search and retrieval: "openai", "gemini" or "ollama" ("" is
+// off). An empty APIKey, APIBase or Proxy is taken from the provider's
+// entry in providers. BatchSize caps the texts per request, and
+// Dimensions asks models that support it for shorter vectors.
+type EmbeddingsConfig struct {
+	Provider   string `json:"provider" env:"PICOCLAW_EMBEDDINGS_PROVIDER"`
+	Model      string `json:"model" env:"PICOCLAW_EMBEDDINGS_MODEL"`
+	APIKey     string `json:"api_key" env:"PICOCLAW_EMBEDDINGS_API_KEY"`
+	APIBase    string `json:"api_base" env:"PICOCLAW_EMBEDDINGS_API_BASE"`
+	Proxy      string `json:"proxy,omitempty" env:"PICOCLAW_EMBEDDINGS_PROXY"`
+	BatchSize  int    `json:"batch_size" env:"PICOCLAW_EMBEDDINGS_BATCH_SIZE"`
+	Dimensions int    `json:"dimensions" env:"PICOCLAW_EMBEDDINGS_DIMENSIONS"`
+}
+
+// HealthCheckConfig has the gateway ask each configured provider for one
+// token at startup and every IntervalMinutes (0 for startup only), so
+// broken keys are reported before a user message fails on them.
+type HealthCheckConfig struct {
+	Enabled         bool `json:"enabled" env:"PICOCLAW_HEALTH_CHECK_ENABLED"`
+	IntervalMinutes int  `json:"interval_minutes" env:"PICOCLAW_HEALTH_CHECK_INTERVAL_MINUTES"`
+	TimeoutSeconds  int  `json:"timeout_seconds" env:"PICOCLAW_HEALTH_CHECK_TIMEOUT_SECONDS"`
+}
+
+// TimeoutsConfig holds timeouts in seconds; 0 disables a limit. Turn bounds
+// a whole agent turn. Provider and tool calls have their own limits but
+// derive their deadlines from the turn, so they never outlive it. Tools and
+// Channels override the tool and send timeouts by tool or channel name.
+type TimeoutsConfig struct {
+	Turn          int            `json:"turn" env:"PICOCLAW_TIMEOUTS_TURN"`
+	Provider      int            `json:"provider" env:"PICOCLAW_TIMEOUTS_PROVIDER"`
+	Tool          int            `json:"tool" env:"PICOCLAW_TIMEOUTS_TOOL"`
+	ChannelSend   int            `json:"channel_send" env:"PICOCLAW_TIMEOUTS_CHANNEL_SEND"`
+	Transcription int            `json:"transcription" env:"PICOCLAW_TIMEOUTS_TRANSCRIPTION"`
+	Video         int            `json:"video" env:"PICOCLAW_TIMEOUTS_VIDEO"`
+	Tools         map[string]int `json:"tools,omitempty"`
+	Channels      map[string]int `json:"channels,omitempty"`
+}
+
+// TurnTimeout returns the limit for a whole agent turn.
+func (t TimeoutsConfig) TurnTimeout() time.Duration {
+	return seconds(t.Turn)
+}
+
+// ProviderTimeout returns the limit for a single LLM call.
+func (t TimeoutsConfig) ProviderTimeout() time.Duration {
+	return seconds(t.Provider)
+}
+
+// ToolTimeout returns the limit for one call of the named tool.
+func (t TimeoutsConfig) ToolTimeout(name string) time.Duration {
+	if s, ok := t.Tools[name]; ok {
+		return seconds(s)
+	}
+	return seconds(t.Tool)
+}
+
+// SendTimeout returns the limit for delivering one message on a channel.
+func (t TimeoutsConfig) SendTimeout(channel string) time.Duration {
+	if s, ok := t.Channels[channel]; ok {
+		return seconds(s)
+	}
+	return seconds(t.ChannelSend)
+}
+
+// TranscriptionTimeout returns the limit for transcribing one voice message.
+func (t TimeoutsConfig) TranscriptionTimeout() time.Duration {
+	return seconds(t.Transcription)
+}
+
+// VideoTimeout returns the limit for extracting audio and keyframes from
+// one video attachment, including transcription.
+func (t TimeoutsConfig) VideoTimeout() time.Duration {
+	return seconds(t.Video)
+}
+
+func seconds(n int) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+// VoiceConfig controls how voice messages are prepared for transcription.
+// When ffmpeg is available, audio is normalized to 16 kHz mono FLAC and cut
+// at MaxDurationSeconds; without it, files are sent as-is. Files larger than
+// MaxSizeMB are rejected. 0 disables a limit.
+type VoiceConfig struct {
+	FFmpegPath         string `json:"ffmpeg_path" env:"PICOCLAW_VOICE_FFMPEG_PATH"`
+	MaxSizeMB          int    `json:"max_size_mb" env:"PICOCLAW_VOICE_MAX_SIZE_MB"`
+	MaxDurationSeconds int    `json:"max_duration_seconds" env:"PICOCLAW_VOICE_MAX_DURATION_SECONDS"`
+}
+
+// VideoConfig controls video attachments. When enabled and ffmpeg is
+// available, the audio track of downloaded videos is transcribed and, with
+// vision on, Keyframes evenly spaced frames are attached as images. Only
+// the first MaxDurationSeconds of a video are looked at.
+type VideoConfig struct {
+	Enabled            bool `json:"enabled" env:"PICOCLAW_VIDEO_ENABLED"`
+	Keyframes          int  `json:"keyframes" env:"PICOCLAW_VIDEO_KEYFRAMES"`
+	MaxDurationSeconds int  `json:"max_duration_seconds" env:"PICOCLAW_VIDEO_MAX_DURATION_SECONDS"`
+}
+
+// VisionConfig controls image attachments. When enabled, up to MaxImages
+// images per message (including video keyframes) are downloaded and sent to
+// the model as image parts; larger images than MaxSizeMB are skipped.
+// Models without vision support get only the text.
+type VisionConfig struct {
+	Enabled   bool `json:"enabled" env:"PICOCLAW_VISION_ENABLED"`
+	MaxImages int  `json:"max_images" env:"PICOCLAW_VISION_MAX_IMAGES"`
+	MaxSizeMB int  `json:"max_size_mb" env:"PICOCLAW_VISION_MAX_SIZE_MB"`
+}
+
+// ProactiveConfig caps the messages the agent sends on its own (heartbeat
+// briefings, follow-ups, habit nudges, journal prompts and digests) per
+// chat and day. Users choose a level in chat: off, low (LowPerDay), normal
+// (NormalPerDay) or high (no limit); DefaultLevel applies until they do.
+type ProactiveConfig struct {
+	DefaultLevel string `json:"default_level" env:"PICOCLAW_PROACTIVE_DEFAULT_LEVEL"`
+	LowPerDay    int    `json:"low_per_day" env:"PICOCLAW_PROACTIVE_LOW_PER_DAY"`
+	NormalPerDay int    `json:"normal_per_day" env:"PICOCLAW_PROACTIVE_NORMAL_PER_DAY"`
+}
+
+// UpdatesConfig controls the check for newer releases when the gateway
+// starts. Channel is "stable", "beta" (prereleases too) or "off"; GitHub
+// is asked at most once per CheckIntervalHours.
+type UpdatesConfig struct {
+	Channel            string `json:"channel" env:"PICOCLAW_UPDATES_CHANNEL"`
+	CheckIntervalHours int    `json:"check_interval_hours" env:"PICOCLAW_UPDATES_CHECK_INTERVAL_HOURS"`
+}
+
+// AdminConfig lists the users who may run admin commands such as !status
+// and !reload from chat, and approve pairing codes with !pair. An entry is
+// "channel:id", with the user ID the platform gives.
+type AdminConfig struct {
+	Users FlexibleStringSlice `json:"users" env:"PICOCLAW_ADMIN_USERS"`
+}
+
+// LowMemoryConfig trims PicoClaw for constrained hardware such as a
+// Raspberry Pi. Mode "auto" turns low-memory mode on when the memory
+// available (the container's limit or the RAM) is below ThresholdMB; "on"
+// and "off" force it.
+type LowMemoryConfig struct {
+	Mode        string `json:"mode" env:"PICOCLAW_LOW_MEMORY_MODE"`
+	ThresholdMB int    `json:"threshold_mb" env:"PICOCLAW_LOW_MEMORY_THRESHOLD_MB"`
+}
+
+// KVConfig selects the store for the gateway's runtime state: seen
+// message IDs, rate limit buckets, usage counters and scheduler markers.
+// Backend is "bolt" (a file, Path defaulting to workspace/state/kv.db),
+// "redis" (shared between gateways) or "memory" (lost on restart).
+type KVConfig struct {
+	Backend string        `json:"backend" env:"PICOCLAW_KV_BACKEND"`
+	Path    string        `json:"path" env:"PICOCLAW_KV_PATH"`
+	Redis   KVRedisConfig `json:"redis"`
+}
+
+type KVRedisConfig struct {
+	Addr     string `json:"addr" env:"PICOCLAW_KV_REDIS_ADDR"`
+	Username string `json:"username" env:"PICOCLAW_KV_REDIS_USERNAME"`
+	Password string `json:"password" env:"PICOCLAW_KV_REDIS_PASSWORD"`
+	DB       int    `json:"db" env:"PICOCLAW_KV_REDIS_DB"`
+	Prefix   string `json:"prefix" env:"PICOCLAW_KV_REDIS_PREFIX"`
+}
+
+// LogConfig sets how logs are written. Format "text" is the usual
+// human-readable lines; "json" writes one JSON object per line to stdout,
+// for log collectors in containers.
+type LogConfig struct {
+	Format string `json:"format" env:"PICOCLAW_LOG_FORMAT"`
+}
+
+// StartersConfig has the agent open the day with one question about
+// today's calendar events or open tasks, on the first heartbeat from Hour
+// (local time, 0-23). Calendars are iCalendar (.ics) URLs or file paths.
+// The question goes to the last active chat as a proactive message.
+type StartersConfig struct {
+	Enabled   bool                `json:"enabled" env:"PICOCLAW_STARTERS_ENABLED"`
+	Hour      int                 `json:"hour" env:"PICOCLAW_STARTERS_HOUR"`
+	Calendars FlexibleStringSlice `json:"calendars" env:"PICOCLAW_STARTERS_CALENDARS"`
+}
+
 type DevicesConfig struct {
 	Enabled    bool `json:"enabled" env:"PICOCLAW_DEVICES_ENABLED"`
 	MonitorUSB bool `json:"monitor_usb" env:"PICOCLAW_DEVICES_MONITOR_USB"`
@@ -324,6 +1109,7 @@ type ProvidersConfig struct {
 	GitHubCopilot ProviderConfig       `json:"github_copilot"`
 	Antigravity   ProviderConfig       `json:"antigravity"`
 	Qwen          ProviderConfig       `json:"qwen"`
+	Azure         AzureProviderConfig  `json:"azure"`
 }
 
 // IsEmpty checks if all provider configs are empty (no API keys or API bases set)
@@ -345,7 +1131,8 @@ func (p ProvidersConfig) IsEmpty() bool {
 		p.VolcEngine.APIKey == "" && p.VolcEngine.APIBase == "" &&
 		p.GitHubCopilot.APIKey == "" && p.GitHubCopilot.APIBase == "" &&
 		p.Antigravity.APIKey == "" && p.Antigravity.APIBase == "" &&
-		p.Qwen.APIKey == "" && p.Qwen.APIBase == ""
+		p.Qwen.APIKey == "" && p.Qwen.APIBase == "" &&
+		p.Azure.APIKey == "" && p.Azure.Endpoint == ""
 }
 
 // MarshalJSON implements custom JSON marshaling for ProvidersConfig
@@ -366,6 +1153,18 @@ type ProviderConfig struct {
 	ConnectMode string `json:"connect_mode,omitempty" env:"PICOCLAW_PROVIDERS_{{.Name}}_CONNECT_MODE"` //only for Github Copilot, `stdio` or `grpc`
 }
 
+// AzureProviderConfig is an Azure OpenAI resource. Azure routes requests
+// by deployment, so it takes the resource Endpoint
+// (https://<resource>.openai.azure.com) and the Deployment name instead of
+// an API base and a model. APIVersion defaults to a recent GA version.
+type AzureProviderConfig struct {
+	Endpoint   string `json:"endpoint" env:"PICOCLAW_PROVIDERS_AZURE_ENDPOINT"`
+	Deployment string `json:"deployment" env:"PICOCLAW_PROVIDERS_AZURE_DEPLOYMENT"`
+	APIKey     string `json:"api_key" env:"PICOCLAW_PROVIDERS_AZURE_API_KEY"`
+	APIVersion string `json:"api_version,omitempty" env:"PICOCLAW_PROVIDERS_AZURE_API_VERSION"`
+	Proxy      string `json:"proxy,omitempty" env:"PICOCLAW_PROVIDERS_AZURE_PROXY"`
+}
+
 type OpenAIProviderConfig struct {
 	ProviderConfig
 	WebSearch bool `json:"web_search" env:"PICOCLAW_PROVIDERS_OPENAI_WEB_SEARCH"`
@@ -374,7 +1173,7 @@ type OpenAIProviderConfig struct {
 // ModelConfig represents a model-centric provider configuration.
 // It allows adding new providers (especially OpenAI-compatible ones) via configuration only.
 // The model field uses protocol prefix format: [protocol/]model-identifier
-// Supported protocols: openai, anthropic, antigravity, claude-cli, codex-cli, github-copilot
+// Supported protocols: openai, anthropic, azure, antigravity, claude-cli, codex-cli, github-copilot
 // Default protocol is "openai" if no prefix is specified.
 type ModelConfig struct {
 	// Required fields
@@ -385,6 +1184,9 @@ type ModelConfig struct {
 	APIBase string `json:"api_base,omitempty"` // API endpoint URL
 	APIKey  string `json:"api_key"`            // API authentication key
 	Proxy   string `json:"proxy,omitempty"`    // HTTP proxy URL
+	// APIKeys are more keys for the same endpoint. Requests use one key
+	// until the provider refuses it (401, 402 or 429), then the next.
+	APIKeys []string `json:"api_keys,omitempty"`
 
 	// Special providers (CLI-based, OAuth, etc.)
 	AuthMethod  string `json:"auth_method,omitempty"`  // Authentication method: oauth, token
@@ -393,7 +1195,29 @@ type ModelConfig struct {
 
 	// Optional optimizations
 	RPM            int    `json:"rpm,omitempty"`              // Requests per minute limit
+	TPM            int    `json:"tpm,omitempty"`              // Tokens per minute limit
 	MaxTokensField string `json:"max_tokens_field,omitempty"` // Field name for max tokens (e.g., "max_completion_tokens")
+	APIVersion     string `json:"api_version,omitempty"`      // Azure OpenAI api-version query parameter
+
+	// KeyPool names the pool the entry's providers share their API keys
+	// in: "<model_name>#<n>" for the nth entry with that model name. It
+	// doesn't depend on the keys, so a reload can replace all of them.
+	KeyPool string `json:"-"`
+}
+
+// Keys returns APIKey and APIKeys without blanks and duplicates.
+func (c *ModelConfig) Keys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, key := range append([]string{c.APIKey}, c.APIKeys...) {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
 }
 
 // Validate checks if the ModelConfig has all required fields.
@@ -407,9 +1231,14 @@ func (c *ModelConfig) Validate() error {
 	return nil
 }
 
+// GatewayConfig is the gateway's HTTP server. With AdminToken set it also
+// serves /admin/allowlist, for managing who may use the bot, /admin/budget,
+// which shows how the prompt fills the context window, and the /metrics
+// of the provider rate limits.
 type GatewayConfig struct {
-	Host string `json:"host" env:"PICOCLAW_GATEWAY_HOST"`
-	Port int    `json:"port" env:"PICOCLAW_GATEWAY_PORT"`
+	Host       string `json:"host" env:"PICOCLAW_GATEWAY_HOST"`
+	Port       int    `json:"port" env:"PICOCLAW_GATEWAY_PORT"`
+	AdminToken string `json:"admin_token" env:"PICOCLAW_GATEWAY_ADMIN_TOKEN"`
 }
 
 type BraveConfig struct {
@@ -433,6 +1262,15 @@ type WebToolsConfig struct {
 	Brave      BraveConfig      `json:"brave"`
 	DuckDuckGo DuckDuckGoConfig `json:"duckduckgo"`
 	Perplexity PerplexityConfig `json:"perplexity"`
+	Citations  CitationsConfig  `json:"citations"`
+}
+
+// CitationsConfig numbers the pages web_search and web_fetch return, asks
+// the model to cite them, and lists up to MaxSources of them (0 for all)
+// under replies based on them.
+type CitationsConfig struct {
+	Enabled    bool `json:"enabled" env:"PICOCLAW_TOOLS_WEB_CITATIONS_ENABLED"`
+	MaxSources int  `json:"max_sources" env:"PICOCLAW_TOOLS_WEB_CITATIONS_MAX_SOURCES"`
 }
 
 type CronToolsConfig struct {
@@ -445,16 +1283,151 @@ type ExecConfig struct {
 }
 
 type ToolsConfig struct {
-	Web    WebToolsConfig    `json:"web"`
-	Cron   CronToolsConfig   `json:"cron"`
-	Exec   ExecConfig        `json:"exec"`
-	Skills SkillsToolsConfig `json:"skills"`
+	Web       WebToolsConfig    `json:"web"`
+	Cron      CronToolsConfig   `json:"cron"`
+	Exec      ExecConfig        `json:"exec"`
+	Skills    SkillsToolsConfig `json:"skills"`
+	Links     LinksConfig       `json:"links"`
+	Bookmarks BookmarksConfig   `json:"bookmarks"`
+	Habits    HabitsConfig      `json:"habits"`
+	Expenses  ExpensesConfig    `json:"expenses"`
+	Confirm   ConfirmConfig     `json:"confirm"`
+	Wellness  WellnessConfig    `json:"wellness"`
+	Journal   JournalConfig     `json:"journal"`
+	FollowUps FollowUpsConfig   `json:"follow_ups"`
+	Workflows WorkflowsConfig   `json:"workflows"`
+	Focus     FocusConfig       `json:"focus"`
+}
+
+// FocusConfig enables the focus tool for time-boxed focus sessions. While
+// one runs, proactive messages to its chat are held back until it ends;
+// then the chat gets a note and the session goes into the daily note. Minutes is
+// the length of a session when the user doesn't give one.
+type FocusConfig struct {
+	Enabled bool `json:"enabled" env:"PICOCLAW_TOOLS_FOCUS_ENABLED"`
+	Minutes int  `json:"minutes" env:"PICOCLAW_TOOLS_FOCUS_MINUTES"`
+}
+
+// WorkflowsConfig enables the run_workflow tool, which runs the multi-step
+// pipelines defined as YAML files in workspace/workflows.
+type WorkflowsConfig struct {
+	Enabled bool `json:"enabled" env:"PICOCLAW_TOOLS_WORKFLOWS_ENABLED"`
+}
+
+// FollowUpsConfig enables the ask_user tool, which parks a task that needs
+// an answer from the user in workspace/state/followups.json and resumes it
+// with the user's next message in that chat. Tasks left unanswered for
+// ExpireHours are dropped (0 keeps them).
+type FollowUpsConfig struct {
+	Enabled     bool `json:"enabled" env:"PICOCLAW_TOOLS_FOLLOW_UPS_ENABLED"`
+	ExpireHours int  `json:"expire_hours" env:"PICOCLAW_TOOLS_FOLLOW_UPS_EXPIRE_HOURS"`
+}
+
+// Expire returns ExpireHours as a duration.
+func (c FollowUpsConfig) Expire() time.Duration {
+	return time.Duration(c.ExpireHours) * time.Hour
+}
+
+// JournalConfig enables guided journaling. Users opt in through the
+// journal tool; each evening on the first heartbeat from PromptHour (local
+// time, 0-23) they get Questions, and the agent saves their answers to the
+// daily note under a Journal section. With WeeklySummary on, the agent
+// writes a reflection on the week's entries on SummaryDay (a weekday name)
+// from SummaryHour. Both need the heartbeat enabled.
+type JournalConfig struct {
+	Enabled       bool                `json:"enabled" env:"PICOCLAW_TOOLS_JOURNAL_ENABLED"`
+	PromptHour    int                 `json:"prompt_hour" env:"PICOCLAW_TOOLS_JOURNAL_PROMPT_HOUR"`
+	Questions     FlexibleStringSlice `json:"questions" env:"PICOCLAW_TOOLS_JOURNAL_QUESTIONS"`
+	WeeklySummary bool                `json:"weekly_summary" env:"PICOCLAW_TOOLS_JOURNAL_WEEKLY_SUMMARY"`
+	SummaryDay    string              `json:"summary_day" env:"PICOCLAW_TOOLS_JOURNAL_SUMMARY_DAY"`
+	SummaryHour   int                 `json:"summary_hour" env:"PICOCLAW_TOOLS_JOURNAL_SUMMARY_HOUR"`
+}
+
+// WellnessConfig enables the health_log and health_report tools, which
+// keep each user's sleep, weight and workouts in workspace/wellness.
+// Exports from Apple Health or Garmin are added with "picoclaw wellness
+// import".
+type WellnessConfig struct {
+	Enabled bool `json:"enabled" env:"PICOCLAW_TOOLS_WELLNESS_ENABLED"`
+}
+
+// ConfirmConfig lists tools that need the user's approval before each
+// call. On channels with buttons (Discord) the agent asks with
+// Confirm/Cancel buttons and runs the tool only if the user who sent the
+// message clicks Confirm within Timeout seconds; other channels run the
+// tool as before.
+type ConfirmConfig struct {
+	Tools   FlexibleStringSlice `json:"tools" env:"PICOCLAW_TOOLS_CONFIRM_TOOLS"`
+	Timeout int                 `json:"timeout" env:"PICOCLAW_TOOLS_CONFIRM_TIMEOUT"`
+}
+
+// Requires reports whether calls to the named tool need approval.
+func (c ConfirmConfig) Requires(name string) bool {
+	for _, t := range c.Tools {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// BookmarksConfig enables the bookmark_add and bookmark_list tools, which
+// keep a read-later list per chat in workspace/bookmarks. With Digest on,
+// each chat gets its unread bookmarks every week on DigestDay (a weekday
+// name) at DigestHour (local time, 0-23).
+type BookmarksConfig struct {
+	Enabled    bool   `json:"enabled" env:"PICOCLAW_TOOLS_BOOKMARKS_ENABLED"`
+	Digest     bool   `json:"digest" env:"PICOCLAW_TOOLS_BOOKMARKS_DIGEST"`
+	DigestDay  string `json:"digest_day" env:"PICOCLAW_TOOLS_BOOKMARKS_DIGEST_DAY"`
+	DigestHour int    `json:"digest_hour" env:"PICOCLAW_TOOLS_BOOKMARKS_DIGEST_HOUR"`
+}
+
+// LinksConfig controls fetching bare URLs found in incoming messages and
+// adding their content to the turn. YouTube links get the video
+// transcript, Twitter/X links the post text and GitHub links the README or
+// issue; Fallback fetches any other page as readable text.
+type LinksConfig struct {
+	Enabled     bool   `json:"enabled" env:"PICOCLAW_TOOLS_LINKS_ENABLED"`
+	MaxLinks    int    `json:"max_links" env:"PICOCLAW_TOOLS_LINKS_MAX_LINKS"`
+	MaxChars    int    `json:"max_chars" env:"PICOCLAW_TOOLS_LINKS_MAX_CHARS"`
+	YouTube     bool   `json:"youtube" env:"PICOCLAW_TOOLS_LINKS_YOUTUBE"`
+	Twitter     bool   `json:"twitter" env:"PICOCLAW_TOOLS_LINKS_TWITTER"`
+	GitHub      bool   `json:"github" env:"PICOCLAW_TOOLS_LINKS_GITHUB"`
+	GitHubToken string `json:"github_token" env:"PICOCLAW_TOOLS_LINKS_GITHUB_TOKEN"`
+	Fallback    bool   `json:"fallback" env:"PICOCLAW_TOOLS_LINKS_FALLBACK"`
+}
+
+// HabitsConfig enables the habit_track and habit_report tools, which keep
+// each user's habits in workspace/habits. With Reminders on, habits that
+// have a reminder time get a nudge on the first heartbeat after it when
+// they aren't done yet, so reminders need the heartbeat enabled.
+type HabitsConfig struct {
+	Enabled   bool `json:"enabled" env:"PICOCLAW_TOOLS_HABITS_ENABLED"`
+	Reminders bool `json:"reminders" env:"PICOCLAW_TOOLS_HABITS_REMINDERS"`
+}
+
+// ExpensesConfig enables the expense_log and expense_report tools, which
+// append each user's expenses to workspace/expenses/YYYY-MM.csv. Currency
+// is the ISO code used when a message names none.
+type ExpensesConfig struct {
+	Enabled  bool   `json:"enabled" env:"PICOCLAW_TOOLS_EXPENSES_ENABLED"`
+	Currency string `json:"currency" env:"PICOCLAW_TOOLS_EXPENSES_CURRENCY"`
 }
 
 type SkillsToolsConfig struct {
 	Registries            SkillsRegistriesConfig `json:"registries"`
 	MaxConcurrentSearches int                    `json:"max_concurrent_searches" env:"PICOCLAW_SKILLS_MAX_CONCURRENT_SEARCHES"`
 	SearchCache           SearchCacheConfig      `json:"search_cache"`
+	// NotifyUpdates tells the owner on the last active channel when a
+	// skill is installed, updated or removed, with a diff of its SKILL.md.
+	// Changes are always logged.
+	NotifyUpdates bool `json:"notify_updates" env:"PICOCLAW_SKILLS_NOTIFY_UPDATES"`
+	// ShadowWarnings logs a warning when a workspace or global skill hides
+	// a builtin skill of the same name.
+	ShadowWarnings bool `json:"shadow_warnings" env:"PICOCLAW_SKILLS_SHADOW_WARNINGS"`
+	// Protected names skills that workspace and global skills can't
+	// override; the builtin is always used.
+	Protected FlexibleStringSlice `json:"protected,omitempty" env:"PICOCLAW_SKILLS_PROTECTED"`
 }
 
 type SearchCacheConfig struct {
@@ -481,19 +1454,22 @@ type ClawHubRegistryConfig struct {
 func LoadConfig(path string) (*Config, error) {
 	cfg := DefaultConfig()
 
+	// Without a file the config comes from the environment alone
 	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return cfg, nil
-		}
+	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
+	if err == nil {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := json.Unmarshal(data, cfg); err != nil {
+	vars, err := environment()
+	if err != nil {
 		return nil, err
 	}
-
-	if err := env.Parse(cfg); err != nil {
+	if err := env.ParseWithOptions(cfg, env.Options{Environment: vars}); err != nil {
 		return nil, err
 	}
 
@@ -611,12 +1587,26 @@ func (c *Config) findMatches(modelName string) []ModelConfig {
 	var matches []ModelConfig
 	for i := range c.ModelList {
 		if c.ModelList[i].ModelName == modelName {
-			matches = append(matches, c.ModelList[i])
+			matches = append(matches, c.ModelEntry(i))
 		}
 	}
 	return matches
 }
 
+// ModelEntry returns a copy of the ith model_list entry with its KeyPool
+// set.
+func (c *Config) ModelEntry(i int) ModelConfig {
+	mc := c.ModelList[i]
+	n := 0
+	for j := 0; j < i; j++ {
+		if c.ModelList[j].ModelName == mc.ModelName {
+			n++
+		}
+	}
+	mc.KeyPool = fmt.Sprintf("%s#%d", mc.ModelName, n)
+	return mc
+}
+
 // HasProvidersConfig checks if any provider in the old providers config has configuration.
 func (c *Config) HasProvidersConfig() bool {
 	v := c.Providers
@@ -636,7 +1626,8 @@ func (c *Config) HasProvidersConfig() bool {
 		v.VolcEngine.APIKey != "" || v.VolcEngine.APIBase != "" ||
 		v.GitHubCopilot.APIKey != "" || v.GitHubCopilot.APIBase != "" ||
 		v.Antigravity.APIKey != "" || v.Antigravity.APIBase != "" ||
-		v.Qwen.APIKey != "" || v.Qwen.APIBase != ""
+		v.Qwen.APIKey != "" || v.Qwen.APIBase != "" ||
+		v.Azure.APIKey != "" || v.Azure.Endpoint != ""
 }
 
 // ValidateModelList validates all ModelConfig entries in the model_list.
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 )
 
 func TestAgentModelConfig_UnmarshalString(t *testing.T) {
@@ -392,3 +393,168 @@ func TestLoadConfig_OpenAIWebSearchCanBeDisabled(t *testing.T) {
 		t.Fatal("OpenAI codex web search should be false when disabled in config file")
 	}
 }
+
+func TestLoadConfig_EnvOnly(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "brave_key")
+	if err := os.WriteFile(secret, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	t.Setenv("PICOCLAW_HOME", dir)
+	t.Setenv("PICOCLAW_GATEWAY_PORT", "9000")
+	t.Setenv("PICOCLAW_TOOLS_WEB_BRAVE_API_KEY_FILE", secret)
+
+	cfg, err := LoadConfig(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if cfg.Gateway.Port != 9000 {
+		t.Errorf("Gateway.Port = %d, want 9000", cfg.Gateway.Port)
+	}
+	if cfg.Tools.Web.Brave.APIKey != "from-file" {
+		t.Errorf("Brave.APIKey = %q, want the secret file's contents", cfg.Tools.Web.Brave.APIKey)
+	}
+	if got, want := cfg.WorkspacePath(), filepath.Join(dir, "workspace"); got != want {
+		t.Errorf("WorkspacePath() = %q, want %q", got, want)
+	}
+
+	// A variable set directly wins over its _FILE
+	t.Setenv("PICOCLAW_TOOLS_WEB_BRAVE_API_KEY", "direct")
+	cfg, err = LoadConfig(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if cfg.Tools.Web.Brave.APIKey != "direct" {
+		t.Errorf("Brave.APIKey = %q, want direct", cfg.Tools.Web.Brave.APIKey)
+	}
+}
+
+func TestTimeoutsConfig_Overrides(t *testing.T) {
+	cfg := DefaultConfig().Timeouts
+	cfg.Tools = map[string]int{"exec": 300, "web_fetch": 0}
+	cfg.Channels = map[string]int{"discord": 10}
+
+	if got := cfg.ToolTimeout("exec"); got != 300*time.Second {
+		t.Errorf("ToolTimeout(exec) = %v, want 5m", got)
+	}
+	if got := cfg.ToolTimeout("web_fetch"); got != 0 {
+		t.Errorf("ToolTimeout(web_fetch) = %v, want no limit", got)
+	}
+	if got := cfg.ToolTimeout("read_file"); got != 120*time.Second {
+		t.Errorf("ToolTimeout(read_file) = %v, want the default", got)
+	}
+	if got := cfg.SendTimeout("discord"); got != 10*time.Second {
+		t.Errorf("SendTimeout(discord) = %v, want 10s", got)
+	}
+	if got := cfg.SendTimeout("telegram"); got != 30*time.Second {
+		t.Errorf("SendTimeout(telegram) = %v, want the default", got)
+	}
+}
+
+func TestDiscordConfig_ThreadModeFor(t *testing.T) {
+	cfg := DiscordConfig{
+		ThreadMode: "off",
+		Channels: map[string]DiscordChannelConfig{
+			"guild1":   {ThreadMode: "auto"},
+			"channel1": {ThreadMode: "always"},
+		},
+	}
+
+	tests := []struct {
+		guild, channel, want string
+	}{
+		{"guild1", "channel1", "always"},
+		{"guild1", "channel2", "auto"},
+		{"guild2", "channel3", "off"},
+	}
+	for _, tt := range tests {
+		if got := cfg.ThreadModeFor(tt.guild, tt.channel); got != tt.want {
+			t.Errorf("ThreadModeFor(%s, %s) = %q, want %q", tt.guild, tt.channel, got, tt.want)
+		}
+	}
+}
+
+func TestDiscordConfig_ModeFor(t *testing.T) {
+	cfg := DiscordConfig{
+		Channels: map[string]DiscordChannelConfig{
+			"guild1":   {Mode: DiscordModeObserve},
+			"channel1": {Mode: DiscordModeReply},
+			"channel4": {Mode: DiscordModeObserve},
+		},
+	}
+
+	tests := []struct {
+		guild, channel, want string
+	}{
+		{"guild1", "channel1", DiscordModeReply},
+		{"guild1", "channel2", DiscordModeObserve},
+		{"guild2", "channel3", DiscordModeReply},
+		{"guild2", "channel4", DiscordModeObserve},
+	}
+	for _, tt := range tests {
+		if got := cfg.ModeFor(tt.guild, tt.channel); got != tt.want {
+			t.Errorf("ModeFor(%s, %s) = %q, want %q", tt.guild, tt.channel, got, tt.want)
+		}
+	}
+}
+
+func TestDiscordConfig_PersonaFor(t *testing.T) {
+	cfg := DiscordConfig{
+		Channels: map[string]DiscordChannelConfig{
+			"guild1":   {Persona: "pirate"},
+			"channel1": {Persona: "librarian"},
+			"channel2": {ThreadMode: "auto"},
+		},
+	}
+
+	tests := []struct {
+		guild, channel, want string
+	}{
+		{"guild1", "channel1", "librarian"},
+		{"guild1", "channel2", "pirate"},
+		{"guild2", "channel3", ""},
+	}
+	for _, tt := range tests {
+		if got := cfg.PersonaFor(tt.guild, tt.channel); got != tt.want {
+			t.Errorf("PersonaFor(%s, %s) = %q, want %q", tt.guild, tt.channel, got, tt.want)
+		}
+	}
+
+	cfg.Persona = "butler"
+	if got := cfg.PersonaFor("guild2", "channel3"); got != "butler" {
+		t.Errorf("PersonaFor with a bot persona = %q, want butler", got)
+	}
+}
+
+func TestLoadConfig_DiscordBots(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	data := `{"channels":{"discord":{"enabled":true,"token":"main"},
+		"discord_bots":[{"name":"work","enabled":true,"token":"work-token","allow_from":["42"],"persona":"assistant"}]}}`
+	if err := os.WriteFile(configPath, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	t.Setenv("PICOCLAW_CHANNELS_DISCORD_TOKEN", "from-env")
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if got := cfg.Channels.Discord.ChannelName(); got != "discord" {
+		t.Errorf("main ChannelName() = %q, want discord", got)
+	}
+	if len(cfg.Channels.DiscordBots) != 1 {
+		t.Fatalf("DiscordBots = %d, want 1", len(cfg.Channels.DiscordBots))
+	}
+	bot := cfg.Channels.DiscordBots[0]
+	if got := bot.ChannelName(); got != "discord_work" {
+		t.Errorf("bot ChannelName() = %q, want discord_work", got)
+	}
+	// Overrides of the main bot don't leak into the others
+	if cfg.Channels.Discord.Token != "from-env" || bot.Token != "work-token" {
+		t.Errorf("tokens = %q, %q; want from-env, work-token", cfg.Channels.Discord.Token, bot.Token)
+	}
+	if got := bot.PersonaFor("guild", "channel"); got != "assistant" {
+		t.Errorf("bot PersonaFor = %q, want assistant", got)
+	}
+}
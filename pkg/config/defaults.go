@@ -10,13 +10,30 @@ func DefaultConfig() *Config {
 	return &Config{
 		Agents: AgentsConfig{
 			Defaults: AgentDefaults{
-				Workspace:           "~/.picoclaw/workspace",
+				Workspace:           defaultWorkspace(),
 				RestrictToWorkspace: true,
 				Provider:            "",
 				Model:               "glm-4.7",
 				MaxTokens:           8192,
 				Temperature:         nil, // nil means use provider default
 				MaxToolIterations:   20,
+				MaxParallelTools:    1,
+				ToolHistoryMaxChars: 2000,
+				GuardrailsMinChars:  4000,
+				QuoteGuard:          "flag",
+				Profile:             AgentProfileFull,
+				LoopDetection: LoopDetectionConfig{
+					Enabled:         true,
+					MaxRepeats:      3,
+					MaxAlternations: 3,
+				},
+				TurnLimits: TurnLimitsConfig{
+					MaxSeconds: 600,
+				},
+				Routing: RoutingConfig{
+					MaxChars:         280,
+					MaxContextTokens: 2000,
+				},
 			},
 		},
 		Bindings: []AgentBinding{},
@@ -43,10 +60,23 @@ func DefaultConfig() *Config {
 				AllowFrom:         FlexibleStringSlice{},
 			},
 			Discord: DiscordConfig{
-				Enabled:     false,
-				Token:       "",
-				AllowFrom:   FlexibleStringSlice{},
-				MentionOnly: false,
+				Enabled:          false,
+				Token:            "",
+				AllowFrom:        FlexibleStringSlice{},
+				MentionOnly:      false,
+				ThreadMode:       "off",
+				ThreadAfter:      3,
+				ReactionControls: false,
+				Summarize:        false,
+				SummarizeMax:     500,
+				StreamReplies:    false,
+				TypingTimeout:    300,
+				Pairing:          false,
+				Knowledge: DiscordKnowledgeConfig{
+					FAQChannels:   FlexibleStringSlice{},
+					MinConfidence: 0.6,
+					MaxSources:    3,
+				},
 			},
 			MaixCam: MaixCamConfig{
 				Enabled:   false,
@@ -67,10 +97,12 @@ func DefaultConfig() *Config {
 				AllowFrom:    FlexibleStringSlice{},
 			},
 			Slack: SlackConfig{
-				Enabled:   false,
-				BotToken:  "",
-				AppToken:  "",
-				AllowFrom: FlexibleStringSlice{},
+				Enabled:       false,
+				BotToken:      "",
+				AppToken:      "",
+				AllowFrom:     FlexibleStringSlice{},
+				MentionOnly:   false,
+				ReplyInThread: false,
 			},
 			LINE: LINEConfig{
 				Enabled:            false,
@@ -113,6 +145,95 @@ func DefaultConfig() *Config {
 				AllowFrom:      FlexibleStringSlice{},
 				ReplyTimeout:   5,
 			},
+			WhatsAppCloud: WhatsAppCloudConfig{
+				Enabled:       false,
+				PhoneNumberID: "",
+				AccessToken:   "",
+				AppSecret:     "",
+				VerifyToken:   "",
+				APIVersion:    "v21.0",
+				WebhookPath:   "/webhook/whatsapp",
+				AllowFrom:     FlexibleStringSlice{},
+			},
+			Signal: SignalConfig{
+				Enabled:        false,
+				Account:        "",
+				RPCURL:         "http://127.0.0.1:8080",
+				AttachmentsDir: "~/.local/share/signal-cli/attachments",
+				AllowFrom:      FlexibleStringSlice{},
+			},
+			Email: EmailConfig{
+				Enabled:      false,
+				IMAPPort:     993,
+				SMTPPort:     587,
+				Mailbox:      "INBOX",
+				PollInterval: 60,
+				AllowFrom:    FlexibleStringSlice{},
+			},
+			Mastodon: MastodonConfig{
+				Enabled:   false,
+				MaxChars:  500,
+				AllowFrom: FlexibleStringSlice{},
+			},
+			Webhook: WebhookConfig{
+				Enabled:   false,
+				Hooks:     []WebhookHookConfig{},
+				AllowFrom: FlexibleStringSlice{},
+			},
+			WebSocket: WebSocketConfig{
+				Enabled:      false,
+				Token:        "",
+				AllowOrigins: FlexibleStringSlice{},
+				AllowFrom:    FlexibleStringSlice{},
+			},
+			API: APIConfig{
+				Enabled:   false,
+				Token:     "",
+				Timeout:   300,
+				AllowFrom: FlexibleStringSlice{},
+			},
+			MQTT: MQTTConfig{
+				Enabled:   false,
+				Broker:    "tcp://localhost:1883",
+				ClientID:  "picoclaw",
+				InTopic:   "picoclaw/in/+",
+				OutTopic:  "picoclaw/out",
+				QoS:       1,
+				AllowFrom: FlexibleStringSlice{},
+			},
+			Ntfy: NtfyConfig{
+				Enabled: false,
+				Server:  "https://ntfy.sh",
+				Alerts:  FlexibleStringSlice{"error", "reminder"},
+			},
+			Pushover: PushoverConfig{
+				Enabled: false,
+				Alerts:  FlexibleStringSlice{"error", "reminder"},
+			},
+			Gotify: GotifyConfig{
+				Enabled: false,
+				Alerts:  FlexibleStringSlice{"error", "reminder"},
+			},
+			RateLimit: RateLimitConfig{
+				Enabled:       true,
+				UserPerMinute: 10,
+				UserBurst:     5,
+				ChatPerMinute: 30,
+				ChatBurst:     10,
+			},
+			Retry: RetryConfig{
+				Enabled:        true,
+				MaxAttempts:    5,
+				InitialBackoff: 2,
+				MaxBackoff:     60,
+			},
+			Throttle: ThrottleConfig{
+				Enabled:       true,
+				PerMinute:     60,
+				Burst:         10,
+				ChatPerMinute: 20,
+				ChatBurst:     5,
+			},
 		},
 		Providers: ProvidersConfig{
 			OpenAI: OpenAIProviderConfig{WebSearch: true},
@@ -216,6 +337,14 @@ func DefaultConfig() *Config {
 				APIKey:    "",
 			},
 
+			// Cohere (free trial keys) - https://dashboard.cohere.com/api-keys
+			{
+				ModelName: "command-a",
+				Model:     "cohere/command-a-03-2025",
+				APIBase:   "https://api.cohere.com/v2",
+				APIKey:    "",
+			},
+
 			// Volcengine (火山引擎) - https://console.volcengine.com/ark
 			{
 				ModelName: "doubao-pro",
@@ -283,6 +412,10 @@ func DefaultConfig() *Config {
 					APIKey:     "",
 					MaxResults: 5,
 				},
+				Citations: CitationsConfig{
+					Enabled:    true,
+					MaxSources: 5,
+				},
 			},
 			Cron: CronToolsConfig{
 				ExecTimeoutMinutes: 5,
@@ -290,6 +423,60 @@ func DefaultConfig() *Config {
 			Exec: ExecConfig{
 				EnableDenyPatterns: true,
 			},
+			Links: LinksConfig{
+				Enabled:  false,
+				MaxLinks: 3,
+				MaxChars: 4000,
+				YouTube:  true,
+				Twitter:  true,
+				GitHub:   true,
+				Fallback: true,
+			},
+			Bookmarks: BookmarksConfig{
+				Enabled:    true,
+				Digest:     true,
+				DigestDay:  "sunday",
+				DigestHour: 18,
+			},
+			Habits: HabitsConfig{
+				Enabled:   true,
+				Reminders: true,
+			},
+			Expenses: ExpensesConfig{
+				Enabled:  true,
+				Currency: "USD",
+			},
+			Confirm: ConfirmConfig{
+				Tools:   FlexibleStringSlice{"exec"},
+				Timeout: 60,
+			},
+			Wellness: WellnessConfig{
+				Enabled: true,
+			},
+			Journal: JournalConfig{
+				Enabled:    true,
+				PromptHour: 21,
+				Questions: FlexibleStringSlice{
+					"What went well today?",
+					"What was hard, and how did you handle it?",
+					"What are you grateful for?",
+					"What do you want to focus on tomorrow?",
+				},
+				WeeklySummary: true,
+				SummaryDay:    "sunday",
+				SummaryHour:   22,
+			},
+			FollowUps: FollowUpsConfig{
+				Enabled:     true,
+				ExpireHours: 72,
+			},
+			Workflows: WorkflowsConfig{
+				Enabled: true,
+			},
+			Focus: FocusConfig{
+				Enabled: false,
+				Minutes: 25,
+			},
 			Skills: SkillsToolsConfig{
 				Registries: SkillsRegistriesConfig{
 					ClawHub: ClawHubRegistryConfig{
@@ -302,6 +489,7 @@ func DefaultConfig() *Config {
 					MaxSize:    50,
 					TTLSeconds: 300,
 				},
+				ShadowWarnings: true,
 			},
 		},
 		Heartbeat: HeartbeatConfig{
@@ -312,5 +500,104 @@ func DefaultConfig() *Config {
 			Enabled:    false,
 			MonitorUSB: true,
 		},
+		Retention: RetentionConfig{
+			Enabled:       false,
+			IntervalHours: 24,
+			DefaultDays:   0,
+			Categories: map[string]int{
+				"daily_notes": 365,
+				"sessions":    90,
+				"audit":       30,
+			},
+		},
+		Maintenance: MaintenanceConfig{
+			Message: "I'm down for maintenance right now. Your message has been queued and I'll reply once I'm back.",
+		},
+		Canary: CanaryConfig{
+			Enabled: false,
+			Percent: 10,
+		},
+		Audit: AuditConfig{
+			Enabled: false,
+		},
+		Feedback: FeedbackConfig{
+			Enabled:  false,
+			FollowUp: true,
+		},
+		SelfReview: SelfReviewConfig{
+			Enabled: false,
+			Hour:    23,
+		},
+		WireLog: WireLogConfig{
+			Enabled:   false,
+			Level:     "redacted",
+			MaxSizeMB: 10,
+			MaxFiles:  5,
+		},
+		Embeddings: EmbeddingsConfig{
+			BatchSize: 64,
+		},
+		HealthCheck: HealthCheckConfig{
+			Enabled:         false,
+			IntervalMinutes: 60,
+			TimeoutSeconds:  20,
+		},
+		Timeouts: TimeoutsConfig{
+			Turn:          900,
+			Provider:      120,
+			Tool:          120,
+			ChannelSend:   30,
+			Transcription: 30,
+			Video:         120,
+			Tools: map[string]int{
+				"summarize_audio": 900,
+			},
+		},
+		Voice: VoiceConfig{
+			FFmpegPath:         "ffmpeg",
+			MaxSizeMB:          25,
+			MaxDurationSeconds: 600,
+		},
+		Video: VideoConfig{
+			Enabled:            false,
+			Keyframes:          4,
+			MaxDurationSeconds: 300,
+		},
+		Vision: VisionConfig{
+			Enabled:   true,
+			MaxImages: 4,
+			MaxSizeMB: 5,
+		},
+		Proactive: ProactiveConfig{
+			DefaultLevel: "normal",
+			LowPerDay:    1,
+			NormalPerDay: 4,
+		},
+		Starters: StartersConfig{
+			Enabled:   false,
+			Hour:      8,
+			Calendars: FlexibleStringSlice{},
+		},
+		Updates: UpdatesConfig{
+			Channel:            "stable",
+			CheckIntervalHours: 24,
+		},
+		Log: LogConfig{
+			Format: "text",
+		},
+		Admin: AdminConfig{
+			Users: FlexibleStringSlice{},
+		},
+		LowMemory: LowMemoryConfig{
+			Mode:        "auto",
+			ThresholdMB: 1024,
+		},
+		KV: KVConfig{
+			Backend: "bolt",
+			Redis: KVRedisConfig{
+				Addr:   "localhost:6379",
+				Prefix: "picoclaw:",
+			},
+		},
 	}
 }
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EditFile changes one value in the config file at path and leaves the
+// rest of the file as written. Unlike SaveConfig it doesn't fill in
+// defaults, and it doesn't write out secrets that came from the
+// environment or a _FILE setting. keys are the object keys leading to the
+// value, which must exist; edit gets its current JSON and returns the new
+// value.
+func EditFile(path string, keys []string, edit func(current json.RawMessage) (any, error)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	start, end, err := findJSONValue(data, keys)
+	if err != nil {
+		return err
+	}
+	value, err := edit(json.RawMessage(data[start:end]))
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:start])
+	out.Write(encoded)
+	out.Write(data[end:])
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// findJSONValue returns the byte range of the value at keys in data.
+func findJSONValue(data []byte, keys []string) (int, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for depth, key := range keys {
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+			return 0, 0, fmt.Errorf("%s is not an object", strings.Join(keys[:depth], "."))
+		}
+		found := false
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return 0, 0, err
+			}
+			if tok == key {
+				found = true
+				break
+			}
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return 0, 0, err
+			}
+		}
+		if !found {
+			return 0, 0, fmt.Errorf("no %s", strings.Join(keys[:depth+1], "."))
+		}
+	}
+
+	var value json.RawMessage
+	if err := dec.Decode(&value); err != nil {
+		return 0, 0, err
+	}
+	end := int(dec.InputOffset())
+	return end - len(value), end, nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEditFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	original := `{
+  "providers": {"openai": {"api_key": ""}},
+  "channels": {
+    "telegram": {"enabled": true, "allow_from": []},
+    "discord": {
+      "enabled": true,
+      "allow_from": ["1", "2"]
+    }
+  }
+}
+`
+	os.WriteFile(path, []byte(original), 0600)
+
+	err := EditFile(path, []string{"channels", "discord", "allow_from"}, func(current json.RawMessage) (any, error) {
+		var allow []string
+		if err := json.Unmarshal(current, &allow); err != nil {
+			return nil, err
+		}
+		return append(allow, "3"), nil
+	})
+	if err != nil {
+		t.Fatalf("EditFile: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	want := `{
+  "providers": {"openai": {"api_key": ""}},
+  "channels": {
+    "telegram": {"enabled": true, "allow_from": []},
+    "discord": {
+      "enabled": true,
+      "allow_from": ["1","2","3"]
+    }
+  }
+}
+`
+	if string(data) != want {
+		t.Errorf("file =\n%s\nwant\n%s", data, want)
+	}
+
+	if err := EditFile(path, []string{"channels", "slack", "allow_from"}, func(json.RawMessage) (any, error) { return nil, nil }); err == nil {
+		t.Error("edit of a missing key succeeded")
+	}
+}
@@ -0,0 +1,71 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HomeDir returns PicoClaw's home directory, $PICOCLAW_HOME or ~/.picoclaw.
+// The config file, the default workspace and credentials live under it, so
+// a container needs a single volume.
+func HomeDir() string {
+	if home := os.Getenv("PICOCLAW_HOME"); home != "" {
+		return expandHome(home)
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".picoclaw")
+}
+
+// ConfigPath returns the config file, $PICOCLAW_CONFIG or config.json in
+// HomeDir.
+func ConfigPath() string {
+	if path := os.Getenv("PICOCLAW_CONFIG"); path != "" {
+		return expandHome(path)
+	}
+	return filepath.Join(HomeDir(), "config.json")
+}
+
+// defaultWorkspace is the workspace used when the config doesn't set one.
+// It is written with "~" unless PICOCLAW_HOME moves it, so saved configs
+// stay portable.
+func defaultWorkspace() string {
+	if os.Getenv("PICOCLAW_HOME") != "" {
+		return filepath.Join(HomeDir(), "workspace")
+	}
+	return "~/.picoclaw/workspace"
+}
+
+// environment returns the environment config overrides are read from.
+// PICOCLAW_X_FILE=/path sets PICOCLAW_X to the contents of the file, the
+// way mounted secrets (Docker, Kubernetes) are usually passed; a variable
+// set directly wins.
+func environment() (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			vars[k] = v
+		}
+	}
+	for k, path := range vars {
+		name, ok := strings.CutSuffix(k, "_FILE")
+		if !ok || !strings.HasPrefix(name, "PICOCLAW_") || path == "" {
+			continue
+		}
+		if _, set := vars[name]; set {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", k, err)
+		}
+		vars[name] = strings.TrimRight(string(data), "\r\n")
+	}
+	return vars, nil
+}
@@ -324,6 +324,23 @@ func ConvertProvidersToModelList(cfg *Config) []ModelConfig {
 				}, true
 			},
 		},
+		{
+			providerNames: []string{"azure", "azure-openai"},
+			protocol:      "azure",
+			buildConfig: func(p ProvidersConfig) (ModelConfig, bool) {
+				if p.Azure.Endpoint == "" || p.Azure.Deployment == "" {
+					return ModelConfig{}, false
+				}
+				return ModelConfig{
+					ModelName:  "azure",
+					Model:      "azure/" + p.Azure.Deployment,
+					APIKey:     p.Azure.APIKey,
+					APIBase:    p.Azure.Endpoint,
+					Proxy:      p.Azure.Proxy,
+					APIVersion: p.Azure.APIVersion,
+				}, true
+			},
+		},
 	}
 
 	// Process each provider migration
@@ -131,14 +131,44 @@ func TestConvertProvidersToModelList_AllProviders(t *testing.T) {
 			GitHubCopilot: ProviderConfig{ConnectMode: "grpc"},
 			Antigravity:   ProviderConfig{AuthMethod: "oauth"},
 			Qwen:          ProviderConfig{APIKey: "key17"},
+			Azure:         AzureProviderConfig{Endpoint: "https://res.openai.azure.com", Deployment: "gpt4o", APIKey: "key18"},
 		},
 	}
 
 	result := ConvertProvidersToModelList(cfg)
 
-	// All 17 providers should be converted
-	if len(result) != 17 {
-		t.Errorf("len(result) = %d, want 17", len(result))
+	// All 18 providers should be converted
+	if len(result) != 18 {
+		t.Errorf("len(result) = %d, want 18", len(result))
+	}
+}
+
+func TestConvertProvidersToModelList_Azure(t *testing.T) {
+	cfg := &Config{
+		Providers: ProvidersConfig{
+			Azure: AzureProviderConfig{
+				Endpoint:   "https://res.openai.azure.com",
+				Deployment: "prod-gpt4o",
+				APIKey:     "azure-key",
+				APIVersion: "2025-01-01-preview",
+			},
+		},
+	}
+
+	result := ConvertProvidersToModelList(cfg)
+	if len(result) != 1 {
+		t.Fatalf("len(result) = %d, want 1", len(result))
+	}
+	mc := result[0]
+	if mc.Model != "azure/prod-gpt4o" || mc.APIBase != "https://res.openai.azure.com" ||
+		mc.APIKey != "azure-key" || mc.APIVersion != "2025-01-01-preview" {
+		t.Errorf("model config = %+v", mc)
+	}
+
+	// A deployment is required, since Azure routes by it
+	cfg.Providers.Azure.Deployment = ""
+	if result := ConvertProvidersToModelList(cfg); len(result) != 0 {
+		t.Errorf("converted Azure without a deployment: %+v", result)
 	}
 }
 
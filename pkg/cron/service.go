@@ -57,9 +57,20 @@ type CronStore struct {
 
 type JobHandler func(job *CronJob) (string, error)
 
+// builtinJob is a job the gateway schedules in code, such as the nightly
+// self-review. It calls run instead of the job handler and isn't saved to
+// the store, so the cron tool neither lists nor removes it.
+type builtinJob struct {
+	name   string
+	expr   string
+	run    func(now time.Time)
+	nextMS *int64
+}
+
 type CronService struct {
 	storePath string
 	store     *CronStore
+	builtins  []*builtinJob
 	onJob     JobHandler
 	mu        sync.RWMutex
 	running   bool
@@ -165,12 +176,45 @@ func (cs *CronService) checkJobs() {
 		log.Printf("[cron] failed to save store: %v", err)
 	}
 
+	var dueBuiltins []*builtinJob
+	for _, job := range cs.builtins {
+		if job.nextMS != nil && *job.nextMS <= now {
+			dueBuiltins = append(dueBuiltins, job)
+			job.nextMS = cs.computeNextRun(&CronSchedule{Kind: "cron", Expr: job.expr}, now)
+		}
+	}
+
 	cs.mu.Unlock()
 
 	// Execute jobs outside lock.
 	for _, jobID := range dueJobIDs {
 		cs.executeJobByID(jobID)
 	}
+	// Built-in jobs can run for minutes, so they don't hold up the others
+	for _, job := range dueBuiltins {
+		log.Printf("[cron] running built-in job %s", job.name)
+		go job.run(time.UnixMilli(now))
+	}
+}
+
+// Schedule runs fn on the cron expression expr, in local time, while the
+// service runs. Jobs scheduled this way live in memory only; the caller
+// schedules them again on every start.
+func (cs *CronService) Schedule(name, expr string, fn func(now time.Time)) error {
+	if !cs.gronx.IsValid(expr) {
+		return fmt.Errorf("invalid cron expression %q for %s", expr, name)
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.builtins = append(cs.builtins, &builtinJob{
+		name:   name,
+		expr:   expr,
+		run:    fn,
+		nextMS: cs.computeNextRun(&CronSchedule{Kind: "cron", Expr: expr}, time.Now().UnixMilli()),
+	})
+	return nil
 }
 
 func (cs *CronService) executeJobByID(jobID string) {
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 )
 
 func TestSaveStore_FilePermissions(t *testing.T) {
@@ -33,6 +34,42 @@ func TestSaveStore_FilePermissions(t *testing.T) {
 	}
 }
 
+func TestScheduleBuiltinJob(t *testing.T) {
+	cs := NewCronService(filepath.Join(t.TempDir(), "cron", "jobs.json"), nil)
+	if err := cs.Schedule("broken", "not a cron expression", func(time.Time) {}); err == nil {
+		t.Error("Schedule accepted an invalid expression")
+	}
+	ran := make(chan time.Time, 1)
+	if err := cs.Schedule("nightly", "0 23 * * *", func(now time.Time) { ran <- now }); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Stop()
+
+	// Make the job due
+	cs.mu.Lock()
+	cs.builtins[0].nextMS = int64Ptr(time.Now().UnixMilli() - 1)
+	cs.mu.Unlock()
+	cs.checkJobs()
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("due built-in job didn't run")
+	}
+
+	cs.mu.Lock()
+	next := cs.builtins[0].nextMS
+	cs.mu.Unlock()
+	if next == nil || time.UnixMilli(*next).Hour() != 23 || !time.UnixMilli(*next).After(time.Now()) {
+		t.Errorf("next run = %v, want the next 23:00", next)
+	}
+	if len(cs.ListJobs(true)) != 0 {
+		t.Error("built-in job was listed with the stored jobs")
+	}
+}
+
 func int64Ptr(v int64) *int64 {
 	return &v
 }
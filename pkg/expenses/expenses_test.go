@@ -0,0 +1,115 @@
+package expenses
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseText(t *testing.T) {
+	tests := []struct {
+		text string
+		want Parsed
+	}{
+		{"spent 12.50 on lunch", Parsed{Amount: 12.5, Description: "lunch"}},
+		{"€8 taxi home", Parsed{Amount: 8, Currency: "EUR", Description: "taxi home"}},
+		{"paid $1,200 for rent", Parsed{Amount: 1200, Currency: "USD", Description: "rent"}},
+		{"groceries 45 eur", Parsed{Amount: 45, Currency: "EUR", Description: "groceries"}},
+		{"coffee 3,5", Parsed{Amount: 3.5, Description: "coffee"}},
+		{"20 bucks at the cinema", Parsed{Amount: 20, Currency: "USD", Description: "the cinema"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseText(tt.text)
+		if err != nil {
+			t.Errorf("ParseText(%q) error: %v", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseText(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+
+	if _, err := ParseText("had lunch with Sam"); err == nil {
+		t.Error("expected an error when there is no amount")
+	}
+}
+
+func TestInferCategory(t *testing.T) {
+	tests := map[string]string{
+		"lunch":            "food",
+		"weekly groceries": "groceries",
+		"Uber to airport":  "transport",
+		"rent":             "housing",
+		"birthday present": "other",
+	}
+	for desc, want := range tests {
+		if got := InferCategory(desc); got != want {
+			t.Errorf("InferCategory(%q) = %q, want %q", desc, got, want)
+		}
+	}
+}
+
+func TestStore_AddMonth(t *testing.T) {
+	s := NewStore(t.TempDir())
+	oct := time.Date(2026, 10, 5, 0, 0, 0, 0, time.Local)
+
+	for _, e := range []Entry{
+		{Date: oct, Amount: 12.5, Currency: "USD", Category: "food", Description: "lunch, with \"friends\"", User: "alice"},
+		{Date: oct.AddDate(0, 0, 3), Amount: 40, Currency: "USD", Category: "groceries", Description: "market", User: "alice"},
+		{Date: oct, Amount: 99, Currency: "USD", Category: "other", Description: "not mine", User: "bob"},
+		{Date: oct.AddDate(0, -1, 0), Amount: 30, Currency: "USD", Category: "food", Description: "dinner", User: "alice"},
+	} {
+		if err := s.Add(e); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	entries, err := s.Month(oct, "alice")
+	if err != nil {
+		t.Fatalf("Month: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Description != "lunch, with \"friends\"" || entries[1].Amount != 40 {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	data, _ := os.ReadFile(s.MonthFile(oct))
+	if !strings.HasPrefix(string(data), "date,amount,currency,category,description,user\n") {
+		t.Errorf("missing CSV header:\n%s", data)
+	}
+
+	if entries, _ := s.Month(oct.AddDate(0, 2, 0), "alice"); entries != nil {
+		t.Errorf("expected no entries for an empty month, got %+v", entries)
+	}
+}
+
+func TestSummary_Format(t *testing.T) {
+	day := time.Date(2026, 10, 5, 0, 0, 0, 0, time.Local)
+	s := Summarize([]Entry{
+		{Date: day, Amount: 10, Currency: "USD", Category: "food", Description: "lunch"},
+		{Date: day, Amount: 50, Currency: "USD", Category: "groceries", Description: "market"},
+		{Date: day, Amount: 15, Currency: "USD", Category: "food", Description: "dinner"},
+		{Date: day, Amount: 7, Currency: "EUR", Category: "transport", Description: "taxi"},
+	})
+	if s.Totals["USD"] != 75 || s.Totals["EUR"] != 7 {
+		t.Errorf("totals = %v", s.Totals)
+	}
+	if s.Categories[1].Category != "groceries" || s.Categories[2].Category != "food" || s.Categories[2].Count != 2 {
+		t.Errorf("categories = %+v", s.Categories)
+	}
+	if len(s.Largest) != 3 || s.Largest[0].Description != "market" {
+		t.Errorf("largest = %+v", s.Largest)
+	}
+
+	prev := Summarize([]Entry{{Date: day, Amount: 50, Currency: "USD", Category: "food"}})
+	out := s.Format("October 2026", prev)
+	for _, want := range []string{"Total: 75.00 USD (+50% vs last month's 50.00)", "Total: 7.00 EUR\n", "groceries"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	if out := Summarize(nil).Format("October 2026", Summary{}); out != "No expenses logged for October 2026." {
+		t.Errorf("empty summary = %q", out)
+	}
+}
@@ -0,0 +1,112 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package expenses
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parsed is what ParseText found in a message such as "spent 12.50 on lunch".
+type Parsed struct {
+	Amount      float64
+	Currency    string // "" when the message names no currency
+	Description string
+}
+
+var (
+	thousandsRe = regexp.MustCompile(`(\d),(\d{3})\b`)
+	amountRe    = regexp.MustCompile(`(?i)([$€£¥₹])?\s?(\d+(?:[.,]\d{1,2})?)\s*(usd|eur|gbp|jpy|inr|idr|cny|aud|cad|chf|dollars?|bucks|euros?|pounds?|rupees?|yen)?\b`)
+	// descriptionRe finds what the money went on after the amount.
+	descriptionRe = regexp.MustCompile(`(?i)^\s*(?:on|for|at|in)\s+(.+)$`)
+	fillerRe      = regexp.MustCompile(`(?i)\b(i|i've|just|spent|paid|pay|bought|cost|costs|was|for|on|at)\b`)
+)
+
+var currencySymbols = map[string]string{
+	"$": "USD", "€": "EUR", "£": "GBP", "¥": "JPY", "₹": "INR",
+}
+
+var currencyWords = map[string]string{
+	"dollar": "USD", "dollars": "USD", "bucks": "USD",
+	"euro": "EUR", "euros": "EUR",
+	"pound": "GBP", "pounds": "GBP",
+	"rupee": "INR", "rupees": "INR",
+	"yen": "JPY",
+}
+
+// ParseText extracts the amount, currency and description from a natural
+// language expense like "spent 12.50 on lunch" or "€8 taxi home".
+func ParseText(text string) (Parsed, error) {
+	text = thousandsRe.ReplaceAllString(strings.TrimSpace(text), "$1$2")
+	loc := amountRe.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return Parsed{}, fmt.Errorf("no amount found in %q", text)
+	}
+	m := amountRe.FindStringSubmatch(text)
+
+	amount, err := strconv.ParseFloat(strings.Replace(m[2], ",", ".", 1), 64)
+	if err != nil || amount <= 0 {
+		return Parsed{}, fmt.Errorf("invalid amount %q", m[2])
+	}
+
+	p := Parsed{Amount: amount}
+	switch {
+	case m[1] != "":
+		p.Currency = currencySymbols[m[1]]
+	case m[3] != "":
+		word := strings.ToLower(m[3])
+		if c, ok := currencyWords[word]; ok {
+			p.Currency = c
+		} else {
+			p.Currency = strings.ToUpper(word)
+		}
+	}
+
+	if d := descriptionRe.FindStringSubmatch(text[loc[1]:]); d != nil {
+		p.Description = d[1]
+	} else {
+		rest := text[:loc[0]] + " " + text[loc[1]:]
+		p.Description = fillerRe.ReplaceAllString(rest, " ")
+	}
+	p.Description = strings.Trim(strings.Join(strings.Fields(p.Description), " "), " .,!")
+	return p, nil
+}
+
+// categoryKeywords maps keywords to categories, checked in order.
+var categoryKeywords = []struct {
+	category string
+	words    []string
+}{
+	{"groceries", []string{"groceries", "grocery", "supermarket", "market"}},
+	{"food", []string{"lunch", "dinner", "breakfast", "brunch", "coffee", "cafe", "restaurant", "pizza", "burger", "sushi", "snack", "snacks", "takeout", "meal", "drinks", "beer", "bar"}},
+	{"travel", []string{"flight", "flights", "hotel", "airbnb", "hostel"}},
+	{"transport", []string{"taxi", "uber", "lyft", "bus", "train", "metro", "subway", "fuel", "gas", "petrol", "parking", "toll"}},
+	{"housing", []string{"rent", "mortgage"}},
+	{"utilities", []string{"electricity", "water", "internet", "phone", "bill", "bills"}},
+	{"entertainment", []string{"movie", "movies", "cinema", "netflix", "spotify", "concert", "game", "games", "tickets"}},
+	{"health", []string{"pharmacy", "doctor", "medicine", "gym", "dentist"}},
+	{"shopping", []string{"clothes", "shoes", "shirt", "jacket", "amazon"}},
+}
+
+// InferCategory guesses a category from the description's words, falling
+// back to "other".
+func InferCategory(description string) string {
+	words := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z')
+	})
+	for _, c := range categoryKeywords {
+		for _, kw := range c.words {
+			for _, w := range words {
+				if w == kw {
+					return c.category
+				}
+			}
+		}
+	}
+	return "other"
+}
@@ -0,0 +1,198 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package expenses logs spending to one CSV file per month and summarizes
+// it by category.
+package expenses
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const dateFormat = "2006-01-02"
+
+var csvHeader = []string{"date", "amount", "currency", "category", "description", "user"}
+
+// Entry is one expense. User is the sender who logged it; each user only
+// sees their own entries.
+type Entry struct {
+	Date        time.Time
+	Amount      float64
+	Currency    string
+	Category    string
+	Description string
+	User        string
+}
+
+// Store keeps expenses in workspace/expenses/YYYY-MM.csv, a plain file the
+// user can open in a spreadsheet.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStore creates an expense store for a workspace.
+func NewStore(workspace string) *Store {
+	return &Store{dir: filepath.Join(workspace, "expenses")}
+}
+
+// MonthFile returns the CSV file holding the month of t.
+func (s *Store) MonthFile(t time.Time) string {
+	return filepath.Join(s.dir, t.Format("2006-01")+".csv")
+}
+
+// Add appends e to its month's file.
+func (s *Store) Add(e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	path := s.MonthFile(e.Date)
+	_, statErr := os.Stat(path)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if os.IsNotExist(statErr) {
+		w.Write(csvHeader)
+	}
+	w.Write([]string{
+		e.Date.Format(dateFormat),
+		strconv.FormatFloat(e.Amount, 'f', 2, 64),
+		e.Currency,
+		e.Category,
+		e.Description,
+		e.User,
+	})
+	w.Flush()
+	return w.Error()
+}
+
+// Month returns the user's entries for the month of t, in logged order.
+func (s *Store) Month(t time.Time, user string) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.MonthFile(t))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(s.MonthFile(t)), err)
+	}
+
+	var entries []Entry
+	for i, rec := range records {
+		if i == 0 || len(rec) < len(csvHeader) || rec[5] != user {
+			continue
+		}
+		date, err := time.ParseInLocation(dateFormat, rec[0], t.Location())
+		if err != nil {
+			continue
+		}
+		amount, err := strconv.ParseFloat(rec[1], 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, Entry{
+			Date:        date,
+			Amount:      amount,
+			Currency:    rec[2],
+			Category:    rec[3],
+			Description: rec[4],
+			User:        rec[5],
+		})
+	}
+	return entries, nil
+}
+
+// Name implements privacy.Store.
+func (s *Store) Name() string {
+	return "expenses"
+}
+
+// Purge implements privacy.Store by rewriting each month's file without
+// the user's entries.
+func (s *Store) Purge(user string, dryRun bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.csv"))
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, path := range files {
+		n, err := purgeFile(path, user, dryRun)
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+	return removed, nil
+}
+
+// purgeFile drops the user's rows from one month's file.
+func purgeFile(path, user string, dryRun bool) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	f.Close()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+
+	kept := records[:0:0]
+	for i, rec := range records {
+		if i > 0 && len(rec) >= len(csvHeader) && rec[5] == user {
+			continue
+		}
+		kept = append(kept, rec)
+	}
+	removed := len(records) - len(kept)
+	if removed == 0 || dryRun {
+		return removed, nil
+	}
+
+	tmpPath := path + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return 0, err
+	}
+	w := csv.NewWriter(out)
+	w.WriteAll(kept)
+	if err := w.Error(); err != nil {
+		out.Close()
+		return 0, err
+	}
+	if err := out.Close(); err != nil {
+		return 0, err
+	}
+	return removed, os.Rename(tmpPath, path)
+}
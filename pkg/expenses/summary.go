@@ -0,0 +1,98 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package expenses
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CategoryTotal is the spending in one category and currency.
+type CategoryTotal struct {
+	Category string
+	Currency string
+	Amount   float64
+	Count    int
+}
+
+// Summary totals one month of entries. Amounts in different currencies
+// are never added together.
+type Summary struct {
+	Totals     map[string]float64 // currency → total
+	Categories []CategoryTotal    // largest first
+	Largest    []Entry            // up to three biggest entries
+	Count      int
+}
+
+// Summarize totals entries per currency and category.
+func Summarize(entries []Entry) Summary {
+	s := Summary{Totals: make(map[string]float64), Count: len(entries)}
+	byKey := make(map[string]*CategoryTotal)
+	for _, e := range entries {
+		s.Totals[e.Currency] += e.Amount
+		key := e.Currency + "/" + e.Category
+		ct, ok := byKey[key]
+		if !ok {
+			ct = &CategoryTotal{Category: e.Category, Currency: e.Currency}
+			byKey[key] = ct
+		}
+		ct.Amount += e.Amount
+		ct.Count++
+	}
+	for _, ct := range byKey {
+		s.Categories = append(s.Categories, *ct)
+	}
+	sort.Slice(s.Categories, func(i, j int) bool {
+		if s.Categories[i].Currency != s.Categories[j].Currency {
+			return s.Categories[i].Currency < s.Categories[j].Currency
+		}
+		return s.Categories[i].Amount > s.Categories[j].Amount
+	})
+
+	s.Largest = append([]Entry(nil), entries...)
+	sort.SliceStable(s.Largest, func(i, j int) bool { return s.Largest[i].Amount > s.Largest[j].Amount })
+	if len(s.Largest) > 3 {
+		s.Largest = s.Largest[:3]
+	}
+	return s
+}
+
+// Format renders the summary, comparing totals with the previous month
+// when there is one.
+func (s Summary) Format(month string, previous Summary) string {
+	if s.Count == 0 {
+		return fmt.Sprintf("No expenses logged for %s.", month)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Expenses for %s (%d entries)\n", month, s.Count)
+
+	currencies := make([]string, 0, len(s.Totals))
+	for c := range s.Totals {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+	for _, c := range currencies {
+		fmt.Fprintf(&sb, "\nTotal: %.2f %s", s.Totals[c], c)
+		if prev, ok := previous.Totals[c]; ok && prev > 0 {
+			fmt.Fprintf(&sb, " (%+.0f%% vs last month's %.2f)", (s.Totals[c]-prev)/prev*100, prev)
+		}
+		sb.WriteString("\n")
+		for _, ct := range s.Categories {
+			if ct.Currency != c {
+				continue
+			}
+			fmt.Fprintf(&sb, "  %-14s %10.2f  %3.0f%%  (%d)\n", ct.Category, ct.Amount, ct.Amount/s.Totals[c]*100, ct.Count)
+		}
+	}
+
+	sb.WriteString("\nLargest:\n")
+	for _, e := range s.Largest {
+		fmt.Fprintf(&sb, "  %s  %.2f %s  %s (%s)\n", e.Date.Format("Jan 2"), e.Amount, e.Currency, e.Description, e.Category)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,203 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package focus keeps time-boxed focus sessions. While a session runs in
+// a chat, the agent's proactive messages to it are held back; when it ends
+// the chat gets a note, then the held messages, and the session is logged
+// in the daily note.
+package focus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/journal"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// noteHeading is the daily note section sessions are logged under.
+const noteHeading = "## Focus"
+
+// ErrActive is returned when a chat starts a session while one runs.
+var ErrActive = errors.New("a focus session is already running")
+
+// Session is one focus session of a chat.
+type Session struct {
+	Channel string    `json:"channel"`
+	ChatID  string    `json:"chat_id"`
+	Task    string    `json:"task,omitempty"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	// Held are the proactive messages that arrived during the session,
+	// delivered when it ends.
+	Held []bus.OutboundMessage `json:"held,omitempty"`
+}
+
+// Minutes returns the planned length of the session.
+func (s Session) Minutes() int {
+	return int(s.End.Sub(s.Start).Round(time.Minute) / time.Minute)
+}
+
+// Store keeps the running sessions in workspace/state/focus.json. The file
+// is re-read on every call so the agent's tool, the channel manager and
+// the service that ends sessions can share it.
+type Store struct {
+	workspace string
+	path      string
+	mu        *sync.Mutex // one lock per file, see utils.FileLock
+}
+
+// NewStore creates the store for a workspace.
+func NewStore(workspace string) *Store {
+	path := filepath.Join(workspace, "state", "focus.json")
+	return &Store{
+		workspace: workspace,
+		path:      path,
+		mu:        utils.FileLock(path),
+	}
+}
+
+func chatKey(channel, chatID string) string {
+	return channel + ":" + chatID
+}
+
+// Start begins a session of d in the chat.
+func (s *Store) Start(channel, chatID, task string, d time.Duration, now time.Time) (Session, error) {
+	if d <= 0 {
+		return Session{}, fmt.Errorf("a focus session needs a length")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := s.load()
+	if old, ok := sessions[chatKey(channel, chatID)]; ok && now.Before(old.End) {
+		return old, ErrActive
+	}
+	sess := Session{Channel: channel, ChatID: chatID, Task: task, Start: now, End: now.Add(d)}
+	sessions[chatKey(channel, chatID)] = sess
+	return sess, s.save(sessions)
+}
+
+// Active returns the chat's running session, if any.
+func (s *Store) Active(channel, chatID string, now time.Time) (Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.load()[chatKey(channel, chatID)]
+	if !ok || !now.Before(sess.End) {
+		return Session{}, false
+	}
+	return sess, true
+}
+
+// Hold queues a message for the chat of msg until its running session
+// ends. It reports false, and keeps nothing, when no session runs.
+func (s *Store) Hold(msg bus.OutboundMessage, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := s.load()
+	key := chatKey(msg.Channel, msg.ChatID)
+	sess, ok := sessions[key]
+	if !ok || !now.Before(sess.End) {
+		return false
+	}
+	sess.Held = append(sess.Held, msg)
+	sessions[key] = sess
+	if err := s.save(sessions); err != nil {
+		// Better delivered during the session than lost
+		return false
+	}
+	return true
+}
+
+// Stop ends the chat's running session early and logs it. The caller
+// delivers the session's held messages.
+func (s *Store) Stop(channel, chatID string, now time.Time) (Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := s.load()
+	key := chatKey(channel, chatID)
+	sess, ok := sessions[key]
+	if !ok || !now.Before(sess.End) {
+		return Session{}, false
+	}
+	delete(sessions, key)
+	s.save(sessions)
+	s.log(sess, now)
+	return sess, true
+}
+
+// Finish removes the sessions that have run their course, logs them and
+// returns them, oldest first, with their held messages.
+func (s *Store) Finish(now time.Time) []Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := s.load()
+	var done []Session
+	for key, sess := range sessions {
+		if !now.Before(sess.End) {
+			done = append(done, sess)
+			delete(sessions, key)
+		}
+	}
+	if len(done) == 0 {
+		return nil
+	}
+	s.save(sessions)
+	sort.Slice(done, func(i, j int) bool { return done[i].End.Before(done[j].End) })
+	for _, sess := range done {
+		s.log(sess, sess.End)
+	}
+	return done
+}
+
+// log writes a session into the daily note of the day it started, e.g.
+// "- 09:00–09:25 Write the report (25 min)".
+func (s *Store) log(sess Session, end time.Time) {
+	line := fmt.Sprintf("- %s–%s", sess.Start.Format("15:04"), end.Format("15:04"))
+	if sess.Task != "" {
+		line += " " + sess.Task
+	}
+	if end.Before(sess.End) {
+		line += fmt.Sprintf(" (stopped after %d of %d min)", int(end.Sub(sess.Start)/time.Minute), sess.Minutes())
+	} else {
+		line += fmt.Sprintf(" (%d min)", sess.Minutes())
+	}
+	// A failed write only loses the log line
+	journal.AppendToNote(s.workspace, sess.Start, noteHeading, line)
+}
+
+func (s *Store) load() map[string]Session {
+	sessions := make(map[string]Session)
+	if raw, err := os.ReadFile(s.path); err == nil {
+		json.Unmarshal(raw, &sessions)
+	}
+	return sessions
+}
+
+func (s *Store) save(sessions map[string]Session) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
@@ -0,0 +1,100 @@
+package focus
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/journal"
+)
+
+func TestStoreSessions(t *testing.T) {
+	ws := t.TempDir()
+	s := NewStore(ws)
+	start := time.Date(2026, 10, 15, 9, 0, 0, 0, time.Local)
+
+	sess, err := s.Start("telegram", "42", "write the report", 25*time.Minute, start)
+	if err != nil || sess.Minutes() != 25 {
+		t.Fatalf("Start = %+v, %v", sess, err)
+	}
+	if _, err := s.Start("telegram", "42", "", 25*time.Minute, start.Add(time.Minute)); !errors.Is(err, ErrActive) {
+		t.Errorf("second Start err = %v, want ErrActive", err)
+	}
+	// The state file is shared between stores
+	if _, ok := NewStore(ws).Active("telegram", "42", start.Add(10*time.Minute)); !ok {
+		t.Error("session not active mid-way")
+	}
+	if _, ok := s.Active("telegram", "7", start); ok {
+		t.Error("another chat is focused")
+	}
+
+	if _, ok := s.Stop("telegram", "42", start.Add(12*time.Minute)); !ok {
+		t.Fatal("Stop found no session")
+	}
+	if _, ok := s.Active("telegram", "42", start.Add(13*time.Minute)); ok {
+		t.Error("session still active after Stop")
+	}
+	note, _ := os.ReadFile(journal.NotePath(ws, start))
+	if !strings.Contains(string(note), "## Focus") || !strings.Contains(string(note), "09:00–09:12 write the report (stopped after 12 of 25 min)") {
+		t.Errorf("daily note = %q", note)
+	}
+}
+
+func TestServiceEndsSessions(t *testing.T) {
+	ws := t.TempDir()
+	store := NewStore(ws)
+	msgBus := bus.NewMessageBus()
+	svc := NewService(store, msgBus)
+	start := time.Date(2026, 10, 15, 14, 0, 0, 0, time.Local)
+	store.Start("discord", "chan1", "", 25*time.Minute, start)
+
+	svc.Check(start.Add(24 * time.Minute))
+	if _, ok := store.Active("discord", "chan1", start.Add(24*time.Minute)); !ok {
+		t.Fatal("session ended early")
+	}
+	svc.Check(start.Add(25 * time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	out, ok := msgBus.SubscribeOutbound(ctx)
+	if !ok || out.ChatID != "chan1" || out.Proactive != "" || !strings.Contains(out.Content, "25-minute focus session") {
+		t.Errorf("end note = %+v, %v", out, ok)
+	}
+	note, _ := os.ReadFile(journal.NotePath(ws, start))
+	if !strings.Contains(string(note), "- 14:00–14:25 (25 min)") {
+		t.Errorf("daily note = %q", note)
+	}
+	if done := store.Finish(start.Add(time.Hour)); len(done) != 0 {
+		t.Errorf("session finished twice: %+v", done)
+	}
+}
+
+func TestServiceReleasesHeldMessages(t *testing.T) {
+	store := NewStore(t.TempDir())
+	msgBus := bus.NewMessageBus()
+	svc := NewService(store, msgBus)
+	start := time.Date(2026, 10, 15, 14, 0, 0, 0, time.Local)
+	nudge := bus.OutboundMessage{Channel: "discord", ChatID: "chan1", Content: "stretch", Proactive: bus.ProactiveNudge}
+
+	if store.Hold(nudge, start) {
+		t.Fatal("held a message with no session running")
+	}
+	store.Start("discord", "chan1", "", 25*time.Minute, start)
+	if !store.Hold(nudge, start.Add(5*time.Minute)) {
+		t.Fatal("message not held during the session")
+	}
+
+	svc.Check(start.Add(25 * time.Minute))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if out, ok := msgBus.SubscribeOutbound(ctx); !ok || out.Proactive != "" {
+		t.Fatalf("first message = %+v, %v, want the end note", out, ok)
+	}
+	if out, ok := msgBus.SubscribeOutbound(ctx); !ok || out.Content != "stretch" || out.Proactive != bus.ProactiveNudge {
+		t.Errorf("second message = %+v, %v, want the held nudge", out, ok)
+	}
+}
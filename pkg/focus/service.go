@@ -0,0 +1,127 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package focus
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/constants"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// checkInterval is how often the service looks for sessions that ended, so
+// the end note arrives at most this late.
+const checkInterval = 30 * time.Second
+
+// Service ends focus sessions on time: each chat whose session ran its
+// course gets an end note, and the session is logged in the daily note.
+type Service struct {
+	store    *Store
+	bus      *bus.MessageBus
+	mu       sync.Mutex
+	stopChan chan struct{}
+}
+
+// NewService creates the service for the sessions in store.
+func NewService(store *Store, msgBus *bus.MessageBus) *Service {
+	return &Service{store: store, bus: msgBus}
+}
+
+// Start begins checking for ended sessions.
+func (s *Service) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopChan != nil {
+		return nil
+	}
+	s.stopChan = make(chan struct{})
+	go s.runLoop(s.stopChan)
+	return nil
+}
+
+// Stop stops the checks.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopChan == nil {
+		return
+	}
+	close(s.stopChan)
+	s.stopChan = nil
+}
+
+func (s *Service) runLoop(stopChan chan struct{}) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			s.Check(time.Now())
+		}
+	}
+}
+
+// Check ends the sessions that have run their course and sends their end
+// notes, then the messages held during them. The user asked for the notes,
+// so they aren't proactive messages.
+func (s *Service) Check(now time.Time) {
+	for _, sess := range s.store.Finish(now) {
+		logger.InfoCF("focus", "Focus session ended", map[string]interface{}{
+			"channel": sess.Channel,
+			"minutes": sess.Minutes(),
+		})
+		if s.bus == nil || constants.IsInternalChannel(sess.Channel) {
+			continue
+		}
+		s.bus.PublishOutbound(bus.OutboundMessage{
+			Channel: sess.Channel,
+			ChatID:  sess.ChatID,
+			Content: EndNote(sess),
+		})
+		Release(s.bus, sess)
+	}
+}
+
+// Release sends the messages held during an ended session. They are still
+// proactive, so the chat's frequency setting applies to them as usual.
+func Release(msgBus *bus.MessageBus, sess Session) {
+	if len(sess.Held) > 0 {
+		logger.InfoCF("focus", "Delivering messages held during focus", map[string]interface{}{
+			"channel": sess.Channel,
+			"count":   len(sess.Held),
+		})
+	}
+	for _, msg := range sess.Held {
+		msgBus.PublishOutbound(msg)
+	}
+}
+
+// StartNote is the note posted when a session starts.
+func StartNote(sess Session) string {
+	what := "Focus time"
+	if sess.Task != "" {
+		what = "Focusing on " + sess.Task
+	}
+	return fmt.Sprintf("🍅 %s until %s (%d min). I'll hold back anything that isn't urgent until then.",
+		what, sess.End.Format("15:04"), sess.Minutes())
+}
+
+// EndNote is the note posted when a session has run its course.
+func EndNote(sess Session) string {
+	what := "focus session"
+	if sess.Task != "" {
+		what = "focus session on " + sess.Task
+	}
+	return fmt.Sprintf("⏰ Your %d-minute %s is done. Time for a short break!", sess.Minutes(), what)
+}
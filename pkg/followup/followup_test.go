@@ -0,0 +1,45 @@
+package followup
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreParkAndTake(t *testing.T) {
+	ws := t.TempDir()
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	s := NewStore(ws, 24*time.Hour)
+
+	old := Task{Channel: "telegram", ChatID: "42", Question: "Which hotel?", Task: "Book a taxi", AskedAt: now.Add(-48 * time.Hour)}
+	fresh := Task{Channel: "telegram", ChatID: "42", Question: "Flight number?", Task: "Track the flight", AskedAt: now.Add(-time.Hour)}
+	other := Task{Channel: "discord", ChatID: "42", Question: "Which repo?", Task: "Open an issue", AskedAt: now}
+	for _, task := range []Task{old, fresh, other} {
+		if _, err := s.Park(task); err != nil {
+			t.Fatalf("Park: %v", err)
+		}
+	}
+	if _, err := s.Park(Task{Channel: "telegram", ChatID: "42", Question: "?"}); err == nil {
+		t.Error("expected an error for a task without a description")
+	}
+
+	// Parked tasks survive a restart; expired ones are dropped
+	tasks, err := NewStore(ws, 24*time.Hour).Take("telegram", "42", now)
+	if err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Task != "Track the flight" || tasks[0].ID == "" {
+		t.Errorf("taken = %+v", tasks)
+	}
+	if again, _ := s.Take("telegram", "42", now); len(again) != 0 {
+		t.Errorf("tasks handed back twice: %+v", again)
+	}
+	if rest, _ := s.Take("discord", "42", now); len(rest) != 1 {
+		t.Errorf("other chat's tasks = %+v", rest)
+	}
+
+	note := ResumeNote(tasks)
+	if !strings.Contains(note, `You asked "Flight number?" to be able to: Track the flight`) {
+		t.Errorf("note = %q", note)
+	}
+}
@@ -0,0 +1,145 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package followup parks tasks the agent can't finish without asking the
+// user something, and hands them back when the user's next message in
+// that chat arrives.
+package followup
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// Task is a parked task waiting for the user's answer to Question.
+type Task struct {
+	ID       string    `json:"id"`
+	Channel  string    `json:"channel"`
+	ChatID   string    `json:"chat_id"`
+	Question string    `json:"question"`
+	Task     string    `json:"task"` // what to do once the answer is in
+	AskedAt  time.Time `json:"asked_at"`
+}
+
+// Store persists parked tasks in workspace/state/followups.json, so they
+// survive restarts.
+type Store struct {
+	path   string
+	expire time.Duration
+	mu     *sync.Mutex // shared with the starters
+}
+
+// NewStore creates a follow-up store for a workspace. Tasks not answered
+// within expire are dropped; zero keeps them until answered.
+func NewStore(workspace string, expire time.Duration) *Store {
+	path := filepath.Join(workspace, "state", "followups.json")
+	return &Store{
+		path:   path,
+		expire: expire,
+		mu:     utils.FileLock(path),
+	}
+}
+
+// Park saves t, filling in its ID and AskedAt.
+func (s *Store) Park(t Task) (Task, error) {
+	if strings.TrimSpace(t.Question) == "" || strings.TrimSpace(t.Task) == "" {
+		return t, fmt.Errorf("question and task are required")
+	}
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return t, err
+	}
+	t.ID = hex.EncodeToString(id)
+	if t.AskedAt.IsZero() {
+		t.AskedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return t, s.save(append(s.load(), t))
+}
+
+// Take removes and returns the chat's parked tasks, oldest first,
+// dropping expired ones.
+func (s *Store) Take(channel, chatID string, now time.Time) ([]Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := s.load()
+	var taken, kept []Task
+	for _, t := range tasks {
+		switch {
+		case s.expire > 0 && now.Sub(t.AskedAt) > s.expire:
+			// dropped
+		case t.Channel == channel && t.ChatID == chatID:
+			taken = append(taken, t)
+		default:
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == len(tasks) {
+		return nil, nil
+	}
+	return taken, s.save(kept)
+}
+
+// Pending returns every parked task that hasn't expired, oldest first,
+// without taking it.
+func (s *Store) Pending(now time.Time) []Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pending []Task
+	for _, t := range s.load() {
+		if s.expire <= 0 || now.Sub(t.AskedAt) <= s.expire {
+			pending = append(pending, t)
+		}
+	}
+	return pending
+}
+
+func (s *Store) load() []Task {
+	var tasks []Task
+	if raw, err := os.ReadFile(s.path); err == nil {
+		json.Unmarshal(raw, &tasks)
+	}
+	return tasks
+}
+
+func (s *Store) save(tasks []Task) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(tasks, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+// ResumeNote tells the agent about the tasks it parked, ahead of the
+// user's reply.
+func ResumeNote(tasks []Task) string {
+	var sb strings.Builder
+	sb.WriteString("[Parked tasks waiting on this reply:\n")
+	for _, t := range tasks {
+		fmt.Fprintf(&sb, "- You asked %q to be able to: %s\n", t.Question, t.Task)
+	}
+	sb.WriteString("Finish them with the user's answer below. If it doesn't answer a question, ask again with ask_user or drop that task.]")
+	return sb.String()
+}
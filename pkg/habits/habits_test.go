@@ -0,0 +1,100 @@
+package habits
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+func day(date string) time.Time {
+	t, _ := time.ParseInLocation(dateFormat, date, time.Local)
+	return t.Add(12 * time.Hour)
+}
+
+func TestComputeStats_Daily(t *testing.T) {
+	h := Habit{Frequency: Daily, Done: []string{"2026-10-01", "2026-10-02", "2026-10-03", "2026-10-10", "2026-10-11"}}
+
+	// Not done yet today: yesterday's streak still counts
+	st := ComputeStats(h, day("2026-10-12"))
+	if st.DoneNow || st.CurrentStreak != 2 || st.LongestStreak != 3 || st.Total != 5 {
+		t.Errorf("stats = %+v", st)
+	}
+	// A missed day breaks the streak
+	if st := ComputeStats(h, day("2026-10-13")); st.CurrentStreak != 0 {
+		t.Errorf("streak after a gap = %d", st.CurrentStreak)
+	}
+}
+
+func TestComputeStats_Weekly(t *testing.T) {
+	// Monday of one week, Sunday of the next and Wednesday of the one after
+	h := Habit{Frequency: Weekly, Done: []string{"2026-09-28", "2026-10-11", "2026-10-14"}}
+	st := ComputeStats(h, day("2026-10-15"))
+	if !st.DoneNow || st.CurrentStreak != 3 || st.LongestStreak != 3 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+func TestStore_DefineComplete(t *testing.T) {
+	store := NewStore(t.TempDir())
+	u := User{Channel: "telegram", ChatID: "42", SenderID: "42|alice"}
+
+	if created, err := store.Define(u, "Run", "", "20:00"); err != nil || !created {
+		t.Fatalf("Define = %v, %v", created, err)
+	}
+	if _, err := store.Define(u, "read", "hourly", ""); err == nil {
+		t.Error("unknown frequency should be rejected")
+	}
+	if _, err := store.Define(u, "read", Daily, "8pm"); err == nil {
+		t.Error("bad remind_at should be rejected")
+	}
+
+	h, err := store.Complete(u, "run", day("2026-10-15"))
+	if err != nil || !h.DoneOn(day("2026-10-15")) {
+		t.Fatalf("Complete = %+v, %v", h, err)
+	}
+	store.Complete(u, "RUN", day("2026-10-15"))
+	if got := store.Habits(u); len(got) != 1 || len(got[0].Done) != 1 {
+		t.Errorf("habits = %+v", got)
+	}
+
+	other := User{Channel: "telegram", ChatID: "42", SenderID: "43"}
+	if got := store.Habits(other); len(got) != 0 {
+		t.Errorf("another user sees %+v", got)
+	}
+}
+
+func TestReminder_Check(t *testing.T) {
+	store := NewStore(t.TempDir())
+	u := User{Channel: "telegram", ChatID: "42", SenderID: "42"}
+	store.Define(u, "run", Daily, "20:00")
+	store.Define(u, "stretch", Daily, "20:00")
+	store.Define(u, "review", Weekly, "20:00")
+	store.Complete(u, "stretch", day("2026-10-15"))
+
+	msgBus := bus.NewMessageBus()
+	r := NewReminder(store, msgBus)
+
+	thursday := time.Date(2026, 10, 15, 20, 30, 0, 0, time.Local)
+	r.Check(thursday.Add(-time.Hour)) // before remind_at
+	r.Check(thursday)
+	r.Check(thursday.Add(30 * time.Minute)) // already reminded today
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, ok := msgBus.SubscribeOutbound(ctx)
+	if !ok || msg.ChatID != "42" {
+		t.Fatalf("reminder = %+v, %v", msg, ok)
+	}
+	if !strings.Contains(msg.Content, "run not logged") {
+		t.Errorf("reminder content = %q", msg.Content)
+	}
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel2()
+	if extra, ok := msgBus.SubscribeOutbound(ctx2); ok {
+		t.Errorf("unexpected second reminder: %+v", extra)
+	}
+}
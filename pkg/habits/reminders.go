@@ -0,0 +1,88 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package habits
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/constants"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/templates"
+)
+
+// Reminder nudges users about habits they haven't done yet. It runs on the
+// heartbeat, so a reminder arrives within one heartbeat interval after the
+// habit's remind_at time, at most once a day. Weekly habits are only
+// nudged on Sundays, the last day of their week.
+type Reminder struct {
+	store *Store
+	bus   *bus.MessageBus
+}
+
+// NewReminder creates a reminder for the habits in store.
+func NewReminder(store *Store, msgBus *bus.MessageBus) *Reminder {
+	return &Reminder{store: store, bus: msgBus}
+}
+
+// Check sends each user one message listing the habits that are due.
+func (r *Reminder) Check(now time.Time) {
+	today := now.Format(dateFormat)
+	clock := now.Format("15:04")
+
+	err := r.store.update(func(u User, habits []Habit) bool {
+		if constants.IsInternalChannel(u.Channel) || u.ChatID == "" {
+			return false
+		}
+
+		var due []string
+		for i := range habits {
+			h := &habits[i]
+			if h.RemindAt == "" || clock < h.RemindAt || h.LastReminded == today {
+				continue
+			}
+			if h.Frequency == Weekly && now.Weekday() != time.Sunday {
+				continue
+			}
+			if ComputeStats(*h, now).DoneNow {
+				continue
+			}
+			h.LastReminded = today
+			due = append(due, h.Name)
+		}
+		if len(due) == 0 {
+			return false
+		}
+
+		r.bus.PublishOutbound(bus.OutboundMessage{
+			Channel: u.Channel,
+			ChatID:  u.ChatID,
+			Content: templates.Render(r.store.workspace, templates.Message{
+				Kind:    templates.KindHabitReminder,
+				Channel: u.Channel,
+				ChatID:  u.ChatID,
+				Now:     now,
+				Content: reminderText(due),
+				Data:    due,
+			}),
+			Proactive: bus.ProactiveNudge,
+			Alert:     bus.AlertReminder,
+		})
+		return true
+	})
+	if err != nil {
+		logger.WarnCF("habits", "Habit reminder check failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
+
+func reminderText(due []string) string {
+	return fmt.Sprintf("🌱 Gentle nudge: %s not logged yet. No pressure, just tell me when it's done and I'll mark it.",
+		strings.Join(due, ", "))
+}
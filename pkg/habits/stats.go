@@ -0,0 +1,81 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package habits
+
+import "time"
+
+// Stats summarizes a habit's history as of a given day. Periods are days
+// for daily habits and ISO weeks for weekly ones.
+type Stats struct {
+	CurrentStreak int     // consecutive periods done, up to now
+	LongestStreak int     // longest run of consecutive periods done
+	DoneNow       bool    // done in the current period
+	Total         int     // days the habit was done
+	Rate30        float64 // share of the last 30 days (or 4 weeks) done
+}
+
+// ComputeStats works out the streaks and completion rate of h as of now.
+// A streak stays current while the current period is still open, so a
+// daily habit done yesterday but not yet today keeps its streak.
+func ComputeStats(h Habit, now time.Time) Stats {
+	done := make(map[int]bool)
+	for _, date := range h.Done {
+		day, err := time.ParseInLocation(dateFormat, date, now.Location())
+		if err != nil {
+			continue
+		}
+		done[periodIndex(h.Frequency, day)] = true
+	}
+
+	st := Stats{Total: len(h.Done)}
+	current := periodIndex(h.Frequency, now)
+	st.DoneNow = done[current]
+
+	start := current
+	if !st.DoneNow {
+		start--
+	}
+	for p := start; done[p]; p-- {
+		st.CurrentStreak++
+	}
+
+	for p := range done {
+		if done[p-1] {
+			continue // not the start of a run
+		}
+		run := 1
+		for done[p+run] {
+			run++
+		}
+		if run > st.LongestStreak {
+			st.LongestStreak = run
+		}
+	}
+
+	window := 30
+	if h.Frequency == Weekly {
+		window = 4
+	}
+	hits := 0
+	for p := current - window + 1; p <= current; p++ {
+		if done[p] {
+			hits++
+		}
+	}
+	st.Rate30 = float64(hits) / float64(window)
+	return st
+}
+
+// periodIndex numbers days, or Monday-based weeks for weekly habits, so
+// consecutive periods have consecutive indexes.
+func periodIndex(frequency string, t time.Time) int {
+	days := int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400)
+	if frequency == Weekly {
+		// 1970-01-01 was a Thursday; shift so weeks start on Monday
+		return (days + 3) / 7
+	}
+	return days
+}
@@ -0,0 +1,252 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package habits tracks habits users define in chat: completions logged
+// by day, streak statistics and gentle reminders for habits not yet done.
+package habits
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Habit frequencies.
+const (
+	Daily  = "daily"
+	Weekly = "weekly"
+)
+
+const dateFormat = "2006-01-02"
+
+// Habit is one habit of one user. Done holds the dates it was completed
+// on, sorted and without duplicates.
+type Habit struct {
+	Name         string    `json:"name"`
+	Frequency    string    `json:"frequency"`
+	RemindAt     string    `json:"remind_at,omitempty"` // "HH:MM" local time, empty for no reminder
+	Created      time.Time `json:"created"`
+	Done         []string  `json:"done,omitempty"`
+	LastReminded string    `json:"last_reminded,omitempty"`
+}
+
+// DoneOn reports whether the habit was completed on day.
+func (h Habit) DoneOn(day time.Time) bool {
+	date := day.Format(dateFormat)
+	i := sort.SearchStrings(h.Done, date)
+	return i < len(h.Done) && h.Done[i] == date
+}
+
+// User identifies whose habits these are and where reminders go.
+type User struct {
+	Channel  string `json:"channel"`
+	ChatID   string `json:"chat_id"`
+	SenderID string `json:"sender_id"`
+}
+
+type userData struct {
+	User   User    `json:"user"`
+	Habits []Habit `json:"habits"`
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// Store keeps each user's habits in workspace/habits/<channel>_<sender>.json.
+type Store struct {
+	workspace string
+	dir       string
+	mu        sync.Mutex
+}
+
+// NewStore creates a habit store for a workspace.
+func NewStore(workspace string) *Store {
+	return &Store{workspace: workspace, dir: filepath.Join(workspace, "habits")}
+}
+
+// Define adds a habit, or updates the frequency and reminder time of an
+// existing one with the same name. It returns true when the habit is new.
+func (s *Store) Define(u User, name, frequency, remindAt string) (bool, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return false, fmt.Errorf("habit name is required")
+	}
+	if frequency == "" {
+		frequency = Daily
+	}
+	if frequency != Daily && frequency != Weekly {
+		return false, fmt.Errorf("frequency must be %q or %q", Daily, Weekly)
+	}
+	if remindAt != "" {
+		if _, err := time.Parse("15:04", remindAt); err != nil {
+			return false, fmt.Errorf("remind_at must be HH:MM, got %q", remindAt)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := s.load(u)
+	if i := findHabit(data.Habits, name); i >= 0 {
+		data.Habits[i].Frequency = frequency
+		data.Habits[i].RemindAt = remindAt
+		return false, s.save(data)
+	}
+	data.Habits = append(data.Habits, Habit{
+		Name:      name,
+		Frequency: frequency,
+		RemindAt:  remindAt,
+		Created:   time.Now(),
+	})
+	return true, s.save(data)
+}
+
+// Remove deletes a habit and its history.
+func (s *Store) Remove(u User, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := s.load(u)
+	i := findHabit(data.Habits, name)
+	if i < 0 {
+		return fmt.Errorf("no habit named %q", name)
+	}
+	data.Habits = append(data.Habits[:i], data.Habits[i+1:]...)
+	return s.save(data)
+}
+
+// Complete logs the habit as done on day and returns it.
+func (s *Store) Complete(u User, name string, day time.Time) (Habit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := s.load(u)
+	i := findHabit(data.Habits, name)
+	if i < 0 {
+		return Habit{}, fmt.Errorf("no habit named %q", name)
+	}
+	h := &data.Habits[i]
+	if !h.DoneOn(day) {
+		h.Done = append(h.Done, day.Format(dateFormat))
+		sort.Strings(h.Done)
+	}
+	return *h, s.save(data)
+}
+
+// Habits returns the user's habits in the order they were defined.
+func (s *Store) Habits(u User) []Habit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(u).Habits
+}
+
+// update rewrites every user's habits with fn, for the reminder check.
+// fn returns false when nothing changed.
+func (s *Store) update(fn func(u User, habits []Habit) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var data userData
+		if err := json.Unmarshal(raw, &data); err != nil {
+			continue
+		}
+		if fn(data.User, data.Habits) {
+			if err := s.save(data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Name implements privacy.Store.
+func (s *Store) Name() string {
+	return "habits"
+}
+
+// Purge implements privacy.Store by deleting the habits of every chat
+// account with the sender ID userID.
+func (s *Store) Purge(userID string, dryRun bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var data userData
+		if err := json.Unmarshal(raw, &data); err != nil || data.User.SenderID != userID {
+			continue
+		}
+		removed += len(data.Habits)
+		if dryRun {
+			continue
+		}
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+func findHabit(habits []Habit, name string) int {
+	for i, h := range habits {
+		if strings.EqualFold(h.Name, strings.TrimSpace(name)) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Store) path(u User) string {
+	return filepath.Join(s.dir, unsafeFileChars.ReplaceAllString(u.Channel+"_"+u.SenderID, "_")+".json")
+}
+
+// load reads the user's habits. The chat is taken from u, so reminders go
+// to where the user last talked about their habits.
+func (s *Store) load(u User) userData {
+	var data userData
+	if raw, err := os.ReadFile(s.path(u)); err == nil {
+		json.Unmarshal(raw, &data)
+	}
+	data.User = u
+	return data
+}
+
+func (s *Store) save(data userData) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := s.path(data.User)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -11,6 +11,7 @@ import (
 
 type Server struct {
 	server    *http.Server
+	mux       *http.ServeMux
 	mu        sync.RWMutex
 	ready     bool
 	checks    map[string]Check
@@ -33,6 +34,7 @@ type StatusResponse struct {
 func NewServer(host string, port int) *Server {
 	mux := http.NewServeMux()
 	s := &Server{
+		mux:       mux,
 		ready:     false,
 		checks:    make(map[string]Check),
 		startTime: time.Now(),
@@ -52,18 +54,20 @@ func NewServer(host string, port int) *Server {
 	return s
 }
 
+// Handle serves pattern with handler alongside the health endpoints. It
+// must be called before Start.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Start serves the endpoints. /ready reports not ready until SetReady, so
+// orchestrators such as Kubernetes only route traffic once the caller has
+// finished starting up.
 func (s *Server) Start() error {
-	s.mu.Lock()
-	s.ready = true
-	s.mu.Unlock()
 	return s.server.ListenAndServe()
 }
 
 func (s *Server) StartContext(ctx context.Context) error {
-	s.mu.Lock()
-	s.ready = true
-	s.mu.Unlock()
-
 	errCh := make(chan error, 1)
 	go func() {
 		errCh <- s.server.ListenAndServe()
@@ -103,6 +107,25 @@ func (s *Server) RegisterCheck(name string, checkFn func() (bool, string)) {
 	}
 }
 
+// RegisterWarning records a check that shows in /ready without making the
+// server not ready: a failure is reported with status "warn".
+func (s *Server) RegisterWarning(name string, checkFn func() (bool, string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status, msg := checkFn()
+	check := Check{
+		Name:      name,
+		Status:    statusString(status),
+		Message:   msg,
+		Timestamp: time.Now(),
+	}
+	if !status {
+		check.Status = "warn"
+	}
+	s.checks[name] = check
+}
+
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
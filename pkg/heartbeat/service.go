@@ -18,6 +18,7 @@ import (
 	"github.com/sipeed/picoclaw/pkg/constants"
 	"github.com/sipeed/picoclaw/pkg/logger"
 	"github.com/sipeed/picoclaw/pkg/state"
+	"github.com/sipeed/picoclaw/pkg/templates"
 	"github.com/sipeed/picoclaw/pkg/tools"
 )
 
@@ -31,12 +32,18 @@ const (
 // channel and chatID are derived from the last active user channel.
 type HeartbeatHandler func(prompt, channel, chatID string) *tools.ToolResult
 
+// NoteFunc returns a note about the chat for the briefing, or "" when it
+// has nothing to add.
+type NoteFunc func(channel, chatID string, now time.Time) string
+
 // HeartbeatService manages periodic heartbeat checks
 type HeartbeatService struct {
 	workspace string
 	bus       *bus.MessageBus
 	state     *state.Manager
 	handler   HeartbeatHandler
+	onBeat    []func(now time.Time)
+	notes     []NoteFunc
 	interval  time.Duration
 	enabled   bool
 	mu        sync.RWMutex
@@ -76,6 +83,24 @@ func (hs *HeartbeatService) SetHandler(handler HeartbeatHandler) {
 	hs.handler = handler
 }
 
+// OnBeat registers fn to run on every heartbeat, before the HEARTBEAT.md
+// check and whether or not there are tasks in it. It suits periodic checks
+// that don't need the agent, such as reminders.
+func (hs *HeartbeatService) OnBeat(fn func(now time.Time)) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.onBeat = append(hs.onBeat, fn)
+}
+
+// AddNote registers fn to add a note to every heartbeat prompt, such as
+// the user's wellness summary, which the agent can work into the
+// briefing it sends.
+func (hs *HeartbeatService) AddNote(fn NoteFunc) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.notes = append(hs.notes, fn)
+}
+
 // Start begins the heartbeat service
 func (hs *HeartbeatService) Start() error {
 	hs.mu.Lock()
@@ -147,6 +172,8 @@ func (hs *HeartbeatService) executeHeartbeat() {
 	hs.mu.RLock()
 	enabled := hs.enabled
 	handler := hs.handler
+	onBeat := hs.onBeat
+	notes := hs.notes
 	if !hs.enabled || hs.stopChan == nil {
 		hs.mu.RUnlock()
 		return
@@ -159,6 +186,11 @@ func (hs *HeartbeatService) executeHeartbeat() {
 
 	logger.DebugC("heartbeat", "Executing heartbeat")
 
+	now := time.Now()
+	for _, fn := range onBeat {
+		fn(now)
+	}
+
 	prompt := hs.buildPrompt()
 	if prompt == "" {
 		logger.InfoC("heartbeat", "No heartbeat prompt (HEARTBEAT.md empty or missing)")
@@ -177,6 +209,10 @@ func (hs *HeartbeatService) executeHeartbeat() {
 	// Debug log for channel resolution
 	hs.logInfo("Resolved channel: %s, chatID: %s (from lastChannel: %s)", channel, chatID, lastChannel)
 
+	if chatID != "" {
+		prompt += formatNotes(notes, channel, chatID, now)
+	}
+
 	result := handler(prompt, channel, chatID)
 
 	if result == nil {
@@ -215,6 +251,20 @@ func (hs *HeartbeatService) executeHeartbeat() {
 	hs.logInfo("Heartbeat completed: %s", result.ForLLM)
 }
 
+// formatNotes collects the notes about the chat into a prompt section.
+func formatNotes(notes []NoteFunc, channel, chatID string, now time.Time) string {
+	var sb strings.Builder
+	for _, fn := range notes {
+		if note := fn(channel, chatID, now); note != "" {
+			sb.WriteString("\n- " + note)
+		}
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	return "\n\n## Notes for the briefing\n\nMention these briefly if you send the user anything:" + sb.String() + "\n"
+}
+
 // buildPrompt builds the heartbeat prompt from HEARTBEAT.md
 func (hs *HeartbeatService) buildPrompt() string {
 	heartbeatPath := filepath.Join(hs.workspace, "HEARTBEAT.md")
@@ -310,7 +360,14 @@ func (hs *HeartbeatService) sendResponse(response string) {
 	msgBus.PublishOutbound(bus.OutboundMessage{
 		Channel: platform,
 		ChatID:  userID,
-		Content: response,
+		Content: templates.Render(hs.workspace, templates.Message{
+			Kind:    templates.KindBriefing,
+			Channel: platform,
+			ChatID:  userID,
+			Content: response,
+			Data:    response,
+		}),
+		Proactive: bus.ProactiveBriefing,
 	})
 
 	hs.logInfo("Heartbeat result sent to %s", platform)
@@ -3,6 +3,7 @@ package heartbeat
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -219,3 +220,43 @@ func TestHeartbeatFilePath(t *testing.T) {
 		t.Errorf("Expected HEARTBEAT.md at %s, but it doesn't exist", expectedPath)
 	}
 }
+
+func TestExecuteHeartbeat_OnBeat(t *testing.T) {
+	hs := NewHeartbeatService(t.TempDir(), 30, true)
+	hs.stopChan = make(chan struct{}) // Enable for testing
+
+	// Hooks run even without HEARTBEAT.md tasks
+	beats := 0
+	hs.OnBeat(func(now time.Time) { beats++ })
+	hs.executeHeartbeat()
+
+	if beats != 1 {
+		t.Errorf("OnBeat ran %d times, want 1", beats)
+	}
+}
+
+func TestExecuteHeartbeat_AddsNotes(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "HEARTBEAT.md"), []byte("- Send the morning briefing"), 0644)
+	hs := NewHeartbeatService(dir, 30, true)
+	hs.stopChan = make(chan struct{}) // Enable for testing
+	hs.state.SetLastChannel("telegram:42")
+
+	hs.AddNote(func(channel, chatID string, now time.Time) string {
+		return "Wellness: slept 7.5h for " + channel + ":" + chatID
+	})
+	hs.AddNote(func(channel, chatID string, now time.Time) string { return "" })
+	var prompt string
+	hs.SetHandler(func(p, channel, chatID string) *tools.ToolResult {
+		prompt = p
+		return tools.SilentResult("HEARTBEAT_OK")
+	})
+	hs.executeHeartbeat()
+
+	if !strings.Contains(prompt, "## Notes for the briefing") || !strings.Contains(prompt, "- Wellness: slept 7.5h for telegram:42") {
+		t.Errorf("prompt has no wellness note:\n%s", prompt)
+	}
+	if strings.Count(prompt, "\n- ") != 2 {
+		t.Errorf("empty notes should be left out:\n%s", prompt)
+	}
+}
@@ -0,0 +1,330 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package identity links a person's accounts on different channels, so a
+// conversation started in a Discord DM can go on over Telegram. Accounts
+// are "channel:userID".
+package identity
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// linkCodeTTL is how long a link code can be redeemed.
+	linkCodeTTL = 15 * time.Minute
+	// linkCodeLength is the length of a link code. At 5 bits a character
+	// it is far beyond guessing within maxFailedRedeems tries.
+	linkCodeLength = 10
+	// linkCodeAlphabet leaves out characters that are easily confused.
+	linkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	// maxFailedRedeems is how many wrong codes an account can send within
+	// linkCodeTTL before it has to wait.
+	maxFailedRedeems = 5
+)
+
+var (
+	// ErrUnknownCode is returned for codes that were never issued or have
+	// expired.
+	ErrUnknownCode = errors.New("unknown or expired link code")
+	// ErrSameAccount is returned for redeeming a code on the account that
+	// asked for it.
+	ErrSameAccount = errors.New("redeem the code from your account on another channel")
+	// ErrTooManyAttempts is returned once an account sent too many wrong
+	// codes.
+	ErrTooManyAttempts = errors.New("too many wrong link codes, try again later")
+)
+
+// Identity is a person and the accounts they linked.
+type Identity struct {
+	Name     string    `json:"name"`
+	Accounts []string  `json:"accounts"`
+	Created  time.Time `json:"created"`
+}
+
+type linkCode struct {
+	Code    string    `json:"code"`
+	Account string    `json:"account"`
+	Created time.Time `json:"created"`
+}
+
+// failedRedeem is a wrong code sent by an account.
+type failedRedeem struct {
+	Account string    `json:"account"`
+	At      time.Time `json:"at"`
+}
+
+type storeState struct {
+	Identities []Identity     `json:"identities"`
+	Pending    []linkCode     `json:"pending,omitempty"`
+	Failed     []failedRedeem `json:"failed,omitempty"`
+}
+
+// Store keeps identities in workspace/state/identities.json. A user links
+// accounts by asking for a code on one channel and sending it from
+// another. The file is re-read on every call, so links made with the CLI
+// apply to a running gateway.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore returns the identity store of a workspace.
+func NewStore(workspace string) *Store {
+	return &Store{path: filepath.Join(workspace, "state", "identities.json")}
+}
+
+// Account returns the account key of a user on a channel. Compound sender
+// IDs ("123|alice") are reduced to the ID.
+func Account(channel, userID string) string {
+	if id, _, ok := strings.Cut(userID, "|"); ok && id != "" {
+		userID = id
+	}
+	return strings.ToLower(channel + ":" + userID)
+}
+
+// RequestCode returns a code that links account with the account that
+// redeems it. A code still pending for account is returned again.
+func (s *Store) RequestCode(account string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.load()
+	for _, p := range st.Pending {
+		if p.Account == account {
+			return p.Code, nil
+		}
+	}
+	code, err := newCode()
+	if err != nil {
+		return "", err
+	}
+	st.Pending = append(st.Pending, linkCode{Code: code, Account: account, Created: time.Now()})
+	return code, s.save(st)
+}
+
+// Redeem links account with the one a code was issued to. An account
+// that sent maxFailedRedeems wrong codes within linkCodeTTL is refused
+// until the oldest of them expires.
+func (s *Store) Redeem(code, account string) (Identity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	st := s.load()
+	failed := 0
+	for _, f := range st.Failed {
+		if f.Account == account {
+			failed++
+		}
+	}
+	if failed >= maxFailedRedeems {
+		return Identity{}, ErrTooManyAttempts
+	}
+	for i, p := range st.Pending {
+		if p.Code != code {
+			continue
+		}
+		if p.Account == account {
+			return Identity{}, ErrSameAccount
+		}
+		st.Pending = append(st.Pending[:i], st.Pending[i+1:]...)
+		id, err := link(&st, p.Account, account)
+		if err != nil {
+			return Identity{}, err
+		}
+		return id, s.save(st)
+	}
+	st.Failed = append(st.Failed, failedRedeem{Account: account, At: time.Now()})
+	if err := s.save(st); err != nil {
+		return Identity{}, err
+	}
+	return Identity{}, ErrUnknownCode
+}
+
+// Link links two accounts directly, for the owner.
+func (s *Store) Link(a, b string) (Identity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.load()
+	id, err := link(&st, a, b)
+	if err != nil {
+		return Identity{}, err
+	}
+	return id, s.save(st)
+}
+
+// Unlink removes an account from its identity, dropping identities left
+// with a single account. It reports whether the account was linked.
+func (s *Store) Unlink(account string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.load()
+	i, j := find(st, account)
+	if i < 0 {
+		return false, nil
+	}
+	accounts := st.Identities[i].Accounts
+	st.Identities[i].Accounts = append(accounts[:j:j], accounts[j+1:]...)
+	if len(st.Identities[i].Accounts) < 2 {
+		st.Identities = append(st.Identities[:i], st.Identities[i+1:]...)
+	}
+	return true, s.save(st)
+}
+
+// Lookup returns the identity an account belongs to.
+func (s *Store) Lookup(account string) (Identity, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.load()
+	if i, _ := find(st, account); i >= 0 {
+		return st.Identities[i], true
+	}
+	return Identity{}, false
+}
+
+// List returns the identities.
+func (s *Store) List() []Identity {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load().Identities
+}
+
+// Links returns each identity's accounts by name, in the form of the
+// session.identity_links config.
+func (s *Store) Links() map[string][]string {
+	identities := s.List()
+	if len(identities) == 0 {
+		return nil
+	}
+	links := make(map[string][]string, len(identities))
+	for _, id := range identities {
+		links[id.Name] = id.Accounts
+	}
+	return links
+}
+
+// link puts a and b in one identity: the one either already has, merging
+// them if both do, or a new one.
+func link(st *storeState, a, b string) (Identity, error) {
+	if a == b {
+		return Identity{}, ErrSameAccount
+	}
+	ia, _ := find(*st, a)
+	ib, _ := find(*st, b)
+	switch {
+	case ia >= 0 && ia == ib:
+		return st.Identities[ia], nil
+	case ia >= 0 && ib >= 0:
+		st.Identities[ia].Accounts = append(st.Identities[ia].Accounts, st.Identities[ib].Accounts...)
+		merged := st.Identities[ia]
+		st.Identities = append(st.Identities[:ib], st.Identities[ib+1:]...)
+		return merged, nil
+	case ia >= 0:
+		st.Identities[ia].Accounts = append(st.Identities[ia].Accounts, b)
+		return st.Identities[ia], nil
+	case ib >= 0:
+		st.Identities[ib].Accounts = append(st.Identities[ib].Accounts, a)
+		return st.Identities[ib], nil
+	}
+
+	name, err := newName()
+	if err != nil {
+		return Identity{}, err
+	}
+	id := Identity{Name: name, Accounts: []string{a, b}, Created: time.Now()}
+	st.Identities = append(st.Identities, id)
+	return id, nil
+}
+
+// find returns the index of the identity holding account and of the
+// account in it, or -1.
+func find(st storeState, account string) (int, int) {
+	for i, id := range st.Identities {
+		for j, a := range id.Accounts {
+			if a == account {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+// newCode returns a random link code such as "K7QM2XHD9R".
+func newCode() (string, error) {
+	b := make([]byte, linkCodeLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(linkCodeAlphabet))))
+		if err != nil {
+			return "", fmt.Errorf("generating link code: %w", err)
+		}
+		b[i] = linkCodeAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// newName returns a random identity name such as "id-3f9a1c2b".
+func newName() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating identity name: %w", err)
+	}
+	return "id-" + hex.EncodeToString(b), nil
+}
+
+// load reads the state, dropping expired codes and failed attempts.
+func (s *Store) load() storeState {
+	var st storeState
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return st
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return storeState{}
+	}
+	live := st.Pending[:0]
+	for _, p := range st.Pending {
+		if time.Since(p.Created) < linkCodeTTL {
+			live = append(live, p)
+		}
+	}
+	st.Pending = live
+	recent := st.Failed[:0]
+	for _, f := range st.Failed {
+		if time.Since(f.At) < linkCodeTTL {
+			recent = append(recent, f)
+		}
+	}
+	st.Failed = recent
+	return st
+}
+
+func (s *Store) save(st storeState) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,97 @@
+package identity
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStore_LinkWithCode(t *testing.T) {
+	s := NewStore(t.TempDir())
+	discord := Account("discord", "123")
+	telegram := Account("telegram", "456|alice")
+	if telegram != "telegram:456" {
+		t.Fatalf("Account() = %q", telegram)
+	}
+
+	code, err := s.RequestCode(discord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := s.RequestCode(discord); again != code {
+		t.Errorf("second request got a new code %s, want %s", again, code)
+	}
+	if _, err := s.Redeem(code, discord); !errors.Is(err, ErrSameAccount) {
+		t.Errorf("redeeming on the same account: err = %v", err)
+	}
+
+	id, err := s.Redeem(code, telegram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(id.Accounts) != 2 {
+		t.Errorf("Accounts = %v", id.Accounts)
+	}
+	if _, err := s.Redeem(code, Account("slack", "789")); !errors.Is(err, ErrUnknownCode) {
+		t.Errorf("code redeemed twice: err = %v", err)
+	}
+	if links := s.Links(); len(links[id.Name]) != 2 {
+		t.Errorf("Links() = %v", links)
+	}
+
+	// A third account joins the existing identity
+	slack := Account("slack", "789")
+	if joined, err := s.Link(slack, discord); err != nil || joined.Name != id.Name || len(joined.Accounts) != 3 {
+		t.Errorf("Link() = %+v, %v", joined, err)
+	}
+}
+
+func TestStore_LimitsWrongCodes(t *testing.T) {
+	s := NewStore(t.TempDir())
+	code, err := s.RequestCode("discord:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != linkCodeLength {
+		t.Errorf("code = %q", code)
+	}
+	for i := 0; i < maxFailedRedeems; i++ {
+		if _, err := s.Redeem("WRONGCODE"+string(rune('A'+i)), "telegram:2"); !errors.Is(err, ErrUnknownCode) {
+			t.Fatalf("wrong code %d: err = %v", i, err)
+		}
+	}
+	if _, err := s.Redeem(code, "telegram:2"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("right code after too many wrong ones: err = %v", err)
+	}
+	// Other accounts aren't held back
+	if _, err := s.Redeem(strings.ToLower(code), "slack:3"); err != nil {
+		t.Errorf("Redeem() = %v", err)
+	}
+}
+
+func TestStore_MergeAndUnlink(t *testing.T) {
+	s := NewStore(t.TempDir())
+	a, _ := s.Link("discord:1", "telegram:1")
+	b, _ := s.Link("slack:1", "email:1")
+	if a.Name == b.Name {
+		t.Fatal("separate links share an identity")
+	}
+
+	merged, err := s.Link("telegram:1", "email:1")
+	if err != nil || len(merged.Accounts) != 4 || len(s.List()) != 1 {
+		t.Fatalf("merge = %+v, %v; identities %v", merged, err, s.List())
+	}
+
+	for _, account := range []string{"discord:1", "telegram:1", "slack:1"} {
+		if ok, err := s.Unlink(account); !ok || err != nil {
+			t.Errorf("Unlink(%s) = %v, %v", account, ok, err)
+		}
+	}
+	// email:1 alone is no longer linked to anything
+	if len(s.List()) != 0 {
+		t.Errorf("identities left: %v", s.List())
+	}
+	if ok, _ := s.Unlink("email:1"); ok {
+		t.Error("unlinked an account that wasn't linked")
+	}
+}
@@ -0,0 +1,121 @@
+package journal
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+func TestAppendSection(t *testing.T) {
+	ws := t.TempDir()
+	day := time.Date(2026, 10, 15, 21, 0, 0, 0, time.Local)
+	path := NotePath(ws, day)
+	os.MkdirAll(strings.TrimSuffix(path, "20261015.md"), 0755)
+	os.WriteFile(path, []byte("# 2026-10-15\n\nBought milk.\n\n## Journal\n\nFirst entry.\n\n## Later\n\nkeep me\n"), 0644)
+
+	if err := appendSection(path, day, journalHeading, "Second entry."); err != nil {
+		t.Fatalf("appendSection: %v", err)
+	}
+	if err := appendSection(path, day, reflectionHeading, "A good week."); err != nil {
+		t.Fatalf("appendSection: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	want := "# 2026-10-15\n\nBought milk.\n\n## Journal\n\nFirst entry.\n\nSecond entry.\n\n## Later\n\nkeep me\n\n## Weekly Reflection\n\nA good week.\n"
+	if string(data) != want {
+		t.Errorf("note =\n%s\nwant\n%s", data, want)
+	}
+	if got := section(string(data), journalHeading); got != "First entry.\n\nSecond entry." {
+		t.Errorf("section = %q", got)
+	}
+}
+
+func TestStoreSave(t *testing.T) {
+	ws := t.TempDir()
+	store := NewStore(ws)
+	u := User{Channel: "telegram", ChatID: "42", SenderID: "42"}
+	store.Subscribe(u)
+
+	evening := time.Date(2026, 10, 14, 21, 0, 0, 0, time.Local)
+	store.update(func(sub *Subscriber) bool {
+		sub.PromptedAt = evening
+		return true
+	})
+	if _, ok := store.Pending(u, evening.Add(time.Hour)); !ok {
+		t.Fatal("prompt should be pending")
+	}
+
+	// An answer after midnight goes to the evening's note
+	day, err := store.Save(u, []Entry{{Question: "What went well today?", Answer: "Finished the report."}, {Answer: " "}}, evening.Add(4*time.Hour))
+	if err != nil || day.Format(dateFormat) != "2026-10-14" {
+		t.Fatalf("Save = %v, %v", day, err)
+	}
+	if _, ok := store.Pending(u, evening.Add(5*time.Hour)); ok {
+		t.Error("prompt still pending after saving")
+	}
+	data, _ := os.ReadFile(NotePath(ws, evening))
+	if !strings.Contains(string(data), "## Journal\n\n**What went well today?**\nFinished the report.\n") {
+		t.Errorf("note = %q", data)
+	}
+
+	if removed, _ := store.Unsubscribe(u); !removed {
+		t.Error("Unsubscribe reported no subscription")
+	}
+}
+
+func TestPrompterCheck(t *testing.T) {
+	ws := t.TempDir()
+	store := NewStore(ws)
+	u := User{Channel: "telegram", ChatID: "42", SenderID: "42"}
+	store.Subscribe(u)
+	saturday := time.Date(2026, 10, 17, 20, 0, 0, 0, time.Local)
+	store.Save(u, []Entry{{Answer: "Long walk by the sea."}}, saturday)
+
+	reflected := make(chan string, 1)
+	summarize := func(ctx context.Context, prompt string, got User) (string, error) {
+		reflected <- prompt
+		return "You slowed down this week.", nil
+	}
+	msgBus := bus.NewMessageBus()
+	p := NewPrompter(store, msgBus, []string{"What went well today?"}, 21, time.Sunday, 22, summarize)
+
+	sunday := time.Date(2026, 10, 18, 21, 30, 0, 0, time.Local)
+	p.Check(sunday.Add(-time.Hour)) // before the prompt hour
+	p.Check(sunday)
+	p.Check(sunday.Add(10 * time.Minute)) // already prompted today
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, ok := msgBus.SubscribeOutbound(ctx)
+	if !ok || msg.ChatID != "42" || !strings.Contains(msg.Content, "1. What went well today?") {
+		t.Fatalf("prompt = %+v, %v", msg, ok)
+	}
+
+	p.Check(sunday.Add(time.Hour)) // summary hour
+	select {
+	case prompt := <-reflected:
+		if !strings.Contains(prompt, "### 2026-10-17\nLong walk by the sea.") {
+			t.Errorf("reflection prompt = %q", prompt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no weekly reflection")
+	}
+	msg, ok = msgBus.SubscribeOutbound(ctx)
+	if !ok || !strings.Contains(msg.Content, "You slowed down this week.") {
+		t.Fatalf("reflection = %+v, %v", msg, ok)
+	}
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel2()
+	p.Check(sunday.Add(90 * time.Minute))
+	if extra, ok := msgBus.SubscribeOutbound(ctx2); ok {
+		t.Errorf("unexpected message: %+v", extra)
+	}
+	data, _ := os.ReadFile(NotePath(ws, sunday))
+	if !strings.Contains(string(data), "## Weekly Reflection\n\nYou slowed down this week.") {
+		t.Errorf("reflection not saved: %q", data)
+	}
+}
@@ -0,0 +1,143 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package journal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	journalHeading    = "## Journal"
+	reflectionHeading = "## Weekly Reflection"
+)
+
+// Entry is one journal answer. Question is empty for free-form entries.
+type Entry struct {
+	Question string `json:"question,omitempty"`
+	Answer   string `json:"answer"`
+}
+
+// Day is the Journal section of one daily note.
+type Day struct {
+	Date string
+	Text string
+}
+
+// NotePath returns the daily note for day, memory/YYYYMM/YYYYMMDD.md, the
+// same file the agent's memory uses.
+func NotePath(workspace string, day time.Time) string {
+	date := day.Format("20060102")
+	return filepath.Join(workspace, "memory", date[:6], date+".md")
+}
+
+// WriteReflection adds a weekly reflection to day's note.
+func WriteReflection(workspace string, day time.Time, text string) error {
+	return appendSection(NotePath(workspace, day), day, reflectionHeading, strings.TrimSpace(text))
+}
+
+// AppendToNote adds body to the section under heading in day's note, for
+// other features that log to the daily note.
+func AppendToNote(workspace string, day time.Time, heading, body string) error {
+	return appendSection(NotePath(workspace, day), day, heading, strings.TrimSpace(body))
+}
+
+// Week returns the Journal sections of the seven days ending with end,
+// oldest first, skipping days without one.
+func Week(workspace string, end time.Time) []Day {
+	var days []Day
+	for i := 6; i >= 0; i-- {
+		day := end.AddDate(0, 0, -i)
+		data, err := os.ReadFile(NotePath(workspace, day))
+		if err != nil {
+			continue
+		}
+		if text := section(string(data), journalHeading); text != "" {
+			days = append(days, Day{Date: day.Format(dateFormat), Text: text})
+		}
+	}
+	return days
+}
+
+func formatEntries(entries []Entry) string {
+	var sb strings.Builder
+	for _, e := range entries {
+		answer := strings.TrimSpace(e.Answer)
+		if answer == "" {
+			continue
+		}
+		if q := strings.TrimSpace(e.Question); q != "" {
+			fmt.Fprintf(&sb, "**%s**\n", q)
+		}
+		sb.WriteString(answer)
+		sb.WriteString("\n\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// appendSection adds body to the section under heading in the note at
+// path, creating the note (with the date header the agent's memory uses)
+// and the section as needed.
+func appendSection(path string, day time.Time, heading, body string) error {
+	if body == "" {
+		return fmt.Errorf("nothing to write")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var content string
+	if data, err := os.ReadFile(path); err == nil {
+		content = string(data)
+	}
+	if content == "" {
+		content = fmt.Sprintf("# %s\n", day.Format(dateFormat))
+	}
+
+	start, end := sectionBounds(content, heading)
+	if start < 0 {
+		content = strings.TrimRight(content, "\n") + "\n\n" + heading + "\n\n" + body + "\n"
+	} else {
+		before := strings.TrimRight(content[:end], "\n")
+		after := content[end:]
+		content = before + "\n\n" + body + "\n"
+		if after != "" {
+			content += "\n" + after
+		}
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// section returns the text under heading, without the heading.
+func section(content, heading string) string {
+	start, end := sectionBounds(content, heading)
+	if start < 0 {
+		return ""
+	}
+	return strings.TrimSpace(content[start+len(heading) : end])
+}
+
+// sectionBounds finds the line holding heading and the start of the next
+// heading of the same or a higher level, or the end of content.
+func sectionBounds(content, heading string) (start, end int) {
+	start = -1
+	offset := 0
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if start < 0 {
+			if trimmed == heading {
+				start = offset
+			}
+		} else if strings.HasPrefix(trimmed, "# ") || strings.HasPrefix(trimmed, "## ") {
+			return start, offset
+		}
+		offset += len(line)
+	}
+	return start, len(content)
+}
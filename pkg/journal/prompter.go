@@ -0,0 +1,165 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package journal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/constants"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/templates"
+)
+
+// summaryTimeout bounds writing one weekly reflection.
+const summaryTimeout = 5 * time.Minute
+
+// SummarizeFunc asks the agent to answer prompt for u, e.g. through
+// AgentLoop.ProcessHeartbeat.
+type SummarizeFunc func(ctx context.Context, prompt string, u User) (string, error)
+
+// Prompter sends the evening journal prompt and the weekly reflection.
+// It runs on the heartbeat, so both arrive within one heartbeat interval
+// after their hour, at most once a day.
+type Prompter struct {
+	store       *Store
+	bus         *bus.MessageBus
+	questions   []string
+	promptHour  int
+	summaryDay  time.Weekday
+	summaryHour int
+	summarize   SummarizeFunc
+}
+
+// NewPrompter creates a prompter for the subscribers in store. A nil
+// summarize turns the weekly reflection off.
+func NewPrompter(store *Store, msgBus *bus.MessageBus, questions []string, promptHour int,
+	summaryDay time.Weekday, summaryHour int, summarize SummarizeFunc,
+) *Prompter {
+	return &Prompter{
+		store:       store,
+		bus:         msgBus,
+		questions:   questions,
+		promptHour:  promptHour,
+		summaryDay:  summaryDay,
+		summaryHour: summaryHour,
+		summarize:   summarize,
+	}
+}
+
+// Check sends the prompt and starts the weekly reflection for every
+// subscriber that is due.
+func (p *Prompter) Check(now time.Time) {
+	today := now.Format(dateFormat)
+	var reflect []User
+
+	err := p.store.update(func(sub *Subscriber) bool {
+		if constants.IsInternalChannel(sub.Channel) || sub.ChatID == "" {
+			return false
+		}
+		changed := false
+
+		if now.Hour() >= p.promptHour && sub.PromptedAt.Format(dateFormat) != today {
+			p.bus.PublishOutbound(bus.OutboundMessage{
+				Channel:   sub.Channel,
+				ChatID:    sub.ChatID,
+				Content:   PromptText(p.questions),
+				Proactive: bus.ProactiveNudge,
+			})
+			sub.PromptedAt = now
+			changed = true
+		}
+
+		if p.summarize != nil && now.Weekday() == p.summaryDay && now.Hour() >= p.summaryHour && sub.SummarizedOn != today {
+			sub.SummarizedOn = today
+			reflect = append(reflect, sub.User)
+			changed = true
+		}
+		return changed
+	})
+	if err != nil {
+		logger.WarnCF("journal", "Journal prompt check failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if len(reflect) == 0 {
+		return
+	}
+	days := Week(p.store.workspace, now)
+	if len(days) == 0 {
+		return
+	}
+	// The agent can take a while; don't hold up the heartbeat
+	go func() {
+		for _, u := range reflect {
+			p.reflect(u, days, now)
+		}
+	}()
+}
+
+// reflect has the agent write the weekly reflection, then sends it to u
+// and adds it to today's note.
+func (p *Prompter) reflect(u User, days []Day, now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), summaryTimeout)
+	defer cancel()
+
+	text, err := p.summarize(ctx, ReflectionPrompt(days), u)
+	if err == nil && strings.TrimSpace(text) == "" {
+		err = fmt.Errorf("empty reflection")
+	}
+	if err != nil {
+		logger.WarnCF("journal", "Weekly reflection failed", map[string]interface{}{
+			"channel": u.Channel,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := WriteReflection(p.store.workspace, now, text); err != nil {
+		logger.WarnCF("journal", "Failed to save weekly reflection", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	p.bus.PublishOutbound(bus.OutboundMessage{
+		Channel: u.Channel,
+		ChatID:  u.ChatID,
+		Content: templates.Render(p.store.workspace, templates.Message{
+			Kind:    templates.KindJournalReflection,
+			Channel: u.Channel,
+			ChatID:  u.ChatID,
+			Now:     now,
+			Content: "📓 Your week in review\n\n" + strings.TrimSpace(text),
+			Data:    strings.TrimSpace(text),
+		}),
+		Proactive: bus.ProactiveDigest,
+	})
+}
+
+// PromptText is the evening prompt listing questions.
+func PromptText(questions []string) string {
+	var sb strings.Builder
+	sb.WriteString("📓 Time for your evening journal. Answer as much or as little as you like:\n")
+	for i, q := range questions {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, q)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// ReflectionPrompt asks the agent to reflect on a week of entries.
+func ReflectionPrompt(days []Day) string {
+	var sb strings.Builder
+	sb.WriteString("Write a short, warm reflection on my journal from the past week, in the second person. ")
+	sb.WriteString("Note recurring themes, what went well, what was hard and anything worth carrying into next week. ")
+	sb.WriteString("Don't repeat the entries back, don't call any tools, and keep it under 200 words.\n\n")
+	for _, d := range days {
+		fmt.Fprintf(&sb, "### %s\n%s\n\n", d.Date, d.Text)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,198 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package journal runs guided journaling: an evening prompt with a few
+// questions, answers saved to the day's note under a Journal section and a
+// reflective summary at the end of the week.
+package journal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const dateFormat = "2006-01-02"
+
+// answerWindow is how long after a prompt a reply still counts as its
+// answer, so a reply after midnight lands in the evening's note.
+const answerWindow = 12 * time.Hour
+
+// User identifies a journaling user and the chat prompts go to.
+type User struct {
+	Channel  string `json:"channel"`
+	ChatID   string `json:"chat_id"`
+	SenderID string `json:"sender_id"`
+}
+
+func (u User) key() string {
+	return u.Channel + ":" + u.SenderID
+}
+
+// Subscriber is a user who opted in to the evening prompt.
+type Subscriber struct {
+	User
+	PromptedAt   time.Time `json:"prompted_at,omitempty"`
+	AnsweredAt   time.Time `json:"answered_at,omitempty"`
+	SummarizedOn string    `json:"summarized_on,omitempty"` // date of the last weekly summary
+}
+
+// pending reports whether the last prompt is still waiting for an answer.
+func (s Subscriber) pending(now time.Time) bool {
+	return !s.PromptedAt.IsZero() && s.AnsweredAt.Before(s.PromptedAt) && now.Sub(s.PromptedAt) < answerWindow
+}
+
+// Store keeps journaling subscribers in workspace/journal/subscribers.json
+// and writes entries to the daily notes in workspace/memory.
+type Store struct {
+	workspace string
+	mu        sync.Mutex
+}
+
+// NewStore creates a journal store for a workspace.
+func NewStore(workspace string) *Store {
+	return &Store{workspace: workspace}
+}
+
+// Subscribe opts the user in to the evening prompt. Subscribing again only
+// moves prompts to the chat given in u.
+func (s *Store) Subscribe(u User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.load()
+	for i := range subs {
+		if subs[i].key() == u.key() {
+			subs[i].User = u
+			return s.save(subs)
+		}
+	}
+	return s.save(append(subs, Subscriber{User: u}))
+}
+
+// Unsubscribe stops the user's prompts. It returns false when the user
+// wasn't subscribed.
+func (s *Store) Unsubscribe(u User) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.load()
+	for i := range subs {
+		if subs[i].key() == u.key() {
+			return true, s.save(append(subs[:i], subs[i+1:]...))
+		}
+	}
+	return false, nil
+}
+
+// Pending returns the day of a prompt the user hasn't answered yet.
+func (s *Store) Pending(u User, now time.Time) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.load() {
+		if sub.key() == u.key() && sub.pending(now) {
+			return sub.PromptedAt, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Save writes entries to the journal of the pending prompt's day, or of
+// now when no prompt is pending, and marks the prompt answered. It returns
+// the day written to.
+func (s *Store) Save(u User, entries []Entry, now time.Time) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.load()
+	day := now
+	for i := range subs {
+		if subs[i].key() == u.key() {
+			if subs[i].pending(now) {
+				day = subs[i].PromptedAt
+			}
+			subs[i].AnsweredAt = now
+		}
+	}
+	if err := appendSection(NotePath(s.workspace, day), day, journalHeading, formatEntries(entries)); err != nil {
+		return day, err
+	}
+	return day, s.save(subs)
+}
+
+// update rewrites the subscribers with fn, for the prompter. fn returns
+// false when nothing changed.
+func (s *Store) update(fn func(sub *Subscriber) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.load()
+	changed := false
+	for i := range subs {
+		if fn(&subs[i]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.save(subs)
+}
+
+// Name implements privacy.Store.
+func (s *Store) Name() string {
+	return "journal subscriptions"
+}
+
+// Purge implements privacy.Store by unsubscribing every chat account with
+// the sender ID userID. Entries are in the daily notes, which the purger
+// scrubs itself.
+func (s *Store) Purge(userID string, dryRun bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.load()
+	kept := subs[:0:0]
+	for _, sub := range subs {
+		if sub.SenderID != userID {
+			kept = append(kept, sub)
+		}
+	}
+	removed := len(subs) - len(kept)
+	if removed == 0 || dryRun {
+		return removed, nil
+	}
+	return removed, s.save(kept)
+}
+
+func (s *Store) path() string {
+	return filepath.Join(s.workspace, "journal", "subscribers.json")
+}
+
+func (s *Store) load() []Subscriber {
+	var subs []Subscriber
+	if raw, err := os.ReadFile(s.path()); err == nil {
+		json.Unmarshal(raw, &subs)
+	}
+	return subs
+}
+
+func (s *Store) save(subs []Subscriber) error {
+	if err := os.MkdirAll(filepath.Dir(s.path()), 0755); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(subs, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := s.path() + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path())
+}
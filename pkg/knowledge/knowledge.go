@@ -0,0 +1,341 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package knowledge is a small retrieval index over community sources,
+// such as a server's pinned messages and FAQ channel, used to answer
+// repeat questions with citations. Matching is lexical: a source's
+// confidence for a question is the share of the question's terms,
+// weighted by how rare they are, that the source contains.
+package knowledge
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// saveDelay is how long changes wait before the index is written, so a
+// busy channel's messages are saved together rather than one by one.
+const saveDelay = 5 * time.Second
+
+// Source kinds.
+const (
+	KindPinned   = "pinned"
+	KindFAQ      = "faq"
+	KindObserved = "observed" // A message of a channel the bot only reads
+)
+
+// Doc is an indexed message.
+type Doc struct {
+	ID        string    `json:"id"`
+	GuildID   string    `json:"guild_id"`
+	ChannelID string    `json:"channel_id"`
+	Channel   string    `json:"channel,omitempty"` // Channel name, for citing
+	Kind      string    `json:"kind"`
+	Author    string    `json:"author,omitempty"`
+	Text      string    `json:"text"`
+	Link      string    `json:"link,omitempty"`
+	Public    bool      `json:"public,omitempty"` // Everyone in the guild can read its channel
+	Updated   time.Time `json:"updated"`
+}
+
+// Result is a source that matched a question.
+type Result struct {
+	Doc        Doc
+	Confidence float64 // 0 to 1
+}
+
+// Index keeps the docs of one bot in a JSON file and answers searches
+// from memory. Changes are written a few seconds after they are made;
+// Flush writes them at once.
+type Index struct {
+	path string
+	mu   sync.Mutex
+	docs map[string]Doc
+	// terms caches each doc's terms; df counts the docs holding a term
+	terms map[string]map[string]bool
+	df    map[string]int
+
+	saveDelay time.Duration
+	saveTimer *time.Timer // pending save, nil when the file is current
+}
+
+// Open loads the index at path; a missing file is an empty index.
+func Open(path string) (*Index, error) {
+	idx := &Index{
+		path:      path,
+		docs:      make(map[string]Doc),
+		terms:     make(map[string]map[string]bool),
+		df:        make(map[string]int),
+		saveDelay: saveDelay,
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var docs []Doc
+	if err := json.Unmarshal(data, &docs); err != nil {
+		return nil, err
+	}
+	for _, d := range docs {
+		idx.add(d)
+	}
+	return idx, nil
+}
+
+// Put adds or replaces a doc.
+func (x *Index) Put(d Doc) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if old, ok := x.docs[d.ID]; ok && old.Text == d.Text && old.Kind == d.Kind && old.Public == d.Public {
+		return nil
+	}
+	x.remove(d.ID)
+	if d.Updated.IsZero() {
+		d.Updated = time.Now()
+	}
+	x.add(d)
+	return x.changed()
+}
+
+// Delete removes a doc.
+func (x *Index) Delete(id string) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if !x.remove(id) {
+		return nil
+	}
+	return x.changed()
+}
+
+// Replace swaps the docs of kind in a channel for docs, for re-indexing a
+// channel's pins.
+func (x *Index) Replace(channelID, kind string, docs []Doc) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for id, d := range x.docs {
+		if d.ChannelID == channelID && d.Kind == kind {
+			x.remove(id)
+		}
+	}
+	now := time.Now()
+	for _, d := range docs {
+		if d.Updated.IsZero() {
+			d.Updated = now
+		}
+		x.remove(d.ID)
+		x.add(d)
+	}
+	return x.changed()
+}
+
+// Trim keeps the keep most recently updated docs of kind in a channel and
+// drops the rest, so the messages of a busy channel don't grow the index
+// without end.
+func (x *Index) Trim(channelID, kind string, keep int) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var docs []Doc
+	for _, d := range x.docs {
+		if d.ChannelID == channelID && d.Kind == kind {
+			docs = append(docs, d)
+		}
+	}
+	if len(docs) <= keep {
+		return nil
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].Updated.After(docs[j].Updated) })
+	for _, d := range docs[keep:] {
+		x.remove(d.ID)
+	}
+	return x.changed()
+}
+
+// Flush writes pending changes to the file.
+func (x *Index) Flush() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.saveTimer == nil {
+		return nil
+	}
+	x.saveTimer.Stop()
+	x.saveTimer = nil
+	return x.save()
+}
+
+// changed schedules a save of the index, unless one is pending already.
+func (x *Index) changed() error {
+	if x.saveDelay <= 0 {
+		return x.save()
+	}
+	if x.saveTimer != nil {
+		return nil
+	}
+	// The timer can't fire its func before mu is released, so t is set
+	var t *time.Timer
+	t = time.AfterFunc(x.saveDelay, func() {
+		x.mu.Lock()
+		defer x.mu.Unlock()
+		if x.saveTimer != t {
+			return
+		}
+		x.saveTimer = nil
+		if err := x.save(); err != nil {
+			logger.WarnCF("knowledge", "Failed to save knowledge index", map[string]interface{}{
+				"path":  x.path,
+				"error": err.Error(),
+			})
+		}
+	})
+	x.saveTimer = t
+	return nil
+}
+
+// Len returns the number of docs in a guild.
+func (x *Index) Len(guildID string) int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	n := 0
+	for _, d := range x.docs {
+		if d.GuildID == guildID {
+			n++
+		}
+	}
+	return n
+}
+
+// Search returns up to limit docs of the guild that match the question
+// with at least minConfidence, best first. Only docs the audience of the
+// asking channel can read are searched: public ones and those of
+// channelID itself.
+func (x *Index) Search(guildID, channelID, question string, minConfidence float64, limit int) []Result {
+	query := tokenize(question)
+	if len(query) == 0 || limit <= 0 {
+		return nil
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	n := len(x.docs)
+	idf := func(term string) float64 {
+		return math.Log(1 + float64(n+1)/float64(x.df[term]+1))
+	}
+	var total float64
+	for term := range query {
+		total += idf(term)
+	}
+
+	var results []Result
+	for id, d := range x.docs {
+		if d.GuildID != guildID || (!d.Public && d.ChannelID != channelID) {
+			continue
+		}
+		var matched float64
+		for term := range query {
+			if x.terms[id][term] {
+				matched += idf(term)
+			}
+		}
+		if c := matched / total; c > 0 && c >= minConfidence {
+			results = append(results, Result{Doc: d, Confidence: c})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Confidence != results[j].Confidence {
+			return results[i].Confidence > results[j].Confidence
+		}
+		return len(results[i].Doc.Text) < len(results[j].Doc.Text)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func (x *Index) add(d Doc) {
+	terms := tokenize(d.Text)
+	x.docs[d.ID] = d
+	x.terms[d.ID] = terms
+	for term := range terms {
+		x.df[term]++
+	}
+}
+
+func (x *Index) remove(id string) bool {
+	if _, ok := x.docs[id]; !ok {
+		return false
+	}
+	for term := range x.terms[id] {
+		if x.df[term]--; x.df[term] <= 0 {
+			delete(x.df, term)
+		}
+	}
+	delete(x.docs, id)
+	delete(x.terms, id)
+	return true
+}
+
+func (x *Index) save() error {
+	docs := make([]Doc, 0, len(x.docs))
+	for _, d := range x.docs {
+		docs = append(docs, d)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	if err := os.MkdirAll(filepath.Dir(x.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(docs, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := x.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, x.path)
+}
+
+// stopWords are left out of matching, since nearly every question has them.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "any": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "can": true, "could": true, "do": true, "does": true,
+	"for": true, "from": true, "get": true, "has": true, "have": true, "how": true, "i": true,
+	"if": true, "in": true, "is": true, "it": true, "its": true, "me": true, "my": true,
+	"of": true, "on": true, "or": true, "our": true, "should": true, "so": true, "that": true,
+	"the": true, "there": true, "this": true, "to": true, "we": true, "what": true, "when": true,
+	"where": true, "which": true, "who": true, "why": true, "will": true, "with": true,
+	"would": true, "you": true, "your": true, "anyone": true, "know": true,
+	"someone": true, "please": true, "hi": true, "hey": true, "hello": true, "thanks": true,
+}
+
+// tokenize returns the distinct words of text that count for matching,
+// lowercased, without stop words and with plural "s" trimmed.
+func tokenize(text string) map[string]bool {
+	terms := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if len([]rune(w)) < 2 || stopWords[w] {
+			continue
+		}
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			w = strings.TrimSuffix(w, "s")
+		}
+		terms[w] = true
+	}
+	return terms
+}
@@ -0,0 +1,124 @@
+package knowledge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIndex_Search(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "knowledge", "discord.json")
+	idx, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := []Doc{
+		{ID: "faq:1", GuildID: "g1", ChannelID: "faq", Kind: KindFAQ, Text: "The server rules: no spam and be kind to other members.", Public: true},
+		{ID: "pinned:2", GuildID: "g1", ChannelID: "general", Kind: KindPinned, Text: "Meetups happen every first Friday at the library."},
+		{ID: "pinned:3", GuildID: "g2", ChannelID: "other", Kind: KindPinned, Text: "Meetups happen on Sundays.", Public: true},
+	}
+	for _, d := range docs {
+		if err := idx.Put(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results := idx.Search("g1", "general", "When do the meetups happen?", 0.6, 3)
+	if len(results) != 1 || results[0].Doc.ID != "pinned:2" {
+		t.Fatalf("Search = %+v", results)
+	}
+	if c := results[0].Confidence; c < 0.99 || c > 1 {
+		t.Errorf("confidence = %v, want 1", c)
+	}
+
+	if got := idx.Search("g1", "general", "Where can I buy a blue widget for meetups?", 0.6, 3); len(got) != 0 {
+		t.Errorf("weak match = %+v, want none", got)
+	}
+	if got := idx.Search("g1", "general", "what is the weather", 0, 3); len(got) != 0 {
+		t.Errorf("unrelated question matched %+v", got)
+	}
+	// Docs of a private channel stay in it, public ones are found anywhere
+	if got := idx.Search("g1", "random", "When do the meetups happen?", 0.6, 3); len(got) != 0 {
+		t.Errorf("private doc found from another channel: %+v", got)
+	}
+	if got := idx.Search("g1", "random", "What are the server rules?", 0.6, 3); len(got) != 1 || got[0].Doc.ID != "faq:1" {
+		t.Errorf("public doc = %+v", got)
+	}
+
+	// Changes are saved together, later or on Flush
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("index saved before the delay: %v", err)
+	}
+	if err := idx.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The index survives a reload
+	reloaded, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Len("g1") != 2 || reloaded.Len("g2") != 1 {
+		t.Errorf("reloaded lens = %d, %d", reloaded.Len("g1"), reloaded.Len("g2"))
+	}
+
+	if err := reloaded.Replace("general", KindPinned, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.Search("g1", "general", "When do the meetups happen?", 0.6, 3); len(got) != 0 {
+		t.Errorf("unpinned doc still matched: %+v", got)
+	}
+	if err := reloaded.Delete("faq:1"); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Len("g1") != 0 {
+		t.Errorf("Len after delete = %d", reloaded.Len("g1"))
+	}
+}
+
+func TestIndex_Trim(t *testing.T) {
+	idx, err := Open(filepath.Join(t.TempDir(), "knowledge.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for i, word := range []string{"apple", "banana", "cherry", "grape", "mango"} {
+		d := Doc{ID: fmt.Sprintf("observed:%d", i), GuildID: "g1", ChannelID: "chat", Kind: KindObserved,
+			Text: "I like " + word, Updated: start.Add(time.Duration(i) * time.Minute)}
+		if err := idx.Put(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := idx.Put(Doc{ID: "faq:1", GuildID: "g1", ChannelID: "chat", Kind: KindFAQ, Text: "rules"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := idx.Trim("chat", KindObserved, 2); err != nil {
+		t.Fatal(err)
+	}
+	if idx.Len("g1") != 3 {
+		t.Fatalf("Len after trim = %d, want the 2 newest and the FAQ", idx.Len("g1"))
+	}
+	if got := idx.Search("g1", "chat", "mango", 0.5, 5); len(got) != 1 || got[0].Doc.ID != "observed:4" {
+		t.Errorf("newest message not kept: %+v", got)
+	}
+	if got := idx.Search("g1", "chat", "apple", 0.5, 5); len(got) != 0 {
+		t.Errorf("oldest message kept: %+v", got)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("How do I reset my Passwords? It's the passwords page!")
+	for _, want := range []string{"reset", "password", "page"} {
+		if !got[want] {
+			t.Errorf("missing term %q in %v", want, got)
+		}
+	}
+	for _, stop := range []string{"how", "do", "the", "i"} {
+		if got[stop] {
+			t.Errorf("stop word %q kept", stop)
+		}
+	}
+}
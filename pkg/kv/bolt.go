@@ -0,0 +1,192 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package kv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+var boltBucket = []byte("kv")
+
+// boltSweepInterval is how often expired keys are removed from the file.
+const boltSweepInterval = time.Hour
+
+// Bolt is a Store in a bbolt file. Values are stored after an 8-byte
+// expiry (Unix nanoseconds, 0 for none); expired keys read as missing and
+// are swept hourly.
+type Bolt struct {
+	db   *bolt.DB
+	stop chan struct{}
+}
+
+// OpenBolt opens or creates the bbolt file at path. Only one process can
+// have it open, so a second gateway on the same workspace fails here
+// instead of blocking.
+func OpenBolt(path string) (*Bolt, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	b := &Bolt{db: db, stop: make(chan struct{})}
+	b.sweep()
+	go b.sweepLoop()
+	return b, nil
+}
+
+func (b *Bolt) Get(key string) ([]byte, bool, error) {
+	var value []byte
+	var ok bool
+	err := b.db.View(func(tx *bolt.Tx) error {
+		value, ok = boltGet(tx, key, time.Now())
+		return nil
+	})
+	return value, ok, err
+}
+
+func (b *Bolt) Set(key string, value []byte, ttl time.Duration) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return boltPut(tx, key, value, deadline(ttl))
+	})
+}
+
+func (b *Bolt) Add(key string, value []byte, ttl time.Duration) (bool, error) {
+	added := false
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		if _, ok := boltGet(tx, key, time.Now()); ok {
+			return nil
+		}
+		added = true
+		return boltPut(tx, key, value, deadline(ttl))
+	})
+	return added, err
+}
+
+func (b *Bolt) Incr(key string, n int64, ttl time.Duration) (int64, error) {
+	var v int64
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		expires := deadline(ttl)
+		raw := tx.Bucket(boltBucket).Get([]byte(key))
+		old, ok := boltGet(tx, key, time.Now())
+		if ok {
+			expires = boltExpiry(raw)
+		}
+		cur, err := parseInt(old)
+		if err != nil {
+			return err
+		}
+		v = cur + n
+		return boltPut(tx, key, []byte(strconv.FormatInt(v, 10)), expires)
+	})
+	return v, err
+}
+
+func (b *Bolt) Delete(key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete([]byte(key))
+	})
+}
+
+func (b *Bolt) Close() error {
+	select {
+	case <-b.stop:
+		return ErrClosed
+	default:
+	}
+	close(b.stop)
+	return b.db.Close()
+}
+
+func (b *Bolt) sweepLoop() {
+	ticker := time.NewTicker(boltSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.sweep()
+		}
+	}
+}
+
+// sweep deletes expired keys.
+func (b *Bolt) sweep() {
+	now := time.Now()
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		var expired [][]byte
+		bucket.ForEach(func(k, v []byte) error {
+			if exp := boltExpiry(v); !exp.IsZero() && !now.Before(exp) {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.WarnCF("kv", "Failed to sweep expired keys", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
+
+// boltGet returns a copy of the live value of key.
+func boltGet(tx *bolt.Tx, key string, now time.Time) ([]byte, bool) {
+	raw := tx.Bucket(boltBucket).Get([]byte(key))
+	if len(raw) < 8 {
+		return nil, false
+	}
+	if exp := boltExpiry(raw); !exp.IsZero() && !now.Before(exp) {
+		return nil, false
+	}
+	return append([]byte(nil), raw[8:]...), true
+}
+
+func boltPut(tx *bolt.Tx, key string, value []byte, expires time.Time) error {
+	raw := make([]byte, 8+len(value))
+	if !expires.IsZero() {
+		binary.BigEndian.PutUint64(raw, uint64(expires.UnixNano()))
+	}
+	copy(raw[8:], value)
+	return tx.Bucket(boltBucket).Put([]byte(key), raw)
+}
+
+func boltExpiry(raw []byte) time.Time {
+	if len(raw) < 8 {
+		return time.Time{}
+	}
+	n := binary.BigEndian.Uint64(raw)
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(n))
+}
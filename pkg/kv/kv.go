@@ -0,0 +1,76 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package kv is the small key-value store behind the gateway's runtime
+// state: seen message IDs, rate limit counters, usage counters and when
+// scheduled jobs last ran. bbolt in workspace/state/kv.db is the default;
+// Redis lets several gateways share it. State the CLI edits, such as
+// pairings and linked accounts, stays in JSON files, since the bolt file
+// is locked by the gateway that opened it.
+package kv
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+// Backends, see config.KVConfig.
+const (
+	BackendBolt   = "bolt"
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+)
+
+// ErrClosed is returned by a store after Close.
+var ErrClosed = errors.New("kv store is closed")
+
+// Store is a key-value store with expiring keys. A ttl of 0 keeps a key
+// until it is deleted. Keys are namespaced by their users with a prefix,
+// such as "dedupe:" or "usage:".
+type Store interface {
+	// Get returns the value of key, and false if it is missing or expired.
+	Get(key string) ([]byte, bool, error)
+	// Set stores value under key.
+	Set(key string, value []byte, ttl time.Duration) error
+	// Add stores value under key only if the key is missing, reporting
+	// whether it did.
+	Add(key string, value []byte, ttl time.Duration) (bool, error)
+	// Incr adds n to the integer under key, creating it with ttl if
+	// missing, and returns the new value.
+	Incr(key string, n int64, ttl time.Duration) (int64, error)
+	// Delete removes key.
+	Delete(key string) error
+	Close() error
+}
+
+// Open opens the store cfg selects. The bolt file defaults to
+// workspace/state/kv.db.
+func Open(cfg config.KVConfig, workspace string) (Store, error) {
+	switch cfg.Backend {
+	case BackendBolt, "":
+		path := cfg.Path
+		if path == "" {
+			path = filepath.Join(workspace, "state", "kv.db")
+		}
+		return OpenBolt(path)
+	case BackendRedis:
+		return NewRedis(cfg.Redis), nil
+	case BackendMemory:
+		return NewMemory(), nil
+	}
+	return nil, fmt.Errorf("unknown kv backend %q (want bolt, redis or memory)", cfg.Backend)
+}
+
+// deadline returns when a key set now with ttl expires, or the zero time.
+func deadline(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
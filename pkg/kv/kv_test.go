@@ -0,0 +1,181 @@
+package kv
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+
+	if _, ok, err := s.Get("missing"); ok || err != nil {
+		t.Errorf("Get(missing) = %v, %v", ok, err)
+	}
+
+	if err := s.Set("a", []byte("1"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, _ := s.Get("a"); !ok || string(v) != "1" {
+		t.Errorf("Get(a) = %q, %v", v, ok)
+	}
+
+	if added, _ := s.Add("a", []byte("2"), 0); added {
+		t.Error("Add replaced an existing key")
+	}
+	if added, _ := s.Add("b", nil, 0); !added {
+		t.Error("Add didn't add a missing key")
+	}
+
+	for want := int64(3); want <= 6; want += 3 {
+		if v, err := s.Incr("n", 3, 0); err != nil || v != want {
+			t.Errorf("Incr = %d, %v; want %d", v, err, want)
+		}
+	}
+
+	if err := s.Set("short", []byte("x"), 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, ok, _ := s.Get("short"); ok {
+		t.Error("expired key still readable")
+	}
+	if added, _ := s.Add("short", nil, 0); !added {
+		t.Error("Add refused an expired key")
+	}
+
+	if err := s.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := s.Get("a"); ok {
+		t.Error("deleted key still readable")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, NewMemory())
+}
+
+func TestBolt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "kv.db")
+	s, err := OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, s)
+
+	// Values survive reopening
+	s.Close()
+	s, err = OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if v, ok, _ := s.Get("n"); !ok || string(v) != "6" {
+		t.Errorf("after reopening Get(n) = %q, %v", v, ok)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	workspace := t.TempDir()
+	s, err := Open(config.KVConfig{Backend: BackendBolt}, workspace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if _, err := Open(config.KVConfig{Backend: "etcd"}, workspace); err == nil {
+		t.Error("unknown backend accepted")
+	}
+}
+
+// fakeRedis answers one connection's commands from a map, sending each
+// command to commands.
+func fakeRedis(t *testing.T, commands chan<- []string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rd := bufio.NewReader(conn)
+		data := map[string]string{}
+		for {
+			req, err := readReply(rd)
+			if err != nil {
+				return
+			}
+			var args []string
+			for _, a := range req.([]interface{}) {
+				args = append(args, string(a.([]byte)))
+			}
+			commands <- args
+
+			reply := "+OK\r\n"
+			switch strings.ToUpper(args[0]) {
+			case "GET":
+				if v, ok := data[args[1]]; ok {
+					reply = "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+				} else {
+					reply = "$-1\r\n"
+				}
+			case "SET":
+				if len(args) > 3 && args[3] == "NX" {
+					if _, ok := data[args[1]]; ok {
+						reply = "$-1\r\n"
+						break
+					}
+				}
+				data[args[1]] = args[2]
+			case "EVAL":
+				reply = ":" + args[4] + "\r\n"
+			case "DEL":
+				delete(data, args[1])
+				reply = ":1\r\n"
+			}
+			conn.Write([]byte(reply))
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestRedis(t *testing.T) {
+	commands := make(chan []string, 16)
+	addr := fakeRedis(t, commands)
+	s := NewRedis(config.KVRedisConfig{Addr: addr, Password: "secret", DB: 2, Prefix: "pc:"})
+	defer s.Close()
+
+	if err := s.Set("a", []byte("v"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"AUTH secret", "SELECT 2", "SET pc:a v PX 60000"} {
+		if got := strings.Join(<-commands, " "); got != want {
+			t.Errorf("command = %q, want %q", got, want)
+		}
+	}
+
+	if v, ok, err := s.Get("a"); err != nil || !ok || string(v) != "v" {
+		t.Errorf("Get(a) = %q, %v, %v", v, ok, err)
+	}
+	if _, ok, _ := s.Get("b"); ok {
+		t.Error("Get(b) found a missing key")
+	}
+	if added, _ := s.Add("a", nil, 0); added {
+		t.Error("Add replaced an existing key")
+	}
+	if v, err := s.Incr("n", 5, time.Hour); err != nil || v != 5 {
+		t.Errorf("Incr = %d, %v", v, err)
+	}
+}
@@ -0,0 +1,112 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package kv
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+func (e memoryEntry) live(now time.Time) bool {
+	return e.expires.IsZero() || now.Before(e.expires)
+}
+
+// Memory is a Store in process memory, for tests and for gateways that
+// don't need state to survive a restart.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	sets    int
+}
+
+// NewMemory returns an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]memoryEntry)}
+}
+
+func (m *Memory) Get(key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok || !e.live(time.Now()) {
+		return nil, false, nil
+	}
+	return append([]byte(nil), e.value...), true, nil
+}
+
+func (m *Memory) Set(key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setLocked(key, value, ttl)
+	return nil
+}
+
+func (m *Memory) Add(key string, value []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[key]; ok && e.live(time.Now()) {
+		return false, nil
+	}
+	m.setLocked(key, value, ttl)
+	return true, nil
+}
+
+func (m *Memory) Incr(key string, n int64, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok || !e.live(time.Now()) {
+		e = memoryEntry{expires: deadline(ttl)}
+	}
+	v, err := parseInt(e.value)
+	if err != nil {
+		return 0, err
+	}
+	v += n
+	e.value = []byte(strconv.FormatInt(v, 10))
+	m.entries[key] = e
+	return v, nil
+}
+
+func (m *Memory) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// setLocked stores an entry, sweeping expired ones every so often so
+// keys that are never read again don't pile up.
+func (m *Memory) setLocked(key string, value []byte, ttl time.Duration) {
+	m.entries[key] = memoryEntry{value: append([]byte(nil), value...), expires: deadline(ttl)}
+	m.sets++
+	if m.sets%1024 == 0 {
+		now := time.Now()
+		for k, e := range m.entries {
+			if !e.live(now) {
+				delete(m.entries, k)
+			}
+		}
+	}
+}
+
+// parseInt reads a counter, treating a missing value as 0.
+func parseInt(value []byte) (int64, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(value), 10, 64)
+}
@@ -0,0 +1,238 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package kv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+// redisTimeout bounds connecting and each command.
+const redisTimeout = 5 * time.Second
+
+// redisIncr increments a counter and sets its expiry only when the
+// increment created it, in one step.
+const redisIncr = `local v = redis.call('INCRBY', KEYS[1], ARGV[1])
+if v == tonumber(ARGV[1]) and tonumber(ARGV[2]) > 0 then
+  redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return v`
+
+// redisError is an error reply from the server.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// Redis is a Store on a Redis server, for gateways that share state. It
+// speaks just enough of the protocol for the Store methods over one
+// connection, reconnecting when it breaks.
+type Redis struct {
+	cfg config.KVRedisConfig
+
+	mu     sync.Mutex
+	conn   net.Conn
+	rd     *bufio.Reader
+	closed bool
+}
+
+// NewRedis returns a store on the server cfg names. It connects on first
+// use.
+func NewRedis(cfg config.KVRedisConfig) *Redis {
+	if cfg.Addr == "" {
+		cfg.Addr = "localhost:6379"
+	}
+	return &Redis{cfg: cfg}
+}
+
+func (r *Redis) Get(key string) ([]byte, bool, error) {
+	reply, err := r.do("GET", r.key(key))
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %v", reply)
+	}
+	return value, true, nil
+}
+
+func (r *Redis) Set(key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", r.key(key), string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := r.do(args...)
+	return err
+}
+
+func (r *Redis) Add(key string, value []byte, ttl time.Duration) (bool, error) {
+	args := []string{"SET", r.key(key), string(value), "NX"}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	reply, err := r.do(args...)
+	return err == nil && reply != nil, err
+}
+
+func (r *Redis) Incr(key string, n int64, ttl time.Duration) (int64, error) {
+	reply, err := r.do("EVAL", redisIncr, "1", r.key(key),
+		strconv.FormatInt(n, 10), strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return 0, err
+	}
+	v, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected INCRBY reply %v", reply)
+	}
+	return v, nil
+}
+
+func (r *Redis) Delete(key string) error {
+	_, err := r.do("DEL", r.key(key))
+	return err
+}
+
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrClosed
+	}
+	r.closed = true
+	if r.conn != nil {
+		return r.conn.Close()
+	}
+	return nil
+}
+
+func (r *Redis) key(key string) string {
+	return r.cfg.Prefix + key
+}
+
+// do runs a command, retrying once on a fresh connection if the old one
+// broke (the server restarted, or closed it as idle).
+func (r *Redis) do(args ...string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, ErrClosed
+	}
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if r.conn == nil {
+			if err = r.connect(); err != nil {
+				return nil, err
+			}
+		}
+		var reply interface{}
+		reply, err = r.roundTrip(args)
+		var redisErr redisError
+		if err == nil || errors.As(err, &redisErr) {
+			return reply, err
+		}
+		r.conn.Close()
+		r.conn = nil
+	}
+	return nil, err
+}
+
+func (r *Redis) connect() error {
+	conn, err := net.DialTimeout("tcp", r.cfg.Addr, redisTimeout)
+	if err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+	r.conn = conn
+	r.rd = bufio.NewReader(conn)
+
+	var setup [][]string
+	if r.cfg.Password != "" {
+		if r.cfg.Username != "" {
+			setup = append(setup, []string{"AUTH", r.cfg.Username, r.cfg.Password})
+		} else {
+			setup = append(setup, []string{"AUTH", r.cfg.Password})
+		}
+	}
+	if r.cfg.DB != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(r.cfg.DB)})
+	}
+	for _, args := range setup {
+		if _, err := r.roundTrip(args); err != nil {
+			conn.Close()
+			r.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Redis) roundTrip(args []string) (interface{}, error) {
+	r.conn.SetDeadline(time.Now().Add(redisTimeout))
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, a := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(a)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, a...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := r.conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readReply(r.rd)
+}
+
+// readReply reads one RESP2 reply: a string, an int64, a []byte, nil for
+// a null, or a []interface{} for an array.
+func readReply(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(rd); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
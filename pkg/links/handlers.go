@@ -0,0 +1,260 @@
+package links
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/tools"
+)
+
+// Endpoints, overridable in tests.
+var (
+	youTubeWatchURL  = "https://www.youtube.com/watch"
+	twitterOEmbedURL = "https://publish.twitter.com/oembed"
+	gitHubAPIURL     = "https://api.github.com"
+)
+
+var (
+	htmlTitleRe   = regexp.MustCompile(`(?is)<title>(.*?)</title>`)
+	tweetParaRe   = regexp.MustCompile(`(?is)<p[^>]*>(.*?)</p>`)
+	htmlBreakRe   = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlTagRe     = regexp.MustCompile(`<[^>]+>`)
+	gitHubIssueRe = regexp.MustCompile(`^/([^/]+)/([^/]+)/(?:issues|pull)/(\d+)`)
+	gitHubRepoRe  = regexp.MustCompile(`^/([^/]+)/([^/]+)/?$`)
+)
+
+// youTubeHandler fetches the caption track of a YouTube video.
+type youTubeHandler struct {
+	client *http.Client
+}
+
+func (h *youTubeHandler) Name() string { return "youtube transcript" }
+
+func (h *youTubeHandler) Match(u *url.URL) bool {
+	return youTubeVideoID(u) != ""
+}
+
+func youTubeVideoID(u *url.URL) string {
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	switch host {
+	case "youtu.be":
+		return strings.Trim(u.Path, "/")
+	case "youtube.com", "m.youtube.com", "music.youtube.com":
+		if u.Path == "/watch" {
+			return u.Query().Get("v")
+		}
+		for _, prefix := range []string{"/shorts/", "/live/", "/embed/"} {
+			if strings.HasPrefix(u.Path, prefix) {
+				return strings.Trim(strings.TrimPrefix(u.Path, prefix), "/")
+			}
+		}
+	}
+	return ""
+}
+
+type youTubeCaptionTrack struct {
+	BaseURL      string `json:"baseUrl"`
+	LanguageCode string `json:"languageCode"`
+	Kind         string `json:"kind"` // "asr" for auto-generated captions
+}
+
+func (h *youTubeHandler) Fetch(ctx context.Context, u *url.URL) (string, error) {
+	id := youTubeVideoID(u)
+	page, err := get(ctx, h.client, youTubeWatchURL+"?v="+url.QueryEscape(id), map[string]string{
+		"Accept-Language": "en-US,en;q=0.9",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	tracks, err := youTubeCaptionTracks(string(page))
+	if err != nil {
+		return "", err
+	}
+	track := pickCaptionTrack(tracks)
+
+	data, err := get(ctx, h.client, track.BaseURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch captions: %w", err)
+	}
+	var transcript struct {
+		Texts []string `xml:"text"`
+	}
+	if err := xml.Unmarshal(data, &transcript); err != nil {
+		return "", fmt.Errorf("failed to parse captions: %w", err)
+	}
+
+	lines := make([]string, 0, len(transcript.Texts))
+	for _, t := range transcript.Texts {
+		// Caption text is HTML-escaped inside the XML escaping
+		if t = strings.TrimSpace(html.UnescapeString(t)); t != "" {
+			lines = append(lines, t)
+		}
+	}
+
+	var sb strings.Builder
+	if m := htmlTitleRe.FindStringSubmatch(string(page)); m != nil {
+		fmt.Fprintf(&sb, "Title: %s\n\n", strings.TrimSuffix(html.UnescapeString(strings.TrimSpace(m[1])), " - YouTube"))
+	}
+	sb.WriteString(strings.Join(lines, " "))
+	return sb.String(), nil
+}
+
+// youTubeCaptionTracks decodes the captionTracks array embedded in a watch
+// page's player response.
+func youTubeCaptionTracks(page string) ([]youTubeCaptionTrack, error) {
+	const marker = `"captionTracks":`
+	idx := strings.Index(page, marker)
+	if idx < 0 {
+		return nil, fmt.Errorf("video has no captions")
+	}
+
+	var tracks []youTubeCaptionTrack
+	if err := json.NewDecoder(strings.NewReader(page[idx+len(marker):])).Decode(&tracks); err != nil {
+		return nil, fmt.Errorf("failed to parse caption tracks: %w", err)
+	}
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("video has no captions")
+	}
+	return tracks, nil
+}
+
+// pickCaptionTrack prefers manual English captions, then auto-generated
+// English, then whatever comes first.
+func pickCaptionTrack(tracks []youTubeCaptionTrack) youTubeCaptionTrack {
+	for _, asr := range []bool{false, true} {
+		for _, t := range tracks {
+			if strings.HasPrefix(t.LanguageCode, "en") && (t.Kind == "asr") == asr {
+				return t
+			}
+		}
+	}
+	return tracks[0]
+}
+
+// twitterHandler extracts the text of a post on Twitter/X through the
+// public oEmbed endpoint, which needs no API key.
+type twitterHandler struct {
+	client *http.Client
+}
+
+func (h *twitterHandler) Name() string { return "post text" }
+
+func (h *twitterHandler) Match(u *url.URL) bool {
+	switch strings.TrimPrefix(strings.ToLower(u.Host), "www.") {
+	case "twitter.com", "x.com", "mobile.twitter.com", "mobile.x.com":
+		return strings.Contains(u.Path, "/status/")
+	}
+	return false
+}
+
+func (h *twitterHandler) Fetch(ctx context.Context, u *url.URL) (string, error) {
+	canonical := "https://twitter.com" + u.Path
+	body, err := get(ctx, h.client, twitterOEmbedURL+"?omit_script=true&url="+url.QueryEscape(canonical), nil)
+	if err != nil {
+		return "", err
+	}
+
+	var embed struct {
+		AuthorName string `json:"author_name"`
+		HTML       string `json:"html"`
+	}
+	if err := json.Unmarshal(body, &embed); err != nil {
+		return "", fmt.Errorf("failed to parse oEmbed response: %w", err)
+	}
+
+	m := tweetParaRe.FindStringSubmatch(embed.HTML)
+	if m == nil {
+		return "", fmt.Errorf("no post text in oEmbed response")
+	}
+	text := htmlBreakRe.ReplaceAllString(m[1], "\n")
+	text = html.UnescapeString(htmlTagRe.ReplaceAllString(text, ""))
+	return fmt.Sprintf("%s: %s", embed.AuthorName, strings.TrimSpace(text)), nil
+}
+
+// gitHubHandler fetches a repository's README, or an issue or pull request.
+type gitHubHandler struct {
+	client *http.Client
+	token  string
+}
+
+func (h *gitHubHandler) Name() string { return "github" }
+
+func (h *gitHubHandler) Match(u *url.URL) bool {
+	if strings.TrimPrefix(strings.ToLower(u.Host), "www.") != "github.com" {
+		return false
+	}
+	return gitHubIssueRe.MatchString(u.Path) || gitHubRepoRe.MatchString(u.Path)
+}
+
+func (h *gitHubHandler) Fetch(ctx context.Context, u *url.URL) (string, error) {
+	headers := map[string]string{"Accept": "application/vnd.github+json"}
+	if h.token != "" {
+		headers["Authorization"] = "Bearer " + h.token
+	}
+
+	if m := gitHubIssueRe.FindStringSubmatch(u.Path); m != nil {
+		body, err := get(ctx, h.client, fmt.Sprintf("%s/repos/%s/%s/issues/%s", gitHubAPIURL, m[1], m[2], m[3]), headers)
+		if err != nil {
+			return "", err
+		}
+		var issue struct {
+			Title       string                 `json:"title"`
+			State       string                 `json:"state"`
+			Body        string                 `json:"body"`
+			User        struct{ Login string } `json:"user"`
+			PullRequest *struct{}              `json:"pull_request"`
+		}
+		if err := json.Unmarshal(body, &issue); err != nil {
+			return "", fmt.Errorf("failed to parse issue: %w", err)
+		}
+		kind := "Issue"
+		if issue.PullRequest != nil {
+			kind = "Pull request"
+		}
+		return fmt.Sprintf("%s #%s: %s (%s, by %s)\n\n%s", kind, m[3], issue.Title, issue.State, issue.User.Login, issue.Body), nil
+	}
+
+	m := gitHubRepoRe.FindStringSubmatch(u.Path)
+	headers["Accept"] = "application/vnd.github.raw"
+	body, err := get(ctx, h.client, fmt.Sprintf("%s/repos/%s/%s/readme", gitHubAPIURL, m[1], m[2]), headers)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("README of %s/%s\n\n%s", m[1], m[2], body), nil
+}
+
+// readableHandler fetches any other page and reduces HTML to its text.
+type readableHandler struct {
+	client *http.Client
+}
+
+func (h *readableHandler) Name() string { return "page text" }
+
+func (h *readableHandler) Match(u *url.URL) bool {
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+func (h *readableHandler) Fetch(ctx context.Context, u *url.URL) (string, error) {
+	body, err := get(ctx, h.client, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(body)
+	switch {
+	case strings.HasPrefix(contentType, "text/html"):
+		return tools.ExtractText(string(body)), nil
+	case strings.HasPrefix(contentType, "text/"):
+		return string(body), nil
+	default:
+		return "", fmt.Errorf("unsupported content type %s", contentType)
+	}
+}
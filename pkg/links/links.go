@@ -0,0 +1,166 @@
+// Package links expands bare URLs in incoming messages into their content,
+// using a specialized handler per site and a readable-text fallback.
+package links
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// maxBodyBytes caps how much of any response is read.
+const maxBodyBytes = 4 << 20
+
+var urlRe = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+`)
+
+// Handler fetches the useful content behind one kind of URL.
+type Handler interface {
+	// Name labels the content, e.g. "youtube transcript".
+	Name() string
+	Match(u *url.URL) bool
+	Fetch(ctx context.Context, u *url.URL) (string, error)
+}
+
+// Dispatcher routes each URL to the first handler that matches it.
+type Dispatcher struct {
+	handlers []Handler
+	maxLinks int
+	maxChars int
+}
+
+// NewDispatcher builds a dispatcher from cfg, or returns nil when link
+// expansion is disabled or no handler is enabled.
+func NewDispatcher(cfg config.LinksConfig) *Dispatcher {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	var handlers []Handler
+	if cfg.YouTube {
+		handlers = append(handlers, &youTubeHandler{client: client})
+	}
+	if cfg.Twitter {
+		handlers = append(handlers, &twitterHandler{client: client})
+	}
+	if cfg.GitHub {
+		handlers = append(handlers, &gitHubHandler{client: client, token: cfg.GitHubToken})
+	}
+	if cfg.Fallback {
+		handlers = append(handlers, &readableHandler{client: client})
+	}
+	if len(handlers) == 0 {
+		return nil
+	}
+
+	d := &Dispatcher{handlers: handlers, maxLinks: cfg.MaxLinks, maxChars: cfg.MaxChars}
+	if d.maxLinks <= 0 {
+		d.maxLinks = 3
+	}
+	if d.maxChars <= 0 {
+		d.maxChars = 4000
+	}
+	return d
+}
+
+// Expand fetches the URLs in text and returns their content as a block to
+// add to the message, or "" when there was nothing to add. Failures are
+// logged and skipped so a dead link never blocks the turn.
+func (d *Dispatcher) Expand(ctx context.Context, text string) string {
+	var sb strings.Builder
+	for _, raw := range ExtractURLs(text, d.maxLinks) {
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		handler := d.handlerFor(u)
+		if handler == nil {
+			continue
+		}
+
+		content, err := handler.Fetch(ctx, u)
+		if err != nil {
+			logger.WarnCF("links", "Failed to fetch link", map[string]interface{}{
+				"url":     raw,
+				"handler": handler.Name(),
+				"error":   err.Error(),
+			})
+			continue
+		}
+		content = strings.TrimSpace(content)
+		if content == "" {
+			continue
+		}
+		if runes := []rune(content); len(runes) > d.maxChars {
+			content = string(runes[:d.maxChars]) + "\n... (truncated)"
+		}
+
+		fmt.Fprintf(&sb, "\n\n[link: %s (%s)]\n%s", raw, handler.Name(), content)
+	}
+	return sb.String()
+}
+
+func (d *Dispatcher) handlerFor(u *url.URL) Handler {
+	for _, h := range d.handlers {
+		if h.Match(u) {
+			return h
+		}
+	}
+	return nil
+}
+
+// ExtractURLs returns up to max distinct http(s) URLs from text, trimming
+// trailing punctuation that usually belongs to the sentence.
+func ExtractURLs(text string, max int) []string {
+	seen := make(map[string]bool)
+	var urls []string
+	for _, m := range urlRe.FindAllString(text, -1) {
+		m = strings.TrimRight(m, ".,;:!?)]}>")
+		if seen[m] {
+			continue
+		}
+		seen[m] = true
+		urls = append(urls, m)
+		if max > 0 && len(urls) >= max {
+			break
+		}
+	}
+	return urls
+}
+
+// get fetches url and returns the body, failing on non-2xx responses.
+func get(ctx context.Context, client *http.Client, url string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return body, nil
+}
@@ -0,0 +1,106 @@
+package links
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func TestExtractURLs(t *testing.T) {
+	text := "see https://example.com/a. and (https://example.com/b) plus https://example.com/a again, http://x.org/c?d=1"
+	got := ExtractURLs(text, 0)
+	want := []string{"https://example.com/a", "https://example.com/b", "http://x.org/c?d=1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractURLs = %v, want %v", got, want)
+	}
+	if got := ExtractURLs(text, 2); len(got) != 2 {
+		t.Errorf("max 2 returned %d URLs", len(got))
+	}
+}
+
+func TestNewDispatcher_Disabled(t *testing.T) {
+	if d := NewDispatcher(config.LinksConfig{Enabled: false, Fallback: true}); d != nil {
+		t.Error("disabled config should return nil")
+	}
+	if d := NewDispatcher(config.LinksConfig{Enabled: true}); d != nil {
+		t.Error("config without handlers should return nil")
+	}
+}
+
+func TestDispatcher_Handlers(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/watch":
+			fmt.Fprintf(w, `<html><title>Cats - YouTube</title><script>var p = {"captions":{"captionTracks":[`+
+				`{"baseUrl":"%[1]s/captions?lang=de","languageCode":"de"},`+
+				`{"baseUrl":"%[1]s/captions?lang=en","languageCode":"en","kind":"asr"}]}};</script></html>`, server.URL)
+		case "/captions":
+			if r.URL.Query().Get("lang") != "en" {
+				http.Error(w, "wrong track", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `<transcript><text start="0">cats are</text><text start="1">great &amp;#39;fr&amp;#39;</text></transcript>`)
+		case "/oembed":
+			fmt.Fprint(w, `{"author_name":"Alice","html":"<blockquote><p lang=\"en\">hello<br>world &amp; more</p>&mdash; Alice</blockquote>"}`)
+		case "/repos/o/r/issues/7":
+			fmt.Fprint(w, `{"title":"Crash on start","state":"open","body":"It crashes.","user":{"login":"bob"}}`)
+		case "/repos/o/r/readme":
+			fmt.Fprint(w, "# r\nA repo.")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	youTubeWatchURL = server.URL + "/watch"
+	twitterOEmbedURL = server.URL + "/oembed"
+	gitHubAPIURL = server.URL
+	defer func() {
+		youTubeWatchURL = "https://www.youtube.com/watch"
+		twitterOEmbedURL = "https://publish.twitter.com/oembed"
+		gitHubAPIURL = "https://api.github.com"
+	}()
+
+	d := NewDispatcher(config.LinksConfig{Enabled: true, MaxLinks: 5, YouTube: true, Twitter: true, GitHub: true})
+
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"https://youtu.be/abc123", "Title: Cats\n\ncats are great 'fr'"},
+		{"https://x.com/alice/status/42", "Alice: hello\nworld & more"},
+		{"https://github.com/o/r/issues/7", "Issue #7: Crash on start (open, by bob)\n\nIt crashes."},
+		{"https://github.com/o/r", "README of o/r\n\n# r\nA repo."},
+	}
+	for _, tt := range tests {
+		got := d.Expand(context.Background(), "look at "+tt.link)
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("Expand(%s) = %q, want it to contain %q", tt.link, got, tt.want)
+		}
+	}
+
+	// Without the fallback, other links are left alone
+	if got := d.Expand(context.Background(), "https://example.com/page"); got != "" {
+		t.Errorf("unmatched link expanded to %q", got)
+	}
+}
+
+func TestReadableHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<!DOCTYPE html><html><head><style>p{}</style></head><body><p>Hello page</p></body></html>`)
+	}))
+	defer server.Close()
+
+	d := NewDispatcher(config.LinksConfig{Enabled: true, MaxChars: 5, Fallback: true})
+	got := d.Expand(context.Background(), server.URL+"/post")
+	if !strings.Contains(got, "(page text)]\nHello\n... (truncated)") {
+		t.Errorf("Expand = %q", got)
+	}
+}
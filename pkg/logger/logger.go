@@ -31,6 +31,7 @@ var (
 	}
 
 	currentLevel = INFO
+	jsonOutput   bool
 	logger       *Logger
 	once         sync.Once
 	mu           sync.RWMutex
@@ -67,6 +68,21 @@ func GetLevel() LogLevel {
 	return currentLevel
 }
 
+// SetJSON switches console output between text lines and one JSON entry
+// per line on stdout, for log collectors.
+func SetJSON(enabled bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	jsonOutput = enabled
+}
+
+// JSON reports whether console output is JSON, see SetJSON.
+func JSON() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return jsonOutput
+}
+
 func EnableFileLogging(filePath string) error {
 	mu.Lock()
 	defer mu.Unlock()
@@ -116,12 +132,23 @@ func logMessage(level LogLevel, component string, message string, fields map[str
 		}
 	}
 
-	if logger.file != nil {
+	if logger.file != nil || jsonOutput {
 		jsonData, err := json.Marshal(entry)
 		if err == nil {
-			logger.file.WriteString(string(jsonData) + "\n")
+			if logger.file != nil {
+				logger.file.WriteString(string(jsonData) + "\n")
+			}
+			if jsonOutput {
+				os.Stdout.WriteString(string(jsonData) + "\n")
+			}
 		}
 	}
+	if jsonOutput {
+		if level == FATAL {
+			os.Exit(1)
+		}
+		return
+	}
 
 	var fieldStr string
 	if len(fields) > 0 {
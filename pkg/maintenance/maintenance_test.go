@@ -0,0 +1,91 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+func TestStore_EnqueueFirstPerChat(t *testing.T) {
+	store := NewStore(t.TempDir())
+	if err := store.Enable("back soon"); err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+
+	first, _ := store.Enqueue(bus.InboundMessage{Channel: "telegram", ChatID: "1", Content: "a"})
+	again, _ := store.Enqueue(bus.InboundMessage{Channel: "telegram", ChatID: "1", Content: "b"})
+	other, _ := store.Enqueue(bus.InboundMessage{Channel: "telegram", ChatID: "2", Content: "c"})
+	if !first || again || !other {
+		t.Errorf("first flags = %v, %v, %v; want true, false, true", first, again, other)
+	}
+
+	st := store.Status()
+	if !st.Enabled || st.Message != "back soon" || len(st.Queue) != 3 {
+		t.Errorf("unexpected state: %+v", st)
+	}
+}
+
+func TestStore_EnqueueFullQueueNotifiesOnce(t *testing.T) {
+	store := NewStore(t.TempDir())
+	store.Enable("")
+	for i := 0; i < maxQueued; i++ {
+		store.Enqueue(bus.InboundMessage{Channel: "telegram", ChatID: "1", Content: "filler"})
+	}
+
+	first, _ := store.Enqueue(bus.InboundMessage{Channel: "telegram", ChatID: "2", Content: "dropped"})
+	again, _ := store.Enqueue(bus.InboundMessage{Channel: "telegram", ChatID: "2", Content: "dropped too"})
+	if !first || again {
+		t.Errorf("first flags = %v, %v; want true, false", first, again)
+	}
+	if st := store.Status(); st.Dropped != 2 || len(st.Queue) != maxQueued {
+		t.Errorf("dropped = %d, queued = %d", st.Dropped, len(st.Queue))
+	}
+
+	// The next maintenance window tells the chat again
+	store.Disable()
+	store.Enable("")
+	if first, _ := store.Enqueue(bus.InboundMessage{Channel: "telegram", ChatID: "2", Content: "hi"}); !first {
+		t.Error("chat not told about the next maintenance window")
+	}
+}
+
+func TestService_ReplayAfterDisable(t *testing.T) {
+	ws := t.TempDir()
+	store := NewStore(ws)
+	store.Enable("")
+	store.Enqueue(bus.InboundMessage{Channel: "telegram", ChatID: "1", Content: "first"})
+	store.Enqueue(bus.InboundMessage{Channel: "discord", ChatID: "2", Content: "second"})
+
+	msgBus := bus.NewMessageBus()
+	svc := NewService(store, msgBus)
+	if n := svc.Replay(); n != 0 {
+		t.Fatalf("Replay during maintenance = %d, want 0", n)
+	}
+
+	// A second store on the same workspace (e.g. the CLI) ends maintenance
+	if n, err := NewStore(ws).Disable(); err != nil || n != 2 {
+		t.Fatalf("Disable = %d, %v", n, err)
+	}
+
+	if n := svc.Replay(); n != 2 {
+		t.Fatalf("Replay = %d, want 2", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, want := range []string{"first", "second"} {
+		msg, ok := msgBus.ConsumeInbound(ctx)
+		if !ok {
+			t.Fatalf("expected replayed message %q", want)
+		}
+		if msg.Content != want {
+			t.Errorf("content = %q, want %q", msg.Content, want)
+		}
+	}
+
+	if len(store.Status().Queue) != 0 {
+		t.Error("queue not empty after replay")
+	}
+}
@@ -0,0 +1,95 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package maintenance
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const pollInterval = 5 * time.Second
+
+// Service replays queued messages once maintenance mode is turned off,
+// whether that happened through the CLI or a chat command.
+type Service struct {
+	store    *Store
+	bus      *bus.MessageBus
+	mu       sync.Mutex
+	stopChan chan struct{}
+}
+
+// NewService creates a replay service for a store.
+func NewService(store *Store, msgBus *bus.MessageBus) *Service {
+	return &Service{
+		store: store,
+		bus:   msgBus,
+	}
+}
+
+// Start begins polling the store for messages to replay.
+func (s *Service) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopChan != nil {
+		return nil
+	}
+	s.stopChan = make(chan struct{})
+	go s.runLoop(s.stopChan)
+	return nil
+}
+
+// Stop stops the replay loop.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopChan == nil {
+		return
+	}
+	close(s.stopChan)
+	s.stopChan = nil
+}
+
+func (s *Service) runLoop(stopChan chan struct{}) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			s.Replay()
+		}
+	}
+}
+
+// Replay republishes queued messages to the inbound bus if maintenance is
+// off and returns how many were replayed.
+func (s *Service) Replay() int {
+	queue, err := s.store.Drain()
+	if err != nil {
+		logger.ErrorCF("maintenance", "Failed to drain maintenance queue", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return 0
+	}
+	if len(queue) == 0 {
+		return 0
+	}
+
+	logger.InfoCF("maintenance", "Replaying messages queued during maintenance", map[string]interface{}{
+		"count": len(queue),
+	})
+	for _, msg := range queue {
+		s.bus.PublishInbound(msg)
+	}
+	return len(queue)
+}
@@ -0,0 +1,150 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package maintenance implements a switch that pauses message processing
+// without shutting channels down. While enabled, inbound messages are
+// answered with an away message and queued; they are replayed through the
+// bus once maintenance ends.
+package maintenance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// maxQueued bounds the queue so a long maintenance window can't grow the
+// state file without limit. Messages beyond the limit are dropped.
+const maxQueued = 500
+
+// State is the persisted maintenance state.
+type State struct {
+	Enabled bool                 `json:"enabled"`
+	Message string               `json:"message,omitempty"`
+	Since   time.Time            `json:"since,omitempty"`
+	Queue   []bus.InboundMessage `json:"queue,omitempty"`
+	Dropped int                  `json:"dropped,omitempty"`
+	// Notified are the chats ("channel:chat_id") that got the away
+	// message, kept apart from the queue so a chat whose messages are all
+	// dropped isn't told again with each one.
+	Notified []string `json:"notified,omitempty"`
+}
+
+// Store persists maintenance state in workspace/state/maintenance.json.
+// The file is re-read on every operation so the CLI and a running gateway
+// can share it.
+type Store struct {
+	path string
+	mu   *sync.Mutex // shared with the replay service
+}
+
+// NewStore creates a maintenance store for a workspace.
+func NewStore(workspace string) *Store {
+	path := filepath.Join(workspace, "state", "maintenance.json")
+	return &Store{path: path, mu: utils.FileLock(path)}
+}
+
+// Enable turns maintenance mode on. An empty message means the configured
+// default away message is used.
+func (s *Store) Enable(message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.load()
+	if !st.Enabled {
+		st.Since = time.Now()
+	}
+	st.Enabled = true
+	st.Message = message
+	return s.save(st)
+}
+
+// Disable turns maintenance mode off and returns the number of queued
+// messages waiting to be processed.
+func (s *Store) Disable() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.load()
+	st.Enabled = false
+	st.Message = ""
+	st.Since = time.Time{}
+	st.Notified = nil
+	return len(st.Queue), s.save(st)
+}
+
+// Status returns the current state.
+func (s *Store) Status() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Enqueue stores an inbound message for later processing. It returns true
+// for the chat's first message of the maintenance window, queued or not,
+// so callers can send the away message only once per chat.
+func (s *Store) Enqueue(msg bus.InboundMessage) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.load()
+	chat := msg.Channel + ":" + msg.ChatID
+	first := !slices.Contains(st.Notified, chat)
+	if first {
+		st.Notified = append(st.Notified, chat)
+	}
+
+	if len(st.Queue) >= maxQueued {
+		st.Dropped++
+		return first, s.save(st)
+	}
+	st.Queue = append(st.Queue, msg)
+	return first, s.save(st)
+}
+
+// Drain removes and returns all queued messages if maintenance is off.
+// While maintenance is on it returns nil and leaves the queue untouched.
+func (s *Store) Drain() ([]bus.InboundMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.load()
+	if st.Enabled || len(st.Queue) == 0 {
+		return nil, nil
+	}
+	queue := st.Queue
+	st.Queue = nil
+	st.Dropped = 0
+	return queue, s.save(st)
+}
+
+func (s *Store) load() State {
+	var st State
+	if raw, err := os.ReadFile(s.path); err == nil {
+		json.Unmarshal(raw, &st)
+	}
+	return st
+}
+
+func (s *Store) save(st State) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
@@ -0,0 +1,353 @@
+// Package markdown converts the Markdown models write into the formatting
+// each chat app understands.
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// Dialect is a chat app's message formatting.
+type Dialect int
+
+const (
+	// Discord renders most Markdown, but not tables or images.
+	Discord Dialect = iota
+	// TelegramHTML is the HTML subset of Telegram's HTML parse mode.
+	TelegramHTML
+	// Slack is Slack's mrkdwn.
+	Slack
+	// Plain is text without markup, for channels that show it verbatim.
+	Plain
+)
+
+// tableWidth is the widest a table may be to be kept as an aligned block;
+// wider tables become one line per row, which wraps better on phones.
+const tableWidth = 60
+
+var (
+	codeBlockRe   = regexp.MustCompile("(?s)```([\\w+#.-]*)[ \\t]*\\n?(.*?)```")
+	inlineCodeRe  = regexp.MustCompile("`([^`\\n]+)`")
+	placeholderRe = regexp.MustCompile("\x00(\\d+)\x00")
+
+	headerRe     = regexp.MustCompile(`(?m)^(#{1,6})\s+(.+?)\s*#*$`)
+	bulletRe     = regexp.MustCompile(`(?m)^(\s*)[-*+]\s+`)
+	quoteRe      = regexp.MustCompile(`(?m)^>\s?`)
+	imageRe      = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	linkRe       = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	boldRe       = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	italicStarRe = regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*\n]*?)\*([^*\w]|$)`)
+	italicUndRe  = regexp.MustCompile(`(^|\W)_([^_\s][^_\n]*?)_(\W|$)`)
+	strikeRe     = regexp.MustCompile(`~~(.+?)~~`)
+	tableSepRe   = regexp.MustCompile(`^\|?\s*:?-{2,}:?\s*(\|\s*:?-{2,}:?\s*)*\|?$`)
+)
+
+// code is a code block or inline code span kept out of the conversion.
+type code struct {
+	lang  string
+	body  string
+	block bool
+}
+
+// Format converts Markdown text to dialect. Code blocks and inline code
+// are carried over verbatim, and tables are flattened since no chat app
+// renders them.
+func Format(text string, dialect Dialect) string {
+	if text == "" {
+		return ""
+	}
+
+	var codes []code
+	hold := func(c code) string {
+		codes = append(codes, c)
+		return fmt.Sprintf("\x00%d\x00", len(codes)-1)
+	}
+	text = codeBlockRe.ReplaceAllStringFunc(text, func(m string) string {
+		sub := codeBlockRe.FindStringSubmatch(m)
+		return hold(code{lang: sub[1], body: strings.TrimRight(sub[2], "\n"), block: true})
+	})
+	text = flattenTables(text, dialect, hold)
+	text = inlineCodeRe.ReplaceAllStringFunc(text, func(m string) string {
+		return hold(code{body: inlineCodeRe.FindStringSubmatch(m)[1]})
+	})
+
+	switch dialect {
+	case Discord:
+		text = toDiscord(text)
+	case TelegramHTML:
+		text = toTelegramHTML(text)
+	case Slack:
+		text = toSlack(text)
+	default:
+		text = toPlain(text)
+	}
+
+	return placeholderRe.ReplaceAllStringFunc(text, func(m string) string {
+		var i int
+		fmt.Sscanf(strings.Trim(m, "\x00"), "%d", &i)
+		return renderCode(codes[i], dialect)
+	})
+}
+
+// minSplitLen is the smallest chunk fitChunk splits to, so a line that
+// formatting blows up can't split the text into slivers.
+const minSplitLen = 64
+
+// Split formats text for dialect in chunks of at most maxLen characters.
+// The Markdown is split first, so code blocks are closed and reopened at
+// chunk boundaries rather than cut, and each chunk is then formatted on
+// its own.
+func Split(text string, dialect Dialect, maxLen int) []string {
+	chunks := SplitSource(text, dialect, maxLen)
+	for i, chunk := range chunks {
+		chunks[i] = Format(chunk, dialect)
+	}
+	return chunks
+}
+
+// SplitSource splits Markdown text into the chunks Split formats, for
+// callers that format each chunk more than one way. A chunk that
+// formatting lengthens past maxLen, e.g. with Telegram HTML escapes, is
+// split again.
+func SplitSource(text string, dialect Dialect, maxLen int) []string {
+	var chunks []string
+	for _, chunk := range utils.SplitMessage(text, maxLen) {
+		chunks = append(chunks, fitChunk(chunk, dialect, maxLen)...)
+	}
+	return chunks
+}
+
+// fitChunk splits chunk until each part is at most maxLen characters once
+// formatted.
+func fitChunk(chunk string, dialect Dialect, maxLen int) []string {
+	n := utf8.RuneCountInString(Format(chunk, dialect))
+	if n <= maxLen {
+		return []string{chunk}
+	}
+	// Aim for the share of the chunk that fits, less a tenth as formatting
+	// doesn't lengthen it evenly
+	splitLen := len(chunk) * maxLen / n * 9 / 10
+	if splitLen < minSplitLen || splitLen >= len(chunk) {
+		return []string{chunk}
+	}
+	var parts []string
+	for _, part := range utils.SplitMessage(chunk, splitLen) {
+		parts = append(parts, fitChunk(part, dialect, maxLen)...)
+	}
+	return parts
+}
+
+func renderCode(c code, dialect Dialect) string {
+	switch dialect {
+	case TelegramHTML:
+		if !c.block {
+			return "<code>" + escapeHTML(c.body) + "</code>"
+		}
+		if c.lang != "" {
+			return fmt.Sprintf("<pre><code class=\"language-%s\">%s</code></pre>", c.lang, escapeHTML(c.body))
+		}
+		return "<pre>" + escapeHTML(c.body) + "</pre>"
+	case Plain:
+		return c.body
+	case Slack:
+		// mrkdwn has no language tags
+		if c.block {
+			return "```\n" + c.body + "\n```"
+		}
+		return "`" + c.body + "`"
+	default:
+		if c.block {
+			return "```" + c.lang + "\n" + c.body + "\n```"
+		}
+		return "`" + c.body + "`"
+	}
+}
+
+func toDiscord(text string) string {
+	text = imageRe.ReplaceAllString(text, "$2")
+	// Discord has three header levels
+	return headerRe.ReplaceAllStringFunc(text, func(m string) string {
+		sub := headerRe.FindStringSubmatch(m)
+		if len(sub[1]) > 3 {
+			return "**" + sub[2] + "**"
+		}
+		return m
+	})
+}
+
+func toTelegramHTML(text string) string {
+	text = imageRe.ReplaceAllString(text, "$2")
+	text = quoteRe.ReplaceAllString(text, "")
+	text = escapeHTML(text)
+	text = headerRe.ReplaceAllString(text, "<b>$2</b>")
+	text = linkRe.ReplaceAllString(text, `<a href="$2">$1</a>`)
+	text = boldRe.ReplaceAllString(text, "<b>$1$2</b>")
+	text = italics(text, "<i>", "</i>")
+	text = strikeRe.ReplaceAllString(text, "<s>$1</s>")
+	return bulletRe.ReplaceAllString(text, "$1• ")
+}
+
+func toSlack(text string) string {
+	text = imageRe.ReplaceAllString(text, "$2")
+	// Quotes keep their ">", which escaping would turn into "&gt;"
+	text = quoteRe.ReplaceAllString(text, "\x02")
+	text = escapeHTML(text)
+	text = strings.ReplaceAll(text, "\x02", "> ")
+	text = linkRe.ReplaceAllString(text, "<$2|$1>")
+	// Bold is *x* in mrkdwn, so bold is held back from the italic pass
+	text = headerRe.ReplaceAllString(text, "\x01$2\x01")
+	text = boldRe.ReplaceAllString(text, "\x01$1$2\x01")
+	text = italics(text, "_", "_")
+	text = strings.ReplaceAll(text, "\x01", "*")
+	text = strikeRe.ReplaceAllString(text, "~$1~")
+	return bulletRe.ReplaceAllString(text, "$1• ")
+}
+
+func toPlain(text string) string {
+	text = imageRe.ReplaceAllString(text, "$2")
+	text = linkRe.ReplaceAllStringFunc(text, func(m string) string {
+		sub := linkRe.FindStringSubmatch(m)
+		if sub[1] == sub[2] {
+			return sub[2]
+		}
+		return sub[1] + " (" + sub[2] + ")"
+	})
+	text = headerRe.ReplaceAllString(text, "$2")
+	text = stripInline(text)
+	return bulletRe.ReplaceAllString(text, "$1• ")
+}
+
+// flattenTables replaces Markdown tables: narrow ones with an aligned
+// code block, others (and all of them in plain text) with a line per row.
+func flattenTables(text string, dialect Dialect, hold func(code) string) string {
+	lines := strings.Split(text, "\n")
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		if i+1 >= len(lines) || !strings.Contains(lines[i], "|") || !tableSepRe.MatchString(strings.TrimSpace(lines[i+1])) {
+			out = append(out, lines[i])
+			continue
+		}
+		header := tableCells(lines[i])
+		var rows [][]string
+		j := i + 2
+		for ; j < len(lines) && strings.Contains(lines[j], "|"); j++ {
+			rows = append(rows, tableCells(lines[j]))
+		}
+		if aligned, ok := alignTable(header, rows); ok && dialect != Plain {
+			out = append(out, hold(code{body: aligned, block: true}))
+		} else {
+			out = append(out, tableRows(header, rows)...)
+		}
+		i = j - 1
+	}
+	return strings.Join(out, "\n")
+}
+
+func tableCells(line string) []string {
+	line = strings.Trim(strings.TrimSpace(line), "|")
+	cells := strings.Split(line, "|")
+	for i, c := range cells {
+		cells[i] = strings.TrimSpace(c)
+	}
+	return cells
+}
+
+// alignTable renders a table as padded columns, when it is narrow enough.
+func alignTable(header []string, rows [][]string) (string, bool) {
+	all := append([][]string{header}, rows...)
+	widths := make([]int, len(header))
+	for _, row := range all {
+		for c := range widths {
+			if c < len(row) {
+				widths[c] = max(widths[c], utf8.RuneCountInString(stripInline(row[c])))
+			}
+		}
+	}
+	total := 0
+	for _, w := range widths {
+		total += w + 2
+	}
+	if total-2 > tableWidth {
+		return "", false
+	}
+
+	var sb strings.Builder
+	for r, row := range all {
+		var cells []string
+		for c, w := range widths {
+			cell := ""
+			if c < len(row) {
+				cell = stripInline(row[c])
+			}
+			cells = append(cells, cell+strings.Repeat(" ", w-utf8.RuneCountInString(cell)))
+		}
+		sb.WriteString(strings.TrimRight(strings.Join(cells, "  "), " "))
+		sb.WriteString("\n")
+		if r == 0 {
+			for c, w := range widths {
+				if c > 0 {
+					sb.WriteString("  ")
+				}
+				sb.WriteString(strings.Repeat("-", w))
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n"), true
+}
+
+// tableRows renders each row as "- first — header: cell, header: cell".
+func tableRows(header []string, rows [][]string) []string {
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		var fields []string
+		for c := 1; c < len(row); c++ {
+			if row[c] == "" {
+				continue
+			}
+			if c < len(header) && header[c] != "" {
+				fields = append(fields, header[c]+": "+row[c])
+			} else {
+				fields = append(fields, row[c])
+			}
+		}
+		line := "- " + row[0]
+		if len(fields) > 0 {
+			line += " — " + strings.Join(fields, ", ")
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// italics wraps *x* and _x_ in open and close. The patterns consume the
+// character around each match, so a second pass catches neighbours such
+// as "*a* *b*".
+func italics(text, open, close string) string {
+	for range 2 {
+		text = italicStarRe.ReplaceAllString(text, "${1}"+open+"${2}"+close+"${3}")
+		text = italicUndRe.ReplaceAllString(text, "${1}"+open+"${2}"+close+"${3}")
+	}
+	return text
+}
+
+// stripInline removes emphasis markers, for text shown verbatim.
+func stripInline(s string) string {
+	s = boldRe.ReplaceAllString(s, "$1$2")
+	s = strikeRe.ReplaceAllString(s, "$1")
+	s = italics(s, "", "")
+	return strings.ReplaceAll(s, "`", "")
+}
+
+func escapeHTML(text string) string {
+	text = strings.ReplaceAll(text, "&", "&amp;")
+	text = strings.ReplaceAll(text, "<", "&lt;")
+	return strings.ReplaceAll(text, ">", "&gt;")
+}
@@ -0,0 +1,83 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		in      string
+		want    string
+	}{
+		{"telegram emphasis", TelegramHTML, "**bold**, *it* and ~~gone~~", "<b>bold</b>, <i>it</i> and <s>gone</s>"},
+		{"telegram escapes", TelegramHTML, "a < b & `x<y`", "a &lt; b &amp; <code>x&lt;y</code>"},
+		{"telegram headers on any line", TelegramHTML, "intro\n## Plan\n- step", "intro\n<b>Plan</b>\n• step"},
+		{"telegram link", TelegramHTML, "[docs](https://x.dev/a?b=1&c=2)", `<a href="https://x.dev/a?b=1&amp;c=2">docs</a>`},
+		{"telegram code block", TelegramHTML, "```go\nif a < b {}\n```", "<pre><code class=\"language-go\">if a &lt; b {}</code></pre>"},
+		{"snake_case is not italic", TelegramHTML, "set max_tokens_total", "set max_tokens_total"},
+		{"slack", Slack, "**bold** and *it*, [site](https://x.dev)", "*bold* and _it_, <https://x.dev|site>"},
+		{"slack quote", Slack, "> said & done", "> said &amp; done"},
+		{"slack drops language", Slack, "```py\nprint(1)\n```", "```\nprint(1)\n```"},
+		{"discord deep header", Discord, "#### Notes\n## Kept", "**Notes**\n## Kept"},
+		{"discord image", Discord, "![chart](https://x.dev/c.png)", "https://x.dev/c.png"},
+		{"plain", Plain, "# Title\n**Hi** see [docs](https://x.dev) and `cmd`\n* item", "Title\nHi see docs (https://x.dev) and cmd\n• item"},
+		{"code is verbatim", Discord, "```\n**not bold** | a |\n|---|\n```", "```\n**not bold** | a |\n|---|\n```"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.in, tt.dialect); got != tt.want {
+			t.Errorf("%s: Format(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormat_Tables(t *testing.T) {
+	table := "Prices:\n| Item | Price |\n|------|------:|\n| **Tea** | 2 |\n| Cake | 3.50 |\nEnjoy"
+
+	want := "Prices:\n```\nItem  Price\n----  -----\nTea   2\nCake  3.50\n```\nEnjoy"
+	if got := Format(table, Discord); got != want {
+		t.Errorf("narrow table = %q, want %q", got, want)
+	}
+	want = "Prices:\n• Tea — Price: 2\n• Cake — Price: 3.50\nEnjoy"
+	if got := Format(table, Plain); got != want {
+		t.Errorf("plain table = %q, want %q", got, want)
+	}
+
+	wide := "| City | Notes |\n|---|---|\n| Porto | " + strings.Repeat("lovely ", 10) + "|"
+	if got := Format(wide, Slack); !strings.HasPrefix(got, "• Porto — Notes: lovely") {
+		t.Errorf("wide table = %q", got)
+	}
+}
+
+func TestSplit_FitsAfterFormatting(t *testing.T) {
+	// Escapes make the HTML far longer than the Markdown
+	text := strings.Repeat("if a < b && c > d then \"x\" & 'y'\n", 200)
+	chunks := Split(text, TelegramHTML, 3500)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks", len(chunks))
+	}
+	for i, c := range chunks {
+		if n := utf8.RuneCountInString(c); n > 3500 {
+			t.Errorf("chunk %d is %d characters, over 3500", i, n)
+		}
+	}
+	if got := strings.Count(strings.Join(chunks, "\n"), "&lt;"); got != 200 {
+		t.Errorf("%d escaped lines across the chunks, want 200", got)
+	}
+}
+
+func TestSplit_KeepsCodeBlocks(t *testing.T) {
+	text := "Here:\n```go\n" + strings.Repeat("fmt.Println(\"a < b\")\n", 60) + "```"
+	chunks := Split(text, TelegramHTML, 500)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks", len(chunks))
+	}
+	for i, c := range chunks {
+		if strings.Count(c, "<pre>") != strings.Count(c, "</pre>") || strings.Contains(c, "```") {
+			t.Errorf("chunk %d has a broken code block: %q", i, c)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package markdown
+
+import (
+	"sort"
+	"strings"
+)
+
+// CanonicalFields renames the frontmatter keys listed in aliases to the
+// canonical key they stand for, so files written for other tools or in
+// other languages read the same as ours. Keys are matched ignoring case.
+// A canonical key given directly wins over its aliases, and of two aliases
+// the one that sorts first wins; other keys are kept, lowercased.
+func CanonicalFields(fields map[string]string, aliases map[string]string) map[string]string {
+	lookup := make(map[string]string, len(aliases))
+	for alias, canonical := range aliases {
+		lookup[strings.ToLower(alias)] = canonical
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make(map[string]string, len(fields))
+	fromAlias := make(map[string]bool)
+	for _, key := range keys {
+		value := fields[key]
+		lower := strings.ToLower(strings.TrimSpace(key))
+		canonical, isAlias := lookup[lower]
+		if !isAlias {
+			canonical = lower
+		}
+		if _, seen := result[canonical]; seen && (isAlias || !fromAlias[canonical]) {
+			continue
+		}
+		result[canonical] = value
+		fromAlias[canonical] = isAlias
+	}
+	return result
+}
@@ -0,0 +1,24 @@
+package markdown
+
+import "testing"
+
+func TestCanonicalFields(t *testing.T) {
+	aliases := map[string]string{"title": "name", "名称": "name", "summary": "description"}
+
+	got := CanonicalFields(map[string]string{
+		"Title":   "weather",
+		"summary": "Get the forecast",
+		"Version": "1.2",
+	}, aliases)
+	if got["name"] != "weather" || got["description"] != "Get the forecast" || got["version"] != "1.2" {
+		t.Errorf("aliases not mapped: %v", got)
+	}
+	if _, ok := got["title"]; ok {
+		t.Errorf("alias key kept: %v", got)
+	}
+
+	got = CanonicalFields(map[string]string{"名称": "天气", "name": "weather"}, aliases)
+	if got["name"] != "weather" {
+		t.Errorf("canonical key should win over alias, got %q", got["name"])
+	}
+}
@@ -0,0 +1,338 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package privacy implements user data deletion ("forget me") across the
+// workspace: sessions, memory, the user profile and any registered stores.
+package privacy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/session"
+)
+
+// minTermLength guards against purging with a term so short it would match
+// unrelated lines all over memory (e.g. a user ID of "1").
+const minTermLength = 3
+
+// Store is a per-user data store that can be purged alongside sessions and
+// memory, e.g. an audit log or usage ledger.
+type Store interface {
+	Name() string
+	// Purge removes records for userID and returns how many were affected.
+	// With dryRun set it only counts them.
+	Purge(userID string, dryRun bool) (int, error)
+}
+
+// Report describes what a purge removed, or would remove for a dry run.
+type Report struct {
+	UserID           string
+	Sessions         []string       // session keys deleted
+	SummariesCleared []string       // other sessions whose summary mentioned the user
+	PinsRemoved      map[string]int // other session key -> pins mentioning the user
+	FileLines        map[string]int // memory/profile file -> lines removed
+	Stores           map[string]int // registered store name -> records removed
+	// SharedSessions are kept sessions the user may have written in: the
+	// main DM session, which all users share with the default dm_scope,
+	// and group chats. Their messages don't record who sent them, so the
+	// user's can't be told apart and removed.
+	SharedSessions []string
+}
+
+// Empty reports whether nothing matched.
+func (r *Report) Empty() bool {
+	if len(r.Sessions) > 0 || len(r.SummariesCleared) > 0 || len(r.SharedSessions) > 0 {
+		return false
+	}
+	for _, n := range r.FileLines {
+		if n > 0 {
+			return false
+		}
+	}
+	for _, n := range r.PinsRemoved {
+		if n > 0 {
+			return false
+		}
+	}
+	for _, n := range r.Stores {
+		if n > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the report as a short human-readable list.
+func (r *Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "User: %s\n", r.UserID)
+	fmt.Fprintf(&sb, "Sessions: %d\n", len(r.Sessions))
+	for _, key := range r.Sessions {
+		fmt.Fprintf(&sb, "  - %s\n", key)
+	}
+	if len(r.SummariesCleared) > 0 {
+		fmt.Fprintf(&sb, "Summaries mentioning user: %d\n", len(r.SummariesCleared))
+	}
+	if pins := r.pinCount(); pins > 0 {
+		fmt.Fprintf(&sb, "Pins mentioning user: %d\n", pins)
+	}
+	if len(r.SharedSessions) > 0 {
+		fmt.Fprintf(&sb, "Shared sessions NOT purged: %d (messages there aren't stored per sender, so the user's can't be removed)\n", len(r.SharedSessions))
+		for _, key := range r.SharedSessions {
+			fmt.Fprintf(&sb, "  - %s\n", key)
+		}
+	}
+
+	files := make([]string, 0, len(r.FileLines))
+	for f := range r.FileLines {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		fmt.Fprintf(&sb, "%s: %d line(s)\n", f, r.FileLines[f])
+	}
+
+	names := make([]string, 0, len(r.Stores))
+	for name := range r.Stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%s: %d record(s)\n", name, r.Stores[name])
+	}
+	return sb.String()
+}
+
+func (r *Report) pinCount() int {
+	n := 0
+	for _, pins := range r.PinsRemoved {
+		n += pins
+	}
+	return n
+}
+
+// Purger deletes everything a workspace holds about a single user.
+type Purger struct {
+	workspace string
+	sessions  *session.SessionManager
+	stores    []Store
+}
+
+// NewPurger creates a purger for a workspace. sessions should be the live
+// session manager when running inside the agent, so in-memory sessions are
+// dropped along with their files.
+func NewPurger(workspace string, sessions *session.SessionManager) *Purger {
+	return &Purger{
+		workspace: workspace,
+		sessions:  sessions,
+	}
+}
+
+// AddStore registers an additional per-user store to purge.
+func (p *Purger) AddStore(s Store) {
+	p.stores = append(p.stores, s)
+}
+
+// Plan reports what Purge would delete without changing anything.
+func (p *Purger) Plan(userID string, aliases []string) (*Report, error) {
+	return p.run(userID, aliases, true)
+}
+
+// Purge deletes the user's sessions, drops summaries and pins of other
+// sessions that mention the user (or any alias), scrubs lines mentioning
+// them from memory and USER.md, purges registered stores and records a
+// tombstone so stale summaries can't re-teach what was removed.
+func (p *Purger) Purge(userID string, aliases []string) (*Report, error) {
+	return p.run(userID, aliases, false)
+}
+
+func (p *Purger) run(userID string, aliases []string, dryRun bool) (*Report, error) {
+	userID = strings.TrimSpace(userID)
+	terms, err := purgeTerms(userID, aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		UserID:      userID,
+		PinsRemoved: make(map[string]int),
+		FileLines:   make(map[string]int),
+		Stores:      make(map[string]int),
+	}
+
+	if p.sessions != nil {
+		for _, key := range p.sessions.Keys() {
+			if sessionKeyHasPeer(key, userID) {
+				report.Sessions = append(report.Sessions, key)
+				if !dryRun {
+					if err := p.sessions.Delete(key); err != nil {
+						return report, fmt.Errorf("delete session %s: %w", key, err)
+					}
+				}
+				continue
+			}
+			if sharedSession(key) && len(p.sessions.GetHistory(key)) > 0 {
+				report.SharedSessions = append(report.SharedSessions, key)
+			}
+			changed := false
+			if containsAny(p.sessions.GetSummary(key), terms) {
+				report.SummariesCleared = append(report.SummariesCleared, key)
+				if !dryRun {
+					p.sessions.SetSummary(key, "")
+					changed = true
+				}
+			}
+			// Pins outlive pruning, so a pin quoting the user would
+			// otherwise stay in the chat's prompt indefinitely.
+			pins := p.sessions.GetPins(key)
+			kept := pins[:0:0]
+			for _, pin := range pins {
+				if containsAny(pin.User, terms) || containsAny(pin.Assistant, terms) {
+					continue
+				}
+				kept = append(kept, pin)
+			}
+			if n := len(pins) - len(kept); n > 0 {
+				report.PinsRemoved[key] = n
+				if !dryRun {
+					p.sessions.SetPins(key, kept)
+					changed = true
+				}
+			}
+			if changed {
+				if err := p.sessions.Save(key); err != nil {
+					return report, fmt.Errorf("save session %s: %w", key, err)
+				}
+			}
+		}
+		sort.Strings(report.Sessions)
+		sort.Strings(report.SummariesCleared)
+		sort.Strings(report.SharedSessions)
+	}
+
+	for _, path := range p.userFiles() {
+		n, err := scrubFile(path, terms, dryRun)
+		if err != nil {
+			return report, err
+		}
+		if n > 0 {
+			rel, relErr := filepath.Rel(p.workspace, path)
+			if relErr != nil {
+				rel = path
+			}
+			report.FileLines[rel] = n
+		}
+	}
+
+	for _, s := range p.stores {
+		n, err := s.Purge(userID, dryRun)
+		if err != nil {
+			return report, fmt.Errorf("purge %s: %w", s.Name(), err)
+		}
+		report.Stores[s.Name()] = n
+	}
+
+	if !dryRun {
+		if err := LoadTombstones(p.workspace).Add(userID, terms); err != nil {
+			return report, fmt.Errorf("write tombstone: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+// userFiles lists the files that may mention a user: long-term memory,
+// daily notes and the USER.md profile.
+func (p *Purger) userFiles() []string {
+	files := []string{
+		filepath.Join(p.workspace, "USER.md"),
+		filepath.Join(p.workspace, "memory", "MEMORY.md"),
+	}
+	notes, _ := filepath.Glob(filepath.Join(p.workspace, "memory", "[0-9][0-9][0-9][0-9][0-9][0-9]", "*.md"))
+	sort.Strings(notes)
+	return append(files, notes...)
+}
+
+func purgeTerms(userID string, aliases []string) ([]string, error) {
+	if len(userID) < minTermLength {
+		return nil, fmt.Errorf("user ID %q is too short (min %d characters)", userID, minTermLength)
+	}
+	terms := []string{userID}
+	for _, a := range aliases {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+		if len(a) < minTermLength {
+			return nil, fmt.Errorf("alias %q is too short (min %d characters)", a, minTermLength)
+		}
+		terms = append(terms, a)
+	}
+	return terms, nil
+}
+
+// sessionKeyHasPeer reports whether a session key is a direct conversation
+// with userID, whose ID is the peer after "direct"
+// (e.g. "agent:main:telegram:direct:12345"). Other segments name agents,
+// channels and groups, never the user.
+func sessionKeyHasPeer(key, userID string) bool {
+	i := strings.LastIndex(key, ":direct:")
+	return i >= 0 && strings.EqualFold(key[i+len(":direct:"):], userID)
+}
+
+// sharedSession reports whether a session key is one several users write
+// in: the agent's main session ("agent:main:main") or a group or channel
+// ("agent:main:discord:group:123").
+func sharedSession(key string) bool {
+	return strings.HasPrefix(key, "agent:") && !strings.Contains(key, ":direct:")
+}
+
+func containsAny(text string, terms []string) bool {
+	lower := strings.ToLower(text)
+	for _, term := range terms {
+		if strings.Contains(lower, strings.ToLower(term)) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeMatchingLines drops lines that mention any term (case-insensitive).
+func removeMatchingLines(text string, terms []string) (string, int) {
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	removed := 0
+	for _, line := range lines {
+		if containsAny(line, terms) {
+			removed++
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n"), removed
+}
+
+func scrubFile(path string, terms []string, dryRun bool) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	kept, removed := removeMatchingLines(string(data), terms)
+	if removed == 0 || dryRun {
+		return removed, nil
+	}
+	if err := os.WriteFile(path, []byte(kept), 0644); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
@@ -0,0 +1,155 @@
+package privacy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/session"
+)
+
+func setupWorkspace(t *testing.T) (string, *session.SessionManager) {
+	t.Helper()
+	ws := t.TempDir()
+	sm := session.NewSessionManager(filepath.Join(ws, "sessions"))
+
+	sm.AddMessage("agent:main:telegram:direct:12345", "user", "hi")
+	sm.Save("agent:main:telegram:direct:12345")
+	sm.AddMessage("agent:main:telegram:group:-100", "user", "hello all")
+	sm.SetSummary("agent:main:telegram:group:-100", "Alice asked about trains.\nBob likes tea.")
+	sm.AddPin("agent:main:telegram:group:-100", session.Pin{User: "Alice: which platform?", Assistant: "Platform 2."})
+	sm.AddPin("agent:main:telegram:group:-100", session.Pin{User: "Bob: which tea?", Assistant: "Earl Grey."})
+	sm.Save("agent:main:telegram:group:-100")
+
+	os.MkdirAll(filepath.Join(ws, "memory", "202602"), 0755)
+	os.WriteFile(filepath.Join(ws, "memory", "MEMORY.md"), []byte("# Memory\n- Alice (12345) is vegetarian\n- Bob prefers tea\n"), 0644)
+	os.WriteFile(filepath.Join(ws, "memory", "202602", "20260203.md"), []byte("# 2026-02-03\nalice booked a flight\n"), 0644)
+	return ws, sm
+}
+
+func TestPurger_PlanDoesNotModify(t *testing.T) {
+	ws, sm := setupWorkspace(t)
+	p := NewPurger(ws, sm)
+
+	report, err := p.Plan("12345", []string{"Alice"})
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if len(report.Sessions) != 1 || len(report.SummariesCleared) != 1 || report.PinsRemoved["agent:main:telegram:group:-100"] != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	if len(sm.GetPins("agent:main:telegram:group:-100")) != 2 {
+		t.Error("Plan removed pins")
+	}
+	if report.FileLines[filepath.Join("memory", "MEMORY.md")] != 1 {
+		t.Errorf("MEMORY.md lines = %d, want 1", report.FileLines[filepath.Join("memory", "MEMORY.md")])
+	}
+
+	if len(sm.Keys()) != 2 {
+		t.Error("Plan deleted sessions")
+	}
+	data, _ := os.ReadFile(filepath.Join(ws, "memory", "MEMORY.md"))
+	if !strings.Contains(string(data), "Alice") {
+		t.Error("Plan modified MEMORY.md")
+	}
+	if LoadTombstones(ws).IsForgotten("12345") {
+		t.Error("Plan wrote a tombstone")
+	}
+}
+
+func TestPurger_Purge(t *testing.T) {
+	ws, sm := setupWorkspace(t)
+	p := NewPurger(ws, sm)
+
+	if _, err := p.Purge("12345", []string{"Alice"}); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+
+	keys := sm.Keys()
+	if len(keys) != 1 || keys[0] != "agent:main:telegram:group:-100" {
+		t.Errorf("remaining sessions = %v", keys)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "sessions", "agent_main_telegram_direct_12345.json")); !os.IsNotExist(err) {
+		t.Error("session file still exists")
+	}
+	if sm.GetSummary("agent:main:telegram:group:-100") != "" {
+		t.Error("summary mentioning user was not cleared")
+	}
+	if pins := sm.GetPins("agent:main:telegram:group:-100"); len(pins) != 1 || pins[0].Assistant != "Earl Grey." {
+		t.Errorf("pins = %+v", pins)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(ws, "memory", "MEMORY.md"))
+	if strings.Contains(string(data), "Alice") || !strings.Contains(string(data), "Bob prefers tea") {
+		t.Errorf("MEMORY.md = %q", data)
+	}
+	data, _ = os.ReadFile(filepath.Join(ws, "memory", "202602", "20260203.md"))
+	if strings.Contains(strings.ToLower(string(data)), "alice") {
+		t.Errorf("daily note not scrubbed: %q", data)
+	}
+
+	tombstones := LoadTombstones(ws)
+	if !tombstones.IsForgotten("12345") {
+		t.Fatal("expected tombstone")
+	}
+	if got := tombstones.Redact("Alice likes jazz\nBob likes tea"); got != "Bob likes tea" {
+		t.Errorf("Redact = %q", got)
+	}
+}
+
+func TestPurger_RejectsShortTerms(t *testing.T) {
+	p := NewPurger(t.TempDir(), nil)
+	if _, err := p.Plan("1", nil); err == nil {
+		t.Error("expected error for short user ID")
+	}
+	if _, err := p.Plan("12345", []string{"Al"}); err == nil {
+		t.Error("expected error for short alias")
+	}
+}
+
+func TestSessionKeyHasPeer(t *testing.T) {
+	for _, tc := range []struct {
+		key, userID string
+		want        bool
+	}{
+		{"agent:main:telegram:direct:12345", "12345", true},
+		{"agent:main:direct:Alice", "alice", true},
+		{"agent:main:telegram:default:direct:12345", "12345", true},
+		{"agent:main:telegram:direct:12345", "telegram", false},
+		{"agent:main:telegram:direct:12345", "main", false},
+		{"agent:main:main", "main", false},
+		{"agent:main:discord:group:12345", "12345", false},
+	} {
+		if got := sessionKeyHasPeer(tc.key, tc.userID); got != tc.want {
+			t.Errorf("sessionKeyHasPeer(%q, %q) = %v, want %v", tc.key, tc.userID, got, tc.want)
+		}
+	}
+}
+
+func TestPurger_ReportsSharedSessions(t *testing.T) {
+	ws := t.TempDir()
+	sm := session.NewSessionManager(filepath.Join(ws, "sessions"))
+	// With dm_scope "main" every DM lands in the agent's main session
+	sm.AddMessage("agent:main:main", "user", "my blood test came back fine")
+	sm.AddMessage("agent:main:telegram:group:-100", "user", "hello all")
+	sm.GetOrCreate("agent:main:discord:group:7") // nothing said there yet
+
+	report, err := NewPurger(ws, sm).Purge("12345", nil)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if len(report.Sessions) != 0 {
+		t.Errorf("deleted shared sessions %v", report.Sessions)
+	}
+	want := []string{"agent:main:main", "agent:main:telegram:group:-100"}
+	if strings.Join(report.SharedSessions, ",") != strings.Join(want, ",") {
+		t.Errorf("shared sessions = %v, want %v", report.SharedSessions, want)
+	}
+	if report.Empty() || !strings.Contains(report.String(), "Shared sessions NOT purged: 2") {
+		t.Errorf("report doesn't say shared sessions were kept:\n%s", report)
+	}
+	if len(sm.GetHistory("agent:main:main")) != 1 {
+		t.Error("main session history changed")
+	}
+}
@@ -0,0 +1,137 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package privacy
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TombstoneTTL is how long a purged user's terms keep being redacted from
+// memory and summaries. It covers the window where stale summaries or
+// notes could otherwise re-teach the agent what was just deleted.
+const TombstoneTTL = 30 * 24 * time.Hour
+
+// Tombstone records that a user's data was purged.
+type Tombstone struct {
+	UserID      string    `json:"user_id"`
+	Terms       []string  `json:"terms"`
+	ForgottenAt time.Time `json:"forgotten_at"`
+}
+
+// Tombstones is the persistent list of purged users for a workspace,
+// stored in workspace/state/tombstones.json.
+type Tombstones struct {
+	path    string
+	entries []Tombstone
+	mu      sync.RWMutex
+}
+
+// LoadTombstones reads the tombstone list for a workspace.
+// A missing or unreadable file yields an empty list.
+func LoadTombstones(workspace string) *Tombstones {
+	t := &Tombstones{
+		path: filepath.Join(workspace, "state", "tombstones.json"),
+	}
+	if data, err := os.ReadFile(t.path); err == nil {
+		json.Unmarshal(data, &t.entries)
+	}
+	return t
+}
+
+// Add records a tombstone for userID and saves the list.
+// Expired entries are dropped on save.
+func (t *Tombstones) Add(userID string, terms []string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	kept := make([]Tombstone, 0, len(t.entries)+1)
+	for _, e := range t.entries {
+		if e.UserID == userID || now.Sub(e.ForgottenAt) > TombstoneTTL {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	kept = append(kept, Tombstone{
+		UserID:      userID,
+		Terms:       terms,
+		ForgottenAt: now,
+	})
+	t.entries = kept
+
+	return t.save()
+}
+
+// Active returns the tombstones that have not yet expired.
+func (t *Tombstones) Active() []Tombstone {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	now := time.Now()
+	var active []Tombstone
+	for _, e := range t.entries {
+		if now.Sub(e.ForgottenAt) <= TombstoneTTL {
+			active = append(active, e)
+		}
+	}
+	return active
+}
+
+// IsForgotten reports whether userID has an active tombstone.
+func (t *Tombstones) IsForgotten(userID string) bool {
+	for _, e := range t.Active() {
+		if strings.EqualFold(e.UserID, userID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact removes every line of text that mentions an actively tombstoned term.
+func (t *Tombstones) Redact(text string) string {
+	active := t.Active()
+	if len(active) == 0 || text == "" {
+		return text
+	}
+
+	var terms []string
+	for _, e := range active {
+		terms = append(terms, e.Terms...)
+	}
+
+	kept, _ := removeMatchingLines(text, terms)
+	return kept
+}
+
+// Mentions reports whether text mentions an actively tombstoned term.
+func (t *Tombstones) Mentions(text string) bool {
+	for _, e := range t.Active() {
+		if containsAny(text, e.Terms) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Tombstones) save() error {
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(t.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := t.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, t.path)
+}
@@ -0,0 +1,178 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package proactive decides whether a message the agent sends on its own
+// (a briefing, follow-up, nudge or digest) may go out, based on how often
+// the chat wants to hear from the agent.
+package proactive
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// Frequency levels a chat can choose.
+const (
+	Off    = "off"
+	Low    = "low"
+	Normal = "normal"
+	High   = "high"
+)
+
+// Levels lists the frequency levels from least to most contact.
+var Levels = []string{Off, Low, Normal, High}
+
+// ValidLevel reports whether level is one of Levels.
+func ValidLevel(level string) bool {
+	for _, l := range Levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+type sentCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+type storeData struct {
+	Levels map[string]string    `json:"levels,omitempty"`
+	Sent   map[string]sentCount `json:"sent,omitempty"`
+}
+
+// Engine keeps each chat's frequency level and counts the proactive
+// messages sent to it today, in workspace/state/proactive.json. The file
+// is re-read on every call so the agent's tool and the channel manager
+// can share it.
+type Engine struct {
+	path string
+	cfg  config.ProactiveConfig
+	mu   *sync.Mutex // shared with other engines on the same file
+}
+
+// NewEngine creates the engine for a workspace.
+func NewEngine(workspace string, cfg config.ProactiveConfig) *Engine {
+	path := filepath.Join(workspace, "state", "proactive.json")
+	return &Engine{
+		path: path,
+		cfg:  cfg,
+		mu:   utils.FileLock(path),
+	}
+}
+
+func chatKey(channel, chatID string) string {
+	return channel + ":" + chatID
+}
+
+// Level returns the chat's frequency level, or the configured default.
+func (e *Engine) Level(channel, chatID string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.level(e.load(), channel, chatID)
+}
+
+// SetLevel sets the chat's frequency level.
+func (e *Engine) SetLevel(channel, chatID, level string) error {
+	if !ValidLevel(level) {
+		return fmt.Errorf("level must be one of %s", strings.Join(Levels, ", "))
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	data := e.load()
+	if data.Levels == nil {
+		data.Levels = make(map[string]string)
+	}
+	data.Levels[chatKey(channel, chatID)] = level
+	return e.save(data)
+}
+
+// DailyLimit returns how many proactive messages a level allows a day,
+// or -1 for no limit.
+func (e *Engine) DailyLimit(level string) int {
+	switch level {
+	case Off:
+		return 0
+	case Low:
+		return e.cfg.LowPerDay
+	case High:
+		return -1
+	}
+	return e.cfg.NormalPerDay
+}
+
+// Allow reports whether one more proactive message may go to the chat
+// today, and counts it if so.
+func (e *Engine) Allow(channel, chatID string, now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	data := e.load()
+	limit := e.DailyLimit(e.level(data, channel, chatID))
+	if limit < 0 {
+		return true
+	}
+
+	key := chatKey(channel, chatID)
+	today := now.Format("2006-01-02")
+	sent := data.Sent[key]
+	if sent.Date != today {
+		sent = sentCount{Date: today}
+	}
+	if sent.Count >= limit {
+		return false
+	}
+	sent.Count++
+	if data.Sent == nil {
+		data.Sent = make(map[string]sentCount)
+	}
+	data.Sent[key] = sent
+	// A failed save only means the count may be off for today
+	e.save(data)
+	return true
+}
+
+func (e *Engine) level(data storeData, channel, chatID string) string {
+	if level, ok := data.Levels[chatKey(channel, chatID)]; ok {
+		return level
+	}
+	if ValidLevel(e.cfg.DefaultLevel) {
+		return e.cfg.DefaultLevel
+	}
+	return Normal
+}
+
+func (e *Engine) load() storeData {
+	var data storeData
+	if raw, err := os.ReadFile(e.path); err == nil {
+		json.Unmarshal(raw, &data)
+	}
+	return data
+}
+
+func (e *Engine) save(data storeData) error {
+	if err := os.MkdirAll(filepath.Dir(e.path), 0755); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := e.path + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, e.path)
+}
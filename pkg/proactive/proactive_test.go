@@ -0,0 +1,44 @@
+package proactive
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func TestEngineAllow(t *testing.T) {
+	ws := t.TempDir()
+	cfg := config.ProactiveConfig{DefaultLevel: Normal, LowPerDay: 1, NormalPerDay: 2}
+	e := NewEngine(ws, cfg)
+	morning := time.Date(2026, 10, 15, 8, 0, 0, 0, time.Local)
+
+	for i, want := range []bool{true, true, false} {
+		if got := e.Allow("telegram", "42", morning); got != want {
+			t.Errorf("normal message %d allowed = %v, want %v", i+1, got, want)
+		}
+	}
+	if !e.Allow("telegram", "42", morning.AddDate(0, 0, 1)) {
+		t.Error("count not reset the next day")
+	}
+
+	// Levels are per chat and shared through the state file
+	if err := NewEngine(ws, cfg).SetLevel("telegram", "7", Off); err != nil {
+		t.Fatalf("SetLevel: %v", err)
+	}
+	if e.Allow("telegram", "7", morning) {
+		t.Error("off allowed a message")
+	}
+	e.SetLevel("discord", "1", High)
+	for i := 0; i < 10; i++ {
+		if !e.Allow("discord", "1", morning) {
+			t.Fatal("high limited a message")
+		}
+	}
+	if e.Level("slack", "C1") != Normal {
+		t.Errorf("default level = %s", e.Level("slack", "C1"))
+	}
+	if err := e.SetLevel("slack", "C1", "sometimes"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
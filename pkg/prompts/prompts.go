@@ -0,0 +1,262 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package prompts is a library of reusable prompt snippets stored as
+// markdown files in workspace/prompts. A prompt's frontmatter declares
+// the variables its body uses as {{name}}:
+//
+//	---
+//	description: Review code for a given focus
+//	variables:
+//	  - name: focus
+//	    required: true
+//	  - name: language
+//	    default: Go
+//	---
+//	Review the following {{language}} code, focusing on {{focus}}.
+//
+// Users invoke a prompt with "/prompt review focus=security" and the
+// expanded text becomes their message.
+package prompts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Prompt is one prompt from the library.
+type Prompt struct {
+	Name        string
+	Description string
+	Variables   []Variable
+	Body        string
+}
+
+// Variable is a placeholder in a prompt's body.
+type Variable struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Default     string `yaml:"default"`
+	Required    bool   `yaml:"required"`
+}
+
+// UnmarshalYAML also accepts a bare variable name.
+func (v *Variable) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		v.Name = node.Value
+		return nil
+	}
+	type plain Variable
+	return node.Decode((*plain)(v))
+}
+
+type frontmatter struct {
+	Name        string     `yaml:"name"`
+	Description string     `yaml:"description"`
+	Variables   []Variable `yaml:"variables"`
+}
+
+var (
+	frontmatterRe = regexp.MustCompile(`(?s)^---\r?\n(.*?)\r?\n---(?:\r?\n)*`)
+	placeholderRe = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_-]+)\s*\}\}`)
+)
+
+// Dir returns the prompts directory of a workspace.
+func Dir(workspace string) string {
+	return filepath.Join(workspace, "prompts")
+}
+
+// Load reads every prompt in dir, sorted by name. A missing directory
+// has no prompts.
+func Load(dir string) ([]*Prompt, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var prompts []*Prompt
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".md" {
+			continue
+		}
+		p, err := LoadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, p)
+	}
+	sort.Slice(prompts, func(i, j int) bool { return prompts[i].Name < prompts[j].Name })
+	return prompts, nil
+}
+
+// LoadFile reads one prompt. The name defaults to the file name without
+// its extension.
+func LoadFile(path string) (*Prompt, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content := string(raw)
+
+	var fm frontmatter
+	if m := frontmatterRe.FindStringSubmatch(content); m != nil {
+		if err := yaml.Unmarshal([]byte(m[1]), &fm); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		content = content[len(m[0]):]
+	}
+	if fm.Name == "" {
+		fm.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return &Prompt{
+		Name:        fm.Name,
+		Description: fm.Description,
+		Variables:   fm.Variables,
+		Body:        strings.TrimSpace(content),
+	}, nil
+}
+
+// Find returns the prompt called name in dir.
+func Find(dir, name string) (*Prompt, error) {
+	prompts, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range prompts {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("no prompt named %q", name)
+}
+
+// Expand fills the body's placeholders from values, falling back to each
+// variable's default. Placeholders the frontmatter doesn't declare are
+// filled too when values has them, and left as-is otherwise.
+func (p *Prompt) Expand(values map[string]string) (string, error) {
+	filled := make(map[string]string, len(values))
+	for k, v := range values {
+		filled[k] = v
+	}
+	var missing []string
+	for _, v := range p.Variables {
+		if filled[v.Name] != "" {
+			continue
+		}
+		if v.Default == "" && v.Required {
+			missing = append(missing, v.Name)
+			continue
+		}
+		filled[v.Name] = v.Default
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing %s. Usage: %s", strings.Join(missing, ", "), p.Usage())
+	}
+
+	return placeholderRe.ReplaceAllStringFunc(p.Body, func(m string) string {
+		name := placeholderRe.FindStringSubmatch(m)[1]
+		if v, ok := filled[name]; ok {
+			return v
+		}
+		return m
+	}), nil
+}
+
+// ExpandArgs expands the prompt with the arguments of a /prompt command.
+// Free text fills {{input}} when the body has it, and is appended to the
+// prompt otherwise.
+func (p *Prompt) ExpandArgs(args string) (string, error) {
+	values, rest := ParseArgs(args)
+	usesInput := false
+	for _, m := range placeholderRe.FindAllStringSubmatch(p.Body, -1) {
+		usesInput = usesInput || m[1] == "input"
+	}
+	if rest != "" && usesInput && values["input"] == "" {
+		values["input"] = rest
+		rest = ""
+	}
+
+	text, err := p.Expand(values)
+	if err != nil {
+		return "", err
+	}
+	if rest != "" {
+		text += "\n\n" + rest
+	}
+	return text, nil
+}
+
+// Usage shows how to invoke the prompt, e.g.
+// "/prompt review focus=<focus> [language=Go]".
+func (p *Prompt) Usage() string {
+	var sb strings.Builder
+	sb.WriteString("/prompt " + p.Name)
+	for _, v := range p.Variables {
+		switch {
+		case v.Default != "":
+			fmt.Fprintf(&sb, " [%s=%s]", v.Name, v.Default)
+		case v.Required:
+			fmt.Fprintf(&sb, " %s=<%s>", v.Name, v.Name)
+		default:
+			fmt.Fprintf(&sb, " [%s=...]", v.Name)
+		}
+	}
+	return sb.String()
+}
+
+// ParseArgs splits the text after "/prompt <name>" into the leading
+// key=value pairs and the free text after them, which keeps its line
+// breaks. Values can be quoted: topic="rust async".
+func ParseArgs(s string) (map[string]string, string) {
+	values := map[string]string{}
+	rest := strings.TrimSpace(s)
+	for rest != "" {
+		tok, after := nextToken(rest)
+		k, v, ok := strings.Cut(tok, "=")
+		if !ok || k == "" || strings.ContainsAny(k, "\"'") {
+			break
+		}
+		values[k] = unquote(v)
+		rest = strings.TrimLeft(after, " \t\r\n")
+	}
+	return values, rest
+}
+
+// nextToken returns the text up to the first whitespace outside single
+// or double quotes, and what follows it.
+func nextToken(s string) (string, string) {
+	var quote rune
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ' ' || r == '\t' || r == '\r' || r == '\n':
+			return s[:i], s[i:]
+		}
+	}
+	return s, ""
+}
+
+// unquote strips the quotes from a quoted value, e.g. the "rust async" in
+// topic="rust async".
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
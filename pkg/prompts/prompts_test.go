@@ -0,0 +1,75 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const reviewPrompt = `---
+description: Review code for a given focus
+variables:
+  - name: focus
+    required: true
+  - name: language
+    default: Go
+  - input
+---
+Review the following {{language}} code, focusing on {{ focus }}.
+
+{{input}}
+`
+
+func TestLoadAndExpand(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "review.md"), []byte(reviewPrompt), 0644)
+	os.WriteFile(filepath.Join(dir, "standup.md"), []byte("Summarize my day as a standup update for {{team}}."), 0644)
+
+	library, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(library) != 2 || library[0].Name != "review" || len(library[0].Variables) != 3 {
+		t.Fatalf("Load = %+v", library)
+	}
+	if got := library[0].Usage(); got != "/prompt review focus=<focus> [language=Go] [input=...]" {
+		t.Errorf("Usage = %q", got)
+	}
+
+	p, err := Find(dir, "Review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.ExpandArgs(` focus="error handling" func main() {
+	panic("x")
+}`)
+	if err != nil {
+		t.Fatalf("ExpandArgs: %v", err)
+	}
+	want := "Review the following Go code, focusing on error handling.\n\nfunc main() {\n\tpanic(\"x\")\n}"
+	if got != want {
+		t.Errorf("ExpandArgs = %q, want %q", got, want)
+	}
+
+	if _, err := p.ExpandArgs("language=Rust"); err == nil || !strings.Contains(err.Error(), "missing focus") {
+		t.Errorf("missing required variable: err = %v", err)
+	}
+
+	// Without an {{input}} placeholder, free text is appended
+	standup, _ := Find(dir, "standup")
+	got, _ = standup.ExpandArgs("team=platform shipped the release")
+	if got != "Summarize my day as a standup update for platform.\n\nshipped the release" {
+		t.Errorf("standup = %q", got)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	values, rest := ParseArgs(`a=1 b='two words' c="x=y"  then free text a=2`)
+	if values["a"] != "1" || values["b"] != "two words" || values["c"] != "x=y" || len(values) != 3 {
+		t.Errorf("values = %v", values)
+	}
+	if rest != "then free text a=2" {
+		t.Errorf("rest = %q", rest)
+	}
+}
@@ -19,6 +19,7 @@ type UsageInfo = protocoltypes.UsageInfo
 type Message = protocoltypes.Message
 type ToolDefinition = protocoltypes.ToolDefinition
 type ToolFunctionDefinition = protocoltypes.ToolFunctionDefinition
+type ResponseFormat = protocoltypes.ResponseFormat
 
 const defaultBaseURL = "https://api.anthropic.com"
 
@@ -99,16 +100,14 @@ func buildParams(messages []Message, tools []ToolDefinition, model string, optio
 	for _, msg := range messages {
 		switch msg.Role {
 		case "system":
-			system = append(system, anthropic.TextBlockParam{Text: msg.Content})
+			system = append(system, systemBlocks(msg)...)
 		case "user":
 			if msg.ToolCallID != "" {
 				anthropicMessages = append(anthropicMessages,
 					anthropic.NewUserMessage(anthropic.NewToolResultBlock(msg.ToolCallID, msg.Content, false)),
 				)
 			} else {
-				anthropicMessages = append(anthropicMessages,
-					anthropic.NewUserMessage(anthropic.NewTextBlock(msg.Content)),
-				)
+				anthropicMessages = append(anthropicMessages, anthropic.NewUserMessage(userBlocks(msg)...))
 			}
 		case "assistant":
 			if len(msg.ToolCalls) > 0 {
@@ -143,9 +142,21 @@ func buildParams(messages []Message, tools []ToolDefinition, model string, optio
 		MaxTokens: maxTokens,
 	}
 
+	// Claude has no JSON mode, so the format is asked for in the system
+	// prompt
+	if rf, ok := options["response_format"].(ResponseFormat); ok {
+		instruction := "Reply with only a JSON object, without code fences or any other text."
+		if rf.Schema != nil {
+			schema, _ := json.Marshal(rf.Schema)
+			instruction += " It must follow this JSON schema:\n" + string(schema)
+		}
+		system = append(system, anthropic.TextBlockParam{Text: instruction})
+	}
+
 	if len(system) > 0 {
 		params.System = system
 	}
+	cacheConversation(system, anthropicMessages)
 
 	if temp, ok := options["temperature"].(float64); ok {
 		params.Temperature = anthropic.Float(temp)
@@ -158,6 +169,65 @@ func buildParams(messages []Message, tools []ToolDefinition, model string, optio
 	return params, nil
 }
 
+// maxCacheBreakpoints is how many cache_control breakpoints the API takes
+// in one request.
+const maxCacheBreakpoints = 4
+
+// systemBlocks splits a system message at its cache breaks, with a cache
+// breakpoint closing each part that ends at one.
+func systemBlocks(msg Message) []anthropic.TextBlockParam {
+	var blocks []anthropic.TextBlockParam
+	start := 0
+	for _, end := range msg.CacheBreaks {
+		if end <= start || end > len(msg.Content) || len(blocks) == maxCacheBreakpoints-1 {
+			continue
+		}
+		blocks = append(blocks, anthropic.TextBlockParam{
+			Text:         msg.Content[start:end],
+			CacheControl: anthropic.NewCacheControlEphemeralParam(),
+		})
+		start = end
+	}
+	if start < len(msg.Content) || len(blocks) == 0 {
+		blocks = append(blocks, anthropic.TextBlockParam{Text: msg.Content[start:]})
+	}
+	return blocks
+}
+
+// cacheConversation puts a cache breakpoint on the last block of the
+// conversation, so the next call of a turn, after a tool call, reads the
+// conversation so far from the cache. It only does so when the system
+// prompt is cached, and a breakpoint is left.
+func cacheConversation(system []anthropic.TextBlockParam, messages []anthropic.MessageParam) {
+	used := 0
+	for _, block := range system {
+		if block.CacheControl.Type != "" {
+			used++
+		}
+	}
+	if used == 0 || used >= maxCacheBreakpoints || len(messages) == 0 {
+		return
+	}
+	content := messages[len(messages)-1].Content
+	if len(content) == 0 {
+		return
+	}
+	if cc := content[len(content)-1].GetCacheControl(); cc != nil {
+		*cc = anthropic.NewCacheControlEphemeralParam()
+	}
+}
+
+// userBlocks is the text of a user message followed by its images.
+func userBlocks(msg Message) []anthropic.ContentBlockParamUnion {
+	blocks := []anthropic.ContentBlockParamUnion{anthropic.NewTextBlock(msg.Content)}
+	for _, part := range msg.Parts {
+		if mimeType, data, ok := part.Image(); ok {
+			blocks = append(blocks, anthropic.NewImageBlockBase64(mimeType, data))
+		}
+	}
+	return blocks
+}
+
 func translateTools(tools []ToolDefinition) []anthropic.ToolUnionParam {
 	result := make([]anthropic.ToolUnionParam, 0, len(tools))
 	for _, t := range tools {
@@ -218,14 +288,18 @@ func parseResponse(resp *anthropic.Message) *LLMResponse {
 		finishReason = "stop"
 	}
 
+	// input_tokens leaves out the tokens read from or written to the cache
+	prompt := resp.Usage.InputTokens + resp.Usage.CacheReadInputTokens + resp.Usage.CacheCreationInputTokens
 	return &LLMResponse{
 		Content:      content,
 		ToolCalls:    toolCalls,
 		FinishReason: finishReason,
 		Usage: &UsageInfo{
-			PromptTokens:     int(resp.Usage.InputTokens),
+			PromptTokens:     int(prompt),
 			CompletionTokens: int(resp.Usage.OutputTokens),
-			TotalTokens:      int(resp.Usage.InputTokens + resp.Usage.OutputTokens),
+			TotalTokens:      int(prompt + resp.Usage.OutputTokens),
+			CacheReadTokens:  int(resp.Usage.CacheReadInputTokens),
+			CacheWriteTokens: int(resp.Usage.CacheCreationInputTokens),
 		},
 	}
 }
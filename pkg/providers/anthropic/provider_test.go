@@ -52,6 +52,49 @@ func TestBuildParams_SystemMessage(t *testing.T) {
 	}
 }
 
+func TestBuildParams_CacheBreaks(t *testing.T) {
+	system := "identity and bootstrap|memory|time"
+	messages := []Message{
+		{Role: "system", Content: system, CacheBreaks: []int{22, 29}},
+		{Role: "user", Content: "Hi"},
+		{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_1", Name: "clock"}}},
+		{Role: "tool", Content: "12:00", ToolCallID: "call_1"},
+	}
+	params, err := buildParams(messages, nil, "claude-sonnet-4.6", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("buildParams() error: %v", err)
+	}
+	if len(params.System) != 3 {
+		t.Fatalf("len(System) = %d, want 3", len(params.System))
+	}
+	for i, want := range []struct {
+		text   string
+		cached bool
+	}{{"identity and bootstrap", true}, {"|memory", true}, {"|time", false}} {
+		got := params.System[i]
+		if got.Text != want.text || (got.CacheControl.Type != "") != want.cached {
+			t.Errorf("System[%d] = %q cached %q, want %q cached %v", i, got.Text, got.CacheControl.Type, want.text, want.cached)
+		}
+	}
+
+	last := params.Messages[len(params.Messages)-1].Content
+	if cc := last[len(last)-1].GetCacheControl(); cc == nil || cc.Type != "ephemeral" {
+		t.Errorf("last block cache control = %+v, want ephemeral", cc)
+	}
+	if cc := params.Messages[0].Content[0].GetCacheControl(); cc == nil || cc.Type != "" {
+		t.Errorf("first message cached: %+v", cc)
+	}
+
+	// Without cache breaks nothing is cached
+	params, _ = buildParams([]Message{{Role: "system", Content: system}, {Role: "user", Content: "Hi"}}, nil, "claude-sonnet-4.6", nil)
+	if len(params.System) != 1 || params.System[0].CacheControl.Type != "" {
+		t.Errorf("System = %+v, want one uncached block", params.System)
+	}
+	if cc := params.Messages[0].Content[0].GetCacheControl(); cc.Type != "" {
+		t.Errorf("message cached without a cached system prompt")
+	}
+}
+
 func TestBuildParams_ToolCallMessage(t *testing.T) {
 	messages := []Message{
 		{Role: "user", Content: "What's the weather?"},
@@ -115,6 +158,13 @@ func TestParseResponse_TextOnly(t *testing.T) {
 	if result.Usage.PromptTokens != 10 {
 		t.Errorf("PromptTokens = %d, want 10", result.Usage.PromptTokens)
 	}
+
+	resp.Usage.CacheReadInputTokens = 1000
+	resp.Usage.CacheCreationInputTokens = 200
+	cached := parseResponse(resp).Usage
+	if cached.PromptTokens != 1210 || cached.CacheReadTokens != 1000 || cached.CacheWriteTokens != 200 {
+		t.Errorf("cached usage = %+v, want 1210 prompt tokens with 1000 read and 200 written", cached)
+	}
 	if result.Usage.CompletionTokens != 20 {
 		t.Errorf("CompletionTokens = %d, want 20", result.Usage.CompletionTokens)
 	}
@@ -164,6 +164,12 @@ type antigravityPart struct {
 	ThoughtSignatureSnake string                       `json:"thought_signature,omitempty"`
 	FunctionCall          *antigravityFunctionCall     `json:"functionCall,omitempty"`
 	FunctionResponse      *antigravityFunctionResponse `json:"functionResponse,omitempty"`
+	InlineData            *antigravityInlineData       `json:"inlineData,omitempty"`
+}
+
+type antigravityInlineData struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
 }
 
 type antigravityFunctionCall struct {
@@ -193,6 +199,7 @@ type antigravitySystemPrompt struct {
 type antigravityGenConfig struct {
 	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
 	Temperature     float64 `json:"temperature,omitempty"`
+	TopP            float64 `json:"topP,omitempty"`
 }
 
 func (p *AntigravityProvider) buildRequest(messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) antigravityRequest {
@@ -222,9 +229,15 @@ func (p *AntigravityProvider) buildRequest(messages []Message, tools []ToolDefin
 					}},
 				})
 			} else {
+				parts := []antigravityPart{{Text: msg.Content}}
+				for _, part := range msg.Parts {
+					if mimeType, data, ok := part.Image(); ok {
+						parts = append(parts, antigravityPart{InlineData: &antigravityInlineData{MimeType: mimeType, Data: data}})
+					}
+				}
 				req.Contents = append(req.Contents, antigravityContent{
 					Role:  "user",
-					Parts: []antigravityPart{{Text: msg.Content}},
+					Parts: parts,
 				})
 			}
 		case "assistant":
@@ -304,7 +317,10 @@ func (p *AntigravityProvider) buildRequest(messages []Message, tools []ToolDefin
 	if temp, ok := options["temperature"].(float64); ok {
 		config.Temperature = temp
 	}
-	if config.MaxOutputTokens > 0 || config.Temperature > 0 {
+	if topP, ok := options["top_p"].(float64); ok {
+		config.TopP = topP
+	}
+	if config.MaxOutputTokens > 0 || config.Temperature > 0 || config.TopP > 0 {
 		req.Config = config
 	}
 
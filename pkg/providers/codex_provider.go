@@ -204,7 +204,7 @@ func buildCodexParams(messages []Message, tools []ToolDefinition, model string,
 				inputItems = append(inputItems, responses.ResponseInputItemUnionParam{
 					OfMessage: &responses.EasyInputMessageParam{
 						Role:    responses.EasyInputMessageRoleUser,
-						Content: responses.EasyInputMessageContentUnionParam{OfString: openai.Opt(msg.Content)},
+						Content: codexUserContent(msg),
 					},
 				})
 			}
@@ -406,3 +406,26 @@ func createCodexTokenSource() func() (string, string, error) {
 		return cred.AccessToken, cred.AccountID, nil
 	}
 }
+
+// codexUserContent is the text of a user message, followed by its images
+// as input_image parts when it has any.
+func codexUserContent(msg Message) responses.EasyInputMessageContentUnionParam {
+	if len(msg.Parts) == 0 {
+		return responses.EasyInputMessageContentUnionParam{OfString: openai.Opt(msg.Content)}
+	}
+	list := responses.ResponseInputMessageContentListParam{
+		responses.ResponseInputContentParamOfInputText(msg.Content),
+	}
+	for _, part := range msg.Parts {
+		if part.ImageURL == nil {
+			continue
+		}
+		list = append(list, responses.ResponseInputContentUnionParam{
+			OfInputImage: &responses.ResponseInputImageParam{
+				Detail:   responses.ResponseInputImageDetailAuto,
+				ImageURL: openai.String(part.ImageURL.URL),
+			},
+		})
+	}
+	return responses.EasyInputMessageContentUnionParam{OfInputItemContentList: list}
+}
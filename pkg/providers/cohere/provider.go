@@ -0,0 +1,306 @@
+package cohereprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/providers/protocoltypes"
+)
+
+type ToolCall = protocoltypes.ToolCall
+type LLMResponse = protocoltypes.LLMResponse
+type UsageInfo = protocoltypes.UsageInfo
+type Message = protocoltypes.Message
+type ContentPart = protocoltypes.ContentPart
+type ToolDefinition = protocoltypes.ToolDefinition
+type ResponseFormat = protocoltypes.ResponseFormat
+
+const defaultBaseURL = "https://api.cohere.com/v2"
+
+// Provider talks to Cohere's v2 chat API. It differs from the OpenAI one
+// in what matters here: an assistant message calling tools carries its
+// text as a tool_plan, tool results are documents, the reply's content is
+// a list of parts, and usage and finish reasons have their own names.
+type Provider struct {
+	apiKey     string
+	apiBase    string
+	httpClient *http.Client
+}
+
+func NewProvider(apiKey, apiBase, proxy string) *Provider {
+	client := &http.Client{
+		Timeout: 120 * time.Second,
+	}
+	if proxy != "" {
+		parsed, err := url.Parse(proxy)
+		if err == nil {
+			client.Transport = &http.Transport{
+				Proxy: http.ProxyURL(parsed),
+			}
+		} else {
+			log.Printf("cohere: invalid proxy URL %q: %v", proxy, err)
+		}
+	}
+
+	base := strings.TrimRight(strings.TrimSpace(apiBase), "/")
+	if base == "" {
+		base = defaultBaseURL
+	}
+	return &Provider{
+		apiKey:     apiKey,
+		apiBase:    base,
+		httpClient: client,
+	}
+}
+
+func (p *Provider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	body, err := json.Marshal(buildRequest(messages, tools, model, options))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.apiBase+"/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed:\n  Status: %d\n  Body:   %s", resp.StatusCode, string(respBody))
+	}
+
+	return parseResponse(respBody)
+}
+
+func (p *Provider) GetDefaultModel() string {
+	return "command-a-03-2025"
+}
+
+func buildRequest(messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) map[string]interface{} {
+	request := map[string]interface{}{
+		"model":    model,
+		"messages": translateMessages(messages),
+	}
+	if len(tools) > 0 {
+		request["tools"] = tools
+	}
+	if maxTokens, ok := asInt(options["max_tokens"]); ok {
+		request["max_tokens"] = maxTokens
+	}
+	if temperature, ok := asFloat(options["temperature"]); ok {
+		request["temperature"] = temperature
+	}
+	if topP, ok := asFloat(options["top_p"]); ok {
+		request["p"] = topP
+	}
+	if rf, ok := options["response_format"].(ResponseFormat); ok {
+		format := map[string]interface{}{"type": "json_object"}
+		if rf.Schema != nil {
+			format["json_schema"] = rf.Schema
+		}
+		request["response_format"] = format
+	}
+	return request
+}
+
+func translateMessages(messages []Message) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(messages))
+	for _, msg := range messages {
+		switch {
+		case msg.Role == "tool" || (msg.Role == "user" && msg.ToolCallID != ""):
+			out = append(out, map[string]interface{}{
+				"role":         "tool",
+				"tool_call_id": msg.ToolCallID,
+				"content": []map[string]interface{}{{
+					"type":     "document",
+					"document": map[string]interface{}{"data": msg.Content},
+				}},
+			})
+		case msg.Role == "assistant" && len(msg.ToolCalls) > 0:
+			m := map[string]interface{}{
+				"role":       "assistant",
+				"tool_calls": translateToolCalls(msg.ToolCalls),
+			}
+			if msg.Content != "" {
+				m["tool_plan"] = msg.Content
+			}
+			out = append(out, m)
+		case msg.Role == "user" && len(msg.Parts) > 0:
+			parts := make([]ContentPart, 0, len(msg.Parts)+1)
+			if msg.Content != "" {
+				parts = append(parts, ContentPart{Type: "text", Text: msg.Content})
+			}
+			parts = append(parts, msg.Parts...)
+			out = append(out, map[string]interface{}{"role": "user", "content": parts})
+		default:
+			out = append(out, map[string]interface{}{"role": msg.Role, "content": msg.Content})
+		}
+	}
+	return out
+}
+
+// translateToolCalls writes tool calls the way Cohere takes them back,
+// with the arguments as a JSON string.
+func translateToolCalls(calls []ToolCall) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(calls))
+	for _, tc := range calls {
+		name := tc.Name
+		arguments := "{}"
+		if tc.Arguments != nil {
+			if data, err := json.Marshal(tc.Arguments); err == nil {
+				arguments = string(data)
+			}
+		}
+		if tc.Function != nil {
+			if name == "" {
+				name = tc.Function.Name
+			}
+			if tc.Arguments == nil && tc.Function.Arguments != "" {
+				arguments = tc.Function.Arguments
+			}
+		}
+		out = append(out, map[string]interface{}{
+			"id":   tc.ID,
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":      name,
+				"arguments": arguments,
+			},
+		})
+	}
+	return out
+}
+
+func parseResponse(body []byte) (*LLMResponse, error) {
+	var apiResponse struct {
+		FinishReason string `json:"finish_reason"`
+		Message      struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+			ToolPlan  string `json:"tool_plan"`
+			ToolCalls []struct {
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"message"`
+		Usage struct {
+			BilledUnits struct {
+				InputTokens  float64 `json:"input_tokens"`
+				OutputTokens float64 `json:"output_tokens"`
+			} `json:"billed_units"`
+			Tokens struct {
+				InputTokens  float64 `json:"input_tokens"`
+				OutputTokens float64 `json:"output_tokens"`
+			} `json:"tokens"`
+			CachedTokens float64 `json:"cached_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	var content strings.Builder
+	for _, part := range apiResponse.Message.Content {
+		if part.Type == "text" {
+			content.WriteString(part.Text)
+		}
+	}
+
+	toolCalls := make([]ToolCall, 0, len(apiResponse.Message.ToolCalls))
+	for _, tc := range apiResponse.Message.ToolCalls {
+		arguments := make(map[string]interface{})
+		if tc.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(tc.Function.Arguments), &arguments); err != nil {
+				log.Printf("cohere: failed to decode tool call arguments for %q: %v", tc.Function.Name, err)
+				arguments["raw"] = tc.Function.Arguments
+			}
+		}
+		toolCalls = append(toolCalls, ToolCall{
+			ID:        tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: arguments,
+		})
+	}
+	// The plan is the text that comes with tool calls
+	text := content.String()
+	if text == "" && len(toolCalls) > 0 {
+		text = apiResponse.Message.ToolPlan
+	}
+
+	finishReason := "stop"
+	switch apiResponse.FinishReason {
+	case "TOOL_CALL":
+		finishReason = "tool_calls"
+	case "MAX_TOKENS":
+		finishReason = "length"
+	}
+
+	// tokens counts what the model saw; billed_units stands in when it is
+	// missing
+	usage := apiResponse.Usage.Tokens
+	if usage.InputTokens == 0 && usage.OutputTokens == 0 {
+		usage = apiResponse.Usage.BilledUnits
+	}
+	return &LLMResponse{
+		Content:      text,
+		ToolCalls:    toolCalls,
+		FinishReason: finishReason,
+		Usage: &UsageInfo{
+			PromptTokens:     int(usage.InputTokens),
+			CompletionTokens: int(usage.OutputTokens),
+			TotalTokens:      int(usage.InputTokens + usage.OutputTokens),
+			CacheReadTokens:  int(apiResponse.Usage.CachedTokens),
+		},
+	}, nil
+}
+
+func asInt(v interface{}) (int, bool) {
+	switch val := v.(type) {
+	case int:
+		return val, true
+	case int64:
+		return int(val), true
+	case float64:
+		return int(val), true
+	default:
+		return 0, false
+	}
+}
+
+func asFloat(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	default:
+		return 0, false
+	}
+}
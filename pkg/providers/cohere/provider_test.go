@@ -0,0 +1,148 @@
+package cohereprovider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/providers/protocoltypes"
+)
+
+func TestProviderChat_ToolRoundTrip(t *testing.T) {
+	var requestBody map[string]interface{}
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "r1",
+			"finish_reason": "TOOL_CALL",
+			"message": {
+				"role": "assistant",
+				"tool_plan": "I will check the weather.",
+				"tool_calls": [{"id": "weather_1", "type": "function", "function": {"name": "weather", "arguments": "{\"city\":\"Oslo\"}"}}]
+			},
+			"usage": {"billed_units": {"input_tokens": 10, "output_tokens": 5}, "tokens": {"input_tokens": 120, "output_tokens": 30}}
+		}`))
+	}))
+	defer server.Close()
+
+	messages := []Message{
+		{Role: "system", Content: "You are picoclaw."},
+		{Role: "user", Content: "weather in Bergen?"},
+		{Role: "assistant", Content: "Checking.", ToolCalls: []ToolCall{{ID: "weather_0", Name: "weather", Arguments: map[string]interface{}{"city": "Bergen"}}}},
+		{Role: "tool", ToolCallID: "weather_0", Content: "rain"},
+		{Role: "assistant", Content: "It rains in Bergen."},
+		{Role: "user", Content: "and Oslo?"},
+	}
+	tools := []ToolDefinition{{Type: "function", Function: protocoltypes.ToolFunctionDefinition{
+		Name:        "weather",
+		Description: "Get the weather",
+		Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}}},
+	}}}
+
+	p := NewProvider("key", server.URL+"/", "")
+	resp, err := p.Chat(t.Context(), messages, tools, "command-r-08-2024", map[string]interface{}{"max_tokens": 512, "temperature": 0.3})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if auth != "Bearer key" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if requestBody["model"] != "command-r-08-2024" || requestBody["max_tokens"] != float64(512) || requestBody["temperature"] != 0.3 {
+		t.Errorf("request = %v", requestBody)
+	}
+	sent := requestBody["messages"].([]interface{})
+	if len(sent) != 6 {
+		t.Fatalf("sent %d messages", len(sent))
+	}
+	call := sent[2].(map[string]interface{})
+	if call["tool_plan"] != "Checking." || call["content"] != nil {
+		t.Errorf("assistant tool call = %v", call)
+	}
+	fn := call["tool_calls"].([]interface{})[0].(map[string]interface{})["function"].(map[string]interface{})
+	if fn["name"] != "weather" || fn["arguments"] != `{"city":"Bergen"}` {
+		t.Errorf("tool call function = %v", fn)
+	}
+	result := sent[3].(map[string]interface{})
+	doc := result["content"].([]interface{})[0].(map[string]interface{})
+	if result["role"] != "tool" || result["tool_call_id"] != "weather_0" || doc["type"] != "document" ||
+		doc["document"].(map[string]interface{})["data"] != "rain" {
+		t.Errorf("tool result = %v", result)
+	}
+
+	if resp.FinishReason != "tool_calls" || resp.Content != "I will check the weather." {
+		t.Errorf("response = %+v", resp)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "weather_1" || resp.ToolCalls[0].Arguments["city"] != "Oslo" {
+		t.Errorf("tool calls = %+v", resp.ToolCalls)
+	}
+	if resp.Usage.PromptTokens != 120 || resp.Usage.CompletionTokens != 30 || resp.Usage.TotalTokens != 150 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+}
+
+func TestParseResponse_Text(t *testing.T) {
+	resp, err := parseResponse([]byte(`{
+		"finish_reason": "MAX_TOKENS",
+		"message": {"role": "assistant", "content": [{"type": "text", "text": "Hello"}, {"type": "text", "text": " there"}]},
+		"usage": {"billed_units": {"input_tokens": 7, "output_tokens": 2}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "Hello there" || resp.FinishReason != "length" || len(resp.ToolCalls) != 0 {
+		t.Errorf("response = %+v", resp)
+	}
+	if resp.Usage.PromptTokens != 7 || resp.Usage.CompletionTokens != 2 {
+		t.Errorf("usage without tokens = %+v", resp.Usage)
+	}
+}
+
+func TestBuildRequest_ResponseFormatAndImages(t *testing.T) {
+	schema := map[string]interface{}{"type": "object"}
+	req := buildRequest([]Message{{
+		Role:    "user",
+		Content: "what is this?",
+		Parts:   []ContentPart{{Type: "image_url", ImageURL: &protocoltypes.ImageURL{URL: "data:image/png;base64,AAAA"}}},
+	}}, nil, "command-a-vision-07-2025", map[string]interface{}{
+		"response_format": ResponseFormat{Name: "answer", Schema: schema},
+		"top_p":           0.9,
+	})
+
+	data, _ := json.Marshal(req)
+	got := string(data)
+	for _, want := range []string{
+		`"response_format":{"json_schema":{"type":"object"},"type":"json_object"}`,
+		`"p":0.9`,
+		`{"type":"text","text":"what is this?"}`,
+		`{"type":"image_url","image_url":{"url":"data:image/png;base64,AAAA"}}`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("request %s\nlacks %s", got, want)
+		}
+	}
+	if _, ok := req["tools"]; ok {
+		t.Error("tools sent without any")
+	}
+}
+
+func TestProviderChat_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"trial key rate limit"}`, http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := NewProvider("key", server.URL, "").Chat(t.Context(), []Message{{Role: "user", Content: "hi"}}, nil, "command-r", nil)
+	if err == nil || !strings.Contains(err.Error(), "Status: 429") {
+		t.Errorf("err = %v, want the status for the error classifier", err)
+	}
+}
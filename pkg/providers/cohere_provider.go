@@ -0,0 +1,27 @@
+package providers
+
+import (
+	"context"
+
+	cohereprovider "github.com/sipeed/picoclaw/pkg/providers/cohere"
+)
+
+// CohereProvider talks to Cohere's Command models through their own chat
+// API; see cohereprovider.Provider.
+type CohereProvider struct {
+	delegate *cohereprovider.Provider
+}
+
+func NewCohereProvider(apiKey, apiBase, proxy string) *CohereProvider {
+	return &CohereProvider{
+		delegate: cohereprovider.NewProvider(apiKey, apiBase, proxy),
+	}
+}
+
+func (p *CohereProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	return p.delegate.Chat(ctx, messages, tools, model, options)
+}
+
+func (p *CohereProvider) GetDefaultModel() string {
+	return p.delegate.GetDefaultModel()
+}
@@ -0,0 +1,276 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+// ErrNoEmbeddings is returned by NewEmbeddingProvider when no embedding
+// provider is configured.
+var ErrNoEmbeddings = errors.New("embeddings are not configured")
+
+// EmbeddingProvider turns texts into vectors, one per text in the same
+// order, for memory search and retrieval.
+type EmbeddingProvider interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Default models and endpoints of the embedding providers.
+var embeddingDefaults = map[string]struct{ model, base string }{
+	"openai": {"text-embedding-3-small", "https://api.openai.com/v1"},
+	"gemini": {"text-embedding-004", "https://generativelanguage.googleapis.com/v1beta"},
+	"ollama": {"nomic-embed-text", "http://localhost:11434"},
+}
+
+// NewEmbeddingProvider creates the provider of cfg.Embeddings. A missing
+// API key, base or proxy is taken from the provider's entry in providers.
+func NewEmbeddingProvider(cfg *config.Config) (EmbeddingProvider, error) {
+	ec := cfg.Embeddings
+	name := strings.ToLower(strings.TrimSpace(ec.Provider))
+	if name == "" {
+		return nil, ErrNoEmbeddings
+	}
+	defaults, ok := embeddingDefaults[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding provider %q (want openai, gemini or ollama)", ec.Provider)
+	}
+
+	var fallback config.ProviderConfig
+	switch name {
+	case "openai":
+		fallback = cfg.Providers.OpenAI.ProviderConfig
+	case "gemini":
+		fallback = cfg.Providers.Gemini
+	case "ollama":
+		fallback = cfg.Providers.Ollama
+	}
+	apiKey := firstNonEmpty(ec.APIKey, fallback.APIKey)
+	apiBase := strings.TrimRight(firstNonEmpty(ec.APIBase, fallback.APIBase, defaults.base), "/")
+	model := firstNonEmpty(ec.Model, defaults.model)
+	if apiKey == "" && name != "ollama" {
+		return nil, fmt.Errorf("embeddings: no API key for %s", name)
+	}
+
+	client, err := embeddingClient(firstNonEmpty(ec.Proxy, fallback.Proxy))
+	if err != nil {
+		return nil, err
+	}
+	e := embedder{client: client, apiKey: apiKey, apiBase: apiBase, model: model, dimensions: ec.Dimensions}
+
+	var provider EmbeddingProvider
+	switch name {
+	case "openai":
+		provider = &openAIEmbedder{e}
+	case "gemini":
+		provider = &geminiEmbedder{e}
+	case "ollama":
+		provider = &ollamaEmbedder{e}
+	}
+	return &batchEmbedder{inner: provider, size: ec.BatchSize}, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func embeddingClient(proxy string) (*http.Client, error) {
+	client := &http.Client{Timeout: 60 * time.Second}
+	if proxy != "" {
+		parsed, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("embeddings: invalid proxy URL %q: %w", proxy, err)
+		}
+		client.Transport = &http.Transport{Proxy: http.ProxyURL(parsed)}
+	}
+	return client, nil
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, from
+// -1 to 1, or 0 when their lengths differ or either is zero.
+func CosineSimilarity(a, b []float32) float32 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(na) * math.Sqrt(nb)))
+}
+
+// batchEmbedder splits texts into requests of at most size texts, since
+// every API limits how many one request may carry.
+type batchEmbedder struct {
+	inner EmbeddingProvider
+	size  int
+}
+
+func (b *batchEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	size := b.size
+	if size <= 0 {
+		size = len(texts)
+	}
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		end := min(start+size, len(texts))
+		batch, err := b.inner.Embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("embeddings: got %d vectors for %d texts", len(batch), end-start)
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// embedder is what the implementations share.
+type embedder struct {
+	client     *http.Client
+	apiKey     string
+	apiBase    string
+	model      string
+	dimensions int
+}
+
+// post sends body as JSON to url and decodes the answer into out.
+func (e *embedder) post(ctx context.Context, url string, header http.Header, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("embeddings: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("embeddings: reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("embeddings: API error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("embeddings: decoding response: %w", err)
+	}
+	return nil
+}
+
+// openAIEmbedder calls the OpenAI embeddings API, which OpenAI-compatible
+// servers such as vLLM also serve.
+type openAIEmbedder struct{ embedder }
+
+func (e *openAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body := map[string]interface{}{"model": e.model, "input": texts}
+	if e.dimensions > 0 {
+		body["dimensions"] = e.dimensions
+	}
+	var out struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	header := http.Header{"Authorization": {"Bearer " + e.apiKey}}
+	if err := e.post(ctx, e.apiBase+"/embeddings", header, body, &out); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("embeddings: index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// geminiEmbedder calls Gemini's batchEmbedContents.
+type geminiEmbedder struct{ embedder }
+
+func (e *geminiEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	model := "models/" + strings.TrimPrefix(e.model, "models/")
+	requests := make([]map[string]interface{}, len(texts))
+	for i, text := range texts {
+		requests[i] = map[string]interface{}{
+			"model":   model,
+			"content": map[string]interface{}{"parts": []map[string]string{{"text": text}}},
+		}
+		if e.dimensions > 0 {
+			requests[i]["outputDimensionality"] = e.dimensions
+		}
+	}
+	var out struct {
+		Embeddings []struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+	}
+	header := http.Header{"X-Goog-Api-Key": {e.apiKey}}
+	if err := e.post(ctx, e.apiBase+"/"+model+":batchEmbedContents", header, map[string]interface{}{"requests": requests}, &out); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(out.Embeddings))
+	for i, emb := range out.Embeddings {
+		vectors[i] = emb.Values
+	}
+	return vectors, nil
+}
+
+// ollamaEmbedder calls a local Ollama's /api/embed.
+type ollamaEmbedder struct{ embedder }
+
+func (e *ollamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body := map[string]interface{}{"model": e.model, "input": texts}
+	if e.dimensions > 0 {
+		body["dimensions"] = e.dimensions
+	}
+	var out struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	var header http.Header
+	if e.apiKey != "" {
+		header = http.Header{"Authorization": {"Bearer " + e.apiKey}}
+	}
+	base := strings.TrimSuffix(e.apiBase, "/v1")
+	if err := e.post(ctx, base+"/api/embed", header, body, &out); err != nil {
+		return nil, err
+	}
+	return out.Embeddings, nil
+}
@@ -0,0 +1,136 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+// embeddingServer answers the three embedding APIs with vectors whose only
+// value is the length of each text, and records the request sizes.
+func embeddingServer(t *testing.T) (*httptest.Server, *[]int) {
+	t.Helper()
+	var batches []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		var texts []string
+		switch {
+		case r.URL.Path == "/embeddings" || r.URL.Path == "/api/embed":
+			for _, in := range body["input"].([]interface{}) {
+				texts = append(texts, in.(string))
+			}
+		case strings.HasSuffix(r.URL.Path, ":batchEmbedContents"):
+			if r.Header.Get("X-Goog-Api-Key") != "gk" {
+				http.Error(w, "bad key", http.StatusUnauthorized)
+				return
+			}
+			for _, req := range body["requests"].([]interface{}) {
+				parts := req.(map[string]interface{})["content"].(map[string]interface{})["parts"].([]interface{})
+				texts = append(texts, parts[0].(map[string]interface{})["text"].(string))
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		batches = append(batches, len(texts))
+
+		switch {
+		case r.URL.Path == "/embeddings":
+			// Out of order, as the API allows
+			var data []string
+			for i := len(texts) - 1; i >= 0; i-- {
+				data = append(data, fmt.Sprintf(`{"index":%d,"embedding":[%d]}`, i, len(texts[i])))
+			}
+			fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(data, ","))
+		case r.URL.Path == "/api/embed":
+			var vecs []string
+			for _, text := range texts {
+				vecs = append(vecs, fmt.Sprintf("[%d]", len(text)))
+			}
+			fmt.Fprintf(w, `{"embeddings":[%s]}`, strings.Join(vecs, ","))
+		default:
+			var vecs []string
+			for _, text := range texts {
+				vecs = append(vecs, fmt.Sprintf(`{"values":[%d]}`, len(text)))
+			}
+			fmt.Fprintf(w, `{"embeddings":[%s]}`, strings.Join(vecs, ","))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &batches
+}
+
+func TestEmbeddingProviders(t *testing.T) {
+	srv, batches := embeddingServer(t)
+	texts := []string{"a", "bb", "ccc"}
+
+	for _, tc := range []struct {
+		name string
+		ec   config.EmbeddingsConfig
+	}{
+		{"openai", config.EmbeddingsConfig{Provider: "openai", APIKey: "k", APIBase: srv.URL, BatchSize: 2}},
+		{"gemini", config.EmbeddingsConfig{Provider: "gemini", APIKey: "gk", APIBase: srv.URL, BatchSize: 2}},
+		{"ollama", config.EmbeddingsConfig{Provider: "ollama", APIBase: srv.URL + "/v1", BatchSize: 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			*batches = nil
+			cfg := config.DefaultConfig()
+			cfg.Embeddings = tc.ec
+			p, err := NewEmbeddingProvider(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vectors, err := p.Embed(context.Background(), texts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range vectors {
+				if len(v) != 1 || int(v[0]) != len(texts[i]) {
+					t.Errorf("vector %d = %v", i, v)
+				}
+			}
+			if fmt.Sprint(*batches) != "[2 1]" {
+				t.Errorf("batches = %v, want [2 1]", *batches)
+			}
+		})
+	}
+}
+
+func TestNewEmbeddingProvider_Config(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if _, err := NewEmbeddingProvider(cfg); !errors.Is(err, ErrNoEmbeddings) {
+		t.Errorf("unconfigured: err = %v", err)
+	}
+	cfg.Embeddings.Provider = "openai"
+	if _, err := NewEmbeddingProvider(cfg); err == nil {
+		t.Error("expected an error without an API key")
+	}
+	cfg.Providers.OpenAI.APIKey = "from-providers"
+	if _, err := NewEmbeddingProvider(cfg); err != nil {
+		t.Errorf("key from providers: %v", err)
+	}
+	cfg.Embeddings.Provider = "acme"
+	if _, err := NewEmbeddingProvider(cfg); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	if got := CosineSimilarity([]float32{1, 0}, []float32{2, 0}); got < 0.999 {
+		t.Errorf("parallel = %v", got)
+	}
+	if got := CosineSimilarity([]float32{1, 0}, []float32{0, 3}); got != 0 {
+		t.Errorf("orthogonal = %v", got)
+	}
+	if got := CosineSimilarity([]float32{1}, []float32{1, 2}); got != 0 {
+		t.Errorf("mismatched = %v", got)
+	}
+}
@@ -7,6 +7,7 @@ package providers
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/sipeed/picoclaw/pkg/config"
@@ -53,12 +54,35 @@ func ExtractProtocol(model string) (protocol, modelID string) {
 
 // CreateProviderFromConfig creates a provider based on the ModelConfig.
 // It uses the protocol prefix in the Model field to determine which provider to create.
-// Supported protocols: openai, anthropic, antigravity, claude-cli, codex-cli, github-copilot
+// Supported protocols: openai, anthropic, cohere, azure, antigravity, claude-cli, codex-cli, github-copilot
 // Returns the provider, the model ID (without protocol prefix), and any error.
+// With RPM or TPM set, the provider queues requests to stay under them.
+// With API keys, it rotates through them; see KeyRotatingProvider.
 func CreateProviderFromConfig(cfg *config.ModelConfig) (LLMProvider, string, error) {
 	if cfg == nil {
 		return nil, "", fmt.Errorf("config is nil")
 	}
+	create := createProviderFromConfig
+	if cfg.AuthMethod == "" && len(cfg.Keys()) > 0 {
+		create = withKeyRotation
+	}
+	provider, modelID, err := create(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	return WithRateLimit(provider, rateLimitName(cfg), cfg.RPM, cfg.TPM), modelID, nil
+}
+
+// rateLimitName names the limits of a model_list entry. Load-balanced
+// entries share a model name, so the API host tells them apart.
+func rateLimitName(cfg *config.ModelConfig) string {
+	if u, err := url.Parse(cfg.APIBase); err == nil && u.Host != "" {
+		return cfg.ModelName + "@" + u.Host
+	}
+	return cfg.ModelName
+}
+
+func createProviderFromConfig(cfg *config.ModelConfig) (LLMProvider, string, error) {
 
 	if cfg.Model == "" {
 		return nil, "", fmt.Errorf("model is required")
@@ -118,6 +142,19 @@ func CreateProviderFromConfig(cfg *config.ModelConfig) (LLMProvider, string, err
 		}
 		return NewHTTPProviderWithMaxTokensField(cfg.APIKey, apiBase, cfg.Proxy, cfg.MaxTokensField), modelID, nil
 
+	case "cohere":
+		if cfg.APIKey == "" {
+			return nil, "", fmt.Errorf("api_key is required for cohere protocol (model: %s)", cfg.Model)
+		}
+		return NewCohereProvider(cfg.APIKey, cfg.APIBase, cfg.Proxy), modelID, nil
+
+	case "azure", "azure-openai":
+		// The model ID is the deployment name and api_base the resource endpoint
+		if cfg.APIBase == "" || cfg.APIKey == "" {
+			return nil, "", fmt.Errorf("api_base (the resource endpoint) and api_key are required for azure protocol (model: %s)", cfg.Model)
+		}
+		return NewAzureHTTPProvider(cfg.APIKey, cfg.APIBase, cfg.APIVersion, cfg.Proxy, cfg.MaxTokensField), modelID, nil
+
 	case "antigravity":
 		return NewAntigravityProvider(), modelID, nil
 
@@ -124,8 +124,8 @@ func TestCreateProviderFromConfig_DefaultAPIBase(t *testing.T) {
 			}
 
 			// Verify we got an HTTPProvider for all these protocols
-			if _, ok := provider.(*HTTPProvider); !ok {
-				t.Fatalf("expected *HTTPProvider, got %T", provider)
+			if _, ok := pooled(provider).(*HTTPProvider); !ok {
+				t.Fatalf("expected *HTTPProvider, got %T", pooled(provider))
 			}
 		})
 	}
@@ -150,6 +150,56 @@ func TestCreateProviderFromConfig_Anthropic(t *testing.T) {
 	}
 }
 
+func TestCreateProviderFromConfig_Azure(t *testing.T) {
+	cfg := &config.ModelConfig{
+		ModelName:  "test-azure",
+		Model:      "azure/prod-gpt4o",
+		APIBase:    "https://res.openai.azure.com",
+		APIKey:     "test-key",
+		APIVersion: "2024-10-21",
+	}
+
+	provider, modelID, err := CreateProviderFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("CreateProviderFromConfig() error = %v", err)
+	}
+	if _, ok := pooled(provider).(*HTTPProvider); !ok {
+		t.Errorf("provider = %T, want *HTTPProvider", pooled(provider))
+	}
+	if modelID != "prod-gpt4o" {
+		t.Errorf("modelID = %q, want the deployment", modelID)
+	}
+
+	cfg.APIBase = ""
+	if _, _, err := CreateProviderFromConfig(cfg); err == nil {
+		t.Error("expected an error without the resource endpoint")
+	}
+}
+
+func TestCreateProviderFromConfig_Cohere(t *testing.T) {
+	cfg := &config.ModelConfig{
+		ModelName: "test-cohere",
+		Model:     "cohere/command-r-08-2024",
+		APIKey:    "test-key",
+	}
+
+	provider, modelID, err := CreateProviderFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("CreateProviderFromConfig() error = %v", err)
+	}
+	if _, ok := pooled(provider).(*CohereProvider); !ok {
+		t.Errorf("provider = %T, want *CohereProvider", pooled(provider))
+	}
+	if modelID != "command-r-08-2024" {
+		t.Errorf("modelID = %q, want %q", modelID, "command-r-08-2024")
+	}
+
+	cfg.APIKey = ""
+	if _, _, err := CreateProviderFromConfig(cfg); err == nil {
+		t.Error("expected an error without an API key")
+	}
+}
+
 func TestCreateProviderFromConfig_Antigravity(t *testing.T) {
 	cfg := &config.ModelConfig{
 		ModelName: "test-antigravity",
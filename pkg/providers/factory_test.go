@@ -211,8 +211,8 @@ func TestCreateProviderReturnsHTTPProviderForOpenRouter(t *testing.T) {
 		t.Fatalf("CreateProvider() error = %v", err)
 	}
 
-	if _, ok := provider.(*HTTPProvider); !ok {
-		t.Fatalf("provider type = %T, want *HTTPProvider", provider)
+	if _, ok := pooled(provider).(*HTTPProvider); !ok {
+		t.Fatalf("provider type = %T, want *HTTPProvider", pooled(provider))
 	}
 }
 
@@ -0,0 +1,251 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// healthSkipped are the protocols whose check would start a CLI process
+// rather than make an API call.
+var healthSkipped = map[string]bool{"claude-cli": true, "codex-cli": true}
+
+// HealthTarget is a provider to check and the model to ask. Err is set
+// when the provider couldn't even be created, e.g. without a key. Primary
+// marks the provider the agents use; the others are fallbacks and other
+// model_list entries.
+type HealthTarget struct {
+	Name     string
+	Provider LLMProvider
+	Model    string
+	Err      error
+	Primary  bool
+}
+
+// HealthResult is the outcome of one check.
+type HealthResult struct {
+	Name    string
+	Model   string
+	Primary bool
+	OK      bool
+	Reason  FailoverReason
+	Error   string
+	Latency time.Duration
+	Checked time.Time
+}
+
+// Summary describes the result in a line, e.g. for the gateway's startup
+// output.
+func (r HealthResult) Summary() string {
+	if r.OK {
+		return fmt.Sprintf("%s (%s) ok in %s", r.Name, r.Model, r.Latency.Round(time.Millisecond))
+	}
+	switch r.Reason {
+	case FailoverAuth:
+		return fmt.Sprintf("%s (%s): API key rejected: %s", r.Name, r.Model, r.Error)
+	case FailoverBilling:
+		return fmt.Sprintf("%s (%s): out of credit: %s", r.Name, r.Model, r.Error)
+	}
+	return fmt.Sprintf("%s (%s): %s", r.Name, r.Model, r.Error)
+}
+
+// HealthTargets returns one target per distinct provider: the default one
+// the agents use, for defaultName resolved to defaultModel, then each
+// model_list entry with an API base, key or protocol not seen yet.
+// CLI-based providers are left out.
+func HealthTargets(cfg *config.Config, defaultProvider LLMProvider, defaultName, defaultModel string) []HealthTarget {
+	var targets []HealthTarget
+	seen := make(map[string]bool)
+	key := func(mc *config.ModelConfig) string {
+		protocol, _ := ExtractProtocol(mc.Model)
+		return protocol + "|" + mc.APIBase + "|" + mc.APIKey + "|" + mc.AuthMethod
+	}
+
+	defaultProtocol := ""
+	if mc, err := cfg.GetModelConfig(defaultName); err == nil {
+		seen[key(mc)] = true
+		defaultProtocol, _ = ExtractProtocol(mc.Model)
+	}
+	if defaultProvider != nil && !healthSkipped[defaultProtocol] {
+		targets = append(targets, HealthTarget{Name: defaultName, Provider: defaultProvider, Model: defaultModel, Primary: true})
+	}
+
+	for i := range cfg.ModelList {
+		mc := cfg.ModelEntry(i)
+		protocol, _ := ExtractProtocol(mc.Model)
+		if seen[key(&mc)] || healthSkipped[protocol] {
+			continue
+		}
+		seen[key(&mc)] = true
+		if mc.Workspace == "" {
+			mc.Workspace = cfg.WorkspacePath()
+		}
+		provider, modelID, err := CreateProviderFromConfig(&mc)
+		targets = append(targets, HealthTarget{Name: mc.ModelName, Provider: provider, Model: modelID, Err: err})
+	}
+	return targets
+}
+
+// CheckHealth asks target's model for a single token, which is enough to
+// tell a rejected key, an unknown model or an unreachable endpoint.
+func CheckHealth(ctx context.Context, target HealthTarget) HealthResult {
+	result := HealthResult{Name: target.Name, Model: target.Model, Primary: target.Primary, Checked: time.Now()}
+	err := target.Err
+	if err == nil {
+		start := time.Now()
+		_, err = target.Provider.Chat(ctx, []Message{{Role: "user", Content: "ping"}}, nil, target.Model,
+			map[string]interface{}{"max_tokens": 1})
+		result.Latency = time.Since(start)
+	}
+	if err != nil {
+		result.Reason = FailoverUnknown
+		if fe := ClassifyError(err, target.Name, target.Model); fe != nil {
+			result.Reason = fe.Reason
+		}
+		result.Error = utils.Truncate(err.Error(), 200)
+		return result
+	}
+	result.OK = true
+	return result
+}
+
+// HealthChecker checks its targets now and then and reports each result,
+// so a broken key shows up before the first user message fails on it.
+type HealthChecker struct {
+	targets  []HealthTarget
+	interval time.Duration
+	timeout  time.Duration
+	onResult func(result HealthResult, changed bool)
+
+	mu      sync.RWMutex
+	results map[string]HealthResult
+	stop    chan struct{}
+}
+
+// NewHealthChecker creates a checker for targets. With an interval of 0 it
+// only checks when CheckAll is called.
+func NewHealthChecker(targets []HealthTarget, interval, timeout time.Duration) *HealthChecker {
+	return &HealthChecker{
+		targets:  targets,
+		interval: interval,
+		timeout:  timeout,
+		results:  make(map[string]HealthResult),
+	}
+}
+
+// OnResult sets fn to be called after each check, with changed true when a
+// provider became healthy or broken, or failed its first check.
+func (h *HealthChecker) OnResult(fn func(result HealthResult, changed bool)) {
+	h.onResult = fn
+}
+
+// CheckAll checks every target at once and returns the results in target
+// order.
+func (h *HealthChecker) CheckAll(ctx context.Context) []HealthResult {
+	results := make([]HealthResult, len(h.targets))
+	var wg sync.WaitGroup
+	for i, target := range h.targets {
+		wg.Add(1)
+		go func(i int, target HealthTarget) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+			results[i] = CheckHealth(checkCtx, target)
+		}(i, target)
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		h.mu.Lock()
+		previous, checked := h.results[r.Name]
+		h.results[r.Name] = r
+		h.mu.Unlock()
+		changed := (checked && previous.OK != r.OK) || (!checked && !r.OK)
+
+		if r.OK {
+			logger.DebugCF("providers", "Provider health check passed", map[string]interface{}{
+				"provider":   r.Name,
+				"model":      r.Model,
+				"latency_ms": r.Latency.Milliseconds(),
+			})
+		} else {
+			fields := map[string]interface{}{
+				"provider": r.Name,
+				"model":    r.Model,
+				"reason":   string(r.Reason),
+				"error":    r.Error,
+			}
+			if r.Primary {
+				logger.ErrorCF("providers", "Provider health check failed", fields)
+			} else {
+				logger.WarnCF("providers", "Provider health check failed", fields)
+			}
+		}
+		if h.onResult != nil {
+			h.onResult(r, changed)
+		}
+	}
+	return results
+}
+
+// Results returns the latest result of each target, by name.
+func (h *HealthChecker) Results() []HealthResult {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	results := make([]HealthResult, 0, len(h.results))
+	for _, r := range h.results {
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+// Start checks the targets every interval until Stop.
+func (h *HealthChecker) Start() error {
+	if h.interval <= 0 || len(h.targets) == 0 {
+		return nil
+	}
+	h.mu.Lock()
+	if h.stop != nil {
+		h.mu.Unlock()
+		return nil
+	}
+	h.stop = make(chan struct{})
+	stop := h.stop
+	h.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				h.CheckAll(context.Background())
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop ends the periodic checks.
+func (h *HealthChecker) Stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stop != nil {
+		close(h.stop)
+		h.stop = nil
+	}
+}
@@ -0,0 +1,86 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+type healthMockProvider struct {
+	err     error
+	options map[string]interface{}
+}
+
+func (p *healthMockProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	p.options = options
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &LLMResponse{Content: "p"}, nil
+}
+
+func (p *healthMockProvider) GetDefaultModel() string { return "mock" }
+
+func TestHealthChecker(t *testing.T) {
+	good := &healthMockProvider{}
+	bad := &healthMockProvider{err: errors.New("API error (status 401): invalid api key")}
+	checker := NewHealthChecker([]HealthTarget{
+		{Name: "good", Provider: good, Model: "m1", Primary: true},
+		{Name: "bad", Provider: bad, Model: "m2"},
+		{Name: "missing", Err: errors.New("api_key is required")},
+	}, 0, time.Second)
+
+	var changed []string
+	checker.OnResult(func(r HealthResult, c bool) {
+		if c {
+			changed = append(changed, r.Name)
+		}
+	})
+
+	results := checker.CheckAll(context.Background())
+	if !results[0].OK || results[1].OK || results[2].OK || !results[0].Primary || results[1].Primary {
+		t.Fatalf("results = %+v", results)
+	}
+	if results[1].Reason != FailoverAuth || !strings.Contains(results[1].Summary(), "API key rejected") {
+		t.Errorf("bad key summary = %q", results[1].Summary())
+	}
+	if good.options["max_tokens"] != 1 {
+		t.Errorf("check options = %v, want a single token", good.options)
+	}
+	if strings.Join(changed, ",") != "bad,missing" {
+		t.Errorf("changed = %v, want the failures", changed)
+	}
+
+	changed = nil
+	bad.err = nil
+	checker.CheckAll(context.Background())
+	if strings.Join(changed, ",") != "bad" {
+		t.Errorf("changed = %v, want the recovery", changed)
+	}
+	if got := checker.Results(); len(got) != 3 || got[0].Name != "bad" || !got[0].OK {
+		t.Errorf("latest results = %+v", got)
+	}
+}
+
+func TestHealthTargets(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ModelList = []config.ModelConfig{
+		{ModelName: "main", Model: "openai/gpt-4o", APIKey: "k1"},
+		{ModelName: "mini", Model: "openai/gpt-4o-mini", APIKey: "k1"},
+		{ModelName: "other", Model: "openai/gpt-4o", APIBase: "https://example.test/v1", APIKey: "k2"},
+		{ModelName: "cli", Model: "claude-cli/claude"},
+	}
+	targets := HealthTargets(cfg, &healthMockProvider{}, "main", "gpt-4o")
+
+	var names []string
+	for _, target := range targets {
+		names = append(names, target.Name)
+	}
+	if strings.Join(names, ",") != "main,other" {
+		t.Errorf("targets = %v, want one per provider without CLIs", names)
+	}
+}
@@ -28,10 +28,22 @@ func NewHTTPProviderWithMaxTokensField(apiKey, apiBase, proxy, maxTokensField st
 	}
 }
 
+// NewAzureHTTPProvider returns a provider for an Azure OpenAI resource;
+// see openai_compat.NewAzureProvider.
+func NewAzureHTTPProvider(apiKey, endpoint, apiVersion, proxy, maxTokensField string) *HTTPProvider {
+	return &HTTPProvider{
+		delegate: openai_compat.NewAzureProvider(apiKey, endpoint, apiVersion, proxy, maxTokensField),
+	}
+}
+
 func (p *HTTPProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
 	return p.delegate.Chat(ctx, messages, tools, model, options)
 }
 
+func (p *HTTPProvider) ChatStream(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (<-chan StreamChunk, error) {
+	return p.delegate.ChatStream(ctx, messages, tools, model, options)
+}
+
 func (p *HTTPProvider) GetDefaultModel() string {
 	return ""
 }
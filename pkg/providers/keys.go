@@ -0,0 +1,434 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// How long a key rests after the provider refused it for its rate limit
+// or its quota. A key the provider rejects as invalid rests until the
+// keys are reloaded.
+const (
+	keyRateLimitCooldown = time.Minute
+	keyBillingCooldown   = time.Hour
+)
+
+// keyPools are shared by every provider created for the same model_list
+// entry, so they rotate through one set of keys and count their usage
+// together. They are keyed by the entry's KeyPool.
+var (
+	keyPoolsMu sync.Mutex
+	keyPools   = make(map[string]*keyPool)
+)
+
+// poolKey is one API key of a pool, with its provider and usage.
+type poolKey struct {
+	key      string
+	provider LLMProvider
+	until    time.Time // resting until, after a rate limit or quota error
+	rejected bool      // refused as invalid, until the keys are reloaded
+
+	requests         int64
+	failures         int64
+	promptTokens     int64
+	completionTokens int64
+}
+
+// keyPool holds the API keys of a model_list entry. Requests stick to the
+// current key, so prompt caches stay warm, and move on to the next key
+// when the provider refuses it.
+type keyPool struct {
+	name    string
+	mu      sync.Mutex
+	keys    []*poolKey
+	current int
+	now     func() time.Time
+	// reloadable is set for the pools of model_list entries
+	reloadable bool
+}
+
+// poolID identifies a model_list entry's pool. Entries that didn't come
+// from a Config have no KeyPool, so their model name, host and first key
+// tell them apart; ReloadKeys can't find those.
+func poolID(cfg *config.ModelConfig) string {
+	if cfg.KeyPool != "" {
+		return cfg.KeyPool
+	}
+	id := rateLimitName(cfg)
+	if keys := cfg.Keys(); len(keys) > 0 {
+		id += "|" + keys[0]
+	}
+	return id
+}
+
+// tenantPoolPrefix starts the pools of tenants' own keys, which aren't
+// model_list entries and so aren't reloaded.
+const tenantPoolPrefix = "tenant:"
+
+// TenantKeyPool names the pool of a tenant's own key for the entry with
+// KeyPool pool, apart from the entry's.
+func TenantKeyPool(tenantID, pool string) string {
+	return tenantPoolPrefix + tenantID + ":" + pool
+}
+
+// sharedKeyPool returns the pool for cfg's entry, creating it with cfg's
+// keys.
+func sharedKeyPool(cfg *config.ModelConfig) (*keyPool, error) {
+	id := poolID(cfg)
+	keyPoolsMu.Lock()
+	defer keyPoolsMu.Unlock()
+	if p, ok := keyPools[id]; ok {
+		return p, nil
+	}
+	p := &keyPool{
+		name:       rateLimitName(cfg),
+		now:        time.Now,
+		reloadable: cfg.KeyPool != "" && !strings.HasPrefix(id, tenantPoolPrefix),
+	}
+	if err := p.setKeys(cfg); err != nil {
+		return nil, err
+	}
+	keyPools[id] = p
+	return p, nil
+}
+
+// setKeys replaces the pool's keys with cfg's. Keys it already had keep
+// their usage but are tried again, since a reload is when rejected keys
+// get fixed.
+func (p *keyPool) setKeys(cfg *config.ModelConfig) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	old := make(map[string]*poolKey, len(p.keys))
+	for _, k := range p.keys {
+		old[k.key] = k
+	}
+
+	var keys []*poolKey
+	for _, key := range cfg.Keys() {
+		if k, ok := old[key]; ok {
+			// Requests in flight record their usage on k, so it is kept
+			k.until = time.Time{}
+			k.rejected = false
+			keys = append(keys, k)
+			continue
+		}
+		own := *cfg
+		own.APIKey = key
+		own.APIKeys = nil
+		provider, _, err := createProviderFromConfig(&own)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, &poolKey{key: key, provider: provider})
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no API keys for %s", p.name)
+	}
+
+	p.keys = keys
+	p.current = 0
+	return nil
+}
+
+// pick returns the current key, or the next one that isn't resting.
+func (p *keyPool) pick() *poolKey {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for i := range p.keys {
+		idx := (p.current + i) % len(p.keys)
+		k := p.keys[idx]
+		if k.rejected || now.Before(k.until) {
+			continue
+		}
+		if idx != p.current {
+			logger.InfoCF("provider", "Rotating API key", map[string]interface{}{
+				"provider": p.name,
+				"key":      maskKey(k.key),
+			})
+			p.current = idx
+		}
+		return k
+	}
+	return nil
+}
+
+// record counts a request made with k, and rests k when the provider
+// refused it. It reports whether another key may succeed where k failed.
+func (p *keyPool) record(k *poolKey, usage *UsageInfo, err error) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	k.requests++
+	if usage != nil {
+		k.promptTokens += int64(usage.PromptTokens)
+		k.completionTokens += int64(usage.CompletionTokens)
+	}
+	if err == nil {
+		return false
+	}
+	k.failures++
+	// A single key is never rested, so the entry behaves like a provider
+	// without a pool until more keys are added
+	if len(p.keys) < 2 {
+		return false
+	}
+
+	reason := FailoverUnknown
+	if fe := ClassifyError(err, p.name, ""); fe != nil {
+		reason = fe.Reason
+	}
+	switch reason {
+	case FailoverAuth:
+		k.rejected = true
+	case FailoverRateLimit:
+		k.until = p.now().Add(keyRateLimitCooldown)
+	case FailoverBilling:
+		k.until = p.now().Add(keyBillingCooldown)
+	default:
+		return false
+	}
+	logger.WarnCF("provider", "API key refused, resting it", map[string]interface{}{
+		"provider": p.name,
+		"key":      maskKey(k.key),
+		"reason":   string(reason),
+		"error":    err.Error(),
+	})
+	return len(p.keys) > 1
+}
+
+// exhausted is the error when every key is resting, wrapping the error
+// of the last key tried. It reads as a rate limit while any key will come
+// back on its own, so the fallback chain doesn't take the provider for
+// broken.
+func (p *keyPool) exhausted(lastErr error) error {
+	p.mu.Lock()
+	reason := "every API key was rejected (status 401)"
+	for _, k := range p.keys {
+		if !k.rejected {
+			reason = "every API key hit its rate limit or quota (status 429)"
+			break
+		}
+	}
+	p.mu.Unlock()
+	if lastErr != nil {
+		return fmt.Errorf("%s: %s: %w", p.name, reason, lastErr)
+	}
+	return fmt.Errorf("%s: %s", p.name, reason)
+}
+
+// maskKey shows the last four characters of a key, enough to tell keys
+// apart in logs and !status.
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return "…"
+	}
+	return "…" + key[len(key)-4:]
+}
+
+// KeyRotatingProvider spreads the requests of a model_list entry over its
+// API keys: a key refused for its rate limit, its quota or as invalid is
+// rested, and the request is retried with the next one. Entries with a
+// single key get one too, so a reload can add more.
+type KeyRotatingProvider struct {
+	pool *keyPool
+}
+
+// withKeyRotation creates the provider of an entry with API keys.
+func withKeyRotation(cfg *config.ModelConfig) (LLMProvider, string, error) {
+	pool, err := sharedKeyPool(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	_, modelID := ExtractProtocol(cfg.Model)
+	return &KeyRotatingProvider{pool: pool}, modelID, nil
+}
+
+func (p *KeyRotatingProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	var lastErr error
+	for {
+		k := p.pool.pick()
+		if k == nil {
+			return nil, p.pool.exhausted(lastErr)
+		}
+		resp, err := k.provider.Chat(ctx, messages, tools, model, options)
+		var usage *UsageInfo
+		if resp != nil {
+			usage = resp.Usage
+		}
+		if !p.pool.record(k, usage, err) || ctx.Err() != nil {
+			return resp, err
+		}
+		lastErr = err
+	}
+}
+
+// ChatStream streams with the current key, moving on to the next one when
+// the stream is refused. A provider that can't stream is called with
+// Chat, and its reply comes as the only chunk.
+func (p *KeyRotatingProvider) ChatStream(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (<-chan StreamChunk, error) {
+	var lastErr error
+	for {
+		k := p.pool.pick()
+		if k == nil {
+			return nil, p.pool.exhausted(lastErr)
+		}
+		sp, ok := k.provider.(StreamingProvider)
+		if !ok {
+			resp, err := k.provider.Chat(ctx, messages, tools, model, options)
+			var usage *UsageInfo
+			if resp != nil {
+				usage = resp.Usage
+			}
+			if p.pool.record(k, usage, err) && ctx.Err() == nil {
+				lastErr = err
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			chunks := make(chan StreamChunk, 1)
+			chunks <- StreamChunk{Response: resp}
+			close(chunks)
+			return chunks, nil
+		}
+
+		inner, err := sp.ChatStream(ctx, messages, tools, model, options)
+		if err != nil {
+			if p.pool.record(k, nil, err) && ctx.Err() == nil {
+				lastErr = err
+				continue
+			}
+			return nil, err
+		}
+		chunks := make(chan StreamChunk)
+		go func() {
+			defer close(chunks)
+			var usage *UsageInfo
+			var streamErr error
+			for chunk := range inner {
+				if chunk.Response != nil {
+					usage = chunk.Response.Usage
+				}
+				if chunk.Err != nil {
+					streamErr = chunk.Err
+				}
+				chunks <- chunk
+			}
+			p.pool.record(k, usage, streamErr)
+		}()
+		return chunks, nil
+	}
+}
+
+func (p *KeyRotatingProvider) GetDefaultModel() string {
+	p.pool.mu.Lock()
+	defer p.pool.mu.Unlock()
+	return p.pool.keys[0].provider.GetDefaultModel()
+}
+
+// ReloadKeys gives the key pools of the running providers the keys of
+// cfg's model_list, so keys can be added or swapped without a restart,
+// and returns the names of the entries it updated. Entries are matched by
+// model name and position, so every key of an entry can change; a pool
+// whose entry is gone, or lost its keys, is reported in the error.
+func ReloadKeys(cfg *config.Config) ([]string, error) {
+	var updated []string
+	var errs []error
+	matched := make(map[string]bool)
+	for i := range cfg.ModelList {
+		mc := cfg.ModelEntry(i)
+		keyPoolsMu.Lock()
+		pool, ok := keyPools[poolID(&mc)]
+		keyPoolsMu.Unlock()
+		if !ok {
+			continue
+		}
+		matched[poolID(&mc)] = true
+		if err := pool.setKeys(&mc); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		updated = append(updated, pool.name)
+	}
+
+	keyPoolsMu.Lock()
+	var gone []string
+	for id, pool := range keyPools {
+		if pool.reloadable && !matched[id] {
+			gone = append(gone, pool.name)
+		}
+	}
+	keyPoolsMu.Unlock()
+	sort.Strings(gone)
+	for _, name := range gone {
+		errs = append(errs, fmt.Errorf("%s is no longer in model_list, its keys stay until a restart", name))
+	}
+	sort.Strings(updated)
+	return updated, errors.Join(errs...)
+}
+
+// KeyStats is the usage and state of one pooled API key.
+type KeyStats struct {
+	Provider         string
+	Key              string // masked
+	Requests         int64
+	Failures         int64
+	PromptTokens     int64
+	CompletionTokens int64
+	Current          bool
+	Rejected         bool
+	RestingUntil     time.Time
+}
+
+// KeyUsage returns the keys of every pool with more than one, by provider
+// and in pool order.
+func KeyUsage() []KeyStats {
+	keyPoolsMu.Lock()
+	pools := make([]*keyPool, 0, len(keyPools))
+	for _, p := range keyPools {
+		pools = append(pools, p)
+	}
+	keyPoolsMu.Unlock()
+	sort.Slice(pools, func(i, j int) bool { return pools[i].name < pools[j].name })
+
+	var stats []KeyStats
+	for _, p := range pools {
+		p.mu.Lock()
+		if len(p.keys) < 2 {
+			p.mu.Unlock()
+			continue
+		}
+		now := p.now()
+		for i, k := range p.keys {
+			s := KeyStats{
+				Provider:         p.name,
+				Key:              maskKey(k.key),
+				Requests:         k.requests,
+				Failures:         k.failures,
+				PromptTokens:     k.promptTokens,
+				CompletionTokens: k.completionTokens,
+				Current:          i == p.current,
+				Rejected:         k.rejected,
+			}
+			if now.Before(k.until) {
+				s.RestingUntil = k.until
+			}
+			stats = append(stats, s)
+		}
+		p.mu.Unlock()
+	}
+	return stats
+}
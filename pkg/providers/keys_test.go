@@ -0,0 +1,186 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+// isolateKeyPools gives the test its own key pools, so the pools other
+// tests leave behind don't show up in ReloadKeys.
+func isolateKeyPools(t *testing.T) {
+	t.Helper()
+	keyPoolsMu.Lock()
+	saved := keyPools
+	keyPools = make(map[string]*keyPool)
+	keyPoolsMu.Unlock()
+	t.Cleanup(func() {
+		keyPoolsMu.Lock()
+		keyPools = saved
+		keyPoolsMu.Unlock()
+	})
+}
+
+func TestKeyRotatingProvider(t *testing.T) {
+	var mu sync.Mutex
+	status := map[string]int{"Bearer key-aaaa-1111": 429, "Bearer key-bbbb-2222": 200, "Bearer key-cccc-3333": 401}
+	var used []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auth := r.Header.Get("Authorization")
+		used = append(used, auth[len(auth)-4:])
+		code := status[auth]
+		mu.Unlock()
+		if code != http.StatusOK {
+			w.WriteHeader(code)
+			fmt.Fprint(w, `{"error":{"message":"refused"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}`)
+	}))
+	defer server.Close()
+
+	cfg := &config.ModelConfig{
+		ModelName: "pooled-test",
+		Model:     "openai/gpt-4o",
+		APIBase:   server.URL,
+		APIKey:    "key-aaaa-1111",
+		APIKeys:   []string{"key-bbbb-2222", "key-aaaa-1111", " "},
+		KeyPool:   "pooled-test#0",
+	}
+	isolateKeyPools(t)
+	provider, _, err := CreateProviderFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "hello"}}, nil, "gpt-4o", nil)
+	if err != nil || resp.Content != "hi" {
+		t.Fatalf("Chat = %+v, %v", resp, err)
+	}
+	if _, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "again"}}, nil, "gpt-4o", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(used, ","); got != "1111,2222,2222" {
+		t.Errorf("keys used = %s, want the rate-limited key skipped after its 429", got)
+	}
+
+	var stats []KeyStats
+	for _, s := range KeyUsage() {
+		if s.Provider == rateLimitName(cfg) {
+			stats = append(stats, s)
+		}
+	}
+	if len(stats) != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if stats[0].Key != "…1111" || stats[0].Failures != 1 || stats[0].RestingUntil.IsZero() {
+		t.Errorf("rate-limited key = %+v", stats[0])
+	}
+	if !stats[1].Current || stats[1].Requests != 2 || stats[1].PromptTokens != 20 || stats[1].CompletionTokens != 4 {
+		t.Errorf("working key = %+v", stats[1])
+	}
+
+	// A reload swaps the working key for a rejected one
+	cfg.APIKeys = []string{"key-cccc-3333"}
+	full := &config.Config{ModelList: []config.ModelConfig{*cfg}}
+	updated, err := ReloadKeys(full)
+	if err != nil || len(updated) != 1 {
+		t.Fatalf("ReloadKeys = %v, %v", updated, err)
+	}
+	used = nil
+	_, err = provider.Chat(context.Background(), []Message{{Role: "user", Content: "hello"}}, nil, "gpt-4o", nil)
+	if err == nil {
+		t.Fatal("Chat succeeded with no usable key")
+	}
+	if fe := ClassifyError(err, "pooled-test", "gpt-4o"); fe == nil || fe.Reason != FailoverRateLimit {
+		t.Errorf("error %v doesn't classify as a rate limit", err)
+	}
+	if got := strings.Join(used, ","); got != "1111,3333" {
+		t.Errorf("keys used after reload = %s", got)
+	}
+}
+
+// pooled returns the provider behind p's current key, for tests of the
+// provider an entry creates.
+func pooled(p LLMProvider) LLMProvider {
+	if kp, ok := p.(*KeyRotatingProvider); ok {
+		kp.pool.mu.Lock()
+		defer kp.pool.mu.Unlock()
+		return kp.pool.keys[kp.pool.current].provider
+	}
+	return p
+}
+
+func TestKeyPoolsPerEntry(t *testing.T) {
+	first := config.ModelConfig{ModelName: "balanced-test", Model: "openai/gpt-4o", APIBase: "https://api.example.com/v1", APIKey: "key-aaaa-1111"}
+	second := first
+	second.APIKey = "key-bbbb-2222"
+	cfg := &config.Config{ModelList: []config.ModelConfig{first, second}}
+	isolateKeyPools(t)
+
+	var pools []*keyPool
+	for i := range cfg.ModelList {
+		mc := cfg.ModelEntry(i)
+		provider, _, err := CreateProviderFromConfig(&mc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pools = append(pools, provider.(*KeyRotatingProvider).pool)
+	}
+	if pools[0] == pools[1] || pools[1].keys[0].key != "key-bbbb-2222" {
+		t.Fatal("load-balanced entries with different keys share a pool")
+	}
+
+	// An entry started with a single key pools the keys a reload adds, and
+	// one whose only key expired takes its replacement
+	cfg.ModelList[0].APIKeys = []string{"key-cccc-3333"}
+	cfg.ModelList[1].APIKey = "key-dddd-4444"
+	updated, err := ReloadKeys(cfg)
+	if err != nil || len(updated) != 2 {
+		t.Fatalf("ReloadKeys = %v, %v", updated, err)
+	}
+	if len(pools[0].keys) != 2 || pools[0].keys[1].key != "key-cccc-3333" {
+		t.Errorf("first entry has %d keys after reload", len(pools[0].keys))
+	}
+	if len(pools[1].keys) != 1 || pools[1].keys[0].key != "key-dddd-4444" {
+		t.Errorf("second entry kept its expired key")
+	}
+
+	// An entry removed from model_list can't be updated, and says so
+	cfg.ModelList = cfg.ModelList[:1]
+	updated, err = ReloadKeys(cfg)
+	if len(updated) != 1 || err == nil || !strings.Contains(err.Error(), "no longer in model_list") {
+		t.Errorf("ReloadKeys without the second entry = %v, %v", updated, err)
+	}
+}
+
+func TestKeyPoolExhausted(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	p := &keyPool{name: "test", now: func() time.Time { return now }, keys: []*poolKey{
+		{key: "key-aaaa-1111", provider: &healthMockProvider{}},
+		{key: "key-bbbb-2222", provider: &healthMockProvider{}},
+	}}
+	p.record(p.keys[0], nil, fmt.Errorf("API error (status 401): invalid api key"))
+	p.record(p.keys[1], nil, fmt.Errorf("API error (status 401): invalid api key"))
+	if k := p.pick(); k != nil {
+		t.Fatalf("pick = %s, want none", k.key)
+	}
+	if fe := ClassifyError(p.exhausted(nil), "test", ""); fe == nil || fe.Reason != FailoverAuth {
+		t.Errorf("exhausted = %v, want an auth error", p.exhausted(nil))
+	}
+
+	p.keys[1].rejected = false
+	p.record(p.keys[1], nil, fmt.Errorf("API error (status 429): slow down"))
+	now = now.Add(keyRateLimitCooldown + time.Second)
+	if k := p.pick(); k == nil || k.key != "key-bbbb-2222" {
+		t.Errorf("pick after the cooldown = %v", k)
+	}
+}
@@ -45,5 +45,5 @@ func CreateProvider(cfg *config.Config) (LLMProvider, string, error) {
 		return nil, "", fmt.Errorf("failed to create provider for model %q: %w", model, err)
 	}
 
-	return provider, modelID, nil
+	return WrapWireLog(provider, cfg.WireLog, cfg.WorkspacePath()), modelID, nil
 }
@@ -24,14 +24,20 @@ type ToolDefinition = protocoltypes.ToolDefinition
 type ToolFunctionDefinition = protocoltypes.ToolFunctionDefinition
 type ExtraContent = protocoltypes.ExtraContent
 type GoogleExtra = protocoltypes.GoogleExtra
+type ResponseFormat = protocoltypes.ResponseFormat
 
 type Provider struct {
 	apiKey         string
 	apiBase        string
 	maxTokensField string // Field name for max tokens (e.g., "max_completion_tokens" for o1/glm models)
 	httpClient     *http.Client
+	azureVersion   string // Azure OpenAI api-version; set only for Azure
 }
 
+// DefaultAzureAPIVersion is the Azure OpenAI api-version used when the
+// config doesn't set one.
+const DefaultAzureAPIVersion = "2024-10-21"
+
 func NewProvider(apiKey, apiBase, proxy string) *Provider {
 	return NewProviderWithMaxTokensField(apiKey, apiBase, proxy, "")
 }
@@ -60,17 +66,73 @@ func NewProviderWithMaxTokensField(apiKey, apiBase, proxy, maxTokensField string
 	}
 }
 
+// NewAzureProvider returns a provider for Azure OpenAI, which routes by
+// deployment rather than by model: requests go to
+// {endpoint}/openai/deployments/{deployment}/chat/completions with the
+// api-version query parameter and authenticate with an api-key header.
+// The model passed to Chat is the deployment name.
+func NewAzureProvider(apiKey, endpoint, apiVersion, proxy, maxTokensField string) *Provider {
+	p := NewProviderWithMaxTokensField(apiKey, endpoint, proxy, maxTokensField)
+	p.azureVersion = apiVersion
+	if p.azureVersion == "" {
+		p.azureVersion = DefaultAzureAPIVersion
+	}
+	return p
+}
+
+// chatURL returns the chat completions endpoint for model.
+func (p *Provider) chatURL(model string) string {
+	if p.azureVersion == "" {
+		return p.apiBase + "/chat/completions"
+	}
+	base := strings.TrimSuffix(p.apiBase, "/openai")
+	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		base, url.PathEscape(model), url.QueryEscape(p.azureVersion))
+}
+
 func (p *Provider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	req, err := p.newRequest(ctx, messages, tools, model, options, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed:\n  Status: %d\n  Body:   %s", resp.StatusCode, string(body))
+	}
+
+	return parseResponse(body)
+}
+
+// newRequest builds a chat completions request; stream asks for the reply
+// as server-sent events.
+func (p *Provider) newRequest(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}, stream bool) (*http.Request, error) {
 	if p.apiBase == "" {
 		return nil, fmt.Errorf("API base not configured")
 	}
 
-	model = normalizeModel(model, p.apiBase)
+	if p.azureVersion == "" {
+		model = normalizeModel(model, p.apiBase)
+	}
 
 	requestBody := map[string]interface{}{
 		"model":    model,
 		"messages": messages,
 	}
+	if stream {
+		requestBody["stream"] = true
+		requestBody["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
 
 	if len(tools) > 0 {
 		requestBody["tools"] = tools
@@ -102,37 +164,50 @@ func (p *Provider) Chat(ctx context.Context, messages []Message, tools []ToolDef
 		}
 	}
 
+	if topP, ok := asFloat(options["top_p"]); ok {
+		requestBody["top_p"] = topP
+	}
+
+	if rf, ok := options["response_format"].(ResponseFormat); ok {
+		requestBody["response_format"] = responseFormat(rf)
+	}
+
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", p.apiBase+"/chat/completions", bytes.NewReader(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.chatURL(model), bytes.NewReader(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if p.apiKey != "" {
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	if p.apiKey != "" && p.azureVersion != "" {
+		req.Header.Set("api-key", p.apiKey)
+	} else if p.apiKey != "" {
 		req.Header.Set("Authorization", "Bearer "+p.apiKey)
 	}
+	return req, nil
+}
 
-	resp, err := p.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+// responseFormat is the response_format of a request: a JSON schema, or
+// JSON mode when there is none.
+func responseFormat(rf ResponseFormat) map[string]interface{} {
+	if rf.Schema == nil {
+		return map[string]interface{}{"type": "json_object"}
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	name := rf.Name
+	if name == "" {
+		name = "response"
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed:\n  Status: %d\n  Body:   %s", resp.StatusCode, string(body))
+	return map[string]interface{}{
+		"type":        "json_schema",
+		"json_schema": map[string]interface{}{"name": name, "schema": rf.Schema},
 	}
-
-	return parseResponse(body)
 }
 
 func parseResponse(body []byte) (*LLMResponse, error) {
@@ -173,42 +248,16 @@ func parseResponse(body []byte) (*LLMResponse, error) {
 	choice := apiResponse.Choices[0]
 	toolCalls := make([]ToolCall, 0, len(choice.Message.ToolCalls))
 	for _, tc := range choice.Message.ToolCalls {
-		arguments := make(map[string]interface{})
-		name := ""
-
 		// Extract thought_signature from Gemini/Google-specific extra content
 		thoughtSignature := ""
 		if tc.ExtraContent != nil && tc.ExtraContent.Google != nil {
 			thoughtSignature = tc.ExtraContent.Google.ThoughtSignature
 		}
-
+		name, args := "", ""
 		if tc.Function != nil {
-			name = tc.Function.Name
-			if tc.Function.Arguments != "" {
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &arguments); err != nil {
-					log.Printf("openai_compat: failed to decode tool call arguments for %q: %v", name, err)
-					arguments["raw"] = tc.Function.Arguments
-				}
-			}
-		}
-
-		// Build ToolCall with ExtraContent for Gemini 3 thought_signature persistence
-		toolCall := ToolCall{
-			ID:               tc.ID,
-			Name:             name,
-			Arguments:        arguments,
-			ThoughtSignature: thoughtSignature,
+			name, args = tc.Function.Name, tc.Function.Arguments
 		}
-
-		if thoughtSignature != "" {
-			toolCall.ExtraContent = &ExtraContent{
-				Google: &GoogleExtra{
-					ThoughtSignature: thoughtSignature,
-				},
-			}
-		}
-
-		toolCalls = append(toolCalls, toolCall)
+		toolCalls = append(toolCalls, buildToolCall(tc.ID, name, args, thoughtSignature))
 	}
 
 	return &LLMResponse{
@@ -219,6 +268,34 @@ func parseResponse(body []byte) (*LLMResponse, error) {
 	}, nil
 }
 
+// buildToolCall decodes a tool call's JSON arguments; arguments that
+// don't decode are kept under "raw".
+func buildToolCall(id, name, rawArguments, thoughtSignature string) ToolCall {
+	arguments := make(map[string]interface{})
+	if rawArguments != "" {
+		if err := json.Unmarshal([]byte(rawArguments), &arguments); err != nil {
+			log.Printf("openai_compat: failed to decode tool call arguments for %q: %v", name, err)
+			arguments["raw"] = rawArguments
+		}
+	}
+
+	// Build ToolCall with ExtraContent for Gemini 3 thought_signature persistence
+	toolCall := ToolCall{
+		ID:               id,
+		Name:             name,
+		Arguments:        arguments,
+		ThoughtSignature: thoughtSignature,
+	}
+	if thoughtSignature != "" {
+		toolCall.ExtraContent = &ExtraContent{
+			Google: &GoogleExtra{
+				ThoughtSignature: thoughtSignature,
+			},
+		}
+	}
+	return toolCall
+}
+
 func normalizeModel(model, apiBase string) string {
 	idx := strings.Index(model, "/")
 	if idx == -1 {
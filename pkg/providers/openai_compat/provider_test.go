@@ -47,6 +47,62 @@ func TestProviderChat_UsesMaxCompletionTokensForGLM(t *testing.T) {
 	}
 }
 
+func TestProviderChat_ResponseFormat(t *testing.T) {
+	var requestBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"{}"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	p := NewProvider("key", server.URL, "")
+	schema := map[string]interface{}{"type": "object"}
+	_, err := p.Chat(t.Context(), []Message{{Role: "user", Content: "hi"}}, nil, "gpt-4o", map[string]interface{}{
+		"response_format": ResponseFormat{Name: "reply", Schema: schema},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	rf, _ := requestBody["response_format"].(map[string]interface{})
+	js, _ := rf["json_schema"].(map[string]interface{})
+	if rf["type"] != "json_schema" || js["name"] != "reply" || js["schema"] == nil {
+		t.Errorf("response_format = %v", requestBody["response_format"])
+	}
+}
+
+func TestProviderChat_AzureRoutesByDeployment(t *testing.T) {
+	var gotPath, gotVersion, gotKey, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotVersion = r.URL.Query().Get("api-version")
+		gotKey = r.Header.Get("api-key")
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	p := NewAzureProvider("azure-key", server.URL+"/", "", "", "")
+	resp, err := p.Chat(t.Context(), []Message{{Role: "user", Content: "hi"}}, nil, "prod-gpt4o", nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.Content != "ok" {
+		t.Errorf("Content = %q", resp.Content)
+	}
+	if gotPath != "/openai/deployments/prod-gpt4o/chat/completions" {
+		t.Errorf("path = %q", gotPath)
+	}
+	if gotVersion != DefaultAzureAPIVersion {
+		t.Errorf("api-version = %q, want %q", gotVersion, DefaultAzureAPIVersion)
+	}
+	if gotKey != "azure-key" || gotAuth != "" {
+		t.Errorf("api-key = %q, Authorization = %q", gotKey, gotAuth)
+	}
+}
+
 func TestProviderChat_ParsesToolCalls(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		resp := map[string]interface{}{
@@ -275,3 +331,73 @@ func TestNormalizeModel_UsesAPIBase(t *testing.T) {
 		t.Fatalf("normalizeModel(openrouter) = %q, want %q", got, "openrouter/auto")
 	}
 }
+
+func TestProviderChatStream_AssemblesTextAndToolCalls(t *testing.T) {
+	var requestBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&requestBody)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range []string{
+			`{"choices":[{"delta":{"content":"Hel"}}]}`,
+			`{"choices":[{"delta":{"content":"lo"}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"weather","arguments":"{\"city\":"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Oslo\"}"}}]},"finish_reason":"tool_calls"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`,
+			`[DONE]`,
+		} {
+			w.Write([]byte("data: " + event + "\n\n"))
+		}
+	}))
+	defer server.Close()
+
+	p := NewProvider("key", server.URL, "")
+	chunks, err := p.ChatStream(t.Context(), []Message{{Role: "user", Content: "hi"}}, nil, "gpt-4o", nil)
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	var deltas []string
+	var final *LLMResponse
+	toolCallChunks := 0
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		if chunk.Delta != "" {
+			deltas = append(deltas, chunk.Delta)
+		}
+		if chunk.ToolCall {
+			toolCallChunks++
+		}
+		if chunk.Response != nil {
+			final = chunk.Response
+		}
+	}
+
+	if requestBody["stream"] != true {
+		t.Errorf("request stream = %v, want true", requestBody["stream"])
+	}
+	if len(deltas) != 2 || final == nil || final.Content != "Hello" {
+		t.Fatalf("deltas = %q, final = %+v", deltas, final)
+	}
+	if toolCallChunks != 1 {
+		t.Errorf("chunks marking the tool call = %d, want 1", toolCallChunks)
+	}
+	if final.FinishReason != "tool_calls" || final.Usage == nil || final.Usage.TotalTokens != 8 {
+		t.Errorf("finish = %q, usage = %+v", final.FinishReason, final.Usage)
+	}
+	if len(final.ToolCalls) != 1 || final.ToolCalls[0].Name != "weather" || final.ToolCalls[0].Arguments["city"] != "Oslo" {
+		t.Errorf("tool calls = %+v", final.ToolCalls)
+	}
+}
+
+func TestProviderChatStream_RefusedRequestFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"rate limited"}`, http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	p := NewProvider("key", server.URL, "")
+	if _, err := p.ChatStream(t.Context(), []Message{{Role: "user", Content: "hi"}}, nil, "gpt-4o", nil); err == nil {
+		t.Fatal("ChatStream() succeeded on a 429")
+	}
+}
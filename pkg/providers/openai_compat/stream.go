@@ -0,0 +1,172 @@
+package openai_compat
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/providers/protocoltypes"
+)
+
+type StreamChunk = protocoltypes.StreamChunk
+
+// streamChunk is one server-sent event of a streamed chat completion.
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Function *struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+				ExtraContent *struct {
+					Google *struct {
+						ThoughtSignature string `json:"thought_signature"`
+					} `json:"google"`
+				} `json:"extra_content"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *UsageInfo `json:"usage"`
+}
+
+// partialToolCall collects a tool call whose name and arguments arrive in
+// pieces.
+type partialToolCall struct {
+	id, name, thoughtSignature string
+	arguments                  strings.Builder
+}
+
+// ChatStream is Chat with the reply streamed as it is generated. Tool
+// calls are only in the final chunk's response, once complete.
+func (p *Provider) ChatStream(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (<-chan StreamChunk, error) {
+	req, err := p.newRequest(ctx, messages, tools, model, options, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API request failed:\n  Status: %d\n  Body:   %s", resp.StatusCode, string(body))
+	}
+
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		send := func(chunk StreamChunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		final, err := readStream(resp.Body, func(delta string, toolCall bool) bool {
+			return send(StreamChunk{Delta: delta, ToolCall: toolCall})
+		})
+		if err != nil {
+			send(StreamChunk{Err: err})
+			return
+		}
+		send(StreamChunk{Response: final})
+	}()
+	return chunks, nil
+}
+
+// readStream reads server-sent events up to "data: [DONE]", passing each
+// piece of text to onDelta, and assembles the complete response. The first
+// tool call is passed on as well, with toolCall set. It stops early if
+// onDelta returns false.
+func readStream(body io.Reader, onDelta func(delta string, toolCall bool) bool) (*LLMResponse, error) {
+	var content strings.Builder
+	calls := make(map[int]*partialToolCall)
+	final := &LLMResponse{FinishReason: "stop"}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Usage != nil {
+			final.Usage = chunk.Usage
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		choice := chunk.Choices[0]
+		if choice.FinishReason != "" {
+			final.FinishReason = choice.FinishReason
+		}
+		if len(choice.Delta.ToolCalls) > 0 && len(calls) == 0 {
+			if !onDelta("", true) {
+				return nil, fmt.Errorf("stream cancelled")
+			}
+		}
+		for _, tc := range choice.Delta.ToolCalls {
+			call := calls[tc.Index]
+			if call == nil {
+				call = &partialToolCall{}
+				calls[tc.Index] = call
+			}
+			if tc.ID != "" {
+				call.id = tc.ID
+			}
+			if tc.Function != nil {
+				call.name += tc.Function.Name
+				call.arguments.WriteString(tc.Function.Arguments)
+			}
+			if tc.ExtraContent != nil && tc.ExtraContent.Google != nil {
+				call.thoughtSignature = tc.ExtraContent.Google.ThoughtSignature
+			}
+		}
+		if delta := choice.Delta.Content; delta != "" {
+			content.WriteString(delta)
+			if !onDelta(delta, false) {
+				return nil, fmt.Errorf("stream cancelled")
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	indexes := make([]int, 0, len(calls))
+	for i := range calls {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	final.ToolCalls = make([]ToolCall, 0, len(calls))
+	for _, i := range indexes {
+		call := calls[i]
+		final.ToolCalls = append(final.ToolCalls, buildToolCall(call.id, call.name, call.arguments.String(), call.thoughtSignature))
+	}
+	final.Content = content.String()
+	return final, nil
+}
@@ -0,0 +1,220 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// promptedCallSeq numbers the tool calls read from replies, which come
+// without IDs. The IDs carry the start time too, so calls saved in a
+// session before a restart keep theirs apart.
+var (
+	promptedCallSeq   atomic.Int64
+	promptedCallEpoch = time.Now().Unix()
+)
+
+// PromptedToolsProvider offers tools to models that can't take them
+// through the API, such as small local models: the tools are listed in the
+// system prompt, and a reply made of {"tool": ..., "arguments": {...}} is
+// read back as a tool call. Earlier calls and their results are sent as
+// plain messages.
+type PromptedToolsProvider struct {
+	inner LLMProvider
+}
+
+// WithPromptedTools wraps inner so it gets its tools in the prompt.
+func WithPromptedTools(inner LLMProvider) *PromptedToolsProvider {
+	if p, ok := inner.(*PromptedToolsProvider); ok {
+		return p
+	}
+	return &PromptedToolsProvider{inner: inner}
+}
+
+func (p *PromptedToolsProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	resp, err := p.inner.Chat(ctx, promptedMessages(messages, tools), nil, model, options)
+	if err != nil || resp == nil || len(resp.ToolCalls) > 0 || len(tools) == 0 {
+		return resp, err
+	}
+	if calls, rest := parsePromptedToolCalls(resp.Content, tools); len(calls) > 0 {
+		resp.ToolCalls = calls
+		resp.Content = rest
+		resp.FinishReason = "tool_calls"
+	}
+	return resp, nil
+}
+
+func (p *PromptedToolsProvider) GetDefaultModel() string {
+	return p.inner.GetDefaultModel()
+}
+
+// promptedMessages adds the tools to the system prompt and turns tool
+// calls and results into text. Results of calls made together go in one
+// user message, since some chat templates refuse two in a row.
+func promptedMessages(messages []Message, tools []ToolDefinition) []Message {
+	out := make([]Message, 0, len(messages)+1)
+	names := make(map[string]string) // tool call ID -> tool name
+	toolsPrompt := promptedToolsPrompt(tools)
+	for _, m := range messages {
+		switch {
+		case m.Role == "system":
+			if toolsPrompt != "" {
+				m.Content = strings.TrimSpace(m.Content + "\n\n" + toolsPrompt)
+				toolsPrompt = ""
+			}
+			m.CacheBreaks = nil
+		case m.Role == "assistant" && len(m.ToolCalls) > 0:
+			var calls []string
+			for _, tc := range m.ToolCalls {
+				tc = NormalizeToolCall(tc)
+				names[tc.ID] = tc.Name
+				data, _ := json.Marshal(map[string]interface{}{"tool": tc.Name, "arguments": tc.Arguments})
+				calls = append(calls, string(data))
+			}
+			m.Content = strings.TrimSpace(m.Content + "\n" + strings.Join(calls, "\n"))
+			m.ToolCalls = nil
+		case m.Role == "tool":
+			name := names[m.ToolCallID]
+			if name == "" {
+				name = "the tool"
+			}
+			result := fmt.Sprintf("Result of %s:\n%s", name, m.Content)
+			if last := len(out) - 1; last >= 0 && out[last].Role == "user" && strings.HasPrefix(out[last].Content, "Result of ") {
+				out[last].Content += "\n\n" + result
+				continue
+			}
+			m = Message{Role: "user", Content: result}
+		}
+		out = append(out, m)
+	}
+	if toolsPrompt != "" {
+		out = append([]Message{{Role: "system", Content: toolsPrompt}}, out...)
+	}
+	return out
+}
+
+// promptedToolsPrompt describes tools in as few tokens as a small model
+// can still follow: one line per tool, with its parameters and a "?" on
+// the optional ones.
+func promptedToolsPrompt(tools []ToolDefinition) string {
+	if len(tools) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## Tools\n\nTo use a tool, reply with only this JSON and nothing else, then wait for the result:\n")
+	sb.WriteString(`{"tool": "<name>", "arguments": {<parameters>}}`)
+	sb.WriteString("\nOtherwise just answer.\n\n")
+	for _, t := range tools {
+		fn := t.Function
+		props, _ := fn.Parameters["properties"].(map[string]interface{})
+		required := make(map[string]bool)
+		switch list := fn.Parameters["required"].(type) {
+		case []string:
+			for _, name := range list {
+				required[name] = true
+			}
+		case []interface{}:
+			for _, name := range list {
+				if s, ok := name.(string); ok {
+					required[s] = true
+				}
+			}
+		}
+		params := make([]string, 0, len(props))
+		for name, schema := range props {
+			typ, _ := schema.(map[string]interface{})["type"].(string)
+			if !required[name] {
+				name += "?"
+			}
+			if typ != "" {
+				name += ": " + typ
+			}
+			params = append(params, name)
+		}
+		sort.Strings(params)
+		fmt.Fprintf(&sb, "- %s(%s): %s\n", fn.Name, strings.Join(params, ", "), firstSentence(fn.Description))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// firstSentence keeps a tool description to its first sentence.
+func firstSentence(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[:i+1]
+	}
+	return s
+}
+
+// parsePromptedToolCalls reads the tool calls in a reply, as one or more
+// {"tool": ..., "arguments": ...} objects ("name" works too) or the
+// {"tool_calls": [...]} form the CLI providers use, and returns them with
+// the text around them. Calls of tools that weren't offered are left in
+// the text.
+func parsePromptedToolCalls(text string, tools []ToolDefinition) ([]ToolCall, string) {
+	if calls := extractToolCallsFromText(text); len(calls) > 0 {
+		return calls, stripToolCallsFromText(text)
+	}
+
+	offered := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		offered[t.Function.Name] = true
+	}
+	original := text
+	var calls []ToolCall
+	var rest strings.Builder
+	for {
+		start := strings.Index(text, "{")
+		if start < 0 {
+			rest.WriteString(text)
+			break
+		}
+		dec := json.NewDecoder(strings.NewReader(text[start:]))
+		var call struct {
+			Tool      string                 `json:"tool"`
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		if err := dec.Decode(&call); err != nil {
+			rest.WriteString(text[:start+1])
+			text = text[start+1:]
+			continue
+		}
+		end := start + int(dec.InputOffset())
+		name := call.Tool
+		if name == "" {
+			name = call.Name
+		}
+		if !offered[name] {
+			rest.WriteString(text[:end])
+			text = text[end:]
+			continue
+		}
+		rest.WriteString(text[:start])
+		text = text[end:]
+		calls = append(calls, NormalizeToolCall(ToolCall{
+			ID:        fmt.Sprintf("call_prompted_%d_%d", promptedCallEpoch, promptedCallSeq.Add(1)),
+			Type:      "function",
+			Name:      name,
+			Arguments: call.Arguments,
+		}))
+	}
+	if len(calls) == 0 {
+		return nil, original
+	}
+	remaining := strings.TrimSpace(rest.String())
+	remaining = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(remaining, "```json"), "```"))
+	if remaining == "```" {
+		remaining = ""
+	}
+	return calls, remaining
+}
@@ -0,0 +1,137 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+var promptedTestTools = []ToolDefinition{
+	{Type: "function", Function: ToolFunctionDefinition{
+		Name:        "read_file",
+		Description: "Read the contents of a file. Paths are relative to the workspace.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path":  map[string]interface{}{"type": "string"},
+				"lines": map[string]interface{}{"type": "integer"},
+			},
+			"required": []string{"path"},
+		},
+	}},
+}
+
+func TestPromptedToolsPrompt(t *testing.T) {
+	got := promptedToolsPrompt(promptedTestTools)
+	if !strings.HasSuffix(got, "\n- read_file(lines?: integer, path: string): Read the contents of a file.") {
+		t.Errorf("prompt = %q", got)
+	}
+	if promptedToolsPrompt(nil) != "" {
+		t.Error("prompt without tools is not empty")
+	}
+}
+
+func TestParsePromptedToolCalls(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		text  string
+		tools []string
+		rest  string
+	}{
+		{"bare", `{"tool": "read_file", "arguments": {"path": "a.txt"}}`, []string{"read_file"}, ""},
+		{"fenced", "```json\n{\"name\": \"read_file\", \"arguments\": {\"path\": \"a.txt\"}}\n```", []string{"read_file"}, ""},
+		{"with text", `Let me look. {"tool": "read_file", "arguments": {"path": "a.txt"}}`, []string{"read_file"}, "Let me look."},
+		{"tool_calls form", `{"tool_calls":[{"id":"c1","type":"function","function":{"name":"read_file","arguments":"{\"path\":\"a.txt\"}"}}]}`, []string{"read_file"}, ""},
+		{"unknown tool", `{"tool": "rm_rf", "arguments": {}}`, nil, `{"tool": "rm_rf", "arguments": {}}`},
+		{"plain JSON answer", `The config is {"debug": true}.`, nil, `The config is {"debug": true}.`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls, rest := parsePromptedToolCalls(tc.text, promptedTestTools)
+			var names []string
+			for _, c := range calls {
+				names = append(names, c.Name)
+				if c.Arguments["path"] != "a.txt" {
+					t.Errorf("arguments = %v", c.Arguments)
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(tc.tools, ",") {
+				t.Errorf("calls = %v, want %v", names, tc.tools)
+			}
+			if rest != tc.rest {
+				t.Errorf("rest = %q, want %q", rest, tc.rest)
+			}
+		})
+	}
+}
+
+func TestPromptedMessages(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "You are picoclaw.", CacheBreaks: []int{5}},
+		{Role: "user", Content: "read a and b"},
+		{Role: "assistant", ToolCalls: []ToolCall{
+			{ID: "1", Name: "read_file", Arguments: map[string]interface{}{"path": "a"}},
+			{ID: "2", Name: "read_file", Arguments: map[string]interface{}{"path": "b"}},
+		}},
+		{Role: "tool", ToolCallID: "1", Content: "A"},
+		{Role: "tool", ToolCallID: "2", Content: "B"},
+	}
+	got := promptedMessages(messages, promptedTestTools)
+	if len(got) != 4 {
+		t.Fatalf("got %d messages: %+v", len(got), got)
+	}
+	if !strings.HasPrefix(got[0].Content, "You are picoclaw.\n\n## Tools") || got[0].CacheBreaks != nil {
+		t.Errorf("system = %+v", got[0])
+	}
+	if got[2].Role != "assistant" || got[2].ToolCalls != nil ||
+		got[2].Content != `{"arguments":{"path":"a"},"tool":"read_file"}`+"\n"+`{"arguments":{"path":"b"},"tool":"read_file"}` {
+		t.Errorf("assistant = %+v", got[2])
+	}
+	if got[3].Role != "user" || got[3].Content != "Result of read_file:\nA\n\nResult of read_file:\nB" {
+		t.Errorf("results = %+v", got[3])
+	}
+	if messages[0].CacheBreaks == nil || messages[2].ToolCalls == nil {
+		t.Error("the caller's messages were changed")
+	}
+}
+
+// promptedInner records what it was sent and replies with reply.
+type promptedInner struct {
+	reply    string
+	messages []Message
+	tools    []ToolDefinition
+}
+
+func (p *promptedInner) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	p.messages, p.tools = messages, tools
+	return &LLMResponse{Content: p.reply, FinishReason: "stop"}, nil
+}
+
+func (p *promptedInner) GetDefaultModel() string { return "tiny" }
+
+func TestPromptedToolsProvider_Chat(t *testing.T) {
+	inner := &promptedInner{reply: `{"tool": "read_file", "arguments": {"path": "a.txt"}}`}
+	p := WithPromptedTools(inner)
+	if WithPromptedTools(p) != p {
+		t.Error("wrapping twice made a second wrapper")
+	}
+
+	resp, err := p.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, promptedTestTools, "tiny", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inner.tools != nil {
+		t.Error("tools were sent through the API")
+	}
+	if len(inner.messages) != 2 || inner.messages[0].Role != "system" || !strings.Contains(inner.messages[0].Content, "read_file") {
+		t.Errorf("messages = %+v", inner.messages)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "read_file" || resp.FinishReason != "tool_calls" || resp.Content != "" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	inner.reply = "Hello!"
+	resp, _ = p.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, promptedTestTools, "tiny", nil)
+	if len(resp.ToolCalls) != 0 || resp.Content != "Hello!" {
+		t.Errorf("plain answer = %+v", resp)
+	}
+}
@@ -1,5 +1,11 @@
 package protocoltypes
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
 type ToolCall struct {
 	ID               string                 `json:"id"`
 	Type             string                 `json:"type,omitempty"`
@@ -31,10 +37,25 @@ type LLMResponse struct {
 	Usage        *UsageInfo `json:"usage,omitempty"`
 }
 
+// StreamChunk is one piece of a streamed completion: Delta is text
+// generated since the previous chunk, and ToolCall is set once the reply
+// starts calling tools. The last chunk of a stream carries the complete
+// Response, or Err if the stream failed.
+type StreamChunk struct {
+	Delta    string
+	ToolCall bool
+	Response *LLMResponse
+	Err      error
+}
+
 type UsageInfo struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	// CacheReadTokens and CacheWriteTokens are the part of PromptTokens
+	// read from and written to the provider's prompt cache.
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
 }
 
 type Message struct {
@@ -42,6 +63,75 @@ type Message struct {
 	Content    string     `json:"content"`
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string     `json:"tool_call_id,omitempty"`
+	// Parts are images sent along with Content. They only live for the
+	// turn they arrive in and are never saved to the session.
+	Parts []ContentPart `json:"-"`
+	// CacheBreaks are offsets in Content, in ascending order, where the
+	// prompt up to that point stays the same from turn to turn. Providers
+	// with prompt caching put a cache breakpoint at each; others ignore
+	// them.
+	CacheBreaks []int `json:"-"`
+}
+
+// MarshalJSON encodes a message with parts in the OpenAI multimodal form,
+// where content is a list of text and image_url parts.
+func (m Message) MarshalJSON() ([]byte, error) {
+	type plain Message
+	if len(m.Parts) == 0 {
+		return json.Marshal(plain(m))
+	}
+	parts := make([]ContentPart, 0, len(m.Parts)+1)
+	if m.Content != "" {
+		parts = append(parts, ContentPart{Type: "text", Text: m.Content})
+	}
+	parts = append(parts, m.Parts...)
+	return json.Marshal(struct {
+		plain
+		Content []ContentPart `json:"content"`
+	}{plain: plain(m), Content: parts})
+}
+
+// ContentPart is one part of a multimodal message.
+type ContentPart struct {
+	Type     string    `json:"type"` // "text" or "image_url"
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+// ImageURL holds an image as a data URL ("data:image/png;base64,...").
+type ImageURL struct {
+	URL string `json:"url"`
+}
+
+// ImagePart wraps image bytes of the given MIME type as a content part.
+func ImagePart(mimeType string, data []byte) ContentPart {
+	return ContentPart{
+		Type:     "image_url",
+		ImageURL: &ImageURL{URL: "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)},
+	}
+}
+
+// Image returns the MIME type and base64 data of an image part, for
+// providers that take them separately.
+func (p ContentPart) Image() (mimeType, data string, ok bool) {
+	if p.Type != "image_url" || p.ImageURL == nil {
+		return "", "", false
+	}
+	rest, found := strings.CutPrefix(p.ImageURL.URL, "data:")
+	if !found {
+		return "", "", false
+	}
+	mimeType, data, found = strings.Cut(rest, ";base64,")
+	return mimeType, data, found
+}
+
+// ResponseFormat asks a provider for a reply in JSON, passed to Chat as
+// the "response_format" option. With a Schema the reply must follow it;
+// without one any JSON object will do. Providers without a JSON mode
+// ignore it, so callers should still check the reply.
+type ResponseFormat struct {
+	Name   string                 `json:"name,omitempty"`
+	Schema map[string]interface{} `json:"schema,omitempty"`
 }
 
 type ToolDefinition struct {
@@ -0,0 +1,32 @@
+package protocoltypes
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMessageMarshalJSON(t *testing.T) {
+	plain, _ := json.Marshal(Message{Role: "user", Content: "hi"})
+	if string(plain) != `{"role":"user","content":"hi"}` {
+		t.Errorf("text message = %s", plain)
+	}
+
+	withImage, err := json.Marshal(Message{Role: "user", Content: "hi", Parts: []ContentPart{ImagePart("image/png", []byte("png"))}})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"role":"user","content":[{"type":"text","text":"hi"},{"type":"image_url","image_url":{"url":"data:image/png;base64,cG5n"}}]}`
+	if string(withImage) != want {
+		t.Errorf("got  %s\nwant %s", withImage, want)
+	}
+}
+
+func TestContentPartImage(t *testing.T) {
+	mimeType, data, ok := ImagePart("image/jpeg", []byte("jpg")).Image()
+	if !ok || mimeType != "image/jpeg" || data != "anBn" {
+		t.Errorf("Image() = %q, %q, %v", mimeType, data, ok)
+	}
+	if _, _, ok := (ContentPart{Type: "image_url", ImageURL: &ImageURL{URL: "https://example.com/a.png"}}).Image(); ok {
+		t.Error("a plain URL is not an inline image")
+	}
+}
@@ -0,0 +1,296 @@
+package providers
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// rateWindow is the window RPM and TPM limits are counted over.
+const rateWindow = time.Minute
+
+// rateLimiters are shared by every provider created for the same
+// model_list entry, so the agent, the cheap model route and /model switches
+// all count against the same limits.
+var (
+	rateLimitersMu sync.Mutex
+	rateLimiters   = make(map[string]*rateLimiter)
+)
+
+type rateEntry struct {
+	at     time.Time
+	tokens int
+}
+
+// rateLimiter holds requests back until they fit in a sliding one-minute
+// window of at most rpm requests and tpm tokens. Waiting requests queue up
+// one behind the other.
+type rateLimiter struct {
+	name    string
+	rpm     int
+	tpm     int
+	queue   sync.Mutex // held by the request at the front of the queue
+	mu      sync.Mutex
+	entries []*rateEntry
+	waiting atomic.Int64
+	now     func() time.Time
+}
+
+// sharedRateLimiter returns the limiter for name, creating it or updating
+// its limits.
+func sharedRateLimiter(name string, rpm, tpm int) *rateLimiter {
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+
+	l, ok := rateLimiters[name]
+	if !ok {
+		l = &rateLimiter{name: name, now: time.Now}
+		rateLimiters[name] = l
+	}
+	l.mu.Lock()
+	l.rpm, l.tpm = rpm, tpm
+	l.mu.Unlock()
+	return l
+}
+
+// acquire waits until a request of about tokens fits the limits and
+// records it. A request bigger than the whole TPM limit goes once the
+// window is empty.
+func (l *rateLimiter) acquire(ctx context.Context, tokens int) (*rateEntry, error) {
+	depth := l.waiting.Add(1)
+	defer l.waiting.Add(-1)
+
+	l.queue.Lock()
+	defer l.queue.Unlock()
+
+	logged := false
+	for {
+		entry, wait := l.reserve(tokens)
+		if entry != nil {
+			return entry, nil
+		}
+		if !logged {
+			logger.InfoCF("provider", "Rate limit reached, queueing request", map[string]interface{}{
+				"provider":    l.name,
+				"queue_depth": depth,
+				"wait_ms":     wait.Milliseconds(),
+			})
+			logged = true
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("waiting for %s rate limit: %w", l.name, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve records the request when it fits the window, or returns how
+// long to wait before trying again.
+func (l *rateLimiter) reserve(tokens int) (*rateEntry, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	kept := l.entries[:0]
+	for _, e := range l.entries {
+		if now.Sub(e.at) < rateWindow {
+			kept = append(kept, e)
+		}
+	}
+	l.entries = kept
+
+	var wait time.Duration
+	if l.rpm > 0 && len(l.entries) >= l.rpm {
+		wait = l.entries[len(l.entries)-l.rpm].at.Add(rateWindow).Sub(now)
+	}
+	if l.tpm > 0 && len(l.entries) > 0 {
+		used := 0
+		for _, e := range l.entries {
+			used += e.tokens
+		}
+		// Drop the oldest requests until this one fits
+		for _, e := range l.entries {
+			if used+tokens <= l.tpm {
+				break
+			}
+			used -= e.tokens
+			wait = max(wait, e.at.Add(rateWindow).Sub(now))
+		}
+	}
+	if wait > 0 {
+		return nil, wait
+	}
+	entry := &rateEntry{at: now, tokens: tokens}
+	l.entries = append(l.entries, entry)
+	return entry, 0
+}
+
+// settle replaces a request's estimate with the tokens it actually used.
+func (l *rateLimiter) settle(e *rateEntry, usage *UsageInfo) {
+	if usage == nil || usage.TotalTokens == 0 {
+		return
+	}
+	l.mu.Lock()
+	e.tokens = usage.TotalTokens
+	l.mu.Unlock()
+}
+
+// RateLimitedProvider keeps a provider under its requests- and
+// tokens-per-minute limits by queueing requests, rather than letting busy
+// channels run into HTTP 429 errors.
+type RateLimitedProvider struct {
+	inner   LLMProvider
+	limiter *rateLimiter
+}
+
+// WithRateLimit wraps provider with the limits of a model_list entry, and
+// returns it unchanged when neither limit is set.
+func WithRateLimit(provider LLMProvider, name string, rpm, tpm int) LLMProvider {
+	if provider == nil || (rpm <= 0 && tpm <= 0) {
+		return provider
+	}
+	return &RateLimitedProvider{inner: provider, limiter: sharedRateLimiter(name, max(rpm, 0), max(tpm, 0))}
+}
+
+// estimateRequestTokens guesses the prompt size of a request at 2.5
+// characters per token, like the agent's own estimates.
+func estimateRequestTokens(messages []Message) int {
+	chars := 0
+	for _, m := range messages {
+		chars += utf8.RuneCountInString(m.Content)
+	}
+	return chars * 2 / 5
+}
+
+func (p *RateLimitedProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	entry, err := p.limiter.acquire(ctx, estimateRequestTokens(messages))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.inner.Chat(ctx, messages, tools, model, options)
+	if resp != nil {
+		p.limiter.settle(entry, resp.Usage)
+	}
+	return resp, err
+}
+
+// ChatStream streams from the wrapped provider once the request fits the
+// limits. A provider that can't stream is called with Chat, and its reply
+// comes as the only chunk.
+func (p *RateLimitedProvider) ChatStream(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (<-chan StreamChunk, error) {
+	sp, ok := p.inner.(StreamingProvider)
+	if !ok {
+		resp, err := p.Chat(ctx, messages, tools, model, options)
+		if err != nil {
+			return nil, err
+		}
+		chunks := make(chan StreamChunk, 1)
+		chunks <- StreamChunk{Response: resp}
+		close(chunks)
+		return chunks, nil
+	}
+
+	entry, err := p.limiter.acquire(ctx, estimateRequestTokens(messages))
+	if err != nil {
+		return nil, err
+	}
+	inner, err := sp.ChatStream(ctx, messages, tools, model, options)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		for chunk := range inner {
+			if chunk.Response != nil {
+				p.limiter.settle(entry, chunk.Response.Usage)
+			}
+			chunks <- chunk
+		}
+	}()
+	return chunks, nil
+}
+
+func (p *RateLimitedProvider) GetDefaultModel() string {
+	return p.inner.GetDefaultModel()
+}
+
+// RateLimitStats is the state of one provider's rate limit.
+type RateLimitStats struct {
+	Provider string
+	RPM      int
+	TPM      int
+	Requests int // in the last minute
+	Tokens   int // in the last minute
+	Queued   int // requests waiting for the limit
+}
+
+// RateLimits returns the state of every provider with limits, by name.
+func RateLimits() []RateLimitStats {
+	rateLimitersMu.Lock()
+	limiters := make([]*rateLimiter, 0, len(rateLimiters))
+	for _, l := range rateLimiters {
+		limiters = append(limiters, l)
+	}
+	rateLimitersMu.Unlock()
+
+	stats := make([]RateLimitStats, 0, len(limiters))
+	for _, l := range limiters {
+		l.mu.Lock()
+		s := RateLimitStats{Provider: l.name, RPM: l.rpm, TPM: l.tpm, Queued: int(l.waiting.Load())}
+		now := l.now()
+		for _, e := range l.entries {
+			if now.Sub(e.at) < rateWindow {
+				s.Requests++
+				s.Tokens += e.tokens
+			}
+		}
+		l.mu.Unlock()
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Provider < stats[j].Provider })
+	return stats
+}
+
+// MetricsHandler serves the rate limits in the Prometheus text format.
+// Requests must carry token as a bearer token, since the provider names
+// reveal the models and API hosts in use.
+func MetricsHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		stats := RateLimits()
+		metrics := []struct {
+			name, help string
+			value      func(RateLimitStats) int
+		}{
+			{"picoclaw_provider_queue_depth", "Requests waiting for the provider's rate limit.", func(s RateLimitStats) int { return s.Queued }},
+			{"picoclaw_provider_requests_last_minute", "Requests sent to the provider in the last minute.", func(s RateLimitStats) int { return s.Requests }},
+			{"picoclaw_provider_tokens_last_minute", "Tokens used with the provider in the last minute.", func(s RateLimitStats) int { return s.Tokens }},
+			{"picoclaw_provider_rpm_limit", "The provider's requests-per-minute limit (0 for none).", func(s RateLimitStats) int { return s.RPM }},
+			{"picoclaw_provider_tpm_limit", "The provider's tokens-per-minute limit (0 for none).", func(s RateLimitStats) int { return s.TPM }},
+		}
+		for _, m := range metrics {
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+			for _, s := range stats {
+				fmt.Fprintf(w, "%s{provider=%q} %d\n", m.name, s.Provider, m.value(s))
+			}
+		}
+	})
+}
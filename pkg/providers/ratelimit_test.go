@@ -0,0 +1,80 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterWindow(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	l := &rateLimiter{name: "test", rpm: 2, tpm: 100, now: func() time.Time { return now }}
+
+	if e, _ := l.reserve(30); e == nil {
+		t.Fatal("first request held back")
+	}
+	now = now.Add(10 * time.Second)
+	if e, _ := l.reserve(30); e == nil {
+		t.Fatal("second request held back")
+	}
+	if e, wait := l.reserve(30); e != nil || wait != 50*time.Second {
+		t.Errorf("third request = %v, wait %v; want held back 50s by RPM", e, wait)
+	}
+
+	now = now.Add(50 * time.Second)
+	e, _ := l.reserve(60)
+	if e == nil {
+		t.Fatal("request held back after the first one left the window")
+	}
+	l.settle(e, &UsageInfo{TotalTokens: 70})
+	if e, wait := l.reserve(10); e != nil || wait != 10*time.Second {
+		t.Errorf("request over TPM = %v, wait %v; want held back 10s", e, wait)
+	}
+
+	// A request bigger than the whole limit goes once the window is empty
+	now = now.Add(time.Minute)
+	if e, _ := l.reserve(500); e == nil {
+		t.Error("oversized request held back with an empty window")
+	}
+}
+
+func TestRateLimitedProviderQueues(t *testing.T) {
+	p := WithRateLimit(echoProvider{}, "ratelimit-queue-test", 1, 0)
+	if _, err := p.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, "echo", nil); err != nil {
+		t.Fatalf("first Chat: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := p.Chat(ctx, []Message{{Role: "user", Content: "hi"}}, nil, "echo", nil); err == nil {
+		t.Fatal("second Chat within the minute went through")
+	}
+
+	rec := httptest.NewRecorder()
+	MetricsHandler("secret").ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("metrics without a token: status %d, want 401", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	MetricsHandler("secret").ServeHTTP(rec, req)
+	body := rec.Body.String()
+	for _, want := range []string{
+		`picoclaw_provider_requests_last_minute{provider="ratelimit-queue-test"} 1`,
+		`picoclaw_provider_queue_depth{provider="ratelimit-queue-test"} 0`,
+		`picoclaw_provider_rpm_limit{provider="ratelimit-queue-test"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %q:\n%s", want, body)
+		}
+	}
+
+	if WithRateLimit(echoProvider{}, "unlimited", 0, 0) != (echoProvider{}) {
+		t.Error("provider without limits was wrapped")
+	}
+}
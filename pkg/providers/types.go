@@ -16,12 +16,49 @@ type ToolDefinition = protocoltypes.ToolDefinition
 type ToolFunctionDefinition = protocoltypes.ToolFunctionDefinition
 type ExtraContent = protocoltypes.ExtraContent
 type GoogleExtra = protocoltypes.GoogleExtra
+type ContentPart = protocoltypes.ContentPart
+type ImageURL = protocoltypes.ImageURL
+type StreamChunk = protocoltypes.StreamChunk
+type ResponseFormat = protocoltypes.ResponseFormat
+
+// ImagePart wraps image bytes of the given MIME type as a content part.
+var ImagePart = protocoltypes.ImagePart
 
 type LLMProvider interface {
 	Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error)
 	GetDefaultModel() string
 }
 
+// StreamingProvider is a provider that can stream a completion as it is
+// generated. ChatStream fails the same way Chat does if the request is
+// refused; once it returns, the channel yields the text as it arrives and
+// is closed after the final chunk.
+type StreamingProvider interface {
+	LLMProvider
+	ChatStream(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (<-chan StreamChunk, error)
+}
+
+// CollectStream reads a stream to its end, calling onDelta with each piece
+// of text until the reply starts calling tools, and returns the complete
+// response.
+func CollectStream(chunks <-chan StreamChunk, onDelta func(delta string)) (*LLMResponse, error) {
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			return nil, chunk.Err
+		}
+		if chunk.ToolCall {
+			onDelta = nil
+		}
+		if chunk.Delta != "" && onDelta != nil {
+			onDelta(chunk.Delta)
+		}
+		if chunk.Response != nil {
+			return chunk.Response, nil
+		}
+	}
+	return nil, fmt.Errorf("stream ended without a response")
+}
+
 // FailoverReason classifies why an LLM request failed for fallback decisions.
 type FailoverReason string
 
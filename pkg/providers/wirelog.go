@@ -0,0 +1,526 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// Wire log levels.
+const (
+	WireLogFull     = "full"     // requests and responses verbatim
+	WireLogRedacted = "redacted" // verbatim with secrets and personal data masked
+	WireLogMetadata = "metadata" // roles, sizes, tool names, usage and timing only
+)
+
+const wireLogFileName = "provider-wire.jsonl"
+
+var (
+	// wireCredentialRe keeps the key name so the log still shows what was there.
+	wireCredentialRe   = regexp.MustCompile(`(?i)\b(api[_-]?key|token|secret|password|passwd)\b(\s*[:=]\s*)\S+`)
+	wireRedactPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)bearer\s+[a-z0-9._~+/-]+=*`),
+		regexp.MustCompile(`\b(sk|pk|rk|xox[abpr]|ghp|gho|AKIA)[-_A-Za-z0-9]{12,}\b`),
+		regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		regexp.MustCompile(`\+?\d[\d -]{8,}\d`),
+	}
+)
+
+// WireLogProvider wraps a provider and appends every request and response
+// to a size-rotated JSONL file, for debugging model behavior without a proxy.
+type WireLogProvider struct {
+	inner LLMProvider
+	level string
+	out   *rotatingWriter
+}
+
+// WrapWireLog wraps provider with a wire log when cfg enables one and
+// returns provider unchanged otherwise. Logs go to cfg.Dir, or
+// workspace/logs when unset.
+func WrapWireLog(provider LLMProvider, cfg config.WireLogConfig, workspace string) LLMProvider {
+	if !cfg.Enabled || provider == nil {
+		return provider
+	}
+
+	level := cfg.Level
+	switch level {
+	case WireLogFull, WireLogRedacted, WireLogMetadata:
+	default:
+		level = WireLogRedacted
+	}
+
+	dir := WireLogDir(cfg, workspace)
+	maxSize := int64(cfg.MaxSizeMB) * 1024 * 1024
+	if maxSize <= 0 {
+		maxSize = 10 * 1024 * 1024
+	}
+	maxFiles := cfg.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = 5
+	}
+
+	return &WireLogProvider{
+		inner: provider,
+		level: level,
+		out:   sharedRotatingWriter(filepath.Join(dir, wireLogFileName), maxSize, maxFiles),
+	}
+}
+
+// WireLogDir returns the directory the wire log is written to.
+func WireLogDir(cfg config.WireLogConfig, workspace string) string {
+	if cfg.Dir != "" {
+		return cfg.Dir
+	}
+	return filepath.Join(workspace, "logs")
+}
+
+type wireRecord struct {
+	Time       time.Time   `json:"time"`
+	Level      string      `json:"level"`
+	Model      string      `json:"model"`
+	DurationMS int64       `json:"duration_ms"`
+	Request    interface{} `json:"request"`
+	Response   interface{} `json:"response,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+type wireRequest struct {
+	Messages []Message              `json:"messages"`
+	Tools    []ToolDefinition       `json:"tools,omitempty"`
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+type wireMessageMeta struct {
+	Role      string   `json:"role"`
+	Chars     int      `json:"chars"`
+	ToolCalls []string `json:"tool_calls,omitempty"`
+}
+
+type wireRequestMeta struct {
+	Messages []wireMessageMeta      `json:"messages"`
+	Tools    []string               `json:"tools,omitempty"`
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+type wireResponseMeta struct {
+	Chars        int        `json:"chars"`
+	ToolCalls    []string   `json:"tool_calls,omitempty"`
+	FinishReason string     `json:"finish_reason,omitempty"`
+	Usage        *UsageInfo `json:"usage,omitempty"`
+}
+
+func (p *WireLogProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	start := time.Now()
+	resp, err := p.inner.Chat(ctx, messages, tools, model, options)
+	p.record(start, messages, tools, model, options, resp, err)
+	return resp, err
+}
+
+// ChatStream streams from the wrapped provider and logs the exchange once
+// the stream ends. A provider that can't stream is called with Chat, and
+// its reply comes as the only chunk.
+func (p *WireLogProvider) ChatStream(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (<-chan StreamChunk, error) {
+	start := time.Now()
+	sp, ok := p.inner.(StreamingProvider)
+	if !ok {
+		resp, err := p.Chat(ctx, messages, tools, model, options)
+		if err != nil {
+			return nil, err
+		}
+		chunks := make(chan StreamChunk, 1)
+		chunks <- StreamChunk{Response: resp}
+		close(chunks)
+		return chunks, nil
+	}
+
+	inner, err := sp.ChatStream(ctx, messages, tools, model, options)
+	if err != nil {
+		p.record(start, messages, tools, model, options, nil, err)
+		return nil, err
+	}
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		for chunk := range inner {
+			if chunk.Response != nil || chunk.Err != nil {
+				p.record(start, messages, tools, model, options, chunk.Response, chunk.Err)
+			}
+			chunks <- chunk
+		}
+	}()
+	return chunks, nil
+}
+
+func (p *WireLogProvider) record(start time.Time, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}, resp *LLMResponse, err error) {
+	rec := wireRecord{
+		Time:       start,
+		Level:      p.level,
+		Model:      model,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		rec.Error = p.text(err.Error())
+	}
+
+	switch p.level {
+	case WireLogMetadata:
+		rec.Request = requestMeta(messages, tools, options)
+		if resp != nil {
+			rec.Response = responseMeta(resp)
+		}
+	default:
+		rec.Request = wireRequest{Messages: p.messages(messages), Tools: tools, Options: options}
+		if resp != nil {
+			rec.Response = p.response(resp)
+		}
+	}
+
+	if werr := p.out.writeJSON(rec); werr != nil {
+		logger.WarnCF("provider", "Failed to write wire log", map[string]interface{}{
+			"error": werr.Error(),
+		})
+	}
+}
+
+func (p *WireLogProvider) GetDefaultModel() string {
+	return p.inner.GetDefaultModel()
+}
+
+func (p *WireLogProvider) text(s string) string {
+	if p.level == WireLogRedacted {
+		return RedactSecrets(s)
+	}
+	return s
+}
+
+func (p *WireLogProvider) messages(messages []Message) []Message {
+	if p.level != WireLogRedacted {
+		return messages
+	}
+	out := make([]Message, len(messages))
+	for i, m := range messages {
+		m.Content = RedactSecrets(m.Content)
+		m.ToolCalls = p.toolCalls(m.ToolCalls)
+		m.Parts = redactImages(m.Parts)
+		out[i] = m
+	}
+	return out
+}
+
+// redactImages replaces image data with its size, since a photo can hold
+// as much personal data as any text.
+func redactImages(parts []ContentPart) []ContentPart {
+	if len(parts) == 0 {
+		return parts
+	}
+	out := make([]ContentPart, len(parts))
+	for i, part := range parts {
+		if mimeType, data, ok := part.Image(); ok {
+			part.ImageURL = &ImageURL{URL: fmt.Sprintf("data:%s;base64,[%d bytes redacted]", mimeType, len(data))}
+		}
+		out[i] = part
+	}
+	return out
+}
+
+func (p *WireLogProvider) response(resp *LLMResponse) *LLMResponse {
+	if p.level != WireLogRedacted {
+		return resp
+	}
+	redacted := *resp
+	redacted.Content = RedactSecrets(resp.Content)
+	redacted.ToolCalls = p.toolCalls(resp.ToolCalls)
+	return &redacted
+}
+
+func (p *WireLogProvider) toolCalls(calls []ToolCall) []ToolCall {
+	if len(calls) == 0 {
+		return calls
+	}
+	out := make([]ToolCall, len(calls))
+	for i, tc := range calls {
+		if tc.Function != nil {
+			fn := *tc.Function
+			fn.Arguments = RedactSecrets(fn.Arguments)
+			tc.Function = &fn
+		}
+		if tc.Arguments != nil {
+			args := make(map[string]interface{}, len(tc.Arguments))
+			for k, v := range tc.Arguments {
+				if s, ok := v.(string); ok {
+					v = RedactSecrets(s)
+				}
+				args[k] = v
+			}
+			tc.Arguments = args
+		}
+		out[i] = tc
+	}
+	return out
+}
+
+func requestMeta(messages []Message, tools []ToolDefinition, options map[string]interface{}) wireRequestMeta {
+	meta := wireRequestMeta{Options: options}
+	for _, m := range messages {
+		meta.Messages = append(meta.Messages, wireMessageMeta{
+			Role:      m.Role,
+			Chars:     len([]rune(m.Content)),
+			ToolCalls: toolCallNames(m.ToolCalls),
+		})
+	}
+	for _, t := range tools {
+		meta.Tools = append(meta.Tools, t.Function.Name)
+	}
+	return meta
+}
+
+func responseMeta(resp *LLMResponse) wireResponseMeta {
+	return wireResponseMeta{
+		Chars:        len([]rune(resp.Content)),
+		ToolCalls:    toolCallNames(resp.ToolCalls),
+		FinishReason: resp.FinishReason,
+		Usage:        resp.Usage,
+	}
+}
+
+func toolCallNames(calls []ToolCall) []string {
+	var names []string
+	for _, tc := range calls {
+		name := tc.Name
+		if name == "" && tc.Function != nil {
+			name = tc.Function.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// RedactSecrets masks API keys, bearer tokens, credentials, email addresses
+// and phone-like numbers in s.
+func RedactSecrets(s string) string {
+	s = wireCredentialRe.ReplaceAllString(s, "${1}${2}[REDACTED]")
+	for _, re := range wireRedactPatterns {
+		s = re.ReplaceAllString(s, "[REDACTED]")
+	}
+	return s
+}
+
+// rotatingWriter appends lines to a file and rotates it once it exceeds
+// maxSize, keeping maxFiles old copies as name.1 (newest) .. name.N.
+type rotatingWriter struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+var (
+	wireWritersMu sync.Mutex
+	wireWriters   = make(map[string]*rotatingWriter)
+)
+
+// sharedRotatingWriter returns one writer per path so several wrapped
+// providers (e.g. the default and canary models) share rotation state.
+func sharedRotatingWriter(path string, maxSize int64, maxFiles int) *rotatingWriter {
+	wireWritersMu.Lock()
+	defer wireWritersMu.Unlock()
+
+	if w, ok := wireWriters[path]; ok {
+		return w
+	}
+	w := &rotatingWriter{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	wireWriters[path] = w
+	return w
+}
+
+func (w *rotatingWriter) writeJSON(v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	if w.size > 0 && w.size+int64(len(line)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	return err
+}
+
+func (w *rotatingWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	return nil
+}
+
+func (w *rotatingWriter) rotate() error {
+	w.file.Close()
+	w.file = nil
+
+	os.Remove(fmt.Sprintf("%s.%d", w.path, w.maxFiles))
+	for i := w.maxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+	}
+	if err := os.Rename(w.path, w.path+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return w.open()
+}
+
+// WireExchange is one request and response read back from the wire log.
+// Messages, Tools and Response are only set for the full and redacted
+// levels; metadata records keep just the timing and the error.
+type WireExchange struct {
+	Time       time.Time
+	Level      string
+	Model      string
+	DurationMS int64
+	Messages   []Message
+	Tools      []ToolDefinition
+	Options    map[string]interface{}
+	Response   *LLMResponse
+	Error      string
+}
+
+// ReadWireLog returns the exchanges in dir logged between from and to,
+// oldest first, including the rotated files.
+func ReadWireLog(dir string, from, to time.Time) ([]WireExchange, error) {
+	path := filepath.Join(dir, wireLogFileName)
+	var paths []string
+	for i := 1; ; i++ {
+		rotated := fmt.Sprintf("%s.%d", path, i)
+		if _, err := os.Stat(rotated); err != nil {
+			break
+		}
+		paths = append([]string{rotated}, paths...)
+	}
+	paths = append(paths, path)
+
+	var exchanges []WireExchange
+	for _, p := range paths {
+		read, err := readWireFile(p, from, to)
+		if err != nil {
+			return nil, err
+		}
+		exchanges = append(exchanges, read...)
+	}
+	return exchanges, nil
+}
+
+func readWireFile(path string, from, to time.Time) ([]WireExchange, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var exchanges []WireExchange
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rec struct {
+			wireRecord
+			Request  json.RawMessage `json:"request"`
+			Response json.RawMessage `json:"response"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		if rec.Time.Before(from) || rec.Time.After(to) {
+			continue
+		}
+
+		ex := WireExchange{
+			Time:       rec.Time,
+			Level:      rec.Level,
+			Model:      rec.Model,
+			DurationMS: rec.DurationMS,
+			Error:      rec.Error,
+		}
+		if rec.Level != WireLogMetadata {
+			var req struct {
+				Messages []json.RawMessage      `json:"messages"`
+				Tools    []ToolDefinition       `json:"tools"`
+				Options  map[string]interface{} `json:"options"`
+			}
+			if json.Unmarshal(rec.Request, &req) == nil {
+				ex.Tools, ex.Options = req.Tools, req.Options
+				for _, raw := range req.Messages {
+					ex.Messages = append(ex.Messages, decodeWireMessage(raw))
+				}
+			}
+			if len(rec.Response) > 0 && string(rec.Response) != "null" {
+				var resp LLMResponse
+				if json.Unmarshal(rec.Response, &resp) == nil {
+					ex.Response = &resp
+				}
+			}
+		}
+		exchanges = append(exchanges, ex)
+	}
+	return exchanges, scanner.Err()
+}
+
+// decodeWireMessage reads a logged message back, including one whose
+// content was written as a list of text and image parts.
+func decodeWireMessage(raw json.RawMessage) Message {
+	var m struct {
+		Role       string          `json:"role"`
+		Content    json.RawMessage `json:"content"`
+		ToolCalls  []ToolCall      `json:"tool_calls"`
+		ToolCallID string          `json:"tool_call_id"`
+	}
+	json.Unmarshal(raw, &m)
+	msg := Message{Role: m.Role, ToolCalls: m.ToolCalls, ToolCallID: m.ToolCallID}
+	if json.Unmarshal(m.Content, &msg.Content) != nil {
+		var parts []ContentPart
+		json.Unmarshal(m.Content, &parts)
+		for _, part := range parts {
+			if part.Type == "text" {
+				msg.Content += part.Text
+			} else {
+				msg.Parts = append(msg.Parts, part)
+			}
+		}
+	}
+	return msg
+}
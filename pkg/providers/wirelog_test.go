@@ -0,0 +1,116 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+type echoProvider struct{}
+
+func (echoProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	return &LLMResponse{Content: "mail me at bob@example.com", FinishReason: "stop"}, nil
+}
+
+func (echoProvider) GetDefaultModel() string { return "echo" }
+
+func readWireLog(t *testing.T, path string) []map[string]interface{} {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open wire log: %v", err)
+	}
+	defer f.Close()
+
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("bad wire log line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestWrapWireLog_Disabled(t *testing.T) {
+	var p LLMProvider = echoProvider{}
+	if got := WrapWireLog(p, config.WireLogConfig{}, t.TempDir()); got != p {
+		t.Error("disabled wire log should return the provider unchanged")
+	}
+}
+
+func TestWireLog_Levels(t *testing.T) {
+	messages := []Message{{Role: "user", Content: "my api_key=abc123 and sk-abcdefghijklmnop1234"}}
+
+	tests := []struct {
+		level   string
+		want    []string
+		notWant []string
+	}{
+		{WireLogFull, []string{"api_key=abc123", "bob@example.com"}, nil},
+		{WireLogRedacted, []string{"api_key=[REDACTED]", "[REDACTED]"}, []string{"abc123", "sk-abcdefghijklmnop1234", "bob@example.com"}},
+		{WireLogMetadata, []string{`"chars":45`, `"finish_reason":"stop"`}, []string{"api_key", "bob@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			dir := t.TempDir()
+			p := WrapWireLog(echoProvider{}, config.WireLogConfig{Enabled: true, Level: tt.level, Dir: dir}, "")
+			if _, err := p.Chat(context.Background(), messages, nil, "echo", nil); err != nil {
+				t.Fatalf("Chat: %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, wireLogFileName))
+			if err != nil {
+				t.Fatalf("read wire log: %v", err)
+			}
+			line := string(data)
+			for _, s := range tt.want {
+				if !strings.Contains(line, s) {
+					t.Errorf("wire log missing %q:\n%s", s, line)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(line, s) {
+					t.Errorf("wire log should not contain %q:\n%s", s, line)
+				}
+			}
+		})
+	}
+}
+
+func TestWireLog_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, wireLogFileName)
+	w := &rotatingWriter{path: path, maxSize: 100, maxFiles: 2}
+
+	for i := 0; i < 10; i++ {
+		if err := w.writeJSON(map[string]string{"pad": strings.Repeat("x", 40)}); err != nil {
+			t.Fatalf("writeJSON: %v", err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+		if info.Size() > 100 {
+			t.Errorf("%s is %d bytes, over the limit", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 rotated files, found %s.3", path)
+	}
+	if len(readWireLog(t, path)) == 0 {
+		t.Error("current wire log is empty")
+	}
+}
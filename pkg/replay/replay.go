@@ -0,0 +1,165 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package replay reconstructs past turns from the audit log and the
+// provider wire log, so a reply can be traced back to the exact prompt
+// that produced it and tried again against another model.
+package replay
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/audit"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// firstTurnWindow is how far back the wire log is searched for the first
+// turn of a session, which has no earlier turn to bound it.
+const firstTurnWindow = 30 * time.Minute
+
+// Turn is a past turn with the provider calls it made, oldest first.
+type Turn struct {
+	audit.Entry
+	Calls []providers.WireExchange
+}
+
+// Full reports whether the wire log kept the turn's prompts, rather than
+// only their metadata.
+func (t *Turn) Full() bool {
+	return len(t.Calls) > 0 && len(t.Calls[0].Messages) > 0
+}
+
+// Prompt returns the messages of the turn's first provider call.
+func (t *Turn) Prompt() []providers.Message {
+	if len(t.Calls) == 0 {
+		return nil
+	}
+	return t.Calls[0].Messages
+}
+
+// Load finds a turn in the workspace's audit log and matches it with the
+// provider calls in the wire log under wireDir.
+func Load(workspace, wireDir, turnID string) (*Turn, error) {
+	// Turn IDs are timestamps, so only the days around it are read
+	var since time.Time
+	if nanos, err := strconv.ParseInt(strings.TrimPrefix(turnID, "t"), 10, 64); err == nil {
+		since = time.Unix(0, nanos).AddDate(0, 0, -1)
+	}
+	entries, err := audit.NewLog(workspace).ReadSince(since)
+	if err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+
+	var turn *audit.Entry
+	for i := range entries {
+		if entries[i].Kind == audit.KindTurn && entries[i].TurnID == turnID {
+			turn = &entries[i]
+			break
+		}
+	}
+	if turn == nil {
+		return nil, fmt.Errorf("turn %s not found in the audit log", turnID)
+	}
+
+	// Calls made before the session's previous turn was logged, or before
+	// another chat's turn with the same message, belong to those turns
+	from := turn.Time.Add(-firstTurnWindow)
+	for _, e := range entries {
+		if e.Kind != audit.KindTurn || e.TurnID == turn.TurnID || !e.Time.After(from) || !e.Time.Before(turn.Time) {
+			continue
+		}
+		if e.SessionKey == turn.SessionKey || e.UserMessage == turn.UserMessage {
+			from = e.Time
+		}
+	}
+	exchanges, err := providers.ReadWireLog(wireDir, from, turn.Time)
+	if err != nil {
+		return nil, fmt.Errorf("reading wire log: %w", err)
+	}
+	return &Turn{Entry: *turn, Calls: matchCalls(*turn, exchanges)}, nil
+}
+
+// matchCalls picks the exchanges that belong to a turn: those whose last
+// user message holds the turn's message. Metadata-only exchanges can't be
+// matched by content, so those of the turn's model are kept instead.
+func matchCalls(turn audit.Entry, exchanges []providers.WireExchange) []providers.WireExchange {
+	var calls []providers.WireExchange
+	for _, ex := range exchanges {
+		if len(ex.Messages) == 0 {
+			if ex.Model == turn.Model {
+				calls = append(calls, ex)
+			}
+			continue
+		}
+		want := turn.UserMessage
+		if ex.Level == providers.WireLogRedacted {
+			want = providers.RedactSecrets(want)
+		}
+		if strings.Contains(lastUserMessage(ex.Messages), want) {
+			calls = append(calls, ex)
+		}
+	}
+	return calls
+}
+
+func lastUserMessage(messages []providers.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
+
+// Rerun sends the turn's first prompt to provider and model, with the
+// same tools and options.
+func (t *Turn) Rerun(ctx context.Context, provider providers.LLMProvider, model string) (*providers.LLMResponse, error) {
+	if !t.Full() {
+		return nil, fmt.Errorf("the wire log has no prompt for turn %s", t.TurnID)
+	}
+	first := t.Calls[0]
+	return provider.Chat(ctx, first.Messages, first.Tools, model, first.Options)
+}
+
+// RenderPrompt writes messages as a readable transcript, one block per
+// message.
+func RenderPrompt(w io.Writer, messages []providers.Message) {
+	for i, m := range messages {
+		header := m.Role
+		if m.ToolCallID != "" {
+			header += " (" + m.ToolCallID + ")"
+		}
+		fmt.Fprintf(w, "--- [%d] %s ---\n", i, header)
+		if m.Content != "" {
+			fmt.Fprintln(w, m.Content)
+		}
+		if len(m.Parts) > 0 {
+			fmt.Fprintf(w, "[%d image(s)]\n", len(m.Parts))
+		}
+		for _, tc := range m.ToolCalls {
+			fmt.Fprintf(w, "→ %s\n", FormatToolCall(tc))
+		}
+	}
+}
+
+// FormatToolCall renders a tool call as name(arguments).
+func FormatToolCall(tc providers.ToolCall) string {
+	name, args := tc.Name, ""
+	if tc.Function != nil {
+		if name == "" {
+			name = tc.Function.Name
+		}
+		args = tc.Function.Arguments
+	}
+	if args == "" && len(tc.Arguments) > 0 {
+		args = fmt.Sprint(tc.Arguments)
+	}
+	return name + "(" + args + ")"
+}
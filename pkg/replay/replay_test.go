@@ -0,0 +1,85 @@
+package replay
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/audit"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+type fixedProvider struct {
+	reply string
+	calls int
+}
+
+func (p *fixedProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, options map[string]interface{}) (*providers.LLMResponse, error) {
+	p.calls++
+	return &providers.LLMResponse{Content: p.reply + " (" + model + ")", FinishReason: "stop"}, nil
+}
+
+func (p *fixedProvider) GetDefaultModel() string { return "fixed" }
+
+func TestLoadAndRerun(t *testing.T) {
+	workspace := t.TempDir()
+	wireDir := filepath.Join(workspace, "logs")
+	wired := providers.WrapWireLog(&fixedProvider{reply: "it is sunny"},
+		config.WireLogConfig{Enabled: true, Level: providers.WireLogFull, Dir: wireDir}, workspace)
+
+	log := audit.NewLog(workspace)
+	ask := func(session, message string) audit.Entry {
+		prompt := []providers.Message{
+			{Role: "system", Content: "You are picoclaw."},
+			{Role: "user", Content: message},
+		}
+		resp, err := wired.Chat(context.Background(), prompt, nil, "smart-model", nil)
+		if err != nil {
+			t.Fatalf("Chat: %v", err)
+		}
+		entry := audit.Entry{
+			TurnID:      audit.NewTurnID(),
+			SessionKey:  session,
+			Channel:     "telegram",
+			ChatID:      session,
+			Model:       "smart-model",
+			UserMessage: message,
+			Response:    resp.Content,
+			Iterations:  1,
+		}
+		if err := log.RecordTurn(entry); err != nil {
+			t.Fatalf("RecordTurn: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		return entry
+	}
+	ask("a", "hello")
+	ask("b", "what's the weather?")
+	target := ask("a", "what's the weather?")
+
+	turn, err := Load(workspace, wireDir, target.TurnID)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(turn.Calls) != 1 {
+		t.Fatalf("got %d calls, want only the target turn's", len(turn.Calls))
+	}
+	if !turn.Full() || turn.Prompt()[0].Content != "You are picoclaw." {
+		t.Errorf("prompt not reconstructed: %+v", turn.Prompt())
+	}
+
+	other := &fixedProvider{reply: "it may rain"}
+	resp, err := turn.Rerun(context.Background(), other, "cheap-model")
+	if err != nil {
+		t.Fatalf("Rerun: %v", err)
+	}
+	if other.calls != 1 || resp.Content != "it may rain (cheap-model)" {
+		t.Errorf("rerun = %q after %d calls", resp.Content, other.calls)
+	}
+
+	if _, err := Load(workspace, wireDir, "t1"); err == nil {
+		t.Error("expected an error for an unknown turn")
+	}
+}
@@ -0,0 +1,115 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package resources
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+// Low-memory modes, see config.LowMemoryConfig.
+const (
+	ModeAuto = "auto"
+	ModeOn   = "on"
+	ModeOff  = "off"
+)
+
+// Report is what Guard found and changed.
+type Report struct {
+	Memory    Memory
+	Detected  bool
+	LowMemory bool
+	// Changes lists the settings lowered, as "setting: old → new".
+	Changes []string
+	// Warnings lists heavy features left on.
+	Warnings []string
+}
+
+// Guard turns on low-memory mode when cfg asks for it, or in "auto" mode
+// when the memory available is below the threshold. Low-memory mode lowers
+// concurrency, cache sizes and attachment limits in cfg, and sets a soft
+// limit for the Go heap unless GOMEMLIMIT is set.
+func Guard(cfg *config.Config) Report {
+	mem, ok := DetectMemory()
+	r := guard(cfg, mem, ok)
+	if r.LowMemory && ok && os.Getenv("GOMEMLIMIT") == "" {
+		limit := int64(mem.Bytes) / 4 * 3
+		debug.SetMemoryLimit(limit)
+		r.Changes = append(r.Changes, fmt.Sprintf("Go heap soft limit: %d MB", limit>>20))
+	}
+	return r
+}
+
+func guard(cfg *config.Config, mem Memory, detected bool) Report {
+	r := Report{Memory: mem, Detected: detected}
+	switch cfg.LowMemory.Mode {
+	case ModeOff:
+		return r
+	case ModeOn:
+		r.LowMemory = true
+	default:
+		r.LowMemory = detected && mem.MB() < cfg.LowMemory.ThresholdMB
+	}
+	if !r.LowMemory {
+		return r
+	}
+
+	lower := func(name string, v *int, max int) {
+		if *v == 0 || *v > max {
+			r.Changes = append(r.Changes, fmt.Sprintf("%s: %d → %d", name, *v, max))
+			*v = max
+		}
+	}
+	lower("agents.defaults.max_subagents", &cfg.Agents.Defaults.MaxSubagents, 1)
+	lower("agents.defaults.max_parallel_tools", &cfg.Agents.Defaults.MaxParallelTools, 1)
+	lower("tools.skills.max_concurrent_searches", &cfg.Tools.Skills.MaxConcurrentSearches, 1)
+	lower("tools.skills.search_cache.max_size", &cfg.Tools.Skills.SearchCache.MaxSize, 10)
+	lower("vision.max_images", &cfg.Vision.MaxImages, 2)
+	lower("vision.max_size_mb", &cfg.Vision.MaxSizeMB, 2)
+	lower("voice.max_size_mb", &cfg.Voice.MaxSizeMB, 10)
+	lower("video.keyframes", &cfg.Video.Keyframes, 2)
+
+	if cfg.Video.Enabled {
+		r.Warnings = append(r.Warnings, "video: ffmpeg decodes every video attachment; consider video.enabled false")
+	}
+	if cfg.Canary.Enabled {
+		r.Warnings = append(r.Warnings, "canary: a second model runs next to the default one")
+	}
+	if strings.EqualFold(cfg.Embeddings.Provider, "ollama") {
+		base := cfg.Embeddings.APIBase
+		if base == "" {
+			base = cfg.Providers.Ollama.APIBase
+		}
+		if isLocal(base) {
+			r.Warnings = append(r.Warnings, "embeddings: a local Ollama embedding model needs memory of its own; consider a hosted provider")
+		}
+	}
+	return r
+}
+
+// isLocal reports whether the API at base runs on this machine; an empty
+// base means Ollama's default, localhost.
+func isLocal(base string) bool {
+	if base == "" {
+		return true
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
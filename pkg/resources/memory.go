@@ -0,0 +1,92 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package resources detects how much memory PicoClaw may use and trims
+// its config on constrained hardware such as a Raspberry Pi.
+package resources
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Memory is the memory available to the process and where the limit
+// comes from: "cgroup" (a container limit) or "ram".
+type Memory struct {
+	Bytes  uint64
+	Source string
+}
+
+// MB returns the memory in megabytes.
+func (m Memory) MB() int {
+	return int(m.Bytes >> 20)
+}
+
+// DetectMemory returns the smaller of the cgroup memory limit and the
+// physical RAM, or false where neither can be read (outside Linux).
+func DetectMemory() (Memory, bool) {
+	return detectMemory("/")
+}
+
+func detectMemory(root string) (Memory, bool) {
+	ram, ramOK := memTotal(filepath.Join(root, "proc", "meminfo"))
+	limit, limitOK := cgroupLimit(root)
+	switch {
+	case limitOK && (!ramOK || limit < ram):
+		return Memory{Bytes: limit, Source: "cgroup"}, true
+	case ramOK:
+		return Memory{Bytes: ram, Source: "ram"}, true
+	}
+	return Memory{}, false
+}
+
+// memTotal reads MemTotal from /proc/meminfo.
+func memTotal(path string) (uint64, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, false
+			}
+			return kb << 10, true
+		}
+	}
+	return 0, false
+}
+
+// cgroupLimit reads the memory limit of cgroup v2, then v1. Unlimited
+// cgroups report "max" (v2) or a huge number (v1), both treated as none.
+func cgroupLimit(root string) (uint64, bool) {
+	for _, path := range []string{
+		filepath.Join(root, "sys", "fs", "cgroup", "memory.max"),
+		filepath.Join(root, "sys", "fs", "cgroup", "memory", "memory.limit_in_bytes"),
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		value := strings.TrimSpace(string(data))
+		if value == "max" {
+			return 0, false
+		}
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || n >= 1<<62 {
+			return 0, false
+		}
+		return n, true
+	}
+	return 0, false
+}
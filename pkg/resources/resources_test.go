@@ -0,0 +1,126 @@
+package resources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func writeFile(t *testing.T, root, path, content string) {
+	t.Helper()
+	full := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectMemory(t *testing.T) {
+	const meminfo = "MemTotal:        1000000 kB\nMemFree:          500000 kB\n"
+
+	tests := []struct {
+		name   string
+		files  map[string]string
+		want   Memory
+		wantOK bool
+	}{
+		{"nothing", nil, Memory{}, false},
+		{"ram only", map[string]string{"proc/meminfo": meminfo},
+			Memory{Bytes: 1000000 << 10, Source: "ram"}, true},
+		{"cgroup v2 unlimited", map[string]string{
+			"proc/meminfo":             meminfo,
+			"sys/fs/cgroup/memory.max": "max\n",
+		}, Memory{Bytes: 1000000 << 10, Source: "ram"}, true},
+		{"cgroup v2 limit", map[string]string{
+			"proc/meminfo":             meminfo,
+			"sys/fs/cgroup/memory.max": "268435456\n",
+		}, Memory{Bytes: 256 << 20, Source: "cgroup"}, true},
+		{"cgroup v1 unlimited", map[string]string{
+			"proc/meminfo": meminfo,
+			"sys/fs/cgroup/memory/memory.limit_in_bytes": "9223372036854771712\n",
+		}, Memory{Bytes: 1000000 << 10, Source: "ram"}, true},
+		{"cgroup above ram", map[string]string{
+			"proc/meminfo":             meminfo,
+			"sys/fs/cgroup/memory.max": "4294967296\n",
+		}, Memory{Bytes: 1000000 << 10, Source: "ram"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for path, content := range tt.files {
+				writeFile(t, root, path, content)
+			}
+			got, ok := detectMemory(root)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("detectMemory() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	small := Memory{Bytes: 512 << 20, Source: "ram"}
+	large := Memory{Bytes: 8 << 30, Source: "ram"}
+
+	tests := []struct {
+		name     string
+		mode     string
+		mem      Memory
+		detected bool
+		want     bool
+	}{
+		{"auto small", ModeAuto, small, true, true},
+		{"auto large", ModeAuto, large, true, false},
+		{"auto unknown", ModeAuto, Memory{}, false, false},
+		{"on", ModeOn, large, true, true},
+		{"off", ModeOff, small, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.LowMemory.Mode = tt.mode
+			before := cfg.Tools.Skills.MaxConcurrentSearches
+			r := guard(cfg, tt.mem, tt.detected)
+			if r.LowMemory != tt.want {
+				t.Fatalf("LowMemory = %v, want %v", r.LowMemory, tt.want)
+			}
+			if !tt.want {
+				if len(r.Changes) != 0 || cfg.Tools.Skills.MaxConcurrentSearches != before {
+					t.Errorf("config changed outside low-memory mode: %v", r.Changes)
+				}
+				return
+			}
+			if cfg.Agents.Defaults.MaxSubagents != 1 || cfg.Tools.Skills.MaxConcurrentSearches != 1 {
+				t.Errorf("concurrency not lowered: subagents %d, searches %d",
+					cfg.Agents.Defaults.MaxSubagents, cfg.Tools.Skills.MaxConcurrentSearches)
+			}
+			if cfg.Vision.MaxSizeMB > 2 || cfg.Voice.MaxSizeMB > 10 {
+				t.Errorf("attachment limits not lowered: vision %d MB, voice %d MB",
+					cfg.Vision.MaxSizeMB, cfg.Voice.MaxSizeMB)
+			}
+		})
+	}
+}
+
+func TestGuard_WarnsAboutHeavyFeatures(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.LowMemory.Mode = ModeOn
+	cfg.Video.Enabled = true
+	r := guard(cfg, Memory{}, false)
+	if len(r.Warnings) != 1 {
+		t.Errorf("Warnings = %v, want one about video", r.Warnings)
+	}
+
+	cfg.Embeddings.Provider = "ollama"
+	if r := guard(cfg, Memory{}, false); len(r.Warnings) != 2 {
+		t.Errorf("Warnings = %v, want one about local embeddings too", r.Warnings)
+	}
+	cfg.Embeddings.APIBase = "http://gpu-box:11434"
+	if r := guard(cfg, Memory{}, false); len(r.Warnings) != 1 {
+		t.Errorf("Warnings = %v, a remote Ollama needs no warning", r.Warnings)
+	}
+}
@@ -0,0 +1,239 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package retention deletes workspace data that is older than the configured
+// retention period for its category.
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/session"
+)
+
+// Data categories understood by the retention service.
+const (
+	CategoryDailyNotes = "daily_notes"
+	CategorySessions   = "sessions"
+	CategoryAudit      = "audit"
+	CategoryObserved   = "observed"
+)
+
+const defaultIntervalHours = 24
+
+// Result counts what a single retention run deleted, per category.
+type Result map[string]int
+
+type target struct {
+	workspace string
+	sessions  *session.SessionManager
+}
+
+// Service periodically enforces retention policies on one or more workspaces.
+type Service struct {
+	cfg      config.RetentionConfig
+	targets  []target
+	interval time.Duration
+	mu       sync.Mutex
+	stopChan chan struct{}
+}
+
+// NewService creates a retention service from config.
+func NewService(cfg config.RetentionConfig) *Service {
+	hours := cfg.IntervalHours
+	if hours <= 0 {
+		hours = defaultIntervalHours
+	}
+	return &Service{
+		cfg:      cfg,
+		interval: time.Duration(hours) * time.Hour,
+	}
+}
+
+// AddWorkspace registers a workspace to enforce. sessions may be nil, in
+// which case session files are pruned by modification time instead.
+func (s *Service) AddWorkspace(workspace string, sessions *session.SessionManager) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.targets = append(s.targets, target{workspace: workspace, sessions: sessions})
+}
+
+// Start begins periodic enforcement. The first run happens immediately.
+func (s *Service) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopChan != nil {
+		return nil
+	}
+	if !s.cfg.Enabled {
+		logger.InfoC("retention", "Retention service disabled")
+		return nil
+	}
+
+	s.stopChan = make(chan struct{})
+	go s.runLoop(s.stopChan)
+
+	logger.InfoCF("retention", "Retention service started", map[string]interface{}{
+		"interval_hours": s.interval.Hours(),
+	})
+	return nil
+}
+
+// Stop stops periodic enforcement.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopChan == nil {
+		return
+	}
+	close(s.stopChan)
+	s.stopChan = nil
+}
+
+func (s *Service) runLoop(stopChan chan struct{}) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.RunOnce(time.Now())
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			s.RunOnce(time.Now())
+		}
+	}
+}
+
+// RunOnce enforces all policies against data older than their cutoff
+// relative to now, and returns what was deleted.
+func (s *Service) RunOnce(now time.Time) Result {
+	s.mu.Lock()
+	targets := append([]target(nil), s.targets...)
+	s.mu.Unlock()
+
+	result := Result{}
+	for _, t := range targets {
+		if cutoff, ok := s.cutoff(CategoryDailyNotes, now); ok {
+			result[CategoryDailyNotes] += pruneDailyNotes(t.workspace, cutoff)
+		}
+		if cutoff, ok := s.cutoff(CategorySessions, now); ok {
+			result[CategorySessions] += pruneSessions(t, cutoff)
+		}
+		if cutoff, ok := s.cutoff(CategoryAudit, now); ok {
+			result[CategoryAudit] += pruneByModTime(filepath.Join(t.workspace, "audit"), cutoff)
+		}
+		if cutoff, ok := s.cutoff(CategoryObserved, now); ok {
+			result[CategoryObserved] += pruneByModTime(filepath.Join(t.workspace, "observed"), cutoff)
+		}
+	}
+
+	total := 0
+	for _, n := range result {
+		total += n
+	}
+	if total > 0 {
+		fields := map[string]interface{}{}
+		for category, n := range result {
+			fields[category] = n
+		}
+		logger.InfoCF("retention", "Deleted expired data", fields)
+	}
+	return result
+}
+
+func (s *Service) cutoff(category string, now time.Time) (time.Time, bool) {
+	days := s.cfg.DaysFor(category)
+	if days <= 0 {
+		return time.Time{}, false
+	}
+	return now.AddDate(0, 0, -days), true
+}
+
+// pruneDailyNotes removes memory/YYYYMM/YYYYMMDD.md notes dated before
+// cutoff, and month directories left empty.
+func pruneDailyNotes(workspace string, cutoff time.Time) int {
+	memoryDir := filepath.Join(workspace, "memory")
+	months, err := os.ReadDir(memoryDir)
+	if err != nil {
+		return 0
+	}
+
+	removed := 0
+	for _, month := range months {
+		if !month.IsDir() || len(month.Name()) != 6 {
+			continue
+		}
+		monthDir := filepath.Join(memoryDir, month.Name())
+		files, err := os.ReadDir(monthDir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			name := f.Name()
+			if f.IsDir() || filepath.Ext(name) != ".md" {
+				continue
+			}
+			date, err := time.ParseInLocation("20060102", name[:len(name)-3], time.Local)
+			if err != nil || !date.Before(cutoff) {
+				continue
+			}
+			if err := os.Remove(filepath.Join(monthDir, name)); err == nil {
+				removed++
+			}
+		}
+		// Only succeeds if the directory is now empty
+		os.Remove(monthDir)
+	}
+	return removed
+}
+
+func pruneSessions(t target, cutoff time.Time) int {
+	if t.sessions == nil {
+		return pruneByModTime(filepath.Join(t.workspace, "sessions"), cutoff)
+	}
+	deleted, trimmed, err := t.sessions.PruneOlderThan(cutoff)
+	if err != nil {
+		logger.WarnCF("retention", "Failed to prune sessions", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	if len(trimmed) > 0 {
+		logger.DebugCF("retention", "Trimmed old sessions down to their pins", map[string]interface{}{
+			"sessions": len(trimmed),
+		})
+	}
+	return len(deleted)
+}
+
+// pruneByModTime removes regular files in dir last modified before cutoff.
+func pruneByModTime(dir string, cutoff time.Time) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+
+	removed := 0
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err == nil {
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,69 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func touch(t *testing.T, path string, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunOnce_PerCategoryPolicies(t *testing.T) {
+	ws := t.TempDir()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.Local)
+
+	touch(t, filepath.Join(ws, "memory", "202401", "20240115.md"), now)
+	touch(t, filepath.Join(ws, "memory", "202605", "20260520.md"), now)
+	touch(t, filepath.Join(ws, "sessions", "old.json"), now.AddDate(0, 0, -100))
+	touch(t, filepath.Join(ws, "sessions", "new.json"), now.AddDate(0, 0, -1))
+	touch(t, filepath.Join(ws, "audit", "2026-04.jsonl"), now.AddDate(0, 0, -40))
+	touch(t, filepath.Join(ws, "observed", "discord-2026-04-01.jsonl"), now.AddDate(0, 0, -61))
+	touch(t, filepath.Join(ws, "observed", "discord-2026-05-31.jsonl"), now.AddDate(0, 0, -1))
+
+	svc := NewService(config.RetentionConfig{
+		Enabled:     true,
+		DefaultDays: 0,
+		Categories: map[string]int{
+			CategoryDailyNotes: 365,
+			CategorySessions:   90,
+			CategoryObserved:   30,
+			// audit not listed: falls back to DefaultDays (keep forever)
+		},
+	})
+	svc.AddWorkspace(ws, nil)
+
+	result := svc.RunOnce(now)
+	if result[CategoryDailyNotes] != 1 || result[CategorySessions] != 1 || result[CategoryAudit] != 0 || result[CategoryObserved] != 1 {
+		t.Errorf("result = %v", result)
+	}
+
+	if _, err := os.Stat(filepath.Join(ws, "memory", "202401")); !os.IsNotExist(err) {
+		t.Error("empty month directory was not removed")
+	}
+	if _, err := os.Stat(filepath.Join(ws, "memory", "202605", "20260520.md")); err != nil {
+		t.Error("recent daily note was removed")
+	}
+	if _, err := os.Stat(filepath.Join(ws, "sessions", "new.json")); err != nil {
+		t.Error("recent session was removed")
+	}
+	if _, err := os.Stat(filepath.Join(ws, "audit", "2026-04.jsonl")); err != nil {
+		t.Error("audit log removed despite keep-forever default")
+	}
+	if _, err := os.Stat(filepath.Join(ws, "observed", "discord-2026-05-31.jsonl")); err != nil {
+		t.Error("recent observed log was removed")
+	}
+}
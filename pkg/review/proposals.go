@@ -0,0 +1,255 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package review
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusApplied    = "applied"
+	StatusRejected   = "rejected"
+	StatusSuperseded = "superseded"
+)
+
+// bootstrapTargets are the workspace prompt files a proposal may edit.
+var bootstrapTargets = []string{"AGENTS.md", "SOUL.md", "IDENTITY.md"}
+
+// Proposal is a suggested edit to a prompt or skill file that waits for the
+// owner's approval. Content is the full new file; Diff is shown for review.
+type Proposal struct {
+	ID       int       `json:"id"`
+	Created  time.Time `json:"created"`
+	File     string    `json:"file"`
+	Reason   string    `json:"reason,omitempty"`
+	Diff     string    `json:"diff"`
+	Content  string    `json:"content"`
+	BaseHash string    `json:"base_hash"`
+	Status   string    `json:"status"`
+	Resolved time.Time `json:"resolved,omitempty"`
+}
+
+// Store persists proposals in workspace/state/proposals.json. The file is
+// re-read on every operation so the CLI and a running gateway can share it.
+type Store struct {
+	workspace string
+	path      string
+	mu        sync.Mutex
+}
+
+// NewStore creates a proposal store for a workspace.
+func NewStore(workspace string) *Store {
+	return &Store{
+		workspace: workspace,
+		path:      filepath.Join(workspace, "state", "proposals.json"),
+	}
+}
+
+// ValidateTarget checks that file is a workspace-relative path to one of
+// the bootstrap prompt files or a skill's SKILL.md.
+func ValidateTarget(file string) error {
+	if file == "" || filepath.IsAbs(file) || strings.Contains(file, "\\") {
+		return fmt.Errorf("invalid proposal path %q", file)
+	}
+	clean := filepath.ToSlash(filepath.Clean(file))
+	if clean != file {
+		return fmt.Errorf("invalid proposal path %q", file)
+	}
+	for _, name := range bootstrapTargets {
+		if clean == name {
+			return nil
+		}
+	}
+	parts := strings.Split(clean, "/")
+	if len(parts) == 3 && parts[0] == "skills" && parts[1] != "" && parts[1] != ".." && parts[2] == "SKILL.md" {
+		return nil
+	}
+	return fmt.Errorf("proposals may only edit %s or skills/<name>/SKILL.md, not %q",
+		strings.Join(bootstrapTargets, ", "), file)
+}
+
+// List returns all proposals, oldest first.
+func (s *Store) List() []Proposal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Get returns the proposal with the given ID.
+func (s *Store) Get(id int) (Proposal, bool) {
+	for _, p := range s.List() {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return Proposal{}, false
+}
+
+// Add records a pending proposal to replace file with content. Earlier
+// pending proposals for the same file are marked superseded. It returns
+// false if content is identical to the current file.
+func (s *Store) Add(file, reason, content string) (Proposal, bool, error) {
+	if err := ValidateTarget(file); err != nil {
+		return Proposal{}, false, err
+	}
+
+	current, err := s.readTarget(file)
+	if err != nil {
+		return Proposal{}, false, err
+	}
+	diff := utils.UnifiedDiff(file, current, content)
+	if diff == "" {
+		return Proposal{}, false, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all := s.load()
+	nextID := 1
+	for i := range all {
+		if all[i].ID >= nextID {
+			nextID = all[i].ID + 1
+		}
+		if all[i].File == file && all[i].Status == StatusPending {
+			all[i].Status = StatusSuperseded
+			all[i].Resolved = time.Now()
+		}
+	}
+
+	p := Proposal{
+		ID:       nextID,
+		Created:  time.Now(),
+		File:     file,
+		Reason:   reason,
+		Diff:     diff,
+		Content:  content,
+		BaseHash: hashContent(current),
+		Status:   StatusPending,
+	}
+	all = append(all, p)
+	return p, true, s.save(all)
+}
+
+// Apply writes a pending proposal's content to its file. It refuses if the
+// file changed since the proposal was made, since the diff would no longer
+// describe what gets written.
+func (s *Store) Apply(id int) (Proposal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all := s.load()
+	i, err := findPending(all, id)
+	if err != nil {
+		return Proposal{}, err
+	}
+	p := all[i]
+
+	if err := ValidateTarget(p.File); err != nil {
+		return p, err
+	}
+	current, err := s.readTarget(p.File)
+	if err != nil {
+		return p, err
+	}
+	if hashContent(current) != p.BaseHash {
+		return p, fmt.Errorf("%s changed since proposal %d was made; reject it and re-run the review", p.File, id)
+	}
+
+	path := filepath.Join(s.workspace, filepath.FromSlash(p.File))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return p, err
+	}
+	if err := os.WriteFile(path, []byte(p.Content), 0644); err != nil {
+		return p, err
+	}
+
+	all[i].Status = StatusApplied
+	all[i].Resolved = time.Now()
+	return all[i], s.save(all)
+}
+
+// Reject marks a pending proposal as rejected.
+func (s *Store) Reject(id int) (Proposal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all := s.load()
+	i, err := findPending(all, id)
+	if err != nil {
+		return Proposal{}, err
+	}
+	all[i].Status = StatusRejected
+	all[i].Resolved = time.Now()
+	return all[i], s.save(all)
+}
+
+func findPending(all []Proposal, id int) (int, error) {
+	for i, p := range all {
+		if p.ID != id {
+			continue
+		}
+		if p.Status != StatusPending {
+			return i, fmt.Errorf("proposal %d is already %s", id, p.Status)
+		}
+		return i, nil
+	}
+	return -1, fmt.Errorf("proposal %d not found", id)
+}
+
+func (s *Store) readTarget(file string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(s.workspace, filepath.FromSlash(file)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (s *Store) load() []Proposal {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil
+	}
+	var all []Proposal
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil
+	}
+	return all
+}
+
+func (s *Store) save(all []Proposal) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,331 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package review implements the nightly self-review: the agent reads the
+// recent audit log and feedback, writes a short "what went wrong / what to
+// improve" note into its daily memory, and proposes edits to its prompt or
+// skill files. Proposals are stored as diffs and only applied once the
+// owner approves them.
+package review
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/agent"
+	"github.com/sipeed/picoclaw/pkg/audit"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/structured"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+const (
+	// maxReviewTurns caps how many turns are sent to the model. Rated and
+	// failed turns are picked first.
+	maxReviewTurns = 40
+	// maxEditableSize is the largest file offered for editing. Larger files
+	// would need to be truncated in the prompt, and a proposal rewriting a
+	// truncated file would lose its tail.
+	maxEditableSize   = 8000
+	maxEditableSkills = 10
+)
+
+const reviewPrompt = `You are reviewing your own conversations from the last day as a personal AI assistant.
+Look for replies that went wrong: turns the user rated 👎, turns that ended in an error, misunderstandings, unnecessary tool calls, and repeated corrections from the user.
+
+Respond with a single JSON object and nothing else:
+{
+  "notes": "A short markdown note: what went wrong and what to improve. At most 10 bullet points.",
+  "proposals": [
+    {"file": "AGENTS.md", "reason": "why this edit helps", "content": "the complete new file content"}
+  ]
+}
+
+Only propose an edit when a concrete problem in the turns justifies it, and only for the editable files listed below. Keep edits small and keep everything in the file that is still useful. Use an empty proposals list if nothing needs to change.`
+
+// Result describes one review run.
+type Result struct {
+	Turns      int
+	ThumbsDown int
+	Errors     int
+	Notes      string
+	Proposals  []Proposal
+}
+
+// Reviewer runs a self-review over a workspace's audit log.
+type Reviewer struct {
+	workspace string
+	provider  providers.LLMProvider
+	model     string
+	audit     *audit.Log
+	proposals *Store
+}
+
+// NewReviewer creates a reviewer that asks provider/model for the review.
+func NewReviewer(workspace string, provider providers.LLMProvider, model string) *Reviewer {
+	return &Reviewer{
+		workspace: workspace,
+		provider:  provider,
+		model:     model,
+		audit:     audit.NewLog(workspace),
+		proposals: NewStore(workspace),
+	}
+}
+
+// reviewTurn is a turn together with the feedback it received.
+type reviewTurn struct {
+	audit.Entry
+	feedback audit.FeedbackExample
+	rated    bool
+}
+
+// Run reviews the turns logged since the given time. When there is nothing
+// to review it returns an empty result without calling the model.
+func (r *Reviewer) Run(ctx context.Context, since time.Time) (*Result, error) {
+	entries, err := r.audit.ReadSince(since)
+	if err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+
+	turns := collectTurns(entries)
+	result := &Result{Turns: len(turns)}
+	for _, t := range turns {
+		if t.rated && t.feedback.Rating == audit.RatingDown {
+			result.ThumbsDown++
+		}
+		if t.Error != "" {
+			result.Errors++
+		}
+	}
+	if len(turns) == 0 {
+		return result, nil
+	}
+
+	editable := r.editableFiles()
+	messages := []providers.Message{
+		{Role: "system", Content: reviewPrompt},
+		{Role: "user", Content: buildReviewInput(selectTurns(turns), editable)},
+	}
+	var reply reviewReply
+	err = structured.Chat(ctx, r.provider, r.model, messages, structured.Request{
+		Name:   "self_review",
+		Schema: reviewSchema,
+		Options: map[string]interface{}{
+			"max_tokens":  4096,
+			"temperature": 0.2,
+		},
+	}, &reply)
+	if err != nil {
+		return nil, fmt.Errorf("review request failed: %w", err)
+	}
+
+	notes, proposed := strings.TrimSpace(reply.Notes), reply.Proposals
+	result.Notes = notes
+
+	if notes != "" {
+		note := fmt.Sprintf("## Self-review\n\n_Reviewed %d turns since %s (%d 👎, %d errors)._\n\n%s\n",
+			result.Turns, since.Format("2006-01-02 15:04"), result.ThumbsDown, result.Errors, notes)
+		if err := agent.NewMemoryStore(r.workspace).AppendToday(note); err != nil {
+			return result, fmt.Errorf("writing review note: %w", err)
+		}
+	}
+
+	for _, p := range proposed {
+		if _, ok := editable[p.File]; !ok {
+			logger.WarnCF("review", "Ignoring proposal for a file that was not offered for editing", map[string]interface{}{
+				"file": p.File,
+			})
+			continue
+		}
+		saved, changed, err := r.proposals.Add(p.File, p.Reason, p.Content)
+		if err != nil {
+			logger.WarnCF("review", "Failed to save proposal", map[string]interface{}{
+				"file":  p.File,
+				"error": err.Error(),
+			})
+			continue
+		}
+		if changed {
+			result.Proposals = append(result.Proposals, saved)
+		}
+	}
+
+	logger.InfoCF("review", "Self-review complete", map[string]interface{}{
+		"turns":     result.Turns,
+		"proposals": len(result.Proposals),
+	})
+	return result, nil
+}
+
+// collectTurns returns the logged turns, oldest first, with their feedback.
+func collectTurns(entries []audit.Entry) []reviewTurn {
+	feedback := make(map[string]audit.FeedbackExample)
+	for _, ex := range audit.FeedbackExamples(entries) {
+		feedback[ex.TurnID] = ex
+	}
+
+	var turns []reviewTurn
+	for _, e := range entries {
+		if e.Kind != audit.KindTurn {
+			continue
+		}
+		ex, rated := feedback[e.TurnID]
+		turns = append(turns, reviewTurn{Entry: e, feedback: ex, rated: rated})
+	}
+	return turns
+}
+
+// selectTurns keeps at most maxReviewTurns turns, preferring 👎-rated turns,
+// then failed turns, then any rated turn, then the most recent ones. The
+// selection is returned in chronological order.
+func selectTurns(turns []reviewTurn) []reviewTurn {
+	if len(turns) <= maxReviewTurns {
+		return turns
+	}
+
+	priority := func(t reviewTurn) int {
+		switch {
+		case t.rated && t.feedback.Rating == audit.RatingDown:
+			return 0
+		case t.Error != "":
+			return 1
+		case t.rated:
+			return 2
+		}
+		return 3
+	}
+
+	ranked := make([]reviewTurn, len(turns))
+	copy(ranked, turns)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		pi, pj := priority(ranked[i]), priority(ranked[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return ranked[i].Time.After(ranked[j].Time)
+	})
+
+	selected := ranked[:maxReviewTurns]
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].Time.Before(selected[j].Time)
+	})
+	return selected
+}
+
+func buildReviewInput(turns []reviewTurn, editable map[string]string) string {
+	var sb strings.Builder
+	sb.WriteString("# Conversation turns\n\n")
+	for i, t := range turns {
+		fmt.Fprintf(&sb, "## Turn %d (%s, %s)\n", i+1, t.Channel, t.Time.Format("15:04"))
+		if t.rated {
+			switch t.feedback.Rating {
+			case audit.RatingDown:
+				sb.WriteString("Rating: 👎\n")
+			case audit.RatingUp:
+				sb.WriteString("Rating: 👍\n")
+			}
+			if t.feedback.Comment != "" {
+				fmt.Fprintf(&sb, "User comment: %s\n", utils.Truncate(t.feedback.Comment, 300))
+			}
+		}
+		fmt.Fprintf(&sb, "User: %s\n", utils.Truncate(t.UserMessage, 600))
+		if len(t.ToolCalls) > 0 {
+			fmt.Fprintf(&sb, "Tools: %s\n", strings.Join(t.ToolCalls, ", "))
+		}
+		if t.Error != "" {
+			fmt.Fprintf(&sb, "Error: %s\n", utils.Truncate(t.Error, 300))
+		}
+		fmt.Fprintf(&sb, "Assistant: %s\n\n", utils.Truncate(t.Response, 800))
+	}
+
+	if len(editable) == 0 {
+		sb.WriteString("# Editable files\n\nNone. Do not propose edits.\n")
+		return sb.String()
+	}
+
+	names := make([]string, 0, len(editable))
+	for name := range editable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sb.WriteString("# Editable files\n\n")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "## %s\n```\n%s\n```\n\n", name, editable[name])
+	}
+	return sb.String()
+}
+
+// editableFiles returns the prompt and skill files the review may propose
+// edits to, keyed by workspace-relative path.
+func (r *Reviewer) editableFiles() map[string]string {
+	files := make(map[string]string)
+	add := func(rel string) {
+		data, err := os.ReadFile(filepath.Join(r.workspace, filepath.FromSlash(rel)))
+		if err != nil || len(data) > maxEditableSize {
+			return
+		}
+		files[rel] = string(data)
+	}
+
+	for _, name := range bootstrapTargets {
+		add(name)
+	}
+
+	dirs, err := os.ReadDir(filepath.Join(r.workspace, "skills"))
+	if err != nil {
+		return files
+	}
+	skills := 0
+	for _, d := range dirs {
+		if !d.IsDir() || skills >= maxEditableSkills {
+			continue
+		}
+		before := len(files)
+		add("skills/" + d.Name() + "/SKILL.md")
+		if len(files) > before {
+			skills++
+		}
+	}
+	return files
+}
+
+type proposedEdit struct {
+	File    string `json:"file"`
+	Reason  string `json:"reason"`
+	Content string `json:"content"`
+}
+
+// reviewReply is the model's answer to reviewPrompt.
+type reviewReply struct {
+	Notes     string         `json:"notes"`
+	Proposals []proposedEdit `json:"proposals"`
+}
+
+var reviewSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"notes": map[string]interface{}{"type": "string"},
+		"proposals": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"file":    map[string]interface{}{"type": "string", "minLength": 1},
+					"reason":  map[string]interface{}{"type": "string"},
+					"content": map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"file", "reason", "content"},
+			},
+		},
+	},
+	"required": []string{"notes", "proposals"},
+}
@@ -0,0 +1,127 @@
+package review
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/audit"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+type stubProvider struct {
+	reply    string
+	messages []providers.Message
+	opts     map[string]interface{}
+}
+
+func (p *stubProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, opts map[string]interface{}) (*providers.LLMResponse, error) {
+	p.messages = messages
+	p.opts = opts
+	return &providers.LLMResponse{Content: p.reply}, nil
+}
+
+func (p *stubProvider) GetDefaultModel() string {
+	return "stub"
+}
+
+func TestValidateTarget(t *testing.T) {
+	tests := []struct {
+		file string
+		ok   bool
+	}{
+		{"AGENTS.md", true},
+		{"SOUL.md", true},
+		{"skills/weather/SKILL.md", true},
+		{"USER.md", false},
+		{"MEMORY.md", false},
+		{"skills/weather/run.sh", false},
+		{"skills/../AGENTS.md", false},
+		{"../AGENTS.md", false},
+		{"/etc/passwd", false},
+		{"skills/a/b/SKILL.md", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateTarget(tt.file); (err == nil) != tt.ok {
+			t.Errorf("ValidateTarget(%q) = %v, want ok=%v", tt.file, err, tt.ok)
+		}
+	}
+}
+
+func TestReviewer_Run(t *testing.T) {
+	ws := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ws, "AGENTS.md"), []byte("Be helpful.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	log := audit.NewLog(ws)
+	log.RecordTurn(audit.Entry{Channel: "telegram", ChatID: "1", UserMessage: "what's 2+2", Response: "5"})
+	turn, _ := log.LastTurn("telegram", "1")
+	log.RecordFeedback(turn, "42", audit.RatingDown, "wrong answer")
+
+	provider := &stubProvider{reply: "```json\n" + `{
+		"notes": "- Answered 2+2 wrong.",
+		"proposals": [
+			{"file": "AGENTS.md", "reason": "double-check arithmetic", "content": "Be helpful.\nDouble-check arithmetic.\n"},
+			{"file": "USER.md", "reason": "not editable", "content": "x"}
+		]
+	}` + "\n```"}
+
+	result, err := NewReviewer(ws, provider, "stub").Run(context.Background(), time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Turns != 1 || result.ThumbsDown != 1 {
+		t.Errorf("result = %+v", result)
+	}
+	if rf, ok := provider.opts["response_format"].(providers.ResponseFormat); !ok || rf.Name != "self_review" {
+		t.Errorf("options = %v, want the review schema as response format", provider.opts)
+	}
+	if !strings.Contains(provider.messages[1].Content, "User comment: wrong answer") {
+		t.Errorf("review input missing feedback:\n%s", provider.messages[1].Content)
+	}
+
+	if len(result.Proposals) != 1 || result.Proposals[0].File != "AGENTS.md" {
+		t.Fatalf("proposals = %+v", result.Proposals)
+	}
+
+	note, err := os.ReadFile(filepath.Join(ws, "memory", time.Now().Format("200601"), time.Now().Format("20060102")+".md"))
+	if err != nil || !strings.Contains(string(note), "Answered 2+2 wrong") {
+		t.Errorf("daily note = %q, %v", note, err)
+	}
+
+	store := NewStore(ws)
+	if _, err := store.Apply(result.Proposals[0].ID); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(ws, "AGENTS.md"))
+	if string(data) != "Be helpful.\nDouble-check arithmetic.\n" {
+		t.Errorf("AGENTS.md = %q", data)
+	}
+	if _, err := store.Apply(result.Proposals[0].ID); err == nil {
+		t.Error("applying twice should fail")
+	}
+}
+
+func TestStore_ApplyRefusesChangedFile(t *testing.T) {
+	ws := t.TempDir()
+	path := filepath.Join(ws, "SOUL.md")
+	os.WriteFile(path, []byte("calm\n"), 0644)
+
+	store := NewStore(ws)
+	p, changed, err := store.Add("SOUL.md", "", "calm and kind\n")
+	if err != nil || !changed {
+		t.Fatalf("Add = %v, %v", changed, err)
+	}
+
+	os.WriteFile(path, []byte("edited by hand\n"), 0644)
+	if _, err := store.Apply(p.ID); err == nil {
+		t.Fatal("Apply should refuse a file changed since the proposal")
+	}
+	if _, err := store.Reject(p.ID); err != nil {
+		t.Fatalf("Reject: %v", err)
+	}
+}
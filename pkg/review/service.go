@@ -0,0 +1,162 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/constants"
+	"github.com/sipeed/picoclaw/pkg/cron"
+	"github.com/sipeed/picoclaw/pkg/kv"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/state"
+)
+
+// reviewTimeout bounds one review run.
+const reviewTimeout = 5 * time.Minute
+
+// Service runs the review once a day at a configured local hour, as a job
+// of the cron service. The date of the last run is kept in
+// workspace/state/review.json, or the kv store, so gateways sharing the
+// store review once between them.
+type Service struct {
+	reviewer  *Reviewer
+	workspace string
+	hour      int
+	bus       *bus.MessageBus
+	state     kv.Store
+}
+
+// SetStore keeps the date of the last review in store rather than in
+// workspace/state/review.json.
+func (s *Service) SetStore(store kv.Store) {
+	s.state = store
+}
+
+// NewService creates a nightly review service.
+func NewService(reviewer *Reviewer, workspace string, hour int, msgBus *bus.MessageBus) *Service {
+	if hour < 0 || hour > 23 {
+		hour = 23
+	}
+	return &Service{
+		reviewer:  reviewer,
+		workspace: workspace,
+		hour:      hour,
+		bus:       msgBus,
+	}
+}
+
+// Schedule registers the daily review with the cron service.
+func (s *Service) Schedule(cs *cron.CronService) error {
+	if err := cs.Schedule("self-review", fmt.Sprintf("0 %d * * *", s.hour), s.run); err != nil {
+		return err
+	}
+	logger.InfoCF("review", "Nightly self-review scheduled", map[string]interface{}{
+		"hour": s.hour,
+	})
+	return nil
+}
+
+// run reviews the last day and tells the owner about the proposals.
+func (s *Service) run(now time.Time) {
+	today := now.Format("2006-01-02")
+	if s.lastRunDate() == today {
+		return
+	}
+	s.saveLastRunDate(today)
+
+	ctx, cancel := context.WithTimeout(context.Background(), reviewTimeout)
+	defer cancel()
+
+	result, err := s.reviewer.Run(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		logger.ErrorCF("review", "Nightly self-review failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	s.notify(result)
+}
+
+// notify tells the owner on the last active channel that proposals are
+// waiting for approval.
+func (s *Service) notify(result *Result) {
+	if s.bus == nil || len(result.Proposals) == 0 {
+		return
+	}
+
+	lastChannel := state.NewManager(s.workspace).GetLastChannel()
+	channel, chatID, ok := strings.Cut(lastChannel, ":")
+	if !ok || channel == "" || chatID == "" || constants.IsInternalChannel(channel) {
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🌙 Nightly self-review: %d turns, %d 👎, %d errors. Notes are in today's memory.\n\n",
+		result.Turns, result.ThumbsDown, result.Errors)
+	sb.WriteString("Proposed edits awaiting your approval:\n")
+	for _, p := range result.Proposals {
+		fmt.Fprintf(&sb, "  #%d %s: %s\n", p.ID, p.File, p.Reason)
+	}
+	sb.WriteString("\nReview them with: picoclaw review show <id>")
+
+	s.bus.PublishOutbound(bus.OutboundMessage{
+		Channel: channel,
+		ChatID:  chatID,
+		Content: sb.String(),
+	})
+}
+
+// stateKey holds the date of the last review in a kv store.
+const stateKey = "scheduler:review:last_run"
+
+type serviceState struct {
+	LastRun string `json:"last_run"`
+}
+
+func (s *Service) statePath() string {
+	return filepath.Join(s.workspace, "state", "review.json")
+}
+
+func (s *Service) lastRunDate() string {
+	if s.state != nil {
+		if data, ok, err := s.state.Get(stateKey); err == nil && ok {
+			return string(data)
+		}
+	}
+	data, err := os.ReadFile(s.statePath())
+	if err != nil {
+		return ""
+	}
+	var st serviceState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return ""
+	}
+	return st.LastRun
+}
+
+func (s *Service) saveLastRunDate(date string) {
+	if s.state != nil {
+		if err := s.state.Set(stateKey, []byte(date), 0); err == nil {
+			os.Remove(s.statePath())
+			return
+		}
+	}
+	data, _ := json.Marshal(serviceState{LastRun: date})
+	os.MkdirAll(filepath.Dir(s.statePath()), 0755)
+	if err := os.WriteFile(s.statePath(), data, 0644); err != nil {
+		logger.WarnCF("review", "Failed to save review state", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
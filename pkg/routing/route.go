@@ -14,6 +14,9 @@ type RouteInput struct {
 	ParentPeer *RoutePeer
 	GuildID    string
 	TeamID     string
+	// SenderID picks the agent of the sender's tenant, when tenants are
+	// enabled.
+	SenderID string
 }
 
 // ResolvedRoute is the result of agent routing.
@@ -23,12 +26,44 @@ type ResolvedRoute struct {
 	AccountID      string
 	SessionKey     string
 	MainSessionKey string
-	MatchedBy      string // "binding.peer", "binding.peer.parent", "binding.guild", "binding.team", "binding.account", "binding.channel", "default"
+	MatchedBy      string // "tenant", "binding.peer", "binding.peer.parent", "binding.guild", "binding.team", "binding.account", "binding.channel", "default"
 }
 
 // RouteResolver determines which agent handles a message based on config bindings.
 type RouteResolver struct {
-	cfg *config.Config
+	cfg        *config.Config
+	identities IdentityLinker
+}
+
+// IdentityLinker provides identity links made at runtime, such as by
+// users linking their accounts, in the form of session.identity_links.
+type IdentityLinker interface {
+	Links() map[string][]string
+}
+
+// SetIdentities adds the links of l to those in the config.
+func (r *RouteResolver) SetIdentities(l IdentityLinker) {
+	r.identities = l
+}
+
+// identityLinks returns the configured links merged with the runtime ones.
+func (r *RouteResolver) identityLinks() map[string][]string {
+	links := r.cfg.Session.IdentityLinks
+	if r.identities == nil {
+		return links
+	}
+	runtime := r.identities.Links()
+	if len(runtime) == 0 {
+		return links
+	}
+	merged := make(map[string][]string, len(links)+len(runtime))
+	for name, ids := range links {
+		merged[name] = ids
+	}
+	for name, ids := range runtime {
+		merged[name] = append(merged[name], ids...)
+	}
+	return merged
 }
 
 // NewRouteResolver creates a new route resolver.
@@ -48,12 +83,15 @@ func (r *RouteResolver) ResolveRoute(input RouteInput) ResolvedRoute {
 	if dmScope == "" {
 		dmScope = DMScopeMain
 	}
-	identityLinks := r.cfg.Session.IdentityLinks
+	identityLinks := r.identityLinks()
 
 	bindings := r.filterBindings(channel, accountID)
 
 	choose := func(agentID string, matchedBy string) ResolvedRoute {
-		resolvedAgentID := r.pickAgentID(agentID)
+		resolvedAgentID := agentID
+		if matchedBy != "tenant" {
+			resolvedAgentID = r.pickAgentID(agentID)
+		}
 		sessionKey := strings.ToLower(BuildAgentPeerSessionKey(SessionKeyParams{
 			AgentID:       resolvedAgentID,
 			Channel:       channel,
@@ -73,6 +111,11 @@ func (r *RouteResolver) ResolveRoute(input RouteInput) ResolvedRoute {
 		}
 	}
 
+	// A tenant's members always reach the tenant's agent
+	if agentID := r.tenantFor(channel, input.SenderID); agentID != "" {
+		return choose(agentID, "tenant")
+	}
+
 	// Priority 1: Peer binding
 	if peer != nil && strings.TrimSpace(peer.ID) != "" {
 		if match := r.findPeerMatch(bindings, peer); match != nil {
@@ -220,6 +263,10 @@ func (r *RouteResolver) pickAgentID(agentID string) string {
 		return NormalizeAgentID(r.resolveDefaultAgentID())
 	}
 	normalized := NormalizeAgentID(trimmed)
+	if IsTenantAgentID(normalized) {
+		// Only a tenant's own members reach its agent
+		return NormalizeAgentID(r.resolveDefaultAgentID())
+	}
 	agents := r.cfg.Agents.List
 	if len(agents) == 0 {
 		return normalized
@@ -232,6 +279,12 @@ func (r *RouteResolver) pickAgentID(agentID string) string {
 	return NormalizeAgentID(r.resolveDefaultAgentID())
 }
 
+// DefaultAgentID returns the agent that messages no binding matches go
+// to.
+func (r *RouteResolver) DefaultAgentID() string {
+	return NormalizeAgentID(r.resolveDefaultAgentID())
+}
+
 func (r *RouteResolver) resolveDefaultAgentID() string {
 	agents := r.cfg.Agents.List
 	if len(agents) == 0 {
@@ -115,6 +115,36 @@ func TestBuildAgentPeerSessionKey_IdentityLink(t *testing.T) {
 	}
 }
 
+func TestBuildAgentPeerSessionKey_IdentityLinkAcrossChannels(t *testing.T) {
+	links := map[string][]string{
+		"john": {"telegram:user123", "discord:555"},
+	}
+	for _, p := range []struct{ channel, id string }{{"telegram", "user123"}, {"discord", "555"}} {
+		got := BuildAgentPeerSessionKey(SessionKeyParams{
+			AgentID:       "main",
+			Channel:       p.channel,
+			Peer:          &RoutePeer{Kind: "direct", ID: p.id},
+			DMScope:       DMScopePerPeer,
+			IdentityLinks: links,
+		})
+		if want := "agent:main:direct:john"; got != want {
+			t.Errorf("%s: key = %q, want %q", p.channel, got, want)
+		}
+	}
+
+	// Links don't change the configured scope
+	got := BuildAgentPeerSessionKey(SessionKeyParams{
+		AgentID:       "main",
+		Channel:       "discord",
+		Peer:          &RoutePeer{Kind: "direct", ID: "555"},
+		DMScope:       DMScopePerChannelPeer,
+		IdentityLinks: links,
+	})
+	if want := "agent:main:discord:direct:john"; got != want {
+		t.Errorf("per-channel-peer key = %q, want %q", got, want)
+	}
+}
+
 func TestParseAgentSessionKey_Valid(t *testing.T) {
 	parsed := ParseAgentSessionKey("agent:sales:telegram:direct:user123")
 	if parsed == nil {
@@ -0,0 +1,39 @@
+package routing
+
+import (
+	"strings"
+)
+
+// tenantAgentPrefix starts the agent ID of every tenant's agent.
+const tenantAgentPrefix = "tenant-"
+
+// TenantAgentID returns the ID of the agent that serves a tenant.
+func TenantAgentID(tenantID string) string {
+	return NormalizeAgentID(tenantAgentPrefix + tenantID)
+}
+
+// IsTenantAgentID reports whether agentID is a tenant's agent.
+func IsTenantAgentID(agentID string) bool {
+	return strings.HasPrefix(agentID, tenantAgentPrefix)
+}
+
+// tenantFor returns the agent of the tenant whose members include the
+// sender, or "" when tenants are off or the sender belongs to none. A
+// member is "channel:sender_id" and matches the platform's user ID, the
+// part before "|" of a sender ID in "id|username" form; usernames, which
+// can change hands, never match.
+func (r *RouteResolver) tenantFor(channel, senderID string) string {
+	if !r.cfg.Tenants.Enabled || senderID == "" {
+		return ""
+	}
+	id, _, _ := strings.Cut(senderID, "|")
+	for _, t := range r.cfg.Tenants.List {
+		for _, m := range t.Members {
+			ch, member, scoped := strings.Cut(strings.TrimSpace(m), ":")
+			if scoped && strings.EqualFold(ch, channel) && member != "" && member == id {
+				return TenantAgentID(t.ID)
+			}
+		}
+	}
+	return ""
+}
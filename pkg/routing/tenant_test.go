@@ -0,0 +1,52 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func TestResolveRoute_Tenants(t *testing.T) {
+	bindings := []config.AgentBinding{
+		{AgentID: "tenant-kim", Match: config.BindingMatch{Channel: "telegram", AccountID: "*"}},
+	}
+	cfg := testConfig(nil, bindings)
+	cfg.Tenants = config.TenantsConfig{
+		Enabled: true,
+		List: []config.TenantConfig{
+			{ID: "alex", Members: []string{"telegram:111", "@alexd", "333"}},
+			{ID: "kim", Members: []string{"discord:222"}},
+		},
+	}
+	r := NewRouteResolver(cfg)
+
+	tests := []struct {
+		channel, sender string
+		agent, by       string
+	}{
+		{"telegram", "111|someone", "tenant-alex", "tenant"},
+		// Usernames and bare IDs aren't members
+		{"discord", "9|alexd", DefaultAgentID, "default"},
+		{"discord", "333", DefaultAgentID, "default"},
+		{"discord", "222", "tenant-kim", "tenant"},
+		// Kim's account on another channel isn't a member, and the
+		// binding to Kim's agent is ignored
+		{"telegram", "222", DefaultAgentID, "binding.channel"},
+		{"discord", "111", DefaultAgentID, "default"},
+	}
+	for _, tt := range tests {
+		route := r.ResolveRoute(RouteInput{
+			Channel:  tt.channel,
+			SenderID: tt.sender,
+			Peer:     &RoutePeer{Kind: "direct", ID: tt.sender},
+		})
+		if route.AgentID != tt.agent || route.MatchedBy != tt.by {
+			t.Errorf("%s/%s routed to %q by %q, want %q by %q", tt.channel, tt.sender, route.AgentID, route.MatchedBy, tt.agent, tt.by)
+		}
+	}
+
+	cfg.Tenants.Enabled = false
+	if route := r.ResolveRoute(RouteInput{Channel: "discord", SenderID: "222"}); route.AgentID != DefaultAgentID {
+		t.Errorf("with tenants off routed to %q", route.AgentID)
+	}
+}
@@ -15,8 +15,29 @@ type Session struct {
 	Key      string              `json:"key"`
 	Messages []providers.Message `json:"messages"`
 	Summary  string              `json:"summary,omitempty"`
-	Created  time.Time           `json:"created"`
-	Updated  time.Time           `json:"updated"`
+	// Creativity is the chat's sampling preset ("low" or "high"), empty
+	// for the agent's defaults.
+	Creativity string `json:"creativity,omitempty"`
+	// Topic labels what the conversation is currently about, for topic
+	// checkpoints.
+	Topic string `json:"topic,omitempty"`
+	// FactCheck has the agent verify claims before answering in this chat.
+	FactCheck bool `json:"fact_check,omitempty"`
+	// Routing pins the chat's model choice ("cheap" or "smart"), empty to
+	// route each turn by its complexity.
+	Routing string `json:"routing,omitempty"`
+	// Pins are the exchanges the user pinned in this chat.
+	Pins    []Pin     `json:"pins,omitempty"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// Pin is an exchange the user pinned, copied out of the messages so that
+// truncation, summaries and pruning of the session leave it alone.
+type Pin struct {
+	User      string    `json:"user,omitempty"`
+	Assistant string    `json:"assistant"`
+	Created   time.Time `json:"created"`
 }
 
 type SessionManager struct {
@@ -122,6 +143,190 @@ func (sm *SessionManager) SetSummary(key string, summary string) {
 	}
 }
 
+// GetTopic returns the label of the session's current topic.
+func (sm *SessionManager) GetTopic(key string) string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	session, ok := sm.sessions[key]
+	if !ok {
+		return ""
+	}
+	return session.Topic
+}
+
+func (sm *SessionManager) SetTopic(key, topic string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	session, ok := sm.sessions[key]
+	if ok {
+		session.Topic = topic
+		session.Updated = time.Now()
+	}
+}
+
+// GetFactCheck reports whether fact-check mode is on for the session.
+func (sm *SessionManager) GetFactCheck(key string) bool {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	session, ok := sm.sessions[key]
+	return ok && session.FactCheck
+}
+
+// SetFactCheck turns fact-check mode on or off, creating the session if
+// needed.
+func (sm *SessionManager) SetFactCheck(key string, on bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	session, ok := sm.sessions[key]
+	if !ok {
+		session = &Session{
+			Key:      key,
+			Messages: []providers.Message{},
+			Created:  time.Now(),
+		}
+		sm.sessions[key] = session
+	}
+	session.FactCheck = on
+	session.Updated = time.Now()
+}
+
+// GetRouting returns the session's model choice, empty when turns are
+// routed automatically.
+func (sm *SessionManager) GetRouting(key string) string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	session, ok := sm.sessions[key]
+	if !ok {
+		return ""
+	}
+	return session.Routing
+}
+
+// SetRouting sets the session's model choice, creating the session if
+// needed.
+func (sm *SessionManager) SetRouting(key, routing string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	session, ok := sm.sessions[key]
+	if !ok {
+		session = &Session{
+			Key:      key,
+			Messages: []providers.Message{},
+			Created:  time.Now(),
+		}
+		sm.sessions[key] = session
+	}
+	session.Routing = routing
+	session.Updated = time.Now()
+}
+
+// GetCreativity returns the session's sampling preset, empty when it uses
+// the defaults.
+func (sm *SessionManager) GetCreativity(key string) string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	session, ok := sm.sessions[key]
+	if !ok {
+		return ""
+	}
+	return session.Creativity
+}
+
+// SetCreativity sets the session's sampling preset, creating the session
+// if needed.
+func (sm *SessionManager) SetCreativity(key, creativity string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	session, ok := sm.sessions[key]
+	if !ok {
+		session = &Session{
+			Key:      key,
+			Messages: []providers.Message{},
+			Created:  time.Now(),
+		}
+		sm.sessions[key] = session
+	}
+	session.Creativity = creativity
+	session.Updated = time.Now()
+}
+
+// AddPin pins an exchange in a session, creating the session if needed.
+// It reports false when the reply is already pinned.
+func (sm *SessionManager) AddPin(key string, pin Pin) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	session, ok := sm.sessions[key]
+	if !ok {
+		session = &Session{
+			Key:      key,
+			Messages: []providers.Message{},
+			Created:  time.Now(),
+		}
+		sm.sessions[key] = session
+	}
+	for _, p := range session.Pins {
+		if p.Assistant == pin.Assistant {
+			return false
+		}
+	}
+	if pin.Created.IsZero() {
+		pin.Created = time.Now()
+	}
+	session.Pins = append(session.Pins, pin)
+	session.Updated = time.Now()
+	return true
+}
+
+// GetPins returns the pinned exchanges of a session, oldest first.
+func (sm *SessionManager) GetPins(key string) []Pin {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	session, ok := sm.sessions[key]
+	if !ok || len(session.Pins) == 0 {
+		return nil
+	}
+	pins := make([]Pin, len(session.Pins))
+	copy(pins, session.Pins)
+	return pins
+}
+
+// RemovePin unpins the i-th exchange of a session, counting from 0.
+func (sm *SessionManager) RemovePin(key string, i int) (Pin, bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	session, ok := sm.sessions[key]
+	if !ok || i < 0 || i >= len(session.Pins) {
+		return Pin{}, false
+	}
+	pin := session.Pins[i]
+	session.Pins = append(session.Pins[:i:i], session.Pins[i+1:]...)
+	session.Updated = time.Now()
+	return pin, true
+}
+
+// SetPins replaces the pinned exchanges of a session.
+func (sm *SessionManager) SetPins(key string, pins []Pin) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	session, ok := sm.sessions[key]
+	if ok {
+		session.Pins = append([]Pin(nil), pins...)
+		session.Updated = time.Now()
+	}
+}
+
 func (sm *SessionManager) TruncateHistory(key string, keepLast int) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
@@ -178,10 +383,14 @@ func (sm *SessionManager) Save(key string) error {
 	}
 
 	snapshot := Session{
-		Key:     stored.Key,
-		Summary: stored.Summary,
-		Created: stored.Created,
-		Updated: stored.Updated,
+		Key:        stored.Key,
+		Summary:    stored.Summary,
+		Creativity: stored.Creativity,
+		Topic:      stored.Topic,
+		FactCheck:  stored.FactCheck,
+		Pins:       append([]Pin(nil), stored.Pins...),
+		Created:    stored.Created,
+		Updated:    stored.Updated,
 	}
 	if len(stored.Messages) > 0 {
 		snapshot.Messages = make([]providers.Message, len(stored.Messages))
@@ -280,3 +489,72 @@ func (sm *SessionManager) SetHistory(key string, history []providers.Message) {
 		session.Updated = time.Now()
 	}
 }
+
+// Keys returns the keys of all known sessions.
+func (sm *SessionManager) Keys() []string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	keys := make([]string, 0, len(sm.sessions))
+	for key := range sm.sessions {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Delete removes a session from memory and deletes its file from storage.
+func (sm *SessionManager) Delete(key string) error {
+	sm.mu.Lock()
+	delete(sm.sessions, key)
+	sm.mu.Unlock()
+
+	if sm.storage == "" {
+		return nil
+	}
+
+	filename := sanitizeFilename(key)
+	if filename == "." || !filepath.IsLocal(filename) || strings.ContainsAny(filename, `/\`) {
+		return os.ErrInvalid
+	}
+
+	err := os.Remove(filepath.Join(sm.storage, filename+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// PruneOlderThan deletes sessions that have not been updated since cutoff
+// and returns their keys. A session with pins is cut down to them instead,
+// so what the user pinned outlives the rest of the conversation; the keys
+// of those are returned as trimmed.
+func (sm *SessionManager) PruneOlderThan(cutoff time.Time) (deleted, trimmed []string, err error) {
+	sm.mu.Lock()
+	for key, s := range sm.sessions {
+		if !s.Updated.Before(cutoff) {
+			continue
+		}
+		if len(s.Pins) == 0 {
+			deleted = append(deleted, key)
+			continue
+		}
+		if len(s.Messages) > 0 || s.Summary != "" {
+			s.Messages = []providers.Message{}
+			s.Summary = ""
+			trimmed = append(trimmed, key)
+		}
+	}
+	sm.mu.Unlock()
+
+	for i, key := range deleted {
+		if err := sm.Delete(key); err != nil {
+			return deleted[:i], nil, err
+		}
+	}
+	for i, key := range trimmed {
+		if err := sm.Save(key); err != nil {
+			return deleted, trimmed[:i], err
+		}
+	}
+	return deleted, trimmed, nil
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestSanitizeFilename(t *testing.T) {
@@ -72,3 +73,44 @@ func TestSave_RejectsPathTraversal(t *testing.T) {
 		}
 	}
 }
+
+func TestPruneOlderThan_KeepsPins(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewSessionManager(tmpDir)
+	for _, key := range []string{"chat:plain", "chat:pinned"} {
+		sm.AddMessage(key, "user", "which database?")
+		sm.AddMessage(key, "assistant", "Postgres.")
+	}
+	if !sm.AddPin("chat:pinned", Pin{User: "which database?", Assistant: "Postgres."}) {
+		t.Fatal("AddPin() = false")
+	}
+	if sm.AddPin("chat:pinned", Pin{Assistant: "Postgres."}) {
+		t.Error("the same reply was pinned twice")
+	}
+	for _, key := range []string{"chat:plain", "chat:pinned"} {
+		if err := sm.Save(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, trimmed, err := sm.PruneOlderThan(time.Now().Add(time.Minute))
+	if err != nil || len(deleted) != 1 || deleted[0] != "chat:plain" || len(trimmed) != 1 || trimmed[0] != "chat:pinned" {
+		t.Fatalf("PruneOlderThan() = %v, %v, %v", deleted, trimmed, err)
+	}
+
+	reloaded := NewSessionManager(tmpDir)
+	if _, err := os.Stat(filepath.Join(tmpDir, "chat_plain.json")); !os.IsNotExist(err) {
+		t.Error("session without pins was not deleted")
+	}
+	if history := reloaded.GetHistory("chat:pinned"); len(history) != 0 {
+		t.Errorf("pinned session kept %d messages", len(history))
+	}
+	pins := reloaded.GetPins("chat:pinned")
+	if len(pins) != 1 || pins[0].User != "which database?" || pins[0].Assistant != "Postgres." {
+		t.Errorf("pins after pruning = %+v", pins)
+	}
+
+	if _, ok := reloaded.RemovePin("chat:pinned", 0); !ok || len(reloaded.GetPins("chat:pinned")) != 0 {
+		t.Error("RemovePin() didn't remove the pin")
+	}
+}
@@ -0,0 +1,113 @@
+package skills
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// SkillChange is a skill that was installed, updated or removed since the
+// last check. Diff is a unified diff of its SKILL.md; it is empty for
+// installed and removed skills.
+type SkillChange struct {
+	Name       string
+	Source     string
+	OldVersion string
+	NewVersion string
+	Added      bool
+	Removed    bool
+	Diff       string
+}
+
+// Summary describes the change in one line, e.g. "weather updated from
+// 1.0 to 1.1 (workspace)".
+func (c SkillChange) Summary() string {
+	switch {
+	case c.Added:
+		return fmt.Sprintf("%s%s installed (%s)", c.Name, versionSuffix(c.NewVersion), c.Source)
+	case c.Removed:
+		return fmt.Sprintf("%s%s removed (%s)", c.Name, versionSuffix(c.OldVersion), c.Source)
+	case c.OldVersion != c.NewVersion:
+		return fmt.Sprintf("%s updated from %s to %s (%s)", c.Name, orUnversioned(c.OldVersion), orUnversioned(c.NewVersion), c.Source)
+	}
+	return fmt.Sprintf("%s changed without a new version (%s)", c.Name, c.Source)
+}
+
+func versionSuffix(v string) string {
+	if v == "" {
+		return ""
+	}
+	return " " + v
+}
+
+func orUnversioned(v string) string {
+	if v == "" {
+		return "unversioned"
+	}
+	return v
+}
+
+type skillSnapshot struct {
+	info    SkillInfo
+	content string
+}
+
+// ChangeTracker remembers the SKILL.md of every skill a loader lists, so
+// that edits and upgrades, which change the agent's behavior, don't go
+// unnoticed.
+type ChangeTracker struct {
+	loader *SkillsLoader
+	skills map[string]skillSnapshot
+}
+
+// NewChangeTracker creates a tracker that reports changes from now on.
+func NewChangeTracker(loader *SkillsLoader) *ChangeTracker {
+	return &ChangeTracker{loader: loader, skills: snapshot(loader)}
+}
+
+// Check returns the skills that changed since the last check, by name.
+func (t *ChangeTracker) Check() []SkillChange {
+	current := snapshot(t.loader)
+
+	var changes []SkillChange
+	for name, now := range current {
+		old, ok := t.skills[name]
+		switch {
+		case !ok:
+			changes = append(changes, SkillChange{Name: name, Source: now.info.Source, NewVersion: now.info.Version, Added: true})
+		case old.content != now.content:
+			changes = append(changes, SkillChange{
+				Name:       name,
+				Source:     now.info.Source,
+				OldVersion: old.info.Version,
+				NewVersion: now.info.Version,
+				Diff:       utils.UnifiedDiff(name+"/SKILL.md", old.content, now.content),
+			})
+		}
+	}
+	for name, old := range t.skills {
+		if _, ok := current[name]; !ok {
+			changes = append(changes, SkillChange{Name: name, Source: old.info.Source, OldVersion: old.info.Version, Removed: true})
+		}
+	}
+	t.skills = current
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+func snapshot(loader *SkillsLoader) map[string]skillSnapshot {
+	skills := make(map[string]skillSnapshot)
+	for _, info := range loader.ListSkills() {
+		content, err := os.ReadFile(info.Path)
+		if err != nil {
+			continue
+		}
+		// Line endings aren't a change worth reporting
+		skills[info.Name] = skillSnapshot{info: info, content: strings.ReplaceAll(string(content), "\r\n", "\n")}
+	}
+	return skills
+}
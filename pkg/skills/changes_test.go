@@ -0,0 +1,42 @@
+package skills
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeSkill(t *testing.T, workspace, name, content string) {
+	t.Helper()
+	dir := filepath.Join(workspace, "skills", name)
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0644))
+}
+
+func TestChangeTracker(t *testing.T) {
+	workspace := t.TempDir()
+	writeSkill(t, workspace, "weather", "---\nname: weather\ndescription: Forecasts\nversion: 1.0\n---\n\nUse wttr.in\n")
+	writeSkill(t, workspace, "notes", "---\nname: notes\ndescription: Notes\n---\n\nKeep notes\n")
+
+	tracker := NewChangeTracker(NewSkillsLoader(workspace, "", ""))
+	assert.Empty(t, tracker.Check())
+
+	writeSkill(t, workspace, "weather", "---\nname: weather\ndescription: Forecasts\nversion: 1.1\n---\n\nUse open-meteo\n")
+	writeSkill(t, workspace, "github", "---\nname: github\ndescription: Issues\nversion: 2\n---\n")
+	assert.NoError(t, os.RemoveAll(filepath.Join(workspace, "skills", "notes")))
+
+	changes := tracker.Check()
+	assert.Len(t, changes, 3)
+
+	assert.Equal(t, "github 2 installed (workspace)", changes[0].Summary())
+	assert.Equal(t, "notes removed (workspace)", changes[1].Summary())
+
+	updated := changes[2]
+	assert.Equal(t, "weather updated from 1.0 to 1.1 (workspace)", updated.Summary())
+	assert.True(t, strings.Contains(updated.Diff, "-Use wttr.in\n+Use open-meteo\n"), updated.Diff)
+
+	assert.Empty(t, tracker.Check())
+}
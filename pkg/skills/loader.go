@@ -8,9 +8,13 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/markdown"
 )
 
 var namePattern = regexp.MustCompile(`^[a-zA-Z0-9]+(-[a-zA-Z0-9]+)*$`)
@@ -20,9 +24,29 @@ const (
 	MaxDescriptionLength = 1024
 )
 
+// metadataAliases maps frontmatter keys used by other skill ecosystems and
+// in Chinese skills to the SkillMetadata field they stand for.
+var metadataAliases = map[string]string{
+	"title":      "name",
+	"skill":      "name",
+	"skill_name": "name",
+	"skill-name": "name",
+	"名称":         "name",
+	"名字":         "name",
+	"技能名称":       "name",
+	"summary":    "description",
+	"desc":       "description",
+	"about":      "description",
+	"描述":         "description",
+	"说明":         "description",
+	"简介":         "description",
+	"技能描述":       "description",
+}
+
 type SkillMetadata struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Version     string `json:"version,omitempty"`
 }
 
 type SkillInfo struct {
@@ -30,6 +54,12 @@ type SkillInfo struct {
 	Path        string `json:"path"`
 	Source      string `json:"source"`
 	Description string `json:"description"`
+	Version     string `json:"version,omitempty"`
+	// Shadows lists the sources of same-named skills this one hides.
+	Shadows []string `json:"shadows,omitempty"`
+	// Protected is set when this skill is protected and overrides of it
+	// are ignored.
+	Protected bool `json:"protected,omitempty"`
 }
 
 func (info SkillInfo) validate() error {
@@ -58,6 +88,9 @@ type SkillsLoader struct {
 	workspaceSkills string // workspace skills (项目级别)
 	globalSkills    string // 全局 skills (~/.picoclaw/skills)
 	builtinSkills   string // 内置 skills
+	warnShadowing   bool
+	protected       map[string]bool
+	warned          sync.Map // shadowing warnings already logged
 }
 
 func NewSkillsLoader(workspace string, globalSkills string, builtinSkills string) *SkillsLoader {
@@ -66,114 +99,75 @@ func NewSkillsLoader(workspace string, globalSkills string, builtinSkills string
 		workspaceSkills: filepath.Join(workspace, "skills"),
 		globalSkills:    globalSkills, // ~/.picoclaw/skills
 		builtinSkills:   builtinSkills,
+		warnShadowing:   true,
+	}
+}
+
+// skillSource is a directory skills are loaded from.
+type skillSource struct {
+	name string
+	dir  string
+}
+
+// sources returns the skill directories by precedence: workspace skills
+// override global ones (~/.picoclaw/skills), which override builtins.
+func (sl *SkillsLoader) sources() []skillSource {
+	return []skillSource{
+		{name: "workspace", dir: sl.workspaceSkills},
+		{name: "global", dir: sl.globalSkills},
+		{name: "builtin", dir: sl.builtinSkills},
+	}
+}
+
+// SetShadowing configures how skills of the same name override each
+// other. With warn, a workspace or global skill hiding a builtin is
+// logged. Protected skills can't be overridden: they always come from the
+// lowest source that has them, usually the builtins.
+func (sl *SkillsLoader) SetShadowing(warn bool, protected []string) {
+	sl.warnShadowing = warn
+	sl.protected = make(map[string]bool, len(protected))
+	for _, name := range protected {
+		sl.protected[strings.TrimSpace(name)] = true
 	}
 }
 
 func (sl *SkillsLoader) ListSkills() []SkillInfo {
 	skills := make([]SkillInfo, 0)
-
-	if sl.workspaceSkills != "" {
-		if dirs, err := os.ReadDir(sl.workspaceSkills); err == nil {
-			for _, dir := range dirs {
-				if dir.IsDir() {
-					skillFile := filepath.Join(sl.workspaceSkills, dir.Name(), "SKILL.md")
-					if _, err := os.Stat(skillFile); err == nil {
-						info := SkillInfo{
-							Name:   dir.Name(),
-							Path:   skillFile,
-							Source: "workspace",
-						}
-						metadata := sl.getSkillMetadata(skillFile)
-						if metadata != nil {
-							info.Description = metadata.Description
-							info.Name = metadata.Name
-						}
-						if err := info.validate(); err != nil {
-							slog.Warn("invalid skill from workspace", "name", info.Name, "error", err)
-							continue
-						}
-						skills = append(skills, info)
-					}
-				}
+	index := make(map[string]int)
+
+	for _, source := range sl.sources() {
+		for _, info := range sl.listSource(source) {
+			i, exists := index[info.Name]
+			if !exists {
+				index[info.Name] = len(skills)
+				skills = append(skills, info)
+				continue
 			}
-		}
-	}
 
-	// 全局 skills (~/.picoclaw/skills) - 被 workspace skills 覆盖
-	if sl.globalSkills != "" {
-		if dirs, err := os.ReadDir(sl.globalSkills); err == nil {
-			for _, dir := range dirs {
-				if dir.IsDir() {
-					skillFile := filepath.Join(sl.globalSkills, dir.Name(), "SKILL.md")
-					if _, err := os.Stat(skillFile); err == nil {
-						// 检查是否已被 workspace skills 覆盖
-						exists := false
-						for _, s := range skills {
-							if s.Name == dir.Name() && s.Source == "workspace" {
-								exists = true
-								break
-							}
-						}
-						if exists {
-							continue
-						}
-
-						info := SkillInfo{
-							Name:   dir.Name(),
-							Path:   skillFile,
-							Source: "global",
-						}
-						metadata := sl.getSkillMetadata(skillFile)
-						if metadata != nil {
-							info.Description = metadata.Description
-							info.Name = metadata.Name
-						}
-						if err := info.validate(); err != nil {
-							slog.Warn("invalid skill from global", "name", info.Name, "error", err)
-							continue
-						}
-						skills = append(skills, info)
-					}
-				}
+			winner := skills[i]
+			if sl.protected[info.Name] {
+				// The lower source wins
+				info.Protected = true
+				skills[i] = info
+				sl.warnOnce("blocked:"+winner.Source+":"+info.Name, "Protected skill can't be overridden, ignoring it",
+					map[string]interface{}{
+						"skill":     info.Name,
+						"source":    winner.Source,
+						"path":      winner.Path,
+						"protected": info.Path,
+					})
+				continue
 			}
-		}
-	}
 
-	if sl.builtinSkills != "" {
-		if dirs, err := os.ReadDir(sl.builtinSkills); err == nil {
-			for _, dir := range dirs {
-				if dir.IsDir() {
-					skillFile := filepath.Join(sl.builtinSkills, dir.Name(), "SKILL.md")
-					if _, err := os.Stat(skillFile); err == nil {
-						// 检查是否已被 workspace 或 global skills 覆盖
-						exists := false
-						for _, s := range skills {
-							if s.Name == dir.Name() && (s.Source == "workspace" || s.Source == "global") {
-								exists = true
-								break
-							}
-						}
-						if exists {
-							continue
-						}
-
-						info := SkillInfo{
-							Name:   dir.Name(),
-							Path:   skillFile,
-							Source: "builtin",
-						}
-						metadata := sl.getSkillMetadata(skillFile)
-						if metadata != nil {
-							info.Description = metadata.Description
-							info.Name = metadata.Name
-						}
-						if err := info.validate(); err != nil {
-							slog.Warn("invalid skill from builtin", "name", info.Name, "error", err)
-							continue
-						}
-						skills = append(skills, info)
-					}
-				}
+			skills[i].Shadows = append(skills[i].Shadows, info.Source)
+			if source.name == "builtin" && sl.warnShadowing {
+				sl.warnOnce("shadow:"+winner.Source+":"+info.Name, "Skill shadows a builtin skill of the same name",
+					map[string]interface{}{
+						"skill":   info.Name,
+						"source":  winner.Source,
+						"path":    winner.Path,
+						"builtin": info.Path,
+					})
 			}
 		}
 	}
@@ -181,26 +175,64 @@ func (sl *SkillsLoader) ListSkills() []SkillInfo {
 	return skills
 }
 
-func (sl *SkillsLoader) LoadSkill(name string) (string, bool) {
-	// 1. 优先从 workspace skills 加载（项目级别）
-	if sl.workspaceSkills != "" {
-		skillFile := filepath.Join(sl.workspaceSkills, name, "SKILL.md")
-		if content, err := os.ReadFile(skillFile); err == nil {
-			return sl.stripFrontmatter(string(content)), true
-		}
+// listSource returns the valid skills in one source directory.
+func (sl *SkillsLoader) listSource(source skillSource) []SkillInfo {
+	if source.dir == "" {
+		return nil
+	}
+	dirs, err := os.ReadDir(source.dir)
+	if err != nil {
+		return nil
 	}
 
-	// 2. 其次从全局 skills 加载 (~/.picoclaw/skills)
-	if sl.globalSkills != "" {
-		skillFile := filepath.Join(sl.globalSkills, name, "SKILL.md")
-		if content, err := os.ReadFile(skillFile); err == nil {
-			return sl.stripFrontmatter(string(content)), true
+	var skills []SkillInfo
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		skillFile := filepath.Join(source.dir, dir.Name(), "SKILL.md")
+		if _, err := os.Stat(skillFile); err != nil {
+			continue
+		}
+		info := SkillInfo{
+			Name:   dir.Name(),
+			Path:   skillFile,
+			Source: source.name,
 		}
+		metadata := sl.getSkillMetadata(skillFile)
+		if metadata != nil {
+			info.Description = metadata.Description
+			info.Name = metadata.Name
+			info.Version = metadata.Version
+		}
+		if err := info.validate(); err != nil {
+			slog.Warn("invalid skill from "+source.name, "name", info.Name, "error", err)
+			continue
+		}
+		skills = append(skills, info)
+	}
+	return skills
+}
+
+// warnOnce logs a shadowing warning the first time it comes up; skills are
+// listed for every request.
+func (sl *SkillsLoader) warnOnce(key, msg string, fields map[string]interface{}) {
+	if _, seen := sl.warned.LoadOrStore(key, true); seen {
+		return
 	}
+	logger.WarnCF("skills", msg, fields)
+}
 
-	// 3. 最后从内置 skills 加载
-	if sl.builtinSkills != "" {
-		skillFile := filepath.Join(sl.builtinSkills, name, "SKILL.md")
+func (sl *SkillsLoader) LoadSkill(name string) (string, bool) {
+	sources := sl.sources()
+	if sl.protected[name] {
+		slices.Reverse(sources)
+	}
+	for _, source := range sources {
+		if source.dir == "" {
+			continue
+		}
+		skillFile := filepath.Join(source.dir, name, "SKILL.md")
 		if content, err := os.ReadFile(skillFile); err == nil {
 			return sl.stripFrontmatter(string(content)), true
 		}
@@ -268,23 +300,29 @@ func (sl *SkillsLoader) getSkillMetadata(skillPath string) *SkillMetadata {
 		}
 	}
 
-	// Try JSON first (for backward compatibility)
-	var jsonMeta struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
-	}
+	// Try JSON first (for backward compatibility), then fall back to simple
+	// YAML parsing
+	fields := make(map[string]string)
+	var jsonMeta map[string]interface{}
 	if err := json.Unmarshal([]byte(frontmatter), &jsonMeta); err == nil {
-		return &SkillMetadata{
-			Name:        jsonMeta.Name,
-			Description: jsonMeta.Description,
+		for key, value := range jsonMeta {
+			switch v := value.(type) {
+			case string:
+				fields[key] = v
+			case float64:
+				// version: 2 is as good as "2"
+				fields[key] = strconv.FormatFloat(v, 'f', -1, 64)
+			}
 		}
+	} else {
+		fields = sl.parseSimpleYAML(frontmatter)
 	}
 
-	// Fall back to simple YAML parsing
-	yamlMeta := sl.parseSimpleYAML(frontmatter)
+	meta := markdown.CanonicalFields(fields, metadataAliases)
 	return &SkillMetadata{
-		Name:        yamlMeta["name"],
-		Description: yamlMeta["description"],
+		Name:        meta["name"],
+		Description: meta["description"],
+		Version:     meta["version"],
 	}
 }
 
@@ -1,6 +1,8 @@
 package skills
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -123,6 +125,54 @@ func TestExtractFrontmatter(t *testing.T) {
 	}
 }
 
+func TestGetSkillMetadataAliases(t *testing.T) {
+	sl := &SkillsLoader{}
+
+	testcases := []struct {
+		name         string
+		content      string
+		expectedName string
+		expectedDesc string
+	}{
+		{
+			name:         "legacy-yaml-keys",
+			content:      "---\ntitle: weather\nsummary: Get the forecast\n---\n\n# Weather",
+			expectedName: "weather",
+			expectedDesc: "Get the forecast",
+		},
+		{
+			name:         "chinese-keys",
+			content:      "---\n名称: weather\n描述: 查询天气预报\n---\n\n# 天气",
+			expectedName: "weather",
+			expectedDesc: "查询天气预报",
+		},
+		{
+			name:         "json-aliases",
+			content:      "---\n{\"skill_name\": \"weather\", \"desc\": \"Get the forecast\", \"version\": 2}\n---\n",
+			expectedName: "weather",
+			expectedDesc: "Get the forecast",
+		},
+		{
+			name:         "canonical-wins",
+			content:      "---\nname: weather\ntitle: Weather Forecast\ndescription: Get the forecast\n---\n",
+			expectedName: "weather",
+			expectedDesc: "Get the forecast",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "SKILL.md")
+			assert.NoError(t, os.WriteFile(path, []byte(tc.content), 0644))
+
+			meta := sl.getSkillMetadata(path)
+			assert.NotNil(t, meta)
+			assert.Equal(t, tc.expectedName, meta.Name)
+			assert.Equal(t, tc.expectedDesc, meta.Description)
+		})
+	}
+}
+
 func TestStripFrontmatter(t *testing.T) {
 	sl := &SkillsLoader{}
 
@@ -177,3 +227,40 @@ func TestStripFrontmatter(t *testing.T) {
 		})
 	}
 }
+
+func TestListSkillsShadowing(t *testing.T) {
+	workspace := t.TempDir()
+	builtin := t.TempDir()
+	write := func(dir, name, body string) {
+		skillDir := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(skillDir, 0755))
+		content := "---\nname: " + name + "\ndescription: " + body + "\n---\n\n" + body
+		assert.NoError(t, os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(content), 0644))
+	}
+	write(filepath.Join(workspace, "skills"), "weather", "custom weather")
+	write(filepath.Join(workspace, "skills"), "github", "custom github")
+	write(builtin, "weather", "builtin weather")
+	write(builtin, "github", "builtin github")
+
+	sl := NewSkillsLoader(workspace, "", builtin)
+	sl.SetShadowing(true, []string{"github"})
+
+	byName := make(map[string]SkillInfo)
+	for _, s := range sl.ListSkills() {
+		byName[s.Name] = s
+	}
+	assert.Len(t, byName, 2)
+
+	assert.Equal(t, "workspace", byName["weather"].Source)
+	assert.Equal(t, []string{"builtin"}, byName["weather"].Shadows)
+
+	assert.Equal(t, "builtin", byName["github"].Source)
+	assert.True(t, byName["github"].Protected)
+	assert.Empty(t, byName["github"].Shadows)
+
+	content, ok := sl.LoadSkill("github")
+	assert.True(t, ok)
+	assert.Equal(t, "builtin github", content)
+	content, _ = sl.LoadSkill("weather")
+	assert.Equal(t, "custom weather", content)
+}
@@ -0,0 +1,178 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package starters
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxCalendarSize bounds one downloaded calendar.
+const maxCalendarSize = 5 << 20
+
+// Event is one calendar event.
+type Event struct {
+	Summary  string
+	Location string
+	Start    time.Time
+	AllDay   bool
+}
+
+// String describes the event for the agent, e.g. "15:00 Dentist (Main St 1)".
+func (e Event) String() string {
+	s := e.Summary
+	if e.Location != "" {
+		s += " (" + e.Location + ")"
+	}
+	if e.AllDay {
+		return "all day: " + s
+	}
+	return e.Start.Format("15:04") + " " + s
+}
+
+// FetchCalendar reads an iCalendar feed from a URL or a file path.
+func FetchCalendar(ctx context.Context, client *http.Client, source string) ([]Event, error) {
+	var r io.Reader
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "webcal://") {
+		source = strings.Replace(source, "webcal://", "https://", 1)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("calendar returned HTTP %d", resp.StatusCode)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return ParseICS(io.LimitReader(r, maxCalendarSize))
+}
+
+// ParseICS reads the events of an iCalendar file. Recurring events only
+// count on their first occurrence.
+func ParseICS(r io.Reader) ([]Event, error) {
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	var cur *Event
+	for _, line := range lines {
+		name, params, value := splitICSLine(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			cur = &Event{}
+		case name == "END" && value == "VEVENT":
+			if cur != nil && !cur.Start.IsZero() {
+				events = append(events, *cur)
+			}
+			cur = nil
+		case cur == nil:
+		case name == "SUMMARY":
+			cur.Summary = unescapeICS(value)
+		case name == "LOCATION":
+			cur.Location = unescapeICS(value)
+		case name == "DTSTART":
+			cur.Start, cur.AllDay = parseICSTime(params, value)
+		}
+	}
+	return events, nil
+}
+
+// On returns the events on the day of now in now's location, in order.
+func On(events []Event, now time.Time) []Event {
+	y, m, d := now.Date()
+	var day []Event
+	for _, e := range events {
+		if !e.AllDay {
+			e.Start = e.Start.In(now.Location())
+		}
+		if ey, em, ed := e.Start.Date(); ey == y && em == m && ed == d {
+			day = append(day, e)
+		}
+	}
+	sort.SliceStable(day, func(i, j int) bool {
+		if day[i].AllDay != day[j].AllDay {
+			return day[i].AllDay
+		}
+		return day[i].Start.Before(day[j].Start)
+	})
+	return day
+}
+
+// unfoldICS joins continuation lines, which start with a space or tab.
+func unfoldICS(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// splitICSLine splits "DTSTART;TZID=Europe/Berlin:20261015T150000" into
+// its name, parameters and value.
+func splitICSLine(line string) (string, map[string]string, string) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", nil, ""
+	}
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+func parseICSTime(params map[string]string, value string) (time.Time, bool) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, _ := time.ParseInLocation("20060102", value, time.Local)
+		return t, true
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, _ := time.Parse("20060102T150405Z", value)
+		return t, false
+	}
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, _ := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false
+}
+
+func unescapeICS(s string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
@@ -0,0 +1,225 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package starters has the agent open the day with one question about
+// what's on the user's plate, such as "You have the dentist at 3 — want me
+// to set a leave-by reminder?".
+package starters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+const (
+	// askTimeout bounds gathering context and writing one question.
+	askTimeout = 5 * time.Minute
+	// noQuestion is what the agent answers when nothing is worth asking.
+	noQuestion = "NO_QUESTION"
+)
+
+// Source lists what's on the user's plate on the day of now, one item per
+// line, e.g. "15:00 Dentist". channel and chatID are the chat the question
+// goes to; sources with items of several chats list only that one's.
+type Source func(ctx context.Context, now time.Time, channel, chatID string) ([]string, error)
+
+// AskFunc has the agent answer prompt in the context of a chat, e.g.
+// through AgentLoop.ProcessHeartbeat.
+type AskFunc func(ctx context.Context, prompt, channel, chatID string) (string, error)
+
+// TargetFunc returns the chat to start a conversation in, or empty strings
+// when there is none.
+type TargetFunc func() (channel, chatID string)
+
+// Starter sends the morning question. It runs on the heartbeat, so the
+// question arrives within one heartbeat interval after Hour, at most once
+// a day.
+type Starter struct {
+	hour    int
+	sources []namedSource
+	target  TargetFunc
+	ask     AskFunc
+	bus     *bus.MessageBus
+	path    string
+
+	// Muted, when set, skips chats that opted out of proactive messages
+	// before the agent spends a call on them.
+	Muted func(channel, chatID string) bool
+	// OnSent is called with the question once the channel has sent it,
+	// which it doesn't when the chat's proactive limit holds it back.
+	OnSent func(channel, chatID, question string)
+
+	mu sync.Mutex
+}
+
+// NewStarter creates a starter that asks from hour (local time, 0-23).
+// It remembers the last day it ran in workspace/state/starters.json.
+func NewStarter(workspace string, hour int, target TargetFunc, ask AskFunc, msgBus *bus.MessageBus) *Starter {
+	return &Starter{
+		hour:   hour,
+		target: target,
+		ask:    ask,
+		bus:    msgBus,
+		path:   filepath.Join(workspace, "state", "starters.json"),
+	}
+}
+
+type namedSource struct {
+	name string
+	src  Source
+}
+
+// AddSource adds a kind of context, e.g. "Calendar" or "Scheduled tasks".
+func (s *Starter) AddSource(name string, src Source) {
+	s.sources = append(s.sources, namedSource{name, src})
+}
+
+// CalendarSource lists today's events from the given iCalendar feeds.
+func CalendarSource(calendars []string) Source {
+	client := &http.Client{Timeout: 30 * time.Second}
+	return func(ctx context.Context, now time.Time, _, _ string) ([]string, error) {
+		var lines []string
+		var lastErr error
+		for _, cal := range calendars {
+			events, err := FetchCalendar(ctx, client, cal)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			for _, e := range On(events, now) {
+				lines = append(lines, e.String())
+			}
+		}
+		if len(lines) == 0 {
+			return nil, lastErr
+		}
+		return lines, nil
+	}
+}
+
+// Check starts today's question when it is due.
+func (s *Starter) Check(now time.Time) {
+	if now.Hour() < s.hour {
+		return
+	}
+	today := now.Format("2006-01-02")
+
+	s.mu.Lock()
+	if s.lastDay() == today {
+		s.mu.Unlock()
+		return
+	}
+	// Mark the day first; one try a day is enough
+	s.setLastDay(today)
+	s.mu.Unlock()
+
+	channel, chatID := s.target()
+	if channel == "" || chatID == "" {
+		return
+	}
+	if s.Muted != nil && s.Muted(channel, chatID) {
+		return
+	}
+
+	// Feeds and the agent can take a while; don't hold up the heartbeat
+	go s.run(now, channel, chatID)
+}
+
+func (s *Starter) run(now time.Time, channel, chatID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), askTimeout)
+	defer cancel()
+
+	plate := s.gather(ctx, now, channel, chatID)
+	if plate == "" {
+		return
+	}
+	question, err := s.ask(ctx, Prompt(plate), channel, chatID)
+	if err != nil {
+		logger.WarnCF("starters", "Conversation starter failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	question = strings.TrimSpace(question)
+	if question == "" || strings.Contains(question, noQuestion) {
+		return
+	}
+
+	msg := bus.OutboundMessage{
+		Channel:   channel,
+		ChatID:    chatID,
+		Content:   question,
+		Proactive: bus.ProactiveStarter,
+	}
+	if s.OnSent != nil {
+		msg.OnSent = func() { s.OnSent(channel, chatID, question) }
+	}
+	s.bus.PublishOutbound(msg)
+}
+
+// gather collects today's items for a chat from every source, one
+// section each.
+func (s *Starter) gather(ctx context.Context, now time.Time, channel, chatID string) string {
+	var sb strings.Builder
+	for _, ns := range s.sources {
+		lines, err := ns.src(ctx, now, channel, chatID)
+		if err != nil {
+			logger.WarnCF("starters", "Source failed", map[string]interface{}{
+				"source": ns.name,
+				"error":  err.Error(),
+			})
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s:\n", ns.name)
+		for _, line := range lines {
+			fmt.Fprintf(&sb, "- %s\n", line)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// Prompt asks the agent for one question about the day's items.
+func Prompt(plate string) string {
+	return "Here is what's on my plate today:\n\n" + plate + "\n\n" +
+		"Ask me ONE short, friendly question that offers concrete help with the most useful of these, " +
+		"e.g. \"You have the dentist at 3 — want me to set a leave-by reminder?\". " +
+		"Don't do anything yet and don't call any tools; reply with just the question. " +
+		"If nothing is worth asking about, reply " + noQuestion + "."
+}
+
+func (s *Starter) lastDay() string {
+	var state struct {
+		LastDay string `json:"last_day"`
+	}
+	if raw, err := os.ReadFile(s.path); err == nil {
+		json.Unmarshal(raw, &state)
+	}
+	return state.LastDay
+}
+
+func (s *Starter) setLastDay(day string) {
+	raw, _ := json.Marshal(map[string]string{"last_day": day})
+	err := os.MkdirAll(filepath.Dir(s.path), 0755)
+	if err == nil {
+		err = os.WriteFile(s.path, raw, 0644)
+	}
+	if err != nil {
+		logger.WarnCF("starters", "Failed to save starter state", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package starters
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+const testICS = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Dentist\r\n" +
+	"LOCATION:Main St 1\\, Springfield\r\n" +
+	"DTSTART:20261015T130000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Team offsite planning with a very long title that gets\r\n" +
+	"  folded\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20261015T090000\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Mum's birthday\r\n" +
+	"DTSTART;VALUE=DATE:20261015\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Tomorrow\r\n" +
+	"DTSTART:20261016T090000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICS(t *testing.T) {
+	events, err := ParseICS(strings.NewReader(testICS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("parsed %d events, want 4", len(events))
+	}
+	if events[1].Summary != "Team offsite planning with a very long title that gets folded" {
+		t.Errorf("folded summary = %q", events[1].Summary)
+	}
+
+	now := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+	var got []string
+	for _, e := range On(events, now) {
+		got = append(got, e.String())
+	}
+	want := []string{
+		"all day: Mum's birthday",
+		"07:00 Team offsite planning with a very long title that gets folded",
+		"13:00 Dentist (Main St 1, Springfield)",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("today = %q, want %q", got, want)
+	}
+}
+
+func TestStarter_Check(t *testing.T) {
+	mb := bus.NewMessageBus()
+	var prompts []string
+	ask := func(ctx context.Context, prompt, channel, chatID string) (string, error) {
+		prompts = append(prompts, prompt)
+		return "You have the dentist at 13:00 — want me to set a leave-by reminder?", nil
+	}
+	s := NewStarter(t.TempDir(), 8, func() (string, string) { return "telegram", "42" }, ask, mb)
+	s.AddSource("Calendar", func(ctx context.Context, now time.Time, channel, chatID string) ([]string, error) {
+		if channel != "telegram" || chatID != "42" {
+			t.Errorf("source asked for %s:%s", channel, chatID)
+		}
+		return []string{"13:00 Dentist"}, nil
+	})
+	sent := make(chan string, 1)
+	s.OnSent = func(channel, chatID, question string) { sent <- question }
+
+	morning := time.Date(2026, 10, 15, 7, 59, 0, 0, time.Local)
+	s.Check(morning)
+	s.Check(morning.Add(2 * time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	msg, ok := mb.SubscribeOutbound(ctx)
+	if !ok || msg.ChatID != "42" || msg.Proactive != bus.ProactiveStarter || !strings.Contains(msg.Content, "leave-by") {
+		t.Fatalf("outbound = %+v", msg)
+	}
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "Calendar:\n- 13:00 Dentist") {
+		t.Errorf("prompts = %q", prompts)
+	}
+
+	// OnSent waits for the channel to send the question
+	select {
+	case <-sent:
+		t.Fatal("OnSent called before the question was sent")
+	default:
+	}
+	msg.OnSent()
+	if q := <-sent; !strings.Contains(q, "leave-by") {
+		t.Errorf("OnSent question = %q", q)
+	}
+
+	// Once a day
+	s.Check(morning.Add(time.Hour))
+	time.Sleep(50 * time.Millisecond)
+	if len(prompts) != 1 {
+		t.Errorf("asked %d times on one day", len(prompts))
+	}
+}
@@ -0,0 +1,191 @@
+package structured
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Validate checks a decoded JSON value against the subset of JSON Schema
+// tool parameters use: type, properties, required, additionalProperties,
+// items, enum, minimum, maximum, minItems, maxItems, minLength and
+// maxLength. Other keywords are ignored. A nil schema accepts anything.
+func Validate(schema map[string]interface{}, value interface{}) error {
+	return validate(schema, value, "$")
+}
+
+func validate(schema map[string]interface{}, value interface{}, path string) error {
+	if schema == nil {
+		return nil
+	}
+
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		return fmt.Errorf("%s should be %s, not %s", path, typeNames(t), jsonType(value))
+	}
+	if enum := enumValues(schema["enum"]); enum != nil {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(normalize(e), value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s should be one of %v", path, enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		props, _ := schema["properties"].(map[string]interface{})
+		for _, name := range stringList(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s is missing the required field %q", path, name)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sub, known := props[k].(map[string]interface{})
+			if !known {
+				if extra, ok := schema["additionalProperties"].(bool); ok && !extra {
+					return fmt.Errorf("%s has an unexpected field %q", path, k)
+				}
+				if extra, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+					sub = extra
+				}
+			}
+			if err := validate(sub, v[k], path+"."+k); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if n, ok := number(schema["minItems"]); ok && float64(len(v)) < n {
+			return fmt.Errorf("%s should have at least %v items", path, n)
+		}
+		if n, ok := number(schema["maxItems"]); ok && float64(len(v)) > n {
+			return fmt.Errorf("%s should have at most %v items", path, n)
+		}
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range v {
+			if err := validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if n, ok := number(schema["minLength"]); ok && length < n {
+			return fmt.Errorf("%s should be at least %v characters", path, n)
+		}
+		if n, ok := number(schema["maxLength"]); ok && length > n {
+			return fmt.Errorf("%s should be at most %v characters", path, n)
+		}
+	case float64:
+		if n, ok := number(schema["minimum"]); ok && v < n {
+			return fmt.Errorf("%s should be at least %v", path, n)
+		}
+		if n, ok := number(schema["maximum"]); ok && v > n {
+			return fmt.Errorf("%s should be at most %v", path, n)
+		}
+	}
+	return nil
+}
+
+// matchesType reports whether value is of the schema type t, a name or a
+// list of names.
+func matchesType(t interface{}, value interface{}) bool {
+	for _, name := range stringList(t) {
+		actual := jsonType(value)
+		if name == actual || (name == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func typeNames(t interface{}) string {
+	return strings.Join(stringList(t), " or ")
+}
+
+// stringList reads a schema value that is a string or a list of them, in
+// the []string form of Go-built schemas or the []interface{} of decoded
+// ones.
+func stringList(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// enumValues reads an enum list, as []string or []interface{}.
+func enumValues(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		return v
+	case []string:
+		out := make([]interface{}, len(v))
+		for i, s := range v {
+			out[i] = s
+		}
+		return out
+	}
+	return nil
+}
+
+// normalize turns Go numbers in an enum into the float64 decoded JSON
+// holds.
+func normalize(v interface{}) interface{} {
+	if n, ok := number(v); ok {
+		return n
+	}
+	return v
+}
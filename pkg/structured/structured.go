@@ -0,0 +1,151 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package structured gets replies from a model as JSON that follows a
+// schema, for tools and services that parse what the model says rather
+// than show it to a user.
+package structured
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// defaultRepairs is how many times an invalid reply is sent back to the
+// model to be fixed when a Request doesn't say.
+const defaultRepairs = 2
+
+// Request describes the JSON a caller wants back.
+type Request struct {
+	// Name identifies the schema to providers that want one.
+	Name   string
+	Schema map[string]interface{}
+	// Options are passed to Chat along with the response format, e.g.
+	// max_tokens and temperature.
+	Options map[string]interface{}
+	// MaxRepairs is how many times an invalid reply is sent back to be
+	// fixed (default 2).
+	MaxRepairs int
+}
+
+// Chat sends messages to model with the schema as the response format and
+// decodes the reply into v. A reply that isn't JSON or doesn't follow the
+// schema is sent back to the model with the problem, up to MaxRepairs
+// times, before Chat gives up. When the backend rejects the response
+// format, the schema is put in the prompt instead.
+func Chat(ctx context.Context, provider providers.LLMProvider, model string, messages []providers.Message, req Request, v interface{}) error {
+	options := make(map[string]interface{}, len(req.Options)+1)
+	for k, val := range req.Options {
+		options[k] = val
+	}
+	options["response_format"] = providers.ResponseFormat{Name: req.Name, Schema: req.Schema}
+
+	repairs := req.MaxRepairs
+	if repairs <= 0 {
+		repairs = defaultRepairs
+	}
+
+	messages = append([]providers.Message(nil), messages...)
+	var lastErr error
+	for attempt := 0; attempt <= repairs; attempt++ {
+		resp, err := provider.Chat(ctx, messages, nil, model, options)
+		if err != nil && options["response_format"] != nil && formatUnsupported(err) {
+			logger.WarnCF("structured", "Backend rejected the response format, asking for JSON in the prompt", map[string]interface{}{
+				"schema": req.Name,
+				"error":  err.Error(),
+			})
+			delete(options, "response_format")
+			messages = append(messages, providers.Message{Role: "user", Content: schemaInstruction(req.Schema)})
+			resp, err = provider.Chat(ctx, messages, nil, model, options)
+		}
+		if err != nil {
+			return err
+		}
+
+		raw, err := decode(resp.Content, req.Schema)
+		if err == nil {
+			return json.Unmarshal(raw, v)
+		}
+		lastErr = err
+		if attempt == repairs {
+			break
+		}
+
+		logger.DebugCF("structured", "Asking the model to repair its JSON", map[string]interface{}{
+			"schema":  req.Name,
+			"attempt": attempt + 1,
+			"error":   err.Error(),
+		})
+		messages = append(messages,
+			providers.Message{Role: "assistant", Content: resp.Content},
+			providers.Message{Role: "user", Content: fmt.Sprintf(
+				"That reply is not valid: %v. Reply again with only the corrected JSON, without code fences or any other text.", err)},
+		)
+	}
+	return fmt.Errorf("no valid JSON after %d repairs: %w", repairs, lastErr)
+}
+
+// formatUnsupported reports whether err is a backend refusing the
+// response_format option, as OpenAI-compatible servers without structured
+// output support do.
+func formatUnsupported(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "response_format") || strings.Contains(msg, "json_schema") ||
+		strings.Contains(msg, "response format")
+}
+
+// schemaInstruction asks for JSON following schema, for backends that
+// can't be given the schema as a response format.
+func schemaInstruction(schema map[string]interface{}) string {
+	data, _ := json.Marshal(schema)
+	return "Reply with only a JSON value, without code fences or any other text, that follows this JSON schema:\n" + string(data)
+}
+
+// decode extracts the JSON value from a reply and checks it against
+// schema.
+func decode(reply string, schema map[string]interface{}) (json.RawMessage, error) {
+	raw := Extract(reply)
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return nil, fmt.Errorf("not JSON: %v", err)
+	}
+	if err := Validate(schema, value); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(raw), nil
+}
+
+// Extract returns the JSON in a reply, without the code fences or prose
+// models like to wrap it in.
+func Extract(reply string) string {
+	s := strings.TrimSpace(reply)
+	if rest, ok := strings.CutPrefix(s, "```"); ok {
+		if nl := strings.IndexByte(rest, '\n'); nl >= 0 {
+			rest = rest[nl+1:]
+		}
+		s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "```"))
+	}
+	if json.Valid([]byte(s)) {
+		return s
+	}
+
+	start := strings.IndexAny(s, "{[")
+	if start < 0 {
+		return s
+	}
+	closer := "}"
+	if s[start] == '[' {
+		closer = "]"
+	}
+	if end := strings.LastIndex(s, closer); end > start {
+		return s[start : end+1]
+	}
+	return s
+}
@@ -0,0 +1,150 @@
+package structured
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+var taskSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"name":     map[string]interface{}{"type": "string", "minLength": 1},
+		"every":    map[string]interface{}{"type": "integer", "minimum": 60},
+		"channel":  map[string]interface{}{"type": "string", "enum": []string{"telegram", "discord"}},
+		"keywords": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+	},
+	"required":             []string{"name", "every"},
+	"additionalProperties": false,
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		json string
+		want string // part of the error, "" for valid
+	}{
+		{`{"name": "backup", "every": 3600, "channel": "discord", "keywords": ["db"]}`, ""},
+		{`{"name": "backup"}`, `missing the required field "every"`},
+		{`{"name": "backup", "every": 1.5}`, "$.every should be integer"},
+		{`{"name": "backup", "every": 30}`, "at least 60"},
+		{`{"name": "backup", "every": 60, "channel": "irc"}`, "should be one of"},
+		{`{"name": "backup", "every": 60, "keywords": [1]}`, "$.keywords[0] should be string"},
+		{`{"name": "backup", "every": 60, "extra": true}`, `unexpected field "extra"`},
+		{`["backup"]`, "should be object, not array"},
+	}
+	for _, tt := range tests {
+		_, err := decode(tt.json, taskSchema)
+		if (err == nil) != (tt.want == "") || (err != nil && !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%s: error %v, want %q", tt.json, err, tt.want)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	for reply, want := range map[string]string{
+		`{"a": 1}`:                           `{"a": 1}`,
+		"```json\n{\"a\": 1}\n```":           `{"a": 1}`,
+		"Here you go:\n{\"a\": {\"b\": 2}}!": `{"a": {"b": 2}}`,
+		"[1, 2] is the list":                 `[1, 2]`,
+	} {
+		if got := Extract(reply); got != want {
+			t.Errorf("Extract(%q) = %q, want %q", reply, got, want)
+		}
+	}
+}
+
+type scriptedProvider struct {
+	replies  []string
+	requests [][]providers.Message
+	options  []map[string]interface{}
+}
+
+func (p *scriptedProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, options map[string]interface{}) (*providers.LLMResponse, error) {
+	p.requests = append(p.requests, messages)
+	p.options = append(p.options, options)
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	return &providers.LLMResponse{Content: reply}, nil
+}
+
+func (p *scriptedProvider) GetDefaultModel() string { return "scripted" }
+
+func TestChatRepairs(t *testing.T) {
+	p := &scriptedProvider{replies: []string{
+		`Sure! {"name": "backup"}`,
+		"```json\n{\"name\": \"backup\", \"every\": 3600}\n```",
+	}}
+	var task struct {
+		Name  string `json:"name"`
+		Every int    `json:"every"`
+	}
+	prompt := []providers.Message{{Role: "user", Content: "back up the db hourly"}}
+	err := Chat(context.Background(), p, "m", prompt, Request{Name: "task", Schema: taskSchema, Options: map[string]interface{}{"max_tokens": 100}}, &task)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if task.Name != "backup" || task.Every != 3600 {
+		t.Errorf("task = %+v", task)
+	}
+	if len(p.requests) != 2 || len(p.requests[1]) != 3 || !strings.Contains(p.requests[1][2].Content, `"every"`) {
+		t.Errorf("repair request = %+v", p.requests)
+	}
+	if rf, ok := p.options[0]["response_format"].(providers.ResponseFormat); !ok || rf.Name != "task" || p.options[0]["max_tokens"] != 100 {
+		t.Errorf("options = %v", p.options[0])
+	}
+	if len(prompt) != 1 {
+		t.Error("Chat modified the caller's messages")
+	}
+
+	p = &scriptedProvider{replies: []string{"no", "still no"}}
+	if err := Chat(context.Background(), p, "m", prompt, Request{Schema: taskSchema, MaxRepairs: 1}, &task); err == nil || len(p.requests) != 2 {
+		t.Errorf("err = %v after %d requests, want failure after one repair", err, len(p.requests))
+	}
+}
+
+// formatlessProvider is a backend without structured output support: it
+// rejects requests carrying a response_format.
+type formatlessProvider struct {
+	scriptedProvider
+	rejected int
+}
+
+func (p *formatlessProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, options map[string]interface{}) (*providers.LLMResponse, error) {
+	if _, ok := options["response_format"]; ok {
+		p.rejected++
+		return nil, errors.New(`API request failed: Status: 400 Body: {"error": "response_format json_schema is not supported"}`)
+	}
+	return p.scriptedProvider.Chat(ctx, messages, tools, model, options)
+}
+
+func TestChatFallsBackToPromptSchema(t *testing.T) {
+	p := &formatlessProvider{scriptedProvider: scriptedProvider{replies: []string{`{"name": "backup", "every": 3600}`}}}
+	var task struct {
+		Name string `json:"name"`
+	}
+	prompt := []providers.Message{{Role: "user", Content: "back up the db hourly"}}
+	if err := Chat(context.Background(), p, "m", prompt, Request{Name: "task", Schema: taskSchema}, &task); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if task.Name != "backup" || p.rejected != 1 || len(p.requests) != 1 {
+		t.Errorf("task = %+v after %d rejections and %d requests", task, p.rejected, len(p.requests))
+	}
+	if msgs := p.requests[0]; len(msgs) != 2 || !strings.Contains(msgs[1].Content, `"required":["name","every"]`) {
+		t.Errorf("fallback request = %+v", msgs)
+	}
+
+	if err := Chat(context.Background(), errProvider{errors.New("connection refused")}, "m", prompt, Request{Schema: taskSchema}, &task); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("err = %v, want the backend error", err)
+	}
+}
+
+type errProvider struct{ err error }
+
+func (p errProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, options map[string]interface{}) (*providers.LLMResponse, error) {
+	return nil, p.err
+}
+
+func (p errProvider) GetDefaultModel() string { return "err" }
@@ -0,0 +1,230 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package templates lets users reword the messages PicoClaw sends on its
+// own (briefings, reminders, digests, error replies) with Go templates in
+// the workspace, per chat or for everyone.
+package templates
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/cron"
+	"github.com/sipeed/picoclaw/pkg/logger"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// Message kinds, which name the template files.
+const (
+	KindBriefing          = "briefing"
+	KindHabitReminder     = "habit_reminder"
+	KindBookmarkDigest    = "bookmark_digest"
+	KindJournalReflection = "journal_reflection"
+	KindError             = "error"
+)
+
+// weatherURL is where the weather function looks up a location; %s is the
+// escaped location.
+var weatherURL = "https://wttr.in/%s?format=3"
+
+var weatherClient = &http.Client{Timeout: 10 * time.Second}
+
+// Usage is today's model usage, as !status shows it.
+type Usage struct {
+	Requests         int64
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+var (
+	usageMu     sync.RWMutex
+	usageSource func() Usage
+)
+
+// SetUsageSource sets where templates get today's usage from. Without
+// one, .Usage is all zeros.
+func SetUsageSource(fn func() Usage) {
+	usageMu.Lock()
+	usageSource = fn
+	usageMu.Unlock()
+}
+
+// Task is a scheduled job that reports to the chat a message goes to.
+type Task struct {
+	Name    string
+	Message string
+	Next    time.Time
+}
+
+// Message is what a template sees as its data. Profile, Tasks and Usage
+// are methods so they are only loaded when a template uses them.
+type Message struct {
+	Kind    string
+	Channel string
+	ChatID  string
+	Now     time.Time
+	// Content is the message PicoClaw would send without a template.
+	Content string
+	// Data holds what the message is made from, depending on Kind: the
+	// due habit names, the unread bookmarks, the reflection text or the
+	// error.
+	Data interface{}
+
+	workspace string
+}
+
+// Profile returns the user profile in the workspace's USER.md.
+func (m Message) Profile() string {
+	data, err := os.ReadFile(filepath.Join(m.workspace, "USER.md"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Tasks returns the enabled scheduled jobs that deliver to the message's
+// chat, soonest first.
+func (m Message) Tasks() []Task {
+	cs := cron.NewCronService(filepath.Join(m.workspace, "cron", "jobs.json"), nil)
+	var tasks []Task
+	for _, job := range cs.ListJobs(false) {
+		if job.Payload.Channel != m.Channel || job.Payload.To != m.ChatID {
+			continue
+		}
+		t := Task{Name: job.Name, Message: job.Payload.Message}
+		if job.State.NextRunAtMS != nil {
+			t.Next = time.UnixMilli(*job.State.NextRunAtMS)
+		}
+		tasks = append(tasks, t)
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].Next.Before(tasks[j].Next)
+	})
+	return tasks
+}
+
+// Usage returns today's model usage.
+func (m Message) Usage() Usage {
+	usageMu.RLock()
+	fn := usageSource
+	usageMu.RUnlock()
+	if fn == nil {
+		return Usage{}
+	}
+	return fn()
+}
+
+var funcs = template.FuncMap{
+	"contains":  strings.Contains,
+	"hasPrefix": strings.HasPrefix,
+	"lower":     strings.ToLower,
+	"upper":     strings.ToUpper,
+	"trim":      strings.TrimSpace,
+	"join":      strings.Join,
+	"date":      func(layout string, t time.Time) string { return t.Format(layout) },
+	"weather":   weather,
+}
+
+// Render returns msg's content as the workspace's template for its kind
+// words it: templates/<channel>/<chat_id>/<kind>.tmpl for that chat, else
+// templates/<kind>.tmpl. Without a template, or when one fails or renders
+// nothing, the default content is returned.
+func Render(workspace string, msg Message) string {
+	path, ok := lookup(workspace, msg)
+	if !ok {
+		return msg.Content
+	}
+	msg.workspace = workspace
+	if msg.Now.IsZero() {
+		msg.Now = time.Now()
+	}
+
+	out, err := render(path, msg)
+	if err == nil && out == "" {
+		err = fmt.Errorf("rendered an empty message")
+	}
+	if err != nil {
+		logger.WarnCF("templates", "Message template failed, sending the default", map[string]interface{}{
+			"template": path,
+			"error":    err.Error(),
+		})
+		return msg.Content
+	}
+	return out
+}
+
+// lookup finds the template file for msg.
+func lookup(workspace string, msg Message) (string, bool) {
+	dir := filepath.Join(workspace, "templates")
+	name := utils.SanitizeFilename(msg.Kind) + ".tmpl"
+	candidates := []string{filepath.Join(dir, name)}
+	if msg.Channel != "" && msg.ChatID != "" {
+		chat := filepath.Join(dir, utils.SanitizeFilename(msg.Channel), utils.SanitizeFilename(msg.ChatID), name)
+		candidates = append([]string{chat}, candidates...)
+	}
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func render(path string, msg Message) (string, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(funcs).Option("missingkey=zero").Parse(string(text))
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, msg); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// weather returns a one-line forecast for location, or "" when the
+// lookup fails so a briefing still goes out.
+func weather(location string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), weatherClient.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf(weatherURL, url.PathEscape(strings.TrimSpace(location))), nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("User-Agent", "curl/8.0")
+	resp, err := weatherClient.Do(req)
+	if err != nil {
+		logger.DebugCF("templates", "Weather lookup failed", map[string]interface{}{
+			"location": location,
+			"error":    err.Error(),
+		})
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(body))
+}
@@ -0,0 +1,91 @@
+package templates
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/cron"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	ws := t.TempDir()
+	msg := Message{Kind: KindHabitReminder, Channel: "telegram", ChatID: "42", Content: "default", Data: []string{"run", "read"}}
+
+	if got := Render(ws, msg); got != "default" {
+		t.Errorf("without a template got %q", got)
+	}
+
+	writeFile(t, filepath.Join(ws, "templates", "habit_reminder.tmpl"), `Still to do: {{join .Data " & "}}`)
+	if got := Render(ws, msg); got != "Still to do: run & read" {
+		t.Errorf("workspace template got %q", got)
+	}
+
+	writeFile(t, filepath.Join(ws, "templates", "telegram", "42", "habit_reminder.tmpl"), `{{upper .Channel}}: {{.Content}}`)
+	if got := Render(ws, msg); got != "TELEGRAM: default" {
+		t.Errorf("chat template got %q", got)
+	}
+	other := msg
+	other.ChatID = "7"
+	if got := Render(ws, other); got != "Still to do: run & read" {
+		t.Errorf("other chat got %q", got)
+	}
+
+	writeFile(t, filepath.Join(ws, "templates", "telegram", "42", "habit_reminder.tmpl"), `{{.Nope.Deeper}`)
+	if got := Render(ws, msg); got != "default" {
+		t.Errorf("broken template got %q, want the default", got)
+	}
+}
+
+func TestRenderData(t *testing.T) {
+	ws := t.TempDir()
+	writeFile(t, filepath.Join(ws, "USER.md"), "Name: Sam\n")
+
+	cs := cron.NewCronService(filepath.Join(ws, "cron", "jobs.json"), nil)
+	every := int64(3600000)
+	if _, err := cs.AddJob("standup", cron.CronSchedule{Kind: "every", EveryMS: &every}, "standup time", true, "telegram", "42"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cs.AddJob("elsewhere", cron.CronSchedule{Kind: "every", EveryMS: &every}, "not here", true, "discord", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	SetUsageSource(func() Usage { return Usage{Requests: 3, PromptTokens: 100} })
+	defer SetUsageSource(nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s: ☀️ +21°C\n", r.URL.Path[1:])
+	}))
+	defer srv.Close()
+	old := weatherURL
+	weatherURL = srv.URL + "/%s"
+	defer func() { weatherURL = old }()
+
+	writeFile(t, filepath.Join(ws, "templates", "briefing.tmpl"),
+		`{{.Profile}}|{{range .Tasks}}{{.Name}};{{end}}|{{.Usage.Requests}} requests|{{weather "Berlin"}}|{{date "2006-01-02" .Now}}`)
+	got := Render(ws, Message{
+		Kind:    KindBriefing,
+		Channel: "telegram",
+		ChatID:  "42",
+		Now:     time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC),
+		Content: "default",
+	})
+	want := "Name: Sam|standup;|3 requests|Berlin: ☀️ +21°C|2026-03-04"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/followup"
+)
+
+// AskUserTool parks a task that needs information only the user has. The
+// task comes back to the agent with the user's next message in the chat,
+// even after a restart.
+type AskUserTool struct {
+	userContext
+	store *followup.Store
+	bus   *bus.MessageBus
+}
+
+func NewAskUserTool(store *followup.Store, msgBus *bus.MessageBus) *AskUserTool {
+	return &AskUserTool{store: store, bus: msgBus}
+}
+
+func (t *AskUserTool) Name() string {
+	return "ask_user"
+}
+
+func (t *AskUserTool) Description() string {
+	return "Park the current task when you need information only the user can give (e.g. a flight number or which account to use). The task is handed back to you together with the user's reply, even in a later session. Describe the task fully, since the conversation so far may not be available when it resumes."
+}
+
+func (t *AskUserTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"question": map[string]interface{}{
+				"type":        "string",
+				"description": "The question for the user, e.g. 'What's your flight number?'",
+			},
+			"task": map[string]interface{}{
+				"type":        "string",
+				"description": "What to do once the answer arrives, with every detail needed to do it, e.g. 'Check the status of the user's flight tomorrow morning from Berlin and add a reminder 3 hours before departure'",
+			},
+		},
+		"required": []string{"question", "task"},
+	}
+}
+
+func (t *AskUserTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	question, _ := args["question"].(string)
+	task, _ := args["task"].(string)
+	channel, chatID, _ := t.current(ctx)
+	if channel == "" || chatID == "" {
+		return ErrorResult("no chat to ask the user in")
+	}
+
+	parked, err := t.store.Park(followup.Task{
+		Channel:  channel,
+		ChatID:   chatID,
+		Question: question,
+		Task:     task,
+	})
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to park task: %v", err)).WithError(err)
+	}
+
+	if !t.background() {
+		return SilentResult(fmt.Sprintf("Task parked (%s). Ask the user in your reply: %s", parked.ID, question))
+	}
+	// Nobody is waiting on this turn's reply, so ask directly
+	t.bus.PublishOutbound(bus.OutboundMessage{
+		Channel:   channel,
+		ChatID:    chatID,
+		Content:   question,
+		Proactive: bus.ProactiveFollowUp,
+	})
+	return SilentResult(fmt.Sprintf("Task parked (%s) and the question sent to the user. It resumes when they answer; there is nothing more to do for it now.", parked.ID))
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/followup"
+)
+
+func TestAskUserTool(t *testing.T) {
+	store := followup.NewStore(t.TempDir(), 0)
+	msgBus := bus.NewMessageBus()
+	tool := NewAskUserTool(store, msgBus)
+	args := map[string]interface{}{
+		"question": "What's your flight number?",
+		"task":     "Check tomorrow's flight status and set a reminder 3 hours before departure",
+	}
+
+	// In reply to the user, the agent asks in its own reply
+	tool.SetContext("telegram", "42")
+	tool.SetSender("42")
+	if result := tool.Execute(context.Background(), args); result.IsError || !result.Silent {
+		t.Fatalf("interactive result = %+v", result)
+	}
+
+	// From a heartbeat, the tool asks itself
+	tool.SetContext("telegram", "7")
+	tool.SetSender("")
+	tool.Execute(context.Background(), args)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, ok := msgBus.SubscribeOutbound(ctx)
+	if !ok || msg.ChatID != "7" || msg.Content != "What's your flight number?" || msg.Proactive != bus.ProactiveFollowUp {
+		t.Errorf("question = %+v, %v", msg, ok)
+	}
+
+	tasks, _ := store.Take("telegram", "42", time.Now())
+	if len(tasks) != 1 || tasks[0].Task != args["task"] {
+		t.Errorf("parked = %+v", tasks)
+	}
+	if result := tool.Execute(context.Background(), map[string]interface{}{"question": "Which one?"}); !result.IsError {
+		t.Error("expected an error without a task")
+	}
+}
@@ -17,6 +17,50 @@ type ContextualTool interface {
 	SetContext(channel, chatID string)
 }
 
+// ConcurrentTool is an optional interface for tools whose calls can run
+// alongside the other calls of the same reply: they keep no state between
+// calls and change nothing another call could read. Calls of all other
+// tools run one at a time, in order.
+type ConcurrentTool interface {
+	Tool
+	Concurrent() bool
+}
+
+// chatKey is the context key of the chat a tool call was made from.
+type chatKey struct{}
+
+type chatRef struct{ channel, chatID string }
+
+// WithChat returns ctx carrying the chat a tool call is made from, which
+// contextual tools prefer over the chat set with SetContext. Tools are
+// shared by every call, so a chat set on them can change under a call.
+func WithChat(ctx context.Context, channel, chatID string) context.Context {
+	return context.WithValue(ctx, chatKey{}, chatRef{channel, chatID})
+}
+
+// callChat returns the chat of the call in ctx, or channel and chatID
+// when it carries none.
+func callChat(ctx context.Context, channel, chatID string) (string, string) {
+	if ref, ok := ctx.Value(chatKey{}).(chatRef); ok && ref.channel != "" && ref.chatID != "" {
+		return ref.channel, ref.chatID
+	}
+	return channel, chatID
+}
+
+// isConcurrent reports whether the tool declares its calls safe to run at
+// once.
+func isConcurrent(tool Tool) bool {
+	ct, ok := tool.(ConcurrentTool)
+	return ok && ct.Concurrent()
+}
+
+// SenderContextualTool is an optional interface for tools that keep data
+// per user and need to know who sent the current message.
+type SenderContextualTool interface {
+	Tool
+	SetSender(senderID string)
+}
+
 // AsyncCallback is a function type that async tools use to notify completion.
 // When an async tool finishes its work, it calls this callback with the result.
 //
@@ -0,0 +1,332 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bookmarks"
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/structured"
+)
+
+// bookmarkPageChars is how much page text goes into the summary prompt.
+const bookmarkPageChars = 6000
+
+const bookmarkSummaryPrompt = `Summarize this web page for a read-later list. Reply with only a JSON object:
+{"title": "page title", "summary": "one or two sentences on what it is about", "tags": ["3 to 5 short lowercase topic tags"]}
+
+URL: %s
+
+PAGE TEXT:
+%s`
+
+var bookmarkSummarySchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"title":   map[string]interface{}{"type": "string"},
+		"summary": map[string]interface{}{"type": "string"},
+		"tags":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+	},
+	"required": []string{"title", "summary", "tags"},
+}
+
+// BookmarkAddTool saves a link to the chat's read-later list, with a
+// summary and tags generated from the page.
+type BookmarkAddTool struct {
+	store    *bookmarks.Store
+	provider providers.LLMProvider
+	model    string
+	client   *http.Client
+	channel  string
+	chatID   string
+	mu       sync.RWMutex
+}
+
+func NewBookmarkAddTool(store *bookmarks.Store, provider providers.LLMProvider, model string) *BookmarkAddTool {
+	return &BookmarkAddTool{
+		store:    store,
+		provider: provider,
+		model:    model,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (t *BookmarkAddTool) Name() string {
+	return "bookmark_add"
+}
+
+func (t *BookmarkAddTool) Description() string {
+	return "Save a link to the user's read-later list. A summary and tags are generated from the page automatically. Use when the user asks to bookmark, save or read something later."
+}
+
+func (t *BookmarkAddTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "The http(s) URL to save",
+			},
+			"tags": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Optional: tags the user asked for, added to the generated ones",
+			},
+			"note": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional: why the user saved it",
+			},
+		},
+		"required": []string{"url"},
+	}
+}
+
+func (t *BookmarkAddTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *BookmarkAddTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	rawURL, _ := args["url"].(string)
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrorResult("url must be an http(s) URL")
+	}
+	note, _ := args["note"].(string)
+
+	t.mu.RLock()
+	channel, chatID := callChat(ctx, t.channel, t.chatID)
+	t.mu.RUnlock()
+
+	b := bookmarks.Bookmark{
+		URL:     u.String(),
+		Note:    strings.TrimSpace(note),
+		Channel: channel,
+		ChatID:  chatID,
+	}
+
+	// A page that can't be fetched or summarized is still worth saving
+	summaryErr := t.describe(ctx, &b)
+	b.Tags = mergeTags(stringArgs(args["tags"]), b.Tags)
+
+	saved, added, err := t.store.Add(b)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to save bookmark: %v", err)).WithError(err)
+	}
+	if !added {
+		return NewToolResult(fmt.Sprintf("Already bookmarked as #%d: %s", saved.ID, saved.URL))
+	}
+
+	result := fmt.Sprintf("Saved bookmark #%d: %s", saved.ID, formatBookmark(*saved))
+	if summaryErr != nil {
+		result += fmt.Sprintf("\n(no summary: %v)", summaryErr)
+	}
+	return NewToolResult(result)
+}
+
+// describe fills in the title, summary and tags from the page.
+func (t *BookmarkAddTool) describe(ctx context.Context, b *bookmarks.Bookmark) error {
+	text, err := t.fetchText(ctx, b.URL)
+	if err != nil {
+		return err
+	}
+	if runes := []rune(text); len(runes) > bookmarkPageChars {
+		text = string(runes[:bookmarkPageChars])
+	}
+
+	var parsed struct {
+		Title   string   `json:"title"`
+		Summary string   `json:"summary"`
+		Tags    []string `json:"tags"`
+	}
+	prompt := []providers.Message{{Role: "user", Content: fmt.Sprintf(bookmarkSummaryPrompt, b.URL, text)}}
+	err = structured.Chat(ctx, t.provider, t.model, prompt, structured.Request{
+		Name:    "bookmark_summary",
+		Schema:  bookmarkSummarySchema,
+		Options: map[string]interface{}{"max_tokens": 2048, "temperature": 0.3},
+	}, &parsed)
+	if err != nil {
+		return fmt.Errorf("unexpected summary format: %w", err)
+	}
+	b.Title = strings.TrimSpace(parsed.Title)
+	b.Summary = strings.TrimSpace(parsed.Summary)
+	b.Tags = parsed.Tags
+	return nil
+}
+
+func (t *BookmarkAddTool) fetchText(ctx context.Context, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+	if err != nil {
+		return "", err
+	}
+	contentType := http.DetectContentType(body)
+	switch {
+	case strings.HasPrefix(contentType, "text/html"):
+		return ExtractText(string(body)), nil
+	case strings.HasPrefix(contentType, "text/"):
+		return string(body), nil
+	}
+	return "", fmt.Errorf("unsupported content type %s", contentType)
+}
+
+// BookmarkListTool lists the chat's bookmarks and marks them read.
+type BookmarkListTool struct {
+	store   *bookmarks.Store
+	channel string
+	chatID  string
+	mu      sync.RWMutex
+}
+
+func NewBookmarkListTool(store *bookmarks.Store) *BookmarkListTool {
+	return &BookmarkListTool{store: store}
+}
+
+func (t *BookmarkListTool) Name() string {
+	return "bookmark_list"
+}
+
+func (t *BookmarkListTool) Description() string {
+	return "List the user's saved bookmarks, optionally only unread ones or those with a tag. Pass mark_read with bookmark IDs when the user says they have read them."
+}
+
+func (t *BookmarkListTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"tag": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional: only bookmarks with this tag",
+			},
+			"unread_only": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Only list bookmarks not yet marked read",
+			},
+			"mark_read": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "integer"},
+				"description": "Optional: IDs of bookmarks to mark as read before listing",
+			},
+		},
+	}
+}
+
+func (t *BookmarkListTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *BookmarkListTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	t.mu.RLock()
+	channel, chatID := callChat(ctx, t.channel, t.chatID)
+	t.mu.RUnlock()
+
+	var sb strings.Builder
+	if ids, ok := args["mark_read"].([]interface{}); ok {
+		for _, raw := range ids {
+			id, ok := raw.(float64)
+			if !ok {
+				continue
+			}
+			if err := t.store.MarkRead(channel, chatID, int(id)); err != nil {
+				fmt.Fprintf(&sb, "%v\n", err)
+			} else {
+				fmt.Fprintf(&sb, "Marked #%d as read\n", int(id))
+			}
+		}
+	}
+
+	tag, _ := args["tag"].(string)
+	unreadOnly, _ := args["unread_only"].(bool)
+	list := t.store.List(bookmarks.Filter{
+		Channel:    channel,
+		ChatID:     chatID,
+		Tag:        strings.TrimSpace(tag),
+		UnreadOnly: unreadOnly,
+	})
+	if len(list) == 0 {
+		sb.WriteString("No bookmarks found.")
+		return NewToolResult(sb.String())
+	}
+
+	fmt.Fprintf(&sb, "%d bookmark(s), newest first:\n", len(list))
+	for _, b := range list {
+		status := "unread"
+		if b.ReadAt != nil {
+			status = "read"
+		}
+		fmt.Fprintf(&sb, "\n#%d [%s, saved %s] %s\n", b.ID, status, b.AddedAt.Format("2006-01-02"), formatBookmark(b))
+	}
+	return NewToolResult(sb.String())
+}
+
+func formatBookmark(b bookmarks.Bookmark) string {
+	var sb strings.Builder
+	if b.Title != "" {
+		sb.WriteString(b.Title + " - ")
+	}
+	sb.WriteString(b.URL)
+	if b.Summary != "" {
+		sb.WriteString("\n" + b.Summary)
+	}
+	if len(b.Tags) > 0 {
+		sb.WriteString("\nTags: " + strings.Join(b.Tags, ", "))
+	}
+	if b.Note != "" {
+		sb.WriteString("\nNote: " + b.Note)
+	}
+	return sb.String()
+}
+
+// mergeTags combines tag lists, lowercased and without duplicates, keeping
+// the user's tags first.
+func mergeTags(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, list := range lists {
+		for _, tag := range list {
+			tag = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(tag, "#")))
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func stringArgs(raw interface{}) []string {
+	items, _ := raw.([]interface{})
+	var result []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
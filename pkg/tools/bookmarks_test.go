@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/bookmarks"
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// replyProvider answers every prompt with a fixed reply.
+type replyProvider struct {
+	reply string
+}
+
+func (p *replyProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, options map[string]interface{}) (*providers.LLMResponse, error) {
+	return &providers.LLMResponse{Content: p.reply}, nil
+}
+
+func (p *replyProvider) GetDefaultModel() string {
+	return "test-model"
+}
+
+func TestBookmarkTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body><p>All about goroutines.</p></body></html>")
+	}))
+	defer server.Close()
+
+	store := bookmarks.NewStore(t.TempDir())
+	provider := &replyProvider{reply: "```json\n{\"title\": \"Goroutines\", \"summary\": \"How goroutines work.\", \"tags\": [\"Go\", \"concurrency\"]}\n```"}
+	add := NewBookmarkAddTool(store, provider, "test-model")
+	add.SetContext("telegram", "1")
+
+	result := add.Execute(context.Background(), map[string]interface{}{
+		"url":  server.URL + "/post",
+		"tags": []interface{}{"#go", "later"},
+	})
+	if result.IsError {
+		t.Fatalf("bookmark_add failed: %s", result.ForLLM)
+	}
+	for _, want := range []string{"#1", "Goroutines", "How goroutines work.", "Tags: go, later, concurrency"} {
+		if !strings.Contains(result.ForLLM, want) {
+			t.Errorf("result missing %q: %s", want, result.ForLLM)
+		}
+	}
+	if again := add.Execute(context.Background(), map[string]interface{}{"url": server.URL + "/post"}); !strings.Contains(again.ForLLM, "Already bookmarked as #1") {
+		t.Errorf("duplicate add = %s", again.ForLLM)
+	}
+
+	list := NewBookmarkListTool(store)
+	list.SetContext("telegram", "1")
+	result = list.Execute(context.Background(), map[string]interface{}{"mark_read": []interface{}{float64(1)}, "unread_only": true})
+	if !strings.Contains(result.ForLLM, "Marked #1 as read") || !strings.Contains(result.ForLLM, "No bookmarks found.") {
+		t.Errorf("bookmark_list = %s", result.ForLLM)
+	}
+
+	// Other chats don't see the bookmark
+	list.SetContext("discord", "2")
+	if result := list.Execute(context.Background(), map[string]interface{}{}); !strings.Contains(result.ForLLM, "No bookmarks found.") {
+		t.Errorf("other chat listed %s", result.ForLLM)
+	}
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Capabilities describes what the running agent can do. It is built from
+// an allowlist of settings, so no API keys, tokens or URLs end up in it.
+type Capabilities struct {
+	Agent          string                 `json:"agent"`
+	Model          string                 `json:"model"`
+	FallbackModels []string               `json:"fallback_models,omitempty"`
+	Channels       []string               `json:"channels"`
+	Tools          []string               `json:"tools"`
+	Skills         []string               `json:"skills,omitempty"`
+	Limits         map[string]interface{} `json:"limits"`
+	Features       map[string]interface{} `json:"features"`
+}
+
+// CapabilitiesTool lets the agent look up its own model, channels, tools
+// and limits, so it answers "what can you do?" from facts.
+type CapabilitiesTool struct {
+	report func() Capabilities
+}
+
+func NewCapabilitiesTool(report func() Capabilities) *CapabilitiesTool {
+	return &CapabilitiesTool{report: report}
+}
+
+func (t *CapabilitiesTool) Name() string {
+	return "get_capabilities"
+}
+
+func (t *CapabilitiesTool) Description() string {
+	return "Get your own configuration: the model you run on, enabled channels, available tools and skills, limits and optional features. Use it before answering questions about what you can do or how you are set up, instead of guessing."
+}
+
+func (t *CapabilitiesTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+}
+
+func (t *CapabilitiesTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	data, err := json.MarshalIndent(t.report(), "", "  ")
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("failed to encode capabilities: %v", err))
+	}
+	return SilentResult(string(data))
+}
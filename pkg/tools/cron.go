@@ -111,7 +111,7 @@ func (t *CronTool) Execute(ctx context.Context, args map[string]interface{}) *To
 
 	switch action {
 	case "add":
-		return t.addJob(args)
+		return t.addJob(ctx, args)
 	case "list":
 		return t.listJobs()
 	case "remove":
@@ -125,10 +125,9 @@ func (t *CronTool) Execute(ctx context.Context, args map[string]interface{}) *To
 	}
 }
 
-func (t *CronTool) addJob(args map[string]interface{}) *ToolResult {
+func (t *CronTool) addJob(ctx context.Context, args map[string]interface{}) *ToolResult {
 	t.mu.RLock()
-	channel := t.channel
-	chatID := t.chatID
+	channel, chatID := callChat(ctx, t.channel, t.chatID)
 	t.mu.RUnlock()
 
 	if channel == "" || chatID == "" {
@@ -187,6 +186,18 @@ func (t *CronTool) addJob(args map[string]interface{}) *ToolResult {
 	// Truncate message for job name (max 30 chars)
 	messagePreview := utils.Truncate(message, 30)
 
+	// A one-time reminder is just a message for later, which the bus holds
+	if schedule.Kind == "at" && deliver {
+		id := t.msgBus.Hold(bus.OutboundMessage{
+			Channel:   channel,
+			ChatID:    chatID,
+			Content:   message,
+			DeliverAt: time.UnixMilli(*schedule.AtMS),
+			Alert:     bus.AlertReminder,
+		})
+		return SilentResult(fmt.Sprintf("Cron job added: %s (id: %s)", messagePreview, id))
+	}
+
 	job, err := t.cronService.AddJob(
 		messagePreview,
 		schedule,
@@ -284,9 +295,11 @@ func (t *CronTool) ExecuteJob(ctx context.Context, job *cron.CronJob) string {
 		}
 
 		result := t.execTool.Execute(ctx, args)
-		var output string
+		// Only failures are worth a push notification
+		var output, alert string
 		if result.IsError {
 			output = fmt.Sprintf("Error executing scheduled command: %s", result.ForLLM)
+			alert = bus.AlertError
 		} else {
 			output = fmt.Sprintf("Scheduled command '%s' executed:\n%s", job.Payload.Command, result.ForLLM)
 		}
@@ -295,6 +308,7 @@ func (t *CronTool) ExecuteJob(ctx context.Context, job *cron.CronJob) string {
 			Channel: channel,
 			ChatID:  chatID,
 			Content: output,
+			Alert:   alert,
 		})
 		return "ok"
 	}
@@ -305,6 +319,7 @@ func (t *CronTool) ExecuteJob(ctx context.Context, job *cron.CronJob) string {
 			Channel: channel,
 			ChatID:  chatID,
 			Content: job.Payload.Message,
+			Alert:   bus.AlertReminder,
 		})
 		return "ok"
 	}
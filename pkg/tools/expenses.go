@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/expenses"
+)
+
+// ExpenseLogTool records an expense for the current user.
+type ExpenseLogTool struct {
+	userContext
+	store    *expenses.Store
+	currency string
+}
+
+// NewExpenseLogTool creates the tool; currency is used when the user
+// names none.
+func NewExpenseLogTool(store *expenses.Store, currency string) *ExpenseLogTool {
+	if currency == "" {
+		currency = "USD"
+	}
+	return &ExpenseLogTool{store: store, currency: strings.ToUpper(currency)}
+}
+
+func (t *ExpenseLogTool) Name() string {
+	return "expense_log"
+}
+
+func (t *ExpenseLogTool) Description() string {
+	return "Log an expense the user mentions, e.g. 'spent 12.50 on lunch'. Pass the user's words as 'text' and the amount, currency and description are parsed from it; set other fields only to correct the parse. The category is inferred when not given."
+}
+
+func (t *ExpenseLogTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "The expense in the user's words, e.g. 'spent 12.50 on lunch'",
+			},
+			"amount": map[string]interface{}{
+				"type":        "number",
+				"description": "Optional: amount, overrides the one parsed from text",
+			},
+			"currency": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional: ISO currency code, e.g. EUR",
+			},
+			"description": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional: what the money was spent on",
+			},
+			"category": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional: category such as food, groceries, transport, housing, utilities, entertainment, health, shopping, travel",
+			},
+			"date": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional: 'today' (default), 'yesterday' or YYYY-MM-DD",
+			},
+		},
+	}
+}
+
+func (t *ExpenseLogTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	var parsed expenses.Parsed
+	if text, _ := args["text"].(string); strings.TrimSpace(text) != "" {
+		p, err := expenses.ParseText(text)
+		if err != nil && args["amount"] == nil {
+			return ErrorResult(err.Error())
+		}
+		parsed = p
+	}
+	if amount, ok := args["amount"].(float64); ok {
+		parsed.Amount = amount
+	}
+	if parsed.Amount <= 0 {
+		return ErrorResult("an amount greater than zero is required")
+	}
+	if currency, _ := args["currency"].(string); currency != "" {
+		parsed.Currency = strings.ToUpper(strings.TrimSpace(currency))
+	}
+	if parsed.Currency == "" {
+		parsed.Currency = t.currency
+	}
+	if description, _ := args["description"].(string); description != "" {
+		parsed.Description = strings.TrimSpace(description)
+	}
+
+	category, _ := args["category"].(string)
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category == "" {
+		category = expenses.InferCategory(parsed.Description)
+	}
+
+	dateArg, _ := args["date"].(string)
+	date, err := parseDayArg(dateArg, time.Now())
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	_, _, senderID := t.current(ctx)
+	entry := expenses.Entry{
+		Date:        date,
+		Amount:      parsed.Amount,
+		Currency:    parsed.Currency,
+		Category:    category,
+		Description: parsed.Description,
+		User:        senderID,
+	}
+	if err := t.store.Add(entry); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to log expense: %v", err)).WithError(err)
+	}
+	return NewToolResult(fmt.Sprintf("Logged %.2f %s for %q under %s on %s",
+		entry.Amount, entry.Currency, entry.Description, entry.Category, entry.Date.Format("2006-01-02")))
+}
+
+// ExpenseReportTool summarizes a month of the current user's expenses.
+type ExpenseReportTool struct {
+	userContext
+	store *expenses.Store
+}
+
+func NewExpenseReportTool(store *expenses.Store) *ExpenseReportTool {
+	return &ExpenseReportTool{store: store}
+}
+
+func (t *ExpenseReportTool) Name() string {
+	return "expense_report"
+}
+
+func (t *ExpenseReportTool) Description() string {
+	return "Summarize the user's expenses for a month: totals per category, the largest expenses and the change from the previous month."
+}
+
+func (t *ExpenseReportTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"month": map[string]interface{}{
+				"type":        "string",
+				"description": "'this' (default), 'last' or YYYY-MM",
+			},
+		},
+	}
+}
+
+func (t *ExpenseReportTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	monthArg, _ := args["month"].(string)
+	now := time.Now()
+	var month time.Time
+	switch strings.ToLower(strings.TrimSpace(monthArg)) {
+	case "", "this", "current":
+		month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	case "last", "previous":
+		month = time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, now.Location())
+	default:
+		m, err := time.ParseInLocation("2006-01", strings.TrimSpace(monthArg), now.Location())
+		if err != nil {
+			return ErrorResult("month must be 'this', 'last' or YYYY-MM")
+		}
+		month = m
+	}
+
+	_, _, senderID := t.current(ctx)
+	entries, err := t.store.Month(month, senderID)
+	if err != nil {
+		return ErrorResult(err.Error()).WithError(err)
+	}
+	previous, _ := t.store.Month(month.AddDate(0, -1, 0), senderID)
+
+	summary := expenses.Summarize(entries)
+	return NewToolResult(summary.Format(month.Format("January 2006"), expenses.Summarize(previous)))
+}
@@ -90,10 +90,19 @@ func (t *ReadFileTool) Name() string {
 	return "read_file"
 }
 
+// Concurrent implements ConcurrentTool: it only reads.
+func (t *ReadFileTool) Concurrent() bool {
+	return true
+}
+
 func (t *ReadFileTool) Description() string {
 	return "Read the contents of a file"
 }
 
+func (t *ReadFileTool) SelfTest(ctx context.Context) error {
+	return checkWorkspace(t.workspace)
+}
+
 func (t *ReadFileTool) Parameters() map[string]interface{} {
 	return map[string]interface{}{
 		"type": "object",
@@ -143,6 +152,10 @@ func (t *WriteFileTool) Description() string {
 	return "Write content to a file"
 }
 
+func (t *WriteFileTool) SelfTest(ctx context.Context) error {
+	return checkWorkspace(t.workspace)
+}
+
 func (t *WriteFileTool) Parameters() map[string]interface{} {
 	return map[string]interface{}{
 		"type": "object",
@@ -201,6 +214,11 @@ func (t *ListDirTool) Name() string {
 	return "list_dir"
 }
 
+// Concurrent implements ConcurrentTool: it only reads.
+func (t *ListDirTool) Concurrent() bool {
+	return true
+}
+
 func (t *ListDirTool) Description() string {
 	return "List files and directories in a path"
 }
@@ -218,6 +236,18 @@ func (t *ListDirTool) Parameters() map[string]interface{} {
 	}
 }
 
+// SelfTest lists the workspace, the same as the agent's first call
+// usually does.
+func (t *ListDirTool) SelfTest(ctx context.Context) error {
+	if err := checkWorkspace(t.workspace); err != nil {
+		return err
+	}
+	if result := t.Execute(ctx, map[string]interface{}{"path": "."}); result.IsError {
+		return fmt.Errorf("listing the workspace: %s", result.ForLLM)
+	}
+	return nil
+}
+
 func (t *ListDirTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
 	path, ok := args["path"].(string)
 	if !ok {
@@ -245,3 +275,18 @@ func (t *ListDirTool) Execute(ctx context.Context, args map[string]interface{})
 
 	return NewToolResult(result)
 }
+
+// checkWorkspace reports a workspace the file tools can't work in.
+func checkWorkspace(workspace string) error {
+	if workspace == "" {
+		return nil
+	}
+	info, err := os.Stat(workspace)
+	if err != nil {
+		return fmt.Errorf("workspace %s is missing: %w", workspace, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("workspace %s is not a directory", workspace)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/focus"
+)
+
+// FocusTool starts, stops and shows time-boxed focus sessions in the
+// current chat.
+type FocusTool struct {
+	userContext
+	store   *focus.Store
+	minutes int
+	bus     *bus.MessageBus
+	now     func() time.Time
+}
+
+func NewFocusTool(store *focus.Store, minutes int, msgBus *bus.MessageBus) *FocusTool {
+	if minutes <= 0 {
+		minutes = 25
+	}
+	return &FocusTool{store: store, minutes: minutes, bus: msgBus, now: time.Now}
+}
+
+func (t *FocusTool) Name() string {
+	return "focus"
+}
+
+func (t *FocusTool) Description() string {
+	return "Run a pomodoro-style focus session. Use 'start' when the user wants to focus or do a pomodoro (e.g. 'focus on the report for 45 minutes'), 'stop' to end it early, and 'status' to show the time left. While a session runs, briefings, nudges and other messages you'd send on your own are held back until it ends; replies and reminders still arrive. The user gets a note when it ends and the session is logged in the daily note."
+}
+
+func (t *FocusTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type": "string",
+				"enum": []string{"start", "stop", "status"},
+			},
+			"minutes": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("For 'start': length of the session (default %d)", t.minutes),
+			},
+			"task": map[string]interface{}{
+				"type":        "string",
+				"description": "For 'start': what the user is focusing on, e.g. 'write the report'",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *FocusTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	channel, chatID, _ := t.current(ctx)
+	if channel == "" || chatID == "" {
+		return ErrorResult("no chat context for a focus session")
+	}
+	action, _ := args["action"].(string)
+	now := t.now()
+
+	switch action {
+	case "start":
+		minutes := t.minutes
+		if m, ok := args["minutes"].(float64); ok && m >= 1 {
+			minutes = int(m)
+		}
+		task, _ := args["task"].(string)
+		sess, err := t.store.Start(channel, chatID, strings.TrimSpace(task), time.Duration(minutes)*time.Minute, now)
+		if errors.Is(err, focus.ErrActive) {
+			return ErrorResult(fmt.Sprintf("a focus session is already running until %s; stop it first", sess.End.Format("15:04")))
+		}
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to start the focus session: %v", err))
+		}
+		return UserResult(focus.StartNote(sess))
+
+	case "stop":
+		sess, ok := t.store.Stop(channel, chatID, now)
+		if !ok {
+			return NewToolResult("No focus session is running.")
+		}
+		if t.bus != nil {
+			focus.Release(t.bus, sess)
+		}
+		return UserResult(fmt.Sprintf("Focus session stopped after %d of %d minutes. Messages are back on.",
+			int(now.Sub(sess.Start)/time.Minute), sess.Minutes()))
+
+	case "status":
+		sess, ok := t.store.Active(channel, chatID, now)
+		if !ok {
+			return NewToolResult("No focus session is running.")
+		}
+		left := sess.End.Sub(now).Round(time.Minute)
+		return NewToolResult(fmt.Sprintf("Focus session running until %s (%d min left). Task: %s",
+			sess.End.Format("15:04"), int(left/time.Minute), orNone(sess.Task)))
+	}
+	return ErrorResult("action must be start, stop or status")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
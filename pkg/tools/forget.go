@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sipeed/picoclaw/pkg/privacy"
+)
+
+// AdminCheck reports whether the sender of a message on channel is an
+// admin.
+type AdminCheck func(channel, senderID string) bool
+
+// ForgetTool deletes everything the agent knows about a user. It is a
+// two-step operation: the first call returns what would be deleted, and only
+// a second call with confirm=true (after the user agreed) performs the purge.
+// Users can only have themselves forgotten; admins can forget anyone.
+type ForgetTool struct {
+	userContext
+	purger  *privacy.Purger
+	isAdmin AdminCheck
+	pending map[string]bool // user IDs that have been previewed
+	mu      sync.Mutex
+}
+
+func NewForgetTool(purger *privacy.Purger) *ForgetTool {
+	return &ForgetTool{
+		purger:  purger,
+		pending: make(map[string]bool),
+	}
+}
+
+// SetAdminCheck sets how the tool recognizes admins, who may forget other
+// users. Without it only the sender can be forgotten.
+func (t *ForgetTool) SetAdminCheck(check AdminCheck) {
+	t.isAdmin = check
+}
+
+func (t *ForgetTool) Name() string {
+	return "forget"
+}
+
+func (t *ForgetTool) Description() string {
+	return "Permanently delete a user's data: their sessions, memory entries, profile lines and usage records. Only the user themselves or an admin can ask for it. Call first without 'confirm' to get a preview, show it to the user, and only call again with confirm=true after the user explicitly agrees."
+}
+
+func (t *ForgetTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"user_id": map[string]interface{}{
+				"type":        "string",
+				"description": "The sender ID of the user to forget",
+			},
+			"aliases": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Optional: names or handles the user is referred to by in memory",
+			},
+			"confirm": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Set to true only after the user confirmed the preview",
+			},
+		},
+		"required": []string{"user_id"},
+	}
+}
+
+func (t *ForgetTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	userID, _ := args["user_id"].(string)
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return ErrorResult("user_id is required")
+	}
+	if !t.authorized(ctx, userID) {
+		return ErrorResult("only the user themselves or an admin can have a user's data deleted")
+	}
+
+	var aliases []string
+	if raw, ok := args["aliases"].([]interface{}); ok {
+		for _, a := range raw {
+			if s, ok := a.(string); ok {
+				aliases = append(aliases, s)
+			}
+		}
+	}
+
+	confirm, _ := args["confirm"].(bool)
+
+	t.mu.Lock()
+	previewed := t.pending[userID]
+	t.mu.Unlock()
+
+	if !confirm || !previewed {
+		report, err := t.purger.Plan(userID, aliases)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to plan purge: %v", err))
+		}
+		if report.Empty() {
+			return NewToolResult(fmt.Sprintf("No stored data found for user %s.", userID))
+		}
+
+		t.mu.Lock()
+		t.pending[userID] = true
+		t.mu.Unlock()
+
+		return NewToolResult(fmt.Sprintf("Preview — nothing has been deleted yet:\n\n%s\nAsk the user to confirm, then call forget again with confirm=true.", report))
+	}
+
+	report, err := t.purger.Purge(userID, aliases)
+
+	t.mu.Lock()
+	delete(t.pending, userID)
+	t.mu.Unlock()
+
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("purge failed: %v", err))
+	}
+	return NewToolResult(fmt.Sprintf("Deleted data for user %s:\n\n%s", userID, report))
+}
+
+// authorized reports whether the sender of the current message may have
+// userID forgotten: it is their own ID, they are an admin, or the command
+// came from the local CLI. Turns the agent started itself ask for nobody.
+func (t *ForgetTool) authorized(ctx context.Context, userID string) bool {
+	channel, _, senderID := t.current(ctx)
+	if channel == "cli" {
+		return true
+	}
+	if t.background() {
+		return false
+	}
+	if id, _, _ := strings.Cut(senderID, "|"); strings.EqualFold(id, userID) {
+		return true
+	}
+	return t.isAdmin != nil && t.isAdmin(channel, senderID)
+}
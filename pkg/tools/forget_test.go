@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/privacy"
+	"github.com/sipeed/picoclaw/pkg/session"
+)
+
+func TestForgetTool_RequiresPreviewBeforePurge(t *testing.T) {
+	ws := t.TempDir()
+	sm := session.NewSessionManager(filepath.Join(ws, "sessions"))
+	sm.AddMessage("agent:main:telegram:direct:12345", "user", "hi")
+
+	tool := NewForgetTool(privacy.NewPurger(ws, sm))
+	tool.SetContext("telegram", "12345")
+	tool.SetSender("12345|alice")
+	ctx := context.Background()
+
+	// Confirming without a preview only previews
+	result := tool.Execute(ctx, map[string]interface{}{"user_id": "12345", "confirm": true})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if len(sm.Keys()) != 1 {
+		t.Fatal("session deleted before preview")
+	}
+
+	result = tool.Execute(ctx, map[string]interface{}{"user_id": "12345", "confirm": true})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if len(sm.Keys()) != 0 {
+		t.Errorf("session not deleted after confirmation, keys = %v", sm.Keys())
+	}
+}
+
+func TestForgetTool_OnlySelfOrAdmin(t *testing.T) {
+	ws := t.TempDir()
+	sm := session.NewSessionManager(filepath.Join(ws, "sessions"))
+	sm.AddMessage("agent:main:telegram:direct:12345", "user", "hi")
+
+	tool := NewForgetTool(privacy.NewPurger(ws, sm))
+	tool.SetAdminCheck(func(channel, senderID string) bool {
+		return channel == "telegram" && senderID == "1"
+	})
+	ctx := context.Background()
+	args := map[string]interface{}{"user_id": "12345", "confirm": true}
+
+	for _, sender := range []string{"666|mallory", ""} {
+		tool.SetContext("telegram", "666")
+		tool.SetSender(sender)
+		if result := tool.Execute(ctx, args); !result.IsError {
+			t.Errorf("sender %q was allowed to forget another user: %s", sender, result.ForLLM)
+		}
+	}
+
+	tool.SetSender("1")
+	tool.Execute(ctx, args)
+	if result := tool.Execute(ctx, args); result.IsError {
+		t.Fatalf("admin refused: %s", result.ForLLM)
+	}
+	if len(sm.Keys()) != 0 {
+		t.Errorf("admin purge kept sessions %v", sm.Keys())
+	}
+}
@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/habits"
+)
+
+// HabitTrackTool defines habits and logs completions for the current user.
+type HabitTrackTool struct {
+	userContext
+	store *habits.Store
+}
+
+func NewHabitTrackTool(store *habits.Store) *HabitTrackTool {
+	return &HabitTrackTool{store: store}
+}
+
+func (t *HabitTrackTool) Name() string {
+	return "habit_track"
+}
+
+func (t *HabitTrackTool) Description() string {
+	return "Track the user's habits. Use action 'define' when the user wants to start a habit (optionally with a daily reminder time), 'done' when they say they did it (e.g. 'I went for a run'), and 'remove' to stop tracking one."
+}
+
+func (t *HabitTrackTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type": "string",
+				"enum": []string{"define", "done", "remove"},
+			},
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Short habit name, e.g. 'run' or 'read 20 pages'. For 'done', use the name of an existing habit.",
+			},
+			"frequency": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{habits.Daily, habits.Weekly},
+				"description": "For 'define': how often the habit should be done (default daily)",
+			},
+			"remind_at": map[string]interface{}{
+				"type":        "string",
+				"description": "For 'define': optional local time HH:MM to get a gentle reminder if the habit isn't done yet",
+			},
+			"date": map[string]interface{}{
+				"type":        "string",
+				"description": "For 'done': 'today' (default), 'yesterday' or YYYY-MM-DD",
+			},
+		},
+		"required": []string{"action", "name"},
+	}
+}
+
+func (t *HabitTrackTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	action, _ := args["action"].(string)
+	name, _ := args["name"].(string)
+	if strings.TrimSpace(name) == "" {
+		return ErrorResult("name is required")
+	}
+	channel, chatID, senderID := t.current(ctx)
+	user := habits.User{Channel: channel, ChatID: chatID, SenderID: senderID}
+
+	switch action {
+	case "define":
+		frequency, _ := args["frequency"].(string)
+		remindAt, _ := args["remind_at"].(string)
+		created, err := t.store.Define(user, name, frequency, strings.TrimSpace(remindAt))
+		if err != nil {
+			return ErrorResult(err.Error())
+		}
+		verb := "Updated"
+		if created {
+			verb = "Started tracking"
+		}
+		msg := fmt.Sprintf("%s habit %q", verb, name)
+		if remindAt != "" {
+			msg += fmt.Sprintf(" with a reminder at %s if it isn't done yet", remindAt)
+		}
+		return NewToolResult(msg)
+
+	case "done":
+		dateArg, _ := args["date"].(string)
+		day, err := parseDayArg(dateArg, time.Now())
+		if err != nil {
+			return ErrorResult(err.Error())
+		}
+		h, err := t.store.Complete(user, name, day)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("%v. Define it first with action 'define'.", err))
+		}
+		st := habits.ComputeStats(h, time.Now())
+		return NewToolResult(fmt.Sprintf("Logged %q for %s. Current streak: %d %s (best %d).",
+			h.Name, day.Format("2006-01-02"), st.CurrentStreak, periodUnit(h.Frequency, st.CurrentStreak), st.LongestStreak))
+
+	case "remove":
+		if err := t.store.Remove(user, name); err != nil {
+			return ErrorResult(err.Error())
+		}
+		return NewToolResult(fmt.Sprintf("Stopped tracking %q", name))
+	}
+	return ErrorResult(fmt.Sprintf("unknown action: %s", action))
+}
+
+// HabitReportTool reports streaks and completion rates.
+type HabitReportTool struct {
+	userContext
+	store *habits.Store
+}
+
+func NewHabitReportTool(store *habits.Store) *HabitReportTool {
+	return &HabitReportTool{store: store}
+}
+
+func (t *HabitReportTool) Name() string {
+	return "habit_report"
+}
+
+func (t *HabitReportTool) Description() string {
+	return "Show the user's habits with current and best streaks, whether each is done for today/this week, and the completion rate over the last 30 days."
+}
+
+func (t *HabitReportTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional: report on one habit only",
+			},
+		},
+	}
+}
+
+func (t *HabitReportTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	name, _ := args["name"].(string)
+	channel, chatID, senderID := t.current(ctx)
+	list := t.store.Habits(habits.User{Channel: channel, ChatID: chatID, SenderID: senderID})
+
+	now := time.Now()
+	var sb strings.Builder
+	for _, h := range list {
+		if name != "" && !strings.EqualFold(h.Name, strings.TrimSpace(name)) {
+			continue
+		}
+		st := habits.ComputeStats(h, now)
+		status := "not done yet"
+		if st.DoneNow {
+			status = "done"
+		}
+		period := "today"
+		if h.Frequency == habits.Weekly {
+			period = "this week"
+		}
+		fmt.Fprintf(&sb, "- %s (%s): %s %s; streak %d %s, best %d; %.0f%% over the last 30 days; %d total\n",
+			h.Name, h.Frequency, status, period, st.CurrentStreak, periodUnit(h.Frequency, st.CurrentStreak),
+			st.LongestStreak, st.Rate30*100, st.Total)
+	}
+	if sb.Len() == 0 {
+		if name != "" {
+			return NewToolResult(fmt.Sprintf("No habit named %q", name))
+		}
+		return NewToolResult("No habits tracked yet.")
+	}
+	return NewToolResult(sb.String())
+}
+
+func parseDayArg(s string, now time.Time) (time.Time, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "today":
+		return now, nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(s), now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("date must be 'today', 'yesterday' or YYYY-MM-DD")
+	}
+	if day.After(now) {
+		return time.Time{}, fmt.Errorf("date can't be in the future")
+	}
+	return day, nil
+}
+
+func periodUnit(frequency string, n int) string {
+	unit := "day"
+	if frequency == habits.Weekly {
+		unit = "week"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return unit
+}
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/journal"
+)
+
+// JournalTool opts the current user in to or out of the evening journal
+// prompt and saves their answers.
+type JournalTool struct {
+	userContext
+	store     *journal.Store
+	questions []string
+}
+
+func NewJournalTool(store *journal.Store, questions []string) *JournalTool {
+	return &JournalTool{store: store, questions: questions}
+}
+
+func (t *JournalTool) Name() string {
+	return "journal"
+}
+
+func (t *JournalTool) Description() string {
+	desc := "Guided journaling. Use action 'start' when the user wants a journal prompt every evening and 'stop' to end it. Use 'save' to write the user's journal answers to their daily note, when they reply to the evening prompt or ask to journal something."
+	if len(t.questions) > 0 {
+		desc += " The evening prompt asks: " + strings.Join(t.questions, " ")
+	}
+	return desc
+}
+
+func (t *JournalTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type": "string",
+				"enum": []string{"start", "stop", "save"},
+			},
+			"entries": map[string]interface{}{
+				"type":        "array",
+				"description": "For 'save': the user's answers in their own words, lightly tidied. Leave out questions they skipped.",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"question": map[string]interface{}{
+							"type":        "string",
+							"description": "The prompt question answered, or empty for a free-form entry",
+						},
+						"answer": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"required": []string{"answer"},
+				},
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *JournalTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	channel, chatID, senderID := t.current(ctx)
+	u := journal.User{Channel: channel, ChatID: chatID, SenderID: senderID}
+
+	action, _ := args["action"].(string)
+	switch action {
+	case "start":
+		if err := t.store.Subscribe(u); err != nil {
+			return ErrorResult(fmt.Sprintf("failed to start journaling: %v", err)).WithError(err)
+		}
+		return NewToolResult("Journaling started: the user gets the journal prompt here every evening.")
+	case "stop":
+		removed, err := t.store.Unsubscribe(u)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to stop journaling: %v", err)).WithError(err)
+		}
+		if !removed {
+			return NewToolResult("The user wasn't getting journal prompts.")
+		}
+		return NewToolResult("Journaling stopped: no more evening prompts.")
+	case "save":
+		entries := parseJournalEntries(args["entries"])
+		if len(entries) == 0 {
+			return ErrorResult("entries must contain at least one answer")
+		}
+		day, err := t.store.Save(u, entries, time.Now())
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("failed to save journal: %v", err)).WithError(err)
+		}
+		return NewToolResult(fmt.Sprintf("Saved %d journal entries to the note for %s.", len(entries), day.Format("2006-01-02")))
+	}
+	return ErrorResult("action must be 'start', 'stop' or 'save'")
+}
+
+func parseJournalEntries(v interface{}) []journal.Entry {
+	items, _ := v.([]interface{})
+	var entries []journal.Entry
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		answer, _ := m["answer"].(string)
+		if strings.TrimSpace(answer) == "" {
+			continue
+		}
+		question, _ := m["question"].(string)
+		entries = append(entries, journal.Entry{Question: question, Answer: answer})
+	}
+	return entries
+}
@@ -3,12 +3,21 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
 )
 
 type SendCallback func(channel, chatID, content string) error
 
+// EmbedCallback sends content together with a structured embed; content
+// is the plain-text fallback for channels that cannot render embeds.
+type EmbedCallback func(channel, chatID, content string, embed *bus.Embed) error
+
 type MessageTool struct {
 	sendCallback   SendCallback
+	embedCallback  EmbedCallback
 	defaultChannel string
 	defaultChatID  string
 	sentInRound    bool // Tracks whether a message was sent in the current processing round
@@ -42,6 +51,32 @@ func (t *MessageTool) Parameters() map[string]interface{} {
 				"type":        "string",
 				"description": "Optional: target chat/user ID",
 			},
+			"embed": map[string]interface{}{
+				"type":        "object",
+				"description": "Optional: structured card for channels that support it (Discord). content is still sent as the plain-text version elsewhere.",
+				"properties": map[string]interface{}{
+					"title":       map[string]interface{}{"type": "string"},
+					"description": map[string]interface{}{"type": "string"},
+					"url":         map[string]interface{}{"type": "string"},
+					"footer":      map[string]interface{}{"type": "string"},
+					"color": map[string]interface{}{
+						"type":        "string",
+						"description": "Hex color such as #5865F2",
+					},
+					"fields": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"name":   map[string]interface{}{"type": "string"},
+								"value":  map[string]interface{}{"type": "string"},
+								"inline": map[string]interface{}{"type": "boolean"},
+							},
+							"required": []string{"name", "value"},
+						},
+					},
+				},
+			},
 		},
 		"required": []string{"content"},
 	}
@@ -62,6 +97,10 @@ func (t *MessageTool) SetSendCallback(callback SendCallback) {
 	t.sendCallback = callback
 }
 
+func (t *MessageTool) SetEmbedCallback(callback EmbedCallback) {
+	t.embedCallback = callback
+}
+
 func (t *MessageTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
 	content, ok := args["content"].(string)
 	if !ok {
@@ -71,11 +110,12 @@ func (t *MessageTool) Execute(ctx context.Context, args map[string]interface{})
 	channel, _ := args["channel"].(string)
 	chatID, _ := args["chat_id"].(string)
 
+	defaultChannel, defaultChatID := callChat(ctx, t.defaultChannel, t.defaultChatID)
 	if channel == "" {
-		channel = t.defaultChannel
+		channel = defaultChannel
 	}
 	if chatID == "" {
-		chatID = t.defaultChatID
+		chatID = defaultChatID
 	}
 
 	if channel == "" || chatID == "" {
@@ -86,7 +126,13 @@ func (t *MessageTool) Execute(ctx context.Context, args map[string]interface{})
 		return &ToolResult{ForLLM: "Message sending not configured", IsError: true}
 	}
 
-	if err := t.sendCallback(channel, chatID, content); err != nil {
+	var err error
+	if embed := parseEmbed(args["embed"]); embed != nil && t.embedCallback != nil {
+		err = t.embedCallback(channel, chatID, content, embed)
+	} else {
+		err = t.sendCallback(channel, chatID, content)
+	}
+	if err != nil {
 		return &ToolResult{
 			ForLLM:  fmt.Sprintf("sending message: %v", err),
 			IsError: true,
@@ -101,3 +147,41 @@ func (t *MessageTool) Execute(ctx context.Context, args map[string]interface{})
 		Silent: true,
 	}
 }
+
+// parseEmbed reads the optional embed argument, returning nil when it is
+// missing or has nothing to show.
+func parseEmbed(v interface{}) *bus.Embed {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	embed := &bus.Embed{}
+	embed.Title, _ = m["title"].(string)
+	embed.Description, _ = m["description"].(string)
+	embed.URL, _ = m["url"].(string)
+	embed.Footer, _ = m["footer"].(string)
+	if color, ok := m["color"].(string); ok {
+		if c, err := strconv.ParseInt(strings.TrimPrefix(color, "#"), 16, 32); err == nil {
+			embed.Color = int(c)
+		}
+	}
+	if fields, ok := m["fields"].([]interface{}); ok {
+		for _, f := range fields {
+			fm, ok := f.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			field := bus.EmbedField{}
+			field.Name, _ = fm["name"].(string)
+			field.Value, _ = fm["value"].(string)
+			field.Inline, _ = fm["inline"].(bool)
+			embed.Fields = append(embed.Fields, field)
+		}
+	}
+
+	if embed.Title == "" && embed.Description == "" && len(embed.Fields) == 0 {
+		return nil
+	}
+	return embed
+}
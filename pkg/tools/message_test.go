@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
 )
 
 func TestMessageTool_Execute_Success(t *testing.T) {
@@ -257,3 +259,82 @@ func TestMessageTool_Parameters(t *testing.T) {
 		t.Error("Expected chat_id type to be 'string'")
 	}
 }
+
+func TestMessageTool_Execute_Embed(t *testing.T) {
+	tool := NewMessageTool()
+	tool.SetContext("discord", "123")
+
+	var plainSent bool
+	var sentEmbed *bus.Embed
+	var sentContent string
+	tool.SetSendCallback(func(channel, chatID, content string) error {
+		plainSent = true
+		return nil
+	})
+	tool.SetEmbedCallback(func(channel, chatID, content string, embed *bus.Embed) error {
+		sentContent = content
+		sentEmbed = embed
+		return nil
+	})
+
+	result := tool.Execute(context.Background(), map[string]interface{}{
+		"content": "Weather: 21°C, sunny",
+		"embed": map[string]interface{}{
+			"title": "Weather",
+			"color": "#5865F2",
+			"fields": []interface{}{
+				map[string]interface{}{"name": "Temp", "value": "21°C", "inline": true},
+			},
+		},
+	})
+
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.ForLLM)
+	}
+	if plainSent || sentEmbed == nil {
+		t.Fatal("expected the embed callback to be used")
+	}
+	if sentContent != "Weather: 21°C, sunny" {
+		t.Errorf("fallback content = %q", sentContent)
+	}
+	if sentEmbed.Title != "Weather" || sentEmbed.Color != 0x5865F2 {
+		t.Errorf("embed = %+v", sentEmbed)
+	}
+	if len(sentEmbed.Fields) != 1 || !sentEmbed.Fields[0].Inline || sentEmbed.Fields[0].Value != "21°C" {
+		t.Errorf("fields = %+v", sentEmbed.Fields)
+	}
+
+	// An empty embed falls back to a plain message
+	tool.Execute(context.Background(), map[string]interface{}{
+		"content": "hi",
+		"embed":   map[string]interface{}{"footer": "only a footer"},
+	})
+	if !plainSent {
+		t.Error("empty embed should be sent as plain text")
+	}
+}
+
+func TestMessageTool_ExecuteWithContext_ChatPerCall(t *testing.T) {
+	tool := NewMessageTool()
+	tool.SetContext("turn-channel", "turn-chat")
+	var sent []string
+	tool.SetSendCallback(func(channel, chatID, content string) error {
+		sent = append(sent, channel+":"+chatID)
+		return nil
+	})
+	r := NewToolRegistry()
+	r.Register(tool)
+
+	r.ExecuteWithContext(context.Background(), "message", map[string]interface{}{"content": "a"}, "telegram", "42", nil)
+	if !tool.HasSentInRound() {
+		t.Fatal("send not recorded for the round")
+	}
+	r.ExecuteWithContext(context.Background(), "message", map[string]interface{}{"content": "b"}, "", "", nil)
+
+	if len(sent) != 2 || sent[0] != "telegram:42" || sent[1] != "turn-channel:turn-chat" {
+		t.Errorf("sent to %v, want the call's chat then the turn's", sent)
+	}
+	if !tool.HasSentInRound() {
+		t.Error("a later call reset the round's send")
+	}
+}
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/proactive"
+)
+
+// ProactiveFrequencyTool shows or sets how often the agent may contact the
+// current chat on its own.
+type ProactiveFrequencyTool struct {
+	userContext
+	engine *proactive.Engine
+}
+
+func NewProactiveFrequencyTool(engine *proactive.Engine) *ProactiveFrequencyTool {
+	return &ProactiveFrequencyTool{engine: engine}
+}
+
+func (t *ProactiveFrequencyTool) Name() string {
+	return "proactive_frequency"
+}
+
+func (t *ProactiveFrequencyTool) Description() string {
+	return "Show or set how often you message the user on your own (briefings, morning questions, follow-ups, habit nudges, journal prompts, digests). Set a level when the user asks to hear from you less, more, or not at all; omit it to show the current setting. Replies to the user's messages are never limited."
+}
+
+func (t *ProactiveFrequencyTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"level": map[string]interface{}{
+				"type":        "string",
+				"enum":        proactive.Levels,
+				"description": "off: never; low: at most one message a day; normal: a few a day; high: no limit",
+			},
+		},
+	}
+}
+
+func (t *ProactiveFrequencyTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	channel, chatID, _ := t.current(ctx)
+	level, _ := args["level"].(string)
+	level = strings.ToLower(strings.TrimSpace(level))
+
+	if level == "" {
+		level = t.engine.Level(channel, chatID)
+		return NewToolResult(fmt.Sprintf("Proactive messages are set to %s (%s).", level, t.describe(level)))
+	}
+	if err := t.engine.SetLevel(channel, chatID, level); err != nil {
+		return ErrorResult(err.Error())
+	}
+	return NewToolResult(fmt.Sprintf("Proactive messages set to %s (%s).", level, t.describe(level)))
+}
+
+func (t *ProactiveFrequencyTool) describe(level string) string {
+	switch limit := t.engine.DailyLimit(level); {
+	case limit < 0:
+		return "no daily limit"
+	case limit == 0:
+		return "none"
+	case limit == 1:
+		return "at most 1 a day"
+	default:
+		return fmt.Sprintf("at most %d a day", limit)
+	}
+}
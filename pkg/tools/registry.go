@@ -11,8 +11,9 @@ import (
 )
 
 type ToolRegistry struct {
-	tools map[string]Tool
-	mu    sync.RWMutex
+	tools   map[string]Tool
+	allowed map[string]bool // nil for every tool, see Restrict
+	mu      sync.RWMutex
 }
 
 func NewToolRegistry() *ToolRegistry {
@@ -24,9 +25,28 @@ func NewToolRegistry() *ToolRegistry {
 func (r *ToolRegistry) Register(tool Tool) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if r.allowed != nil && !r.allowed[tool.Name()] {
+		return
+	}
 	r.tools[tool.Name()] = tool
 }
 
+// Restrict keeps only the named tools: the others are removed, and tools
+// registered later are ignored unless they are named.
+func (r *ToolRegistry) Restrict(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.allowed = make(map[string]bool, len(names))
+	for _, name := range names {
+		r.allowed[name] = true
+	}
+	for name := range r.tools {
+		if !r.allowed[name] {
+			delete(r.tools, name)
+		}
+	}
+}
+
 func (r *ToolRegistry) Get(name string) (Tool, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -57,9 +77,9 @@ func (r *ToolRegistry) ExecuteWithContext(ctx context.Context, name string, args
 		return ErrorResult(fmt.Sprintf("tool %q not found", name)).WithError(fmt.Errorf("tool not found"))
 	}
 
-	// If tool implements ContextualTool, set context
-	if contextualTool, ok := tool.(ContextualTool); ok && channel != "" && chatID != "" {
-		contextualTool.SetContext(channel, chatID)
+	// The chat goes with the call rather than onto the shared tool
+	if channel != "" && chatID != "" {
+		ctx = WithChat(ctx, channel, chatID)
 	}
 
 	// If tool implements AsyncTool and callback is provided, set callback
@@ -144,6 +164,13 @@ func (r *ToolRegistry) ToProviderDefs() []providers.ToolDefinition {
 	return definitions
 }
 
+// Concurrent reports whether calls of the named tool may run at the same
+// time as other calls.
+func (r *ToolRegistry) Concurrent(name string) bool {
+	tool, ok := r.Get(name)
+	return ok && isConcurrent(tool)
+}
+
 // List returns a list of all registered tool names.
 func (r *ToolRegistry) List() []string {
 	r.mu.RLock()
@@ -0,0 +1,226 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/cron"
+	"github.com/sipeed/picoclaw/pkg/utils"
+)
+
+// ChatReminders returns the enabled cron jobs that deliver to a chat and
+// the messages the bus holds for it, soonest first. Held messages are
+// listed as one-time jobs.
+func ChatReminders(cs *cron.CronService, msgBus *bus.MessageBus, channel, chatID string) []cron.CronJob {
+	var jobs []cron.CronJob
+	for _, j := range cs.ListJobs(false) {
+		if j.Payload.Channel == channel && j.Payload.To == chatID {
+			jobs = append(jobs, j)
+		}
+	}
+	if msgBus != nil {
+		for _, h := range msgBus.Held(channel, chatID) {
+			jobs = append(jobs, heldJob(h))
+		}
+	}
+	sort.SliceStable(jobs, func(a, b int) bool {
+		na, nb := jobs[a].State.NextRunAtMS, jobs[b].State.NextRunAtMS
+		if na == nil || nb == nil {
+			return na != nil
+		}
+		return *na < *nb
+	})
+	return jobs
+}
+
+// heldJob describes a held message as the one-time job it stands for.
+func heldJob(h bus.HeldMessage) cron.CronJob {
+	atMS := h.Message.DeliverAt.UnixMilli()
+	return cron.CronJob{
+		ID:       h.ID,
+		Name:     utils.Truncate(h.Message.Content, 30),
+		Enabled:  true,
+		Schedule: cron.CronSchedule{Kind: "at", AtMS: &atMS},
+		Payload: cron.CronPayload{
+			Kind:    "agent_turn",
+			Message: h.Message.Content,
+			Deliver: true,
+			Channel: h.Message.Channel,
+			To:      h.Message.ChatID,
+		},
+		State: cron.CronJobState{NextRunAtMS: &atMS},
+	}
+}
+
+// CancelReminder removes a job or held message that delivers to a chat.
+// Those of other chats aren't touched, so users can only cancel their
+// own.
+func CancelReminder(cs *cron.CronService, msgBus *bus.MessageBus, channel, chatID, jobID string) (cron.CronJob, bool) {
+	for _, j := range ChatReminders(cs, msgBus, channel, chatID) {
+		if j.ID != jobID {
+			continue
+		}
+		if msgBus != nil {
+			if _, ok := msgBus.CancelHeld(jobID); ok {
+				return j, true
+			}
+		}
+		return j, cs.RemoveJob(jobID)
+	}
+	return cron.CronJob{}, false
+}
+
+// FormatReminders lists jobs one per line with their ID, message and
+// schedule.
+func FormatReminders(jobs []cron.CronJob) string {
+	if len(jobs) == 0 {
+		return "No pending reminders."
+	}
+	var sb strings.Builder
+	sb.WriteString("Pending reminders:")
+	for _, j := range jobs {
+		what := j.Payload.Message
+		if j.Payload.Command != "" {
+			what = fmt.Sprintf("run `%s`", j.Payload.Command)
+		}
+		fmt.Fprintf(&sb, "\n- `%s` %s (%s)", j.ID, what, describeSchedule(j))
+	}
+	return sb.String()
+}
+
+// describeSchedule says when a job runs, e.g. "every 2h, next Tue Mar 10 16:00".
+func describeSchedule(j cron.CronJob) string {
+	var when string
+	switch s := j.Schedule; {
+	case s.Kind == "at":
+		when = "once"
+	case s.Kind == "every" && s.EveryMS != nil:
+		when = "every " + formatInterval(time.Duration(*s.EveryMS)*time.Millisecond)
+	case s.Kind == "cron":
+		when = "cron " + s.Expr
+	default:
+		when = "unknown schedule"
+	}
+	if next := j.State.NextRunAtMS; next != nil {
+		when += ", next " + time.UnixMilli(*next).Format("Mon Jan 2 15:04")
+	}
+	return when
+}
+
+// formatInterval writes whole hours or minutes without the zero units
+// time.Duration adds, e.g. "2h" rather than "2h0m0s".
+func formatInterval(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return d.String()
+}
+
+// ReminderListTool lists the reminders and scheduled tasks of the current
+// chat.
+type ReminderListTool struct {
+	cronService *cron.CronService
+	msgBus      *bus.MessageBus
+	channel     string
+	chatID      string
+	mu          sync.RWMutex
+}
+
+func NewReminderListTool(cronService *cron.CronService, msgBus *bus.MessageBus) *ReminderListTool {
+	return &ReminderListTool{cronService: cronService, msgBus: msgBus}
+}
+
+func (t *ReminderListTool) Name() string {
+	return "reminder_list"
+}
+
+func (t *ReminderListTool) Description() string {
+	return "List the user's pending reminders and scheduled tasks in this chat, with their IDs and when they next run. Use it when the user asks what's scheduled, or before reminder_cancel to find the ID."
+}
+
+func (t *ReminderListTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+}
+
+func (t *ReminderListTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *ReminderListTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	t.mu.RLock()
+	channel, chatID := callChat(ctx, t.channel, t.chatID)
+	t.mu.RUnlock()
+
+	return SilentResult(FormatReminders(ChatReminders(t.cronService, t.msgBus, channel, chatID)))
+}
+
+// ReminderCancelTool cancels one of the current chat's reminders.
+type ReminderCancelTool struct {
+	cronService *cron.CronService
+	msgBus      *bus.MessageBus
+	channel     string
+	chatID      string
+	mu          sync.RWMutex
+}
+
+func NewReminderCancelTool(cronService *cron.CronService, msgBus *bus.MessageBus) *ReminderCancelTool {
+	return &ReminderCancelTool{cronService: cronService, msgBus: msgBus}
+}
+
+func (t *ReminderCancelTool) Name() string {
+	return "reminder_cancel"
+}
+
+func (t *ReminderCancelTool) Description() string {
+	return "Cancel one of the user's pending reminders or scheduled tasks in this chat by its ID, as shown by reminder_list."
+}
+
+func (t *ReminderCancelTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"job_id": map[string]interface{}{
+				"type":        "string",
+				"description": "ID of the reminder to cancel",
+			},
+		},
+		"required": []string{"job_id"},
+	}
+}
+
+func (t *ReminderCancelTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *ReminderCancelTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	jobID, _ := args["job_id"].(string)
+	if jobID == "" {
+		return ErrorResult("job_id is required")
+	}
+	t.mu.RLock()
+	channel, chatID := callChat(ctx, t.channel, t.chatID)
+	t.mu.RUnlock()
+
+	job, ok := CancelReminder(t.cronService, t.msgBus, channel, chatID, jobID)
+	if !ok {
+		return ErrorResult(fmt.Sprintf("no pending reminder %s in this chat", jobID))
+	}
+	return SilentResult(fmt.Sprintf("Cancelled reminder %s: %s", job.ID, job.Name))
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+	"github.com/sipeed/picoclaw/pkg/cron"
+)
+
+func TestReminderTools_OnlyTouchTheirChat(t *testing.T) {
+	cs := cron.NewCronService(filepath.Join(t.TempDir(), "jobs.json"), nil)
+	at := time.Now().Add(time.Hour).UnixMilli()
+	mine, err := cs.AddJob("tea", cron.CronSchedule{Kind: "at", AtMS: &at}, "tea is ready", true, "telegram", "42")
+	if err != nil {
+		t.Fatalf("AddJob: %v", err)
+	}
+	every := int64(2 * time.Hour / time.Millisecond)
+	theirs, _ := cs.AddJob("stretch", cron.CronSchedule{Kind: "every", EveryMS: &every}, "stretch", true, "telegram", "99")
+
+	msgBus := bus.NewMessageBus()
+	defer msgBus.Close()
+	held := msgBus.Hold(bus.OutboundMessage{Channel: "telegram", ChatID: "42", Content: "call mum", DeliverAt: time.Now().Add(time.Hour)})
+
+	list := NewReminderListTool(cs, msgBus)
+	list.SetContext("telegram", "42")
+	result := list.Execute(context.Background(), nil)
+	if !strings.Contains(result.ForLLM, mine.ID) || !strings.Contains(result.ForLLM, held) || strings.Contains(result.ForLLM, theirs.ID) {
+		t.Errorf("reminder_list = %q, want only %s and %s", result.ForLLM, mine.ID, held)
+	}
+
+	cancel := NewReminderCancelTool(cs, msgBus)
+	cancel.SetContext("telegram", "42")
+	if result := cancel.Execute(context.Background(), map[string]interface{}{"job_id": theirs.ID}); !result.IsError {
+		t.Errorf("cancelled another chat's reminder: %s", result.ForLLM)
+	}
+	if result := cancel.Execute(context.Background(), map[string]interface{}{"job_id": mine.ID}); result.IsError {
+		t.Errorf("reminder_cancel: %s", result.ForLLM)
+	}
+	if jobs := cs.ListJobs(true); len(jobs) != 1 || jobs[0].ID != theirs.ID {
+		t.Errorf("jobs after cancel = %+v", jobs)
+	}
+	if result := cancel.Execute(context.Background(), map[string]interface{}{"job_id": held}); result.IsError {
+		t.Errorf("reminder_cancel of a held message: %s", result.ForLLM)
+	}
+	if left := msgBus.Held("telegram", "42"); len(left) != 0 {
+		t.Errorf("still held after cancel: %+v", left)
+	}
+}
+
+func TestFormatInterval(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		2 * time.Hour:    "2h",
+		90 * time.Minute: "90m",
+		30 * time.Second: "30s",
+	} {
+		if got := formatInterval(d); got != want {
+			t.Errorf("formatInterval(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SelfTester is an optional interface for tools that depend on something
+// outside the process, such as a binary, a device or a directory. SelfTest
+// checks it without side effects and returns what is missing.
+type SelfTester interface {
+	Tool
+	SelfTest(ctx context.Context) error
+}
+
+// ToolCheck is the result of a tool's self-test. Problem is empty when the
+// tool passed.
+type ToolCheck struct {
+	Name    string
+	Problem string
+}
+
+func (c ToolCheck) OK() bool { return c.Problem == "" }
+
+// toolNamePattern is what the providers' APIs accept as a function name.
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// jsonSchemaTypes are the types a parameter may have.
+var jsonSchemaTypes = map[string]bool{
+	"string": true, "integer": true, "number": true, "boolean": true,
+	"object": true, "array": true, "null": true,
+}
+
+// CheckTool checks that tool's name and parameter schema are ones the
+// providers accept, then runs its SelfTest if it has one.
+func CheckTool(ctx context.Context, tool Tool) ToolCheck {
+	check := ToolCheck{Name: tool.Name()}
+	if err := checkDefinition(tool); err != nil {
+		check.Problem = err.Error()
+		return check
+	}
+	if st, ok := tool.(SelfTester); ok {
+		if err := st.SelfTest(ctx); err != nil {
+			check.Problem = err.Error()
+		}
+	}
+	return check
+}
+
+func checkDefinition(tool Tool) error {
+	if !toolNamePattern.MatchString(tool.Name()) {
+		return fmt.Errorf("invalid name %q", tool.Name())
+	}
+	if strings.TrimSpace(tool.Description()) == "" {
+		return fmt.Errorf("no description")
+	}
+	params := tool.Parameters()
+	if params == nil {
+		return fmt.Errorf("no parameter schema")
+	}
+	if _, err := json.Marshal(params); err != nil {
+		return fmt.Errorf("parameter schema doesn't encode: %w", err)
+	}
+	if params["type"] != "object" {
+		return fmt.Errorf("parameter schema has type %v, want object", params["type"])
+	}
+	return checkSchema(params, "parameters")
+}
+
+// checkSchema checks the part of JSON Schema the providers agree on: every
+// property has a known type, arrays say what they hold, and required
+// names properties that exist.
+func checkSchema(schema map[string]interface{}, path string) error {
+	types, err := schemaTypes(schema, path)
+	if err != nil {
+		return err
+	}
+	for _, t := range types {
+		switch t {
+		case "object":
+			props, _ := schema["properties"].(map[string]interface{})
+			if schema["properties"] != nil && props == nil {
+				return fmt.Errorf("%s: properties is not an object", path)
+			}
+			names := make([]string, 0, len(props))
+			for name := range props {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				prop, ok := props[name].(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("%s.%s: not a schema", path, name)
+				}
+				if err := checkSchema(prop, path+"."+name); err != nil {
+					return err
+				}
+			}
+			required, ok := stringList(schema["required"])
+			if !ok {
+				return fmt.Errorf("%s: required is not a list of names", path)
+			}
+			for _, name := range required {
+				if _, ok := props[name]; !ok {
+					return fmt.Errorf("%s: required %q is not a property", path, name)
+				}
+			}
+		case "array":
+			items, ok := schema["items"].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: array without items", path)
+			}
+			if err := checkSchema(items, path+"[]"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// schemaTypes returns the types a schema allows. One without a type must
+// be a combination (anyOf, oneOf) or an enum.
+func schemaTypes(schema map[string]interface{}, path string) ([]string, error) {
+	switch t := schema["type"].(type) {
+	case string:
+		if !jsonSchemaTypes[t] {
+			return nil, fmt.Errorf("%s: unknown type %q", path, t)
+		}
+		return []string{t}, nil
+	case nil:
+		for _, key := range []string{"anyOf", "oneOf", "enum"} {
+			if schema[key] != nil {
+				return nil, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: no type", path)
+	}
+	types, ok := stringList(schema["type"])
+	if !ok || len(types) == 0 {
+		return nil, fmt.Errorf("%s: type is %v", path, schema["type"])
+	}
+	for _, t := range types {
+		if !jsonSchemaTypes[t] {
+			return nil, fmt.Errorf("%s: unknown type %q", path, t)
+		}
+	}
+	return types, nil
+}
+
+// stringList reads a list of strings as tools write it, []string or
+// []interface{}. A missing list is an empty one.
+func stringList(v interface{}) ([]string, bool) {
+	switch list := v.(type) {
+	case nil:
+		return nil, true
+	case []string:
+		return list, true
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, s)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// SelfTest checks every registered tool, in name order.
+func (r *ToolRegistry) SelfTest(ctx context.Context) []ToolCheck {
+	r.mu.RLock()
+	tools := make([]Tool, 0, len(r.tools))
+	for _, tool := range r.tools {
+		tools = append(tools, tool)
+	}
+	r.mu.RUnlock()
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name() < tools[j].Name() })
+
+	checks := make([]ToolCheck, 0, len(tools))
+	for _, tool := range tools {
+		checks = append(checks, CheckTool(ctx, tool))
+	}
+	return checks
+}
@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// schemaTool is a tool with any name and parameter schema.
+type schemaTool struct {
+	name   string
+	params map[string]interface{}
+}
+
+func (t *schemaTool) Name() string                       { return t.name }
+func (t *schemaTool) Description() string                { return "test tool" }
+func (t *schemaTool) Parameters() map[string]interface{} { return t.params }
+func (t *schemaTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	return NewToolResult("")
+}
+
+func TestCheckTool_Schema(t *testing.T) {
+	prop := func(kv ...interface{}) map[string]interface{} {
+		m := map[string]interface{}{}
+		for i := 0; i < len(kv); i += 2 {
+			m[kv[i].(string)] = kv[i+1]
+		}
+		return m
+	}
+	object := func(props map[string]interface{}, required interface{}) map[string]interface{} {
+		return map[string]interface{}{"type": "object", "properties": props, "required": required}
+	}
+
+	tests := []struct {
+		name    string
+		tool    *schemaTool
+		problem string
+	}{
+		{"valid", &schemaTool{"ok_tool", object(prop(
+			"query", prop("type", "string"),
+			"tags", prop("type", "array", "items", prop("type", "string")),
+			"mode", prop("enum", []string{"a", "b"}),
+			"limit", prop("type", []interface{}{"integer", "null"}),
+		), []interface{}{"query"})}, ""},
+		{"bad name", &schemaTool{"has space", object(nil, nil)}, "invalid name"},
+		{"not an object", &schemaTool{"t", prop("type", "string")}, "want object"},
+		{"no schema", &schemaTool{"t", nil}, "no parameter schema"},
+		{"unknown type", &schemaTool{"t", object(prop("n", prop("type", "int")), nil)}, `parameters.n: unknown type "int"`},
+		{"missing type", &schemaTool{"t", object(prop("n", prop("description", "a number")), nil)}, "parameters.n: no type"},
+		{"array without items", &schemaTool{"t", object(prop("list", prop("type", "array")), nil)}, "parameters.list: array without items"},
+		{"nested", &schemaTool{"t", object(prop("list", prop("type", "array", "items", object(prop("x", prop("type", "float")), nil))), nil)}, "parameters.list[].x"},
+		{"required missing", &schemaTool{"t", object(prop("a", prop("type", "string")), []string{"b"})}, `required "b" is not a property`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := CheckTool(context.Background(), tt.tool)
+			if tt.problem == "" && !check.OK() {
+				t.Errorf("problem = %q, want none", check.Problem)
+			}
+			if tt.problem != "" && !strings.Contains(check.Problem, tt.problem) {
+				t.Errorf("problem = %q, want %q", check.Problem, tt.problem)
+			}
+		})
+	}
+}
+
+func TestToolRegistry_SelfTest(t *testing.T) {
+	workspace := t.TempDir()
+	r := NewToolRegistry()
+	r.Register(NewListDirTool(workspace, true))
+	r.Register(NewReadFileTool(filepath.Join(workspace, "missing"), true))
+	r.Register(NewExecTool(workspace, true))
+	r.Register(NewSummarizeAudioTool(nil, "", nil, nil, workspace, true))
+
+	checks := r.SelfTest(context.Background())
+	got := make(map[string]string)
+	var names []string
+	for _, c := range checks {
+		got[c.Name] = c.Problem
+		names = append(names, c.Name)
+	}
+	if strings.Join(names, ",") != "exec,list_dir,read_file,summarize_audio" {
+		t.Errorf("checked %v, want every tool in name order", names)
+	}
+	if got["list_dir"] != "" || got["exec"] != "" {
+		t.Errorf("working tools failed: %v", got)
+	}
+	if !strings.Contains(got["read_file"], "workspace") {
+		t.Errorf("read_file problem = %q, want the missing workspace", got["read_file"])
+	}
+	if got["summarize_audio"] == "" {
+		t.Error("summarize_audio passed without ffmpeg or a transcriber")
+	}
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+// SendLaterTool sends a message at a future time. The message bus holds
+// it until then, so nothing runs when it is delivered; for anything that
+// needs the agent at that time, use cron.
+type SendLaterTool struct {
+	msgBus  *bus.MessageBus
+	channel string
+	chatID  string
+	mu      sync.RWMutex
+	now     func() time.Time
+}
+
+func NewSendLaterTool(msgBus *bus.MessageBus) *SendLaterTool {
+	return &SendLaterTool{msgBus: msgBus, now: time.Now}
+}
+
+func (t *SendLaterTool) Name() string {
+	return "send_later"
+}
+
+func (t *SendLaterTool) Description() string {
+	return "Send a message to the user at a future time, e.g. 'tell me in 20 minutes that the tea is ready'. Give either delay_seconds or at. The text is sent as is, without running the agent again; use cron for tasks to do at that time."
+}
+
+func (t *SendLaterTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "The message to send",
+			},
+			"delay_seconds": map[string]interface{}{
+				"type":        "integer",
+				"description": "Seconds from now to send it",
+			},
+			"at": map[string]interface{}{
+				"type":        "string",
+				"description": "When to send it: an RFC 3339 time, or HH:MM local time for the next time the clock shows it",
+			},
+			"channel": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional: target channel, defaults to the current one",
+			},
+			"chat_id": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional: target chat/user ID, defaults to the current one",
+			},
+		},
+		"required": []string{"content"},
+	}
+}
+
+func (t *SendLaterTool) SetContext(channel, chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.channel = channel
+	t.chatID = chatID
+}
+
+func (t *SendLaterTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	content, _ := args["content"].(string)
+	if strings.TrimSpace(content) == "" {
+		return ErrorResult("content is required")
+	}
+
+	now := t.now()
+	var deliverAt time.Time
+	if delay, ok := args["delay_seconds"].(float64); ok {
+		if delay <= 0 {
+			return ErrorResult("delay_seconds must be positive")
+		}
+		deliverAt = now.Add(time.Duration(delay) * time.Second)
+	} else if at, ok := args["at"].(string); ok && at != "" {
+		var err error
+		if deliverAt, err = parseDeliverAt(at, now); err != nil {
+			return ErrorResult(err.Error())
+		}
+	} else {
+		return ErrorResult("one of delay_seconds or at is required")
+	}
+
+	t.mu.RLock()
+	channel, chatID := callChat(ctx, t.channel, t.chatID)
+	t.mu.RUnlock()
+	if c, _ := args["channel"].(string); c != "" {
+		channel = c
+	}
+	if c, _ := args["chat_id"].(string); c != "" {
+		chatID = c
+	}
+	if channel == "" || chatID == "" {
+		return ErrorResult("no target channel/chat specified")
+	}
+
+	t.msgBus.PublishOutbound(bus.OutboundMessage{
+		Channel:   channel,
+		ChatID:    chatID,
+		Content:   content,
+		DeliverAt: deliverAt,
+		Alert:     bus.AlertReminder,
+	})
+	return SilentResult(fmt.Sprintf("Message to %s:%s scheduled for %s", channel, chatID, deliverAt.Format("2006-01-02 15:04 MST")))
+}
+
+// parseDeliverAt reads an RFC 3339 time or a local HH:MM, which means
+// today, or tomorrow once that time has passed.
+func parseDeliverAt(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if at, err := time.Parse(time.RFC3339, s); err == nil {
+		if !at.After(now) {
+			return time.Time{}, fmt.Errorf("%s is in the past", s)
+		}
+		return at, nil
+	}
+	clock, err := time.ParseInLocation("15:04", s, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use RFC 3339 or HH:MM", s)
+	}
+	at := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at, nil
+}
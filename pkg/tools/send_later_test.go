@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/bus"
+)
+
+func TestParseDeliverAt(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		in   string
+		want time.Time
+	}{
+		{"16:00", time.Date(2026, 3, 10, 16, 0, 0, 0, time.UTC)},
+		{"09:15", time.Date(2026, 3, 11, 9, 15, 0, 0, time.UTC)},
+		{"2026-03-12T08:00:00Z", time.Date(2026, 3, 12, 8, 0, 0, 0, time.UTC)},
+	} {
+		got, err := parseDeliverAt(tc.in, now)
+		if err != nil || !got.Equal(tc.want) {
+			t.Errorf("parseDeliverAt(%q) = %v, %v; want %v", tc.in, got, err, tc.want)
+		}
+	}
+	for _, in := range []string{"2026-03-09T08:00:00Z", "tomorrow", "25:00"} {
+		if _, err := parseDeliverAt(in, now); err == nil {
+			t.Errorf("parseDeliverAt(%q) succeeded", in)
+		}
+	}
+}
+
+func TestSendLaterTool_Execute(t *testing.T) {
+	mb := bus.NewMessageBus()
+	defer mb.Close()
+	tool := NewSendLaterTool(mb)
+	tool.SetContext("telegram", "42")
+
+	if result := tool.Execute(context.Background(), map[string]interface{}{"content": "tea"}); !result.IsError {
+		t.Fatalf("missing time accepted: %s", result.ForLLM)
+	}
+
+	start := time.Now()
+	result := tool.Execute(context.Background(), map[string]interface{}{"content": "Your tea is ready", "delay_seconds": float64(1)})
+	if result.IsError || !result.Silent {
+		t.Fatalf("result = %+v", result)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	msg, ok := mb.SubscribeOutbound(ctx)
+	if !ok || msg.Channel != "telegram" || msg.ChatID != "42" || msg.Content != "Your tea is ready" {
+		t.Fatalf("outbound = %+v", msg)
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("delivered after %v, want 1s", waited)
+	}
+}
@@ -119,6 +119,24 @@ func (t *ExecTool) Description() string {
 	return "Execute a shell command and return its output. Use with caution."
 }
 
+// SelfTest checks that the shell commands run in exists, and so does the
+// working directory.
+func (t *ExecTool) SelfTest(ctx context.Context) error {
+	shell := "sh"
+	if runtime.GOOS == "windows" {
+		shell = "powershell"
+	}
+	if _, err := exec.LookPath(shell); err != nil {
+		return fmt.Errorf("%s not found: %w", shell, err)
+	}
+	if t.workingDir != "" {
+		if info, err := os.Stat(t.workingDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("working directory %s is missing", t.workingDir)
+		}
+	}
+	return nil
+}
+
 func (t *ExecTool) Parameters() map[string]interface{} {
 	return map[string]interface{}{
 		"type": "object",
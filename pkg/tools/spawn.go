@@ -85,7 +85,8 @@ func (t *SpawnTool) Execute(ctx context.Context, args map[string]interface{}) *T
 	}
 
 	// Pass callback to manager for async completion notification
-	result, err := t.manager.Spawn(ctx, task, label, agentID, t.originChannel, t.originChatID, t.callback)
+	channel, chatID := callChat(ctx, t.originChannel, t.originChatID)
+	result, err := t.manager.Spawn(ctx, task, label, agentID, channel, chatID, t.callback)
 	if err != nil {
 		return ErrorResult(fmt.Sprintf("failed to spawn subagent: %v", err))
 	}
@@ -35,6 +35,7 @@ type SubagentManager struct {
 	temperature    float64
 	hasMaxTokens   bool
 	hasTemperature bool
+	maxRunning     int
 	nextID         int
 }
 
@@ -76,10 +77,29 @@ func (sm *SubagentManager) RegisterTool(tool Tool) {
 	sm.tools.Register(tool)
 }
 
+// SetMaxRunning caps the subagents running at once; 0 is no limit.
+func (sm *SubagentManager) SetMaxRunning(n int) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.maxRunning = n
+}
+
 func (sm *SubagentManager) Spawn(ctx context.Context, task, label, agentID, originChannel, originChatID string, callback AsyncCallback) (string, error) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
+	if sm.maxRunning > 0 {
+		running := 0
+		for _, t := range sm.tasks {
+			if t.Status == "running" {
+				running++
+			}
+		}
+		if running >= sm.maxRunning {
+			return "", fmt.Errorf("%d subagents are already running, the most allowed; wait for one to finish", running)
+		}
+	}
+
 	taskID := fmt.Sprintf("subagent-%d", sm.nextID)
 	sm.nextID++
 
@@ -326,13 +346,14 @@ func (t *SubagentTool) Execute(ctx context.Context, args map[string]interface{})
 		}
 	}
 
+	channel, chatID := callChat(ctx, t.originChannel, t.originChatID)
 	loopResult, err := RunToolLoop(ctx, ToolLoopConfig{
 		Provider:      sm.provider,
 		Model:         sm.defaultModel,
 		Tools:         tools,
 		MaxIterations: maxIter,
 		LLMOptions:    llmOptions,
-	}, messages, t.originChannel, t.originChatID)
+	}, messages, channel, chatID)
 	if err != nil {
 		return ErrorResult(fmt.Sprintf("Subagent execution failed: %v", err)).WithError(err)
 	}
@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+)
+
+// SummaryPartChars is how much text goes into one summarization call.
+const SummaryPartChars = 12000
+
+// SummaryPrompts are the prompts of SummarizeMultipart. Part gets the
+// focus note and one part; Merge gets the focus note and the part
+// summaries.
+type SummaryPrompts struct {
+	Part  string
+	Merge string
+}
+
+// SplitLines packs lines into parts of at most maxChars, never splitting a
+// line.
+func SplitLines(lines []string, maxChars int) []string {
+	var parts []string
+	var sb strings.Builder
+	for _, line := range lines {
+		if sb.Len() > 0 && sb.Len()+len(line)+1 > maxChars {
+			parts = append(parts, sb.String())
+			sb.Reset()
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	if sb.Len() > 0 {
+		parts = append(parts, sb.String())
+	}
+	return parts
+}
+
+// SummarizeMultipart summarizes each part on its own, then merges the part
+// summaries into the final structure. A single part still goes through the
+// merge step so the output format is the same.
+func SummarizeMultipart(ctx context.Context, provider providers.LLMProvider, model string, parts []string, prompts SummaryPrompts, focus string) (string, error) {
+	focusNote := ""
+	if focus != "" {
+		focusNote = "\nPay particular attention to: " + focus
+	}
+
+	summaries := make([]string, 0, len(parts))
+	for i, part := range parts {
+		s, err := completePrompt(ctx, provider, model, fmt.Sprintf(prompts.Part, focusNote, part))
+		if err != nil {
+			return "", fmt.Errorf("part %d of %d: %w", i+1, len(parts), err)
+		}
+		summaries = append(summaries, fmt.Sprintf("Part %d:\n%s", i+1, s))
+	}
+
+	return completePrompt(ctx, provider, model, fmt.Sprintf(prompts.Merge, focusNote, strings.Join(summaries, "\n\n")))
+}
+
+func completePrompt(ctx context.Context, provider providers.LLMProvider, model, prompt string) (string, error) {
+	resp, err := provider.Chat(ctx, []providers.Message{{Role: "user", Content: prompt}}, nil, model, map[string]interface{}{
+		"max_tokens":  2048,
+		"temperature": 0.3,
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
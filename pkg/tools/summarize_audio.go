@@ -0,0 +1,202 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/voice"
+)
+
+const (
+	// audioChunkLength keeps each uploaded chunk well under the
+	// transcription size limit at 16 kHz mono FLAC.
+	audioChunkLength = 10 * time.Minute
+	// transcriptLineGap groups transcript segments into lines of roughly
+	// this length, each with one timestamp.
+	transcriptLineGap = 30 * time.Second
+)
+
+// AudioSummaryPrompts summarize a timestamped transcript into chapters.
+var AudioSummaryPrompts = SummaryPrompts{
+	Part:  audioPartPrompt,
+	Merge: audioMergePrompt,
+}
+
+const audioPartPrompt = `Summarize this part of a transcribed recording. Each line starts with a [hh:mm:ss] timestamp.
+List the topics discussed in order, each with the timestamp where it starts, followed by the key points and any notable quotes.%s
+
+TRANSCRIPT:
+%s`
+
+const audioMergePrompt = `These are summaries of consecutive parts of one recording. Combine them into one structured summary in Markdown with these sections:
+
+## Overview
+Two or three sentences on what the recording is about.
+
+## Chapters
+One line per topic: "- [hh:mm:ss] Title: one-sentence description", in order.
+
+## Key Points
+The most important takeaways as bullets.
+
+## Notable Quotes
+Up to five short quotes with their timestamps, if any stand out.%s
+
+PART SUMMARIES:
+%s`
+
+// SummarizeAudioTool transcribes a long recording such as a podcast episode
+// in chunks and summarizes the transcript part by part, then merges the
+// parts into one summary with timestamps.
+type SummarizeAudioTool struct {
+	provider    providers.LLMProvider
+	model       string
+	transcriber *voice.GroqTranscriber
+	converter   *voice.AudioConverter
+	workspace   string
+	restrict    bool
+}
+
+func NewSummarizeAudioTool(provider providers.LLMProvider, model string, transcriber *voice.GroqTranscriber, converter *voice.AudioConverter, workspace string, restrict bool) *SummarizeAudioTool {
+	return &SummarizeAudioTool{
+		provider:    provider,
+		model:       model,
+		transcriber: transcriber,
+		converter:   converter,
+		workspace:   workspace,
+		restrict:    restrict,
+	}
+}
+
+func (t *SummarizeAudioTool) Name() string {
+	return "summarize_audio"
+}
+
+func (t *SummarizeAudioTool) Description() string {
+	return "Transcribe and summarize a long audio or video recording (podcast episode, talk, meeting) from a direct http(s) media URL or a file path. Returns a structured summary with chapter timestamps. Takes a while for long recordings."
+}
+
+// SelfTest checks for ffmpeg, which splits recordings, and a transcription
+// API key.
+func (t *SummarizeAudioTool) SelfTest(ctx context.Context) error {
+	if !t.converter.CanConvert() {
+		return fmt.Errorf("ffmpeg not found")
+	}
+	if t.transcriber == nil || !t.transcriber.IsAvailable() {
+		return fmt.Errorf("no transcription API key")
+	}
+	return nil
+}
+
+func (t *SummarizeAudioTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"source": map[string]interface{}{
+				"type":        "string",
+				"description": "Direct URL of the audio/video file, or a file path",
+			},
+			"focus": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional: what the summary should pay most attention to",
+			},
+		},
+		"required": []string{"source"},
+	}
+}
+
+func (t *SummarizeAudioTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	source, _ := args["source"].(string)
+	if source == "" {
+		return ErrorResult("source is required")
+	}
+	focus, _ := args["focus"].(string)
+
+	input, err := t.resolveSource(source)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+
+	dir, chunks, err := t.converter.Split(ctx, input, audioChunkLength)
+	if err != nil {
+		return ErrorResult(err.Error()).WithError(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var lines []string
+	for i, chunk := range chunks {
+		resp, err := t.transcriber.Transcribe(ctx, chunk)
+		if err != nil {
+			return ErrorResult(fmt.Sprintf("transcribing part %d of %d: %v", i+1, len(chunks), err)).WithError(err)
+		}
+		lines = append(lines, transcriptLines(resp, time.Duration(i)*audioChunkLength)...)
+	}
+	if len(lines) == 0 {
+		return ErrorResult("no speech found in the recording")
+	}
+
+	summary, err := SummarizeMultipart(ctx, t.provider, t.model, SplitLines(lines, SummaryPartChars), AudioSummaryPrompts, focus)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("summarizing transcript: %v", err)).WithError(err)
+	}
+
+	return NewToolResult(fmt.Sprintf("Summary of %s (%d transcript lines):\n\n%s", source, len(lines), summary))
+}
+
+// resolveSource accepts URLs as-is, since ffmpeg reads them directly, and
+// validates file paths against the workspace.
+func (t *SummarizeAudioTool) resolveSource(source string) (string, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return source, nil
+	}
+	path, err := validatePath(source, t.workspace, t.restrict)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("cannot read %s: %v", filepath.Base(path), err)
+	}
+	return path, nil
+}
+
+// transcriptLines groups the segments of one chunk into timestamped lines,
+// shifting timestamps by the chunk's offset in the recording.
+func transcriptLines(resp *voice.TranscriptionResponse, offset time.Duration) []string {
+	if len(resp.Segments) == 0 {
+		if text := strings.TrimSpace(resp.Text); text != "" {
+			return []string{fmt.Sprintf("[%s] %s", formatTimestamp(offset), text)}
+		}
+		return nil
+	}
+
+	var lines []string
+	var current []string
+	var lineStart time.Duration
+	for _, seg := range resp.Segments {
+		start := offset + time.Duration(seg.Start*float64(time.Second))
+		if len(current) > 0 && start-lineStart >= transcriptLineGap {
+			lines = append(lines, fmt.Sprintf("[%s] %s", formatTimestamp(lineStart), strings.Join(current, " ")))
+			current = nil
+		}
+		if len(current) == 0 {
+			lineStart = start
+		}
+		if text := strings.TrimSpace(seg.Text); text != "" {
+			current = append(current, text)
+		}
+	}
+	if len(current) > 0 {
+		lines = append(lines, fmt.Sprintf("[%s] %s", formatTimestamp(lineStart), strings.Join(current, " ")))
+	}
+	return lines
+}
+
+func formatTimestamp(d time.Duration) string {
+	s := int(d.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sipeed/picoclaw/pkg/constants"
+)
+
+// userContext records the chat and sender of the current message for
+// tools that keep data per user. Embed it to implement ContextualTool and
+// SenderContextualTool.
+type userContext struct {
+	mu       sync.RWMutex
+	channel  string
+	chatID   string
+	senderID string
+}
+
+func (c *userContext) SetContext(channel, chatID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.channel = channel
+	c.chatID = chatID
+}
+
+func (c *userContext) SetSender(senderID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.senderID = senderID
+}
+
+// current returns the chat of the call in ctx and the sender. Without a
+// sender (e.g. the CLI) the chat stands in for the user.
+func (c *userContext) current(ctx context.Context) (channel, chatID, senderID string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	channel, chatID = callChat(ctx, c.channel, c.chatID)
+	senderID = c.senderID
+	if senderID == "" {
+		senderID = chatID
+	}
+	return channel, chatID, senderID
+}
+
+// background reports whether the turn was started by the agent itself
+// (a heartbeat or cron job) rather than by a message in the chat.
+func (c *userContext) background() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return (c.senderID == "" || c.senderID == "cron") && !constants.IsInternalChannel(c.channel)
+}
@@ -291,6 +291,11 @@ func (t *WebSearchTool) Name() string {
 	return "web_search"
 }
 
+// Concurrent implements ConcurrentTool: it only reads.
+func (t *WebSearchTool) Concurrent() bool {
+	return true
+}
+
 func (t *WebSearchTool) Description() string {
 	return "Search the web for current information. Returns titles, URLs, and snippets from search results."
 }
@@ -355,6 +360,11 @@ func (t *WebFetchTool) Name() string {
 	return "web_fetch"
 }
 
+// Concurrent implements ConcurrentTool: it only reads.
+func (t *WebFetchTool) Concurrent() bool {
+	return true
+}
+
 func (t *WebFetchTool) Description() string {
 	return "Fetch a URL and extract readable content (HTML to text). Use this to get weather info, news, articles, or any web content."
 }
@@ -483,6 +493,12 @@ func (t *WebFetchTool) Execute(ctx context.Context, args map[string]interface{})
 }
 
 func (t *WebFetchTool) extractText(htmlContent string) string {
+	return ExtractText(htmlContent)
+}
+
+// ExtractText strips scripts, styles and tags from an HTML page and
+// collapses whitespace, leaving the readable text.
+func ExtractText(htmlContent string) string {
 	re := regexp.MustCompile(`<script[\s\S]*?</script>`)
 	result := re.ReplaceAllLiteralString(htmlContent, "")
 	re = regexp.MustCompile(`<style[\s\S]*?</style>`)
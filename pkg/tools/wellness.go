@@ -0,0 +1,178 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/wellness"
+)
+
+// HealthLogTool records sleep, weight and workouts for the current user.
+type HealthLogTool struct {
+	userContext
+	store *wellness.Store
+}
+
+func NewHealthLogTool(store *wellness.Store) *HealthLogTool {
+	return &HealthLogTool{store: store}
+}
+
+func (t *HealthLogTool) Name() string {
+	return "health_log"
+}
+
+func (t *HealthLogTool) Description() string {
+	return "Log the user's sleep (hours), weight or a workout (minutes plus activity) when they mention it, e.g. 'slept 7 hours', 'weighed 72.4 this morning', 'ran for 30 minutes'."
+}
+
+func (t *HealthLogTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"kind": map[string]interface{}{
+				"type": "string",
+				"enum": []string{wellness.Sleep, wellness.Weight, wellness.Workout},
+			},
+			"value": map[string]interface{}{
+				"type":        "number",
+				"description": "Hours slept, body weight, or workout minutes",
+			},
+			"unit": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"kg", "lb"},
+				"description": "For weight: unit of value (default kg)",
+			},
+			"activity": map[string]interface{}{
+				"type":        "string",
+				"description": "For workouts: the activity, e.g. running, cycling, yoga",
+			},
+			"date": map[string]interface{}{
+				"type":        "string",
+				"description": "'today' (default), 'yesterday' or YYYY-MM-DD. Sleep is dated by the morning the user woke up.",
+			},
+		},
+		"required": []string{"kind", "value"},
+	}
+}
+
+func (t *HealthLogTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	kind, _ := args["kind"].(string)
+	if !wellness.ValidKind(kind) {
+		return ErrorResult(fmt.Sprintf("kind must be %s, %s or %s", wellness.Sleep, wellness.Weight, wellness.Workout))
+	}
+	value, ok := args["value"].(float64)
+	if !ok || value <= 0 {
+		return ErrorResult("value must be a number greater than zero")
+	}
+	if kind == wellness.Sleep && value > 24 {
+		return ErrorResult("sleep is logged in hours")
+	}
+	if unit, _ := args["unit"].(string); kind == wellness.Weight && strings.EqualFold(unit, "lb") {
+		value *= 0.45359237
+	}
+
+	dateArg, _ := args["date"].(string)
+	date, err := parseDayArg(dateArg, time.Now())
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	activity, _ := args["activity"].(string)
+	record := wellness.Record{
+		Date:   date.Format("2006-01-02"),
+		Kind:   kind,
+		Value:  value,
+		Detail: strings.ToLower(strings.TrimSpace(activity)),
+	}
+
+	_, _, senderID := t.current(ctx)
+	if _, err := t.store.Add(senderID, record); err != nil {
+		return ErrorResult(fmt.Sprintf("failed to log: %v", err)).WithError(err)
+	}
+
+	switch kind {
+	case wellness.Sleep:
+		return NewToolResult(fmt.Sprintf("Logged %.1fh of sleep for %s", value, record.Date))
+	case wellness.Weight:
+		return NewToolResult(fmt.Sprintf("Logged weight %.1f kg for %s", value, record.Date))
+	}
+	return NewToolResult(fmt.Sprintf("Logged a %.0f min %s workout for %s", value, nonEmptyOr(record.Detail, "other"), record.Date))
+}
+
+// HealthReportTool answers trend questions about the user's health data.
+type HealthReportTool struct {
+	userContext
+	store *wellness.Store
+}
+
+func NewHealthReportTool(store *wellness.Store) *HealthReportTool {
+	return &HealthReportTool{store: store}
+}
+
+func (t *HealthReportTool) Name() string {
+	return "health_report"
+}
+
+func (t *HealthReportTool) Description() string {
+	return "Show trends in the user's sleep, weight and workouts, logged in chat or imported from Apple Health or Garmin. Without 'kind' it also returns a short wellness note suitable for daily briefings."
+}
+
+func (t *HealthReportTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"kind": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{wellness.Sleep, wellness.Weight, wellness.Workout},
+				"description": "Optional: report on one kind only",
+			},
+			"days": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of days to look back (default 30)",
+			},
+		},
+	}
+}
+
+func (t *HealthReportTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	days := 30
+	if d, ok := args["days"].(float64); ok && d >= 1 {
+		days = int(d)
+	}
+	kind, _ := args["kind"].(string)
+	if kind != "" && !wellness.ValidKind(kind) {
+		return ErrorResult(fmt.Sprintf("unknown kind %q", kind))
+	}
+
+	_, _, senderID := t.current(ctx)
+	records, err := t.store.Records(senderID)
+	if err != nil {
+		return ErrorResult(err.Error()).WithError(err)
+	}
+	if len(records) == 0 {
+		return NewToolResult("No health data logged yet.")
+	}
+
+	now := time.Now()
+	if kind != "" {
+		return NewToolResult(wellness.ComputeTrend(records, kind, days, now).Format())
+	}
+
+	var sb strings.Builder
+	if note := wellness.Note(records, now); note != "" {
+		fmt.Fprintf(&sb, "Wellness note: %s\n\n", note)
+	}
+	for _, k := range []string{wellness.Sleep, wellness.Weight, wellness.Workout} {
+		sb.WriteString(wellness.ComputeTrend(records, k, days, now).Format())
+		sb.WriteString("\n")
+	}
+	return NewToolResult(strings.TrimRight(sb.String(), "\n"))
+}
+
+func nonEmptyOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
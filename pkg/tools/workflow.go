@@ -0,0 +1,200 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sipeed/picoclaw/pkg/providers"
+	"github.com/sipeed/picoclaw/pkg/utils"
+	"github.com/sipeed/picoclaw/pkg/workflow"
+)
+
+// ConfirmFunc asks the user in a chat to approve prompt. It returns
+// workflow.ErrNoApproval when the chat can't show a confirmation.
+type ConfirmFunc func(ctx context.Context, channel, chatID, senderID, prompt string) (bool, error)
+
+// ToolGate decides whether a workflow's tool step may run, the way the
+// agent decides for its own tool calls (tools.confirm). It returns nil to
+// run the step, or the result to report instead.
+type ToolGate func(ctx context.Context, channel, chatID, senderID, tool string, args map[string]interface{}) *ToolResult
+
+// RunWorkflowTool lists and runs the workflows in workspace/workflows.
+// Tool steps run with the agent's own tools, in the current chat.
+type RunWorkflowTool struct {
+	userContext
+	dir       string
+	provider  providers.LLMProvider
+	model     string
+	tools     *ToolRegistry
+	confirm   ConfirmFunc
+	gate      ToolGate
+	confirmMu sync.RWMutex
+}
+
+func NewRunWorkflowTool(workspace string, provider providers.LLMProvider, model string, registry *ToolRegistry) *RunWorkflowTool {
+	return &RunWorkflowTool{
+		dir:      workflow.Dir(workspace),
+		provider: provider,
+		model:    model,
+		tools:    registry,
+	}
+}
+
+// SetConfirm sets how approval steps ask the user. Without it, a run
+// stops at its first approval step.
+func (t *RunWorkflowTool) SetConfirm(confirm ConfirmFunc) {
+	t.confirmMu.Lock()
+	defer t.confirmMu.Unlock()
+	t.confirm = confirm
+}
+
+// SetToolGate sets the approval tool steps go through, the same as the
+// agent's own calls of those tools.
+func (t *RunWorkflowTool) SetToolGate(gate ToolGate) {
+	t.confirmMu.Lock()
+	defer t.confirmMu.Unlock()
+	t.gate = gate
+}
+
+func (t *RunWorkflowTool) Name() string {
+	return "run_workflow"
+}
+
+func (t *RunWorkflowTool) Description() string {
+	return "Run a saved multi-step workflow from workspace/workflows, or list them when name is omitted. Use it when the user asks for a workflow or routine by name. To run one on a schedule, create a cron job whose message asks you to run it."
+}
+
+func (t *RunWorkflowTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Workflow to run. Omit to list the available workflows and their inputs.",
+			},
+			"inputs": map[string]interface{}{
+				"type":                 "object",
+				"description":          "Values for the workflow's inputs",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+		},
+	}
+}
+
+func (t *RunWorkflowTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	name, _ := args["name"].(string)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return t.list()
+	}
+
+	wf, err := workflow.Find(t.dir, name)
+	if err != nil {
+		return ErrorResult(err.Error())
+	}
+	inputs := map[string]string{}
+	if raw, ok := args["inputs"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			inputs[k] = fmt.Sprint(v)
+		}
+	}
+	channel, chatID, senderID := t.current(ctx)
+	t.confirmMu.RLock()
+	confirm, gate := t.confirm, t.gate
+	t.confirmMu.RUnlock()
+
+	runner := &workflow.Runner{
+		Prompt: func(ctx context.Context, prompt string) (string, error) {
+			return completePrompt(ctx, t.provider, t.model, prompt)
+		},
+		Tool: func(ctx context.Context, tool string, toolArgs map[string]interface{}) (string, error) {
+			if tool == t.Name() {
+				return "", fmt.Errorf("workflows can't run other workflows")
+			}
+			if gate != nil {
+				if refused := gate(ctx, channel, chatID, senderID, tool, toolArgs); refused != nil {
+					return "", fmt.Errorf("%s: %s", tool, refused.ForLLM)
+				}
+			}
+			result := t.tools.ExecuteWithContext(ctx, tool, toolArgs, channel, chatID, nil)
+			if result.IsError {
+				return "", fmt.Errorf("%s: %s", tool, result.ForLLM)
+			}
+			return result.ForLLM, nil
+		},
+	}
+	if confirm != nil {
+		runner.Approve = func(ctx context.Context, prompt string) (bool, error) {
+			return confirm(ctx, channel, chatID, senderID, prompt)
+		}
+	}
+
+	res, err := runner.Run(ctx, wf, inputs)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("Workflow %s failed: %v\n%s", wf.Name, err, formatWorkflowResult(res))).WithError(err)
+	}
+	return NewToolResult(formatWorkflowResult(res))
+}
+
+func (t *RunWorkflowTool) list() *ToolResult {
+	workflows, err := workflow.Load(t.dir)
+	if err != nil {
+		return ErrorResult(fmt.Sprintf("Failed to load workflows: %v", err))
+	}
+	if len(workflows) == 0 {
+		return NewToolResult("No workflows yet. Workflows are YAML files in workspace/workflows.")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Workflows:\n")
+	for _, wf := range workflows {
+		fmt.Fprintf(&sb, "- %s (%d steps)", wf.Name, len(wf.Steps))
+		if wf.Description != "" {
+			sb.WriteString(": " + wf.Description)
+		}
+		sb.WriteString("\n")
+		for _, in := range wf.Inputs {
+			fmt.Fprintf(&sb, "  input %s", in.Name)
+			switch {
+			case in.Required && in.Default == "":
+				sb.WriteString(" (required)")
+			case in.Default != "":
+				fmt.Fprintf(&sb, " (default %q)", in.Default)
+			}
+			if in.Description != "" {
+				sb.WriteString(": " + in.Description)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return NewToolResult(strings.TrimRight(sb.String(), "\n"))
+}
+
+// formatWorkflowResult reports each step and the final output for the LLM.
+func formatWorkflowResult(res *workflow.Result) string {
+	if res == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, s := range res.Steps {
+		switch {
+		case s.Skipped:
+			fmt.Fprintf(&sb, "- %s (%s): skipped\n", s.ID, s.Kind)
+		case s.Kind == "approval":
+			fmt.Fprintf(&sb, "- %s (approval): %s\n", s.ID, s.Output)
+		default:
+			fmt.Fprintf(&sb, "- %s (%s): done\n", s.ID, s.Kind)
+		}
+	}
+	if res.NeedsApproval {
+		fmt.Fprintf(&sb, "Stopped: %s. This chat can't show approval buttons, so the step can't be approved here; tell the user to run it from a chat that can.\n", res.Stopped)
+	} else if res.Stopped != "" {
+		fmt.Fprintf(&sb, "Stopped: %s.\n", res.Stopped)
+	}
+	if out := res.Output(); out != "" {
+		fmt.Fprintf(&sb, "\nOutput:\n%s", utils.Truncate(out, 8000))
+	}
+	return fmt.Sprintf("Workflow %s:\n%s", res.Workflow, strings.TrimRight(sb.String(), "\n"))
+}
@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordTool records that it ran.
+type recordTool struct{ ran bool }
+
+func (r *recordTool) Name() string        { return "exec" }
+func (r *recordTool) Description() string { return "Runs a command" }
+func (r *recordTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+}
+
+func (r *recordTool) Execute(ctx context.Context, args map[string]interface{}) *ToolResult {
+	r.ran = true
+	return NewToolResult("ran")
+}
+
+func TestRunWorkflowTool_ToolStepsGoThroughTheGate(t *testing.T) {
+	workspace := t.TempDir()
+	os.MkdirAll(filepath.Join(workspace, "workflows"), 0755)
+	os.WriteFile(filepath.Join(workspace, "workflows", "cleanup.yaml"), []byte("steps:\n  - id: wipe\n    tool: exec\n    args:\n      command: rm -rf data\n"), 0644)
+
+	registry := NewToolRegistry()
+	exec := &recordTool{}
+	registry.Register(exec)
+	wt := NewRunWorkflowTool(workspace, nil, "", registry)
+	var asked string
+	wt.SetToolGate(func(ctx context.Context, channel, chatID, senderID, tool string, args map[string]interface{}) *ToolResult {
+		asked = tool + " " + args["command"].(string)
+		return ErrorResult("The user cancelled this exec call.")
+	})
+
+	result := wt.Execute(context.Background(), map[string]interface{}{"name": "cleanup", "approve": true})
+	if exec.ran {
+		t.Error("a refused tool step ran")
+	}
+	if asked != "exec rm -rf data" || !result.IsError || !strings.Contains(result.ForLLM, "cancelled") {
+		t.Errorf("asked %q, result = %+v", asked, result)
+	}
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	newText := "a\nb\nc\nD\ne\nf\ng\nh\ni\n"
+
+	got := UnifiedDiff("AGENTS.md", oldText, newText)
+	want := "--- a/AGENTS.md\n+++ b/AGENTS.md\n" +
+		"@@ -1,8 +1,9 @@\n a\n b\n c\n-d\n+D\n e\n f\n g\n h\n+i\n"
+	if got != want {
+		t.Errorf("UnifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+
+	if d := UnifiedDiff("AGENTS.md", oldText, oldText); d != "" {
+		t.Errorf("diff of equal texts = %q", d)
+	}
+	if d := UnifiedDiff("SOUL.md", "", "new\n"); !strings.Contains(d, "@@ -0,0 +1,1 @@\n+new\n") {
+		t.Errorf("diff against empty file = %q", d)
+	}
+}
@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+var fileLocks sync.Map // cleaned path -> *sync.Mutex
+
+// FileLock returns the lock of the file at path. Every store opened on the
+// same file in this process gets the same lock, so two of them, such as
+// an agent's tool and a gateway service, don't overwrite each other's
+// changes.
+func FileLock(path string) *sync.Mutex {
+	mu, _ := fileLocks.LoadOrStore(filepath.Clean(path), &sync.Mutex{})
+	return mu.(*sync.Mutex)
+}
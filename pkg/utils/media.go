@@ -32,6 +32,37 @@ func IsAudioFile(filename, contentType string) bool {
 	return false
 }
 
+// IsVideoFile checks if a file is a video based on its filename extension and content type.
+func IsVideoFile(filename, contentType string) bool {
+	videoExtensions := []string{".mp4", ".mov", ".webm", ".mkv", ".avi", ".m4v", ".3gp"}
+
+	for _, ext := range videoExtensions {
+		if strings.HasSuffix(strings.ToLower(filename), ext) {
+			return true
+		}
+	}
+
+	return strings.HasPrefix(strings.ToLower(contentType), "video/")
+}
+
+// IsImageFile checks if a file is an image vision models can read, based on
+// its filename extension and content type.
+func IsImageFile(filename, contentType string) bool {
+	imageExtensions := []string{".png", ".jpg", ".jpeg", ".gif", ".webp"}
+
+	for _, ext := range imageExtensions {
+		if strings.HasSuffix(strings.ToLower(filename), ext) {
+			return true
+		}
+	}
+
+	switch strings.ToLower(contentType) {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
 // SanitizeFilename removes potentially dangerous characters from a filename
 // and returns a safe version for local filesystem storage.
 func SanitizeFilename(filename string) string {
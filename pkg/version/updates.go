@@ -0,0 +1,181 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Release channels.
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+	ChannelOff    = "off"
+)
+
+// releasesURL lists the project's GitHub releases, newest first.
+var releasesURL = "https://api.github.com/repos/sipeed/picoclaw/releases?per_page=20"
+
+// UpdateCheck is the outcome of the last check for a newer release, kept
+// in workspace/state/update_check.json.
+type UpdateCheck struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Channel   string    `json:"channel"`
+	Latest    string    `json:"latest"`
+	URL       string    `json:"url,omitempty"`
+}
+
+// Available reports whether the release found is newer than this build.
+// Development builds never report an update.
+func (u *UpdateCheck) Available() bool {
+	return u != nil && u.Latest != "" && Newer(u.Latest, Version)
+}
+
+// LastCheck returns the stored result of the last check, or nil.
+func LastCheck(workspace string) *UpdateCheck {
+	data, err := os.ReadFile(checkPath(workspace))
+	if err != nil {
+		return nil
+	}
+	var u UpdateCheck
+	if json.Unmarshal(data, &u) != nil {
+		return nil
+	}
+	return &u
+}
+
+// CheckForUpdate looks up the newest release on channel, at most once per
+// interval: a stored check that is recent enough and for the same channel
+// is returned without asking GitHub.
+func CheckForUpdate(ctx context.Context, workspace, channel string, interval time.Duration) (*UpdateCheck, error) {
+	if channel == ChannelOff || channel == "" {
+		return nil, nil
+	}
+	if last := LastCheck(workspace); last != nil && last.Channel == channel && time.Since(last.CheckedAt) < interval {
+		return last, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, releasesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching releases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching releases: %s", resp.Status)
+	}
+
+	var releases []struct {
+		TagName    string `json:"tag_name"`
+		HTMLURL    string `json:"html_url"`
+		Draft      bool   `json:"draft"`
+		Prerelease bool   `json:"prerelease"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("decoding releases: %w", err)
+	}
+
+	check := &UpdateCheck{CheckedAt: time.Now(), Channel: channel}
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && channel != ChannelBeta) {
+			continue
+		}
+		if check.Latest == "" || Newer(r.TagName, check.Latest) {
+			check.Latest, check.URL = r.TagName, r.HTMLURL
+		}
+	}
+
+	if data, err := json.MarshalIndent(check, "", "  "); err == nil {
+		os.MkdirAll(filepath.Dir(checkPath(workspace)), 0755)
+		os.WriteFile(checkPath(workspace), data, 0644)
+	}
+	return check, nil
+}
+
+func checkPath(workspace string) string {
+	return filepath.Join(workspace, "state", "update_check.json")
+}
+
+// Newer reports whether version a is newer than b, comparing them as
+// semantic versions ("v1.2.0", "1.3.0-beta.2"). A version that doesn't
+// parse, such as "dev", is never newer nor older.
+func Newer(a, b string) bool {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	if !okA || !okB {
+		return false
+	}
+	for i := range 3 {
+		if va.parts[i] != vb.parts[i] {
+			return va.parts[i] > vb.parts[i]
+		}
+	}
+	// A release is newer than its prereleases
+	switch {
+	case va.pre == vb.pre:
+		return false
+	case va.pre == "":
+		return true
+	case vb.pre == "":
+		return false
+	}
+	return comparePrerelease(va.pre, vb.pre) > 0
+}
+
+type semver struct {
+	parts [3]int
+	pre   string
+}
+
+func parseVersion(s string) (semver, bool) {
+	var v semver
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, v.pre, _ = strings.Cut(s, "-")
+	fields := strings.Split(s, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return v, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v.parts[i] = n
+	}
+	return v, true
+}
+
+// comparePrerelease compares dot-separated prerelease identifiers,
+// numerically where both are numbers ("beta.10" > "beta.9").
+func comparePrerelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		na, errA := strconv.Atoi(as[i])
+		nb, errB := strconv.Atoi(bs[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return na - nb
+			}
+		case as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return len(as) - len(bs)
+}
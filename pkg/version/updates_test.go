@@ -0,0 +1,89 @@
+package version
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"v1.2.0", "v1.1.9", true},
+		{"v1.1.9", "v1.2.0", false},
+		{"v1.2.0", "v1.2.0", false},
+		{"1.10.0", "v1.9.0", true},
+		{"v1.2.0", "v1.2.0-beta.1", true},
+		{"v1.2.0-beta.1", "v1.2.0", false},
+		{"v1.2.0-beta.10", "v1.2.0-beta.9", true},
+		{"v1.2.0-rc.1", "v1.2.0-beta.3", true},
+		{"v1.2.0", "dev", false},
+		{"dev", "v1.2.0", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.a, tt.b); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCheckForUpdate(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`[
+			{"tag_name": "v1.4.0", "html_url": "https://example.com/v1.4.0", "draft": true},
+			{"tag_name": "v1.3.0-beta.2", "html_url": "https://example.com/v1.3.0-beta.2", "prerelease": true},
+			{"tag_name": "v1.2.0", "html_url": "https://example.com/v1.2.0"},
+			{"tag_name": "v1.1.0", "html_url": "https://example.com/v1.1.0"}
+		]`))
+	}))
+	defer srv.Close()
+
+	oldURL, oldVersion := releasesURL, Version
+	releasesURL, Version = srv.URL, "v1.1.0"
+	defer func() { releasesURL, Version = oldURL, oldVersion }()
+
+	workspace := t.TempDir()
+	ctx := context.Background()
+
+	check, err := CheckForUpdate(ctx, workspace, ChannelStable, time.Hour)
+	if err != nil {
+		t.Fatalf("CheckForUpdate: %v", err)
+	}
+	if check.Latest != "v1.2.0" || !check.Available() {
+		t.Errorf("stable: got %q (available %v), want v1.2.0", check.Latest, check.Available())
+	}
+
+	// A recent check is reused
+	if _, err := CheckForUpdate(ctx, workspace, ChannelStable, time.Hour); err != nil {
+		t.Fatalf("CheckForUpdate: %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+	if last := LastCheck(workspace); last == nil || last.Latest != "v1.2.0" {
+		t.Errorf("LastCheck = %+v, want v1.2.0", last)
+	}
+
+	// Switching channel checks again
+	check, err = CheckForUpdate(ctx, workspace, ChannelBeta, time.Hour)
+	if err != nil {
+		t.Fatalf("CheckForUpdate: %v", err)
+	}
+	if check.Latest != "v1.3.0-beta.2" {
+		t.Errorf("beta: got %q, want v1.3.0-beta.2", check.Latest)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+
+	if check, _ := CheckForUpdate(ctx, workspace, ChannelOff, time.Hour); check != nil {
+		t.Errorf("off: got %+v, want nil", check)
+	}
+}
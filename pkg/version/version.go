@@ -0,0 +1,59 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package version holds the build's version, set at link time:
+//
+//	go build -ldflags "-X github.com/sipeed/picoclaw/pkg/version.Version=v1.2.0 ..."
+//
+// and checks for newer releases.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	Version   = "dev"
+	GitCommit string
+	BuildTime string
+	GoVersion string
+)
+
+// String returns the version with the git commit, e.g. "v1.2.0 (git: 3f2a9c1)".
+// Builds without ldflags fall back to the commit go build recorded.
+func String() string {
+	v := Version
+	if commit := Commit(); commit != "" {
+		v += fmt.Sprintf(" (git: %s)", commit)
+	}
+	return v
+}
+
+// Commit returns the git commit the binary was built from, if known.
+func Commit() string {
+	if GitCommit != "" {
+		return GitCommit
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" && len(s.Value) >= 8 {
+			return s.Value[:8]
+		}
+	}
+	return ""
+}
+
+// Go returns the Go version the binary was built with.
+func Go() string {
+	if GoVersion != "" {
+		return GoVersion
+	}
+	return runtime.Version()
+}
@@ -0,0 +1,157 @@
+package voice
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+// AudioConverter normalizes voice messages before transcription. Channels
+// hand over whatever the platform delivers (Telegram ogg/opus, iOS m4a,
+// WhatsApp amr, ...); with ffmpeg installed every file is re-encoded to
+// 16 kHz mono FLAC, which the transcriber accepts and which keeps uploads
+// small. Without ffmpeg files pass through unchanged.
+type AudioConverter struct {
+	ffmpeg      string
+	maxBytes    int64
+	maxDuration time.Duration
+}
+
+func NewAudioConverter(cfg config.VoiceConfig) *AudioConverter {
+	c := &AudioConverter{
+		maxBytes:    int64(cfg.MaxSizeMB) * 1024 * 1024,
+		maxDuration: time.Duration(cfg.MaxDurationSeconds) * time.Second,
+	}
+
+	name := cfg.FFmpegPath
+	if name == "" {
+		name = "ffmpeg"
+	}
+	if path, err := exec.LookPath(name); err == nil {
+		c.ffmpeg = path
+	} else {
+		logger.WarnCF("voice", "ffmpeg not found, audio will be sent without conversion", map[string]interface{}{
+			"ffmpeg_path": name,
+		})
+	}
+	return c
+}
+
+// CanConvert reports whether ffmpeg is available.
+func (c *AudioConverter) CanConvert() bool {
+	return c != nil && c.ffmpeg != ""
+}
+
+// Prepare checks the size limit and converts path when possible. It returns
+// the file to upload and a cleanup func that removes any temporary output.
+func (c *AudioConverter) Prepare(ctx context.Context, path string) (string, func(), error) {
+	noop := func() {}
+	if c == nil {
+		return path, noop, nil
+	}
+
+	if err := c.checkSize(path); err != nil {
+		return "", noop, err
+	}
+	if !c.CanConvert() {
+		return path, noop, nil
+	}
+
+	out, err := os.CreateTemp("", "picoclaw-voice-*.flac")
+	if err != nil {
+		return "", noop, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	out.Close()
+	cleanup := func() { os.Remove(out.Name()) }
+
+	args := []string{"-hide_banner", "-loglevel", "error", "-y", "-i", path, "-vn", "-ac", "1", "-ar", "16000"}
+	if c.maxDuration > 0 {
+		args = append(args, "-t", strconv.Itoa(int(c.maxDuration.Seconds())))
+	}
+	args = append(args, "-c:a", "flac", out.Name())
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.ffmpeg, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		cleanup()
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", noop, fmt.Errorf("failed to convert %s: %s", filepath.Base(path), msg)
+	}
+
+	if err := c.checkSize(out.Name()); err != nil {
+		cleanup()
+		return "", noop, err
+	}
+
+	logger.DebugCF("voice", "Converted audio for transcription", map[string]interface{}{
+		"source": filepath.Base(path),
+		"output": out.Name(),
+	})
+	return out.Name(), cleanup, nil
+}
+
+func (c *AudioConverter) checkSize(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+	if c.maxBytes > 0 && info.Size() > c.maxBytes {
+		return fmt.Errorf("audio file is %d bytes, over the %d byte limit", info.Size(), c.maxBytes)
+	}
+	return nil
+}
+
+// Split cuts path into consecutive chunks of the given length, encoded the
+// same way as Prepare, and returns them in order together with the temp
+// directory holding them, which the caller removes. Chunk i starts at
+// i*chunk. Long recordings must be split to stay under the transcription
+// upload limit.
+func (c *AudioConverter) Split(ctx context.Context, path string, chunk time.Duration) (string, []string, error) {
+	if !c.CanConvert() {
+		return "", nil, fmt.Errorf("ffmpeg is required to split audio")
+	}
+
+	dir, err := os.MkdirTemp("", "picoclaw-chunks-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+
+	args := []string{
+		"-hide_banner", "-loglevel", "error", "-y", "-i", path, "-vn", "-ac", "1", "-ar", "16000",
+		"-f", "segment", "-segment_time", strconv.Itoa(int(chunk.Seconds())),
+		"-c:a", "flac", filepath.Join(dir, "chunk%04d.flac"),
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.ffmpeg, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.RemoveAll(dir)
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", nil, fmt.Errorf("failed to split %s: %s", filepath.Base(path), msg)
+	}
+
+	chunks, err := filepath.Glob(filepath.Join(dir, "chunk*.flac"))
+	if err != nil || len(chunks) == 0 {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("no audio found in %s", filepath.Base(path))
+	}
+	sort.Strings(chunks)
+	return dir, chunks, nil
+}
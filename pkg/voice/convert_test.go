@@ -0,0 +1,55 @@
+package voice
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func writeAudio(t *testing.T, name string, size int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatalf("write audio: %v", err)
+	}
+	return path
+}
+
+func TestAudioConverter_PassThroughWithoutFFmpeg(t *testing.T) {
+	c := NewAudioConverter(config.VoiceConfig{FFmpegPath: "picoclaw-no-such-ffmpeg", MaxSizeMB: 1})
+	if c.CanConvert() {
+		t.Fatal("converter should not find a missing ffmpeg")
+	}
+
+	path := writeAudio(t, "voice.ogg", 1024)
+	got, cleanup, err := c.Prepare(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	defer cleanup()
+	if got != path {
+		t.Errorf("Prepare = %q, want the original file", got)
+	}
+}
+
+func TestAudioConverter_SizeLimit(t *testing.T) {
+	c := NewAudioConverter(config.VoiceConfig{FFmpegPath: "picoclaw-no-such-ffmpeg", MaxSizeMB: 1})
+
+	path := writeAudio(t, "voice.m4a", 2*1024*1024)
+	if _, _, err := c.Prepare(context.Background(), path); err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Errorf("Prepare error = %v, want a size limit error", err)
+	}
+}
+
+func TestAudioConverter_NilIsNoop(t *testing.T) {
+	var c *AudioConverter
+	got, cleanup, err := c.Prepare(context.Background(), "voice.ogg")
+	if err != nil || got != "voice.ogg" {
+		t.Errorf("Prepare = %q, %v", got, err)
+	}
+	cleanup()
+}
@@ -20,15 +20,28 @@ type GroqTranscriber struct {
 	apiKey     string
 	apiBase    string
 	httpClient *http.Client
+	converter  *AudioConverter
+	timeout    time.Duration
 }
 
 type TranscriptionResponse struct {
-	Text     string  `json:"text"`
-	Language string  `json:"language,omitempty"`
-	Duration float64 `json:"duration,omitempty"`
+	Text     string                 `json:"text"`
+	Language string                 `json:"language,omitempty"`
+	Duration float64                `json:"duration,omitempty"`
+	Segments []TranscriptionSegment `json:"segments,omitempty"`
 }
 
-func NewGroqTranscriber(apiKey string) *GroqTranscriber {
+// TranscriptionSegment is a timed stretch of the transcript, in seconds
+// from the start of the file.
+type TranscriptionSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// NewGroqTranscriber creates a transcriber for Groq's Whisper API. timeout
+// bounds transcribing one file; 0 is no limit.
+func NewGroqTranscriber(apiKey string, timeout time.Duration) *GroqTranscriber {
 	logger.DebugCF("voice", "Creating Groq transcriber", map[string]interface{}{"has_api_key": apiKey != ""})
 
 	apiBase := "https://api.groq.com/openai/v1"
@@ -38,11 +51,29 @@ func NewGroqTranscriber(apiKey string) *GroqTranscriber {
 		httpClient: &http.Client{
 			Timeout: 60 * time.Second,
 		},
+		timeout: timeout,
 	}
 }
 
+// SetConverter makes the transcriber normalize audio before uploading it.
+func (t *GroqTranscriber) SetConverter(converter *AudioConverter) {
+	t.converter = converter
+}
+
 func (t *GroqTranscriber) Transcribe(ctx context.Context, audioFilePath string) (*TranscriptionResponse, error) {
 	logger.InfoCF("voice", "Starting transcription", map[string]interface{}{"audio_file": audioFilePath})
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+
+	audioFilePath, cleanup, err := t.converter.Prepare(ctx, audioFilePath)
+	if err != nil {
+		logger.ErrorCF("voice", "Failed to prepare audio file", map[string]interface{}{"error": err})
+		return nil, err
+	}
+	defer cleanup()
 
 	audioFile, err := os.Open(audioFilePath)
 	if err != nil {
@@ -84,7 +115,7 @@ func (t *GroqTranscriber) Transcribe(ctx context.Context, audioFilePath string)
 		return nil, fmt.Errorf("failed to write model field: %w", err)
 	}
 
-	if err := writer.WriteField("response_format", "json"); err != nil {
+	if err := writer.WriteField("response_format", "verbose_json"); err != nil {
 		logger.ErrorCF("voice", "Failed to write response_format field", map[string]interface{}{"error": err})
 		return nil, fmt.Errorf("failed to write response_format field: %w", err)
 	}
@@ -0,0 +1,222 @@
+package voice
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipeed/picoclaw/pkg/config"
+	"github.com/sipeed/picoclaw/pkg/logger"
+)
+
+var ffmpegDurationRe = regexp.MustCompile(`Duration: (\d+):(\d{2}):(\d{2}(?:\.\d+)?)`)
+
+// VideoProcessor turns a video attachment into something a model can use:
+// a transcript of its audio track and a few evenly spaced keyframes saved
+// as JPEG files. Inputs must be local files.
+type VideoProcessor struct {
+	ffmpeg      string
+	transcriber *GroqTranscriber
+	keyframes   int
+	maxDuration time.Duration
+	timeout     time.Duration
+}
+
+// VideoResult is what could be extracted from one video. Either part may
+// be empty: a clip without sound has no transcript, and keyframes are
+// skipped when disabled.
+type VideoResult struct {
+	Duration   time.Duration
+	Transcript string
+	Frames     []string
+}
+
+// NewVideoProcessor returns nil when video handling is disabled or ffmpeg
+// cannot be found. transcriber may be nil, in which case only keyframes
+// are extracted. timeout bounds processing one video; 0 is no limit.
+func NewVideoProcessor(cfg config.VideoConfig, ffmpegPath string, transcriber *GroqTranscriber, timeout time.Duration) *VideoProcessor {
+	if !cfg.Enabled {
+		return nil
+	}
+	if ffmpegPath == "" {
+		ffmpegPath = "ffmpeg"
+	}
+	path, err := exec.LookPath(ffmpegPath)
+	if err != nil {
+		logger.WarnCF("voice", "ffmpeg not found, video attachments will not be processed", map[string]interface{}{
+			"ffmpeg_path": ffmpegPath,
+		})
+		return nil
+	}
+	return &VideoProcessor{
+		ffmpeg:      path,
+		transcriber: transcriber,
+		keyframes:   cfg.Keyframes,
+		maxDuration: time.Duration(cfg.MaxDurationSeconds) * time.Second,
+		timeout:     timeout,
+	}
+}
+
+// Process extracts the transcript from input, a local file, and the
+// keyframes too if keyframes is set. It fails only when neither could be
+// produced.
+func (p *VideoProcessor) Process(ctx context.Context, input string, keyframes bool) (*VideoResult, error) {
+	// ffmpeg would open URLs and its other protocols just as well
+	if !filepath.IsAbs(input) {
+		return nil, fmt.Errorf("not a local file: %s", input)
+	}
+	transcribe := p.transcriber != nil && p.transcriber.IsAvailable()
+	if !transcribe && !keyframes {
+		return nil, fmt.Errorf("no transcriber and keyframes not wanted")
+	}
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	result := &VideoResult{Duration: p.probeDuration(ctx, input)}
+
+	var audioErr error
+	if transcribe {
+		result.Transcript, audioErr = p.transcribe(ctx, input)
+		if audioErr != nil {
+			logger.DebugCF("voice", "No transcript for video", map[string]interface{}{
+				"input": input,
+				"error": audioErr.Error(),
+			})
+		}
+	}
+
+	var frameErr error
+	if keyframes {
+		result.Frames, frameErr = p.extractFrames(ctx, input, result.Duration)
+	}
+	if frameErr != nil {
+		logger.WarnCF("voice", "Failed to extract video keyframes", map[string]interface{}{
+			"input": input,
+			"error": frameErr.Error(),
+		})
+	}
+
+	if result.Transcript == "" && len(result.Frames) == 0 {
+		if frameErr != nil {
+			return nil, frameErr
+		}
+		if audioErr != nil {
+			return nil, audioErr
+		}
+	}
+	return result, nil
+}
+
+// probeDuration reads the container duration from ffmpeg's input banner.
+// It returns 0 when the duration is unknown.
+func (p *VideoProcessor) probeDuration(ctx context.Context, input string) time.Duration {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.ffmpeg, "-hide_banner", "-i", input)
+	cmd.Stderr = &stderr
+	_ = cmd.Run() // exits non-zero without an output file; the banner is still printed
+
+	m := ffmpegDurationRe.FindStringSubmatch(stderr.String())
+	if m == nil {
+		return 0
+	}
+	h, _ := strconv.Atoi(m[1])
+	min, _ := strconv.Atoi(m[2])
+	sec, _ := strconv.ParseFloat(m[3], 64)
+	return time.Duration(h)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec*float64(time.Second))
+}
+
+func (p *VideoProcessor) transcribe(ctx context.Context, input string) (string, error) {
+	out, err := os.CreateTemp("", "picoclaw-video-audio-*.flac")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	args := []string{"-hide_banner", "-loglevel", "error", "-y", "-i", input, "-vn", "-ac", "1", "-ar", "16000"}
+	if p.maxDuration > 0 {
+		args = append(args, "-t", strconv.Itoa(int(p.maxDuration.Seconds())))
+	}
+	args = append(args, "-c:a", "flac", out.Name())
+	if err := p.run(ctx, args); err != nil {
+		return "", fmt.Errorf("failed to extract audio: %w", err)
+	}
+
+	resp, err := p.transcriber.Transcribe(ctx, out.Name())
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// extractFrames saves p.keyframes frames spread evenly over the first
+// maxDuration of the video, taking the middle of each segment so the
+// first frame is not a black fade-in.
+func (p *VideoProcessor) extractFrames(ctx context.Context, input string, duration time.Duration) ([]string, error) {
+	if p.keyframes <= 0 {
+		return nil, nil
+	}
+
+	n := p.keyframes
+	span := duration
+	if p.maxDuration > 0 && span > p.maxDuration {
+		span = p.maxDuration
+	}
+	if span <= 0 {
+		n = 1
+	}
+
+	dir := filepath.Join(os.TempDir(), "picoclaw_media")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	prefix := uuid.New().String()[:8]
+	var frames []string
+	var lastErr error
+	for i := 0; i < n; i++ {
+		at := time.Duration(float64(span) * (float64(i) + 0.5) / float64(n))
+		path := filepath.Join(dir, fmt.Sprintf("%s_frame%d.jpg", prefix, i+1))
+		args := []string{
+			"-hide_banner", "-loglevel", "error", "-y",
+			"-ss", strconv.FormatFloat(at.Seconds(), 'f', 2, 64),
+			"-i", input,
+			"-frames:v", "1",
+			"-vf", "scale='min(768,iw)':-2",
+			"-q:v", "4",
+			path,
+		}
+		if err := p.run(ctx, args); err != nil {
+			lastErr = err
+			continue
+		}
+		frames = append(frames, path)
+	}
+
+	if len(frames) == 0 {
+		return nil, lastErr
+	}
+	return frames, nil
+}
+
+func (p *VideoProcessor) run(ctx context.Context, args []string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.ffmpeg, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return fmt.Errorf("%s", msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package voice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sipeed/picoclaw/pkg/config"
+)
+
+func TestNewVideoProcessor_Unavailable(t *testing.T) {
+	if p := NewVideoProcessor(config.VideoConfig{Enabled: false, Keyframes: 4}, "", nil, 0); p != nil {
+		t.Error("disabled video config should return nil")
+	}
+	if p := NewVideoProcessor(config.VideoConfig{Enabled: true, Keyframes: 4}, "picoclaw-no-such-ffmpeg", nil, 0); p != nil {
+		t.Error("missing ffmpeg should return nil")
+	}
+}
+
+func TestFFmpegDurationRe(t *testing.T) {
+	banner := "Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'clip.mp4':\n  Duration: 00:01:05.50, start: 0.000000, bitrate: 812 kb/s\n"
+	m := ffmpegDurationRe.FindStringSubmatch(banner)
+	if m == nil || m[1] != "00" || m[2] != "01" || m[3] != "05.50" {
+		t.Errorf("match = %v", m)
+	}
+}
+
+func TestVideoProcessor_RefusesRemoteInput(t *testing.T) {
+	p := &VideoProcessor{ffmpeg: "ffmpeg", keyframes: 4}
+	for _, input := range []string{"https://example.com/clip.mp4", "concat:a.mp4|b.mp4", "clip.mp4"} {
+		if _, err := p.Process(context.Background(), input, true); err == nil {
+			t.Errorf("Process(%q) succeeded, want an error", input)
+		}
+	}
+}
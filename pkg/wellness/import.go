@@ -0,0 +1,269 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package wellness
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	appleTimeFormat  = "2006-01-02 15:04:05 -0700"
+	garminTimeFormat = "2006-01-02 15:04:05"
+	poundsToKg       = 0.45359237
+)
+
+// ImportFile reads an Apple Health export (export.zip or export.xml) or a
+// Garmin Connect activities CSV, chosen by the file extension.
+func ImportFile(file string) ([]Record, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".zip":
+		return importAppleZip(file)
+	case ".xml":
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ImportAppleHealth(f)
+	case ".csv":
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ImportGarminCSV(f)
+	}
+	return nil, fmt.Errorf("unsupported file %s: expected an Apple Health export (.zip or .xml) or a Garmin CSV", filepath.Base(file))
+}
+
+func importAppleZip(file string) ([]Record, error) {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if path.Base(f.Name) != "export.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return ImportAppleHealth(rc)
+	}
+	return nil, fmt.Errorf("no export.xml in %s", filepath.Base(file))
+}
+
+type interval struct{ start, end time.Time }
+
+// ImportAppleHealth reads sleep, body mass and workouts from an Apple
+// Health export.xml. Sleep is the time asleep per night, with overlapping
+// samples from several devices counted once.
+func ImportAppleHealth(r io.Reader) ([]Record, error) {
+	dec := xml.NewDecoder(r)
+	var records []Record
+	weights := make(map[string]Record)
+	sleep := make(map[string][]interval)
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Apple Health export: %w", err)
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		attrs := make(map[string]string, len(el.Attr))
+		for _, a := range el.Attr {
+			attrs[a.Name.Local] = a.Value
+		}
+		start, errStart := time.Parse(appleTimeFormat, attrs["startDate"])
+		end, errEnd := time.Parse(appleTimeFormat, attrs["endDate"])
+
+		switch el.Name.Local {
+		case "Record":
+			switch attrs["type"] {
+			case "HKQuantityTypeIdentifierBodyMass":
+				value, err := strconv.ParseFloat(attrs["value"], 64)
+				if err != nil || errStart != nil {
+					continue
+				}
+				if attrs["unit"] == "lb" {
+					value *= poundsToKg
+				}
+				date := start.Format(dateFormat)
+				weights[date] = Record{Date: date, Kind: Weight, Value: round1(value), Source: SourceApple}
+			case "HKCategoryTypeIdentifierSleepAnalysis":
+				if !strings.HasPrefix(attrs["value"], "HKCategoryValueSleepAnalysisAsleep") || errStart != nil || errEnd != nil {
+					continue
+				}
+				date := end.Format(dateFormat)
+				sleep[date] = append(sleep[date], interval{start, end})
+			}
+		case "Workout":
+			minutes, err := strconv.ParseFloat(attrs["duration"], 64)
+			if err != nil {
+				if errStart != nil || errEnd != nil {
+					continue
+				}
+				minutes = end.Sub(start).Minutes()
+			} else {
+				switch attrs["durationUnit"] {
+				case "s":
+					minutes /= 60
+				case "hr", "h":
+					minutes *= 60
+				}
+			}
+			if errStart != nil {
+				continue
+			}
+			records = append(records, Record{
+				Date:   start.Format(dateFormat),
+				Kind:   Workout,
+				Value:  round1(minutes),
+				Detail: appleActivity(attrs["workoutActivityType"]),
+				Source: SourceApple,
+			})
+		}
+	}
+
+	for _, w := range weights {
+		records = append(records, w)
+	}
+	for date, spans := range sleep {
+		records = append(records, Record{Date: date, Kind: Sleep, Value: round1(asleepHours(spans)), Source: SourceApple})
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Date < records[j].Date })
+	return records, nil
+}
+
+// asleepHours is the length of the union of spans.
+func asleepHours(spans []interval) float64 {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start.Before(spans[j].start) })
+	var total time.Duration
+	var cur interval
+	for i, s := range spans {
+		switch {
+		case i == 0:
+			cur = s
+		case s.start.After(cur.end):
+			total += cur.end.Sub(cur.start)
+			cur = s
+		case s.end.After(cur.end):
+			cur.end = s.end
+		}
+	}
+	if len(spans) > 0 {
+		total += cur.end.Sub(cur.start)
+	}
+	return total.Hours()
+}
+
+// appleActivity turns "HKWorkoutActivityTypeTraditionalStrengthTraining"
+// into "traditional strength training".
+func appleActivity(t string) string {
+	name := strings.TrimPrefix(t, "HKWorkoutActivityType")
+	var sb strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			sb.WriteByte(' ')
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
+
+// ImportGarminCSV reads workouts from a Garmin Connect activities export,
+// using its "Activity Type", "Date" and "Time" (or "Elapsed Time")
+// columns.
+func ImportGarminCSV(r io.Reader) ([]Record, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Garmin CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	cols := make(map[string]int)
+	for i, name := range rows[0] {
+		cols[strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF"))] = i
+	}
+	typeCol, okType := cols["Activity Type"]
+	dateCol, okDate := cols["Date"]
+	timeCol, okTime := cols["Time"]
+	if !okTime {
+		timeCol, okTime = cols["Elapsed Time"]
+	}
+	if !okType || !okDate || !okTime {
+		return nil, fmt.Errorf("not a Garmin activities export: need Activity Type, Date and Time columns")
+	}
+
+	var records []Record
+	for _, row := range rows[1:] {
+		if len(row) <= typeCol || len(row) <= dateCol || len(row) <= timeCol {
+			continue
+		}
+		start, err := time.ParseInLocation(garminTimeFormat, strings.TrimSpace(row[dateCol]), time.Local)
+		if err != nil {
+			continue
+		}
+		minutes, err := parseClock(row[timeCol])
+		if err != nil {
+			continue
+		}
+		records = append(records, Record{
+			Date:   start.Format(dateFormat),
+			Kind:   Workout,
+			Value:  round1(minutes),
+			Detail: strings.ToLower(strings.TrimSpace(row[typeCol])),
+			Source: SourceGarmin,
+		})
+	}
+	return records, nil
+}
+
+// parseClock converts "01:02:03", "02:03" or "01:02:03.4" to minutes.
+func parseClock(s string) (float64, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var seconds float64
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		seconds = seconds*60 + v
+	}
+	return seconds / 60, nil
+}
+
+func round1(v float64) float64 {
+	return float64(int64(v*10+0.5)) / 10
+}
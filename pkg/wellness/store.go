@@ -0,0 +1,180 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package wellness keeps sleep, weight and workout records logged in chat
+// or imported from Apple Health and Garmin exports, and summarizes trends.
+package wellness
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// Record kinds and the unit of their values.
+const (
+	Sleep   = "sleep"   // hours slept, dated by the day the user woke up
+	Weight  = "weight"  // kilograms
+	Workout = "workout" // minutes; Detail holds the activity
+)
+
+// Record sources.
+const (
+	SourceManual = "manual"
+	SourceApple  = "apple_health"
+	SourceGarmin = "garmin"
+)
+
+const dateFormat = "2006-01-02"
+
+// Record is one measurement on one day.
+type Record struct {
+	Date   string  `json:"date"` // YYYY-MM-DD
+	Kind   string  `json:"kind"`
+	Value  float64 `json:"value"`
+	Detail string  `json:"detail,omitempty"`
+	Source string  `json:"source"`
+}
+
+// ValidKind reports whether kind is one of the record kinds.
+func ValidKind(kind string) bool {
+	return kind == Sleep || kind == Weight || kind == Workout
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// Store keeps each user's records in workspace/wellness/<user>.json, where
+// user is the sender ID on the chat channel.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStore creates a wellness store for a workspace.
+func NewStore(workspace string) *Store {
+	return &Store{dir: filepath.Join(workspace, "wellness")}
+}
+
+// Add stores records for user and returns how many were new. Sleep and
+// weight keep one value per day, the latest replacing the earlier one;
+// imported workouts already stored are skipped, so re-importing the same
+// export is harmless.
+func (s *Store) Add(user string, records ...Record) (int, error) {
+	if user == "" {
+		return 0, fmt.Errorf("user is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.load(user)
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for _, r := range records {
+		if !ValidKind(r.Kind) {
+			return added, fmt.Errorf("unknown kind %q", r.Kind)
+		}
+		if r.Source == "" {
+			r.Source = SourceManual
+		}
+		if i := findSame(existing, r); i >= 0 {
+			existing[i] = r
+			continue
+		}
+		existing = append(existing, r)
+		added++
+	}
+
+	sort.SliceStable(existing, func(i, j int) bool { return existing[i].Date < existing[j].Date })
+	return added, s.save(user, existing)
+}
+
+// Records returns the user's records, oldest first.
+func (s *Store) Records(user string) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(user)
+}
+
+// findSame returns the index of the stored record r replaces, or -1.
+func findSame(records []Record, r Record) int {
+	for i, e := range records {
+		if e.Date != r.Date || e.Kind != r.Kind {
+			continue
+		}
+		switch r.Kind {
+		case Sleep, Weight:
+			return i
+		case Workout:
+			if r.Source != SourceManual && e.Source == r.Source && e.Detail == r.Detail && math.Abs(e.Value-r.Value) < 0.5 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Name implements privacy.Store.
+func (s *Store) Name() string {
+	return "wellness"
+}
+
+// Purge implements privacy.Store by deleting all of the user's records.
+func (s *Store) Purge(user string, dryRun bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.load(user)
+	if err != nil || len(records) == 0 || dryRun {
+		return len(records), err
+	}
+	if err := os.Remove(s.path(user)); err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	return len(records), nil
+}
+
+func (s *Store) path(user string) string {
+	return filepath.Join(s.dir, unsafeFileChars.ReplaceAllString(user, "_")+".json")
+}
+
+func (s *Store) load(user string) ([]Record, error) {
+	raw, err := os.ReadFile(s.path(user))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(s.path(user)), err)
+	}
+	return records, nil
+}
+
+func (s *Store) save(user string, records []Record) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := s.path(user)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
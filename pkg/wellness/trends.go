@@ -0,0 +1,166 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package wellness
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Trend summarizes one kind of record over the last Days days.
+type Trend struct {
+	Kind  string
+	Days  int
+	Count int // nights, weigh-ins or workouts
+	Total float64
+	Avg   float64
+	Min   float64
+	Max   float64
+	First float64 // earliest value in the window
+	Last  float64 // latest value in the window
+	// Activities counts workouts per activity, for Workout trends.
+	Activities map[string]int
+}
+
+// window returns the records of kind dated within the last days days,
+// today included, oldest first.
+func window(records []Record, kind string, days int, now time.Time) []Record {
+	from := now.AddDate(0, 0, -(days - 1)).Format(dateFormat)
+	to := now.Format(dateFormat)
+	var out []Record
+	for _, r := range records {
+		if r.Kind == kind && r.Date >= from && r.Date <= to {
+			out = append(out, r)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Date < out[j].Date })
+	return out
+}
+
+// ComputeTrend summarizes the records of kind over the last days days.
+func ComputeTrend(records []Record, kind string, days int, now time.Time) Trend {
+	t := Trend{Kind: kind, Days: days}
+	rs := window(records, kind, days, now)
+	if kind == Workout {
+		t.Activities = make(map[string]int)
+	}
+	for i, r := range rs {
+		if i == 0 || r.Value < t.Min {
+			t.Min = r.Value
+		}
+		if i == 0 || r.Value > t.Max {
+			t.Max = r.Value
+		}
+		t.Total += r.Value
+		if kind == Workout {
+			t.Activities[activityName(r.Detail)]++
+		}
+	}
+	t.Count = len(rs)
+	if t.Count > 0 {
+		t.Avg = t.Total / float64(t.Count)
+		t.First = rs[0].Value
+		t.Last = rs[len(rs)-1].Value
+	}
+	return t
+}
+
+// Format renders the trend as one line.
+func (t Trend) Format() string {
+	period := fmt.Sprintf("last %d days", t.Days)
+	switch t.Kind {
+	case Sleep:
+		if t.Count == 0 {
+			return fmt.Sprintf("Sleep, %s: nothing logged", period)
+		}
+		return fmt.Sprintf("Sleep, %s: %.1fh on average over %d nights (shortest %.1fh, longest %.1fh)",
+			period, t.Avg, t.Count, t.Min, t.Max)
+	case Weight:
+		if t.Count == 0 {
+			return fmt.Sprintf("Weight, %s: nothing logged", period)
+		}
+		return fmt.Sprintf("Weight, %s: %.1f kg now, %+.1f kg since %.1f kg (range %.1f-%.1f kg, %d weigh-ins)",
+			period, t.Last, t.Last-t.First, t.First, t.Min, t.Max, t.Count)
+	case Workout:
+		if t.Count == 0 {
+			return fmt.Sprintf("Workouts, %s: none logged", period)
+		}
+		return fmt.Sprintf("Workouts, %s: %d sessions, %.0f min in total (%s)",
+			period, t.Count, t.Total, formatActivities(t.Activities))
+	}
+	return ""
+}
+
+// Note is a short wellness summary for briefings: last night's sleep,
+// the weight trend over 30 days and this week's workouts. It is empty
+// when nothing recent was logged.
+func Note(records []Record, now time.Time) string {
+	var parts []string
+
+	if nights := window(records, Sleep, 7, now); len(nights) > 0 {
+		week := ComputeTrend(records, Sleep, 7, now)
+		last := nights[len(nights)-1]
+		if last.Date == now.Format(dateFormat) {
+			parts = append(parts, fmt.Sprintf("Slept %.1fh last night (7-day average %.1fh).", last.Value, week.Avg))
+		} else {
+			parts = append(parts, fmt.Sprintf("Sleep averaged %.1fh over the last 7 days.", week.Avg))
+		}
+	}
+
+	if w := ComputeTrend(records, Weight, 30, now); w.Count > 0 {
+		if w.Count > 1 {
+			parts = append(parts, fmt.Sprintf("Weight %.1f kg (%+.1f kg over 30 days).", w.Last, w.Last-w.First))
+		} else {
+			parts = append(parts, fmt.Sprintf("Weight %.1f kg.", w.Last))
+		}
+	}
+
+	// This week starts on Monday
+	weekday := (int(now.Weekday()) + 6) % 7
+	if wk := ComputeTrend(records, Workout, weekday+1, now); wk.Count > 0 {
+		parts = append(parts, fmt.Sprintf("%d workout(s) this week, %.0f min in total.", wk.Count, wk.Total))
+	} else if weekday >= 3 && hasKind(records, Workout) {
+		parts = append(parts, "No workouts yet this week.")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func hasKind(records []Record, kind string) bool {
+	for _, r := range records {
+		if r.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func activityName(detail string) string {
+	if detail == "" {
+		return "other"
+	}
+	return strings.ToLower(detail)
+}
+
+func formatActivities(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s x%d", name, counts[name])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,147 @@
+package wellness
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const appleExport = `<?xml version="1.0" encoding="UTF-8"?>
+<HealthData locale="en_US">
+ <Record type="HKQuantityTypeIdentifierBodyMass" unit="kg" startDate="2026-10-12 07:30:00 +0200" endDate="2026-10-12 07:30:00 +0200" value="73.1"/>
+ <Record type="HKQuantityTypeIdentifierBodyMass" unit="lb" startDate="2026-10-13 07:30:00 +0200" endDate="2026-10-13 07:30:00 +0200" value="160"/>
+ <Record type="HKQuantityTypeIdentifierStepCount" unit="count" startDate="2026-10-13 08:00:00 +0200" endDate="2026-10-13 08:10:00 +0200" value="900"/>
+ <Record type="HKCategoryTypeIdentifierSleepAnalysis" startDate="2026-10-12 23:00:00 +0200" endDate="2026-10-13 07:00:00 +0200" value="HKCategoryValueSleepAnalysisInBed"/>
+ <Record type="HKCategoryTypeIdentifierSleepAnalysis" startDate="2026-10-12 23:30:00 +0200" endDate="2026-10-13 03:00:00 +0200" value="HKCategoryValueSleepAnalysisAsleepCore"/>
+ <Record type="HKCategoryTypeIdentifierSleepAnalysis" startDate="2026-10-13 02:30:00 +0200" endDate="2026-10-13 06:30:00 +0200" value="HKCategoryValueSleepAnalysisAsleepDeep"/>
+ <Workout workoutActivityType="HKWorkoutActivityTypeTraditionalStrengthTraining" duration="45.5" durationUnit="min" startDate="2026-10-13 18:00:00 +0200" endDate="2026-10-13 18:45:30 +0200">
+  <WorkoutStatistics type="HKQuantityTypeIdentifierActiveEnergyBurned" sum="300"/>
+ </Workout>
+</HealthData>`
+
+func TestImportAppleHealth(t *testing.T) {
+	records, err := ImportAppleHealth(strings.NewReader(appleExport))
+	if err != nil {
+		t.Fatalf("ImportAppleHealth: %v", err)
+	}
+	got := make(map[string]Record)
+	for _, r := range records {
+		got[r.Date+"/"+r.Kind] = r
+	}
+	if len(records) != 4 {
+		t.Errorf("records = %+v", records)
+	}
+	if r := got["2026-10-12/weight"]; r.Value != 73.1 {
+		t.Errorf("weight = %+v", r)
+	}
+	if r := got["2026-10-13/weight"]; r.Value != 72.6 {
+		t.Errorf("weight in lb not converted: %+v", r)
+	}
+	// 23:30-06:30 asleep, the overlap counted once and time in bed ignored
+	if r := got["2026-10-13/sleep"]; r.Value != 7 {
+		t.Errorf("sleep = %+v", r)
+	}
+	if r := got["2026-10-13/workout"]; r.Value != 45.5 || r.Detail != "traditional strength training" || r.Source != SourceApple {
+		t.Errorf("workout = %+v", r)
+	}
+}
+
+func TestImportGarminCSV(t *testing.T) {
+	csv := "\uFEFFActivity Type,Date,Favorite,Title,Distance,Calories,Time\n" +
+		"Running,2026-10-14 07:05:00,false,Morning Run,5.02,380,00:28:30\n" +
+		"Cycling,2026-10-12 17:00:00,false,Ride,20.1,500,01:05:00.5\n" +
+		"Yoga,not a date,false,Yoga,0,90,00:30:00\n"
+	records, err := ImportGarminCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ImportGarminCSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("records = %+v", records)
+	}
+	if r := records[0]; r.Date != "2026-10-14" || r.Detail != "running" || r.Value != 28.5 {
+		t.Errorf("run = %+v", r)
+	}
+	if r := records[1]; r.Value != 65 {
+		t.Errorf("ride = %+v", r)
+	}
+
+	if _, err := ImportGarminCSV(strings.NewReader("a,b\n1,2\n")); err == nil {
+		t.Error("expected an error for a CSV without Garmin columns")
+	}
+}
+
+func TestStore_Add(t *testing.T) {
+	s := NewStore(t.TempDir())
+	imported := []Record{
+		{Date: "2026-10-13", Kind: Workout, Value: 30, Detail: "running", Source: SourceGarmin},
+		{Date: "2026-10-13", Kind: Weight, Value: 73, Source: SourceGarmin},
+	}
+	if n, err := s.Add("user1", imported...); err != nil || n != 2 {
+		t.Fatalf("Add = %d, %v", n, err)
+	}
+	// Re-importing adds nothing; a manual workout of the same kind does
+	if n, _ := s.Add("user1", imported...); n != 0 {
+		t.Errorf("re-import added %d", n)
+	}
+	if n, _ := s.Add("user1", Record{Date: "2026-10-13", Kind: Workout, Value: 30, Detail: "running"}); n != 1 {
+		t.Errorf("manual workout added %d", n)
+	}
+	// A second weigh-in on the same day replaces the first
+	if n, _ := s.Add("user1", Record{Date: "2026-10-13", Kind: Weight, Value: 72.5}); n != 0 {
+		t.Errorf("second weigh-in added %d", n)
+	}
+
+	records, err := s.Records("user1")
+	if err != nil {
+		t.Fatalf("Records: %v", err)
+	}
+	if len(records) != 3 {
+		t.Errorf("records = %+v", records)
+	}
+	for _, r := range records {
+		if r.Kind == Weight && r.Value != 72.5 {
+			t.Errorf("weight = %+v", r)
+		}
+	}
+	if other, _ := s.Records("user2"); len(other) != 0 {
+		t.Errorf("other user sees %d records", len(other))
+	}
+	if _, err := s.Add("user1", Record{Date: "2026-10-13", Kind: "steps", Value: 1}); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+}
+
+func TestTrendAndNote(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.Local) // a Thursday
+	records := []Record{
+		{Date: "2026-09-20", Kind: Weight, Value: 74},
+		{Date: "2026-10-14", Kind: Weight, Value: 73.2},
+		{Date: "2026-10-13", Kind: Sleep, Value: 6},
+		{Date: "2026-10-15", Kind: Sleep, Value: 8},
+		{Date: "2026-10-12", Kind: Workout, Value: 30, Detail: "running"},
+		{Date: "2026-10-14", Kind: Workout, Value: 45, Detail: "running"},
+		{Date: "2026-10-10", Kind: Workout, Value: 60, Detail: "cycling"},
+		{Date: "2026-08-01", Kind: Workout, Value: 60, Detail: "cycling"},
+	}
+
+	w := ComputeTrend(records, Workout, 30, now)
+	if w.Count != 3 || w.Total != 135 || w.Activities["running"] != 2 {
+		t.Errorf("workout trend = %+v", w)
+	}
+	if got := w.Format(); !strings.Contains(got, "running x2, cycling x1") {
+		t.Errorf("workout format = %q", got)
+	}
+	if got := ComputeTrend(records, Weight, 30, now).Format(); !strings.Contains(got, "73.2 kg now, -0.8 kg since 74.0 kg") {
+		t.Errorf("weight format = %q", got)
+	}
+
+	note := Note(records, now)
+	for _, want := range []string{"Slept 8.0h last night (7-day average 7.0h)", "Weight 73.2 kg (-0.8 kg over 30 days)", "2 workout(s) this week, 75 min"} {
+		if !strings.Contains(note, want) {
+			t.Errorf("note missing %q: %s", want, note)
+		}
+	}
+	if note := Note(nil, now); note != "" {
+		t.Errorf("note without data = %q", note)
+	}
+}
@@ -0,0 +1,235 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// ErrNoApproval is returned by an ApproveFunc that has no way to ask the
+// user, e.g. on a channel without buttons.
+var ErrNoApproval = errors.New("approval not available here")
+
+// PromptFunc asks the LLM to answer prompt.
+type PromptFunc func(ctx context.Context, prompt string) (string, error)
+
+// ToolFunc calls a tool and returns its output.
+type ToolFunc func(ctx context.Context, name string, args map[string]interface{}) (string, error)
+
+// ApproveFunc asks the user to approve prompt.
+type ApproveFunc func(ctx context.Context, prompt string) (bool, error)
+
+// Runner runs workflows with the given step implementations.
+type Runner struct {
+	Prompt  PromptFunc
+	Tool    ToolFunc
+	Approve ApproveFunc
+}
+
+// StepResult is the outcome of one step.
+type StepResult struct {
+	ID      string
+	Kind    string
+	Output  string
+	Skipped bool
+}
+
+// Result is the outcome of a run. Stopped explains why the run ended
+// before its last step, and is empty when it finished. NeedsApproval is
+// set when it stopped at an approval step it couldn't ask about.
+type Result struct {
+	Workflow      string
+	Steps         []StepResult
+	Stopped       string
+	NeedsApproval bool
+}
+
+// Output returns the output of the last step that ran.
+func (r *Result) Output() string {
+	for i := len(r.Steps) - 1; i >= 0; i-- {
+		if !r.Steps[i].Skipped && r.Steps[i].Kind != "approval" {
+			return r.Steps[i].Output
+		}
+	}
+	return ""
+}
+
+// templateData is what step templates see.
+type templateData struct {
+	Inputs map[string]string
+	Steps  map[string]string
+}
+
+var templateFuncs = template.FuncMap{
+	"contains":  strings.Contains,
+	"hasPrefix": strings.HasPrefix,
+	"lower":     strings.ToLower,
+	"upper":     strings.ToUpper,
+	"trim":      strings.TrimSpace,
+}
+
+// Run runs wf with inputs. A failing step stops the run with an error;
+// a declined or unavailable approval stops it with Result.Stopped set.
+func (r *Runner) Run(ctx context.Context, wf *Workflow, inputs map[string]string) (*Result, error) {
+	data := templateData{Inputs: map[string]string{}, Steps: map[string]string{}}
+	for _, in := range wf.Inputs {
+		v, ok := inputs[in.Name]
+		if !ok || v == "" {
+			if in.Required && in.Default == "" {
+				return nil, fmt.Errorf("input %q is required", in.Name)
+			}
+			v = in.Default
+		}
+		data.Inputs[in.Name] = v
+	}
+	for k, v := range inputs {
+		if _, ok := data.Inputs[k]; !ok {
+			data.Inputs[k] = v
+		}
+	}
+
+	res := &Result{Workflow: wf.Name}
+	for _, step := range wf.Steps {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		sr := StepResult{ID: step.ID, Kind: step.Kind()}
+
+		if step.If != "" {
+			cond, err := render(step.If, data)
+			if err != nil {
+				return res, fmt.Errorf("step %q: if: %w", step.ID, err)
+			}
+			if !truthy(cond) {
+				sr.Skipped = true
+				res.Steps = append(res.Steps, sr)
+				continue
+			}
+		}
+
+		out, stop, err := r.runStep(ctx, step, data)
+		if err != nil {
+			return res, fmt.Errorf("step %q: %w", step.ID, err)
+		}
+		sr.Output = out
+		res.Steps = append(res.Steps, sr)
+		if stop != "" {
+			res.Stopped = stop
+			res.NeedsApproval = sr.Kind == "approval" && out == ""
+			return res, nil
+		}
+		data.Steps[step.ID] = out
+	}
+	return res, nil
+}
+
+// runStep runs one step, returning its output, or why the run has to
+// stop there.
+func (r *Runner) runStep(ctx context.Context, step Step, data templateData) (string, string, error) {
+	switch step.Kind() {
+	case "tool":
+		if r.Tool == nil {
+			return "", "", fmt.Errorf("tool steps are not available")
+		}
+		args, err := renderArgs(step.Args, data)
+		if err != nil {
+			return "", "", err
+		}
+		out, err := r.Tool(ctx, step.Tool, args)
+		return out, "", err
+
+	case "approval":
+		prompt, err := render(step.Approval, data)
+		if err != nil {
+			return "", "", err
+		}
+		if r.Approve == nil {
+			return "", fmt.Sprintf("step %q needs approval: %s", step.ID, prompt), nil
+		}
+		approved, err := r.Approve(ctx, prompt)
+		switch {
+		case errors.Is(err, ErrNoApproval):
+			return "", fmt.Sprintf("step %q needs approval: %s", step.ID, prompt), nil
+		case err != nil:
+			return "", "", err
+		case !approved:
+			return "declined", fmt.Sprintf("the user declined step %q", step.ID), nil
+		}
+		return "approved", "", nil
+
+	default:
+		if r.Prompt == nil {
+			return "", "", fmt.Errorf("prompt steps are not available")
+		}
+		prompt, err := render(step.Prompt, data)
+		if err != nil {
+			return "", "", err
+		}
+		out, err := r.Prompt(ctx, prompt)
+		return out, "", err
+	}
+}
+
+func render(text string, data templateData) (string, error) {
+	tmpl, err := template.New("").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// renderArgs renders every string in args, including inside lists and
+// maps.
+func renderArgs(args map[string]interface{}, data templateData) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		rv, err := renderValue(v, data)
+		if err != nil {
+			return nil, fmt.Errorf("args.%s: %w", k, err)
+		}
+		out[k] = rv
+	}
+	return out, nil
+}
+
+func renderValue(v interface{}, data templateData) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return render(v, data)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			rv, err := renderValue(item, data)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rv
+		}
+		return out, nil
+	case map[string]interface{}:
+		return renderArgs(v, data)
+	default:
+		return v, nil
+	}
+}
+
+// truthy reports whether a rendered condition holds: anything but empty,
+// "false", "no" or "0".
+func truthy(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "false", "no", "0":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+// PicoClaw - Ultra-lightweight personal AI agent
+// License: MIT
+//
+// Copyright (c) 2026 PicoClaw contributors
+
+// Package workflow loads and runs multi-step pipelines defined as YAML
+// files in workspace/workflows.
+//
+// A workflow is a list of steps run in order. Each step is a prompt for
+// the LLM, a tool call or an approval the user has to give before the
+// workflow goes on. Steps can be skipped with an "if" condition, and
+// prompts, tool arguments and conditions are Go templates that can use
+// the workflow's inputs and the output of earlier steps:
+//
+//	name: morning
+//	inputs:
+//	  - name: city
+//	    default: Berlin
+//	steps:
+//	  - id: weather
+//	    tool: web_search
+//	    args: {query: "weather today in {{.Inputs.city}}"}
+//	  - id: brief
+//	    prompt: "Write a two-line morning brief from: {{.Steps.weather}}"
+//	  - id: confirm
+//	    if: '{{contains .Steps.brief "rain"}}'
+//	    approval: "Rain is expected. Post the brief to the family chat?"
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Workflow is one pipeline definition.
+type Workflow struct {
+	Name        string  `yaml:"name"`
+	Description string  `yaml:"description"`
+	Inputs      []Input `yaml:"inputs"`
+	Steps       []Step  `yaml:"steps"`
+}
+
+// Input is a named value the caller passes in, available to steps as
+// {{.Inputs.<name>}}.
+type Input struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Default     string `yaml:"default"`
+	Required    bool   `yaml:"required"`
+}
+
+// Step is one step of a workflow. Exactly one of Prompt, Tool and
+// Approval is set. The output of a step is available to later steps as
+// {{.Steps.<id>}}.
+type Step struct {
+	ID       string                 `yaml:"id"`
+	If       string                 `yaml:"if"`       // skip the step unless this renders truthy
+	Prompt   string                 `yaml:"prompt"`   // ask the LLM
+	Tool     string                 `yaml:"tool"`     // call a tool with Args
+	Args     map[string]interface{} `yaml:"args"`     // tool arguments; strings are templates
+	Approval string                 `yaml:"approval"` // ask the user to approve before going on
+}
+
+// Kind returns "prompt", "tool" or "approval".
+func (s Step) Kind() string {
+	switch {
+	case s.Tool != "":
+		return "tool"
+	case s.Approval != "":
+		return "approval"
+	default:
+		return "prompt"
+	}
+}
+
+// Dir returns the workflows directory of a workspace.
+func Dir(workspace string) string {
+	return filepath.Join(workspace, "workflows")
+}
+
+// Load reads every workflow in dir, sorted by name. A missing directory
+// has no workflows.
+func Load(dir string) ([]*Workflow, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var workflows []*Workflow
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		wf, err := LoadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, wf)
+	}
+	sort.Slice(workflows, func(i, j int) bool { return workflows[i].Name < workflows[j].Name })
+	return workflows, nil
+}
+
+// LoadFile reads and validates one workflow file. The name defaults to
+// the file name without its extension.
+func LoadFile(path string) (*Workflow, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var wf Workflow
+	if err := yaml.Unmarshal(raw, &wf); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if wf.Name == "" {
+		wf.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := wf.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return &wf, nil
+}
+
+// Find returns the workflow called name in dir.
+func Find(dir, name string) (*Workflow, error) {
+	workflows, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, wf := range workflows {
+		if strings.EqualFold(wf.Name, name) {
+			return wf, nil
+		}
+	}
+	return nil, fmt.Errorf("no workflow named %q", name)
+}
+
+// Validate checks that every step has an ID and exactly one action, and
+// that IDs are unique.
+func (wf *Workflow) Validate() error {
+	if len(wf.Steps) == 0 {
+		return fmt.Errorf("workflow %q has no steps", wf.Name)
+	}
+	seen := make(map[string]bool, len(wf.Steps))
+	for i := range wf.Steps {
+		s := &wf.Steps[i]
+		if s.ID == "" {
+			s.ID = fmt.Sprintf("step%d", i+1)
+		}
+		if seen[s.ID] {
+			return fmt.Errorf("duplicate step id %q", s.ID)
+		}
+		seen[s.ID] = true
+
+		actions := 0
+		for _, set := range []bool{s.Prompt != "", s.Tool != "", s.Approval != ""} {
+			if set {
+				actions++
+			}
+		}
+		if actions != 1 {
+			return fmt.Errorf("step %q must have exactly one of prompt, tool or approval", s.ID)
+		}
+	}
+	for _, in := range wf.Inputs {
+		if in.Name == "" {
+			return fmt.Errorf("workflow %q has an input without a name", wf.Name)
+		}
+	}
+	return nil
+}
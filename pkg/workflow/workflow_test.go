@@ -0,0 +1,144 @@
+package workflow
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const morningYAML = `
+description: Morning brief
+inputs:
+  - name: city
+    default: Berlin
+steps:
+  - id: weather
+    tool: web_search
+    args:
+      query: "weather today in {{.Inputs.city}}"
+      count: 3
+  - id: brief
+    prompt: "Brief from: {{.Steps.weather}}"
+  - id: umbrella
+    if: '{{contains (lower .Steps.brief) "rain"}}'
+    approval: "Rain in {{.Inputs.city}}. Send the umbrella reminder?"
+  - id: remind
+    if: '{{contains (lower .Steps.brief) "rain"}}'
+    tool: message
+    args:
+      content: "Take an umbrella"
+`
+
+func writeWorkflow(t *testing.T, dir, file, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeWorkflow(t, dir, "morning.yaml", morningYAML)
+	writeWorkflow(t, dir, "notes.txt", "not a workflow")
+
+	workflows, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(workflows) != 1 || workflows[0].Name != "morning" || len(workflows[0].Steps) != 4 {
+		t.Fatalf("Load = %+v", workflows)
+	}
+	if _, err := Find(dir, "MORNING"); err != nil {
+		t.Errorf("Find is case-sensitive: %v", err)
+	}
+
+	if got, err := Load(filepath.Join(dir, "missing")); err != nil || got != nil {
+		t.Errorf("Load(missing) = %v, %v; want no workflows", got, err)
+	}
+
+	writeWorkflow(t, dir, "bad.yml", "steps:\n  - id: x\n    prompt: hi\n    tool: exec\n")
+	if _, err := Load(dir); err == nil || !strings.Contains(err.Error(), "exactly one") {
+		t.Errorf("step with two actions: err = %v", err)
+	}
+}
+
+func TestRunner(t *testing.T) {
+	dir := t.TempDir()
+	writeWorkflow(t, dir, "morning.yaml", morningYAML)
+	wf, err := Find(dir, "morning")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []string
+	var approvals []string
+	runner := &Runner{
+		Prompt: func(ctx context.Context, prompt string) (string, error) {
+			if prompt != "Brief from: Rain in Oslo" {
+				t.Errorf("prompt = %q", prompt)
+			}
+			return "Rain all day", nil
+		},
+		Tool: func(ctx context.Context, name string, args map[string]interface{}) (string, error) {
+			calls = append(calls, name)
+			if name == "web_search" && (args["query"] != "weather today in Oslo" || args["count"] != 3) {
+				t.Errorf("web_search args = %v", args)
+			}
+			return "Rain in Oslo", nil
+		},
+		Approve: func(ctx context.Context, prompt string) (bool, error) {
+			approvals = append(approvals, prompt)
+			return true, nil
+		},
+	}
+
+	res, err := runner.Run(context.Background(), wf, map[string]string{"city": "Oslo"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Stopped != "" || len(res.Steps) != 4 || res.Output() != "Rain in Oslo" {
+		t.Errorf("result = %+v", res)
+	}
+	if strings.Join(calls, ",") != "web_search,message" {
+		t.Errorf("tool calls = %v", calls)
+	}
+	if len(approvals) != 1 || approvals[0] != "Rain in Oslo. Send the umbrella reminder?" {
+		t.Errorf("approvals = %v", approvals)
+	}
+
+	// Without a way to ask, the run stops at the approval step
+	runner.Approve = func(ctx context.Context, prompt string) (bool, error) { return false, ErrNoApproval }
+	calls = nil
+	res, err = runner.Run(context.Background(), wf, map[string]string{"city": "Oslo"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !res.NeedsApproval || len(calls) != 1 {
+		t.Errorf("unapproved run = %+v, calls %v", res, calls)
+	}
+}
+
+func TestRunner_SkipsFalseConditions(t *testing.T) {
+	wf := &Workflow{Name: "t", Steps: []Step{
+		{ID: "a", Prompt: "first"},
+		{ID: "b", If: `{{contains .Steps.a "yes"}}`, Prompt: "second"},
+	}}
+	if err := wf.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	runner := &Runner{Prompt: func(ctx context.Context, prompt string) (string, error) { return "no", nil }}
+	res, err := runner.Run(context.Background(), wf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Steps[1].Skipped || res.Output() != "no" {
+		t.Errorf("result = %+v", res)
+	}
+
+	wf.Inputs = []Input{{Name: "topic", Required: true}}
+	if _, err := runner.Run(context.Background(), wf, nil); err == nil {
+		t.Error("missing required input accepted")
+	}
+}